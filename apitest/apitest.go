@@ -0,0 +1,238 @@
+// Package apitest runs the whole todo API (every route behind the full middleware stack) on an
+// httptest server over a fresh in-memory store, for tests of endpoints:
+//
+//	func TestCompleteTodo(t *testing.T) {
+//		s := apitest.New(t)
+//		milk := s.Seed(apitest.NewSeed().Todo("buy milk"))[0]
+//
+//		var todo server.Todo
+//		s.Put("/todos/update?id="+strconv.Itoa(milk.ID), nil).ExpectStatus(http.StatusOK).Decode(&todo)
+//		s.Get("/todos/stats").ExpectJSON(`{"total":1,"open":0,"done":1,"with_due":0}`)
+//	}
+//
+// The API serves one store per process, so tests using apitest can't call t.Parallel.
+package apitest
+
+import (
+	"bytes"             // for request bodies
+	"encoding/json"     // for JSON bodies and comparisons
+	"io"                // for reading responses
+	"net/http"          // for requests
+	"net/http/httptest" // for the test server
+	"reflect"           // for comparing JSON values
+	"testing"           // for failing the test
+	"time"              // for the frozen clock
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server" // for the API and its store
+)
+
+// Start is where the clock of every test server starts: a fixed time, so tests can write
+// down due dates and timestamps they expect
+var Start = time.Date(2030, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// Server is the API under test
+type Server struct {
+	t      testing.TB
+	URL    string              // base URL of the test server
+	Store  *server.Store       // the store behind it, for setting up and checking state directly
+	Clock  *server.ManualClock // the store's clock, standing at Start until moved
+	Client *http.Client        // client sending the requests
+}
+
+// Response is an answer already read in full
+type Response struct {
+	t      testing.TB
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Option configures New
+type Option func(*options)
+
+// options is what the options set
+type options struct {
+	args  []string
+	store []server.StoreOption
+}
+
+
+// WithArgs passes todo-server command line flags to the API, e.g. WithArgs("-max-todos", "10")
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, args...)
+	}
+}
+
+
+// WithStoreOptions adds options for the store, e.g. server.WithIDGenerator; the clock is
+// always the Server's (replacing it with server.WithClock leaves Clock unused)
+func WithStoreOptions(opts ...server.StoreOption) Option {
+	return func(o *options) {
+		o.store = append(o.store, opts...)
+	}
+}
+
+
+// New starts the API on a fresh store and stops it when the test ends
+func New(t testing.TB, opts ...Option) *Server {
+
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	clock := server.NewManualClock(Start)
+	store := server.NewStore(append([]server.StoreOption{server.WithClock(clock)}, o.store...)...)
+
+	// the API is given back when the test ends, for the next test's
+	handler, err := server.New(store, server.WithArgs(o.args...))
+	if err != nil {
+		t.Fatal("apitest:", err)
+	}
+	t.Cleanup(func() { server.Release(handler) })
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &Server{t: t, URL: srv.URL, Store: store, Clock: clock, Client: srv.Client()}
+}
+
+
+// Do sends a request to path with body (nil for none, []byte or string as it is, anything
+// else as JSON) and reads the response; a transport error fails the test
+func (s *Server) Do(method, path string, body any) *Response {
+
+	s.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatal("apitest: encoding request body:", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		s.t.Fatal("apitest:", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.Send(req)
+}
+
+
+// Send sends a request built by the test (its own headers, formats, ...) and reads the response
+func (s *Server) Send(req *http.Request) *Response {
+
+	s.t.Helper()
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		s.t.Fatal("apitest:", req.Method, req.URL.Path+":", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatal("apitest: reading response:", err)
+	}
+	return &Response{t: s.t, Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+
+// Get sends a GET request
+func (s *Server) Get(path string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodGet, path, nil)
+}
+
+
+// Post sends a POST request with body
+func (s *Server) Post(path string, body any) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodPost, path, body)
+}
+
+
+// Put sends a PUT request with body
+func (s *Server) Put(path string, body any) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodPut, path, body)
+}
+
+
+// Delete sends a DELETE request
+func (s *Server) Delete(path string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodDelete, path, nil)
+}
+
+
+// ExpectStatus fails the test unless the response has status code
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.Helper()
+	if r.Status != code {
+		r.t.Fatalf("apitest: status %d, want %d; body: %s", r.Status, code, r.Body)
+	}
+	return r
+}
+
+
+// ExpectHeader fails the test unless the response header name is value
+func (r *Response) ExpectHeader(name, value string) *Response {
+	r.t.Helper()
+	if got := r.Header.Get(name); got != value {
+		r.t.Fatalf("apitest: %s: %q, want %q", name, got, value)
+	}
+	return r
+}
+
+
+// Decode unmarshals the JSON body into v, failing the test if it isn't JSON that fits
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("apitest: decoding %s: %v", r.Body, err)
+	}
+	return r
+}
+
+
+// ExpectJSON fails the test unless the body is the same JSON as want (a JSON string or []byte,
+// or a value to encode). key order and spacing don't matter, numbers are compared as numbers
+func (r *Response) ExpectJSON(want any) *Response {
+
+	r.t.Helper()
+	var wantJSON []byte
+	switch w := want.(type) {
+	case string:
+		wantJSON = []byte(w)
+	case []byte:
+		wantJSON = w
+	default:
+		var err error
+		if wantJSON, err = json.Marshal(w); err != nil {
+			r.t.Fatal("apitest: encoding expected JSON:", err)
+		}
+	}
+
+	var got, expected any
+	if err := json.Unmarshal(r.Body, &got); err != nil {
+		r.t.Fatalf("apitest: body is not JSON (%v): %s", err, r.Body)
+	}
+	if err := json.Unmarshal(wantJSON, &expected); err != nil {
+		r.t.Fatalf("apitest: expected value is not JSON (%v): %s", err, wantJSON)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		r.t.Fatalf("apitest: body\n\t%s\nwant\n\t%s", bytes.TrimSpace(r.Body), wantJSON)
+	}
+	return r
+}
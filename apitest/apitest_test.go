@@ -0,0 +1,151 @@
+package apitest_test
+
+import (
+	"fmt"      // for failure messages
+	"net/http" // for status codes and requests
+	"strconv"  // for todo paths
+	"strings"  // for matching failures
+	"testing"  // for the tests
+	"time"     // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the helpers under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server"  // for todos
+)
+
+// apitest's own tests: seeds, the request helpers, and that every Expect fails the test it's
+// given when the response isn't what it expects (through a recording testing.TB)
+
+// fatal is what recordingT panics with to stop the check that failed, like t.Fatal stops a test
+type fatal struct{}
+
+// recordingT is a test that notes the first failure instead of failing the real one
+type recordingT struct {
+	testing.TB
+	failure string
+}
+
+
+// Fatal records the failure and stops the caller
+func (r *recordingT) Fatal(args ...any) {
+	r.failure = fmt.Sprint(args...)
+	panic(fatal{})
+}
+
+
+// Fatalf records the failure and stops the caller
+func (r *recordingT) Fatalf(format string, args ...any) {
+	r.Fatal(fmt.Sprintf(format, args...))
+}
+
+
+// failureOf runs check against a server failing into a recordingT, returning the failure ("" for none)
+func failureOf(t *testing.T, check func(s *apitest.Server)) (failure string) {
+
+	rec := &recordingT{TB: t}
+	s := apitest.New(rec)
+	s.Seed(apitest.NewSeed().Todo("milk"))
+	defer func() {
+		if v := recover(); v != nil && v != (fatal{}) {
+			panic(v)
+		}
+		failure = rec.failure
+	}()
+	check(s)
+	return ""
+}
+
+
+// TestExpectations checks each expectation passes a response that meets it and fails one that doesn't
+func TestExpectations(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		check func(s *apitest.Server)
+		fails string // part of the failure, "" when the check passes
+	}{
+		{"status", func(s *apitest.Server) { s.Get("/todos/get?id=1").ExpectStatus(http.StatusOK) }, ""},
+		{"wrong status", func(s *apitest.Server) { s.Get("/todos/get?id=9").ExpectStatus(http.StatusOK) }, "status 404, want 200"},
+		{"header", func(s *apitest.Server) {
+			s.Get("/todos/get?id=1").ExpectHeader("Content-Type", "application/json")
+		}, ""},
+		{"wrong header", func(s *apitest.Server) {
+			s.Get("/todos/get?id=1").ExpectHeader("Content-Type", "text/plain")
+		}, `Content-Type: "application/json", want "text/plain"`},
+		{"JSON in any key order and spacing", func(s *apitest.Server) {
+			s.Get("/todos/get?id=1").ExpectJSON(`{ "rev": 1, "done": false, "title": "milk", "id": 1.0 }`)
+		}, ""},
+		{"JSON from a value", func(s *apitest.Server) {
+			s.Get("/todos/get?id=1").ExpectJSON(server.Todo{ID: 1, Title: "milk", Rev: 1})
+		}, ""},
+		{"different JSON", func(s *apitest.Server) {
+			s.Get("/todos/get?id=1").ExpectJSON(`{"id":1,"title":"bread","done":false,"rev":1}`)
+		}, "want"},
+		{"expected value not JSON", func(s *apitest.Server) { s.Get("/todos/get?id=1").ExpectJSON(`{`) }, "expected value is not JSON"},
+		{"body not JSON", func(s *apitest.Server) { s.Get("/todos/export.md").ExpectJSON(`{}`) }, "body is not JSON"},
+		{"decode", func(s *apitest.Server) {
+			var todo server.Todo
+			s.Get("/todos/get?id=1").Decode(&todo)
+			if todo.Title != "milk" {
+				s.Get("/todos/get?id=9").ExpectStatus(http.StatusOK)
+			}
+		}, ""},
+		{"decode into what doesn't fit", func(s *apitest.Server) {
+			var titles []string
+			s.Get("/todos/get?id=1").Decode(&titles)
+		}, "decoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := failureOf(t, tt.check)
+			switch {
+			case tt.fails == "" && got != "":
+				t.Errorf("failed: %s", got)
+			case tt.fails != "" && !strings.Contains(got, tt.fails):
+				t.Errorf("failure %q, want one saying %q", got, tt.fails)
+			}
+		})
+	}
+}
+
+
+// TestRequests checks Post, Put and Delete send their bodies as JSON and Seed creates todos in order
+func TestRequests(t *testing.T) {
+
+	s := apitest.New(t)
+	due := apitest.Start.Add(48 * time.Hour)
+	seeded := s.Seed(apitest.NewSeed().Todo("milk").Done("bread").Due("rent", due).Many(2, "bulk"))
+
+	var titles []string
+	for _, todo := range seeded {
+		titles = append(titles, todo.Title+"#"+strconv.Itoa(todo.ID))
+	}
+	if got := strings.Join(titles, " "); got != "milk#1 bread#2 rent#3 bulk 1#4 bulk 2#5" {
+		t.Fatalf("seeded %s", got)
+	}
+	if !seeded[1].Done || seeded[2].Due == nil || !seeded[2].Due.Equal(due) {
+		t.Errorf("seeded %+v", seeded[1:3])
+	}
+
+	s.Post("/todos/create", map[string]string{"title": "soap"}).ExpectStatus(http.StatusOK).ExpectJSON(`{"id":6,"title":"soap","done":false,"rev":1}`)
+	s.Put("/todos/update?id=6", `{"title":"hand soap"}`).ExpectStatus(http.StatusOK)
+	s.Delete("/todos/delete?id=1").ExpectStatus(http.StatusNoContent)
+	s.Get("/todos/stats").ExpectJSON(`{"total":5,"open":4,"done":1,"with_due":1}`)
+	if got := s.Clock.Now(); !got.Equal(apitest.Start) {
+		t.Errorf("clock at %v, want %v", got, apitest.Start)
+	}
+}
+
+
+// TestFreshStore checks each test's server starts on an empty store, whatever the one before it did
+func TestFreshStore(t *testing.T) {
+
+	t.Run("first", func(t *testing.T) {
+		s := apitest.New(t)
+		s.Seed(apitest.NewSeed().Many(3, "old"))
+	})
+	t.Run("second", func(t *testing.T) {
+		s := apitest.New(t)
+		s.Get("/todos").ExpectStatus(http.StatusOK).ExpectJSON(`{}`)
+		s.Post("/todos/create", map[string]string{"title": "new"}).ExpectJSON(`{"id":1,"title":"new","done":false,"rev":1}`)
+	})
+}
@@ -0,0 +1,78 @@
+package apitest
+
+import (
+	"context" // for store calls outside a request
+	"strconv" // for numbered titles
+	"time"    // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server" // for todos
+)
+
+// SeedData is a list of todos to put in the store before a test, built up with chained calls:
+//
+//	apitest.NewSeed().Todo("buy milk").Done("file taxes").Due("pay rent", apitest.Start.Add(48*time.Hour)).Many(100, "bulk")
+type SeedData struct {
+	todos []server.Todo
+}
+
+
+// NewSeed starts an empty seed
+func NewSeed() *SeedData {
+	return &SeedData{}
+}
+
+
+// Todo adds an open todo
+func (d *SeedData) Todo(title string) *SeedData {
+	d.todos = append(d.todos, server.Todo{Title: title})
+	return d
+}
+
+
+// Done adds a completed todo
+func (d *SeedData) Done(title string) *SeedData {
+	d.todos = append(d.todos, server.Todo{Title: title, Done: true})
+	return d
+}
+
+
+// Due adds an open todo due at due
+func (d *SeedData) Due(title string, due time.Time) *SeedData {
+	d.todos = append(d.todos, server.Todo{Title: title, Due: &due})
+	return d
+}
+
+
+// Many adds n open todos titled "title 1" to "title n"
+func (d *SeedData) Many(n int, title string) *SeedData {
+	for i := range n {
+		d.todos = append(d.todos, server.Todo{Title: title + " " + strconv.Itoa(i+1)})
+	}
+	return d
+}
+
+
+// Add adds todos as they are (their ids are ignored, the store assigns them)
+func (d *SeedData) Add(todos ...server.Todo) *SeedData {
+	d.todos = append(d.todos, todos...)
+	return d
+}
+
+
+// Seed creates the seed's todos in the store, in order, and returns them with their ids.
+// they're created through the store, so they're in the change log like any other todos;
+// a todo the store rejects fails the test
+func (s *Server) Seed(d *SeedData) []server.Todo {
+
+	s.t.Helper()
+	ctx := context.Background()
+	created := make([]server.Todo, 0, len(d.todos))
+	for _, draft := range d.todos {
+		todo, err := s.Store.Create(ctx, draft)
+		if err != nil {
+			s.t.Fatalf("apitest: seeding %q: %v", draft.Title, err)
+		}
+		created = append(created, todo)
+	}
+	return created
+}
@@ -0,0 +1,344 @@
+// Package client calls the todo API over HTTP, so Go programs don't hand-roll requests:
+//
+//	c := client.New("http://localhost:8080", client.WithTimeZone("Europe/Berlin"))
+//	todo, err := c.Create(ctx, client.NewTodo{Title: "call bank", Due: "tomorrow 5pm"})
+//	...
+//	for todo, err := range c.Todos(ctx, client.ListOptions{Done: client.Bool(false)}) {
+//		...
+//	}
+//
+// Every method takes a context, errors from the server are an *APIError (check them with
+// errors.Is against ErrNotFound, ErrConflict, ...), and requests that are safe to repeat are
+// retried on network errors, 429 and 502-504.
+package client
+
+import (
+	"bytes"         // for request bodies
+	"context"       // for cancellation
+	"encoding/json" // for JSON bodies
+	"fmt"           // for error messages
+	"io"            // for response bodies
+	"net/http"      // for talking to the server
+	"net/url"       // for query strings
+	"strconv"       // for Retry-After and ids
+	"strings"       // for the base URL
+	"time"          // for timeouts and backoff
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos, events and errors
+)
+
+// Todo and Event are the API's types, the same the server package uses
+type (
+	Todo       = model.Todo
+	Event      = model.Event
+	FieldError = model.FieldError
+	Trashed    = model.Trashed
+)
+
+// errors an *APIError matches with errors.Is, by status code
+var (
+	ErrNotFound    = model.ErrNotFound    // 404
+	ErrConflict    = model.ErrConflict    // 409
+	ErrValidation  = model.ErrValidation  // 400
+	ErrRejected    = model.ErrRejected    // 422, a store hook vetoed it
+	ErrUnavailable = model.ErrUnavailable // 503, the server can't take changes for now
+)
+
+// Client calls one server; it is safe for concurrent use
+type Client struct {
+	base     string
+	http     *http.Client
+	token    string
+	actor    string
+	actorKey string
+	zone     string
+	language string
+	retries  int
+	backoff  time.Duration
+}
+
+// Option configures New
+type Option func(*Client)
+
+// APIError is an error answer from the server
+type APIError struct {
+	StatusCode int          `json:"-"` // 0 for the failed changes of a sync
+	Message    string       `json:"error"`
+	Fields     []FieldError `json:"fields,omitempty"` // validation errors: every bad field
+	Server     *Todo        `json:"server,omitempty"` // merge conflicts: the todo as it is
+	Client     *Todo        `json:"client,omitempty"` // and as the update would have made it
+
+	body []byte // the answer as sent
+}
+
+// how long a Client waits for an answer unless WithHTTPClient says otherwise
+const defaultTimeout = 30 * time.Second
+
+
+// New returns a client for the server at base, e.g. "http://localhost:8080" (a path prefix is
+// fine, for an API mounted under one)
+func New(base string, opts ...Option) *Client {
+
+	c := &Client{
+		base:    strings.TrimRight(base, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+		retries: 3,
+		backoff: 200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+
+// WithHTTPClient sends the requests with hc, for its transport, timeout or TLS config
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+
+// WithToken sends token as a bearer token, for servers behind an authenticating proxy
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+
+// WithActor sends name as the Todo-Actor the server records changes by, and token (when not "")
+// as the Todo-Actor-Token the /me methods need, which the server's admin hands out
+func WithActor(name, token string) Option {
+	return func(c *Client) {
+		c.actor, c.actorKey = name, token
+	}
+}
+
+
+// WithTimeZone sends an IANA zone ("Europe/Berlin") as the Time-Zone header: due dates in
+// words and ?due=today are read in it
+func WithTimeZone(name string) Option {
+	return func(c *Client) {
+		c.zone = name
+	}
+}
+
+
+// WithLanguage asks for error messages in lang ("de"), sent as Accept-Language
+func WithLanguage(lang string) Option {
+	return func(c *Client) {
+		c.language = lang
+	}
+}
+
+
+// WithRetries sets how often a failed request that is safe to repeat is tried again (3 by
+// default, 0 turns retries off) and the wait before the first retry, doubled for each one after
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries, c.backoff = max(n, 0), backoff
+	}
+}
+
+
+// Error is the server's message, localized when WithLanguage asked for it
+func (e *APIError) Error() string {
+	return fmt.Sprintf("todo api: %d %s", e.StatusCode, e.Message)
+}
+
+
+// Is makes errors.Is(err, ErrNotFound) and friends work by status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrRejected:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+
+// Bool returns a pointer to b, for the optional fields of ListOptions and Patch
+func Bool(b bool) *bool {
+	return &b
+}
+
+
+// String returns a pointer to s, for the optional fields of Patch
+func String(s string) *string {
+	return &s
+}
+
+// request is one call: the body is encoded up front so it can be sent again on a retry
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	stream      io.Reader // sent instead of body, never retried
+}
+
+
+// jsonRequest builds a request with v as its JSON body (none when v is nil)
+func jsonRequest(method, path string, v any) request {
+
+	req := request{method: method, path: path}
+	if v != nil {
+		req.body, _ = json.Marshal(v)
+		req.contentType = "application/json"
+	}
+	return req
+}
+
+
+// call sends req and decodes a JSON answer into out (when not nil)
+func (c *Client) call(ctx context.Context, req request, out any) error {
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("todo api: %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+
+// send sends req, retrying when it is safe to, and returns a 2xx response for the caller to
+// read and close; any other answer is an *APIError
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+
+	// a repeated GET, PUT or DELETE does no more than the first one did; a POST might
+	attempts := 1
+	if req.stream == nil && req.method != http.MethodPost {
+		attempts += c.retries
+	}
+
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.sendOnce(ctx, req)
+		retry := attempt < attempts && ctx.Err() == nil
+		switch {
+		case err != nil && !retry:
+			return nil, err
+		case err == nil && resp.StatusCode < 300:
+			return resp, nil
+		case err == nil && (!retry || !retryableStatus(resp.StatusCode)):
+			return nil, readAPIError(resp)
+		}
+
+		// the server may say how long to back off
+		delay := wait
+		if err == nil {
+			if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs >= 0 {
+				delay = time.Duration(secs) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		wait *= 2
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+
+// sendOnce sends req once
+func (c *Client) sendOnce(ctx context.Context, req request) (*http.Response, error) {
+
+	target := c.base + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	body := req.stream
+	if body == nil {
+		body = bytes.NewReader(req.body)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Accept", "application/json")
+	if req.contentType != "" {
+		hreq.Header.Set("Content-Type", req.contentType)
+	}
+	if c.token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.actor != "" {
+		hreq.Header.Set("Todo-Actor", c.actor)
+	}
+	if c.actorKey != "" {
+		hreq.Header.Set("Todo-Actor-Token", c.actorKey)
+	}
+	if c.zone != "" {
+		hreq.Header.Set("Time-Zone", c.zone)
+	}
+	if c.language != "" {
+		hreq.Header.Set("Accept-Language", c.language)
+	}
+	return c.http.Do(hreq)
+}
+
+
+// retryableStatus is an answer worth asking again for: rate limited or a proxy in trouble
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+
+// readAPIError reads an error answer, which not every endpoint (or proxy) sends as JSON
+func readAPIError(resp *http.Response) error {
+
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, body: data}
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return apiErr
+}
+
+
+// idQuery is the ?id= of the single-todo endpoints
+func idQuery(id int) url.Values {
+	return url.Values{"id": {strconv.Itoa(id)}}
+}
+
+
+// pathID is an {id} path segment
+func pathID(prefix string, id int, suffix string) string {
+	return prefix + "/" + strconv.Itoa(id) + suffix
+}
@@ -0,0 +1,273 @@
+package client_test
+
+import (
+	"archive/zip"       // for the data export
+	"bytes"             // for the exports
+	"context"           // for the calls
+	"encoding/json"     // for the admin's answer
+	"errors"            // for matching API errors
+	"io"                // for reading exports
+	"net/http"          // for status codes
+	"net/http/httptest" // for the admin server
+	"reflect"           // for comparing answers
+	"strings"           // for export contents
+	"testing"           // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/client"  // for the client under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server"  // for the admin endpoints and seeds
+)
+
+// client tests, against the whole API on apitest: lists and tags both ways, saved filters, the
+// trash, export and import, stats and the /me calls
+
+
+// newClient starts an API with todos on two lists, and a client of it acting as alice
+func newClient(t *testing.T) (*apitest.Server, *client.Client) {
+
+	s := apitest.New(t)
+	s.Seed(apitest.NewSeed().Add(
+		server.Todo{Title: "milk", List: "groceries", Tags: []string{"dairy", "fridge"}},
+		server.Todo{Title: "bread", List: "groceries", Tags: []string{"bakery"}, Done: true},
+		server.Todo{Title: "file taxes", List: "home"},
+	))
+	return s, client.New(s.URL, client.WithActor("alice", ""))
+}
+
+
+// titles are the titles of todos, in order
+func titles(todos []client.Todo) string {
+	var out []string
+	for _, todo := range todos {
+		out = append(out, todo.Title)
+	}
+	return strings.Join(out, ", ")
+}
+
+
+// TestListsAndTags checks todos are created, moved and found by list, tag and creator
+func TestListsAndTags(t *testing.T) {
+
+	_, c := newClient(t)
+	ctx := context.Background()
+
+	soap, err := c.Create(ctx, client.NewTodo{Title: "soap", List: "groceries", Tags: []string{"Home"}})
+	if err != nil || soap.List != "groceries" || !reflect.DeepEqual(soap.Tags, []string{"home"}) {
+		t.Fatalf("Create = %+v, %v", soap, err)
+	}
+	if _, err := c.Create(ctx, client.NewTodo{Title: "no list, no tags"}); err != nil {
+		t.Fatalf("Create without list or tags: %v", err)
+	}
+	moved, err := c.Update(ctx, 1, client.Patch{List: client.String("dairy"), Tags: []string{}})
+	if err != nil || moved.List != "dairy" || moved.Tags != nil {
+		t.Fatalf("Update = %+v, %v", moved, err)
+	}
+
+	tests := []struct {
+		name string
+		opts client.ListOptions
+		want string
+	}{
+		{"on a list", client.ListOptions{List: "groceries"}, "bread, soap"},
+		{"with a tag", client.ListOptions{Tag: "home"}, "soap"},
+		{"open on a list", client.ListOptions{List: "groceries", Done: client.Bool(false)}, "soap"},
+		{"created by", client.ListOptions{CreatedBy: "ALICE"}, "soap, no list, no tags"},
+	}
+	for _, tt := range tests {
+		list, err := c.List(ctx, tt.opts)
+		if got := titles(list); err != nil || got != tt.want {
+			t.Errorf("%s: %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	lists, err := c.Lists(ctx)
+	want := []client.ListSummary{{Name: "dairy", Open: 1}, {Name: "groceries", Open: 1, Done: 1}, {Name: "home", Open: 1}}
+	if err != nil || !reflect.DeepEqual(lists, want) {
+		t.Errorf("Lists = %+v, %v, want %+v", lists, err, want)
+	}
+	var onHome []client.Todo
+	for todo, err := range c.ListTodos(ctx, "home") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		onHome = append(onHome, todo)
+	}
+	if got := titles(onHome); got != "file taxes" {
+		t.Errorf("ListTodos(home) = %q", got)
+	}
+	for _, err := range c.ListTodos(ctx, "nothing") {
+		if !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("ListTodos(nothing): %v, want ErrNotFound", err)
+		}
+	}
+
+	tags, err := c.Tags(ctx)
+	if err != nil || !reflect.DeepEqual(tags, []string{"bakery", "home"}) {
+		t.Errorf("Tags = %v, %v", tags, err)
+	}
+	stats, err := c.Stats(ctx)
+	if err != nil || stats.Total != 5 || stats.Tags["bakery"] != (client.TagStats{Total: 1, Done: 1}) {
+		t.Errorf("Stats = %+v, %v", stats, err)
+	}
+}
+
+
+// TestSavedFilters checks a saved filter is kept and runs its query
+func TestSavedFilters(t *testing.T) {
+
+	_, c := newClient(t)
+	ctx := context.Background()
+
+	f, err := c.CreateFilter(ctx, "Open groceries", "list=groceries&done=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UpdateFilter(ctx, f.ID, "Groceries", "list=groceries"); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := c.Filters(ctx)
+	if err != nil || len(filters) != 1 || filters[0].Name != "Groceries" {
+		t.Errorf("Filters = %+v, %v", filters, err)
+	}
+	var matched []client.Todo
+	for todo, err := range c.FilterTodos(ctx, f.ID) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		matched = append(matched, todo)
+	}
+	if got := titles(matched); got != "milk, bread" {
+		t.Errorf("FilterTodos = %q", got)
+	}
+	if err := c.DeleteFilter(ctx, f.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Filter(ctx, f.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Filter after DeleteFilter: %v, want ErrNotFound", err)
+	}
+}
+
+
+// TestTrash checks deleted todos can be listed, restored and purged
+func TestTrash(t *testing.T) {
+
+	_, c := newClient(t)
+	ctx := context.Background()
+
+	for _, id := range []int{1, 2, 3} {
+		if err := c.Delete(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trash, err := c.Trash(ctx)
+	if err != nil || len(trash) != 3 || trash[0].DeletedBy != "alice" {
+		t.Fatalf("Trash = %+v, %v", trash, err)
+	}
+	restored, err := c.RestoreTrashed(ctx, 1)
+	if err != nil || restored.Title != "milk" || restored.List != "groceries" {
+		t.Errorf("RestoreTrashed = %+v, %v", restored, err)
+	}
+	if err := c.PurgeTrashed(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RestoreTrashed(ctx, 2); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("RestoreTrashed of a purged todo: %v, want ErrNotFound", err)
+	}
+	if err := c.EmptyTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if trash, err := c.Trash(ctx); err != nil || len(trash) != 0 {
+		t.Errorf("Trash after EmptyTrash = %+v, %v", trash, err)
+	}
+}
+
+
+// TestExportImport checks what Export writes, Import reads back, lists and tags included
+func TestExportImport(t *testing.T) {
+
+	_, c := newClient(t)
+	ctx := context.Background()
+
+	// each export has what the imports before it added
+	for _, tt := range []struct {
+		format   string
+		imported int
+	}{{client.FormatCSV, 3}, {client.FormatNDJSON, 6}} {
+		body, err := c.Export(ctx, tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(body)
+		body.Close()
+
+		result, err := c.Import(ctx, tt.format, bytes.NewReader(data))
+		if err != nil || result.Imported != tt.imported || len(result.Errors) != 0 {
+			t.Errorf("%s: Import = %+v, %v", tt.format, result, err)
+		}
+	}
+	imported, err := c.List(ctx, client.ListOptions{Tag: "fridge"})
+	if err != nil || len(imported) != 4 {
+		t.Errorf("todos tagged fridge after the imports: %+v, %v", imported, err)
+	}
+	if _, err := c.Import(ctx, client.FormatMarkdown, strings.NewReader("- [ ] milk")); err == nil {
+		t.Error("Import of Markdown succeeded")
+	}
+}
+
+
+// TestMe checks the /me calls with alice's token from the admin server, and without it
+func TestMe(t *testing.T) {
+
+	s, _ := newClient(t)
+	ctx := context.Background()
+
+	admin := httptest.NewServer(server.Admin())
+	defer admin.Close()
+	resp, err := http.Get(admin.URL + "/admin/actors/alice/token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var token struct{ Token string }
+	json.NewDecoder(resp.Body).Decode(&token)
+	resp.Body.Close()
+
+	c := client.New(s.URL, client.WithActor("alice", token.Token))
+	streak, err := c.SetStreakGoal(ctx, 2)
+	if err != nil || streak.Actor != "alice" || streak.DailyGoal != 2 {
+		t.Errorf("SetStreakGoal = %+v, %v", streak, err)
+	}
+	if streak, err := c.Streak(ctx); err != nil || streak.DailyGoal != 2 {
+		t.Errorf("Streak = %+v, %v", streak, err)
+	}
+
+	export, err := c.ExportMe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(export)
+	export.Close()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(archive.File) == 0 || archive.File[0].Name != "data.json" {
+		t.Errorf("ExportMe is not an archive of data.json: %v", err)
+	}
+
+	erasure, err := c.DeleteMe(ctx)
+	if err != nil || erasure.Actor != "alice" || !erasure.EraseAt.After(erasure.RequestedAt) {
+		t.Errorf("DeleteMe = %+v, %v", erasure, err)
+	}
+	if pending, err := c.Erasure(ctx); err != nil || pending != erasure {
+		t.Errorf("Erasure = %+v, %v, want %+v", pending, err, erasure)
+	}
+	if err := c.CancelErasure(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Erasure(ctx); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Erasure after CancelErasure: %v, want ErrNotFound", err)
+	}
+
+	// the actor's name alone isn't enough
+	var apiErr *client.APIError
+	if _, err := client.New(s.URL, client.WithActor("alice", "")).DeleteMe(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("DeleteMe without a token: %v, want a 401", err)
+	}
+}
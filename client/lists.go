@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"  // for cancellation
+	"iter"     // for the todos of a list or saved filter
+	"maps"     // for the tags in order
+	"net/http" // for methods
+	"net/url"  // for list names in paths
+	"slices"   // for the tags in order
+	"time"     // for timestamps
+)
+
+// ListSummary is one list and how many todos are on it
+type ListSummary struct {
+	Name string `json:"name"`
+	Open int    `json:"open"`
+	Done int    `json:"done"`
+}
+
+// SavedFilter is a named GET /todos query, evaluated on each FilterTodos
+type SavedFilter struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"` // e.g. q=work&due=this_week&done=false
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Template creates a todo and its subtasks when applied
+type Template struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`              // {placeholders} allowed, {date} is built in
+	Due       string    `json:"due,omitempty"`      // relative, in words: "in 3 days"
+	Subtasks  []string  `json:"subtasks,omitempty"` // titles, placeholders allowed too
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// filterBody is what saving a filter sends
+type filterBody struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// templateBody is what saving a template sends
+type templateBody struct {
+	Name     string   `json:"name"`
+	Title    string   `json:"title"`
+	Due      string   `json:"due,omitempty"`
+	Subtasks []string `json:"subtasks,omitempty"`
+}
+
+
+// Lists returns every list a todo is on, by name
+func (c *Client) Lists(ctx context.Context) ([]ListSummary, error) {
+	var lists []ListSummary
+	err := c.call(ctx, request{method: http.MethodGet, path: "/lists"}, &lists)
+	return lists, err
+}
+
+
+// ListTodos iterates over the todos on a list; a list no todo is on is an *APIError matching ErrNotFound
+func (c *Client) ListTodos(ctx context.Context, name string) iter.Seq2[Todo, error] {
+	return c.todoStream(ctx, request{method: http.MethodGet, path: "/lists/" + url.PathEscape(name) + "/todos"})
+}
+
+
+// Tags returns every tag a todo has, in order, from the counts of Stats
+func (c *Client) Tags(ctx context.Context) ([]string, error) {
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(stats.Tags)), nil
+}
+
+
+// Filters returns the saved filters, by id
+func (c *Client) Filters(ctx context.Context) ([]SavedFilter, error) {
+	var list []SavedFilter
+	err := c.call(ctx, request{method: http.MethodGet, path: "/filters"}, &list)
+	return list, err
+}
+
+
+// Filter returns one saved filter
+func (c *Client) Filter(ctx context.Context, id int) (SavedFilter, error) {
+	var f SavedFilter
+	err := c.call(ctx, request{method: http.MethodGet, path: pathID("/filters", id, "")}, &f)
+	return f, err
+}
+
+
+// CreateFilter saves a query under a name; query is checked now, e.g. "due=this_week&done=false"
+func (c *Client) CreateFilter(ctx context.Context, name, query string) (SavedFilter, error) {
+	var f SavedFilter
+	err := c.call(ctx, jsonRequest(http.MethodPost, "/filters", filterBody{name, query}), &f)
+	return f, err
+}
+
+
+// UpdateFilter replaces a saved filter's name and query
+func (c *Client) UpdateFilter(ctx context.Context, id int, name, query string) (SavedFilter, error) {
+	var f SavedFilter
+	err := c.call(ctx, jsonRequest(http.MethodPut, pathID("/filters", id, ""), filterBody{name, query}), &f)
+	return f, err
+}
+
+
+// DeleteFilter removes a saved filter
+func (c *Client) DeleteFilter(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: pathID("/filters", id, "")}, nil)
+}
+
+
+// FilterTodos iterates over the todos a saved filter matches now
+func (c *Client) FilterTodos(ctx context.Context, id int) iter.Seq2[Todo, error] {
+	return c.todoStream(ctx, request{method: http.MethodGet, path: pathID("/filters", id, "/todos")})
+}
+
+
+// Templates returns the templates, by id
+func (c *Client) Templates(ctx context.Context) ([]Template, error) {
+	var list []Template
+	err := c.call(ctx, request{method: http.MethodGet, path: "/templates"}, &list)
+	return list, err
+}
+
+
+// Template returns one template
+func (c *Client) Template(ctx context.Context, id int) (Template, error) {
+	var t Template
+	err := c.call(ctx, request{method: http.MethodGet, path: pathID("/templates", id, "")}, &t)
+	return t, err
+}
+
+
+// CreateTemplate saves a template; ID and CreatedAt of t are ignored
+func (c *Client) CreateTemplate(ctx context.Context, t Template) (Template, error) {
+	var saved Template
+	err := c.call(ctx, jsonRequest(http.MethodPost, "/templates", templateBody{t.Name, t.Title, t.Due, t.Subtasks}), &saved)
+	return saved, err
+}
+
+
+// UpdateTemplate replaces a template with t
+func (c *Client) UpdateTemplate(ctx context.Context, id int, t Template) (Template, error) {
+	var saved Template
+	err := c.call(ctx, jsonRequest(http.MethodPut, pathID("/templates", id, ""), templateBody{t.Name, t.Title, t.Due, t.Subtasks}), &saved)
+	return saved, err
+}
+
+
+// DeleteTemplate removes a template
+func (c *Client) DeleteTemplate(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: pathID("/templates", id, "")}, nil)
+}
+
+
+// ApplyTemplate creates a template's todos with its placeholders filled from values: the
+// main todo first, then the subtasks
+func (c *Client) ApplyTemplate(ctx context.Context, id int, values map[string]string) ([]Todo, error) {
+
+	var result struct {
+		Todos []Todo `json:"todos"`
+	}
+	body := struct {
+		Values map[string]string `json:"values,omitempty"`
+	}{values}
+	err := c.call(ctx, jsonRequest(http.MethodPost, pathID("/templates", id, "/apply"), body), &result)
+	return result.Todos, err
+}
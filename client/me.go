@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"  // for cancellation
+	"io"       // for the data export
+	"net/http" // for methods
+	"time"     // for timestamps
+)
+
+// the calls about the client's actor (see WithActor): their streak, their data and its erasure.
+// all but the streak need the actor's token
+
+// Streak is how many days in a row the actor met their daily goal
+type Streak struct {
+	Actor         string      `json:"actor"`
+	DailyGoal     int         `json:"daily_goal"`
+	Current       int         `json:"current"` // days in a row up to today, or yesterday while today isn't met yet
+	Longest       int         `json:"longest"` // in the last year
+	Today         StreakToday `json:"today"`
+	NextMilestone int         `json:"next_milestone,omitempty"`
+}
+
+// StreakToday is how today is going
+type StreakToday struct {
+	Date      string `json:"date"` // 2006-01-02
+	Completed int    `json:"completed"`
+	Met       bool   `json:"met"` // the goal is reached
+}
+
+// Erasure is a pending erasure of the actor's data
+type Erasure struct {
+	Actor       string    `json:"actor"`
+	RequestedAt time.Time `json:"requested_at"`
+	EraseAt     time.Time `json:"erase_at"`
+}
+
+
+// Streak returns the actor's streak
+func (c *Client) Streak(ctx context.Context) (Streak, error) {
+	var streak Streak
+	err := c.call(ctx, request{method: http.MethodGet, path: "/me/streak"}, &streak)
+	return streak, err
+}
+
+
+// SetStreakGoal sets how many todos a day the actor wants to complete (1 to 100)
+func (c *Client) SetStreakGoal(ctx context.Context, daily int) (Streak, error) {
+
+	body := struct {
+		DailyGoal int `json:"daily_goal"`
+	}{daily}
+	var streak Streak
+	err := c.call(ctx, jsonRequest(http.MethodPut, "/me/streak", body), &streak)
+	return streak, err
+}
+
+
+// ExportMe streams the actor's data as a zip (data.json and their todos' attachments); the
+// caller closes it
+func (c *Client) ExportMe(ctx context.Context) (io.ReadCloser, error) {
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/me/export"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+
+// DeleteMe schedules the erasure of the actor's data after the server's grace period, or
+// returns the one already pending
+func (c *Client) DeleteMe(ctx context.Context) (Erasure, error) {
+	var e Erasure
+	err := c.call(ctx, request{method: http.MethodDelete, path: "/me"}, &e)
+	return e, err
+}
+
+
+// Erasure returns the actor's pending erasure, an *APIError matching ErrNotFound when there's none
+func (c *Client) Erasure(ctx context.Context) (Erasure, error) {
+	var e Erasure
+	err := c.call(ctx, request{method: http.MethodGet, path: "/me/erasure"}, &e)
+	return e, err
+}
+
+
+// CancelErasure calls off the actor's pending erasure
+func (c *Client) CancelErasure(ctx context.Context) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: "/me/erasure"}, nil)
+}
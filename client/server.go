@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"  // for cancellation
+	"net/http" // for methods
+)
+
+// Version is the server's build, from GET /version
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+
+// Version returns what build the server runs
+func (c *Client) Version(ctx context.Context) (Version, error) {
+	var v Version
+	err := c.call(ctx, request{method: http.MethodGet, path: "/version"}, &v)
+	return v, err
+}
+
+
+// Ready asks /readyz whether the server takes traffic: nil when it does
+func (c *Client) Ready(ctx context.Context) error {
+	return c.call(ctx, request{method: http.MethodGet, path: "/readyz"}, nil)
+}
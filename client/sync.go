@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"       // for cancellation
+	"encoding/json" // for the change bodies
+	"errors"        // for the resync answer
+	"net/http"      // for methods
+	"time"          // for due dates
+)
+
+// sync operations
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncChange is one change made offline
+type SyncChange struct {
+	Op       string     // SyncCreate, SyncUpdate or SyncDelete
+	ClientID string     // the client's own id for a create, echoed back
+	ID       int        // the todo, for update and delete
+	Rev      int        // update: the revision it was made against, to merge with
+	Title    *string    // new title (create: required)
+	Done     *bool      // new done status
+	Due      *time.Time // new due date
+	ClearDue bool       // update: remove the due date
+}
+
+// SyncApplied is a change that went through
+type SyncApplied struct {
+	Index    int    `json:"index"` // position in the changes sent
+	ClientID string `json:"client_id,omitempty"`
+	ID       int    `json:"id"`
+	Todo     *Todo  `json:"todo,omitempty"` // as stored, nil for deletes
+}
+
+// SyncConflict is a change to a todo that changed on the server meanwhile
+type SyncConflict struct {
+	Index  int      `json:"index"`
+	ID     int      `json:"id"`
+	Server *Todo    `json:"server"`           // the server's version, nil if it was deleted
+	Fields []string `json:"fields,omitempty"` // for updates with a rev: changed on both sides
+}
+
+// SyncFailure is a change the server refused
+type SyncFailure struct {
+	Index int      `json:"index"`
+	Error APIError `json:"error"`
+}
+
+// SyncResult is the answer to Sync
+type SyncResult struct {
+	Applied   []SyncApplied  `json:"applied"`
+	Conflicts []SyncConflict `json:"conflicts"`
+	Failed    []SyncFailure  `json:"failed"`
+	Changes   []Event        `json:"changes"`          // every change after since, this sync's own included
+	LastSeq   uint64         `json:"last_seq"`         // since for the next Sync
+	Resync    bool           `json:"resync,omitempty"` // changes fell out of the log meanwhile: reload with List
+}
+
+
+// MarshalJSON writes the change as POST /todos/sync takes it, null for a cleared due date
+func (c SyncChange) MarshalJSON() ([]byte, error) {
+
+	body := map[string]any{"op": c.Op}
+	if c.ClientID != "" {
+		body["client_id"] = c.ClientID
+	}
+	if c.ID != 0 {
+		body["id"] = c.ID
+	}
+	if c.Rev > 0 {
+		body["rev"] = c.Rev
+	}
+	if c.Title != nil {
+		body["title"] = *c.Title
+	}
+	if c.Done != nil {
+		body["done"] = *c.Done
+	}
+	switch {
+	case c.ClearDue:
+		body["due"] = nil
+	case c.Due != nil:
+		body["due"] = c.Due.Format(time.RFC3339)
+	}
+	return json.Marshal(body)
+}
+
+
+// Sync sends the changes made offline since the last sync (since = its LastSeq, 0 the first
+// time) and returns what the server did with them and what changed there meanwhile. a since
+// the server's log no longer reaches is a result with Resync set and nothing applied
+func (c *Client) Sync(ctx context.Context, since uint64, changes []SyncChange) (SyncResult, error) {
+
+	body := struct {
+		Since   uint64       `json:"since"`
+		Changes []SyncChange `json:"changes"`
+	}{since, changes}
+	if body.Changes == nil {
+		body.Changes = []SyncChange{}
+	}
+
+	var result SyncResult
+	err := c.call(ctx, jsonRequest(http.MethodPost, "/todos/sync", body), &result)
+
+	// the 410 is an answer too: resync, with the last_seq to start from
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone {
+		if json.Unmarshal(apiErr.body, &result) == nil && result.Resync {
+			return result, nil
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,413 @@
+package client
+
+import (
+	"context"       // for cancellation
+	"encoding/json" // for request and response bodies
+	"fmt"           // for stream errors
+	"io"            // for exports and imports
+	"iter"          // for iterators over lists and the change feed
+	"net/http"      // for methods
+	"net/url"       // for filters
+	"sort"          // for id order
+	"strconv"       // for since and limit
+	"time"          // for due dates and waits
+)
+
+// NewTodo is the body of Create; who created it is the client's actor, see WithActor
+type NewTodo struct {
+	Title string   `json:"title"`
+	Due   string   `json:"due,omitempty"`  // RFC 3339 or words ("tomorrow 5pm"), read in the client's zone
+	List  string   `json:"list,omitempty"` // the list it goes on
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// Patch changes the fields it has; the rest stay as they are
+type Patch struct {
+	Rev      int        // revision the change was made against: stale ones are merged or a 409 (0 = last write wins)
+	Title    *string    // new title
+	Done     *bool      // new done status
+	Due      *time.Time // new due date
+	ClearDue bool       // remove the due date (Due is ignored)
+	List     *string    // the list to move it to, "" takes it off its list
+	Tags     []string   // replaces the tags when not nil, an empty one removes them all
+}
+
+// ListOptions filters Todos and List, like the query of GET /todos
+type ListOptions struct {
+	Done      *bool      // only done or only open todos
+	Due       string     // today, tomorrow, this_week, next_week or overdue
+	DueAfter  *time.Time // due at or after
+	DueBefore *time.Time // due before
+	Query     string     // title contains
+	List      string     // only the todos on this list
+	Tag       string     // only the todos with this tag
+	CreatedBy string     // only the todos this actor created, ignoring case
+}
+
+// Stats are the counts of GET /todos/stats
+type Stats struct {
+	Total   int64               `json:"total"`
+	Open    int64               `json:"open"`
+	Done    int64               `json:"done"`
+	WithDue int64               `json:"with_due"`
+	Tags    map[string]TagStats `json:"tags,omitempty"` // by tag, for every tag a todo has
+}
+
+// TagStats counts the todos with one tag
+type TagStats struct {
+	Total int64 `json:"total"`
+	Open  int64 `json:"open"`
+	Done  int64 `json:"done"`
+}
+
+// Snooze is one time a todo was snoozed
+type Snooze struct {
+	At   time.Time  `json:"at"`
+	From *time.Time `json:"from"` // due date before, nil if it had none
+	To   time.Time  `json:"to"`   // due date after
+	For  string     `json:"for,omitempty"`
+}
+
+// SnoozeHistory is how often a todo was snoozed, with the latest snoozes
+type SnoozeHistory struct {
+	ID      int      `json:"id"`
+	Count   int      `json:"count"`
+	Snoozes []Snooze `json:"snoozes"` // oldest first, at most the server's last 100
+}
+
+// ChangePage is one page of the change feed
+type ChangePage struct {
+	Changes []Event `json:"changes"`
+	LastSeq uint64  `json:"last_seq"` // since for the next page
+	HasMore bool    `json:"has_more"`
+}
+
+// ImportError is a row Import refused
+type ImportError struct {
+	Row   int    `json:"row"` // line in the upload, the header is row 1
+	Error string `json:"error"`
+}
+
+// ImportResult is what Import took in
+type ImportResult struct {
+	Imported      int           `json:"imported"`
+	Errors        []ImportError `json:"errors"`
+	ErrorsDropped int           `json:"errors_dropped,omitempty"` // failed rows beyond the ones listed
+}
+
+// export and import formats
+const (
+	FormatCSV      = "csv"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "md" // export only
+)
+
+// largest page the change feed serves
+const maxChangePage = 1000
+
+
+// MarshalJSON writes only the fields the patch sets, null for a cleared due date
+func (p Patch) MarshalJSON() ([]byte, error) {
+
+	body := map[string]any{}
+	if p.Rev > 0 {
+		body["rev"] = p.Rev
+	}
+	if p.Title != nil {
+		body["title"] = *p.Title
+	}
+	if p.Done != nil {
+		body["done"] = *p.Done
+	}
+	switch {
+	case p.ClearDue:
+		body["due"] = nil
+	case p.Due != nil:
+		body["due"] = p.Due.Format(time.RFC3339)
+	}
+	if p.List != nil {
+		body["list"] = *p.List
+	}
+	if p.Tags != nil {
+		body["tags"] = p.Tags
+	}
+	return json.Marshal(body)
+}
+
+
+// values is the query string for the options
+func (o ListOptions) values() url.Values {
+
+	q := url.Values{}
+	if o.Done != nil {
+		q.Set("done", strconv.FormatBool(*o.Done))
+	}
+	if o.Due != "" {
+		q.Set("due", o.Due)
+	}
+	if o.DueAfter != nil {
+		q.Set("due_after", o.DueAfter.Format(time.RFC3339))
+	}
+	if o.DueBefore != nil {
+		q.Set("due_before", o.DueBefore.Format(time.RFC3339))
+	}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.List != "" {
+		q.Set("list", o.List)
+	}
+	if o.Tag != "" {
+		q.Set("tag", o.Tag)
+	}
+	if o.CreatedBy != "" {
+		q.Set("created_by", o.CreatedBy)
+	}
+	return q
+}
+
+
+// Todos iterates over the todos matching opts as the server streams them, without holding the
+// whole list; a failed request or a broken stream is the last pair, with the error
+func (c *Client) Todos(ctx context.Context, opts ListOptions) iter.Seq2[Todo, error] {
+	return c.todoStream(ctx, request{method: http.MethodGet, path: "/todos", query: opts.values()})
+}
+
+
+// List returns the todos matching opts, by id
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]Todo, error) {
+	return collect(c.Todos(ctx, opts))
+}
+
+
+// Get returns one todo
+func (c *Client) Get(ctx context.Context, id int) (Todo, error) {
+	var todo Todo
+	err := c.call(ctx, request{method: http.MethodGet, path: "/todos/get", query: idQuery(id)}, &todo)
+	return todo, err
+}
+
+
+// Create adds a todo
+func (c *Client) Create(ctx context.Context, t NewTodo) (Todo, error) {
+	var todo Todo
+	err := c.call(ctx, jsonRequest(http.MethodPost, "/todos/create", t), &todo)
+	return todo, err
+}
+
+
+// Update applies p to a todo. with p.Rev set, changes made since that revision are merged:
+// an overlapping one is an *APIError matching ErrConflict, with both versions
+func (c *Client) Update(ctx context.Context, id int, p Patch) (Todo, error) {
+
+	req := jsonRequest(http.MethodPut, "/todos/update", p)
+	req.query = idQuery(id)
+
+	var todo Todo
+	err := c.call(ctx, req, &todo)
+	return todo, err
+}
+
+
+// Complete marks a todo done
+func (c *Client) Complete(ctx context.Context, id int) (Todo, error) {
+	return c.Update(ctx, id, Patch{Done: Bool(true)})
+}
+
+
+// Delete removes a todo
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: "/todos/delete", query: idQuery(id)}, nil)
+}
+
+
+// Snooze moves an open todo's due date d forward, from now if it's past or unset; snoozing a
+// done todo is an *APIError matching ErrConflict
+func (c *Client) Snooze(ctx context.Context, id int, d time.Duration) (Todo, error) {
+	return c.snooze(ctx, id, map[string]string{"for": d.String()})
+}
+
+
+// SnoozeUntil moves an open todo's due date to t, which is later than both now and the due date
+func (c *Client) SnoozeUntil(ctx context.Context, id int, t time.Time) (Todo, error) {
+	return c.snooze(ctx, id, map[string]string{"until": t.Format(time.RFC3339)})
+}
+
+
+// Snoozes returns a todo's snooze history
+func (c *Client) Snoozes(ctx context.Context, id int) (SnoozeHistory, error) {
+	var history SnoozeHistory
+	err := c.call(ctx, request{method: http.MethodGet, path: pathID("/todos", id, "/snooze")}, &history)
+	return history, err
+}
+
+
+// snooze posts a snooze body
+func (c *Client) snooze(ctx context.Context, id int, body map[string]string) (Todo, error) {
+	var todo Todo
+	err := c.call(ctx, jsonRequest(http.MethodPost, pathID("/todos", id, "/snooze"), body), &todo)
+	return todo, err
+}
+
+
+// Stats returns the todo counts
+func (c *Client) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	err := c.call(ctx, request{method: http.MethodGet, path: "/todos/stats"}, &stats)
+	return stats, err
+}
+
+
+// Trash returns the deleted todos the server still keeps, most recently deleted first
+func (c *Client) Trash(ctx context.Context) ([]Trashed, error) {
+	var trash []Trashed
+	err := c.call(ctx, request{method: http.MethodGet, path: "/trash"}, &trash)
+	return trash, err
+}
+
+
+// RestoreTrashed puts a deleted todo back, with its id and history
+func (c *Client) RestoreTrashed(ctx context.Context, id int) (Todo, error) {
+	var todo Todo
+	err := c.call(ctx, request{method: http.MethodPost, path: pathID("/trash", id, "/restore")}, &todo)
+	return todo, err
+}
+
+
+// PurgeTrashed deletes a todo in the trash for good
+func (c *Client) PurgeTrashed(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: pathID("/trash", id, "")}, nil)
+}
+
+
+// EmptyTrash deletes every todo in the trash for good
+func (c *Client) EmptyTrash(ctx context.Context) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: "/trash"}, nil)
+}
+
+
+// ChangePage returns up to limit change records after since (1 to 1000, 0 = the server's default).
+// a since that fell out of the server's log is an *APIError with status 410: reload with List
+func (c *Client) ChangePage(ctx context.Context, since uint64, limit int) (ChangePage, error) {
+
+	q := url.Values{"since": {strconv.FormatUint(since, 10)}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var page ChangePage
+	err := c.call(ctx, request{method: http.MethodGet, path: "/changes", query: q}, &page)
+	return page, err
+}
+
+
+// Changes iterates over every change after since, a page at a time, up to the latest one
+func (c *Client) Changes(ctx context.Context, since uint64) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			page, err := c.ChangePage(ctx, since, maxChangePage)
+			if err != nil {
+				yield(Event{}, err)
+				return
+			}
+			for _, e := range page.Changes {
+				if !yield(e, nil) {
+					return
+				}
+			}
+			if !page.HasMore {
+				return
+			}
+			since = page.LastSeq
+		}
+	}
+}
+
+
+// WaitChanges long-polls for changes after since, returning at the latest after wait (capped by
+// the server's -longpoll-max-wait) with whatever arrived, maybe nothing
+func (c *Client) WaitChanges(ctx context.Context, since uint64, wait time.Duration) (ChangePage, error) {
+
+	q := url.Values{"since": {strconv.FormatUint(since, 10)}, "wait": {wait.String()}}
+	var page ChangePage
+	err := c.call(ctx, request{method: http.MethodGet, path: "/todos/changes", query: q}, &page)
+	return page, err
+}
+
+
+// Export streams every todo in format (FormatCSV, FormatNDJSON or FormatMarkdown); the caller closes it
+func (c *Client) Export(ctx context.Context, format string) (io.ReadCloser, error) {
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/todos/export." + format})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+
+// Import uploads todos in format (FormatCSV or FormatNDJSON), read from r as they are sent
+func (c *Client) Import(ctx context.Context, format string, r io.Reader) (ImportResult, error) {
+
+	req := request{method: http.MethodPost, path: "/todos/import", stream: r}
+	switch format {
+	case FormatCSV:
+		req.contentType = "text/csv"
+	case FormatNDJSON:
+		req.contentType = "application/x-ndjson"
+	default:
+		return ImportResult{}, fmt.Errorf("todo api: can't import %q", format)
+	}
+
+	var result ImportResult
+	err := c.call(ctx, req, &result)
+	return result, err
+}
+
+
+// todoStream reads an id -> todo object entry by entry
+func (c *Client) todoStream(ctx context.Context, req request) iter.Seq2[Todo, error] {
+	return func(yield func(Todo, error) bool) {
+
+		resp, err := c.send(ctx, req)
+		if err != nil {
+			yield(Todo{}, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			yield(Todo{}, fmt.Errorf("todo api: %s: not a todo list", req.path))
+			return
+		}
+		for dec.More() {
+			var todo Todo
+			if _, err := dec.Token(); err != nil {
+				yield(Todo{}, fmt.Errorf("todo api: %s: %w", req.path, err))
+				return
+			}
+			if err := dec.Decode(&todo); err != nil {
+				yield(Todo{}, fmt.Errorf("todo api: %s: %w", req.path, err))
+				return
+			}
+			if !yield(todo, nil) {
+				return
+			}
+		}
+	}
+}
+
+
+// collect reads a todo iterator into a list by id, or its error
+func collect(todos iter.Seq2[Todo, error]) ([]Todo, error) {
+
+	var list []Todo
+	for todo, err := range todos {
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, todo)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os" // for arguments and exit codes
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/api" // for the server
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/cli" // for the client commands
+)
+
+
+func main() {
+
+	// `todo add/list/done/rm` are client commands talking to a running server
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1], os.Args[2:]))
+	}
+
+	// anything else runs the server
+	api.Run()
+}
@@ -0,0 +1,3 @@
+module github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1
+
+go 1.25
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bufio"         // for hijacked connections
+	"errors"        // for config errors
+	"fmt"           // for printing logs to terminal and the log lines
+	"net"           // for hijacked connections
+	"net/http"      // for the middleware
+	"os"            // for the log file
+	"path/filepath" // for finding rotated files
+	"sort"          // for pruning the oldest
+	"strconv"       // for status codes and sizes
+	"strings"       // for escaping
+	"sync"          // for guarding the file
+	"time"          // for timestamps and rotation
+)
+
+// the access log: a line per request in the Common Log Format, or the combined one with the
+// referer and user agent, to a file of its own (the application's logs stay on standard output),
+// for log tooling that already reads web server logs. the file is rotated once it reaches
+// -access-log-max-size and/or every -access-log-rotate (aligned to UTC, so 24h rotates at
+// midnight): renamed to <file>.<time>, a new one started, and only the latest -access-log-keep kept
+var (
+	accessLogPath    = flags.String("access-log", "", "file requests are logged to, one line each (off when empty)")
+	accessLogFormat  = flags.String("access-log-format", "combined", "access log line format: combined, or common (without referer and user agent)")
+	accessLogMaxSize = flags.Int("access-log-max-size", 100<<20, "size in bytes the access log is rotated at (0 = not by size)")
+	accessLogRotate  = flags.Duration("access-log-rotate", 0, "how often the access log is rotated, e.g. 24h (0 = not by time)")
+	accessLogKeep    = flags.Int("access-log-keep", 7, "rotated access logs kept, the oldest removed beyond that (0 = keep all)")
+)
+
+// the time format of CLF, [10/Oct/2026:13:55:36 -0700], and of rotated file names
+const (
+	clfTime         = "02/Jan/2006:15:04:05 -0700"
+	rotatedNameTime = "20060102-150405.000"
+)
+
+// the open access log
+var accessLog *os.File
+var accessLogMu sync.Mutex
+var accessLogSize int64         // bytes in it
+var accessLogRotateAt time.Time // when it's due for rotating by time, zero when it isn't
+
+// accessRecorder is a statusRecorder that also sees a connection taken over for a WebSocket
+type accessRecorder struct {
+	*statusRecorder
+}
+
+
+// Hijack hands over the connection, whose answer is the 101 the WebSocket handshake writes itself
+func (a accessRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(a.ResponseWriter).Hijack()
+	if err == nil {
+		a.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+
+// loadAccessLog checks the access log flags and opens the file
+func loadAccessLog() error {
+
+	accessLogMu.Lock()
+	defer accessLogMu.Unlock()
+
+	// a new config starts a new file
+	if accessLog != nil {
+		accessLog.Close()
+		accessLog = nil
+	}
+	if *accessLogPath == "" {
+		return nil
+	}
+	switch {
+	case *accessLogFormat != "combined" && *accessLogFormat != "common":
+		return errors.New("-access-log-format: must be combined or common")
+	case *accessLogMaxSize < 0:
+		return errors.New("-access-log-max-size: must not be negative")
+	case *accessLogRotate < 0:
+		return errors.New("-access-log-rotate: must not be negative")
+	case *accessLogRotate > 0 && *accessLogRotate < time.Minute:
+		return errors.New("-access-log-rotate: at least a minute")
+	case *accessLogKeep < 0:
+		return errors.New("-access-log-keep: must not be negative")
+	}
+	if err := openAccessLog(time.Now()); err != nil {
+		return fmt.Errorf("-access-log: %w", err)
+	}
+	return nil
+}
+
+
+// openAccessLog opens the file to append to and plans its rotation (caller holds accessLogMu)
+func openAccessLog(now time.Time) error {
+
+	f, err := os.OpenFile(*accessLogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	accessLog, accessLogSize = f, info.Size()
+	accessLogRotateAt = time.Time{}
+	if *accessLogRotate > 0 {
+		accessLogRotateAt = now.Truncate(*accessLogRotate).Add(*accessLogRotate)
+	}
+	return nil
+}
+
+
+// rotateAccessLog moves the file aside, starts a new one and removes the rotated ones past
+// -access-log-keep (caller holds accessLogMu); the log stays off if the new one can't be opened
+func rotateAccessLog(now time.Time) {
+
+	accessLog.Close()
+	accessLog = nil
+	rotated := *accessLogPath + "." + now.UTC().Format(rotatedNameTime)
+	if err := os.Rename(*accessLogPath, rotated); err != nil {
+		fmt.Println("access log: rotating failed, going on in the same file:", err)
+	}
+	if err := openAccessLog(now); err != nil {
+		fmt.Println("access log: cannot open a new file, not logging requests:", err)
+		return
+	}
+
+	if *accessLogKeep == 0 {
+		return
+	}
+	old, _ := filepath.Glob(*accessLogPath + ".[0-9]*")
+	sort.Strings(old)
+	for len(old) > *accessLogKeep {
+		os.Remove(old[0])
+		old = old[1:]
+	}
+}
+
+
+// writeAccessLog appends one line, rotating first if it's time to
+func writeAccessLog(line string, now time.Time) {
+	accessLogMu.Lock()
+	defer accessLogMu.Unlock()
+
+	if accessLog == nil {
+		return
+	}
+	bySize := *accessLogMaxSize > 0 && accessLogSize > 0 && accessLogSize+int64(len(line)) > int64(*accessLogMaxSize)
+	byTime := !accessLogRotateAt.IsZero() && !now.Before(accessLogRotateAt)
+	if byTime && accessLogSize == 0 {
+		// nothing to move aside, the file goes on into the next period
+		accessLogRotateAt = now.Truncate(*accessLogRotate).Add(*accessLogRotate)
+		byTime = false
+	}
+	if bySize || byTime {
+		rotateAccessLog(now)
+		if accessLog == nil {
+			return
+		}
+	}
+	n, _ := accessLog.WriteString(line)
+	accessLogSize += int64(n)
+}
+
+
+// clfQuote quotes a request line, referer or user agent for a log line: quotes and backslashes
+// escaped, control characters as \xhh, so a client can't forge lines
+func clfQuote(s string) string {
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+
+// clfField is a value for a log line, - when there's none
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+
+// accessLogLine formats a request as the server answered it
+func accessLogLine(r *http.Request, status, size int, start time.Time) string {
+
+	host := ""
+	if addr, ok := clientIP(r); ok {
+		host = addr.String()
+	}
+	// a user name can't break the line up either
+	user, _, _ := r.BasicAuth()
+	user = strings.Map(func(c rune) rune {
+		if c <= ' ' || c == 0x7f {
+			return '_'
+		}
+		return c
+	}, user)
+	bytes := "-"
+	if size > 0 {
+		bytes = strconv.Itoa(size)
+	}
+
+	line := clfField(host) + " - " + clfField(user) + " [" + start.Format(clfTime) + "] " +
+		clfQuote(r.Method+" "+r.RequestURI+" "+r.Proto) + " " + strconv.Itoa(status) + " " + bytes
+	if *accessLogFormat == "combined" {
+		line += " " + clfQuote(clfField(r.Referer())) + " " + clfQuote(clfField(r.UserAgent()))
+	}
+	return line + "\n"
+}
+
+
+// logAccess writes a line per request to the access log, once it's answered; outermost, so
+// requests turned away by the other middleware are in it too
+func logAccess(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// no access log, pass straight through
+		if *accessLogPath == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(accessRecorder{rec}, r)
+		writeAccessLog(accessLogLine(r, rec.status, rec.bytes, start), time.Now())
+	})
+}
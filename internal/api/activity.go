@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for activity sentences
+	"net/http"      // for HTTP handlers
+	"strconv"       // for paging parameters
+	"strings"       // for joining what changed
+	"time"          // for due dates in the client's zone
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for events and errors
+)
+
+// the change log read as sentences, newest first: "alice completed "ship release"". an update
+// is described by comparing the todo with its previous revision from the history; once that's
+// no longer kept (or the todo is gone) it's just "updated". there are no lists, so the feed
+// can be narrowed to an actor and a todo only
+
+// ActivityEntry is one change, told as a sentence
+type ActivityEntry struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Kind   string    `json:"kind"` // created, updated, completed, reopened or deleted
+	TodoID int       `json:"todo_id"`
+	Text   string    `json:"text"`
+}
+
+// ActivityPage is the body of GET /activity
+type ActivityPage struct {
+	Entries    []ActivityEntry `json:"entries"`               // newest first
+	NextBefore uint64          `json:"next_before,omitempty"` // before= for the next (older) page, 0 = no more
+}
+
+// most entries a page holds, and how many when not told
+const (
+	maxActivityLimit     = 200
+	defaultActivityLimit = 50
+)
+
+
+// describe tells an event as a sentence, with due dates in loc
+func describe(e model.Event, previous *model.Todo, loc *time.Location) (kind, text string) {
+
+	actor := e.Actor
+	if actor == "" {
+		actor = "someone"
+	}
+	title := strconv.Quote(e.Todo.Title)
+	switch {
+	case e.Type == model.EventCreated:
+		return e.Type, actor + " created " + title
+	case e.Type == model.EventDeleted:
+		return e.Type, actor + " deleted " + title
+	case previous == nil:
+		return e.Type, actor + " updated " + title
+	}
+
+	// the first change names the todo, the ones after call it "it"
+	kind = e.Type
+	var changes []string
+	name := func() string {
+		if len(changes) == 0 {
+			return strconv.Quote(previous.Title)
+		}
+		return "it"
+	}
+	for _, change := range diffTodos(*previous, e.Todo) {
+		switch change.Field {
+		case "title":
+			changes = append(changes, fmt.Sprintf("renamed %s to %s", name(), title))
+		case "done":
+			if e.Todo.Done {
+				kind = model.ChangeCompleted
+				changes = append(changes, "completed "+name())
+			} else {
+				kind = "reopened"
+				changes = append(changes, "reopened "+name())
+			}
+		case "due":
+			switch {
+			case e.Todo.Due == nil:
+				changes = append(changes, "removed the due date of "+name())
+			case previous.Due == nil:
+				changes = append(changes, fmt.Sprintf("set %s due %s", name(), e.Todo.Due.In(loc).Format("Mon Jan 2 15:04")))
+			default:
+				changes = append(changes, fmt.Sprintf("moved %s to %s", name(), e.Todo.Due.In(loc).Format("Mon Jan 2 15:04")))
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return kind, actor + " updated " + title
+	}
+	return kind, actor + " " + strings.Join(changes, " and ")
+}
+
+
+// GET /activity?actor=alice&todo=3&limit=50&before=120 returns the latest changes as sentences,
+// newest first; before= pages back through the change log
+func activityHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	limit := defaultActivityLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxActivityLimit {
+			writeError(w, r, model.Invalid("limit", fmt.Sprintf("must be between 1 and %d", maxActivityLimit)))
+			return
+		}
+		limit = n
+	}
+	var before uint64
+	if v := q.Get("before"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, r, model.Invalid("before", "must be a sequence number"))
+			return
+		}
+		before = n
+	}
+	todoID := 0
+	if v := q.Get("todo"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, model.Invalid("todo", "must be an integer"))
+			return
+		}
+		todoID = n
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	actor := q.Get("actor")
+
+	// the whole log, walked from the newest event back
+	page := ActivityPage{Entries: []ActivityEntry{}}
+	events := storeFor(r).Events().Recent(*changeLogSize)
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if (before != 0 && e.Seq >= before) || (actor != "" && e.Actor != actor) || (todoID != 0 && e.Todo.ID != todoID) {
+			continue
+		}
+		if len(page.Entries) == limit {
+			page.NextBefore = page.Entries[limit-1].Seq
+			break
+		}
+
+		var previous *model.Todo
+		if e.Type == model.EventUpdated {
+			if v, err := todoStore.Revision(r.Context(), e.Todo.ID, e.Todo.Rev-1); err == nil {
+				previous = &v.Todo
+			}
+		}
+		kind, text := describe(e, previous, loc)
+		page.Entries = append(page.Entries, ActivityEntry{Seq: e.Seq, Time: e.Time, Actor: e.Actor, Kind: kind, TodoID: e.Todo.ID, Text: text})
+	}
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"crypto/subtle"  // for constant-time token comparison
+	"fmt"            // for printing logs to terminal
+	"net"            // for the admin listener
+	"net/http"       // for HTTP server & handlers
+	"net/http/pprof" // for runtime profiling handlers
+	"strings"        // for header parsing
+	"time"           // for server timeouts
+)
+
+// admin config (admin server is off unless an address is given)
+var adminAddr = flags.String("admin-addr", "", "listen address for the admin server, e.g. 127.0.0.1:6060 (disabled when empty)")
+var adminToken = flags.String("admin-token", "", "bearer token required on every admin endpoint (no auth when empty)")
+var enablePprof = flags.Bool("pprof", false, "expose net/http/pprof under /debug/pprof on the admin server")
+
+// router for admin-only endpoints, kept off the public port
+var adminMux = newAdminRouter()
+
+
+// requireAdmin rejects requests without the configured admin bearer token
+func requireAdmin(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// no token configured means the admin port itself is the perimeter
+		if *adminToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// expect "Authorization: Bearer <token>", or basic auth with the token as password so a
+		// browser can open the dashboard (any user name)
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if _, password, ok := r.BasicAuth(); ok {
+			got = password
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(*adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="todo-server admin", charset="UTF-8"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+
+// AdminHandler returns the admin endpoints behind -admin-token, for serving on a port of one's own
+func AdminHandler() http.Handler {
+	return requireAdmin(adminMux)
+}
+
+
+// registerPprof mounts the profiling handlers on the admin router
+func registerPprof() {
+
+	// index also serves named profiles like /debug/pprof/heap
+	adminMux.HandleFunc("/debug/pprof/", pprof.Index)
+	adminMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	adminMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	adminMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	adminMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+
+// startAdminServer serves the admin router on its own listener in the background.
+// returns nil when the admin server is disabled.
+func startAdminServer(ln net.Listener) *http.Server {
+
+	// nothing to do if admin server is disabled
+	if ln == nil {
+		return nil
+	}
+
+	// profiling is opt-in even on the admin port
+	if *enablePprof {
+		registerPprof()
+	}
+
+	srv := &http.Server{Handler: AdminHandler(), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Println("Admin server started on", ln.Addr())
+
+	go func() {
+		err := srv.Serve(ln)
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Println("admin server stopped:", err)
+		}
+	}()
+
+	return srv
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for errors
+	"math"          // for rounding
+	"net/http"      // for HTTP handlers
+	"slices"        // for tags in order
+	"strconv"       // for the window
+	"strings"       // for comparing tags
+	"time"          // for days and weekdays in the client's zone
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the hourly counts
+)
+
+// how work gets done over the last ?days=, from counters the store keeps as todos change: what
+// was created and completed, per day and per weekday in the client's time zone, and how long
+// todos stay open, for all of them and per tag. the store counts in UTC hours, so in a zone off
+// by a half hour a change near midnight can land on the day next to it
+
+// longest window, as far back as the store counts, and the window when not told
+const (
+	maxAnalyticsDays     = store.AnalyticsHours / 24
+	defaultAnalyticsDays = 30
+)
+
+// AnalyticsDay is what happened on one day
+type AnalyticsDay struct {
+	Date           string   `json:"date"` // 2006-01-02
+	Created        int      `json:"created"`
+	Completed      int      `json:"completed"`
+	CompletionRate *float64 `json:"completion_rate"` // completed / created, null when none were created
+}
+
+// AnalyticsWeekday is the window's total for one weekday
+type AnalyticsWeekday struct {
+	Weekday   string `json:"weekday"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// AnalyticsTag is the window's counts for the todos with one tag
+type AnalyticsTag struct {
+	Tag              string   `json:"tag"`
+	Created          int      `json:"created"`
+	Completed        int      `json:"completed"`
+	CompletionRate   *float64 `json:"completion_rate"`
+	AverageHoursToDo *float64 `json:"average_hours_to_do"`
+	Open             int64    `json:"open"` // open now
+}
+
+// Analytics is the body of GET /analytics
+type Analytics struct {
+	From             string             `json:"from"` // first day of the window
+	To               string             `json:"to"`   // today
+	Created          int                `json:"created"`
+	Completed        int                `json:"completed"`
+	CompletionRate   *float64           `json:"completion_rate"`
+	AverageHoursToDo *float64           `json:"average_hours_to_do"` // creation to completion, over the window's completions
+	Open             int                `json:"open"`                // todos open now
+	AverageOpenHours *float64           `json:"average_open_hours"`  // their average age
+	BusiestWeekday   string             `json:"busiest_weekday,omitempty"`
+	Days             []AnalyticsDay     `json:"days"`     // oldest first, every day of the window
+	Weekdays         []AnalyticsWeekday `json:"weekdays"` // Monday first
+	Tags             []AnalyticsTag     `json:"tags"`     // by tag, the ones with open todos or anything in the window
+}
+
+
+// ratio returns a / b rounded to 3 places, nil when b is 0
+func ratio(a, b float64) *float64 {
+	if b == 0 {
+		return nil
+	}
+	r := math.Round(a/b*1000) / 1000
+	return &r
+}
+
+
+// GET /analytics?days=30 returns completion counts and rates per day and weekday, and todo ages
+func analyticsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	days := defaultAnalyticsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			writeError(w, r, model.Invalid("days", fmt.Sprintf("must be between 1 and %d", maxAnalyticsDays)))
+			return
+		}
+		days = n
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the window is whole days in the client's zone, today the last
+	now := todoStore.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from := today.AddDate(0, 0, 1-days)
+
+	report := Analytics{From: from.Format("2006-01-02"), To: today.Format("2006-01-02")}
+	index := map[string]int{}
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		index[day.Format("2006-01-02")] = len(report.Days)
+		report.Days = append(report.Days, AnalyticsDay{Date: day.Format("2006-01-02")})
+	}
+	weekdays := [7]AnalyticsWeekday{}
+	var toDo time.Duration
+	for _, hour := range storeFor(r).Activity(from, now) {
+		local := hour.Hour.In(loc)
+		i, ok := index[local.Format("2006-01-02")]
+		if !ok {
+			continue // the start of from's first hour, in a zone not on a whole hour
+		}
+		report.Days[i].Created += hour.Created
+		report.Days[i].Completed += hour.Completed
+		weekday := &weekdays[(int(local.Weekday())+6)%7]
+		weekday.Created += hour.Created
+		weekday.Completed += hour.Completed
+		report.Created += hour.Created
+		report.Completed += hour.Completed
+		toDo += hour.TimeToDo
+	}
+	for i := range report.Days {
+		report.Days[i].CompletionRate = ratio(float64(report.Days[i].Completed), float64(report.Days[i].Created))
+	}
+
+	report.Weekdays = make([]AnalyticsWeekday, 7)
+	busiest := 0
+	for i := range weekdays {
+		weekdays[i].Weekday = time.Weekday((i + 1) % 7).String()
+		report.Weekdays[i] = weekdays[i]
+		if weekdays[i].Completed > busiest {
+			busiest, report.BusiestWeekday = weekdays[i].Completed, weekdays[i].Weekday
+		}
+	}
+
+	report.CompletionRate = ratio(float64(report.Completed), float64(report.Created))
+	report.AverageHoursToDo = ratio(toDo.Hours(), float64(report.Completed))
+	ages := storeFor(r).OpenAges(now)
+	report.Open = ages.Open
+	if ages.Open > 0 {
+		report.AverageOpenHours = ratio(ages.AverageAge.Hours(), 1)
+	}
+	report.Tags = analyticsTags(storeFor(r), from, now)
+
+	json.NewEncoder(w).Encode(report)
+}
+
+
+// analyticsTags is each tag's creations and completions from from to now, and its open todos
+func analyticsTags(st *store.Store, from, now time.Time) []AnalyticsTag {
+
+	activity := st.TagActivity(from, now)
+	counts := st.TagCounts()
+	tags := []AnalyticsTag{}
+	for tag, a := range activity {
+		tags = append(tags, AnalyticsTag{
+			Tag:              tag,
+			Created:          a.Created,
+			Completed:        a.Completed,
+			CompletionRate:   ratio(float64(a.Completed), float64(a.Created)),
+			AverageHoursToDo: ratio(a.TimeToDo.Hours(), float64(a.Completed)),
+			Open:             max(counts[tag].Total-counts[tag].Done, 0),
+		})
+	}
+	for tag, c := range counts {
+		if _, ok := activity[tag]; !ok && c.Total > c.Done {
+			tags = append(tags, AnalyticsTag{Tag: tag, Open: c.Total - c.Done})
+		}
+	}
+	slices.SortFunc(tags, func(a, b AnalyticsTag) int { return strings.Compare(a.Tag, b.Tag) })
+	return tags
+}
@@ -0,0 +1,48 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"testing"  // for the tests
+	"time"     // for moving the clock
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// analytics tests: the breakdown by tag, counted with the tags a todo has when it's created or done
+
+
+// TestAnalyticsTags checks each tag's creations, completions and open todos, a retagged todo
+// counting for its new tag when it's done
+func TestAnalyticsTags(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+	s.Clock.Advance(3 * time.Hour)
+	s.Put("/todos/update?id=1", nil).ExpectStatus(http.StatusOK)
+	s.Put("/todos/update?id=3", `{"tags":["home"]}`).ExpectStatus(http.StatusOK)
+	s.Clock.Advance(time.Hour)
+	s.Put("/todos/update?id=3", nil).ExpectStatus(http.StatusOK)
+
+	s.Get("/analytics?days=1").ExpectStatus(http.StatusOK).ExpectJSON(`{"from":"2030-01-01","to":"2030-01-01","created":4,"completed":2,
+		"completion_rate":0.5,"average_hours_to_do":3.5,"open":1,"average_open_hours":4,"busiest_weekday":"Tuesday",
+		"days":[{"date":"2030-01-01","created":4,"completed":2,"completion_rate":0.5}],
+		"weekdays":[{"weekday":"Monday","created":0,"completed":0},{"weekday":"Tuesday","created":4,"completed":2},
+			{"weekday":"Wednesday","created":0,"completed":0},{"weekday":"Thursday","created":0,"completed":0},
+			{"weekday":"Friday","created":0,"completed":0},{"weekday":"Saturday","created":0,"completed":0},
+			{"weekday":"Sunday","created":0,"completed":0}],
+		"tags":[
+			{"tag":"bakery","created":1,"completed":0,"completion_rate":0,"average_hours_to_do":null,"open":0},
+			{"tag":"dairy","created":1,"completed":1,"completion_rate":1,"average_hours_to_do":3,"open":0},
+			{"tag":"fridge","created":1,"completed":1,"completion_rate":1,"average_hours_to_do":3,"open":0},
+			{"tag":"home","created":1,"completed":1,"completion_rate":1,"average_hours_to_do":4,"open":1}]}`)
+
+	// the next day only the open todos are left
+	s.Clock.Advance(24 * time.Hour)
+	var next struct {
+		Tags []map[string]any `json:"tags"`
+	}
+	s.Get("/analytics?days=1").Decode(&next)
+	if len(next.Tags) != 1 || next.Tags[0]["tag"] != "home" || next.Tags[0]["created"] != 0.0 || next.Tags[0]["open"] != 1.0 {
+		t.Errorf("tags the next day: %v", next.Tags)
+	}
+}
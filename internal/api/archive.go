@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"       // for store calls outside a request
+	"encoding/json" // for archive lines
+	"fmt"           // for printing logs to terminal
+	"os"            // for the archive file
+	"sync"          // for guarding the archive file
+	"time"          // for the backstop check
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// memory cap (off unless a limit is given)
+var maxTodos = flags.Int("max-todos", 0, "maximum todos kept in memory; beyond it the oldest completed ones are archived (0 = unlimited)")
+var archiveFile = flags.String("archive-file", "", "NDJSON file evicted todos are appended to (evicted todos are dropped when empty)")
+
+// evicting down to 90% of the cap, not just below it, so a busy instance doesn't scan on every create
+const evictHeadroom = 10 // percent of -max-todos
+
+// how often the cap is checked even without store events (a subscriber may miss events when busy)
+const evictCheckEvery = time.Minute
+
+// open archive, appended to by the evictor only
+var archive *os.File
+var archiveMu sync.Mutex
+
+
+// archiveTodo appends one todo to the archive, in the format POST /todos/import reads back
+func archiveTodo(todo model.Todo) error {
+
+	// no archive: eviction just forgets
+	if archive == nil {
+		return nil
+	}
+
+	line, _ := json.Marshal(todo)
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	if _, err := archive.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	// the todo leaves memory right after this, so the line must be on disk
+	return archive.Sync()
+}
+
+
+// evictTodo archives and removes a todo if it is still done (it may have been reopened since we looked)
+func evictTodo(ctx context.Context, id int) bool {
+
+	// a failed archive write keeps the todo in memory
+	evicted, err := todoStore.Evict(ctx, id, archiveTodo)
+	if err != nil {
+		fmt.Println("archive failed, keeping todo", id, "in memory:", err)
+	}
+	return evicted
+}
+
+
+// purgeCompleted evicts every completed todo now (archived like -max-todos evictions), returning how many
+func purgeCompleted(ctx context.Context) int {
+
+	done := true
+	purged := 0
+	for _, todo := range todoStore.Find(ctx, store.Filter{Done: &done}) {
+		if evictTodo(ctx, todo.ID) {
+			purged++
+		}
+	}
+	return purged
+}
+
+
+// enforceMaxTodos evicts the oldest completed todos (lowest ids) while over the cap
+func enforceMaxTodos() {
+
+	count := todoStore.Count()
+	if count <= *maxTodos {
+		return
+	}
+	target := *maxTodos - *maxTodos*evictHeadroom/100
+
+	ctx := context.Background()
+	done := true
+	evicted := 0
+	for _, todo := range todoStore.Find(ctx, store.Filter{Done: &done}) {
+		if count-evicted <= target {
+			break
+		}
+		if evictTodo(ctx, todo.ID) {
+			evicted++
+		}
+	}
+
+	// open todos are never evicted, so the cap can be exceeded when too few are done
+	if count-evicted > *maxTodos {
+		fmt.Println("over -max-todos with", count-evicted, "todos, not enough completed ones to evict")
+	}
+	if evicted > 0 {
+		fmt.Println("evicted", evicted, "completed todos (over -max-todos)")
+	}
+}
+
+
+// openArchive opens -archive-file for appending, if it's set and not open yet
+func openArchive() {
+
+	if *archiveFile == "" || archive != nil {
+		return
+	}
+	f, err := os.OpenFile(*archiveFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		fmt.Println("cannot open archive:", err)
+		os.Exit(1)
+	}
+	archive = f
+}
+
+
+// startEvictor enforces -max-todos after creates and completions, and once a minute
+func startEvictor() {
+
+	// nothing to enforce
+	if *maxTodos <= 0 {
+		return
+	}
+
+	openArchive()
+
+	events := todoStore.Events().Subscribe()
+	go func() {
+		tick := time.Tick(evictCheckEvery)
+		for {
+			select {
+			case e := <-events:
+				// only creates add todos, only completions make them evictable
+				if (e.Type == model.EventCreated || (e.Type == model.EventUpdated && e.Todo.Done)) && scheduling() {
+					enforceMaxTodos()
+				}
+			case <-tick:
+				if scheduling() {
+					enforceMaxTodos()
+				}
+			}
+		}
+	}()
+}
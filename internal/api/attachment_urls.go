@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crypto/hmac"   // for URL signatures
+	"crypto/sha256" // for URL signatures
+	"encoding/hex"  // for signature encoding
+	"encoding/json" // for JSON responses
+	"fmt"           // for the signed string and errors
+	"net/http"      // for HTTP handlers
+	"net/url"       // for escaping the file name
+	"strconv"       // for ids and expiry times
+	"strings"       // for the link's path
+	"time"          // for expiry
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for errors
+)
+
+// signed, expiring download links to attachments, for fetching one from somewhere that shouldn't be
+// given anything else: an <img> tag, a CDN pulling from this origin, a mail. like the feed tokens
+// the signature is the credential, an HMAC over the attachment and the expiry time, so a link lets
+// through the -allow-cidrs perimeter too, until it expires or the attachment is deleted
+var (
+	attachmentURLSecret = flags.String("attachment-url-secret", "", "secret used to sign attachment download URLs (random per process when empty)")
+	attachmentURLTTL    = flags.Duration("attachment-url-ttl", 15*time.Minute, "how long a signed attachment URL works, unless asked for another time")
+)
+
+// longest a signed URL can be asked to work, as with S3's presigned URLs
+const maxAttachmentURLTTL = 7 * 24 * time.Hour
+
+// effective signing key, set by loadAttachmentURLSecret
+var attachmentURLKey []byte
+
+// AttachmentURL is the body of POST /todos/{id}/attachments/{attachment}/url
+type AttachmentURL struct {
+	URL       string    `json:"url"` // path and query, relative to this server
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+
+// loadAttachmentURLSecret picks the attachment URL signing key
+func loadAttachmentURLSecret() {
+	if *attachmentURLSecret != "" {
+		attachmentURLKey = []byte(*attachmentURLSecret)
+		return
+	}
+	attachmentURLKey = []byte(randomHex(32))
+}
+
+
+// attachmentSignature signs an attachment and an expiry time (unix seconds); the blob key is in
+// it, so a link can't outlive its attachment into another one given the same id
+func attachmentSignature(a Attachment, expires int64) string {
+	mac := hmac.New(sha256.New, attachmentURLKey)
+	fmt.Fprintf(mac, "attachment:%d:%s:%d", a.ID, a.key, expires)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+
+// signedAttachment returns the attachment r's /attachments/{attachment}/{name} link is for, if its
+// signature holds and it hasn't expired, and when it expires
+func signedAttachment(r *http.Request) (Attachment, time.Time, bool) {
+
+	rest, found := strings.CutPrefix(r.URL.Path, "/attachments/")
+	if !found {
+		return Attachment{}, time.Time{}, false
+	}
+	idPart, _, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return Attachment{}, time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		return Attachment{}, time.Time{}, false
+	}
+	attachmentsMu.Lock()
+	a, found := attachments[id]
+	attachmentsMu.Unlock()
+	if !found || !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(attachmentSignature(a, unix))) {
+		return Attachment{}, time.Time{}, false
+	}
+	expires := time.Unix(unix, 0)
+	return a, expires, time.Now().Before(expires)
+}
+
+
+// POST /todos/{id}/attachments/{attachment}/url?ttl=1h mints a signed download link (ttl defaults
+// to -attachment-url-ttl, 7 days at most)
+func attachmentURLHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only POST
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	a, err := pathAttachment(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ttl := *attachmentURLTTL
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl <= 0 || ttl > maxAttachmentURLTTL {
+			writeError(w, r, model.Invalid("ttl", "must be a duration up to 168h, e.g. 15m"))
+			return
+		}
+	}
+
+	// expiry is wall clock time, whatever the store's clock says
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	link := fmt.Sprintf("/attachments/%d/%s?expires=%d&signature=%s", a.ID, url.PathEscape(a.Name), expires.Unix(), attachmentSignature(a, expires.Unix()))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AttachmentURL{URL: link, ExpiresAt: expires.UTC()})
+}
+
+
+// GET /attachments/{attachment}/{name}?expires=...&signature=... downloads the file with nothing
+// but the link; the name is only there for whoever saves it
+func signedAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// deleted, expired and forged links look the same
+	a, expires, ok := signedAttachment(r)
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// a CDN may keep it as long as the link works, no longer
+	serveAttachment(w, r, a, "public, max-age="+strconv.Itoa(int(time.Until(expires).Seconds())))
+}
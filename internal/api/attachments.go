@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"       // for cleaning up outside a request
+	"crypto/sha256" // for the checksum of an upload
+	"encoding/hex"  // for checksums
+	"encoding/json" // for JSON responses
+	"errors"        // for oversized bodies and missing blobs
+	"fmt"           // for blob keys and not found errors
+	"io"            // for reading uploads and streaming downloads
+	"mime"          // for content types and Content-Disposition
+	"net/http"      // for HTTP handlers
+	"path"          // for the base of a file name
+	"sort"          // for stable list order
+	"strconv"       // for ids in the path
+	"strings"       // for attachment paths and names
+	"sync"          // for guarding the attachments
+	"time"          // for timestamps
+	"unicode"       // for control characters in names
+	"unicode/utf8"  // for cutting long names between runes
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/blob"  // for where the bytes go
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for events and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the caller's tenant
+)
+
+// files attached to a todo. what's known about them lives next to the store, like blockers; the
+// bytes go to the blob store -blob-store names, memory by default, or a directory or an S3 bucket
+// when attachments have to outlive the process or be shared between replicas. uploads are the
+// raw request body, downloads are always served as attachments so a page can't run off this origin
+var (
+	blobStore      = flags.String("blob-store", "memory", "where attachment bytes are kept: memory, file:///dir or s3://bucket/prefix?endpoint=https://...&region=...")
+	blobAccessKey  = flags.String("blob-s3-access-key", "", "access key for an s3:// blob store (default $AWS_ACCESS_KEY_ID)")
+	blobSecretKey  = flags.String("blob-s3-secret-key", "", "secret key for an s3:// blob store (default $AWS_SECRET_ACCESS_KEY)")
+	attachmentSize = flags.Int("attachment-max-size", 10<<20, "largest attachment accepted, in bytes")
+)
+
+// most attachments a todo may have, and the longest file name kept
+const (
+	maxAttachments       = 20
+	maxAttachmentNameLen = 255
+)
+
+// Attachment is a file attached to a todo
+type Attachment struct {
+	ID          int       `json:"id"`
+	TodoID      int       `json:"todo_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+	key         string    // in the blob store
+}
+
+// attachments by id, same in-memory pattern as the saved filters; the blob store from -blob-store
+var attachments = make(map[int]Attachment)
+var nextAttachmentID = 1
+var attachmentsMu sync.Mutex
+var blobs blob.Store = blob.NewMemory()
+
+
+// loadBlobStore opens the -blob-store
+func loadBlobStore() error {
+	store, err := blob.Open(*blobStore, blob.Credentials{AccessKey: *blobAccessKey, SecretKey: *blobSecretKey})
+	if err != nil {
+		return fmt.Errorf("-blob-store: %w", err)
+	}
+	blobs = store
+	return nil
+}
+
+
+// isAttachmentPath reports whether path is under /todos/{id}/attachments or /attachments, whose
+// bodies are files and mustn't be transcoded like the JSON ones
+func isAttachmentPath(p string) bool {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	return parts[0] == "attachments" || (len(parts) >= 3 && parts[0] == "todos" && parts[2] == "attachments")
+}
+
+
+// attachmentName cleans a client's file name: no directories, no control characters, not too long
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" {
+		name = ""
+	}
+	for len(name) > maxAttachmentNameLen {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return strings.TrimSpace(name)
+}
+
+
+// todoAttachments returns the todo's attachments, oldest first
+func todoAttachments(todoID int) []Attachment {
+	attachmentsMu.Lock()
+	defer attachmentsMu.Unlock()
+
+	list := []Attachment{}
+	for _, a := range attachments {
+		if a.TodoID == todoID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+
+// startAttachmentCleanup deletes the attachments of todos evicted or erased, and their bytes
+func startAttachmentCleanup() {
+
+	events := todoStore.Events().Subscribe()
+	go func() {
+		for e := range events {
+			// trashed todos keep theirs until they're purged
+			if e.Type != model.EventDeleted || todoStore.InTrash(e.Todo.ID) {
+				continue
+			}
+			dropAttachments(context.Background(), e.Todo.ID)
+		}
+	}()
+}
+
+
+// dropAttachments deletes the attachments of one of ctx's tenant's todos, and their bytes
+// (tenants have none, see tenants.go)
+func dropAttachments(ctx context.Context, todoID int) {
+	if store.TenantFrom(ctx) != "" {
+		return
+	}
+	for _, a := range todoAttachments(todoID) {
+		attachmentsMu.Lock()
+		delete(attachments, a.ID)
+		attachmentsMu.Unlock()
+		if err := blobs.Delete(context.Background(), a.key); err != nil {
+			fmt.Println("attachment cleanup:", err)
+		}
+	}
+}
+
+
+// GET lists the {id} todo's attachments, POST uploads one: the body is the file, its type the
+// Content-Type and its name ?name=
+func attachmentsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := todoPathID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(todoAttachments(id))
+	case http.MethodPost:
+		uploadAttachment(w, r, id)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// uploadAttachment stores the request body as a new attachment of the todo
+func uploadAttachment(w http.ResponseWriter, r *http.Request, id int) {
+
+	name := attachmentName(r.URL.Query().Get("name"))
+	if name == "" {
+		writeError(w, r, model.Invalid("name", "is required"))
+		return
+	}
+	contentType := "application/octet-stream"
+	if v := r.Header.Get("Content-Type"); v != "" {
+		mediaType, params, err := mime.ParseMediaType(v)
+		if err != nil {
+			writeError(w, r, model.Invalid("Content-Type", "is not a media type"))
+			return
+		}
+		contentType = mime.FormatMediaType(mediaType, params)
+	}
+	if len(todoAttachments(id)) >= maxAttachments {
+		writeError(w, r, model.Invalid("id", fmt.Sprintf("todo %d already has %d attachments", id, maxAttachments)))
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(*attachmentSize)))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("attachments are at most %d bytes", *attachmentSize)})
+		return
+	}
+	if err != nil {
+		writeError(w, r, model.Invalid("body", "could not be read"))
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("todos/%d/%s", id, randomHex(16))
+	if err := blobs.Put(r.Context(), key, data, contentType); err != nil {
+		writeError(w, r, fmt.Errorf("storing attachment: %w", err))
+		return
+	}
+
+	attachmentsMu.Lock()
+	a := Attachment{
+		ID:          nextAttachmentID,
+		TodoID:      id,
+		Name:        name,
+		ContentType: contentType,
+		Size:        len(data),
+		SHA256:      hex.EncodeToString(sum[:]),
+		CreatedAt:   todoStore.Now(),
+		key:         key,
+	}
+	attachments[a.ID] = a
+	nextAttachmentID++
+	attachmentsMu.Unlock()
+
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d/attachments/%d", id, a.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+
+// pathAttachment reads {id} and {attachment} of the path and returns the attachment, if it's the todo's
+func pathAttachment(r *http.Request) (Attachment, error) {
+
+	id, err := todoPathID(r, "id")
+	if err != nil {
+		return Attachment{}, err
+	}
+	attID, err := strconv.Atoi(r.PathValue("attachment"))
+	if err != nil {
+		return Attachment{}, model.Invalid("attachment", "must be an integer")
+	}
+	attachmentsMu.Lock()
+	a, ok := attachments[attID]
+	attachmentsMu.Unlock()
+	if !ok || a.TodoID != id {
+		return Attachment{}, fmt.Errorf("attachment %d of todo %d: %w", attID, id, model.ErrNotFound)
+	}
+	return a, nil
+}
+
+
+// GET /todos/{id}/attachments/{attachment} downloads the file, DELETE removes it
+func attachmentHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	a, err := pathAttachment(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		attachmentsMu.Lock()
+		delete(attachments, a.ID)
+		attachmentsMu.Unlock()
+		if err := blobs.Delete(r.Context(), a.key); err != nil {
+			fmt.Println("deleting attachment:", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	serveAttachment(w, r, a, "private")
+}
+
+
+// serveAttachment streams a's bytes from the blob store, cacheable as cacheControl says
+func serveAttachment(w http.ResponseWriter, r *http.Request, a Attachment, cacheControl string) {
+
+	body, err := blobs.Get(r.Context(), a.key)
+	if errors.Is(err, blob.ErrNotFound) {
+		err = fmt.Errorf("the bytes of attachment %d are gone from the blob store: %w", a.ID, model.ErrNotFound)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(a.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("ETag", `"`+a.SHA256+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	if match := r.Header.Get("If-None-Match"); match == `"`+a.SHA256+`"` {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		fmt.Println("serving attachment:", err)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors
+	"net/http"      // for HTTP handlers
+	"net/url"       // for the limits as key=value pairs
+	"slices"        // for column names
+	"sort"          // for column order
+	"strconv"       // for limits
+	"strings"       // for the flag syntax
+	"time"          // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+)
+
+// a kanban board in one response, so a board UI doesn't make one filtered query per column.
+// todos are only open or done, so the open ones are split by their due date, in the client's
+// time zone: no due date (backlog), due after today (scheduled), due later today, or past due
+var boardWIPFlag = flags.String("board-wip", "", "work-in-progress limits of GET /todos/board columns, e.g. today=5,overdue=3")
+
+// board columns, left to right
+var boardColumns = []string{"backlog", "scheduled", "today", "overdue", "done"}
+
+// BoardColumn is one column of GET /todos/board
+type BoardColumn struct {
+	Name      string       `json:"name"`
+	Count     int          `json:"count"`               // todos in the column, also ones beyond ?limit=
+	WIPLimit  int          `json:"wip_limit,omitempty"` // from -board-wip, 0 = none
+	OverLimit bool         `json:"over_limit,omitempty"`
+	Todos     []model.Todo `json:"todos"`
+}
+
+// Board is the body of GET /todos/board
+type Board struct {
+	Columns []BoardColumn `json:"columns"`
+}
+
+// most todos a column lists, and how many when not told
+const (
+	maxBoardLimit     = 1000
+	defaultBoardLimit = 100
+)
+
+// limits from -board-wip, set up by loadBoardWIP
+var boardWIP = map[string]int{}
+
+
+// loadBoardWIP applies -board-wip
+func loadBoardWIP() error {
+
+	values, err := url.ParseQuery(strings.ReplaceAll(*boardWIPFlag, ",", "&"))
+	if err != nil {
+		return fmt.Errorf("-board-wip: %w", err)
+	}
+	limits := map[string]int{}
+	for name, v := range values {
+		if !slices.Contains(boardColumns, name) {
+			return fmt.Errorf("-board-wip: unknown column %q (%s)", name, strings.Join(boardColumns, ", "))
+		}
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("-board-wip: %s=%s: not a limit of at least 1", name, v[0])
+		}
+		limits[name] = n
+	}
+	boardWIP = limits
+	return nil
+}
+
+
+// boardColumn is the column an open todo goes in, as of now; tomorrow is when today ends
+func boardColumn(todo model.Todo, now, tomorrow time.Time) string {
+	switch {
+	case todo.Done:
+		return "done"
+	case todo.Due == nil:
+		return "backlog"
+	case !todo.Due.After(now):
+		return "overdue"
+	case todo.Due.Before(tomorrow):
+		return "today"
+	}
+	return "scheduled"
+}
+
+
+// GET /todos/board?limit=100 returns every todo in its column: backlog oldest first, the due
+// columns soonest due first, done most recently changed first
+func boardHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := defaultBoardLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxBoardLimit {
+			writeError(w, r, model.Invalid("limit", fmt.Sprintf("must be between 1 and %d", maxBoardLimit)))
+			return
+		}
+		limit = n
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	now := todoStore.Now()
+	_, tomorrow, _ := dueRange("today", now, loc)
+
+	// List is by id, so the backlog is oldest first already
+	byColumn := map[string][]model.Todo{}
+	for _, todo := range todoStore.List(r.Context()) {
+		column := boardColumn(todo, now, *tomorrow)
+		byColumn[column] = append(byColumn[column], todo)
+	}
+	for _, column := range []string{"scheduled", "today", "overdue"} {
+		todos := byColumn[column]
+		sort.SliceStable(todos, func(i, j int) bool { return todos[i].Due.Before(*todos[j].Due) })
+	}
+	done := byColumn["done"]
+	modified := make(map[int]time.Time, len(done))
+	for _, todo := range done {
+		modified[todo.ID], _ = storeFor(r).TodoModified(todo.ID)
+	}
+	sort.SliceStable(done, func(i, j int) bool { return modified[done[i].ID].After(modified[done[j].ID]) })
+
+	board := Board{Columns: make([]BoardColumn, 0, len(boardColumns))}
+	for _, name := range boardColumns {
+		todos := byColumn[name]
+		column := BoardColumn{Name: name, Count: len(todos), WIPLimit: boardWIP[name], Todos: todos[:min(len(todos), limit)]}
+		column.OverLimit = column.WIPLimit > 0 && column.Count > column.WIPLimit
+		if column.Todos == nil {
+			column.Todos = []model.Todo{}
+		}
+		board.Columns = append(board.Columns, column)
+	}
+	json.NewEncoder(w).Encode(board)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"time" // for the cool-down
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the breaker
+)
+
+// a circuit breaker in front of the store's backend, a cluster's log or Redis (a lone server's
+// store has none to fail): after -breaker-failures failed changes in a row it answers every
+// change with a 503 straight away, Retry-After saying when the cool-down ends, then lets one
+// through to see whether the backend is back. reads are answered from memory all along
+var breakerFailures = flags.Int("breaker-failures", 5, "failed changes in a row after which the store's backend is left alone for -breaker-cooldown (0 turns the breaker off)")
+var breakerCooldown = flags.Duration("breaker-cooldown", 10*time.Second, "how long changes fail fast once the breaker opens, before one is tried again")
+
+// the breaker, when the store has a backend
+var storeBreaker *store.Breaker
+
+
+// guarded puts the breaker in front of the store's backend, with retries behind it (so a change
+// that took a few attempts counts once)
+func guarded(r store.Replicator) store.Replicator {
+	r = retrying(r)
+	if *breakerFailures <= 0 {
+		return r
+	}
+	storeBreaker = store.NewBreaker(r, *breakerFailures, *breakerCooldown)
+	return storeBreaker
+}
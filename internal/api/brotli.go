@@ -0,0 +1,649 @@
+package api
+
+import (
+	"io"   // for the compressed output
+	"sort" // for prefix code construction
+)
+
+// brotli (RFC 7932) encoder for response compression, there's none in the standard library.
+// it keeps to the parts of the format a small encoder needs: LZ77 over a 256 KiB window with
+// hash chains, and one literal, one insert-and-copy and one distance prefix code per meta-block
+// (no block splitting, context modeling or static dictionary), so it trades some ratio against
+// the reference encoder for staying short. quality 0-11 sets how hard it looks for matches.
+
+// window: distances up to (1 << brotliWindowBits) - 16
+const brotliWindowBits = 18
+const brotliMaxDistance = 1<<brotliWindowBits - 16
+
+// input is compressed in meta-blocks of up to this many bytes (Flush ends one early)
+const brotliBlockSize = 1 << 17
+
+// matches shorter than this are written as literals
+const brotliMinMatch = 4
+
+// match candidates followed per position, by quality
+var brotliChainDepth = [12]int{1, 2, 4, 8, 16, 24, 32, 64, 128, 256, 512, 1024}
+
+// from quality 5 a match is put off by one byte when the next position has a longer one
+const brotliLazyQuality = 5
+
+// insert and copy length codes: base value and number of extra bits (RFC 7932 section 5)
+var brotliInsertBase = [24]int{0, 1, 2, 3, 4, 5, 6, 8, 10, 14, 18, 26, 34, 50, 66, 98, 130, 194, 322, 578, 1090, 2114, 6210, 22594}
+var brotliInsertExtra = [24]uint{0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 7, 8, 9, 10, 12, 14, 24}
+var brotliCopyBase = [24]int{2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 14, 18, 22, 30, 38, 54, 70, 102, 134, 198, 326, 582, 1094, 2118}
+var brotliCopyExtra = [24]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 7, 8, 9, 10, 24}
+
+// first insert-and-copy symbol for [insert code / 8][copy code / 8], the cells with an explicit distance
+var brotliCommandCell = [3][3]int{{128, 192, 384}, {256, 320, 512}, {448, 576, 640}}
+
+// alphabet sizes (distances: 16 + NDIRECT 0 + 48 << NPOSTFIX 0)
+const (
+	brotliLiteralAlphabet  = 256
+	brotliCommandAlphabet  = 704
+	brotliDistanceAlphabet = 64
+)
+
+// order code length code lengths are written in, and the fixed code they're written with (RFC 7932 section 3.5)
+var brotliCodeLengthOrder = [18]int{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+var brotliCodeLengthCodeBits = [6]uint64{0, 7, 3, 2, 1, 15}
+var brotliCodeLengthCodeSize = [6]uint{2, 4, 3, 2, 2, 4}
+
+// hash table size for match finding
+const brotliHashBits = 16
+
+// bitWriter packs bits least significant first, as brotli reads them
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// bitMark is a bitWriter position to roll back to
+type bitMark struct {
+	len   int
+	acc   uint64
+	nbits uint
+}
+
+// brotliCommand copies copyLen bytes from distance back after inserting insertLen literals
+// (copyLen 0: literals only, the end of a meta-block)
+type brotliCommand struct {
+	insertLen int
+	copyLen   int
+	distance  int
+}
+
+// prefixCode is a canonical prefix code: per symbol, the bit-reversed code and its length
+type prefixCode struct {
+	bits []uint64
+	size []uint8
+}
+
+// brotliWriter compresses everything written to it into a brotli stream on w
+type brotliWriter struct {
+	w       io.Writer
+	quality int
+	bw      bitWriter
+	hist    []byte // already compressed input, within the window
+	pending []byte // input not compressed yet
+	started bool   // stream header written
+	err     error
+}
+
+
+// newBrotliWriter starts a brotli stream on w at quality 0-11
+func newBrotliWriter(w io.Writer, quality int) *brotliWriter {
+	return &brotliWriter{w: w, quality: max(0, min(quality, 11))}
+}
+
+
+// writeBits appends the n low bits of v (n <= 32)
+func (b *bitWriter) writeBits(n uint, v uint64) {
+	b.acc |= v << b.nbits
+	b.nbits += n
+	for b.nbits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nbits -= 8
+	}
+}
+
+
+// alignByte pads with zero bits to the next byte boundary
+func (b *bitWriter) alignByte() {
+	if b.nbits > 0 {
+		b.writeBits(8-b.nbits, 0)
+	}
+}
+
+
+// mark remembers the current position
+func (b *bitWriter) mark() bitMark {
+	return bitMark{len: len(b.buf), acc: b.acc, nbits: b.nbits}
+}
+
+
+// reset goes back to a marked position, dropping everything written since
+func (b *bitWriter) reset(m bitMark) {
+	b.buf, b.acc, b.nbits = b.buf[:m.len], m.acc, m.nbits
+}
+
+
+// bitsSince counts bits written since a mark
+func (b *bitWriter) bitsSince(m bitMark) int {
+	return (len(b.buf)-m.len)*8 + int(b.nbits) - int(m.nbits)
+}
+
+
+// Write buffers p and compresses whole meta-blocks as they fill up
+func (z *brotliWriter) Write(p []byte) (int, error) {
+
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		take := min(len(p), brotliBlockSize-len(z.pending))
+		z.pending = append(z.pending, p[:take]...)
+		p = p[take:]
+		if len(z.pending) == brotliBlockSize {
+			z.compressPending()
+			if err := z.emit(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+
+// Flush compresses what's buffered and pads the stream to a byte boundary,
+// so the decoder can hand out everything written so far
+func (z *brotliWriter) Flush() error {
+
+	if z.err != nil {
+		return z.err
+	}
+	z.compressPending()
+
+	// an empty metadata meta-block: ISLAST 0, MNIBBLES 0 (code 3), reserved 0, MSKIPBYTES 0, then padding
+	z.bw.writeBits(1, 0)
+	z.bw.writeBits(2, 3)
+	z.bw.writeBits(1, 0)
+	z.bw.writeBits(2, 0)
+	z.bw.alignByte()
+	return z.emit()
+}
+
+
+// Close compresses what's buffered and ends the stream (it doesn't close w)
+func (z *brotliWriter) Close() error {
+
+	if z.err != nil {
+		return z.err
+	}
+	z.compressPending()
+
+	// ISLAST 1, ISLASTEMPTY 1
+	z.header()
+	z.bw.writeBits(2, 3)
+	z.bw.alignByte()
+	if err := z.emit(); err != nil {
+		return err
+	}
+	z.err = io.ErrClosedPipe
+	return nil
+}
+
+
+// header writes the stream header once: WBITS
+func (z *brotliWriter) header() {
+	if !z.started {
+		z.bw.writeBits(1, 1)
+		z.bw.writeBits(3, brotliWindowBits-17)
+		z.started = true
+	}
+}
+
+
+// emit writes out the whole bytes produced so far
+func (z *brotliWriter) emit() error {
+	if len(z.bw.buf) == 0 {
+		return nil
+	}
+	_, z.err = z.w.Write(z.bw.buf)
+	z.bw.buf = z.bw.buf[:0]
+	return z.err
+}
+
+
+// compressPending turns the buffered input into one meta-block and moves it to the history
+func (z *brotliWriter) compressPending() {
+
+	z.header()
+	if len(z.pending) == 0 {
+		return
+	}
+
+	data := append(z.hist, z.pending...)
+	start := len(z.hist)
+	commands := brotliFindMatches(data, start, z.quality)
+
+	// a meta-block that came out bigger than the input is written uncompressed instead
+	mark := z.bw.mark()
+	writeCompressedMetaBlock(&z.bw, data, start, commands)
+	if z.bw.bitsSince(mark) > 8*len(z.pending)+32 {
+		z.bw.reset(mark)
+		writeMetaBlockHeader(&z.bw, len(z.pending), true)
+		z.bw.alignByte()
+		z.bw.buf = append(z.bw.buf, z.pending...)
+	}
+
+	// keep the window's worth of input for matches in the next meta-block
+	if len(data) > brotliMaxDistance {
+		data = data[len(data)-brotliMaxDistance:]
+	}
+	z.hist = append(z.hist[:0], data...)
+	z.pending = z.pending[:0]
+}
+
+
+// brotliFindMatches splits data[start:] into commands, matching back into all of data
+func brotliFindMatches(data []byte, start, quality int) []brotliCommand {
+
+	head := make([]int32, 1<<brotliHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(data))
+	hash := func(i int) uint32 {
+		v := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		return (v * 0x1e35a7bd) >> (32 - brotliHashBits)
+	}
+	insert := func(i int) {
+		if i+brotliMinMatch <= len(data) {
+			h := hash(i)
+			prev[i], head[h] = head[h], int32(i)
+		}
+	}
+
+	// longest match for position i, following at most depth candidates
+	depth := brotliChainDepth[quality]
+	longest := func(i int) (length, distance int) {
+		if i+brotliMinMatch > len(data) {
+			return 0, 0
+		}
+		limit := len(data) - i
+		for m, tries := head[hash(i)], 0; m >= 0 && tries < depth; m, tries = prev[m], tries+1 {
+			d := i - int(m)
+			if d > brotliMaxDistance {
+				break
+			}
+			// cheap reject: the byte that would make it longer than the best so far
+			if length > 0 && data[int(m)+length] != data[i+length] {
+				continue
+			}
+			n := 0
+			for n < limit && data[int(m)+n] == data[i+n] {
+				n++
+			}
+			if n > length {
+				length, distance = n, d
+				if n == limit {
+					break
+				}
+			}
+		}
+		if length < brotliMinMatch {
+			return 0, 0
+		}
+		return length, distance
+	}
+
+	// the history only provides matches
+	for i := range start {
+		insert(i)
+	}
+
+	var commands []brotliCommand
+	literals := start
+	for i := start; i < len(data); {
+		length, distance := longest(i)
+		if length == 0 {
+			insert(i)
+			i++
+			continue
+		}
+
+		// lazy matching: a literal now may buy a longer match one byte later
+		from := i
+		if quality >= brotliLazyQuality {
+			insert(i)
+			from = i + 1
+			if next, nextDistance := longest(i + 1); next > length+1 {
+				i++
+				from = i
+				length, distance = next, nextDistance
+			}
+		}
+
+		commands = append(commands, brotliCommand{insertLen: i - literals, copyLen: length, distance: distance})
+		for j := from; j < i+length; j++ {
+			insert(j)
+		}
+		i += length
+		literals = i
+	}
+	if literals < len(data) {
+		commands = append(commands, brotliCommand{insertLen: len(data) - literals})
+	}
+	return commands
+}
+
+
+// lengthCode finds the code whose range holds n
+func lengthCode(base *[24]int, n int) int {
+	code := 0
+	for code+1 < len(base) && base[code+1] <= n {
+		code++
+	}
+	return code
+}
+
+
+// commandSymbol returns the insert-and-copy symbol and the insert and copy length codes for c
+func (c brotliCommand) symbol() (symbol, insertCode, copyCode int) {
+
+	insertCode = lengthCode(&brotliInsertBase, c.insertLen)
+	copyCode = lengthCode(&brotliCopyBase, max(c.copyLen, 2)) // copy length is never used for the trailing literals
+	symbol = brotliCommandCell[insertCode>>3][copyCode>>3] + (insertCode&7)<<3 | copyCode&7
+	return symbol, insertCode, copyCode
+}
+
+
+// distanceSymbol returns the distance code (with NPOSTFIX 0, NDIRECT 0) and its extra bits
+func distanceSymbol(distance int) (symbol int, extraBits uint, extra uint64) {
+
+	// distance + 3 is 1h followed by extraBits bits, h selecting the upper or lower half of the range
+	x := distance + 3
+	n := uint(0)
+	for x>>(n+2) != 0 {
+		n++
+	}
+	high := (x >> n) & 1
+	return 16 + 2*(int(n)-1) + high, n, uint64(x - (2+high)<<n)
+}
+
+
+// writeMetaBlockHeader writes ISLAST 0, MNIBBLES, MLEN - 1 and ISUNCOMPRESSED
+func writeMetaBlockHeader(bw *bitWriter, length int, uncompressed bool) {
+
+	// as few nibbles as fit (at least 4)
+	nibbles := uint(4)
+	for (length-1)>>(4*nibbles) != 0 {
+		nibbles++
+	}
+	bw.writeBits(1, 0)
+	bw.writeBits(2, uint64(nibbles-4))
+	bw.writeBits(4*nibbles, uint64(length-1))
+	if uncompressed {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(1, 0)
+	}
+}
+
+
+// writeCompressedMetaBlock writes data[start:] as one meta-block made of commands
+func writeCompressedMetaBlock(bw *bitWriter, data []byte, start int, commands []brotliCommand) {
+
+	// symbol counts for the three prefix codes
+	literalCounts := make([]int, brotliLiteralAlphabet)
+	commandCounts := make([]int, brotliCommandAlphabet)
+	distanceCounts := make([]int, brotliDistanceAlphabet)
+	pos := start
+	for _, c := range commands {
+		symbol, _, _ := c.symbol()
+		commandCounts[symbol]++
+		for _, b := range data[pos : pos+c.insertLen] {
+			literalCounts[b]++
+		}
+		if c.copyLen > 0 {
+			d, _, _ := distanceSymbol(c.distance)
+			distanceCounts[d]++
+		}
+		pos += c.insertLen + c.copyLen
+	}
+
+	writeMetaBlockHeader(bw, len(data)-start, false)
+
+	// one block type of each kind (NBLTYPESL, NBLTYPESI, NBLTYPESD = 1), NPOSTFIX 0, NDIRECT 0,
+	// literal context mode 0, one literal and one distance prefix code (NTREESL, NTREESD = 1)
+	bw.writeBits(3, 0)
+	bw.writeBits(6, 0)
+	bw.writeBits(2, 0)
+	bw.writeBits(2, 0)
+
+	literals := writePrefixCode(bw, literalCounts, 8)
+	insertAndCopy := writePrefixCode(bw, commandCounts, 10)
+	distances := writePrefixCode(bw, distanceCounts, 6)
+
+	pos = start
+	for _, c := range commands {
+		symbol, insertCode, copyCode := c.symbol()
+		bw.writeBits(uint(insertAndCopy.size[symbol]), insertAndCopy.bits[symbol])
+		bw.writeBits(brotliInsertExtra[insertCode], uint64(c.insertLen-brotliInsertBase[insertCode]))
+		bw.writeBits(brotliCopyExtra[copyCode], uint64(max(c.copyLen, 2)-brotliCopyBase[copyCode]))
+		for _, b := range data[pos : pos+c.insertLen] {
+			bw.writeBits(uint(literals.size[b]), literals.bits[b])
+		}
+
+		// the meta-block ends after the trailing literals, before any distance
+		if c.copyLen > 0 {
+			d, extraBits, extra := distanceSymbol(c.distance)
+			bw.writeBits(uint(distances.size[d]), distances.bits[d])
+			bw.writeBits(extraBits, extra)
+		}
+		pos += c.insertLen + c.copyLen
+	}
+}
+
+
+// huffmanLengths builds code lengths of at most limit bits for the symbols with nonzero counts
+func huffmanLengths(counts []int, limit int) []uint8 {
+
+	lengths := make([]uint8, len(counts))
+	var symbols []int
+	for s, n := range counts {
+		if n > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	if len(symbols) < 2 {
+		return lengths
+	}
+
+	// too deep a tree: flatten the counts and retry (rare symbols get the same weight)
+	for floor := 1; ; floor *= 2 {
+		weight := func(s int) int { return max(counts[s], floor) }
+		sort.SliceStable(symbols, func(i, j int) bool { return weight(symbols[i]) < weight(symbols[j]) })
+
+		// two-queue Huffman: sorted leaves, and internal nodes which come out sorted too
+		type node struct{ weight, left, right int }
+		nodes := make([]node, 0, 2*len(symbols))
+		for _, s := range symbols {
+			nodes = append(nodes, node{weight(s), -1, -1})
+		}
+		leaf, inner := 0, len(symbols)
+		pick := func() int {
+			if leaf < len(symbols) && (inner >= len(nodes) || nodes[leaf].weight <= nodes[inner].weight) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for range len(symbols) - 1 {
+			a, b := pick(), pick()
+			nodes = append(nodes, node{nodes[a].weight + nodes[b].weight, a, b})
+		}
+
+		// depths from the root down; leaves are the first len(symbols) nodes
+		depth := make([]int, len(nodes))
+		deepest := 0
+		for i := len(nodes) - 1; i >= len(symbols); i-- {
+			depth[nodes[i].left] = depth[i] + 1
+			depth[nodes[i].right] = depth[i] + 1
+		}
+		for i := range symbols {
+			deepest = max(deepest, depth[i])
+		}
+		if deepest <= limit {
+			for i, s := range symbols {
+				lengths[s] = uint8(depth[i])
+			}
+			return lengths
+		}
+	}
+}
+
+
+// canonicalCode assigns codes to lengths the way the decoder does: by length, then by symbol
+func canonicalCode(lengths []uint8) prefixCode {
+
+	var count [16]uint64
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+		}
+	}
+	var next [16]uint64
+	code := uint64(0)
+	for l := 1; l < 16; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+
+	// codes go out most significant bit first, the writer is least significant first
+	pc := prefixCode{bits: make([]uint64, len(lengths)), size: lengths}
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		for i := uint8(0); i < l; i++ {
+			pc.bits[s] |= (c >> i & 1) << (l - 1 - i)
+		}
+	}
+	return pc
+}
+
+
+// writePrefixCode writes the prefix code for counts and returns it; alphabetBits is what
+// symbols take in a simple prefix code
+func writePrefixCode(bw *bitWriter, counts []int, alphabetBits uint) prefixCode {
+
+	lengths := huffmanLengths(counts, 15)
+	var used []int
+	for s, n := range counts {
+		if n > 0 {
+			used = append(used, s)
+		}
+	}
+
+	// simple prefix code: up to 4 symbols listed, lengths implied (none used: any one symbol)
+	if len(used) <= 4 {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		sort.SliceStable(used, func(i, j int) bool {
+			if lengths[used[i]] != lengths[used[j]] {
+				return lengths[used[i]] < lengths[used[j]]
+			}
+			return used[i] < used[j]
+		})
+		bw.writeBits(2, 1)
+		bw.writeBits(2, uint64(len(used)-1))
+		for _, s := range used {
+			bw.writeBits(alphabetBits, uint64(s))
+		}
+		if len(used) == 4 {
+			if lengths[used[0]] == 1 {
+				bw.writeBits(1, 1) // lengths 1, 2, 3, 3
+			} else {
+				bw.writeBits(1, 0) // lengths 2, 2, 2, 2
+			}
+		}
+		return canonicalCode(lengths)
+	}
+
+	// complex prefix code: the code lengths, themselves prefix coded. runs of zeros use code 17,
+	// never two in a row (the decoder would multiply them); lengths after the last used symbol are implied
+	type token struct{ symbol, extra int }
+	var tokens []token
+	last := used[len(used)-1]
+	for i := 0; i <= last; {
+		if lengths[i] != 0 {
+			tokens = append(tokens, token{int(lengths[i]), 0})
+			i++
+			continue
+		}
+		run := 0
+		for lengths[i+run] == 0 {
+			run++
+		}
+		i += run
+		for run > 0 {
+			if run >= 3 {
+				n := min(run, 10)
+				tokens = append(tokens, token{17, n - 3})
+				run -= n
+				if run == 0 {
+					break
+				}
+			}
+			tokens = append(tokens, token{0, 0})
+			run--
+		}
+	}
+
+	codeLengthCounts := make([]int, 18)
+	for _, t := range tokens {
+		codeLengthCounts[t.symbol]++
+	}
+	codeLengthLengths := huffmanLengths(codeLengthCounts, 5)
+
+	// a single code length symbol takes no bits, but its length must be written nonzero
+	single := -1
+	for s, n := range codeLengthCounts {
+		if n == len(tokens) {
+			single = s
+			codeLengthLengths[s] = 1
+		}
+	}
+
+	// HSKIP 0, then the code length code lengths up to the last nonzero one (all 18 for a single symbol)
+	bw.writeBits(2, 0)
+	end := len(brotliCodeLengthOrder)
+	if single < 0 {
+		for end > 0 && codeLengthLengths[brotliCodeLengthOrder[end-1]] == 0 {
+			end--
+		}
+	}
+	for _, s := range brotliCodeLengthOrder[:end] {
+		l := codeLengthLengths[s]
+		bw.writeBits(brotliCodeLengthCodeSize[l], brotliCodeLengthCodeBits[l])
+	}
+
+	codeLengthCode := canonicalCode(codeLengthLengths)
+	for _, t := range tokens {
+		if single < 0 {
+			bw.writeBits(uint(codeLengthCode.size[t.symbol]), codeLengthCode.bits[t.symbol])
+		}
+		if t.symbol == 17 {
+			bw.writeBits(3, uint64(t.extra))
+		}
+	}
+	return canonicalCode(lengths)
+}
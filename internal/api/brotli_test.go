@@ -0,0 +1,491 @@
+package api
+
+import (
+	"bytes"     // for the inputs and comparing the output
+	"errors"    // for decode errors
+	"math/rand" // for incompressible input
+	"strconv"   // for generated JSON
+	"testing"   // for the tests
+)
+
+// brotli tests: what the writer produces is read back by a decoder for the part of RFC 7932 it
+// uses (one block type and one prefix code of each kind, no dictionary, no context modelling),
+// which checks every prefix code is complete and every copy stays inside what's been decoded
+
+// the first insert and copy length codes of each insert-and-copy cell (RFC 7932 section 5);
+// cells 0 and 1 reuse the last distance
+var testInsertCellBase = [11]int{0, 0, 0, 0, 8, 8, 0, 16, 8, 16, 16}
+var testCopyCellBase = [11]int{0, 8, 0, 8, 0, 8, 16, 0, 16, 8, 16}
+
+// brotliBits reads bits least significant first; the first error sticks and reads give 0 after it
+type brotliBits struct {
+	data []byte
+	pos  int // in bits
+	err  error
+}
+
+// testPrefixCode is a canonical prefix code being decoded: the number of codes of each length
+// and the symbols in code order
+type testPrefixCode struct {
+	count   [16]int
+	symbols []int
+}
+
+
+// bits reads n bits
+func (r *brotliBits) bits(n uint) int {
+	v := 0
+	for i := uint(0); i < n; i++ {
+		if r.pos>>3 >= len(r.data) {
+			r.fail("unexpected end of stream")
+			return 0
+		}
+		v |= int(r.data[r.pos>>3]>>(r.pos&7)&1) << i
+		r.pos++
+	}
+	return v
+}
+
+
+// align skips to the next byte, whose padding must be zero
+func (r *brotliBits) align() {
+	if r.pos&7 != 0 && r.bits(uint(8-r.pos&7)) != 0 {
+		r.fail("nonzero padding")
+	}
+}
+
+
+// fail records the first error
+func (r *brotliBits) fail(msg string) {
+	if r.err == nil {
+		r.err = errors.New("brotli: " + msg + " at bit " + strconv.Itoa(r.pos))
+	}
+}
+
+
+// newTestPrefixCode builds the code for lengths (0 = unused), which must fill the code space
+// unless there's a single symbol
+func newTestPrefixCode(r *brotliBits, lengths []int) *testPrefixCode {
+
+	c := &testPrefixCode{}
+	space := 0
+	for l := 1; l < 16; l++ {
+		for s, sl := range lengths {
+			if sl == l {
+				c.count[l]++
+				c.symbols = append(c.symbols, s)
+				space += 1 << (15 - l)
+			}
+		}
+	}
+	switch {
+	case len(c.symbols) == 0:
+		r.fail("prefix code with no symbols")
+	case len(c.symbols) > 1 && space != 1<<15:
+		r.fail("incomplete or oversubscribed prefix code")
+	}
+	return c
+}
+
+
+// decode reads one symbol; a code of one symbol takes no bits
+func (c *testPrefixCode) decode(r *brotliBits) int {
+
+	if len(c.symbols) == 1 {
+		return c.symbols[0]
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l < 16 && r.err == nil; l++ {
+		code |= r.bits(1)
+		if n := c.count[l]; code-first < n {
+			return c.symbols[index+code-first]
+		}
+		index += c.count[l]
+		first = (first + c.count[l]) << 1
+		code <<= 1
+	}
+	r.fail("no such code")
+	return 0
+}
+
+
+// readCodeLengthCodeLength reads a length with the fixed code of RFC 7932 section 3.5
+func readCodeLengthCodeLength(r *brotliBits) int {
+	switch r.bits(2) {
+	case 0:
+		return 0
+	case 1:
+		return 4
+	case 2:
+		return 3
+	}
+	if r.bits(1) == 0 {
+		return 2
+	}
+	if r.bits(1) == 0 {
+		return 1
+	}
+	return 5
+}
+
+
+// readPrefixCode reads a simple or complex prefix code for an alphabet of size symbols
+func readPrefixCode(r *brotliBits, size int, alphabetBits uint) *testPrefixCode {
+
+	lengths := make([]int, size)
+	hskip := r.bits(2)
+
+	// simple: up to four symbols with implied lengths
+	if hskip == 1 {
+		n := r.bits(2) + 1
+		symbols := make([]int, n)
+		for i := range symbols {
+			symbols[i] = r.bits(alphabetBits)
+			if symbols[i] >= size || lengths[symbols[i]] != 0 {
+				r.fail("bad simple prefix code symbol")
+				return newTestPrefixCode(r, nil)
+			}
+			lengths[symbols[i]] = -1
+		}
+		implied := [][]int{{1}, {1, 1}, {1, 2, 2}, {2, 2, 2, 2}}[n-1]
+		if n == 4 && r.bits(1) == 1 {
+			implied = []int{1, 2, 3, 3}
+		}
+		for i, s := range symbols {
+			lengths[s] = implied[i]
+		}
+		return newTestPrefixCode(r, lengths)
+	}
+
+	// complex: the code lengths, prefix coded themselves
+	codeLengthLengths := make([]int, 18)
+	space, used := 32, 0
+	for _, s := range brotliCodeLengthOrder[hskip:] {
+		l := readCodeLengthCodeLength(r)
+		codeLengthLengths[s] = l
+		if l != 0 {
+			space -= 32 >> l
+			used++
+			if space <= 0 {
+				break
+			}
+		}
+	}
+	if used != 1 && space != 0 {
+		r.fail("bad code length code")
+	}
+	codeLengthCode := newTestPrefixCode(r, codeLengthLengths)
+
+	prev, repeat, repeatLength := 8, 0, -1
+	space = 1 << 15
+	for i := 0; i < size && space > 0 && r.err == nil; {
+		c := codeLengthCode.decode(r)
+		if c < 16 {
+			repeat = 0
+			lengths[i] = c
+			i++
+			if c != 0 {
+				prev = c
+				space -= 1 << 15 >> c
+			}
+			continue
+		}
+
+		// 16 repeats the last nonzero length, 17 a zero; repeated repeats multiply
+		extraBits, length := uint(2), prev
+		if c == 17 {
+			extraBits, length = 3, 0
+		}
+		if length != repeatLength {
+			repeat, repeatLength = 0, length
+		}
+		old := repeat
+		if repeat > 0 {
+			repeat = (repeat - 2) << extraBits
+		}
+		repeat += r.bits(extraBits) + 3
+		for n := repeat - old; n > 0; n-- {
+			if i >= size {
+				r.fail("code lengths run past the alphabet")
+				break
+			}
+			lengths[i] = length
+			i++
+			if length != 0 {
+				space -= 1 << 15 >> length
+			}
+		}
+	}
+	if space != 0 {
+		r.fail("code lengths don't fill the code space")
+	}
+	return newTestPrefixCode(r, lengths)
+}
+
+
+// brotliDecode decodes a stream; complete=false when it stops at a meta-block boundary without
+// having ended, as a flushed stream does
+func brotliDecode(data []byte) (out []byte, complete bool, err error) {
+
+	r := &brotliBits{data: data}
+	windowBits := 16
+	if r.bits(1) == 1 {
+		switch n := r.bits(3); {
+		case n != 0:
+			windowBits = 17 + n
+		default:
+			if n = r.bits(3); n == 1 {
+				r.fail("large window")
+			} else if n != 0 {
+				windowBits = 8 + n
+			} else {
+				windowBits = 17
+			}
+		}
+	}
+	maxDistance := 1<<windowBits - 16
+	last := [4]int{4, 11, 15, 16}
+
+	for r.err == nil {
+		if r.pos&7 == 0 && r.pos>>3 == len(data) {
+			return out, false, nil
+		}
+
+		isLast := r.bits(1) == 1
+		if isLast && r.bits(1) == 1 {
+			r.align()
+			break
+		}
+		nibbles := r.bits(2)
+		if nibbles == 3 {
+			// metadata, skipped
+			if r.bits(1) != 0 {
+				r.fail("reserved bit set")
+			}
+			skip := 0
+			if skipBytes := r.bits(2); skipBytes > 0 {
+				skip = r.bits(8*uint(skipBytes)) + 1
+			}
+			r.align()
+			r.pos += 8 * skip
+			if isLast {
+				break
+			}
+			continue
+		}
+		length := r.bits(4*uint(nibbles+4)) + 1
+		end := len(out) + length
+
+		if !isLast && r.bits(1) == 1 {
+			r.align()
+			if r.pos>>3+length > len(data) {
+				r.fail("uncompressed meta-block past the end")
+				break
+			}
+			out = append(out, data[r.pos>>3:r.pos>>3+length]...)
+			r.pos += 8 * length
+			continue
+		}
+
+		for range 3 {
+			if r.bits(1) != 0 {
+				r.fail("more than one block type")
+			}
+		}
+		if r.bits(2) != 0 || r.bits(4) != 0 {
+			r.fail("NPOSTFIX or NDIRECT set")
+		}
+		r.bits(2) // literal context mode, which one tree doesn't use
+		if r.bits(1) != 0 || r.bits(1) != 0 {
+			r.fail("more than one prefix code")
+		}
+		literals := readPrefixCode(r, brotliLiteralAlphabet, 8)
+		commands := readPrefixCode(r, brotliCommandAlphabet, 10)
+		distances := readPrefixCode(r, brotliDistanceAlphabet, 6)
+
+		for len(out) < end && r.err == nil {
+			symbol := commands.decode(r)
+			cell := symbol >> 6
+			insertCode := testInsertCellBase[cell] + (symbol>>3)&7
+			copyCode := testCopyCellBase[cell] + symbol&7
+			insertLen := brotliInsertBase[insertCode] + r.bits(brotliInsertExtra[insertCode])
+			copyLen := brotliCopyBase[copyCode] + r.bits(brotliCopyExtra[copyCode])
+			if len(out)+insertLen > end {
+				r.fail("literals past the meta-block")
+				break
+			}
+			for range insertLen {
+				out = append(out, byte(literals.decode(r)))
+			}
+			if len(out) == end {
+				break
+			}
+
+			distance := last[0]
+			if cell >= 2 {
+				switch d := distances.decode(r); {
+				case d == 0:
+				case d < 16:
+					r.fail("distance code " + strconv.Itoa(d) + " isn't used by the writer")
+				default:
+					d -= 16
+					bits := uint(1 + d>>1)
+					distance = (2+d&1)<<bits - 4 + r.bits(bits) + 1
+					last = [4]int{distance, last[0], last[1], last[2]}
+				}
+			}
+			switch {
+			case distance > len(out) || distance > maxDistance:
+				r.fail("copy from before the start")
+			case len(out)+copyLen > end:
+				r.fail("copy past the meta-block")
+			default:
+				for range copyLen {
+					out = append(out, out[len(out)-distance])
+				}
+			}
+		}
+		if isLast {
+			r.align()
+			break
+		}
+	}
+	if r.err == nil && r.pos>>3 != len(data) {
+		r.fail("data after the end of the stream")
+	}
+	return out, true, r.err
+}
+
+
+// brotliTestInputs are what the round trips compress
+func brotliTestInputs() map[string][]byte {
+
+	var todos bytes.Buffer
+	todos.WriteString("[")
+	for i := range 4000 {
+		if i > 0 {
+			todos.WriteString(",")
+		}
+		todos.WriteString(`{"id":` + strconv.Itoa(i+1) + `,"title":"todo number ` + strconv.Itoa(i*7919%10007) +
+			`","done":` + strconv.FormatBool(i%3 == 0) + `,"created_at":"2026-10-14T09:00:00Z"}`)
+	}
+	todos.WriteString("]")
+
+	random := make([]byte, 70000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	return map[string][]byte{
+		"empty":        nil,
+		"one byte":     []byte("x"),
+		"two symbols":  []byte("abababbbaabab"),
+		"text":         []byte("the quick brown fox jumps over the lazy dog, the quick brown fox jumps again"),
+		"long run":     bytes.Repeat([]byte("a"), 300000),
+		"todos json":   todos.Bytes(), // several meta-blocks, matches reaching into the previous one
+		"random":       random,        // written uncompressed
+		"all bytes":    bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 255, 254, 253, 128, 127}, 40),
+		"mostly short": []byte(`{"a":1}{"a":2}{"b":1}{"a":1}`),
+	}
+}
+
+
+// TestBrotliRoundTrip compresses each input at several qualities and decodes it back
+func TestBrotliRoundTrip(t *testing.T) {
+
+	for name, input := range brotliTestInputs() {
+		for _, quality := range []int{0, 1, 5, 11} {
+			t.Run(name+"/q"+strconv.Itoa(quality), func(t *testing.T) {
+				var compressed bytes.Buffer
+				z := newBrotliWriter(&compressed, quality)
+				if _, err := z.Write(input); err != nil {
+					t.Fatal("Write:", err)
+				}
+				if err := z.Close(); err != nil {
+					t.Fatal("Close:", err)
+				}
+
+				got, complete, err := brotliDecode(compressed.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !complete {
+					t.Fatal("stream doesn't end")
+				}
+				if !bytes.Equal(got, input) {
+					t.Fatalf("decoded %d bytes, not the %d written", len(got), len(input))
+				}
+				if name != "random" && len(input) > 1000 && compressed.Len() > len(input)/4 {
+					t.Errorf("%d bytes compressed to %d", len(input), compressed.Len())
+				}
+				if name == "random" && compressed.Len() > len(input)+len(input)/100 {
+					t.Errorf("incompressible %d bytes grew to %d", len(input), compressed.Len())
+				}
+			})
+		}
+	}
+}
+
+
+// TestBrotliSmallWrites checks input written a piece at a time comes out the same
+func TestBrotliSmallWrites(t *testing.T) {
+
+	input := brotliTestInputs()["todos json"]
+	var compressed bytes.Buffer
+	z := newBrotliWriter(&compressed, 5)
+	for rest := input; len(rest) > 0; {
+		n := min(len(rest), 999)
+		z.Write(rest[:n])
+		rest = rest[n:]
+	}
+	z.Close()
+	got, _, err := brotliDecode(compressed.Bytes())
+	if err != nil || !bytes.Equal(got, input) {
+		t.Fatalf("decoded %d bytes (%v), want %d", len(got), err, len(input))
+	}
+}
+
+
+// TestBrotliFlush checks a flushed stream decodes to everything written so far, and goes on after
+func TestBrotliFlush(t *testing.T) {
+
+	parts := [][]byte{[]byte(`{"id":1,"title":"first"}`), []byte(`{"id":2,"title":"first again"}`), nil, []byte("end")}
+	var compressed bytes.Buffer
+	z := newBrotliWriter(&compressed, 5)
+	var written []byte
+	for i, part := range parts {
+		z.Write(part)
+		written = append(written, part...)
+		if err := z.Flush(); err != nil {
+			t.Fatal("Flush:", err)
+		}
+		got, complete, err := brotliDecode(compressed.Bytes())
+		if err != nil || complete || !bytes.Equal(got, written) {
+			t.Fatalf("after flush %d: %q, complete %v, %v; want %q", i, got, complete, err, written)
+		}
+	}
+
+	z.Close()
+	got, complete, err := brotliDecode(compressed.Bytes())
+	if err != nil || !complete || !bytes.Equal(got, written) {
+		t.Fatalf("after close: %q, complete %v, %v; want %q", got, complete, err, written)
+	}
+	if _, err := z.Write([]byte("more")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
+
+
+// TestDistanceSymbol checks each distance comes back from its symbol and extra bits
+func TestDistanceSymbol(t *testing.T) {
+
+	for _, distance := range []int{1, 2, 3, 4, 5, 6, 7, 8, 100, 1000, 65535, brotliMaxDistance} {
+		symbol, bits, extra := distanceSymbol(distance)
+		if symbol < 16 || symbol >= brotliDistanceAlphabet || extra >= 1<<bits {
+			t.Errorf("distanceSymbol(%d) = %d, %d, %d", distance, symbol, bits, extra)
+			continue
+		}
+		d := symbol - 16
+		if got := (2+d&1)<<(1+d>>1) - 4 + int(extra) + 1; got != distance || bits != uint(1+d>>1) {
+			t.Errorf("distanceSymbol(%d) decodes to %d", distance, got)
+		}
+	}
+}
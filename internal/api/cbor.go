@@ -0,0 +1,373 @@
+package api
+
+import (
+	"encoding/base64" // for byte strings in JSON
+	"encoding/binary" // for big-endian integers
+	"encoding/json"   // for JSON numbers and strings
+	"errors"          // for decode errors
+	"math"            // for float bits
+	"strconv"         // for numbers
+	"time"            // for epoch timestamps (tag 1)
+)
+
+// CBOR (RFC 8949) both ways: responses are written from the JSON values like MessagePack, and request
+// bodies are turned into JSON for the handlers, so a device can POST /todos/create in CBOR
+
+// deepest nesting accepted in a CBOR request
+const cborMaxDepth = 32
+
+// CBOR major types
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+
+// appendCBORHead appends a major type with its argument in the shortest form
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+
+	switch {
+	case n < 24:
+		return append(b, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major<<5|27), n)
+}
+
+
+// appendCBOR appends v (as returned by decodeOrderedJSON) in CBOR
+func appendCBOR(b []byte, v any) []byte {
+
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6)
+
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+
+	case json.Number:
+		// integers stay integers, the rest are float64
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			if i < 0 {
+				return appendCBORHead(b, cborNegint, uint64(-1-i))
+			}
+			return appendCBORHead(b, cborUint, uint64(i))
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return appendCBORHead(b, cborUint, u)
+		}
+		f, _ := v.Float64()
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(f))
+
+	case string:
+		b = appendCBORHead(b, cborText, uint64(len(v)))
+		return append(b, v...)
+
+	case []any:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, item := range v {
+			b = appendCBOR(b, item)
+		}
+		return b
+
+	case orderedObject:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, entry := range v {
+			b = appendCBOR(b, entry.key)
+			b = appendCBOR(b, entry.value)
+		}
+		return b
+	}
+	return append(b, 0xf6)
+}
+
+// cborDecoder reads one CBOR data item at a time from a request body
+type cborDecoder struct {
+	b []byte
+}
+
+
+// decodeCBOR parses exactly one data item into the values decodeOrderedJSON returns
+func decodeCBOR(b []byte) (any, error) {
+
+	d := &cborDecoder{b: b}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.b) > 0 {
+		return nil, errors.New("cbor: trailing data")
+	}
+	return v, nil
+}
+
+
+// head reads an initial byte and its argument; indefinite is set for length 31 (and break, 0xff)
+func (d *cborDecoder) head() (major byte, info byte, n uint64, indefinite bool, err error) {
+
+	if len(d.b) == 0 {
+		return 0, 0, 0, false, errors.New("cbor: unexpected end")
+	}
+	major, info = d.b[0]>>5, d.b[0]&31
+	d.b = d.b[1:]
+
+	size := 0
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info == 31:
+		return major, info, 0, true, nil
+	case info > 27:
+		return 0, 0, 0, false, errors.New("cbor: reserved additional information")
+	default:
+		size = 1 << (info - 24)
+	}
+	if len(d.b) < size {
+		return 0, 0, 0, false, errors.New("cbor: unexpected end")
+	}
+	for _, c := range d.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	d.b = d.b[size:]
+	return major, info, n, false, nil
+}
+
+
+// isBreak consumes the break marker ending an indefinite length item
+func (d *cborDecoder) isBreak() bool {
+	if len(d.b) > 0 && d.b[0] == 0xff {
+		d.b = d.b[1:]
+		return true
+	}
+	return false
+}
+
+
+// str reads the bytes of a byte or text string of the given major type (chunked when indefinite)
+func (d *cborDecoder) str(major byte, n uint64, indefinite bool) ([]byte, error) {
+
+	if !indefinite {
+		if uint64(len(d.b)) < n {
+			return nil, errors.New("cbor: unexpected end")
+		}
+		s := d.b[:n]
+		d.b = d.b[n:]
+		return s, nil
+	}
+
+	var s []byte
+	for !d.isBreak() {
+		chunkMajor, _, size, chunkIndefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, errors.New("cbor: bad string chunk")
+		}
+		chunk, err := d.str(major, size, false)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+	return s, nil
+}
+
+
+// value reads one data item
+func (d *cborDecoder) value(depth int) (any, error) {
+
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: nested too deep")
+	}
+	major, info, n, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == cborUint || major == cborNegint || major == cborTag) {
+		return nil, errors.New("cbor: indefinite length on a number or tag")
+	}
+
+	switch major {
+	case cborUint:
+		return json.Number(strconv.FormatUint(n, 10)), nil
+
+	case cborNegint:
+		if n == math.MaxUint64 {
+			return json.Number("-18446744073709551616"), nil
+		}
+		return json.Number("-" + strconv.FormatUint(n+1, 10)), nil
+
+	case cborBytes:
+		// JSON has no bytes: base64, like encoding/json does for []byte
+		s, err := d.str(major, n, indefinite)
+		return base64.StdEncoding.EncodeToString(s), err
+
+	case cborText:
+		s, err := d.str(major, n, indefinite)
+		return string(s), err
+
+	case cborArray:
+		list := []any{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+
+	case cborMap:
+		obj := orderedObject{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+			key, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			// JSON keys are strings: numbers become their decimal form
+			switch k := key.(type) {
+			case string:
+				obj = append(obj, objectEntry{k, value})
+			case json.Number:
+				obj = append(obj, objectEntry{string(k), value})
+			default:
+				return nil, errors.New("cbor: map key must be a string or integer")
+			}
+		}
+		return obj, nil
+
+	case cborTag:
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// tag 1, seconds since the epoch: the RFC 3339 string the JSON API takes for dates
+		if n == 1 {
+			if secs, ok := item.(json.Number); ok {
+				f, err := secs.Float64()
+				if err != nil || math.IsInf(f, 0) {
+					return nil, errors.New("cbor: bad epoch time")
+				}
+				whole, frac := math.Modf(f)
+				return time.Unix(int64(whole), int64(frac*1e9)).UTC().Format(time.RFC3339Nano), nil
+			}
+			return nil, errors.New("cbor: epoch time must be a number")
+		}
+
+		// anything else (tag 0 included, already an RFC 3339 string) is its content
+		return item, nil
+	}
+
+	// major type 7: simple values and floats
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil // null, undefined
+	case 25:
+		return cborFloat(halfToFloat(uint16(n)))
+	case 26:
+		return cborFloat(float64(math.Float32frombits(uint32(n))))
+	case 27:
+		return cborFloat(math.Float64frombits(n))
+	}
+	return nil, errors.New("cbor: unsupported simple value")
+}
+
+
+// cborFloat turns a float into a JSON number (JSON has no NaN or infinities)
+func cborFloat(f float64) (any, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("cbor: NaN and infinity don't fit in JSON")
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+
+// halfToFloat decodes an IEEE 754 half precision float (RFC 8949 appendix D)
+func halfToFloat(h uint16) float64 {
+
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+
+// encodeOrderedJSON writes values from decodeCBOR (or decodeOrderedJSON) back out as JSON
+func encodeOrderedJSON(b []byte, v any) []byte {
+
+	switch v := v.(type) {
+	case json.Number:
+		return append(b, v...)
+
+	case []any:
+		b = append(b, '[')
+		for i, item := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = encodeOrderedJSON(b, item)
+		}
+		return append(b, ']')
+
+	case orderedObject:
+		b = append(b, '{')
+		for i, entry := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = encodeOrderedJSON(b, entry.key)
+			b = append(b, ':')
+			b = encodeOrderedJSON(b, entry.value)
+		}
+		return append(b, '}')
+	}
+
+	// nil, bool, string
+	scalar, _ := json.Marshal(v)
+	return append(b, scalar...)
+}
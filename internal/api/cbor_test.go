@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/hex" // for the test vectors
+	"strings"      // for nested items and matching errors
+	"testing"      // for the tests
+)
+
+// CBOR tests: the encodings of RFC 8949 appendix A both ways, what the decoder turns away, and
+// JSON going through CBOR and back
+
+
+// TestAppendCBOR checks values come out in the shortest form, as in RFC 8949 appendix A
+func TestAppendCBOR(t *testing.T) {
+
+	tests := []struct {
+		json string
+		want string // hex
+	}{
+		{`0`, "00"},
+		{`23`, "17"},
+		{`24`, "1818"},
+		{`100`, "1864"},
+		{`1000`, "1903e8"},
+		{`1000000`, "1a000f4240"},
+		{`1000000000000`, "1b000000e8d4a51000"},
+		{`18446744073709551615`, "1bffffffffffffffff"},
+		{`-1`, "20"},
+		{`-100`, "3863"},
+		{`-1000`, "3903e7"},
+		{`1.5`, "fb3ff8000000000000"},
+		{`false`, "f4"},
+		{`true`, "f5"},
+		{`null`, "f6"},
+		{`""`, "60"},
+		{`"a"`, "6161"},
+		{`"IETF"`, "6449455446"},
+		{`"ü"`, "62c3bc"},
+		{`[]`, "80"},
+		{`[1,2,3]`, "83010203"},
+		{`[1,[2,3],[4,5]]`, "8301820203820405"},
+		{`{}`, "a0"},
+		{`{"a":1,"b":[2,3]}`, "a26161016162820203"},
+	}
+	for _, tt := range tests {
+		v, err := decodeOrderedJSON([]byte(tt.json))
+		if err != nil {
+			t.Fatalf("decodeOrderedJSON(%s): %v", tt.json, err)
+		}
+		if got := hex.EncodeToString(appendCBOR(nil, v)); got != tt.want {
+			t.Errorf("appendCBOR(%s) = %s, want %s", tt.json, got, tt.want)
+		}
+	}
+}
+
+
+// TestDecodeCBOR checks items in every form a client may send come out as the JSON they stand for
+func TestDecodeCBOR(t *testing.T) {
+
+	tests := []struct {
+		name string
+		cbor string // hex
+		want string
+	}{
+		{"uint", "1b000000e8d4a51000", `1000000000000`},
+		{"negint", "3903e7", `-1000`},
+		{"smallest negint", "3bffffffffffffffff", `-18446744073709551616`},
+		{"half float", "f93c00", `1`},
+		{"largest half", "f97bff", `65504`},
+		{"smallest half subnormal", "f90001", `5.960464477539063e-08`},
+		{"negative half", "f9c400", `-4`},
+		{"single float", "fa47c35000", `100000`},
+		{"double", "fb3ff199999999999a", `1.1`},
+		{"undefined", "f7", `null`},
+		{"byte string", "4401020304", `"AQIDBA=="`},
+		{"chunked byte string", "5f42010243030405ff", `"AQIDBAU="`},
+		{"chunked text", "7f657374726561646d696e67ff", `"streaming"`},
+		{"indefinite array", "9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"empty indefinite array", "9fff", `[]`},
+		{"indefinite map", "bf6346756ef563416d7421ff", `{"Fun":true,"Amt":-2}`},
+		{"integer key", "a1011864", `{"1":100}`},
+		{"epoch time", "c11a514b67b0", `"2013-03-21T20:04:00Z"`},
+		{"fractional epoch time", "c1fb41d452d9ec200000", `"2013-03-21T20:04:00.5Z"`},
+		{"RFC 3339 time", "c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"other tag", "d82076687474703a2f2f7777772e6578616d706c652e636f6d", `"http://www.example.com"`},
+		{"create request", "a2657469746c6568627579206d696c6b63647565c11a6a5b4f00", `{"title":"buy milk","due":"2026-07-18T10:01:36Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.cbor)
+			v, err := decodeCBOR(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(encodeOrderedJSON(nil, v)); got != tt.want {
+				t.Errorf("decodeCBOR(%s) = %s, want %s", tt.cbor, got, tt.want)
+			}
+		})
+	}
+}
+
+
+// TestDecodeCBORErrors checks what isn't one well formed item that fits in JSON is turned away
+func TestDecodeCBORErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		cbor string // hex
+		want string // part of the error
+	}{
+		{"empty", "", "unexpected end"},
+		{"short argument", "1903", "unexpected end"},
+		{"short string", "6461", "unexpected end"},
+		{"array without items", "8301", "unexpected end"},
+		{"no break", "9f01", "unexpected end"},
+		{"trailing data", "0101", "trailing data"},
+		{"reserved info", "1c", "reserved"},
+		{"indefinite integer", "1f", "indefinite length"},
+		{"indefinite tag", "df00", "indefinite length"},
+		{"mixed chunks", "7f4161ff", "bad string chunk"},
+		{"nested chunks", "7f7fffff", "bad string chunk"},
+		{"bool key", "a1f501", "map key"},
+		{"infinity", "f97c00", "NaN and infinity"},
+		{"NaN", "f97e00", "NaN and infinity"},
+		{"epoch time of a string", "c16161", "epoch time must be a number"},
+		{"infinite epoch time", "c1fa7f800000", "NaN and infinity"},
+		{"simple value", "e0", "unsupported simple value"},
+		{"too deep", strings.Repeat("81", 33) + "00", "nested too deep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.cbor)
+			if _, err := decodeCBOR(b); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decodeCBOR(%s) = %v, want an error containing %q", tt.cbor, err, tt.want)
+			}
+		})
+	}
+
+	// as deep as allowed is fine
+	b, _ := hex.DecodeString(strings.Repeat("81", 32) + "00")
+	if _, err := decodeCBOR(b); err != nil {
+		t.Errorf("32 levels of arrays: %v", err)
+	}
+}
+
+
+// TestCBORRoundTrip checks JSON comes back the same from its CBOR, key order included
+func TestCBORRoundTrip(t *testing.T) {
+
+	tests := []string{
+		`{"id":1,"title":"buy milk","done":false,"due":null,"tags":["home","shop"]}`,
+		`{"z":1,"a":2,"m":{"y":true,"b":false}}`,
+		`[0,-1,23,24,-24,-25,255,256,65535,65536,-9223372036854775808,18446744073709551615,2.25,-0.5,1e+300]`,
+		`{"title":"café ☕ 日本","":""}`,
+		`[[],{},"",null,[[[]]]]`,
+		`"` + strings.Repeat("long ", 20000) + `"`,
+	}
+	for _, input := range tests {
+		v, err := decodeOrderedJSON([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := decodeCBOR(appendCBOR(nil, v))
+		if err != nil {
+			t.Fatalf("decodeCBOR(appendCBOR(%.40s)): %v", input, err)
+		}
+		if got := string(encodeOrderedJSON(nil, back)); got != input {
+			t.Errorf("round trip = %.80s, want %.80s", got, input)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"net/http"      // for HTTP handlers
+	"strconv"       // for parsing since
+	"time"          // for wait timeouts
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// longest a client may ask us to hold a long-poll request
+var longPollMaxWait = flags.Duration("longpoll-max-wait", time.Minute, "maximum wait accepted by GET /todos/changes")
+
+// how many past events are kept for clients catching up by sequence number
+var changeLogSize = flags.Int("change-log-size", store.DefaultChangeLogSize, "number of recent change events kept for catch-up by sequence number")
+
+// default wait when the client doesn't pass one
+const defaultLongPollWait = 30 * time.Second
+
+// page size of GET /changes, by default and at most
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// ChangesResponse is the body of GET /todos/changes
+type ChangesResponse struct {
+	Changes []model.Event `json:"changes"`  // events with seq > since, oldest first
+	LastSeq uint64        `json:"last_seq"` // pass as since on the next call
+}
+
+// ChangeFeedPage is the body of GET /changes
+type ChangeFeedPage struct {
+	Changes []model.Event `json:"changes"`  // at most limit change records with seq > since, in seq order
+	LastSeq uint64        `json:"last_seq"` // since for the next page
+	HasMore bool          `json:"has_more"` // more records after last_seq are already there
+}
+
+
+// long-poll for changes: ?since=<seq>&wait=30s returns as soon as a newer change exists
+func todoChangesHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET method
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// since defaults to 0 = everything still in the log
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	// wait is a Go duration ("30s"), capped by -longpoll-max-wait
+	wait := defaultLongPollWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		var err error
+		if wait, err = time.ParseDuration(v); err != nil || wait < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	wait = min(wait, *longPollMaxWait)
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		events, changed, ok := storeFor(r).Events().Since(since)
+
+		// too far behind, client has to reload the full list and start from last_seq
+		if !ok {
+			w.WriteHeader(http.StatusGone)
+			json.NewEncoder(w).Encode(ChangesResponse{Changes: []model.Event{}, LastSeq: storeFor(r).Events().LastSeq()})
+			return
+		}
+
+		// something new, answer right away
+		if len(events) > 0 {
+			json.NewEncoder(w).Encode(ChangesResponse{Changes: events, LastSeq: events[len(events)-1].Seq})
+			return
+		}
+
+		select {
+		case <-changed:
+			// loop and collect what arrived
+		case <-timeout.C:
+			// nothing happened, client just asks again
+			json.NewEncoder(w).Encode(ChangesResponse{Changes: []model.Event{}, LastSeq: max(since, storeFor(r).Events().LastSeq())})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+
+// the change feed: every mutation's record in sequence order, ?since=<seq>&limit=N a page at a
+// time, answered right away (GET /todos/changes is the long-polling variant)
+func changeFeedHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET method
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var since uint64
+	if v := q.Get("since"); v != "" {
+		var err error
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, r, model.Invalid("since", "must be a sequence number"))
+			return
+		}
+	}
+	limit := defaultChangesLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxChangesLimit {
+			writeError(w, r, model.Invalid("limit", "must be between 1 and 1000"))
+			return
+		}
+		limit = n
+	}
+
+	// a since the server never handed out means the client talks to another server (or one
+	// that restarted): nothing it has is comparable
+	lastSeq := storeFor(r).Events().LastSeq()
+	if since > lastSeq {
+		writeError(w, r, model.Invalid("since", "is ahead of the server"))
+		return
+	}
+
+	events, _, ok := storeFor(r).Events().Since(since)
+	if !ok {
+		// fell out of the log: reload GET /todos and follow on from last_seq
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(ChangeFeedPage{Changes: []model.Event{}, LastSeq: lastSeq})
+		return
+	}
+
+	page := ChangeFeedPage{Changes: []model.Event{}, LastSeq: since}
+	if len(events) > limit {
+		events, page.HasMore = events[:limit], true
+	}
+	if len(events) > 0 {
+		page.Changes, page.LastSeq = events, events[len(events)-1].Seq
+	}
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors
+	"math/rand/v2"  // for picking the requests to hit
+	"net/http"      // for the middleware
+	"net/url"       // for the settings as key=value pairs
+	"strconv"       // for percentages
+	"strings"       // for the flag syntax
+	"sync"          // for guarding the settings
+	"time"          // for injected latency
+)
+
+// fault injection, to see whether clients cope with a slow and unreliable server: on a share of
+// requests, random latency, a 500 or a dropped connection. off unless -chaos or the admin
+// endpoint turns it on
+var chaosFlag = flags.String("chaos", "", "start with fault injection on, e.g. latency=500ms,latency_percent=20,error_percent=5,drop_percent=1")
+
+// ChaosStatus is the body of GET /admin/chaos
+type ChaosStatus struct {
+	Enabled        bool    `json:"enabled"`
+	Latency        string  `json:"latency"`         // longest added delay, each hit gets a random one up to it
+	LatencyPercent float64 `json:"latency_percent"` // share of requests delayed
+	ErrorPercent   float64 `json:"error_percent"`   // share answered 500
+	DropPercent    float64 `json:"drop_percent"`    // share whose connection is cut without an answer
+	Injected       struct {
+		Latency int64 `json:"latency"`
+		Errors  int64 `json:"errors"`
+		Drops   int64 `json:"drops"`
+	} `json:"injected"` // since the server started
+}
+
+// the settings, and what they have done so far
+var chaos struct {
+	sync.Mutex
+	enabled                       bool
+	latency                       time.Duration
+	latencyPct, errorPct, dropPct float64
+	latencies, errors, drops      int64
+}
+
+// probes are left alone, so an orchestrator doesn't restart a server put under chaos on purpose
+var chaosExempt = map[string]bool{"/healthz": true, "/livez": true, "/readyz": true}
+
+
+// loadChaos applies -chaos
+func loadChaos() error {
+
+	if *chaosFlag == "" {
+		return nil
+	}
+	values, err := url.ParseQuery(strings.ReplaceAll(*chaosFlag, ",", "&"))
+	if err != nil {
+		return fmt.Errorf("-chaos: %w", err)
+	}
+	if !values.Has("enabled") {
+		values.Set("enabled", "true")
+	}
+	if err := setChaos(values); err != nil {
+		return fmt.Errorf("-chaos: %w", err)
+	}
+	return nil
+}
+
+
+// setChaos changes the settings named in values (enabled, latency, latency_percent,
+// error_percent, drop_percent), the others stay; nothing changes on an error
+func setChaos(values url.Values) error {
+
+	chaos.Lock()
+	defer chaos.Unlock()
+
+	enabled, latency := chaos.enabled, chaos.latency
+	pcts := map[string]*float64{"latency_percent": &chaos.latencyPct, "error_percent": &chaos.errorPct, "drop_percent": &chaos.dropPct}
+	next := map[string]float64{}
+	for key, values := range values {
+		v := values[0]
+		var err error
+		switch key {
+		case "enabled":
+			enabled, err = strconv.ParseBool(v)
+		case "latency":
+			latency, err = time.ParseDuration(v)
+			if err == nil && (latency < 0 || latency > time.Minute) {
+				err = fmt.Errorf("longer than 1m")
+			}
+		case "latency_percent", "error_percent", "drop_percent":
+			var pct float64
+			pct, err = strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+			if err == nil && (pct < 0 || pct > 100) {
+				err = fmt.Errorf("not between 0 and 100")
+			}
+			next[key] = pct
+		default:
+			return fmt.Errorf("unknown setting %q (enabled, latency, latency_percent, error_percent, drop_percent)", key)
+		}
+		if err != nil {
+			return fmt.Errorf("%s=%s: %v", key, v, err)
+		}
+	}
+
+	chaos.enabled, chaos.latency = enabled, latency
+	for key, pct := range next {
+		*pcts[key] = pct
+	}
+	return nil
+}
+
+
+// currentChaos returns a consistent view of the settings
+func currentChaos() ChaosStatus {
+	chaos.Lock()
+	defer chaos.Unlock()
+
+	status := ChaosStatus{
+		Enabled:        chaos.enabled,
+		Latency:        chaos.latency.String(),
+		LatencyPercent: chaos.latencyPct,
+		ErrorPercent:   chaos.errorPct,
+		DropPercent:    chaos.dropPct,
+	}
+	status.Injected.Latency, status.Injected.Errors, status.Injected.Drops = chaos.latencies, chaos.errors, chaos.drops
+	return status
+}
+
+
+// hit decides, once per fault, whether this request gets it
+func hit(pct float64) bool {
+	return pct > 0 && rand.Float64()*100 < pct
+}
+
+
+// injectChaos delays, fails or drops requests as the chaos settings say
+func injectChaos(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		chaos.Lock()
+		on := chaos.enabled && !chaosExempt[r.URL.Path]
+		var delay time.Duration
+		failed, dropped := false, false
+		if on {
+			if chaos.latency > 0 && hit(chaos.latencyPct) {
+				delay = rand.N(chaos.latency) + 1
+				chaos.latencies++
+			}
+			if dropped = hit(chaos.dropPct); dropped {
+				chaos.drops++
+			} else if failed = hit(chaos.errorPct); failed {
+				chaos.errors++
+			}
+		}
+		chaos.Unlock()
+
+		if delay > 0 {
+			w.Header().Add("Chaos-Injected", "latency")
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		// the server closes the connection (resets the stream on HTTP/2) without logging a panic
+		if dropped {
+			panic(http.ErrAbortHandler)
+		}
+		if failed {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Chaos-Injected", "error")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "internal error"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+
+// admin: GET shows the chaos settings and counts, POST ?enabled=true&latency=500ms&latency_percent=20&
+// error_percent=5&drop_percent=1 changes the ones it names
+func chaosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		// just report
+
+	case http.MethodPost:
+		if err := setChaos(r.URL.Query()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		fmt.Println("chaos settings changed:", r.URL.RawQuery)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(currentChaos())
+}
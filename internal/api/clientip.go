@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"  // for HTTP requests
+	"net/netip" // for address parsing
+	"strings"   // for header parsing
+)
+
+// proxies whose forwarding headers we believe
+var trustedProxyCIDRs = flags.String("trusted-proxies", "", "comma separated CIDRs of reverse proxies allowed to set X-Forwarded-For / X-Real-IP")
+
+// parsed list, filled once at startup by loadTrustedProxies
+var trustedProxies []netip.Prefix
+
+
+// loadTrustedProxies parses -trusted-proxies
+func loadTrustedProxies() error {
+	var err error
+	trustedProxies, err = parsePrefixes(*trustedProxyCIDRs)
+	return err
+}
+
+
+// clientIP returns the real client address: the connection peer, unless the peer is a
+// trusted proxy, in which case the forwarding headers are consulted (ok=false for unix sockets
+// without usable headers)
+func clientIP(r *http.Request) (netip.Addr, bool) {
+
+	peer, ok := remoteAddr(r)
+
+	// unix socket peers are always the local proxy, so trust their headers too
+	trusted := !ok || matchesAny(trustedProxies, peer)
+	if !trusted {
+		return peer, ok
+	}
+
+	// X-Forwarded-For: client, proxy1, proxy2 -> walk right to left, skipping our own proxies
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// garbage in the chain, stop trusting anything further left
+				break
+			}
+			addr = addr.Unmap()
+			if !matchesAny(trustedProxies, addr) || i == 0 {
+				return addr, true
+			}
+		}
+	}
+
+	// single-hop proxies (nginx) often send only X-Real-IP
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); real != "" {
+		if addr, err := netip.ParseAddr(real); err == nil {
+			return addr.Unmap(), true
+		}
+	}
+
+	return peer, ok
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"           // for store calls outside a request
+	"encoding/json"     // for the replicated mutations and JSON responses
+	"errors"            // for config errors
+	"fmt"               // for printing logs to terminal and errors
+	"net"               // for the cluster listener
+	"net/http"          // for the cluster server and forwarding
+	"net/http/httputil" // for forwarding writes to the leader
+	"net/url"           // for peer and leader URLs
+	"strings"           // for parsing -cluster-peers
+	"sync"              // for the forwarding proxies
+	"time"              // for server timeouts
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for the unavailable error
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/raft"    // for consensus
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store"   // for replicated mutations
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for passing traces on to the leader
+)
+
+// clustered mode: a few servers keep the same todos by replicating every change through a Raft
+// log, so losing one (of three, or two of five) loses nothing and the rest carry on. the leader
+// makes the changes: a change is made once a majority of the nodes has it in its log, and the
+// others apply it after. any node answers reads from its own copy; writes sent to a follower are
+// forwarded to the leader. the nodes talk on their own -cluster-addr, with -cluster-secret
+var (
+	clusterID        = flags.String("cluster-id", "", "this node's id in -cluster-peers (clustering is off when empty)")
+	clusterPeers     = flags.String("cluster-peers", "", "every node of the cluster, this one included: id=http://host:port of its -cluster-addr, comma separated")
+	clusterAddr      = flags.String("cluster-addr", "", "listen address for the other nodes' Raft calls, e.g. :7000")
+	clusterDir       = flags.String("cluster-dir", "", "directory of this node's Raft log (default cluster-<id>)")
+	clusterSecret    = flags.String("cluster-secret", "", "shared secret the nodes authenticate each other with")
+	clusterAdvertise = flags.String("cluster-advertise", "", "this node's public URL, for followers to forward writes to while it leads, e.g. http://10.0.0.1:8080")
+)
+
+// request header marking a write forwarded by a follower, so it isn't forwarded again
+const forwardedHeader = "Todo-Forwarded-By"
+
+// the node, while clustered, and the nodes of -cluster-peers
+var cluster *raft.Node
+var clusterNodes map[string]string
+
+// proxies forwarding to the leader, by its URL
+var leaderProxies = make(map[string]*httputil.ReverseProxy)
+var leaderProxiesMu sync.Mutex
+
+
+// clustered reports whether the server is one node of a cluster
+func clustered() bool {
+	return *clusterID != ""
+}
+
+
+// loadCluster checks the cluster flags
+func loadCluster() error {
+
+	if !clustered() {
+		return nil
+	}
+	switch {
+	case *clusterAddr == "":
+		return errors.New("-cluster-id needs a -cluster-addr for the other nodes to call")
+	case *clusterSecret == "":
+		return errors.New("-cluster-id needs a -cluster-secret")
+	case *clusterAdvertise == "":
+		return errors.New("-cluster-id needs a -cluster-advertise URL for forwarded writes")
+	case *mockMode:
+		return errors.New("-cluster-id: a mock server can't be clustered")
+	case *tenantsPath != "":
+		return errors.New("-cluster-id: tenants aren't replicated, leave out -tenants")
+	}
+	if u, err := url.Parse(*clusterAdvertise); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("-cluster-advertise: not an http(s) URL")
+	}
+
+	clusterNodes = map[string]string{}
+	for _, peer := range strings.Split(*clusterPeers, ",") {
+		peer = strings.TrimSpace(peer)
+		if peer == "" {
+			continue
+		}
+		id, addr, found := strings.Cut(peer, "=")
+		if u, err := url.Parse(addr); !found || id == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("-cluster-peers: %q is not id=http://host:port", peer)
+		}
+		if _, dup := clusterNodes[id]; dup {
+			return fmt.Errorf("-cluster-peers: %s is in twice", id)
+		}
+		clusterNodes[id] = addr
+	}
+	if _, ok := clusterNodes[*clusterID]; !ok {
+		return fmt.Errorf("-cluster-peers: has no %s, this node", *clusterID)
+	}
+	return nil
+}
+
+
+// storeOptions are the options of the server's store, replicated when clustered or sharing
+// its todos through Redis
+func storeOptions() []store.Option {
+	opts := []store.Option{store.WithHistorySize(*historySize)}
+	if clustered() {
+		opts = append(opts, store.WithReplicator(guarded(clusterReplicator{})))
+	}
+	if shared != nil {
+		opts = append(opts, store.WithReplicator(guarded(shared)), store.WithIDGenerator(shared))
+	}
+	return opts
+}
+
+// clusterReplicator proposes the store's changes to the cluster
+type clusterReplicator struct{}
+
+
+// Replicate returns once the cluster has committed m; the request may go away meanwhile, the
+// proposal doesn't, so the log and the leader's store stay in the same order
+func (clusterReplicator) Replicate(ctx context.Context, m store.Mutation) error {
+	data, _ := json.Marshal(m)
+	err := cluster.Propose(context.WithoutCancel(ctx), data)
+	switch {
+	case errors.Is(err, raft.ErrNotLeader):
+		// nothing was proposed, so trying again is safe: the leader may just be catching up
+		return fmt.Errorf("this node doesn't lead the cluster: %w: %w", store.ErrTransient, model.ErrUnavailable)
+	case errors.Is(err, raft.ErrNotCommitted):
+		return fmt.Errorf("the cluster didn't commit the change: %w", model.ErrUnavailable)
+	case err != nil:
+		return fmt.Errorf("cluster log: %v: %w", err, model.ErrUnavailable)
+	}
+	return nil
+}
+
+
+// startCluster joins the cluster (call after Handler): the log on disk is replayed into the store
+// once a leader says how much of it is committed
+func startCluster() error {
+
+	if !clustered() {
+		return nil
+	}
+	dir := *clusterDir
+	if dir == "" {
+		dir = "cluster-" + *clusterID
+	}
+	node, err := raft.Start(raft.Config{
+		ID:        *clusterID,
+		Peers:     clusterNodes,
+		Dir:       dir,
+		Secret:    *clusterSecret,
+		Advertise: strings.TrimSuffix(*clusterAdvertise, "/"),
+		Apply: func(data []byte) {
+			var m store.Mutation
+			if err := json.Unmarshal(data, &m); err != nil {
+				fmt.Println("cluster: skipping an entry that isn't a mutation:", err)
+				return
+			}
+			todoStore.Apply(m)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	cluster = node
+	fmt.Println("cluster: node", *clusterID, "of", len(clusterNodes), "joined, log in", dir)
+	return nil
+}
+
+
+// stopCluster leaves the cluster, once the server has drained
+func stopCluster() {
+	if cluster != nil {
+		cluster.Stop()
+	}
+}
+
+
+// startClusterServer serves the Raft calls of the other nodes and their handoffs (nil when not clustered)
+func startClusterServer(ln net.Listener) *http.Server {
+
+	if ln == nil || cluster == nil {
+		return nil
+	}
+	// the Raft calls, and what followers hand over to the leader (see handoff.go)
+	mux := http.NewServeMux()
+	mux.Handle("/raft/", cluster.Handler())
+	mux.HandleFunc("POST /handoff/{kind}", handoffHandler)
+	srv := &http.Server{Handler: traceClusterCalls(nameSpans(mux)), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Println("Cluster server started on", ln.Addr())
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Println("cluster server stopped:", err)
+		}
+	}()
+	return srv
+}
+
+
+// traceClusterCalls gives the calls that carry a trace (handoffs, appends of traced proposals) a
+// span in it; heartbeats and votes carry none and stay out of the traces
+func traceClusterCalls(next http.Handler) http.Handler {
+
+	traced := traceRequests(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		traced.ServeHTTP(w, r)
+	})
+}
+
+
+// forwardWrites sends the writes a follower gets to the leader, as they came; reads are served
+// from the follower's copy
+func forwardWrites(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// reads, and everything on the leader or outside a cluster, are ours
+		if cluster == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions || cluster.IsLeader() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// forwarded once already means leadership moved meanwhile: let the client try again
+		leader, leaderURL := cluster.Leader()
+		if leaderURL == "" || leader == *clusterID || r.Header.Get(forwardedHeader) != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "the cluster has no leader, try again"})
+			return
+		}
+		r.Header.Set(forwardedHeader, *clusterID)
+		tracing.Propagate(r.Header, tracing.FromContext(r.Context()).Traceparent())
+		leaderProxy(leaderURL).ServeHTTP(w, r)
+	})
+}
+
+
+// leaderProxy returns the proxy to the leader at base
+func leaderProxy(base string) *httputil.ReverseProxy {
+	leaderProxiesMu.Lock()
+	defer leaderProxiesMu.Unlock()
+
+	proxy, ok := leaderProxies[base]
+	if !ok {
+		target, _ := url.Parse(base)
+		proxy = httputil.NewSingleHostReverseProxy(target)
+
+		// the client's trace went through this node first, whose span traceresponse names already
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			resp.Header.Del("traceresponse")
+			return nil
+		}
+		leaderProxies[base] = proxy
+	}
+	return proxy
+}
+
+
+// admin: GET /admin/cluster reports this node's view of the cluster
+func clusterHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	if cluster == nil {
+		writeError(w, r, fmt.Errorf("cluster: not clustered: %w", model.ErrNotFound))
+		return
+	}
+	json.NewEncoder(w).Encode(cluster.Status())
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"compress/gzip" // for gzip responses
+	"errors"        // for config errors
+	"io"            // for the encoder interface
+	"net/http"      // for HTTP middleware
+	"strconv"       // for q-values
+	"strings"       // for header parsing
+	"sync"          // for pooling gzip writers
+)
+
+// response compression config
+var compressEncodings = flags.String("compress", "br,gzip", "content codings offered to clients, in order of preference (empty disables compression)")
+var brotliQuality = flags.Int("brotli-quality", 5, "brotli quality, 0 (fastest) to 11 (smallest)")
+var gzipLevel = flags.Int("gzip-level", gzip.DefaultCompression, "gzip level, 1 (fastest) to 9 (smallest), -1 for the default")
+var compressMinSize = flags.Int("compress-min-size", 1024, "responses smaller than this many bytes are sent uncompressed")
+
+// codings this server implements, plus the parsed -compress list
+var supportedEncodings = map[string]bool{"br": true, "gzip": true}
+var offeredEncodings []string
+
+// gzip writers are big (about 800 KiB at level 9), so they're reused
+var gzipWriters sync.Pool
+
+// compressor is what gzip and brotli writers have in common
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter compresses the response once it's known to be compressible and big enough
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	status      int        // held back while buffering
+	buf         []byte     // first bytes, until there are -compress-min-size of them
+	enc         compressor // set once compressing
+	decided     bool       // status sent (compressed or not)
+	headerSeen  bool       // handler called WriteHeader or Write
+	passthrough bool       // not compressing this response
+}
+
+
+// setupCompression validates the compression flags
+func setupCompression() error {
+
+	offeredEncodings = nil
+	for _, name := range strings.Split(*compressEncodings, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !supportedEncodings[name] {
+			return errors.New("-compress: unsupported coding " + strconv.Quote(name) + " (want br, gzip)")
+		}
+		offeredEncodings = append(offeredEncodings, name)
+	}
+	if *brotliQuality < 0 || *brotliQuality > 11 {
+		return errors.New("-brotli-quality must be between 0 and 11")
+	}
+	if *gzipLevel != gzip.DefaultCompression && (*gzipLevel < gzip.BestSpeed || *gzipLevel > gzip.BestCompression) {
+		return errors.New("-gzip-level must be between 1 and 9, or -1")
+	}
+	return nil
+}
+
+
+// negotiateEncoding picks the coding with the highest q-value in Accept-Encoding,
+// ties going to the -compress order ("" = identity)
+func negotiateEncoding(accept string) string {
+
+	// q-value per coding the client listed ("*" covers the rest)
+	quality := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		quality[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range offeredEncodings {
+		q, listed := quality[name]
+		if !listed {
+			q = quality["*"]
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+
+// compressible is true for text-like content types, where compression pays off
+func compressible(contentType string) bool {
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") ||
+		strings.Contains(mediaType, "xml") || strings.Contains(mediaType, "javascript")
+}
+
+
+// compressResponses compresses response bodies with the best coding the client accepts
+func compressResponses(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// websocket upgrades take over the connection, nothing to compress
+		if len(offeredEncodings) == 0 || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the response depends on Accept-Encoding, whether or not this one gets compressed
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+
+// WriteHeader decides whether the response can be compressed; the status itself is held
+// back until there's enough body to know whether it's worth it
+func (c *compressWriter) WriteHeader(code int) {
+
+	if c.headerSeen {
+		return
+	}
+
+	// informational responses don't count, the real one follows
+	if code >= 100 && code < 200 {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+	c.headerSeen = true
+	c.status = code
+
+	h := c.Header()
+	if code == http.StatusNoContent || code == http.StatusNotModified || h.Get("Content-Encoding") != "" || !compressible(h.Get("Content-Type")) {
+		c.passthrough = true
+		c.decided = true
+		c.ResponseWriter.WriteHeader(code)
+	}
+}
+
+
+// Write buffers the start of the body, then compresses (or passes through) the rest
+func (c *compressWriter) Write(b []byte) (int, error) {
+
+	// like net/http: no WriteHeader means 200, no Content-Type means sniffed from the body
+	if !c.headerSeen {
+		if c.Header().Get("Content-Type") == "" {
+			c.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		c.WriteHeader(http.StatusOK)
+	}
+
+	switch {
+	case c.passthrough:
+		return c.ResponseWriter.Write(b)
+	case c.enc != nil:
+		return c.enc.Write(b)
+	}
+
+	c.buf = append(c.buf, b...)
+	if len(c.buf) >= *compressMinSize {
+		if err := c.startCompressing(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+
+// startCompressing sends the held back status with Content-Encoding, and the buffered body through the encoder
+func (c *compressWriter) startCompressing() error {
+
+	h := c.Header()
+	h.Set("Content-Encoding", c.encoding)
+	h.Del("Content-Length") // of the uncompressed body
+	c.ResponseWriter.WriteHeader(c.status)
+	c.decided = true
+
+	switch c.encoding {
+	case "br":
+		c.enc = newBrotliWriter(c.ResponseWriter, *brotliQuality)
+	case "gzip":
+		if gz, ok := gzipWriters.Get().(*gzip.Writer); ok {
+			gz.Reset(c.ResponseWriter)
+			c.enc = gz
+		} else {
+			c.enc, _ = gzip.NewWriterLevel(c.ResponseWriter, *gzipLevel)
+		}
+	}
+
+	_, err := c.enc.Write(c.buf)
+	c.buf = nil
+	return err
+}
+
+
+// FlushError sends what's written so far; a streaming response starts compressing
+// right away even below -compress-min-size, since its final size isn't known
+func (c *compressWriter) FlushError() error {
+
+	// flushing before any body sends the headers: decide on them now
+	if !c.headerSeen {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.decided {
+		if err := c.startCompressing(); err != nil {
+			return err
+		}
+	}
+	if c.enc != nil {
+		if err := c.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+
+// Unwrap lets http.ResponseController reach the underlying writer (deadlines)
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+
+// close ends the response: a body that stayed small goes out as it is, a compressed one is finished
+func (c *compressWriter) close() {
+
+	switch {
+	case c.passthrough || !c.headerSeen:
+		return
+	case c.enc == nil:
+		c.ResponseWriter.WriteHeader(c.status)
+		c.ResponseWriter.Write(c.buf)
+		return
+	}
+
+	c.enc.Close()
+	if gz, ok := c.enc.(*gzip.Writer); ok {
+		gzipWriters.Put(gz)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json" // for the single todo response
+	"net/http"      // for conditional GET headers
+	"time"          // for modification times
+)
+
+// conditional GETs: the store remembers when the collection and each todo last changed, and
+// GET /todos and GET /todos/get answer If-Modified-Since with 304 when nothing changed since,
+// so polling clients get an empty response instead of the whole list
+
+
+// notModified sets Last-Modified from modified and reports whether the request's
+// If-Modified-Since makes the body unnecessary, in which case it has answered 304
+func notModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+
+	// HTTP dates have whole seconds: a change in the current second could be followed by another
+	// one with the same Last-Modified, so it's only sent once that second is over
+	modified = modified.Truncate(time.Second)
+	if !modified.Before(todoStore.Now().Truncate(time.Second)) {
+		return false
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.After(since) {
+		return false
+	}
+
+	// 304 = client's copy is current, no body
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+
+// get one todo by id (?id=1), with Last-Modified
+func getTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// read id from query param
+	id, err := queryID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// 404 if todo doesn't exist
+	modified, err := storeFor(r).TodoModified(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, modified) {
+		return
+	}
+
+	// it may have changed (or gone) since modified was read: then Last-Modified is older than
+	// the body, and the next conditional GET just gets the todo again
+	todo, err := todoStore.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if wantsProto(r) {
+		writeProto(w, marshalTodoProto(todo))
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
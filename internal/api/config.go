@@ -0,0 +1,147 @@
+package api
+
+import (
+	"flag" // for command line config
+)
+
+// command line config. every setting is a flag on this set rather than on flag.CommandLine,
+// so a program embedding the API (package server) keeps its own flags to itself
+var flags = flag.NewFlagSet("todo-server", flag.ContinueOnError)
+
+
+// Configure parses args (the server's command line flags, without the program name) and applies
+// them; flags args doesn't set go back to their defaults, whatever an earlier call set
+func Configure(args []string) error {
+
+	flags.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+	})
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	return applyConfig()
+}
+
+
+// applyConfig validates the parsed flags and sets up what depends on them
+func applyConfig() error {
+
+	// honour -maintenance at startup
+	maintenance.Store(*startInMaintenance)
+
+	// fault injection, if asked to start with it
+	if err := loadChaos(); err != nil {
+		return err
+	}
+
+	// experimental features, on unless -features says otherwise
+	if err := loadFeatures(); err != nil {
+		return err
+	}
+
+	// the time zone for clients that don't send one
+	if err := loadTimezone(); err != nil {
+		return err
+	}
+
+	// message catalogs for Accept-Language
+	if err := loadCatalogs(); err != nil {
+		return err
+	}
+
+	// canned data and injected faults for client testing
+	if err := loadMockRoutes(); err != nil {
+		return err
+	}
+
+	// weights of the "next up" ranking
+	if err := loadNextScore(); err != nil {
+		return err
+	}
+
+	// work-in-progress limits of the board columns
+	if err := loadBoardWIP(); err != nil {
+		return err
+	}
+
+	// where mentioned actors are mailed
+	if err := loadMentionEmail(); err != nil {
+		return err
+	}
+
+	// how long the trash and done todos are kept
+	if err := loadRetention(); err != nil {
+		return err
+	}
+
+	// scheduled and recurring todos and pending erasures kept from before a restart
+	if err := loadScheduled(); err != nil {
+		return err
+	}
+	if err := loadRecurring(); err != nil {
+		return err
+	}
+	if err := loadErasures(); err != nil {
+		return err
+	}
+
+	// the other nodes, when clustered
+	if err := loadCluster(); err != nil {
+		return err
+	}
+
+	// the other replicas' shared todos
+	if err := loadRedis(); err != nil {
+		return err
+	}
+
+	// isolated namespaces and what selects them
+	if err := loadTenants(); err != nil {
+		return err
+	}
+
+	// where attachment bytes are kept
+	if err := loadBlobStore(); err != nil {
+		return err
+	}
+
+	// sample data to start with
+	if err := checkSeedSet(); err != nil {
+		return err
+	}
+
+	// parse the CIDR perimeter
+	if err := loadIPFilter(); err != nil {
+		return err
+	}
+
+	// the access log's file and rotation
+	if err := loadAccessLog(); err != nil {
+		return err
+	}
+
+	// response compression codings and levels
+	if err := setupCompression(); err != nil {
+		return err
+	}
+
+	// notification integrations (chat, mail)
+	if err := setupSlack(); err != nil {
+		return err
+	}
+	if err := setupDiscord(); err != nil {
+		return err
+	}
+	if err := setupReminderMails(); err != nil {
+		return err
+	}
+	if err := loadVAPIDKey(); err != nil {
+		return err
+	}
+
+	// keys for signed feed and attachment URLs, and actor tokens
+	loadFeedSecret()
+	loadActorSecret()
+	loadAttachmentURLSecret()
+	return nil
+}
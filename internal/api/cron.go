@@ -0,0 +1,212 @@
+package api
+
+import (
+	"fmt"     // for parse errors
+	"strconv" // for the fields' numbers
+	"strings" // for splitting the fields
+	"time"    // for the times a schedule gives
+)
+
+// cron expressions, as in a crontab: five fields, minute hour day-of-month month day-of-week, each
+// a *, a number, a range (1-5), any of them with a step (*/15, 8-18/2), or a comma separated list
+// of those; months and days of the week can be named (jan, mon), Sunday is 0 or 7. a day matches
+// when both day fields do, or either one when neither is a * (as cron has it). @yearly, @monthly,
+// @weekly, @daily and @hourly stand for the usual lines. the times are wall clock times in the
+// schedule's zone: one the clocks skip when they go forward doesn't happen that day, one they go
+// through twice when they go back happens once
+
+// how far ahead a schedule is searched for its next time (Feb 29 on a Monday comes around that rarely)
+const cronSearchYears = 28
+
+// the lines the @ names stand for
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// the five fields in order, with their values and names
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string // for min, min+1...
+}{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronSchedule is a parsed cron expression read in a zone
+type cronSchedule struct {
+	fields [5]uint64 // per field, bit n set when n is one of its values
+	anyDay bool      // either day field is a *, so a day must match both
+	loc    *time.Location
+}
+
+
+// parseCron parses a cron expression, read in loc
+func parseCron(expr string, loc *time.Location) (cronSchedule, error) {
+
+	expr = strings.TrimSpace(expr)
+	if line, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = line
+	} else if strings.HasPrefix(expr, "@") {
+		return cronSchedule{}, fmt.Errorf("%s is not one of @yearly, @monthly, @weekly, @daily, @hourly", expr)
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return cronSchedule{}, fmt.Errorf("needs 5 fields (minute hour day-of-month month day-of-week), not %d", len(parts))
+	}
+
+	s := cronSchedule{loc: loc, anyDay: strings.HasPrefix(parts[2], "*") || strings.HasPrefix(parts[4], "*")}
+	for i, part := range parts {
+		bits, err := parseCronField(part, i)
+		if err != nil {
+			return cronSchedule{}, err
+		}
+		s.fields[i] = bits
+	}
+
+	// Sunday is day 0 as well as 7
+	if s.fields[4]&(1<<7) != 0 {
+		s.fields[4] |= 1
+	}
+	return s, nil
+}
+
+
+// parseCronField parses the i'th field into its bit set
+func parseCronField(s string, i int) (uint64, error) {
+
+	f := cronFields[i]
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		span, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s: %q has a step that isn't a positive number", f.name, item)
+			}
+			step = n
+		}
+
+		// *, a value (up to the end with a step) or a range
+		lo, hi := f.min, f.max
+		if span != "*" {
+			from, to, isRange := strings.Cut(span, "-")
+			var err error
+			if lo, err = cronValue(from, i); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = cronValue(to, i); err != nil {
+					return 0, err
+				}
+				if hi < lo {
+					return 0, fmt.Errorf("%s: %q runs backwards", f.name, item)
+				}
+			case !hasStep:
+				hi = lo
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+
+// cronValue reads one value of the i'th field, a number or a name
+func cronValue(s string, i int) (int, error) {
+
+	f := cronFields[i]
+	for n, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + n, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not a value from %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+
+// has reports whether v is one of the i'th field's values
+func (s cronSchedule) has(i, v int) bool {
+	return s.fields[i]&(1<<v) != 0
+}
+
+
+// matchesDay reports whether the schedule runs on the date y-m-d
+func (s cronSchedule) matchesDay(y int, m time.Month, d int) bool {
+
+	if !s.has(3, int(m)) {
+		return false
+	}
+	dom := s.has(2, d)
+	dow := s.has(4, int(time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Weekday()))
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+
+// next returns the schedule's first time after t, ok=false when there's none in cronSearchYears
+func (s cronSchedule) next(t time.Time) (time.Time, bool) {
+
+	t = t.In(s.loc)
+	y, m, d := t.Date()
+	for i := 0; i < cronSearchYears*366; i++ {
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, time.UTC)
+		if !s.matchesDay(day.Year(), day.Month(), day.Day()) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !s.has(1, hour) {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if !s.has(0, minute) {
+					continue
+				}
+				at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, s.loc)
+				// a time the clocks skip comes out as another one
+				if at.Hour() != hour || at.Minute() != minute {
+					continue
+				}
+				if at.After(t) {
+					return at, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+
+// upcoming returns the schedule's first n times after t (fewer if it runs out)
+func (s cronSchedule) upcoming(t time.Time, n int) []time.Time {
+
+	times := []time.Time{}
+	for len(times) < n {
+		at, ok := s.next(t)
+		if !ok {
+			break
+		}
+		times = append(times, at)
+		t = at
+	}
+	return times
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"strings" // for matching parse errors
+	"testing" // for the tests
+	"time"    // for the times a schedule gives
+)
+
+// cron tests: parse errors, and next runs around the clock changes, the day fields and the macros
+
+
+// TestParseCronErrors checks the expressions parseCron turns away, and what it says about them
+func TestParseCronErrors(t *testing.T) {
+
+	tests := []struct {
+		expr string
+		want string // part of the error
+	}{
+		{"", "needs 5 fields"},
+		{"* * * *", "needs 5 fields"},
+		{"* * * * * *", "needs 5 fields"},
+		{"@fortnightly", "is not one of"},
+		{"60 * * * *", "minute"},
+		{"* 24 * * *", "hour"},
+		{"* * 0 * *", "day of month"},
+		{"* * * 13 *", "month"},
+		{"* * * * 8", "day of week"},
+		{"*/0 * * * *", "step"},
+		{"*/x * * * *", "step"},
+		{"30-10 * * * *", "runs backwards"},
+		{"* * * foo *", "month"},
+	}
+	for _, tt := range tests {
+		_, err := parseCron(tt.expr, time.UTC)
+		if err == nil {
+			t.Errorf("parseCron(%q) = nil error, want one containing %q", tt.expr, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseCron(%q) = %q, want it to contain %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+
+// TestCronNext checks the first run after a time, in UTC and across the clocks going forward and back
+func TestCronNext(t *testing.T) {
+
+	newYork := mustZone(t, "America/New_York")
+	berlin := mustZone(t, "Europe/Berlin")
+
+	tests := []struct {
+		name  string
+		expr  string
+		loc   *time.Location
+		after time.Time
+		want  time.Time
+	}{
+		{"every minute", "* * * * *", time.UTC,
+			time.Date(2026, 5, 1, 10, 0, 30, 0, time.UTC), time.Date(2026, 5, 1, 10, 1, 0, 0, time.UTC)},
+		{"strictly after", "0 9 * * *", time.UTC,
+			time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"step", "*/15 8-18/2 * * *", time.UTC,
+			time.Date(2026, 5, 1, 9, 10, 0, 0, time.UTC), time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"list", "5,50 * * * *", time.UTC,
+			time.Date(2026, 5, 1, 9, 10, 0, 0, time.UTC), time.Date(2026, 5, 1, 9, 50, 0, 0, time.UTC)},
+		{"named days", "0 9 * * mon-fri", time.UTC,
+			time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)}, // Fri to Mon
+		{"seven is sunday", "0 0 * * 7", time.UTC,
+			time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"either day field", "0 0 13 * fri", time.UTC,
+			time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC)}, // a Friday before the 13th
+		{"both with a star", "0 0 */10 * fri", time.UTC,
+			time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)}, // the 31st and a Friday (days 1, 11, 21, 31)
+		{"leap day", "0 0 29 2 *", time.UTC,
+			time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"macro", "@monthly", time.UTC,
+			time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"macro in capitals", "@HOURLY", time.UTC,
+			time.Date(2026, 5, 15, 7, 30, 0, 0, time.UTC), time.Date(2026, 5, 15, 8, 0, 0, 0, time.UTC)},
+		{"zone", "0 9 * * *", newYork,
+			time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2026, 5, 1, 9, 0, 0, 0, newYork)},
+
+		// 2026-03-08 02:00 EST the clocks go to 03:00 EDT: 02:30 doesn't happen that day
+		{"skipped by spring forward", "30 2 * * *", newYork,
+			time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 9, 2, 30, 0, 0, newYork)},
+		{"just after spring forward", "30 3 * * *", newYork,
+			time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 8, 3, 30, 0, 0, newYork)},
+		{"hourly over spring forward", "0 * * * *", newYork,
+			time.Date(2026, 3, 8, 1, 30, 0, 0, newYork), time.Date(2026, 3, 8, 3, 0, 0, 0, newYork)},
+
+		// 2026-11-01 02:00 EDT the clocks go back to 01:00 EST: 01:30 comes twice and runs the first time
+		{"first of the repeated hour", "30 1 * * *", newYork,
+			time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)},
+		{"repeated hour runs once", "30 1 * * *", newYork,
+			time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)},
+
+		// Europe goes forward 2026-03-29 02:00 CET to 03:00 CEST
+		{"skipped in Berlin", "15 2 * * *", berlin,
+			time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), time.Date(2026, 3, 30, 2, 15, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseCron(tt.expr, tt.loc)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.expr, err)
+			}
+			got, ok := s.next(tt.after)
+			if !ok {
+				t.Fatalf("next(%v) found no time", tt.after)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("next(%v) = %v, want %v", tt.after, got, tt.want.In(tt.loc))
+			}
+		})
+	}
+}
+
+
+// TestCronNextNone checks a schedule that never comes round finds no time
+func TestCronNextNone(t *testing.T) {
+
+	s, err := parseCron("0 0 31 2 *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("next() = %v for Feb 31, want none", got)
+	}
+}
+
+
+// TestCronUpcoming checks the times in a row, none twice over the clocks going back
+func TestCronUpcoming(t *testing.T) {
+
+	newYork := mustZone(t, "America/New_York")
+	s, err := parseCron("0 */1 * * *", newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.upcoming(time.Date(2026, 11, 1, 0, 30, 0, 0, newYork), 3)
+	want := []time.Time{
+		time.Date(2026, 11, 1, 1, 0, 0, 0, newYork), // 01:00 EDT
+		time.Date(2026, 11, 1, 2, 0, 0, 0, newYork), // 02:00 EST, 01:00 EST has the same wall time as one already run
+		time.Date(2026, 11, 1, 3, 0, 0, 0, newYork),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("upcoming() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("upcoming()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package api
+
+import (
+	"bytes"         // for rendering before writing
+	"fmt"           // for printing logs to terminal
+	"html/template" // for the page, escaped
+	"net/http"      // for HTTP handlers
+	"net/url"       // for the notice after an action
+	"runtime"       // for goroutine and memory numbers
+	"slices"        // for newest-first changes
+	"sort"          // for webhook order
+	"strconv"       // for form values
+	"time"          // for uptime
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// changes shown on the dashboard, newest first
+const dashboardChanges = 50
+
+// the admin dashboard at /admin/: what the admin JSON endpoints report, on one page, with
+// forms for the switches. it sits behind -admin-token like every admin endpoint; browsers
+// log in with basic auth, the token as password
+type dashboardData struct {
+	Version     VersionInfo
+	Uptime      time.Duration
+	Goroutines  int
+	HeapBytes   uint64
+	Stats       TodoStats
+	Cache       ListCacheStats
+	Maintenance MaintenanceStatus
+	Chaos       ChaosStatus
+	Features    []FeatureStatus
+	Webhooks    []Webhook
+	DeadLetters int
+	Changes     []model.Event
+	LastSeq     uint64
+	MaxTodos    int
+	ArchiveFile string
+	Notice      string // what the last action did
+}
+
+const dashboardTemplate = `<!doctype html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>todo-server admin</title>
+	<style>
+		body { margin: 2rem; font: 15px/1.5 system-ui, sans-serif; color: #18181b; }
+		section { margin-bottom: 2rem; }
+		table { border-collapse: collapse; }
+		th, td { padding: .25rem .8rem .25rem 0; text-align: left; vertical-align: top; }
+		th { font-weight: 600; }
+		form { display: inline; }
+		.notice { padding: .5rem .8rem; background: #dcfce7; }
+		.warn { color: #b91c1c; }
+		.muted { color: #71717a; }
+	</style>
+</head>
+<body>
+	<h1>todo-server admin</h1>
+	{{with .Notice}}<p class="notice" role="status">{{.}}</p>{{end}}
+
+	<section>
+		<h2>Server</h2>
+		<table>
+			<tr><th>Version</th><td>{{.Version.Version}} {{with .Version.Commit}}<span class="muted">{{.}}</span>{{end}} ({{.Version.GoVersion}})</td></tr>
+			<tr><th>Uptime</th><td>{{.Uptime}}</td></tr>
+			<tr><th>Goroutines</th><td>{{.Goroutines}}</td></tr>
+			<tr><th>Heap</th><td>{{mib .HeapBytes}}</td></tr>
+			<tr><th>List cache</th><td>{{.Cache.Entries}} entries, {{.Cache.Hits}} hits, {{.Cache.Misses}} misses</td></tr>
+		</table>
+	</section>
+
+	<section>
+		<h2>Todos</h2>
+		<table>
+			<tr><th>Total</th><td>{{.Stats.Total}}{{if gt .MaxTodos 0}} <span class="muted">of -max-todos {{.MaxTodos}}</span>{{end}}</td></tr>
+			<tr><th>Open</th><td>{{.Stats.Open}}</td></tr>
+			<tr><th>Done</th><td>{{.Stats.Done}}</td></tr>
+			<tr><th>With a due date</th><td>{{.Stats.WithDue}}</td></tr>
+		</table>
+		<form method="post">
+			<input type="hidden" name="action" value="purge">
+			<button{{if eq .Stats.Done 0}} disabled{{end}}>Purge completed todos</button>
+		</form>
+		<span class="muted">{{if .ArchiveFile}}they are appended to {{.ArchiveFile}} first{{else}}no -archive-file: they are dropped{{end}}</span>
+	</section>
+
+	<section>
+		<h2>Maintenance</h2>
+		<p>{{if .Maintenance.Enabled}}<strong class="warn">On</strong>: writes get 503 "{{.Maintenance.Message}}"{{else}}Off{{end}}</p>
+		<form method="post">
+			<input type="hidden" name="action" value="maintenance">
+			{{if .Maintenance.Enabled}}
+			<input type="hidden" name="enabled" value="false">
+			<button>Turn off</button>
+			{{else}}
+			<input type="hidden" name="enabled" value="true">
+			<input name="message" placeholder="message for clients (optional)" size="40">
+			<button>Turn on</button>
+			{{end}}
+		</form>
+	</section>
+
+	<section>
+		<h2>Chaos</h2>
+		<p>{{if .Chaos.Enabled}}<strong class="warn">On</strong>: up to {{.Chaos.Latency}} latency on {{.Chaos.LatencyPercent}}%, 500 on {{.Chaos.ErrorPercent}}%, dropped connections on {{.Chaos.DropPercent}}% of requests{{else}}Off{{end}}
+			<span class="muted">(injected so far: {{.Chaos.Injected.Latency}} delays, {{.Chaos.Injected.Errors}} errors, {{.Chaos.Injected.Drops}} drops)</span></p>
+		<form method="post">
+			<input type="hidden" name="action" value="chaos">
+			{{if .Chaos.Enabled}}
+			<input type="hidden" name="enabled" value="false">
+			<button>Turn off</button>
+			{{else}}
+			<input type="hidden" name="enabled" value="true">
+			<input name="latency" value="{{.Chaos.Latency}}" size="6" aria-label="latency">
+			<input name="latency_percent" type="number" min="0" max="100" step="any" value="{{.Chaos.LatencyPercent}}" aria-label="latency percent">
+			<input name="error_percent" type="number" min="0" max="100" step="any" value="{{.Chaos.ErrorPercent}}" aria-label="error percent">
+			<input name="drop_percent" type="number" min="0" max="100" step="any" value="{{.Chaos.DropPercent}}" aria-label="drop percent">
+			<button>Turn on</button>
+			{{end}}
+		</form>
+	</section>
+
+	<section>
+		<h2>Features</h2>
+		<table>
+			<tr><th>Feature</th><th>Rollout</th><th></th></tr>
+			{{range .Features}}
+			<tr>
+				<td>{{.Name}}<br><span class="muted">{{.Description}}</span></td>
+				<td>{{.Percent}}%</td>
+				<td>
+					<form method="post">
+						<input type="hidden" name="action" value="feature">
+						<input type="hidden" name="name" value="{{.Name}}">
+						<input name="percent" type="number" min="0" max="100" value="{{.Percent}}" aria-label="rollout percent">
+						<button>Set</button>
+					</form>
+				</td>
+			</tr>
+			{{end}}
+		</table>
+	</section>
+
+	<section>
+		<h2>Webhooks</h2>
+		{{if .Webhooks}}
+		<table>
+			<tr><th>#</th><th>URL</th><th>Events</th><th>Delivered</th><th>Failed</th><th>Pending</th><th>Dead</th><th>Last attempt</th></tr>
+			{{range .Webhooks}}
+			<tr>
+				<td>{{.ID}}</td><td>{{.URL}}</td><td>{{range $i, $e := .Events}}{{if $i}}, {{end}}{{$e}}{{end}}</td>
+				{{with .Status}}
+				<td>{{.Delivered}}</td><td>{{.Failed}}</td><td>{{.Pending}}</td><td>{{if .DeadLettered}}<span class="warn">{{.DeadLettered}}</span>{{else}}0{{end}}</td>
+				<td>{{if .LastAttempt.IsZero}}<span class="muted">never</span>{{else}}{{.LastAttempt.Format "2006-01-02 15:04:05"}}{{with .LastStatusCode}} ({{.}}){{end}}{{with .LastError}} <span class="warn">{{.}}</span>{{end}}{{end}}</td>
+				{{end}}
+			</tr>
+			{{end}}
+		</table>
+		{{else}}
+		<p class="muted">No webhooks registered.</p>
+		{{end}}
+		{{if .DeadLetters}}
+		<form method="post">
+			<input type="hidden" name="action" value="replay">
+			<button>Replay {{.DeadLetters}} dead letters</button>
+		</form>
+		{{end}}
+	</section>
+
+	<section>
+		<h2>Recent changes</h2>
+		{{if .Changes}}
+		<table>
+			<tr><th>Seq</th><th>Time</th><th>Change</th><th>Todo</th></tr>
+			{{range .Changes}}
+			<tr><td>{{.Seq}}</td><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{kind .}}</td><td>#{{.Todo.ID}} {{.Todo.Title}}</td></tr>
+			{{end}}
+		</table>
+		<p class="muted">The last {{len .Changes}} of {{.LastSeq}} changes, from the change log kept for catch-up</p>
+		{{else}}
+		<p class="muted">No changes yet.</p>
+		{{end}}
+	</section>
+</body>
+</html>
+`
+
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"mib":  func(b uint64) string { return strconv.FormatFloat(float64(b)/(1<<20), 'f', 1, 64) + " MiB" },
+	"kind": model.ChangeKind,
+}).Parse(dashboardTemplate))
+
+// the actions post back to the page; with basic auth the browser sends the credentials on
+// its own, so posts from other sites are refused
+var dashboardCrossOrigin = http.NewCrossOriginProtection()
+
+
+// dashboardHandler serves the page and its actions
+func dashboardHandler() http.Handler {
+	return dashboardCrossOrigin.Handler(http.HandlerFunc(dashboardPageHandler))
+}
+
+
+// admin: GET shows the dashboard, POST runs an action and goes back to it
+func dashboardPageHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// just show
+
+	case http.MethodPost:
+		notice, status := dashboardAction(r)
+		if status != http.StatusOK {
+			http.Error(w, notice, status)
+			return
+		}
+
+		// post/redirect/get, so a reload doesn't repeat the action
+		w.Header().Set("Location", "./?notice="+url.QueryEscape(notice))
+		w.WriteHeader(http.StatusSeeOther)
+		return
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := dashboardSnapshot()
+	data.Notice = r.URL.Query().Get("notice")
+
+	var buf bytes.Buffer
+	if err := dashboardPage.Execute(&buf, data); err != nil {
+		fmt.Println("dashboard render failed:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+
+// dashboardAction runs the posted action, returning what to tell the admin
+func dashboardAction(r *http.Request) (string, int) {
+
+	switch r.PostFormValue("action") {
+	case "purge":
+		return "Purged " + strconv.Itoa(purgeCompleted(r.Context())) + " completed todos", http.StatusOK
+
+	case "maintenance":
+		enabled, err := strconv.ParseBool(r.PostFormValue("enabled"))
+		if err != nil {
+			return "enabled must be true or false", http.StatusBadRequest
+		}
+		setMaintenance(enabled, r.PostFormValue("message"))
+		if enabled {
+			return "Maintenance mode is on", http.StatusOK
+		}
+		return "Maintenance mode is off", http.StatusOK
+
+	case "chaos":
+		settings := url.Values{}
+		for _, key := range []string{"enabled", "latency", "latency_percent", "error_percent", "drop_percent"} {
+			if v := r.PostFormValue(key); v != "" {
+				settings.Set(key, v)
+			}
+		}
+		if err := setChaos(settings); err != nil {
+			return err.Error(), http.StatusBadRequest
+		}
+		if currentChaos().Enabled {
+			return "Chaos is on", http.StatusOK
+		}
+		return "Chaos is off", http.StatusOK
+
+	case "feature":
+		name := r.PostFormValue("name")
+		if _, ok := knownFeatures[name]; !ok {
+			return "unknown feature " + strconv.Quote(name), http.StatusBadRequest
+		}
+		percent, err := parseRollout(r.PostFormValue("percent") + "%")
+		if err != nil {
+			return err.Error(), http.StatusBadRequest
+		}
+		setFeatureRollout(name, percent)
+		return name + " is on for " + strconv.Itoa(percent) + "% of clients", http.StatusOK
+
+	case "replay":
+		return "Requeued " + strconv.Itoa(replayDeadLetters(0)) + " dead letters", http.StatusOK
+	}
+	return "unknown action", http.StatusBadRequest
+}
+
+
+// dashboardSnapshot collects what the page shows
+func dashboardSnapshot() dashboardData {
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	data := dashboardData{
+		Version:     buildInfo(),
+		Uptime:      time.Since(startedAt).Round(time.Second),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapBytes:   mem.HeapAlloc,
+		Stats:       countStats(todoStore),
+		Maintenance: currentMaintenance(),
+		Chaos:       currentChaos(),
+		Features:    currentFeatures(),
+		MaxTodos:    *maxTodos,
+		ArchiveFile: *archiveFile,
+	}
+
+	listCache.Lock()
+	data.Cache = ListCacheStats{Entries: len(listCache.entries), Hits: listCache.hits.Load(), Misses: listCache.misses.Load()}
+	listCache.Unlock()
+
+	// webhooks without their secrets, with delivery health
+	webhooksMu.Lock()
+	for _, hook := range webhooks {
+		hook.Secret = ""
+		data.Webhooks = append(data.Webhooks, hook)
+	}
+	webhooksMu.Unlock()
+	for i := range data.Webhooks {
+		st := currentWebhookStatus(data.Webhooks[i].ID)
+		data.Webhooks[i].Status = &st
+	}
+	sort.Slice(data.Webhooks, func(i, j int) bool { return data.Webhooks[i].ID < data.Webhooks[j].ID })
+
+	deliveryMu.Lock()
+	data.DeadLetters = len(deadLetters)
+	deliveryMu.Unlock()
+
+	// the tail of the change log
+	data.LastSeq = todoStore.Events().LastSeq()
+	data.Changes = todoStore.Events().Recent(dashboardChanges)
+	slices.Reverse(data.Changes)
+	return data
+}
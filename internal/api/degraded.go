@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"    // for the middleware
+	"sync/atomic" // for when it started
+	"time"        // for Read-Only-Since
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for the unavailable error
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the breaker's state
+)
+
+// read-only degraded mode: while the store's backend is out of reach (the breaker is open, the
+// Redis log can't be followed, a cluster node knows no leader) the server goes on answering reads
+// from the todos it has in memory, as they were when it lost the backend, and turns every write
+// away with a 503 saying why, rather than letting each fail its own way. every response carries
+// Read-Only-Since meanwhile, for clients to tell the data may be stale. it ends by itself once
+// the backend is back (a lone server has none, and is never degraded)
+
+// when the server noticed its backend was gone, in unix nanoseconds (0 while it's there)
+var degradedSince atomic.Int64
+
+
+// degraded says why the store is read-only now ("" while it isn't), since when, and when to try
+// writing again
+func degraded() (string, time.Time, time.Duration) {
+
+	reason, wait := "", time.Second
+	if storeBreaker != nil {
+		if status := storeBreaker.Status(); status.State == store.BreakerOpen {
+			reason, wait = "the store's backend is failing", time.Until(*status.OpenUntil)
+		}
+	}
+	if reason == "" && shared != nil && !shared.healthy.Load() {
+		reason = "Redis can't be reached"
+	}
+	if reason == "" && cluster != nil {
+		if leader, _ := cluster.Leader(); leader == "" {
+			reason = "the cluster has no leader"
+		}
+	}
+
+	if reason == "" {
+		degradedSince.Store(0)
+		return "", time.Time{}, 0
+	}
+	degradedSince.CompareAndSwap(0, time.Now().UnixNano())
+	return reason, time.Unix(0, degradedSince.Load()), wait
+}
+
+
+// readOnlyWhenDegraded turns writes away while the store is read-only, and marks the reads
+func readOnlyWhenDegraded(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		reason, since, wait := degraded()
+		if reason == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Read-Only-Since", since.UTC().Format(http.TimeFormat))
+
+		// reads are answered from memory, JSON-RPC and GraphQL ones too
+		if readOnlyRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeError(w, r, model.Unavailable("read-only while "+reason+", reads still work", wait))
+	})
+}
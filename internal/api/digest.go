@@ -0,0 +1,285 @@
+package api
+
+import (
+	"context"       // for store calls outside a request
+	"encoding/json" // for the admin API
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for admin handlers
+	"net/mail"      // for address validation
+	"sort"          // for stable list order
+	"strconv"       // for ids
+	"strings"       // for building the digest
+	"sync"          // for guarding subscriptions
+	"time"          // for scheduling
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// how often the scheduler looks for digests that are due
+const digestCheckEvery = time.Minute
+
+// DigestSubscription is one recipient who opted in to the daily digest (and optionally overdue reminders).
+// there are no user accounts, so each subscription carries its own address and preferences.
+type DigestSubscription struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	SendAt    string    `json:"send_at"`             // local time of day, "08:00"
+	Timezone  string    `json:"timezone"`            // IANA name, e.g. "Europe/Berlin"
+	Reminders bool      `json:"reminders"`           // also mail each todo as it becomes overdue
+	LastSent  string    `json:"last_sent,omitempty"` // local date of the last digest, "2006-01-02"
+	Created   time.Time `json:"created_at"`
+}
+
+// CreateDigestRequest represents input body for subscribing to the digest
+type CreateDigestRequest struct {
+	Email     string `json:"email"`
+	SendAt    string `json:"send_at"`   // defaults to 08:00
+	Timezone  string `json:"timezone"`  // defaults to UTC
+	Reminders bool   `json:"reminders"` // opt in to overdue reminders too
+}
+
+// digest subscriptions, same in-memory pattern as the todos map
+var digests = make(map[int]DigestSubscription)
+var digestsMu sync.Mutex
+var nextDigestID = 1
+
+
+// digestFor builds the subject and body for one recipient at now (their local time), ok=false if nothing is due
+func digestFor(list []model.Todo, now time.Time) (subject, body string, ok bool) {
+
+	// "today" is the recipient's calendar day
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+
+	var overdue, today []model.Todo
+	for _, todo := range list {
+		if todo.Done || todo.Due == nil {
+			continue
+		}
+		switch {
+		case todo.Due.Before(startOfDay):
+			overdue = append(overdue, todo)
+		case todo.Due.Before(endOfDay):
+			today = append(today, todo)
+		}
+	}
+	if len(overdue) == 0 && len(today) == 0 {
+		return "", "", false
+	}
+
+	var b strings.Builder
+	section := func(name string, items []model.Todo) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString(name + "\n\n")
+		for _, todo := range items {
+			b.WriteString("  - " + todo.Title + " (#" + strconv.Itoa(todo.ID) + ", due " + todo.Due.In(now.Location()).Format("Mon Jan 2 15:04") + ")\n")
+		}
+		b.WriteString("\n")
+	}
+	section("Overdue", overdue)
+	section("Due today", today)
+
+	subject = fmt.Sprintf("Todo digest for %s: %d due today, %d overdue", now.Format("Mon Jan 2"), len(today), len(overdue))
+	return subject, b.String(), true
+}
+
+
+// sendDueDigests mails every subscriber whose send time has passed today and who hasn't had today's digest
+func sendDueDigests() {
+
+	digestsMu.Lock()
+	var due []DigestSubscription
+	for _, sub := range digests {
+		loc, _ := time.LoadLocation(sub.Timezone) // validated on subscribe
+		now := todoStore.Now().In(loc)
+		if now.Format("2006-01-02") != sub.LastSent && now.Format("15:04") >= sub.SendAt {
+			due = append(due, sub)
+		}
+	}
+	digestsMu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+	open := false
+	list := todoStore.Find(context.Background(), store.Filter{Done: &open, HasDue: true})
+
+	for _, sub := range due {
+		loc, _ := time.LoadLocation(sub.Timezone)
+		now := todoStore.Now().In(loc)
+
+		// nothing due: skip today quietly, no empty mails
+		subject, body, ok := digestFor(list, now)
+		if ok {
+			if err := sendMail(sub.Email, subject, body); err != nil {
+				fmt.Println("digest to", sub.Email, "failed, retrying next check:", err)
+				continue
+			}
+		}
+
+		digestsMu.Lock()
+		if current, exists := digests[sub.ID]; exists {
+			current.LastSent = now.Format("2006-01-02")
+			digests[sub.ID] = current
+		}
+		digestsMu.Unlock()
+	}
+}
+
+
+// startDigestScheduler checks for due digests every minute (only when mail is configured)
+func startDigestScheduler() {
+
+	// nothing to send with
+	if !mailEnabled() {
+		return
+	}
+
+	go func() {
+		for range time.Tick(digestCheckEvery) {
+			if scheduling() {
+				sendDueDigests()
+			}
+		}
+	}()
+}
+
+
+// admin: list digest subscriptions
+func listDigestsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	digestsMu.Lock()
+	list := make([]DigestSubscription, 0, len(digests))
+	for _, sub := range digests {
+		list = append(list, sub)
+	}
+	digestsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// admin: opt a recipient in to the daily digest
+func createDigestHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// err handling for decoding request body (bad input)
+	var req CreateDigestRequest
+	if err := decodeBody(r, "digest", &req); err != nil {
+		writeError(w, r, bodyError(err))
+		return
+	}
+
+	// defaults: 08:00 in the client's zone (Time-Zone header, then -timezone, then UTC)
+	if req.SendAt == "" {
+		req.SendAt = "08:00"
+	}
+	if req.Timezone == "" {
+		req.Timezone = requestZoneName(r)
+	}
+
+	// validate address, time of day and zone
+	addr, err := mail.ParseAddress(req.Email)
+	sendAt, timeErr := time.Parse("15:04", req.SendAt)
+	_, zoneErr := time.LoadLocation(req.Timezone)
+	if err != nil || timeErr != nil || zoneErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.SendAt = sendAt.Format("15:04") // zero padded, compared as a string by the scheduler
+
+	digestsMu.Lock()
+	sub := DigestSubscription{ID: nextDigestID, Email: addr.Address, SendAt: req.SendAt, Timezone: req.Timezone, Reminders: req.Reminders, Created: time.Now()}
+	digests[sub.ID] = sub
+	nextDigestID++
+	digestsMu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+
+// admin: opt out (?id=)
+func deleteDigestHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	digestsMu.Lock()
+	_, exists := digests[id]
+	delete(digests, id)
+	digestsMu.Unlock()
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// admin: mail one subscriber their digest right now (?id=), even if nothing is due
+func sendDigestNowHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	digestsMu.Lock()
+	sub, exists := digests[id]
+	digestsMu.Unlock()
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	loc, _ := time.LoadLocation(sub.Timezone)
+	now := todoStore.Now().In(loc)
+	subject, body, ok := digestFor(todoStore.List(r.Context()), now)
+	if !ok {
+		subject, body = "Todo digest for "+now.Format("Mon Jan 2")+": nothing due", "Nothing is due today or overdue.\n"
+	}
+
+	// synchronous so the admin sees the relay's answer
+	w.Header().Set("Content-Type", "application/json")
+	if err := sendMail(sub.Email, subject, body); err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"sent": sub.Email})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"         // for request bodies
+	"encoding/json" // for webhook payloads
+	"errors"        // for delivery errors
+	"fmt"           // for streak descriptions
+	"net/http"      // for posting to Discord
+	"slices"        // for event matching
+	"strconv"       // for Retry-After and ids
+	"strings"       // for flag lists
+	"time"          // for timestamps and rate limits
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// Discord integration (off unless a webhook is given)
+var discordWebhooks = flags.String("discord-webhooks", "", "comma separated Discord webhook URLs (disabled when empty)")
+var discordEvents = flags.String("discord-events", "created,completed,overdue", "comma separated notification kinds to post: created, completed, updated, deleted, overdue, streak")
+
+// embed colour and heading per notification kind
+var discordStyles = map[string]struct {
+	Color int
+	Title string
+}{
+	model.EventCreated:    {0x5865F2, "📝 New todo"},
+	model.ChangeCompleted: {0x57F287, "✅ Completed"},
+	model.EventUpdated:    {0xFEE75C, "✏️ Updated"},
+	model.EventDeleted:    {0x99AAB5, "🗑️ Deleted"},
+	notifyOverdue:         {0xED4245, "⏰ Overdue"},
+	notifyStreak:          {0xF47B67, "🔥 Streak"},
+}
+
+// discordEmbed and discordMessage are the parts of Discord's webhook payload we fill in
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Timestamp   string              `json:"timestamp"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      *discordEmbedFooter `json:"footer,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+// parsed flags
+var discordURLs []string
+var discordKinds []string
+
+// client used for every Discord call
+var discordClient = &http.Client{Timeout: 10 * time.Second}
+
+
+// setupDiscord reads the Discord flags and registers the notifier
+func setupDiscord() error {
+
+	// nothing to do if Discord is disabled
+	if *discordWebhooks == "" {
+		return nil
+	}
+
+	for _, u := range strings.Split(*discordWebhooks, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			discordURLs = append(discordURLs, u)
+		}
+	}
+	for _, kind := range strings.Split(*discordEvents, ",") {
+		kind = strings.TrimSpace(kind)
+		if _, ok := discordStyles[kind]; !ok {
+			return errors.New("discord-events: unknown kind " + strconv.Quote(kind))
+		}
+		discordKinds = append(discordKinds, kind)
+	}
+
+	addNotifier("discord", sendDiscord)
+	return nil
+}
+
+
+// discordEmbedFor formats one notification as an embed
+func discordEmbedFor(n Notification) discordEmbed {
+
+	style := discordStyles[n.Kind]
+	embed := discordEmbed{
+		Title:       style.Title,
+		Description: n.Todo.Title,
+		Color:       style.Color,
+		Timestamp:   n.Time.UTC().Format(time.RFC3339),
+		Fields:      []discordEmbedField{{Name: "ID", Value: "#" + strconv.Itoa(n.Todo.ID), Inline: true}},
+		Footer:      &discordEmbedFooter{Text: "todo-api"},
+	}
+	if n.Streak != nil {
+		embed.Description = fmt.Sprintf("%s is on a %d-day streak, most recently with %s", n.Streak.Actor, n.Streak.Days, n.Todo.Title)
+	}
+
+	status := "open"
+	if n.Todo.Done {
+		status = "done"
+	}
+	embed.Fields = append(embed.Fields, discordEmbedField{Name: "Status", Value: status, Inline: true})
+
+	// <t:unix:R> renders in each reader's timezone as "in 2 hours" / "3 days ago"
+	if n.Todo.Due != nil {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Due", Value: "<t:" + strconv.FormatInt(n.Todo.Due.Unix(), 10) + ":R>", Inline: true})
+	}
+	return embed
+}
+
+
+// sendDiscord posts one notification to every webhook
+func sendDiscord(n Notification) error {
+
+	if !slices.Contains(discordKinds, n.Kind) {
+		return nil
+	}
+	body, _ := json.Marshal(discordMessage{Username: "todo-api", Embeds: []discordEmbed{discordEmbedFor(n)}})
+
+	var errs []error
+	for _, u := range discordURLs {
+		if err := postDiscord(u, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+
+// postDiscord delivers to one webhook, waiting out a rate limit once
+func postDiscord(webhookURL string, body []byte) error {
+
+	for attempt := 0; ; attempt++ {
+		resp, err := discordClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		// 429 comes with the seconds to wait (fractional)
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			wait, _ := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+			time.Sleep(time.Duration(wait*float64(time.Second)) + 100*time.Millisecond)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return errors.New("discord webhook returned " + resp.Status)
+		}
+		return nil
+	}
+}
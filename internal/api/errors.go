@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json" // for error bodies
+	"errors"        // for matching domain errors
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for status codes
+	"strconv"       // for ?id= and Retry-After
+	"time"          // for Retry-After
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for the domain errors
+)
+
+// ErrorResponse is the body of every 4xx/5xx from the todo endpoints
+type ErrorResponse struct {
+	Error  string             `json:"error"`
+	Fields []model.FieldError `json:"fields,omitempty"` // what's wrong with the input, for validation errors
+	Server *model.Todo        `json:"server,omitempty"` // for merge conflicts: the todo as it is
+	Client *model.Todo        `json:"client,omitempty"` // and as the change would have made it
+}
+
+
+// errorStatus maps a domain error to its status code (500 for anything else)
+func errorStatus(err error) int {
+
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, model.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+
+// errorResponse is err's status and body in the client's language; unexpected errors are
+// logged and not shown to the client
+func errorResponse(r *http.Request, err error) (int, ErrorResponse, string) {
+
+	status := errorStatus(err)
+	resp := ErrorResponse{Error: err.Error()}
+	var invalid *model.ValidationError
+	if errors.As(err, &invalid) {
+		resp.Fields = invalid.Fields
+	}
+	var conflict *model.MergeConflict
+	if errors.As(err, &conflict) {
+		resp.Server, resp.Client = &conflict.Server, &conflict.Client
+	}
+	if status == http.StatusInternalServerError {
+		fmt.Println("request failed:", err)
+		resp.Error = "internal error"
+	}
+	lang := requestLanguage(r)
+	return status, localizeError(lang, err, resp), lang
+}
+
+
+// writeError answers with err's status and an ErrorResponse
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+
+	status, resp, lang := errorResponse(r, err)
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", retryAfter(err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+
+// retryAfter is when to try again after err, in whole seconds: as the error says, else a second
+func retryAfter(err error) string {
+
+	var unavailable *model.UnavailableError
+	if errors.As(err, &unavailable) && unavailable.RetryAfter > time.Second {
+		return strconv.Itoa(int((unavailable.RetryAfter + time.Second - 1) / time.Second))
+	}
+	return "1"
+}
+
+
+// queryID reads the ?id= every single-todo endpoint takes
+func queryID(r *http.Request) (int, error) {
+
+	v := r.URL.Query().Get("id")
+	if v == "" {
+		return 0, model.Invalid("id", "is required")
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, model.Invalid("id", "must be an integer")
+	}
+	return id, nil
+}
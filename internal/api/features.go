@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors
+	"hash/fnv"      // for rollout buckets
+	"net/http"      // for HTTP handlers
+	"slices"        // for a stable listing
+	"strconv"       // for parsing the admin params
+	"strings"       // for parsing -features
+	"sync"          // for guarding the rollout table
+)
+
+// feature flags: experimental features can be switched off, or on for a share of the clients,
+// without a restart. e.g. -features event-stream=off,due-search=25%
+var featureConfig = flags.String("features", "", "comma separated feature rollouts: name=on|off|N% (see /admin/features)")
+
+// the experimental features and what they gate
+var knownFeatures = map[string]string{
+	"event-stream": "live change streams: /ws, GraphQL subscriptions and the gRPC Watch call",
+	"due-search":   "?due_after= and ?due_before= filters on GET /todos",
+}
+
+// rollout percent per feature, 100 = on for everyone, 0 = off
+var featureRollout = map[string]int{}
+var featureMu sync.RWMutex // protects featureRollout
+
+// FeatureStatus is one entry of GET /admin/features
+type FeatureStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Percent     int    `json:"percent"` // share of clients that get the feature
+}
+
+
+// loadFeatures parses -features; features it doesn't name are on
+func loadFeatures() error {
+
+	rollout := make(map[string]int, len(knownFeatures))
+	for name := range knownFeatures {
+		rollout[name] = 100
+	}
+
+	for _, item := range strings.Split(*featureConfig, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(item, "=")
+		if _, ok := knownFeatures[name]; !ok {
+			return fmt.Errorf("-features: unknown feature %q", name)
+		}
+		percent, err := parseRollout(value)
+		if err != nil {
+			return fmt.Errorf("-features: %s: %w", name, err)
+		}
+		rollout[name] = percent
+	}
+
+	featureMu.Lock()
+	featureRollout = rollout
+	featureMu.Unlock()
+	return nil
+}
+
+
+// parseRollout reads on, off, true, false or a percentage like 25%
+func parseRollout(v string) (int, error) {
+
+	if p, ok := strings.CutSuffix(v, "%"); ok {
+		percent, err := strconv.Atoi(p)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("%q is not a percentage from 0%% to 100%%", v)
+		}
+		return percent, nil
+	}
+	switch strings.ToLower(v) {
+	case "on", "true":
+		return 100, nil
+	case "off", "false":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%q is not on, off or N%%", v)
+}
+
+
+// featureEnabled reports whether the feature is on for this request's client. partial rollouts
+// bucket clients by address, so a client keeps the same answer until the percentage changes
+func featureEnabled(r *http.Request, name string) bool {
+
+	featureMu.RLock()
+	percent := featureRollout[name]
+	featureMu.RUnlock()
+
+	switch percent {
+	case 100:
+		return true
+	case 0:
+		return false
+	}
+
+	// hash the name in too, so a 10% rollout of two features doesn't hit the same clients
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	if addr, ok := clientIP(r); ok {
+		h.Write(addr.AsSlice())
+	}
+	return int(h.Sum32()%100) < percent
+}
+
+
+// requireFeature answers 404 to clients the feature is off for, as if the route didn't exist
+func requireFeature(name string, next http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !featureEnabled(r, name) {
+			http.NotFound(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+
+// setFeatureRollout changes a known feature's rollout percent
+func setFeatureRollout(name string, percent int) {
+	featureMu.Lock()
+	defer featureMu.Unlock()
+	featureRollout[name] = percent
+}
+
+
+// currentFeatures lists every feature with its rollout, by name
+func currentFeatures() []FeatureStatus {
+
+	featureMu.RLock()
+	defer featureMu.RUnlock()
+
+	list := make([]FeatureStatus, 0, len(knownFeatures))
+	for name, description := range knownFeatures {
+		list = append(list, FeatureStatus{Name: name, Description: description, Percent: featureRollout[name]})
+	}
+	slices.SortFunc(list, func(a, b FeatureStatus) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+
+// admin: GET lists the features, POST ?name=...&enabled=true|false or ?name=...&percent=N changes one
+func featuresHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		// just report
+
+	case http.MethodPost:
+		q := r.URL.Query()
+		name := q.Get("name")
+		if _, ok := knownFeatures[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unknown feature " + strconv.Quote(name)})
+			return
+		}
+
+		// enabled=true|false is all or nothing, percent=N rolls out to a share of clients
+		var percent int
+		var err error
+		if v := q.Get("percent"); v != "" {
+			percent, err = parseRollout(strings.TrimSuffix(v, "%") + "%")
+		} else {
+			percent, err = parseRollout(q.Get("enabled"))
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		setFeatureRollout(name, percent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(currentFeatures())
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"crypto/hmac"   // for feed tokens
+	"crypto/sha256" // for feed tokens
+	"encoding/hex"  // for token encoding
+	"encoding/json" // for the admin response
+	"encoding/xml"  // for the Atom feed
+	"net/http"      // for HTTP handlers
+	"strconv"       // for UIDs
+	"strings"       // for building calendars
+	"time"          // for timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// key for signed feed tokens; a random one (changing on restart) is used when empty
+var feedSecret = flags.String("feed-secret", "", "secret used to sign feed tokens for /todos.ics and /todos/feed.atom (random per process when empty)")
+
+// effective feed key, set by loadFeedSecret
+var feedKey []byte
+
+// iCalendar timestamps are UTC basic format
+const icsTimeFormat = "20060102T150405Z"
+
+// how long a VEVENT for a due todo lasts in calendar views
+const icsEventLength = "PT15M"
+
+
+// loadFeedSecret picks the feed signing key
+func loadFeedSecret() {
+	if *feedSecret != "" {
+		feedKey = []byte(*feedSecret)
+		return
+	}
+	feedKey = []byte(randomHex(32))
+}
+
+
+// feedToken signs a feed name; calendar apps can't send headers, so the token rides in ?token=
+func feedToken(feed string) string {
+	mac := hmac.New(sha256.New, feedKey)
+	mac.Write([]byte(feed))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+
+// validFeedToken checks ?token= against the feed's signature in constant time
+func validFeedToken(r *http.Request, feed string) bool {
+	return hmac.Equal([]byte(r.URL.Query().Get("token")), []byte(feedToken(feed)))
+}
+
+
+// icsEscape escapes TEXT values (RFC 5545 3.3.11)
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+
+// writeICSLine appends one content line, folded at 75 octets with CRLF + space
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		// don't cut a UTF-8 sequence in half
+		cut := limit
+		for cut > 0 && line[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with the space
+	}
+	b.WriteString(line + "\r\n")
+}
+
+
+// serve todos with a due date as an iCalendar feed (?token= required, ?kind=todo for VTODO instead of VEVENT)
+func icsFeedHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// signed token instead of auth headers
+	if !validFeedToken(r, "todos.ics") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// Google/Outlook only show events, Apple Reminders and Thunderbird read VTODO
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != "event" && kind != "todo" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	stamp := todoStore.Now().UTC().Format(icsTimeFormat)
+	host := r.Host
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//todo-api//todos.ics//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:Todos")
+
+	for _, todo := range todoStore.Find(r.Context(), store.Filter{HasDue: true}) {
+		due := todo.Due.UTC().Format(icsTimeFormat)
+		uid := "todo-" + strconv.Itoa(todo.ID) + "@" + host
+
+		if kind == "todo" {
+			writeICSLine(&b, "BEGIN:VTODO")
+			writeICSLine(&b, "UID:"+uid)
+			writeICSLine(&b, "DTSTAMP:"+stamp)
+			writeICSLine(&b, "SUMMARY:"+icsEscape(todo.Title))
+			writeICSLine(&b, "DUE:"+due)
+			if todo.Done {
+				writeICSLine(&b, "STATUS:COMPLETED")
+			} else {
+				writeICSLine(&b, "STATUS:NEEDS-ACTION")
+			}
+			writeICSLine(&b, "END:VTODO")
+			continue
+		}
+
+		// done todos stay on the calendar, marked in the title
+		summary := todo.Title
+		if todo.Done {
+			summary = "✓ " + summary
+		}
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+uid)
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "SUMMARY:"+icsEscape(summary))
+		writeICSLine(&b, "DTSTART:"+due)
+		writeICSLine(&b, "DURATION:"+icsEventLength)
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+
+// admin: feed paths with their tokens, ready to paste into a calendar app
+func feedsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"ics":  "/todos.ics?token=" + feedToken("todos.ics"),
+		"atom": "/todos/feed.atom?token=" + feedToken("todos/feed.atom"),
+	})
+}
+
+// how many entries the Atom feed carries
+const atomFeedEntries = 50
+
+// atomFeed is the subset of RFC 4287 we emit
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is a link element
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomAuthor is required on the feed when entries have none
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomEntry is one created/completed change
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary"`
+}
+
+
+// serve recently created and completed todos as an Atom feed (?token= required)
+func atomFeedHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// signed token instead of auth headers
+	if !validFeedToken(r, "todos/feed.atom") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// recent activity comes from the change log, newest first
+	events, _, _ := todoStore.Events().Since(0)
+	feed := atomFeed{
+		ID:      "urn:todo-api:" + r.Host + ":feed",
+		Title:   "Todo activity",
+		Updated: todoStore.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Rel: "self", Href: "/todos/feed.atom"},
+		Author:  atomAuthor{Name: *serviceName},
+	}
+	for i := len(events) - 1; i >= 0 && len(feed.Entries) < atomFeedEntries; i-- {
+		e := events[i]
+		var verb string
+		switch model.ChangeKind(e) {
+		case model.EventCreated:
+			verb = "Created"
+		case model.ChangeCompleted:
+			verb = "Completed"
+		default:
+			continue
+		}
+
+		// the feed is as fresh as its newest entry
+		if len(feed.Entries) == 0 {
+			feed.Updated = e.Time.UTC().Format(time.RFC3339)
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			// seq restarts with the process, the timestamp keeps ids unique
+			ID:      "urn:todo-api:" + r.Host + ":event:" + strconv.FormatUint(e.Seq, 10) + "-" + strconv.FormatInt(e.Time.UnixNano(), 10),
+			Title:   verb + ": " + e.Todo.Title,
+			Updated: e.Time.UTC().Format(time.RFC3339),
+			Summary: verb + " todo #" + strconv.Itoa(e.Todo.ID),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(feed)
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"       // for the caller's tenant
+	"encoding/json" // for JSON encode/decode
+	"fmt"           // for not found errors
+	"net/http"      // for HTTP handlers
+	"net/url"       // for the saved queries
+	"sort"          // for stable list order
+	"strconv"       // for the id in the path
+	"strings"       // for trimming names
+	"sync"          // for guarding the saved filters
+	"time"          // for timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the caller's tenant
+)
+
+// SavedFilter is a named GET /todos query ("work, due this week, not done"), evaluated each
+// time its todos are fetched, so "this week" is always the current one
+type SavedFilter struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"` // GET /todos filters, e.g. q=work&due=this_week&done=false
+	CreatedAt time.Time `json:"created_at"`
+	tenant    string    // whose filter it is, "" for the default tenant, see tenants.go
+}
+
+// SaveFilterRequest represents input body for creating or replacing a saved filter
+type SaveFilterRequest struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// the parameters a saved query may use, same as GET /todos takes
+var filterParams = map[string]bool{"done": true, "due": true, "due_after": true, "due_before": true, "q": true, "list": true, "tag": true, "created_by": true}
+
+// saved filters, same in-memory pattern as the webhooks
+var savedFilters = make(map[int]SavedFilter)
+var savedFiltersMu sync.Mutex
+var nextFilterID = 1
+
+
+// checkSavedFilter validates a request and normalises its query (params sorted, a leading ? dropped)
+func checkSavedFilter(r *http.Request, req *SaveFilterRequest) error {
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return model.Invalid("name", "is required")
+	}
+
+	q, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(req.Query), "?"))
+	if err != nil {
+		return model.Invalid("query", "is not a query string")
+	}
+	for key := range q {
+		if !filterParams[key] {
+			return model.Invalid(key, "is not a filter (done, due, due_after, due_before, q, list, tag, created_by)")
+		}
+	}
+
+	// parse now, so a bad value is refused on save rather than on every fetch
+	loc, err := requestLocation(r)
+	if err != nil {
+		return err
+	}
+	if _, err := todoFilterFromQuery(q, todoStore.Now(), loc); err != nil {
+		return err
+	}
+	req.Query = q.Encode()
+	return nil
+}
+
+
+// savedFilterID reads the {id} of the path
+func savedFilterID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, model.Invalid("id", "must be an integer")
+	}
+	return id, nil
+}
+
+
+// savedFilter looks up one of the ctx tenant's filters, model.ErrNotFound if there is none
+func savedFilter(ctx context.Context, id int) (SavedFilter, error) {
+	savedFiltersMu.Lock()
+	defer savedFiltersMu.Unlock()
+
+	f, ok := savedFilters[id]
+	if !ok || f.tenant != store.TenantFrom(ctx) {
+		return SavedFilter{}, fmt.Errorf("filter %d: %w", id, model.ErrNotFound)
+	}
+	return f, nil
+}
+
+
+// savedFiltersOf lists the ctx tenant's filters, by id
+func savedFiltersOf(ctx context.Context) []SavedFilter {
+
+	savedFiltersMu.Lock()
+	list := make([]SavedFilter, 0, len(savedFilters))
+	for _, f := range savedFilters {
+		if f.tenant == store.TenantFrom(ctx) {
+			list = append(list, f)
+		}
+	}
+	savedFiltersMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+
+// saveFilter checks a new filter and keeps it, for the request's tenant
+func saveFilter(r *http.Request, req SaveFilterRequest) (SavedFilter, error) {
+
+	if err := checkSavedFilter(r, &req); err != nil {
+		return SavedFilter{}, err
+	}
+
+	savedFiltersMu.Lock()
+	defer savedFiltersMu.Unlock()
+	f := SavedFilter{ID: nextFilterID, Name: req.Name, Query: req.Query, CreatedAt: todoStore.Now(), tenant: store.TenantFrom(r.Context())}
+	savedFilters[f.ID] = f
+	nextFilterID++
+	return f, nil
+}
+
+
+// deleteSavedFilter removes a filter and its share links
+func deleteSavedFilter(id int) {
+	savedFiltersMu.Lock()
+	delete(savedFilters, id)
+	savedFiltersMu.Unlock()
+	dropShareLinks(id)
+}
+
+
+// GET lists the saved filters, POST saves one
+func filtersHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(savedFiltersOf(r.Context()))
+
+	case http.MethodPost:
+		var req SaveFilterRequest
+		if err := decodeBody(r, "filter", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		f, err := saveFilter(r, req)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(f)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// GET shows one saved filter, PUT replaces its name and query, DELETE removes it
+func filterHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := savedFilterID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	f, err := savedFilter(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(f)
+
+	case http.MethodPut:
+		var req SaveFilterRequest
+		if err := decodeBody(r, "filter", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		if err := checkSavedFilter(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// it may have been deleted meanwhile
+		savedFiltersMu.Lock()
+		_, exists := savedFilters[id]
+		if exists {
+			f.Name, f.Query = req.Name, req.Query
+			savedFilters[id] = f
+		}
+		savedFiltersMu.Unlock()
+		if !exists {
+			writeError(w, r, fmt.Errorf("filter %d: %w", id, model.ErrNotFound))
+			return
+		}
+		json.NewEncoder(w).Encode(f)
+
+	case http.MethodDelete:
+		deleteSavedFilter(id)
+
+		// 204 = success with no response body
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// savedFilterTodos runs a saved filter for the request: validated on save, but "today" and the
+// zone are the fetch's own
+func savedFilterTodos(r *http.Request, saved SavedFilter) ([]model.Todo, error) {
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		return nil, err
+	}
+	q, _ := url.ParseQuery(saved.Query)
+	filter, err := todoFilterFromQuery(q, todoStore.Now(), loc)
+	if err != nil {
+		return nil, err
+	}
+	if (filter.DueAfter != nil || filter.DueBefore != nil) && !featureEnabled(r, "due-search") {
+		return nil, model.Invalid("due_after", "due date filters are not enabled on this server")
+	}
+	return todoStore.Find(r.Context(), filter), nil
+}
+
+
+// GET runs a saved filter: its todos as GET /todos would list them, in the client's time zone
+func filterTodosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := savedFilterID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	saved, err := savedFilter(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	todos, err := savedFilterTodos(r, saved)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeTodoMap(w, w, todos)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"    // for transcoded request bodies
+	"io"       // for reading request bodies
+	"mime"     // for media type parsing
+	"net/http" // for HTTP middleware
+	"slices"   // for media type aliases
+	"strconv"  // for q-values
+	"strings"  // for header parsing
+)
+
+// bodyFormat is a binary alternative to the JSON responses, chosen with Accept, and
+// optionally to JSON request bodies, chosen with Content-Type
+type bodyFormat struct {
+	mediaType string
+	aliases   []string // other media types clients use for it
+	encode    func(b []byte, v any) []byte
+	decode    func(b []byte) (any, error) // nil: responses only
+}
+
+// formats offered besides JSON
+var bodyFormats = []bodyFormat{
+	{mediaType: "application/msgpack", aliases: []string{"application/x-msgpack", "application/vnd.msgpack"}, encode: appendMsgpack},
+	{mediaType: "application/cbor", encode: appendCBOR, decode: decodeCBOR},
+}
+
+// largest non-JSON request body we transcode
+const formatMaxBody = 1 << 20
+
+// formatWriter holds back a JSON response and writes it in another format at the end
+type formatWriter struct {
+	http.ResponseWriter
+	format      *bodyFormat
+	status      int
+	body        []byte
+	headerSeen  bool
+	passthrough bool // not JSON, sent as it is
+}
+
+
+// acceptQuality returns the q-value Accept gives mediaType: its own entry (named), else type/*,
+// else */* (-1 when no entry covers it; no Accept header accepts everything)
+func acceptQuality(accept, mediaType string) (q float64, named bool) {
+
+	if strings.TrimSpace(accept) == "" {
+		return 1, false
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	exact, group, all := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case mediaType:
+			exact = q
+		case major + "/*":
+			group = q
+		case "*/*":
+			all = q
+		}
+	}
+	switch {
+	case exact >= 0:
+		return exact, true
+	case group >= 0:
+		return group, false
+	}
+	return all, false
+}
+
+
+// responseFormat picks the format a client asked for by name, unless it likes JSON better (nil = JSON)
+func responseFormat(accept string) *bodyFormat {
+
+	jsonQ, _ := acceptQuality(accept, "application/json")
+	for i := range bodyFormats {
+		f := &bodyFormats[i]
+		for _, name := range append([]string{f.mediaType}, f.aliases...) {
+
+			// wildcards mean JSON, only naming the format asks for it
+			if q, named := acceptQuality(accept, name); named && q > 0 && q >= jsonQ {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+
+// requestFormat returns the format a request body is in, if it's one we turn into JSON
+func requestFormat(contentType string) *bodyFormat {
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for i := range bodyFormats {
+		f := &bodyFormats[i]
+		if f.decode != nil && (mediaType == f.mediaType || slices.Contains(f.aliases, mediaType)) {
+			return f
+		}
+	}
+	return nil
+}
+
+
+// negotiateFormat hands handlers JSON request bodies, and writes JSON responses
+// in the format the client asked for in Accept
+func negotiateFormat(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// handlers only read JSON: transcode other bodies up front (400 if they don't parse),
+		// except attached files, which are kept as they come and served as they were
+		if isAttachmentPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if in := requestFormat(r.Header.Get("Content-Type")); in != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, formatMaxBody))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			v, err := in.decode(body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = encodeOrderedJSON(nil, v)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		// the response depends on Accept
+		w.Header().Add("Vary", "Accept")
+		format := responseFormat(r.Header.Get("Accept"))
+		if format == nil || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fw := &formatWriter{ResponseWriter: w, format: format, status: http.StatusOK}
+		defer fw.close()
+		next.ServeHTTP(fw, r)
+	})
+}
+
+
+// WriteHeader holds back JSON responses and lets everything else through
+func (f *formatWriter) WriteHeader(code int) {
+
+	if f.headerSeen {
+		return
+	}
+	if code >= 100 && code < 200 {
+		f.ResponseWriter.WriteHeader(code)
+		return
+	}
+	f.headerSeen = true
+	f.status = code
+
+	mediaType, _, _ := mime.ParseMediaType(f.Header().Get("Content-Type"))
+	if mediaType != "application/json" {
+		f.passthrough = true
+		f.ResponseWriter.WriteHeader(code)
+	}
+}
+
+
+// Write collects a JSON body (it has to be whole to be transcoded)
+func (f *formatWriter) Write(b []byte) (int, error) {
+
+	if !f.headerSeen {
+		f.WriteHeader(http.StatusOK)
+	}
+	if f.passthrough {
+		return f.ResponseWriter.Write(b)
+	}
+	f.body = append(f.body, b...)
+	return len(b), nil
+}
+
+
+// FlushError passes flushes through, except for a body that is still being collected
+func (f *formatWriter) FlushError() error {
+
+	if f.passthrough {
+		return http.NewResponseController(f.ResponseWriter).Flush()
+	}
+	return nil
+}
+
+
+// Unwrap lets http.ResponseController reach the underlying writer (deadlines)
+func (f *formatWriter) Unwrap() http.ResponseWriter {
+	return f.ResponseWriter
+}
+
+
+// close transcodes the collected JSON and sends it
+func (f *formatWriter) close() {
+
+	if f.passthrough || !f.headerSeen {
+		return
+	}
+	h := f.Header()
+	h.Del("Content-Length")
+
+	// status only (errors): nothing to transcode
+	if len(f.body) == 0 {
+		h.Del("Content-Type")
+		f.ResponseWriter.WriteHeader(f.status)
+		return
+	}
+
+	// a body that doesn't parse goes out as the JSON it is
+	v, err := decodeOrderedJSON(f.body)
+	if err != nil {
+		f.ResponseWriter.WriteHeader(f.status)
+		f.ResponseWriter.Write(f.body)
+		return
+	}
+	h.Set("Content-Type", f.format.mediaType)
+	f.ResponseWriter.WriteHeader(f.status)
+	f.ResponseWriter.Write(f.format.encode(nil, v))
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for DOT output and errors
+	"net/http"      // for HTTP handlers
+	"sort"          // for stable output
+	"strconv"       // for ids in the path
+	"strings"       // for DOT labels
+	"sync"          // for guarding the edges
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+)
+
+// dependencies between todos: a todo can be blocked by others, which have to be done first.
+// the edges live next to the store, like snoozes, and edges to a deleted todo are dropped when
+// they're next read. GET /todos/graph shows them, with the critical path: the longest chain of
+// open todos, each blocking the next, which is how many steps the last one is away at best
+
+// most todos one todo may be blocked by
+const maxBlockers = 100
+
+// BlockerRequest is the body of POST /todos/{id}/blockers
+type BlockerRequest struct {
+	ID int `json:"id"` // the todo that blocks it
+}
+
+// GraphNode is a todo in the graph
+type GraphNode struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Done    bool   `json:"done"`
+	Blocked bool   `json:"blocked"` // an open todo with an open blocker
+}
+
+// GraphEdge is "from blocks to"
+type GraphEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Graph is the body of GET /todos/graph
+type Graph struct {
+	Nodes        []GraphNode `json:"nodes"`
+	Edges        []GraphEdge `json:"edges"`
+	CriticalPath []int       `json:"critical_path"` // open todos, first to do first
+}
+
+// blocked todo -> the todos blocking it
+var blockers = make(map[int]map[int]bool)
+var blockersMu sync.Mutex
+
+
+// liveBlockers returns the edges between existing todos, dropping the others (caller holds blockersMu)
+func liveBlockers(todos map[int]model.Todo) map[int]map[int]bool {
+	for id, set := range blockers {
+		if _, ok := todos[id]; !ok {
+			delete(blockers, id)
+			continue
+		}
+		for b := range set {
+			if _, ok := todos[b]; !ok {
+				delete(set, b)
+			}
+		}
+	}
+	return blockers
+}
+
+
+// reaches reports whether from leads to to along blocker edges (caller holds blockersMu)
+func reaches(from, to int, seen map[int]bool) bool {
+	if from == to {
+		return true
+	}
+	seen[from] = true
+	for b := range blockers[from] {
+		if !seen[b] && reaches(b, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+
+// todoPathID reads the {id} of the path and checks the todo exists
+func todoPathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, model.Invalid(name, "must be an integer")
+	}
+	if _, err := todoStore.Get(r.Context(), id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+
+// GET lists the todos blocking {id}, POST {"id": 3} adds one (a cycle is a 409)
+func blockersHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := todoPathID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// just report
+
+	case http.MethodPost:
+		var req BlockerRequest
+		if err := decodeBody(r, "blocker", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		if _, err := todoStore.Get(r.Context(), req.ID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		blockersMu.Lock()
+		switch {
+		case req.ID == id:
+			err = model.Invalid("id", "can't block itself")
+		case len(blockers[id]) >= maxBlockers && !blockers[id][req.ID]:
+			err = model.Invalid("id", fmt.Sprintf("todo %d already has %d blockers", id, maxBlockers))
+		case reaches(req.ID, id, map[int]bool{}):
+			err = fmt.Errorf("todo %d already waits for todo %d, that would be a cycle: %w", req.ID, id, model.ErrConflict)
+		default:
+			if blockers[id] == nil {
+				blockers[id] = map[int]bool{}
+			}
+			blockers[id][req.ID] = true
+		}
+		blockersMu.Unlock()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ids := []int{}
+	blockersMu.Lock()
+	for b := range blockers[id] {
+		if _, err := todoStore.Get(r.Context(), b); err == nil {
+			ids = append(ids, b)
+		}
+	}
+	blockersMu.Unlock()
+	sort.Ints(ids)
+	json.NewEncoder(w).Encode(map[string][]int{"blockers": ids})
+}
+
+
+// DELETE /todos/{id}/blockers/{blocker} removes an edge
+func deleteBlockerHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := todoPathID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	blocker, err := strconv.Atoi(r.PathValue("blocker"))
+	if err != nil {
+		writeError(w, r, model.Invalid("blocker", "must be an integer"))
+		return
+	}
+
+	blockersMu.Lock()
+	found := blockers[id][blocker]
+	delete(blockers[id], blocker)
+	blockersMu.Unlock()
+	if !found {
+		writeError(w, r, fmt.Errorf("todo %d isn't blocked by todo %d: %w", id, blocker, model.ErrNotFound))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// buildGraph returns the todos with edges (every todo with all) and the critical path
+func buildGraph(list []model.Todo, all bool) Graph {
+
+	todos := make(map[int]model.Todo, len(list))
+	for _, todo := range list {
+		todos[todo.ID] = todo
+	}
+
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}, CriticalPath: []int{}}
+	blockersMu.Lock()
+	edges := liveBlockers(todos)
+	inGraph := map[int]bool{}
+	for to, set := range edges {
+		for from := range set {
+			graph.Edges = append(graph.Edges, GraphEdge{From: from, To: to})
+			inGraph[from], inGraph[to] = true, true
+		}
+	}
+
+	// depth is the longest chain of open todos ending in id, next the one before it on it
+	depth, next := map[int]int{}, map[int]int{}
+	var walk func(id int) int
+	walk = func(id int) int {
+		if d, ok := depth[id]; ok {
+			return d
+		}
+		best := 0
+		for b := range edges[id] {
+			if todos[b].Done {
+				continue
+			}
+			if d := walk(b); d > best || (d == best && b < next[id]) {
+				best, next[id] = d, b
+			}
+		}
+		depth[id] = best + 1
+		return depth[id]
+	}
+	end, longest := 0, 0
+	for _, todo := range list {
+		if todo.Done {
+			continue
+		}
+		if d := walk(todo.ID); d > longest {
+			end, longest = todo.ID, d
+		}
+	}
+	for id := end; longest > 1 && id != 0; id = next[id] {
+		graph.CriticalPath = append([]int{id}, graph.CriticalPath...)
+	}
+
+	for _, todo := range list {
+		if !all && !inGraph[todo.ID] {
+			continue
+		}
+		node := GraphNode{ID: todo.ID, Title: todo.Title, Done: todo.Done}
+		node.Blocked = !todo.Done && depth[todo.ID] > 1
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	blockersMu.Unlock()
+
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		return a.From < b.From || (a.From == b.From && a.To < b.To)
+	})
+	return graph
+}
+
+
+// dot writes the graph in Graphviz DOT: done todos grey, the critical path bold
+func (g Graph) dot() string {
+
+	critical := map[int]bool{}
+	for _, id := range g.CriticalPath {
+		critical[id] = true
+	}
+	label := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("digraph todos {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf(`label="#%d %s"`, n.ID, label.Replace(n.Title))
+		if n.Done {
+			attrs += ", color=grey, fontcolor=grey"
+		}
+		if critical[n.ID] {
+			attrs += ", penwidth=2"
+		}
+		fmt.Fprintf(&b, "\t%d [%s];\n", n.ID, attrs)
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		if critical[e.From] && critical[e.To] {
+			attrs = " [penwidth=2]"
+		}
+		fmt.Fprintf(&b, "\t%d -> %d%s;\n", e.From, e.To, attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+
+// GET /todos/graph returns the blocking edges and the todos on them (?all=true: every todo),
+// as JSON or, with ?format=dot, as Graphviz DOT
+func graphHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	all, err := strconv.ParseBool(q.Get("all"))
+	if err != nil && q.Get("all") != "" {
+		writeError(w, r, model.Invalid("all", "must be true or false"))
+		return
+	}
+	graph := buildGraph(todoStore.List(r.Context()), all)
+
+	switch q.Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(graph)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graph.dot()))
+	default:
+		writeError(w, r, model.Invalid("format", "must be json or dot"))
+	}
+}
@@ -0,0 +1,891 @@
+package api
+
+import (
+	"bytes"         // for ordered JSON output
+	"context"       // for store calls
+	"encoding/json" // for request/response bodies
+	"errors"        // for query errors
+	"fmt"           // for error messages
+	"net/http"      // for HTTP handlers
+	"strconv"       // for number literals
+	"strings"       // for lexing
+	"time"          // for timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// graphqlSchema documents what /graphql understands (served at GET /graphql/schema): todos with
+// their lists and tags, the lists they make, and saved filters
+const graphqlSchema = `type Todo {
+  id: Int!
+  title: String!
+  done: Boolean!
+  list: String       # null when it's on none
+  tags: [String!]!
+}
+
+type List {
+  name: String!
+  open: Int!
+  done: Int!
+  todos: [Todo!]!
+}
+
+type Filter {
+  id: Int!
+  name: String!
+  query: String!     # GET /todos filters, e.g. q=work&due=this_week
+  createdAt: String! # RFC3339
+  todos: [Todo!]!    # run now, in the Time-Zone header's zone
+}
+
+type TodoEvent {
+  type: String!      # created, updated or deleted
+  todo: Todo!
+  time: String!      # RFC3339
+}
+
+type Query {
+  todos(done: Boolean, list: String, tag: String): [Todo!]!
+  todo(id: Int!): Todo
+  lists: [List!]!
+  list(name: String!): List
+  filters: [Filter!]!
+  filter(id: Int!): Filter
+}
+
+type Mutation {
+  createTodo(title: String!, list: String, tags: [String!]): Todo!
+  completeTodo(id: Int!): Todo
+  deleteTodo(id: Int!): Boolean!
+  saveFilter(name: String!, query: String!): Filter!
+  deleteFilter(id: Int!): Boolean!
+}
+
+type Subscription {
+  todoChanged: TodoEvent!   # over websocket, graphql-transport-ws protocol
+}
+`
+
+// GraphQLRequest is the standard POST body
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQLResponse is the standard response body
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError is one entry of "errors"
+type GraphQLError struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+// gqlField is one selected field with its arguments and sub-selection
+type gqlField struct {
+	alias string
+	name  string
+	args  map[string]any
+	sel   []gqlField
+}
+
+// gqlOperation is one query, mutation or subscription in a document
+type gqlOperation struct {
+	kind string // query, mutation or subscription
+	name string
+	sel  []gqlField
+}
+
+// gqlVariable is a $name reference, resolved at execution time
+type gqlVariable string
+
+// gqlObject keeps response keys in selection order, as the spec requires
+type gqlObject struct {
+	keys   []string
+	values []any
+}
+
+
+// set appends a key/value pair
+func (o *gqlObject) set(key string, value any) {
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, value)
+}
+
+
+// MarshalJSON writes the keys in insertion order
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// --- lexer / parser ---
+
+// gqlParser is a recursive descent parser over the query text
+type gqlParser struct {
+	src string
+	pos int
+}
+
+
+// skip whitespace, commas (insignificant in GraphQL) and # comments
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+
+// peek returns the next significant byte (0 at end of input)
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+
+// expect consumes punctuator c
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("syntax error at offset %d: expected %q", p.pos, c)
+	}
+	p.pos++
+	return nil
+}
+
+
+// name reads a name token
+func (p *gqlParser) name() (string, error) {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || p.pos > start && c >= '0' && c <= '9' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("syntax error at offset %d: expected name", p.pos)
+	}
+	return p.src[start:p.pos], nil
+}
+
+
+// document parses every operation in the query
+func (p *gqlParser) document() ([]gqlOperation, error) {
+
+	var ops []gqlOperation
+	for p.peek() != 0 {
+
+		op := gqlOperation{kind: "query"}
+
+		// "{ ... }" is a shorthand anonymous query
+		if p.peek() != '{' {
+			kind, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "query", "mutation", "subscription":
+				op.kind = kind
+			case "fragment":
+				return nil, errors.New("fragments are not supported")
+			default:
+				return nil, fmt.Errorf("unknown operation type %q", kind)
+			}
+
+			// optional name
+			if c := p.peek(); c != '(' && c != '{' {
+				if op.name, err = p.name(); err != nil {
+					return nil, err
+				}
+			}
+
+			// variable definitions: types are not checked, values come from "variables"
+			if p.peek() == '(' {
+				if err := p.skipBalanced('(', ')'); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		sel, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.sel = sel
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return nil, errors.New("empty query")
+	}
+	return ops, nil
+}
+
+
+// skipBalanced skips a bracketed section, e.g. variable definitions
+func (p *gqlParser) skipBalanced(open, close byte) error {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return errors.New("syntax error: unbalanced brackets")
+}
+
+
+// selectionSet parses "{ field field(arg: 1) { sub } alias: field }"
+func (p *gqlParser) selectionSet() ([]gqlField, error) {
+
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	var fields []gqlField
+	for p.peek() != '}' {
+
+		if p.peek() == 0 {
+			return nil, errors.New("syntax error: unexpected end of query")
+		}
+		if strings.HasPrefix(p.src[p.pos:], "...") {
+			return nil, errors.New("fragments are not supported")
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		f := gqlField{alias: name, name: name}
+
+		// alias: field
+		if p.peek() == ':' {
+			p.pos++
+			if f.name, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peek() == '(' {
+			if f.args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peek() == '{' {
+			if f.sel, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+		}
+
+		fields = append(fields, f)
+	}
+	p.pos++
+
+	return fields, nil
+}
+
+
+// arguments parses "(name: value, ...)"
+func (p *gqlParser) arguments() (map[string]any, error) {
+
+	p.pos++ // (
+	args := map[string]any{}
+	for p.peek() != ')' {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(); err != nil {
+			return nil, err
+		}
+	}
+	p.pos++
+
+	return args, nil
+}
+
+
+// value parses a literal, a [list] of them or $variable
+func (p *gqlParser) value() (any, error) {
+
+	switch c := p.peek(); {
+	case c == '[':
+		p.pos++
+		list := []any{}
+		for p.peek() != ']' {
+			if p.peek() == 0 {
+				return nil, errors.New("syntax error: unterminated list")
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.pos++
+		return list, nil
+
+	case c == '$':
+		p.pos++
+		name, err := p.name()
+		return gqlVariable(name), err
+
+	case c == '"':
+		// find the closing quote, honouring escapes, and let strconv decode it
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != '"' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return nil, errors.New("syntax error: unterminated string")
+		}
+		s, err := strconv.Unquote(p.src[p.pos : end+1])
+		p.pos = end + 1
+		return s, err
+
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.src[start:p.pos], 64)
+
+	default:
+		// true, false, null or an enum value
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return name, nil
+	}
+}
+
+// --- execution ---
+
+
+// arg returns a field argument with variables substituted
+func (f gqlField) arg(name string, vars map[string]any) (any, bool) {
+	v, ok := f.args[name]
+	if ref, isVar := v.(gqlVariable); isVar {
+		v, ok = vars[string(ref)]
+	}
+	return v, ok && v != nil
+}
+
+
+// intArg reads a required Int argument (JSON numbers arrive as float64)
+func (f gqlField) intArg(name string, vars map[string]any) (int, error) {
+	v, ok := f.arg(name, vars)
+	n, isNum := v.(float64)
+	if !ok || !isNum || n != float64(int(n)) {
+		return 0, fmt.Errorf("argument %q of %s must be an Int", name, f.name)
+	}
+	return int(n), nil
+}
+
+
+// stringArg reads an optional String argument, "" when it's absent
+func (f gqlField) stringArg(name string, vars map[string]any) (string, error) {
+	v, ok := f.arg(name, vars)
+	s, isString := v.(string)
+	if ok && !isString {
+		return "", fmt.Errorf("argument %q of %s must be a String", name, f.name)
+	}
+	return s, nil
+}
+
+
+// stringsArg reads an optional [String!] argument
+func (f gqlField) stringsArg(name string, vars map[string]any) ([]string, error) {
+	v, _ := f.arg(name, vars)
+	items, _ := v.([]any)
+	if v != nil && items == nil {
+		return nil, fmt.Errorf("argument %q of %s must be a list of Strings", name, f.name)
+	}
+	var list []string
+	for _, item := range items {
+		s, isString := item.(string)
+		if !isString {
+			return nil, fmt.Errorf("argument %q of %s must be a list of Strings", name, f.name)
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+
+// projectTodo builds the selected fields of a Todo
+func projectTodo(t model.Todo, sel []gqlField) (*gqlObject, error) {
+
+	obj := &gqlObject{}
+	for _, f := range sel {
+		switch f.name {
+		case "id":
+			obj.set(f.alias, t.ID)
+		case "title":
+			obj.set(f.alias, t.Title)
+		case "done":
+			obj.set(f.alias, t.Done)
+		case "list":
+			if t.List == "" {
+				obj.set(f.alias, nil)
+			} else {
+				obj.set(f.alias, t.List)
+			}
+		case "tags":
+			obj.set(f.alias, append([]string{}, t.Tags...))
+		case "__typename":
+			obj.set(f.alias, "Todo")
+		default:
+			return nil, fmt.Errorf("unknown field %q on Todo", f.name)
+		}
+	}
+	return obj, nil
+}
+
+
+// projectTodos builds a [Todo!]!
+func projectTodos(todos []model.Todo, sel []gqlField) ([]*gqlObject, error) {
+	list := []*gqlObject{}
+	for _, t := range todos {
+		obj, err := projectTodo(t, sel)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, obj)
+	}
+	return list, nil
+}
+
+
+// projectList builds the selected fields of a List
+func projectList(ctx context.Context, l ListSummary, sel []gqlField) (*gqlObject, error) {
+
+	obj := &gqlObject{}
+	for _, f := range sel {
+		switch f.name {
+		case "name":
+			obj.set(f.alias, l.Name)
+		case "open":
+			obj.set(f.alias, l.Open)
+		case "done":
+			obj.set(f.alias, l.Done)
+		case "todos":
+			todos, err := projectTodos(todoStore.Find(ctx, store.Filter{List: l.Name}), f.sel)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(f.alias, todos)
+		case "__typename":
+			obj.set(f.alias, "List")
+		default:
+			return nil, fmt.Errorf("unknown field %q on List", f.name)
+		}
+	}
+	return obj, nil
+}
+
+
+// projectFilter builds the selected fields of a Filter, running it for todos
+func projectFilter(r *http.Request, saved SavedFilter, sel []gqlField) (*gqlObject, error) {
+
+	obj := &gqlObject{}
+	for _, f := range sel {
+		switch f.name {
+		case "id":
+			obj.set(f.alias, saved.ID)
+		case "name":
+			obj.set(f.alias, saved.Name)
+		case "query":
+			obj.set(f.alias, saved.Query)
+		case "createdAt":
+			obj.set(f.alias, saved.CreatedAt.Format(time.RFC3339Nano))
+		case "todos":
+			found, err := savedFilterTodos(r, saved)
+			if err != nil {
+				return nil, err
+			}
+			todos, err := projectTodos(found, f.sel)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(f.alias, todos)
+		case "__typename":
+			obj.set(f.alias, "Filter")
+		default:
+			return nil, fmt.Errorf("unknown field %q on Filter", f.name)
+		}
+	}
+	return obj, nil
+}
+
+
+// projectEvent builds the selected fields of a TodoEvent
+func projectEvent(e model.Event, sel []gqlField) (*gqlObject, error) {
+
+	obj := &gqlObject{}
+	for _, f := range sel {
+		switch f.name {
+		case "type":
+			obj.set(f.alias, e.Type)
+		case "time":
+			obj.set(f.alias, e.Time.Format("2006-01-02T15:04:05.999999999Z07:00"))
+		case "todo":
+			todo, err := projectTodo(e.Todo, f.sel)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(f.alias, todo)
+		case "__typename":
+			obj.set(f.alias, "TodoEvent")
+		default:
+			return nil, fmt.Errorf("unknown field %q on TodoEvent", f.name)
+		}
+	}
+	return obj, nil
+}
+
+
+// executeOperation runs a query or mutation for r, whose zone and client filters are run for;
+// mutation fields run in order as the spec requires
+func executeOperation(r *http.Request, op gqlOperation, vars map[string]any) (*gqlObject, error) {
+
+	data := &gqlObject{}
+	for _, f := range op.sel {
+
+		// __typename is valid on every root type
+		if f.name == "__typename" {
+			data.set(f.alias, map[string]string{"query": "Query", "mutation": "Mutation"}[op.kind])
+			continue
+		}
+
+		value, err := resolveRootField(r, op.kind, f, vars)
+		if err != nil {
+			return nil, err
+		}
+		data.set(f.alias, value)
+	}
+	return data, nil
+}
+
+
+// resolveRootField resolves one Query or Mutation field
+func resolveRootField(r *http.Request, kind string, f gqlField, vars map[string]any) (any, error) {
+
+	ctx := r.Context()
+	switch kind + "." + f.name {
+	case "query.todos":
+		// optional done, list and tag filters
+		done, filter := f.arg("done", vars)
+		var where store.Filter
+		if filter {
+			b, isBool := done.(bool)
+			if !isBool {
+				return nil, errors.New("argument done must be a Boolean")
+			}
+			where.Done = &b
+		}
+		list, err := f.stringArg("list", vars)
+		if err != nil {
+			return nil, err
+		}
+		tag, err := f.stringArg("tag", vars)
+		if err != nil {
+			return nil, err
+		}
+		where.List = strings.TrimSpace(list)
+		if tags := model.CleanTags([]string{tag}); tags != nil {
+			where.Tag = tags[0]
+		}
+		return projectTodos(todoStore.Find(ctx, where), f.sel)
+
+	case "query.todo":
+		id, err := f.intArg("id", vars)
+		if err != nil {
+			return nil, err
+		}
+		todo, err := todoStore.Get(ctx, id)
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return projectTodo(todo, f.sel)
+
+	case "query.lists":
+		list := []*gqlObject{}
+		for _, name := range todoStore.Lists(ctx) {
+			obj, err := projectList(ctx, listSummary(ctx, name), f.sel)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, obj)
+		}
+		return list, nil
+
+	case "query.list":
+		name, err := f.stringArg("name", vars)
+		if err != nil {
+			return nil, err
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			return nil, nil
+		}
+		summary := listSummary(ctx, name)
+		if summary.Open+summary.Done == 0 {
+			return nil, nil
+		}
+		return projectList(ctx, summary, f.sel)
+
+	case "query.filters":
+		list := []*gqlObject{}
+		for _, saved := range savedFiltersOf(ctx) {
+			obj, err := projectFilter(r, saved, f.sel)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, obj)
+		}
+		return list, nil
+
+	case "query.filter":
+		id, err := f.intArg("id", vars)
+		if err != nil {
+			return nil, err
+		}
+		saved, err := savedFilter(ctx, id)
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return projectFilter(r, saved, f.sel)
+
+	case "mutation.createTodo":
+		title, _ := f.arg("title", vars)
+		s, ok := title.(string)
+		if !ok {
+			return nil, errors.New(`argument "title" of createTodo must be a String`)
+		}
+		list, err := f.stringArg("list", vars)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := f.stringsArg("tags", vars)
+		if err != nil {
+			return nil, err
+		}
+		todo, err := todoStore.Create(ctx, model.Todo{Title: s, List: strings.TrimSpace(list), Tags: tags})
+		if err != nil {
+			return nil, err
+		}
+		return projectTodo(todo, f.sel)
+
+	case "mutation.completeTodo":
+		id, err := f.intArg("id", vars)
+		if err != nil {
+			return nil, err
+		}
+		todo, err := todoStore.Complete(ctx, id)
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return projectTodo(todo, f.sel)
+
+	case "mutation.deleteTodo":
+		id, err := f.intArg("id", vars)
+		if err != nil {
+			return nil, err
+		}
+		err = todoStore.Delete(ctx, id)
+		if errors.Is(err, model.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+
+	case "mutation.saveFilter":
+		name, _ := f.arg("name", vars)
+		query, _ := f.arg("query", vars)
+		var req SaveFilterRequest
+		var ok bool
+		if req.Name, ok = name.(string); !ok {
+			return nil, errors.New(`argument "name" of saveFilter must be a String`)
+		}
+		if req.Query, ok = query.(string); !ok {
+			return nil, errors.New(`argument "query" of saveFilter must be a String`)
+		}
+		saved, err := saveFilter(r, req)
+		if err != nil {
+			return nil, err
+		}
+		return projectFilter(r, saved, f.sel)
+
+	case "mutation.deleteFilter":
+		id, err := f.intArg("id", vars)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := savedFilter(ctx, id); errors.Is(err, model.ErrNotFound) {
+			return false, nil
+		}
+		deleteSavedFilter(id)
+		return true, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q on %s", f.name, kind)
+}
+
+
+// pickOperation parses a query and selects the operation to run
+func pickOperation(query, operationName string) (gqlOperation, error) {
+
+	ops, err := (&gqlParser{src: query}).document()
+	if err != nil {
+		return gqlOperation{}, err
+	}
+
+	// a single operation needs no name
+	if operationName == "" {
+		if len(ops) > 1 {
+			return gqlOperation{}, errors.New("operationName is required for documents with several operations")
+		}
+		return ops[0], nil
+	}
+
+	for _, op := range ops {
+		if op.name == operationName {
+			return op, nil
+		}
+	}
+	return gqlOperation{}, fmt.Errorf("no operation named %q", operationName)
+}
+
+
+// run GraphQL queries (GET or POST) and mutations (POST), open subscriptions (websocket upgrade)
+func graphqlHandler(w http.ResponseWriter, r *http.Request) {
+
+	// subscriptions come in as a websocket upgrade
+	if headerHasToken(r.Header, "Upgrade", "websocket") {
+		requireFeature("event-stream", graphqlSubscriptions)(w, r)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	var req GraphQLRequest
+	switch r.Method {
+	case http.MethodPost:
+		// err handling for decoding request body (bad input)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+	case http.MethodGet:
+		// ?query=...&variables={...}, read-only so it keeps working in maintenance mode
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" && json.Unmarshal([]byte(v), &req.Variables) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	op, err := pickOperation(req.Query, req.OperationName)
+	if err == nil && op.kind == "subscription" {
+		err = errors.New("subscriptions are only available over websocket")
+	}
+	if err == nil && op.kind == "mutation" && r.Method == http.MethodGet {
+		err = errors.New("mutations require POST")
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}})
+		return
+	}
+
+	// field errors still answer 200, per GraphQL over HTTP
+	data, err := executeOperation(r, op, req.Variables)
+	if err != nil {
+		json.NewEncoder(w).Encode(GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}})
+		return
+	}
+	json.NewEncoder(w).Encode(GraphQLResponse{Data: data})
+}
+
+
+// get the GraphQL schema in SDL form
+func graphqlSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(graphqlSchema))
+}
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server"  // for seeded todos
+)
+
+// GraphQL tests: todos with their lists and tags, lists, saved filters and the mutations on them
+
+
+// TestGraphQL checks each query and mutation's answer, one server for the table in order
+func TestGraphQL(t *testing.T) {
+
+	s := apitest.New(t)
+	s.Seed(apitest.NewSeed().Add(
+		server.Todo{Title: "milk", List: "groceries", Tags: []string{"dairy"}},
+		server.Todo{Title: "bread", List: "groceries", Done: true},
+		server.Todo{Title: "file taxes", Tags: []string{"home"}},
+	))
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   string
+	}{
+		{"todos with lists and tags", `{ todos { id list tags } }`, http.StatusOK,
+			`{"data":{"todos":[{"id":1,"list":"groceries","tags":["dairy"]},{"id":2,"list":"groceries","tags":[]},{"id":3,"list":null,"tags":["home"]}]}}`},
+		{"todos by list and tag", `{ a: todos(list: "groceries", done: false) { id } b: todos(tag: "#HOME") { id } }`, http.StatusOK,
+			`{"data":{"a":[{"id":1}],"b":[{"id":3}]}}`},
+		{"lists", `{ lists { name open done todos { title } } list(name: "groceries") { done } none: list(name: "nope") { done } }`, http.StatusOK,
+			`{"data":{"lists":[{"name":"groceries","open":1,"done":1,"todos":[{"title":"milk"},{"title":"bread"}]}],"list":{"done":1},"none":null}}`},
+		{"create on a list", `mutation { createTodo(title: "soap", list: "groceries", tags: ["Bathroom"]) { id list tags } }`, http.StatusOK,
+			`{"data":{"createTodo":{"id":4,"list":"groceries","tags":["bathroom"]}}}`},
+		{"no filters yet", `{ filters { id } filter(id: 1) { id } }`, http.StatusOK,
+			`{"data":{"filters":[],"filter":null}}`},
+		{"save a filter", `mutation { saveFilter(name: "Shopping", query: "done=false&list=groceries") { id name query todos { title } } }`, http.StatusOK,
+			`{"data":{"saveFilter":{"id":1,"name":"Shopping","query":"done=false&list=groceries","todos":[{"title":"milk"},{"title":"soap"}]}}}`},
+		{"filters", `{ filters { id name __typename } filter(id: 1) { todos { id } } }`, http.StatusOK,
+			`{"data":{"filters":[{"id":1,"name":"Shopping","__typename":"Filter"}],"filter":{"todos":[{"id":1},{"id":4}]}}}`},
+		{"bad filter", `mutation { saveFilter(name: "x", query: "colour=red") { id } }`, http.StatusOK,
+			`{"errors":[{"message":"validation failed: colour: is not a filter (done, due, due_after, due_before, q, list, tag, created_by)"}]}`},
+		{"unknown field on Filter", `{ filters { owner } }`, http.StatusOK,
+			`{"errors":[{"message":"unknown field \"owner\" on Filter"}]}`},
+		{"delete a filter", `mutation { deleteFilter(id: 1) again: deleteFilter(id: 1) }`, http.StatusOK,
+			`{"data":{"deleteFilter":true,"again":false}}`},
+		{"tags not a list", `mutation { createTodo(title: "x", tags: "a") { id } }`, http.StatusOK,
+			`{"errors":[{"message":"argument \"tags\" of createTodo must be a list of Strings"}]}`},
+		{"unterminated list", `mutation { createTodo(title: "x", tags: ["a"`, http.StatusBadRequest,
+			`{"errors":[{"message":"syntax error: unterminated list"}]}`},
+	}
+	for _, tt := range tests {
+		t.Log(tt.name)
+		s.Post("/graphql", map[string]string{"query": tt.query}).ExpectStatus(tt.status).ExpectJSON(tt.want)
+	}
+}
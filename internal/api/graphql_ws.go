@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json" // for protocol messages
+	"net/http"      // for the upgrade request
+	"time"          // for keepalive pings
+)
+
+// subscriptions use the graphql-transport-ws protocol (as spoken by graphql-ws / Apollo)
+const graphqlWSProtocol = "graphql-transport-ws"
+
+// gqlWSMessage is one protocol message in either direction
+type gqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+
+// send writes one protocol message
+func (c *wsConn) sendGraphQL(id, typ string, payload any) error {
+
+	msg := gqlWSMessage{ID: id, Type: typ}
+	if payload != nil {
+		msg.Payload, _ = json.Marshal(payload)
+	}
+	b, _ := json.Marshal(msg)
+	return c.writeFrame(wsOpText, b)
+}
+
+
+// graphqlSubscriptions serves subscribe / complete requests over one websocket
+func graphqlSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	conn, err := upgradeWebSocket(w, r, graphqlWSProtocol)
+	if err != nil {
+		return
+	}
+	defer conn.conn.Close()
+
+	// subscribe before acking so no event between ack and subscribe is lost
+	events := todoStore.Events().Subscribe()
+	defer todoStore.Events().Unsubscribe(events)
+
+	// client messages are decoded in the background
+	incoming := make(chan gqlWSMessage)
+	go func() {
+		defer close(incoming)
+		for {
+			data, err := conn.readMessage()
+			if err != nil {
+				return
+			}
+			var msg gqlWSMessage
+			if json.Unmarshal(data, &msg) != nil {
+				return
+			}
+			incoming <- msg
+		}
+	}()
+
+	// active subscriptions: id -> selected todoChanged field
+	active := map[string]gqlField{}
+
+	ping := time.NewTicker(wsPingEvery)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg, ok := <-incoming:
+			if !ok {
+				return
+			}
+			handleGraphQLMessage(r, conn, msg, active)
+
+		case e := <-events:
+			// fan the event out to every active subscription with its own selection
+			for id, f := range active {
+				obj, err := projectEvent(e, f.sel)
+				if err != nil {
+					conn.sendGraphQL(id, "error", []GraphQLError{{Message: err.Error()}})
+					delete(active, id)
+					continue
+				}
+				data := &gqlObject{}
+				data.set(f.alias, obj)
+				conn.sendGraphQL(id, "next", GraphQLResponse{Data: data})
+			}
+
+		case <-ping.C:
+			// protocol level keepalive
+			conn.sendGraphQL("", "ping", nil)
+		}
+	}
+}
+
+
+// handleGraphQLMessage reacts to one client message on the connection r upgraded
+func handleGraphQLMessage(r *http.Request, conn *wsConn, msg gqlWSMessage, active map[string]gqlField) {
+
+	switch msg.Type {
+	case "connection_init":
+		conn.sendGraphQL("", "connection_ack", nil)
+
+	case "ping":
+		conn.sendGraphQL("", "pong", nil)
+
+	case "complete":
+		delete(active, msg.ID)
+
+	case "subscribe":
+		var req GraphQLRequest
+		if err := json.Unmarshal(msg.Payload, &req); err != nil {
+			conn.sendGraphQL(msg.ID, "error", []GraphQLError{{Message: "invalid payload"}})
+			return
+		}
+
+		op, err := pickOperation(req.Query, req.OperationName)
+		if err != nil {
+			conn.sendGraphQL(msg.ID, "error", []GraphQLError{{Message: err.Error()}})
+			return
+		}
+
+		// queries and mutations are allowed too: one result, then complete
+		if op.kind != "subscription" {
+			data, err := executeOperation(r, op, req.Variables)
+			if err != nil {
+				conn.sendGraphQL(msg.ID, "next", GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}})
+			} else {
+				conn.sendGraphQL(msg.ID, "next", GraphQLResponse{Data: data})
+			}
+			conn.sendGraphQL(msg.ID, "complete", nil)
+			return
+		}
+
+		// a subscription selects exactly one root field
+		if len(op.sel) != 1 || op.sel[0].name != "todoChanged" {
+			conn.sendGraphQL(msg.ID, "error", []GraphQLError{{Message: "subscriptions must select exactly todoChanged"}})
+			return
+		}
+		active[msg.ID] = op.sel[0]
+	}
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"bytes"           // for holding a refusal's body
+	"encoding/binary" // for message framing
+	"encoding/json"   // for reading a refusal's body
+	"errors"          // for framing errors
+	"fmt"             // for printing logs to terminal
+	"io"              // for reading request bodies
+	"net"             // for the gRPC listener
+	"net/http"        // for HTTP/2 server
+	"strconv"         // for status codes in trailers
+	"strings"         // for path parsing
+	"time"            // for server timeouts
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// gRPC runs on its own port (off unless an address is given)
+var grpcAddr = flags.String("grpc-addr", "", "listen address for the gRPC TodoService, e.g. :9090 (disabled when empty)")
+
+// gRPC status codes we return
+const (
+	grpcOK                 = 0
+	grpcInvalidArgument    = 3
+	grpcNotFound           = 5
+	grpcPermissionDenied   = 7
+	grpcResourceExhausted  = 8
+	grpcFailedPrecondition = 9
+	grpcAborted            = 10
+	grpcUnimplemented      = 12
+	grpcInternal           = 13
+	grpcUnavailable        = 14
+	grpcUnauthenticated    = 16
+)
+
+// service prefix from proto/todo.proto
+const grpcService = "/todo.v1.TodoService/"
+
+// largest request message we accept
+const grpcMaxMessage = 4 << 20
+
+// the calls that change nothing, let through in maintenance and while read-only like GETs
+var grpcReads = map[string]bool{"ListTodos": true, "GetTodo": true, "Watch": true}
+
+
+// grpcRead reports whether r is a gRPC call that only reads
+func grpcRead(r *http.Request) bool {
+	method, ok := strings.CutPrefix(r.URL.Path, grpcService)
+	return ok && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") && grpcReads[method]
+}
+
+
+// readGRPCMessage reads the single length-prefixed message of a unary call
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+
+	// 1 byte compressed flag + 4 byte big endian length
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > grpcMaxMessage {
+		return nil, errors.New("message too large")
+	}
+
+	msg := make([]byte, length)
+	_, err := io.ReadFull(r, msg)
+	return msg, err
+}
+
+
+// writeGRPCMessage writes one length-prefixed, uncompressed message
+func writeGRPCMessage(w http.ResponseWriter, msg []byte) error {
+
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+
+// finishGRPC sends the status trailers that end every call
+func finishGRPC(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	}
+}
+
+
+// finishGRPCError ends a call with the status for a store error, same mapping as errorStatus
+func finishGRPCError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		finishGRPC(w, grpcNotFound, "todo not found")
+	case errors.Is(err, model.ErrValidation):
+		finishGRPC(w, grpcInvalidArgument, err.Error())
+	case errors.Is(err, model.ErrRejected):
+		finishGRPC(w, grpcFailedPrecondition, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		finishGRPC(w, grpcAborted, err.Error())
+	case errors.Is(err, model.ErrUnavailable):
+		finishGRPC(w, grpcUnavailable, err.Error())
+	default:
+		fmt.Println("gRPC call failed:", err)
+		finishGRPC(w, grpcInternal, "internal error")
+	}
+}
+
+
+// serve todo.v1.TodoService calls
+func grpcHandler(w http.ResponseWriter, r *http.Request) {
+
+	// gRPC is always POST over HTTP/2
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// every response is gRPC framed, errors travel in trailers
+	w.Header().Set("Content-Type", "application/grpc")
+
+	method, ok := strings.CutPrefix(r.URL.Path, grpcService)
+	if !ok {
+		finishGRPC(w, grpcUnimplemented, "unknown service")
+		return
+	}
+
+	req, err := readGRPCMessage(r.Body)
+	if err != nil {
+		finishGRPC(w, grpcInvalidArgument, err.Error())
+		return
+	}
+
+	switch method {
+	case "ListTodos":
+		writeGRPCMessage(w, marshalTodoListProto(todoStore.List(r.Context())))
+
+	case "GetTodo":
+		id, err := protoIntField(req, 1)
+		if err != nil {
+			finishGRPC(w, grpcInvalidArgument, err.Error())
+			return
+		}
+		todo, err := todoStore.Get(r.Context(), id)
+		if err != nil {
+			finishGRPCError(w, err)
+			return
+		}
+		writeGRPCMessage(w, marshalTodoProto(todo))
+
+	case "CreateTodo":
+		create, err := unmarshalCreateTodoProto(req)
+		if err != nil {
+			finishGRPC(w, grpcInvalidArgument, err.Error())
+			return
+		}
+		todo, err := todoStore.Create(r.Context(), model.Todo{Title: create.Title, Due: create.Due})
+		if err != nil {
+			finishGRPCError(w, err)
+			return
+		}
+		writeGRPCMessage(w, marshalTodoProto(todo))
+
+	case "UpdateTodo":
+		update, err := unmarshalUpdateTodoProto(req)
+		if err != nil {
+			finishGRPC(w, grpcInvalidArgument, err.Error())
+			return
+		}
+		todo, err := todoStore.Update(r.Context(), update.ID, update.apply)
+		if err != nil {
+			finishGRPCError(w, err)
+			return
+		}
+		writeGRPCMessage(w, marshalTodoProto(todo))
+
+	case "CompleteTodo":
+		id, err := protoIntField(req, 1)
+		if err != nil {
+			finishGRPC(w, grpcInvalidArgument, err.Error())
+			return
+		}
+		todo, err := todoStore.Complete(r.Context(), id)
+		if err != nil {
+			finishGRPCError(w, err)
+			return
+		}
+		writeGRPCMessage(w, marshalTodoProto(todo))
+
+	case "DeleteTodo":
+		id, err := protoIntField(req, 1)
+		if err != nil {
+			finishGRPC(w, grpcInvalidArgument, err.Error())
+			return
+		}
+		if err := todoStore.Delete(r.Context(), id); err != nil {
+			finishGRPCError(w, err)
+			return
+		}
+		writeGRPCMessage(w, nil)
+
+	case "Watch":
+		if !featureEnabled(r, "event-stream") {
+			finishGRPC(w, grpcUnimplemented, "Watch is not enabled on this server")
+			return
+		}
+		grpcWatch(w, r)
+
+	default:
+		finishGRPC(w, grpcUnimplemented, "unknown method "+method)
+		return
+	}
+
+	finishGRPC(w, grpcOK, "")
+}
+
+// grpcRefusal holds back what the middleware answers instead of a call (a 403 from filterIPs,
+// a 503 in maintenance...), for grpcGuards to send as a gRPC status: clients only read those
+type grpcRefusal struct {
+	http.ResponseWriter
+	status int // the refusal's HTTP status, 0 while the call goes through
+	body   bytes.Buffer
+}
+
+
+// WriteHeader holds back anything but a 200
+func (g *grpcRefusal) WriteHeader(code int) {
+	if code == http.StatusOK || g.status != 0 {
+		g.ResponseWriter.WriteHeader(code)
+		return
+	}
+	g.status = code
+}
+
+
+// Write keeps a refusal's body for its message
+func (g *grpcRefusal) Write(b []byte) (int, error) {
+	if g.status != 0 {
+		return g.body.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+
+// Unwrap lets http.ResponseController reach the underlying writer (Watch flushes)
+func (g *grpcRefusal) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+
+// grpcStatusFor is the gRPC status for a refusal's HTTP status, as gRPC maps them
+func grpcStatusFor(status int) int {
+	switch status {
+	case http.StatusBadRequest:
+		return grpcInvalidArgument
+	case http.StatusUnauthorized:
+		return grpcUnauthenticated
+	case http.StatusForbidden:
+		return grpcPermissionDenied
+	case http.StatusNotFound:
+		return grpcNotFound
+	case http.StatusMethodNotAllowed:
+		return grpcUnimplemented
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return grpcAborted
+	case http.StatusUnprocessableEntity:
+		return grpcFailedPrecondition
+	case http.StatusTooManyRequests:
+		return grpcResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return grpcUnavailable
+	}
+	return grpcInternal
+}
+
+
+// grpcGuards puts the calls through the same checks as the REST routes (client addresses,
+// tracing, chaos, maintenance, read-only, tenants and actors, see Handler), with what they turn
+// away answered as a gRPC status and the JSON error as its message
+func grpcGuards(next http.Handler) http.Handler {
+
+	guarded := filterIPs(traceRequests(injectChaos(rejectWritesInMaintenance(readOnlyWhenDegraded(selectTenants(recordActors(next)))))))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		refusal := &grpcRefusal{ResponseWriter: w}
+		guarded.ServeHTTP(refusal, r)
+		if refusal.status == 0 {
+			return
+		}
+
+		// trailers-only response: the status in the headers, no message
+		var body struct {
+			Error   string `json:"error"`
+			Message string `json:"message"` // maintenance's
+		}
+		json.Unmarshal(refusal.body.Bytes(), &body)
+		message := body.Error
+		if body.Message != "" {
+			message = body.Message
+		}
+		if message == "" {
+			message = http.StatusText(refusal.status)
+		}
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", strconv.Itoa(grpcStatusFor(refusal.status)))
+		w.Header().Set("Grpc-Message", message)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+
+// grpcWatch streams hub events until the client cancels the call
+func grpcWatch(w http.ResponseWriter, r *http.Request) {
+
+	events := todoStore.Events().Subscribe()
+	defer todoStore.Events().Unsubscribe(events)
+
+	// send headers now so the client knows the stream is open
+	flusher := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e := <-events:
+			if writeGRPCMessage(w, marshalEventProto(e)) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+
+// startGRPCServer serves the gRPC service over cleartext HTTP/2 in the background.
+// returns nil when gRPC is disabled.
+func startGRPCServer(ln net.Listener) *http.Server {
+
+	// nothing to do if gRPC is disabled
+	if ln == nil {
+		return nil
+	}
+
+	// gRPC clients speak HTTP/2 with prior knowledge, no TLS
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	srv := &http.Server{
+		Handler:           grpcGuards(http.HandlerFunc(grpcHandler)),
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Println("gRPC server started on", ln.Addr())
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Println("gRPC server stopped:", err)
+		}
+	}()
+
+	return srv
+}
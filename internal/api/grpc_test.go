@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"             // for request bodies
+	"context"           // for seeding the store
+	"encoding/hex"      // for the messages
+	"io"                // for reading responses
+	"net/http"          // for the HTTP/2 client and server
+	"net/http/httptest" // for the test server
+	"strconv"           // for the status trailer
+	"strings"           // for short reads
+	"testing"           // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for seeded todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// gRPC tests: message framing, and unary calls over cleartext HTTP/2 through the guards, the way
+// startGRPCServer serves them
+
+
+// TestReadGRPCMessage checks the length-prefixed framing a call's message comes in
+func TestReadGRPCMessage(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string // hex
+		want  string // hex of the message
+		ok    bool
+	}{
+		{"empty message", "0000000000", "", true},
+		{"message", "00000000030801ff", "0801ff", true},
+		{"only the first message", "000000000108" + "0000000001ff", "08", true},
+		{"compressed", "0100000001" + "08", "", false},
+		{"too large", "0000400001", "", false},
+		{"short prefix", "000000", "", false},
+		{"short message", "0000000003" + "08", "", false},
+		{"nothing", "", "", false},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.input)
+		msg, err := readGRPCMessage(bytes.NewReader(b))
+		if (err == nil) != tt.ok || tt.ok && hex.EncodeToString(msg) != tt.want {
+			t.Errorf("%s: readGRPCMessage = %x, %v; want %s", tt.name, msg, err, tt.want)
+		}
+	}
+}
+
+
+// TestWriteGRPCMessage checks a message is written after its flag and length
+func TestWriteGRPCMessage(t *testing.T) {
+
+	for _, msg := range []string{"", "0801", strings.Repeat("ab", 300)} {
+		w := httptest.NewRecorder()
+		b, _ := hex.DecodeString(msg)
+		writeGRPCMessage(w, b)
+		back, err := readGRPCMessage(w.Body)
+		if err != nil || !bytes.Equal(back, b) || w.Body.Len() != 0 {
+			t.Errorf("writeGRPCMessage(%.20s) read back as %x, %v", msg, back, err)
+		}
+	}
+}
+
+
+// TestGRPCStatusFor checks refusals map to the codes gRPC gives those HTTP statuses
+func TestGRPCStatusFor(t *testing.T) {
+
+	tests := map[int]int{
+		http.StatusBadRequest:          grpcInvalidArgument,
+		http.StatusUnauthorized:        grpcUnauthenticated,
+		http.StatusForbidden:           grpcPermissionDenied,
+		http.StatusNotFound:            grpcNotFound,
+		http.StatusConflict:            grpcAborted,
+		http.StatusTooManyRequests:     grpcResourceExhausted,
+		http.StatusServiceUnavailable:  grpcUnavailable,
+		http.StatusGatewayTimeout:      grpcUnavailable,
+		http.StatusInternalServerError: grpcInternal,
+		http.StatusTeapot:              grpcInternal,
+	}
+	for status, want := range tests {
+		if got := grpcStatusFor(status); got != want {
+			t.Errorf("grpcStatusFor(%d) = %d, want %d", status, got, want)
+		}
+	}
+}
+
+
+// startGRPCTest serves the gRPC service on a test server over a new store holding "buy milk",
+// and returns a client speaking HTTP/2 to it without TLS
+func startGRPCTest(t *testing.T) (*httptest.Server, *http.Client) {
+
+	todoStore = store.New(*changeLogSize)
+	todoStore.Create(context.Background(), model.Todo{Title: "buy milk"})
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := httptest.NewUnstartedServer(grpcGuards(http.HandlerFunc(grpcHandler)))
+	srv.Config.Protocols = &protocols
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+}
+
+
+// TestGRPCUnaryCalls checks each method's status and answer
+func TestGRPCUnaryCalls(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string // hex, framed
+		status  int
+		message string // hex of the answer, when OK
+	}{
+		{"list", "ListTodos", "0000000000", grpcOK, "0a0c" + "0801" + "1208" + "627579206d696c6b"},
+		{"get", "GetTodo", "00000000020801", grpcOK, "0801" + "1208" + "627579206d696c6b"},
+		{"get missing", "GetTodo", "00000000020863", grpcNotFound, ""},
+		{"create", "CreateTodo", "00000000040a027478", grpcOK, "0802" + "12027478"},
+		{"create without a title", "CreateTodo", "0000000000", grpcInvalidArgument, ""},
+		{"update", "UpdateTodo", "000000000708011203616263", grpcOK, "0801" + "1203616263"},
+		{"update missing", "UpdateTodo", "00000000020863", grpcNotFound, ""},
+		{"complete", "CompleteTodo", "00000000020801", grpcOK, "0801" + "1208" + "627579206d696c6b" + "1801"},
+		{"delete", "DeleteTodo", "00000000020801", grpcOK, ""},
+		{"delete missing", "DeleteTodo", "00000000020863", grpcNotFound, ""},
+		{"bad message", "GetTodo", "000000000108", grpcInvalidArgument, ""},
+		{"compressed", "GetTodo", "01000000020801", grpcInvalidArgument, ""},
+		{"unknown method", "Frobnicate", "0000000000", grpcUnimplemented, ""},
+		{"unknown service", "/other.v1.Service/GetTodo", "0000000000", grpcUnimplemented, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := startGRPCTest(t)
+			path := tt.path
+			if !strings.HasPrefix(path, "/") {
+				path = grpcService + path
+			}
+			body, _ := hex.DecodeString(tt.body)
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+path, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("Te", "trailers")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			answer, _ := io.ReadAll(resp.Body)
+
+			if resp.ProtoMajor != 2 || resp.StatusCode != http.StatusOK {
+				t.Fatalf("%s %d, want HTTP/2 200", resp.Proto, resp.StatusCode)
+			}
+			status := resp.Trailer.Get("Grpc-Status")
+			if status == "" {
+				status = resp.Header.Get("Grpc-Status") // trailers-only
+			}
+			if status != strconv.Itoa(tt.status) {
+				t.Fatalf("grpc-status %s (%s), want %d", status, resp.Trailer.Get("Grpc-Message"), tt.status)
+			}
+			if tt.status != grpcOK {
+				return
+			}
+			msg, err := readGRPCMessage(bytes.NewReader(answer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(msg); got != tt.message {
+				t.Errorf("answer %s, want %s", got, tt.message)
+			}
+		})
+	}
+}
+
+
+// TestGRPCRefusals checks what the guards turn away arrives as a gRPC status, not an HTTP error
+func TestGRPCRefusals(t *testing.T) {
+
+	srv, client := startGRPCTest(t)
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+	}{
+		{"writes in maintenance", "CreateTodo", grpcUnavailable},
+		{"reads in maintenance", "ListTodos", grpcOK},
+	}
+	setMaintenance(true, "upgrading")
+	defer setMaintenance(false, "")
+	for _, tt := range tests {
+		body, _ := hex.DecodeString("00000000040a027478")
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+grpcService+tt.method, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		status := resp.Trailer.Get("Grpc-Status")
+		if status == "" {
+			status = resp.Header.Get("Grpc-Status")
+		}
+		if resp.StatusCode != http.StatusOK || status != strconv.Itoa(tt.status) {
+			t.Errorf("%s: HTTP %d, grpc-status %s; want 200 and %d", tt.name, resp.StatusCode, status, tt.status)
+		}
+		if tt.status == grpcUnavailable && resp.Header.Get("Grpc-Message") == "" {
+			t.Errorf("%s: no grpc-message", tt.name)
+		}
+	}
+}
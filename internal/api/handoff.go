@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"         // for the posted items
+	"context"       // for the handoff's span
+	"crypto/subtle" // for checking the caller's secret
+	"encoding/json" // for the items
+	"errors"        // for handoff errors
+	"fmt"           // for printing logs to terminal and errors
+	"io"            // for reading the posted items
+	"net/http"      // for posting to the leader
+	"strconv"       // for the status code attribute
+	"strings"       // for the secret
+	"time"          // for the client's timeout
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for tracing handoffs
+)
+
+// handoff: what a server keeps for the one running the scheduled jobs (see scheduler.go), pending
+// todos and recurring rules, moves to that one when it's another server. a cluster node that
+// isn't leading posts them to the leader's -cluster-addr, with -cluster-secret; a replica sharing
+// Redis without the lease pushes them onto the list <prefix>handoff:<kind>, which the lease
+// holder takes them from on its next check. the receiver keeps them under ids of its own. the
+// sender drops its copy once the receiver has it, so an item whose answer got lost may arrive twice
+
+// how long posting to the leader may take
+const handoffTimeout = 5 * time.Second
+
+// most items taken from a Redis list at one check
+const handoffBatch = 100
+
+// client for posting to the leader
+var handoffClient = &http.Client{Timeout: handoffTimeout}
+
+// what takes in the items of each kind handed over
+var handoffReceivers = map[string]func(item json.RawMessage) error{
+	"scheduled": receiveScheduledTodo,
+	"recurring": receiveRecurrence,
+}
+
+
+// handOff sends items of kind to the server running the scheduled jobs; nil once it has them
+func handOff(kind string, items []json.RawMessage) error {
+
+	switch {
+	case cluster != nil:
+		leader, _ := cluster.Leader()
+		addr, ok := clusterNodes[leader]
+		if leader == "" || leader == *clusterID || !ok {
+			return errors.New("the cluster has no other leader to hand over to")
+		}
+		body, _ := json.Marshal(items)
+		req, _ := http.NewRequest(http.MethodPost, strings.TrimSuffix(addr, "/")+"/handoff/"+kind, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+*clusterSecret)
+		req.Header.Set("Content-Type", "application/json")
+
+		// no request started it, so the handoff is a trace of its own, continued by the leader
+		_, span := tracing.Start(context.Background(), "POST /handoff/{kind}", tracing.KindClient)
+		defer span.End()
+		span.SetAttr("handoff.kind", kind)
+		span.SetStatus(tracing.StatusError)
+		tracing.Propagate(req.Header, span.Traceparent())
+
+		resp, err := handoffClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		span.SetAttr("http.status_code", strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("the leader, %s, answered %s", leader, resp.Status)
+		}
+		span.SetStatus(tracing.StatusOK)
+		return nil
+
+	case shared != nil:
+		args := []string{"RPUSH", *redisPrefix + "handoff:" + kind}
+		for _, item := range items {
+			args = append(args, string(item))
+		}
+		shared.mu.Lock()
+		defer shared.mu.Unlock()
+		_, err := shared.command(args...)
+		return err
+	}
+	return errors.New("no other server to hand over to")
+}
+
+
+// takeHandoffs takes in what replicas pushed onto kind's Redis list (a cluster's are posted)
+func takeHandoffs(kind string) {
+
+	if shared == nil {
+		return
+	}
+	for i := 0; i < handoffBatch; i++ {
+		shared.mu.Lock()
+		reply, err := shared.command("LPOP", *redisPrefix+"handoff:"+kind)
+		shared.mu.Unlock()
+		item, ok := reply.(string)
+		if err != nil || !ok {
+			return
+		}
+		if err := handoffReceivers[kind](json.RawMessage(item)); err != nil {
+			fmt.Println("handoff: dropping a", kind, "item that doesn't read:", err)
+		}
+	}
+}
+
+
+// POST /handoff/{kind} on -cluster-addr takes in items a follower hands over; 503 unless leading
+func handoffHandler(w http.ResponseWriter, r *http.Request) {
+
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(*clusterSecret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	receive, ok := handoffReceivers[r.PathValue("kind")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// only the one running the scheduled jobs keeps them; the sender tries again
+	if !scheduling() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var items []json.RawMessage
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 16<<20))
+	if err != nil || json.Unmarshal(body, &items) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for _, item := range items {
+		if err := receive(item); err != nil {
+			fmt.Println("handoff: dropping a", r.PathValue("kind"), "item that doesn't read:", err)
+		}
+	}
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
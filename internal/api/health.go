@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"net/http"      // for HTTP handlers
+	"runtime"       // for goroutine count
+	"strconv"       // for int -> string conversion
+	"sync/atomic"   // for the shutdown flag
+	"time"          // for uptime and timeouts
+)
+
+// process start time, used to report uptime
+var startedAt = time.Now()
+
+// set once graceful shutdown starts, so load balancers stop routing to us
+var shuttingDown atomic.Bool
+
+// how long readiness waits for the store lock before giving up
+const storeCheckTimeout = time.Second
+
+// HealthResponse is the JSON body returned by the probe endpoints
+type HealthResponse struct {
+	Status string            `json:"status"`           // "ok" or "unavailable"
+	Uptime string            `json:"uptime,omitempty"` // time since process start
+	Checks map[string]string `json:"checks,omitempty"` // per-dependency detail
+}
+
+
+// writeHealth sends a probe response with the matching status code
+func writeHealth(w http.ResponseWriter, resp HealthResponse) {
+
+	// probes always answer in JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// anything but "ok" means the probe failed
+	if resp.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+
+// healthz: process is up and serving HTTP
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+
+	writeHealth(w, HealthResponse{
+		Status: "ok",
+		Uptime: time.Since(startedAt).Round(time.Second).String(),
+	})
+}
+
+
+// livez: process is alive and the runtime is scheduling goroutines
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+
+	writeHealth(w, HealthResponse{
+		Status: "ok",
+		Checks: map[string]string{
+			"goroutines": strconv.Itoa(runtime.NumGoroutine()),
+		},
+	})
+}
+
+
+// readyz: store is reachable and ready to take traffic
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+
+	resp := HealthResponse{Status: "ok", Checks: map[string]string{}}
+
+	// draining, take us out of rotation
+	if shuttingDown.Load() {
+		resp.Status = "unavailable"
+		resp.Checks["server"] = "shutting down"
+	}
+
+	// store is reachable if we can take its lock in time
+	if checkStore() {
+		resp.Checks["store"] = "ok"
+	} else {
+		resp.Status = "unavailable"
+		resp.Checks["store"] = "timeout acquiring store lock"
+	}
+
+	// a node without a leader, or a replica that can't follow the shared log, is read-only: it
+	// stays in rotation for the reads, see degraded.go
+	if cluster != nil {
+		resp.Checks["cluster"] = "no leader"
+		if leader, _ := cluster.Leader(); leader != "" {
+			resp.Checks["cluster"] = "ok (leader " + leader + ")"
+		}
+	}
+	if shared != nil {
+		resp.Checks["redis"] = "not following the shared log"
+		if shared.healthy.Load() {
+			resp.Checks["redis"] = "ok"
+		}
+	}
+	if reason, since, _ := degraded(); reason != "" {
+		resp.Checks["writes"] = "read-only since " + since.UTC().Format(time.RFC3339) + ": " + reason
+	}
+
+	// an open breaker fails changes, reads go on (not a reason to take the server out of rotation)
+	if storeBreaker != nil {
+		resp.Checks["breaker"] = storeBreaker.Status().State
+	}
+
+	// which of several servers runs the scheduled jobs (not a reason to take one out of rotation)
+	if cluster != nil || shared != nil {
+		resp.Checks["scheduler"] = "standing by"
+		if scheduling() {
+			resp.Checks["scheduler"] = "running the scheduled jobs"
+		}
+	}
+
+	// in-memory store has no schema, so there is nothing to migrate
+	resp.Checks["migrations"] = "ok (in-memory store, none required)"
+
+	writeHealth(w, resp)
+}
+
+
+// checkStore reports whether the store lock can be acquired within storeCheckTimeout
+func checkStore() bool {
+
+	done := make(chan struct{})
+
+	// try to grab the shard locks in the background so a stuck lock can't hang the probe.
+	// read locks are enough: they wait for a stuck writer but don't block other readers
+	go func() {
+		todoStore.Probe()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(storeCheckTimeout):
+		return false
+	}
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"bytes"         // for rendering before writing
+	"errors"        // for missing todos
+	"fmt"           // for printing logs to terminal
+	"html/template" // for the pages, escaped
+	"net/http"      // for HTTP handlers
+	"net/url"       // for the htmx script's origin
+	"sort"          // for id order
+	"strings"       // for the CSP
+	"sync"          // for the templates of each language
+	"time"          // for overdue checks
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for filters
+)
+
+// the server-rendered UI at /htmx/: plain forms that work without any script, which htmx
+// (when it loads) turns into swaps of one row instead of a page reload
+var htmxUI = flags.Bool("htmx-ui", true, "serve the server-rendered htmx UI at /htmx/")
+var htmxSrc = flags.String("htmx-src", "https://unpkg.com/htmx.org@2.0.4/dist/htmx.min.js", "URL of the htmx script the /htmx/ pages load (empty for plain forms)")
+
+// htmxPage is what the page template sees
+type htmxPage struct {
+	Todos  []model.Todo
+	Filter string // "", "false" or "true", as ?done=
+	Form   htmxForm
+	Count  htmxCount
+	Script string
+	Now    time.Time
+}
+
+// htmxForm is what the add form template sees
+type htmxForm struct {
+	Title string // the rejected title, so it isn't lost
+	Error string // why the last add failed
+	OOB   bool
+}
+
+// htmxRow is what the row template sees
+type htmxRow struct {
+	Todo model.Todo
+	Now  time.Time
+}
+
+// htmxCount is what the counter template sees
+type htmxCount struct {
+	Open, Done int64
+	OOB        bool
+}
+
+// one page, and the partials htmx swaps in: a row, the add form, the error and the counter.
+// hx-swap-oob parts ride along with a response to update elements outside its target. htmx
+// doesn't swap 4xx bodies by default, the config lets the 422 error partials through
+const htmxTemplates = `
+{{define "page"}}<!doctype html>
+<html lang="{{lang}}">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="htmx-config" content='{"includeIndicatorStyles": false, "responseHandling": [{"code": "204", "swap": false}, {"code": "[23]..", "swap": true}, {"code": "422", "swap": true}, {"code": "...", "swap": false, "error": true}]}'>
+	<title>{{t "Todos"}}</title>
+	<link rel="stylesheet" href="style.css">
+	{{with .Script}}<script src="{{.}}" defer></script>{{end}}
+</head>
+<body>
+	<main>
+		<h1>{{t "Todos"}}</h1>
+		{{template "form" .Form}}
+		<nav id="filters">
+			<a href="./" aria-current="{{eq .Filter ""}}">{{t "All"}}</a>
+			<a href="?done=false" aria-current="{{eq .Filter "false"}}">{{t "Open"}}</a>
+			<a href="?done=true" aria-current="{{eq .Filter "true"}}">{{t "Done"}}</a>
+		</nav>
+		<ul id="todos">{{range .Todos}}{{template "row" (row . $.Now)}}{{end}}</ul>
+		{{template "count" .Count}}
+	</main>
+</body>
+</html>
+{{end}}
+
+{{define "form"}}<form id="add" method="post" action="todos" hx-post="todos" hx-target="#todos" hx-swap="beforeend"{{if .OOB}} hx-swap-oob="true"{{end}}>
+	<input id="title" name="title" value="{{.Title}}" placeholder="{{t "What needs doing?"}}" maxlength="1000" required autofocus>
+	<input id="due" name="due" type="date" title="{{t "Due date (optional)"}}">
+	<button type="submit">{{t "Add"}}</button>
+</form>
+{{template "error" .}}{{end}}
+
+{{define "error"}}<p id="error" role="alert"{{if not .Error}} hidden{{end}}{{if .OOB}} hx-swap-oob="true"{{end}}>{{.Error}}</p>
+{{end}}
+
+{{define "row"}}<li id="todo-{{.Todo.ID}}"{{if .Todo.Done}} class="done"{{end}}>
+	<form method="post" action="todos/toggle?id={{.Todo.ID}}" hx-post="todos/toggle?id={{.Todo.ID}}" hx-target="closest li" hx-swap="outerHTML">
+		<button class="toggle" title="{{if .Todo.Done}}{{t "Mark as open"}}{{else}}{{t "Mark as done"}}{{end}}" aria-pressed="{{.Todo.Done}}">{{if .Todo.Done}}✓{{else}}○{{end}}</button>
+	</form>
+	<span class="title">{{.Todo.Title}}</span>
+	{{with .Todo.Due}}<span class="due{{if and (not $.Todo.Done) (.Before $.Now)}} overdue{{end}}">{{(.In $.Now.Location).Format "2006-01-02"}}</span>{{end}}
+	<form method="post" action="todos/delete?id={{.Todo.ID}}" hx-post="todos/delete?id={{.Todo.ID}}" hx-target="closest li" hx-swap="outerHTML">
+		<button class="delete" title="{{t "Delete"}}">✕</button>
+	</form>
+</li>
+{{end}}
+
+{{define "count"}}<p id="count"{{if .OOB}} hx-swap-oob="true"{{end}}>{{t "%d open, %d done" .Open .Done}}</p>
+{{end}}
+`
+
+// parsed once; failing to parse is a bug, not config. t and lang are bound per language
+// on a clone (htmxPagesFor), this one is never executed
+var htmxPages = template.Must(template.New("htmx").Funcs(template.FuncMap{
+	"row":  func(todo model.Todo, now time.Time) htmxRow { return htmxRow{Todo: todo, Now: now} },
+	"t":    func(msg string, args ...any) string { return msg },
+	"lang": func() string { return "en" },
+}).Parse(htmxTemplates))
+
+// the clones for each language asked for so far
+var (
+	htmxPagesMu     sync.Mutex
+	htmxPagesByLang = map[string]*template.Template{}
+)
+
+// the forms post from the page itself; refuse cross-site posts so no other site can
+// submit them from a visitor's browser
+var htmxCrossOrigin = http.NewCrossOriginProtection()
+
+
+// serves the page, its styles and the form posts below /htmx/ when the UI is on
+func htmxHandler() http.Handler {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/htmx/{$}", htmxPageHandler)
+	mux.HandleFunc("/htmx/style.css", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, uiAssets, "style.css")
+	})
+	mux.HandleFunc("/htmx/todos", htmxCreateHandler)
+	mux.HandleFunc("/htmx/todos/toggle", htmxToggleHandler)
+	mux.HandleFunc("/htmx/todos/delete", htmxDeleteHandler)
+	protected := htmxCrossOrigin.Handler(mux)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !*htmxUI {
+			http.NotFound(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+}
+
+
+// isHTMX reports whether htmx sent the request (and wants a partial back)
+func isHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+
+// htmxPagesFor returns the templates with their strings in lang
+func htmxPagesFor(lang string) *template.Template {
+
+	htmxPagesMu.Lock()
+	defer htmxPagesMu.Unlock()
+	if pages, ok := htmxPagesByLang[lang]; ok {
+		return pages
+	}
+	pages := template.Must(htmxPages.Clone()).Funcs(template.FuncMap{
+		"t": func(msg string, args ...any) string {
+			if len(args) > 0 {
+				return fmt.Sprintf(translate(lang, msg), args...)
+			}
+			return translate(lang, msg)
+		},
+		"lang": func() string { return lang },
+	})
+	htmxPagesByLang[lang] = pages
+	return pages
+}
+
+
+// renderHTMX executes the templates into one response in the client's language, or answers
+// 500 if one fails
+func renderHTMX(w http.ResponseWriter, r *http.Request, status int, parts ...func(*bytes.Buffer, *template.Template) error) {
+
+	lang := requestLanguage(r)
+	pages := htmxPagesFor(lang)
+	var buf bytes.Buffer
+	for _, part := range parts {
+		if err := part(&buf, pages); err != nil {
+			fmt.Println("htmx render failed:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+
+// htmxPart renders the named template with data
+func htmxPart(name string, data any) func(*bytes.Buffer, *template.Template) error {
+	return func(buf *bytes.Buffer, pages *template.Template) error {
+		return pages.ExecuteTemplate(buf, name, data)
+	}
+}
+
+
+// htmxCounter counts the whole store, not just the filtered rows
+func htmxCounter(oob bool) htmxCount {
+	total, done, _ := todoStore.Counts()
+	return htmxCount{Open: total - done, Done: done, OOB: oob}
+}
+
+
+// allowHTMXScript adds the script's origin to the CSP, which only allows 'self' by default
+func allowHTMXScript(w http.ResponseWriter) {
+
+	csp := w.Header().Get("Content-Security-Policy")
+	u, err := url.Parse(*htmxSrc)
+	if csp == "" || err != nil || u.Host == "" || strings.Contains(csp, "script-src") {
+		return
+	}
+	w.Header().Set("Content-Security-Policy", csp+"; script-src 'self' "+u.Scheme+"://"+u.Host)
+}
+
+
+// the whole page (?done= filters it like GET /todos)
+func htmxPageHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET (and HEAD)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	renderHTMXPage(w, r, http.StatusOK, htmxForm{})
+}
+
+
+// renderHTMXPage answers with the page, form showing what the last add left behind
+func renderHTMXPage(w http.ResponseWriter, r *http.Request, status int, form htmxForm) {
+
+	page := htmxPage{Form: form, Count: htmxCounter(false), Script: *htmxSrc, Now: todoStore.Now().In(defaultLocation)}
+	var f store.Filter
+	switch page.Filter = r.URL.Query().Get("done"); page.Filter {
+	case "true", "false":
+		done := page.Filter == "true"
+		f.Done = &done
+	default:
+		page.Filter = ""
+	}
+	page.Todos = todoStore.Find(r.Context(), f)
+	sort.Slice(page.Todos, func(i, j int) bool { return page.Todos[i].ID < page.Todos[j].ID })
+
+	allowHTMXScript(w)
+	renderHTMX(w, r, status, htmxPart("page", page))
+}
+
+
+// htmxFailed shows why a change failed: htmx gets the error paragraph in place of the old one,
+// plain forms get the page again with the error above the list
+func htmxFailed(w http.ResponseWriter, r *http.Request, form htmxForm, err error) {
+
+	status, resp, _ := errorResponse(r, err)
+	form.Error = resp.Error
+
+	if !isHTMX(r) {
+		renderHTMXPage(w, r, status, form)
+		return
+	}
+	w.Header().Set("HX-Retarget", "#error")
+	w.Header().Set("HX-Reswap", "outerHTML")
+	renderHTMX(w, r, http.StatusUnprocessableEntity, htmxPart("error", form))
+}
+
+
+// htmxDone answers a change that went through: htmx gets the parts, plain forms go back to the page
+func htmxDone(w http.ResponseWriter, r *http.Request, parts ...func(*bytes.Buffer, *template.Template) error) {
+
+	if !isHTMX(r) {
+		// relative, and left for the browser to resolve, so it works under a mount prefix too
+		target := "./"
+		if r.URL.Path != "/htmx/todos" {
+			target = "../"
+		}
+		w.Header().Set("Location", target)
+		w.WriteHeader(http.StatusSeeOther)
+		return
+	}
+	renderHTMX(w, r, http.StatusOK, append(parts, htmxPart("count", htmxCounter(true)))...)
+}
+
+
+// form post: add a todo; htmx appends its row and gets a fresh form
+func htmxCreateHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the picked day from midnight in the server's -timezone (forms send no Time-Zone header)
+	form := htmxForm{Title: r.PostFormValue("title")}
+	due, err := parseDue(r.PostFormValue("due"), defaultLocation)
+	if err != nil {
+		htmxFailed(w, r, form, model.Invalid("due", "is not a date"))
+		return
+	}
+	todo, err := todoStore.Create(r.Context(), model.Todo{Title: form.Title, Due: due})
+	if err != nil {
+		htmxFailed(w, r, form, err)
+		return
+	}
+
+	htmxDone(w, r, htmxPart("row", htmxRow{Todo: todo, Now: todoStore.Now().In(defaultLocation)}), htmxPart("form", htmxForm{OOB: true}))
+}
+
+
+// form post: flip a todo between open and done, htmx swaps its row
+func htmxToggleHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := queryID(r)
+	if err != nil {
+		htmxFailed(w, r, htmxForm{}, err)
+		return
+	}
+	todo, err := todoStore.Get(r.Context(), id)
+	if err == nil {
+		todo, err = todoStore.SetDone(r.Context(), id, !todo.Done)
+	}
+
+	// a todo deleted elsewhere loses its row
+	if errors.Is(err, model.ErrNotFound) {
+		htmxDone(w, r)
+		return
+	}
+	if err != nil {
+		htmxFailed(w, r, htmxForm{}, err)
+		return
+	}
+	htmxDone(w, r, htmxPart("row", htmxRow{Todo: todo, Now: todoStore.Now().In(defaultLocation)}), htmxPart("error", htmxForm{OOB: true}))
+}
+
+
+// form post: delete a todo, htmx removes its row
+func htmxDeleteHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := queryID(r)
+	if err != nil {
+		htmxFailed(w, r, htmxForm{}, err)
+		return
+	}
+
+	// already gone is fine, the row goes either way
+	if err := todoStore.Delete(r.Context(), id); err != nil && !errors.Is(err, model.ErrNotFound) {
+		htmxFailed(w, r, htmxForm{}, err)
+		return
+	}
+	htmxDone(w, r, htmxPart("error", htmxForm{OOB: true}))
+}
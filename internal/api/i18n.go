@@ -0,0 +1,182 @@
+package api
+
+import (
+	"embed"         // for the built-in catalogs
+	"encoding/json" // for reading catalogs
+	"errors"        // for matching validation errors
+	"fmt"           // for config errors
+	"net/http"      // for Accept-Language
+	"os"            // for -i18n-dir
+	"path/filepath" // for catalog file names
+	"regexp"        // for numbers inside messages
+	"slices"        // for ordering language preferences
+	"strconv"       // for q values
+	"strings"       // for parsing Accept-Language
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for validation errors
+)
+
+// error messages and the server-rendered UI in the client's language, picked from
+// Accept-Language. the messages are written in English; a catalog maps them to another
+// language, and anything a catalog doesn't have stays English
+var i18nDir = flags.String("i18n-dir", "", "directory of extra message catalogs, one <lang>.json each (added to or over the built-in de, fr, es)")
+
+// the built-in catalogs
+//
+//go:embed i18n/*.json
+var builtinCatalogs embed.FS
+
+// language -> English message -> translation, set up by loadCatalogs
+var catalogs = map[string]map[string]string{}
+
+// numbers in a message, so "todo 7" is found as "todo %d"
+var messageNumbers = regexp.MustCompile(`\d+`)
+
+
+// loadCatalogs reads the built-in catalogs, then the ones in -i18n-dir over them
+func loadCatalogs() error {
+
+	loaded := map[string]map[string]string{}
+	read := func(name string, data []byte) error {
+		lang := strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".json"))
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if loaded[lang] == nil {
+			loaded[lang] = map[string]string{}
+		}
+		for english, translated := range messages {
+			loaded[lang][english] = translated
+		}
+		return nil
+	}
+
+	builtin, _ := builtinCatalogs.ReadDir("i18n")
+	for _, entry := range builtin {
+		data, _ := builtinCatalogs.ReadFile("i18n/" + entry.Name())
+		if err := read(entry.Name(), data); err != nil {
+			return fmt.Errorf("built-in catalog %w", err)
+		}
+	}
+
+	if *i18nDir != "" {
+		files, err := filepath.Glob(filepath.Join(*i18nDir, "*.json"))
+		if err != nil || len(files) == 0 {
+			return fmt.Errorf("-i18n-dir: no <lang>.json catalogs in %s", *i18nDir)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("-i18n-dir: %w", err)
+			}
+			if err := read(file, data); err != nil {
+				return fmt.Errorf("-i18n-dir: %w", err)
+			}
+		}
+	}
+
+	catalogs = loaded
+	return nil
+}
+
+
+// requestLanguage picks the catalog for the request's Accept-Language, "en" when none fits
+func requestLanguage(r *http.Request) string {
+
+	type preference struct {
+		tag string
+		q   float64
+	}
+	var prefs []preference
+	for _, item := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if tag != "" && q > 0 {
+			prefs = append(prefs, preference{strings.ToLower(tag), q})
+		}
+	}
+	slices.SortStableFunc(prefs, func(a, b preference) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	// "de-CH" takes a de-ch catalog, or else de
+	for _, p := range prefs {
+		primary, _, _ := strings.Cut(p.tag, "-")
+		for _, lang := range []string{p.tag, primary} {
+			if lang == "en" {
+				return "en"
+			}
+			if _, ok := catalogs[lang]; ok {
+				return lang
+			}
+		}
+	}
+	return "en"
+}
+
+
+// translate looks a message up in the language's catalog, with numbers matched as %d
+func translate(lang, msg string) string {
+
+	catalog := catalogs[lang]
+	if catalog == nil {
+		return msg
+	}
+	if translated, ok := catalog[msg]; ok {
+		return translated
+	}
+
+	numbers := messageNumbers.FindAllString(msg, -1)
+	if len(numbers) == 0 {
+		return msg
+	}
+	translated, ok := catalog[messageNumbers.ReplaceAllString(msg, "%d")]
+	if !ok {
+		return msg
+	}
+	for _, n := range numbers {
+		translated = strings.Replace(translated, "%d", n, 1)
+	}
+	return translated
+}
+
+
+// localizeError translates an error the way writeError shows it: each ": " separated part
+// of the message, and every field of a validation error
+func localizeError(lang string, err error, resp ErrorResponse) ErrorResponse {
+
+	if lang == "en" {
+		return resp
+	}
+
+	var invalid *model.ValidationError
+	if errors.As(err, &invalid) {
+		fields := make([]model.FieldError, len(resp.Fields))
+		parts := make([]string, len(resp.Fields))
+		for i, f := range resp.Fields {
+			fields[i] = model.FieldError{Field: f.Field, Message: translate(lang, f.Message)}
+			parts[i] = f.Field + ": " + fields[i].Message
+		}
+		resp.Error, resp.Fields = translate(lang, model.ErrValidation.Error())+": "+strings.Join(parts, "; "), fields
+		return resp
+	}
+
+	parts := strings.Split(resp.Error, ": ")
+	for i, part := range parts {
+		parts[i] = translate(lang, part)
+	}
+	resp.Error = strings.Join(parts, ": ")
+	return resp
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"       // for printing logs to terminal
+	"net"       // for splitting host:port
+	"net/http"  // for HTTP handlers
+	"net/netip" // for CIDR matching
+	"strings"   // for list parsing
+)
+
+// perimeter config, comma separated CIDRs or single IPs
+var allowCIDRs = flags.String("allow-cidrs", "", "comma separated CIDRs allowed to connect, e.g. 192.168.1.0/24 (everyone when empty)")
+var denyCIDRs = flags.String("deny-cidrs", "", "comma separated CIDRs always refused, checked before the allow list")
+var publicSignedLinks = flags.Bool("public-signed-links", false, "let signed attachment links through from outside -allow-cidrs, e.g. for a CDN (-deny-cidrs still applies)")
+
+// parsed lists, filled once at startup by loadIPFilter
+var allowList []netip.Prefix
+var denyList []netip.Prefix
+
+
+// parsePrefixes turns "10.0.0.0/8, 192.168.1.7" into prefixes (a bare IP becomes a /32 or /128)
+func parsePrefixes(list string) ([]netip.Prefix, error) {
+
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(list, ",") {
+
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		// single address
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+
+// loadIPFilter parses -allow-cidrs, -deny-cidrs and -trusted-proxies
+func loadIPFilter() error {
+
+	var err error
+	if allowList, err = parsePrefixes(*allowCIDRs); err != nil {
+		return fmt.Errorf("-allow-cidrs: %w", err)
+	}
+	if denyList, err = parsePrefixes(*denyCIDRs); err != nil {
+		return fmt.Errorf("-deny-cidrs: %w", err)
+	}
+	if err = loadTrustedProxies(); err != nil {
+		return fmt.Errorf("-trusted-proxies: %w", err)
+	}
+	return nil
+}
+
+
+// matchesAny reports whether addr falls in one of the prefixes
+func matchesAny(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+
+// remoteAddr parses the peer address of the connection (ok=false for unix sockets)
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	// treat ::ffff:1.2.3.4 as 1.2.3.4 so v4 CIDRs match
+	return addr.Unmap(), true
+}
+
+
+// ipAllowed applies deny list first, then the allow list (if any)
+func ipAllowed(addr netip.Addr) bool {
+	if matchesAny(denyList, addr) {
+		return false
+	}
+	return len(allowList) == 0 || matchesAny(allowList, addr)
+}
+
+
+// publicDownload reports whether a client outside the allow list may have r anyway: a signed
+// attachment link says who may fetch the file, not where from, so only with -public-signed-links
+func publicDownload(r *http.Request, addr netip.Addr) bool {
+	if !*publicSignedLinks || matchesAny(denyList, addr) {
+		return false
+	}
+	_, _, signed := signedAttachment(r)
+	return signed
+}
+
+
+// filterIPs refuses clients outside the configured perimeter with 403
+func filterIPs(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// no lists configured, nothing to check
+		if len(allowList) == 0 && len(denyList) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// real client behind any trusted proxy; unix socket peers without
+		// forwarding headers are on this host and passed the file permissions already
+		addr, ok := clientIP(r)
+		if ok && !ipAllowed(addr) && !publicDownload(r, addr) {
+			fmt.Println("denied request from", addr, r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
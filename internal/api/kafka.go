@@ -0,0 +1,482 @@
+package api
+
+import (
+	"bufio"           // for buffered broker reads
+	"encoding/binary" // for the Kafka wire format
+	"encoding/json"   // for event payloads
+	"errors"          // for protocol errors
+	"fmt"             // for printing logs to terminal
+	"hash/crc32"      // for record batch checksums
+	"io"              // for reading responses
+	"net"             // for broker connections
+	"strconv"         // for record keys
+	"strings"         // for splitting the broker list
+	"time"            // for timeouts and retry delay
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// Kafka producer config (off unless brokers are given)
+var kafkaBrokers = flags.String("kafka-brokers", "", "comma separated Kafka bootstrap brokers, e.g. localhost:9092 (disabled when empty)")
+var kafkaTopic = flags.String("kafka-topic", "todo-events", "Kafka topic receiving one keyed record per change")
+
+// Kafka API keys and the versions we speak
+const (
+	kafkaAPIProduce  = 0
+	kafkaAPIMetadata = 3
+
+	kafkaProduceVersion  = 3 // first version taking v2 record batches
+	kafkaMetadataVersion = 4 // first version with allow_auto_topic_creation
+)
+
+// most records sent in one produce request
+const kafkaMaxBatch = 500
+
+// longest pause between retries
+const kafkaMaxRetryDelay = 30 * time.Second
+
+// record batch checksums use CRC-32C
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaConn is one broker connection
+type kafkaConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// kafkaProducer sends change events, keyed by todo id, to the partition leaders of one topic
+type kafkaProducer struct {
+	bootstrap   []string
+	addrs       map[int32]string     // broker id -> host:port
+	leaders     []int32              // partition index -> leader broker id
+	conns       map[int32]*kafkaConn // open connections by broker id
+	correlation int32
+}
+
+// kafkaReader decodes big endian fields, remembering the first error
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+
+// take returns the next n bytes
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = errors.New("kafka: short response")
+		return nil
+	}
+	p := r.b[:n]
+	r.b = r.b[n:]
+	return p
+}
+
+
+func (r *kafkaReader) int16() int16 {
+	if p := r.take(2); p != nil {
+		return int16(binary.BigEndian.Uint16(p))
+	}
+	return 0
+}
+
+
+func (r *kafkaReader) int32() int32 {
+	if p := r.take(4); p != nil {
+		return int32(binary.BigEndian.Uint32(p))
+	}
+	return 0
+}
+
+
+func (r *kafkaReader) int64() int64 {
+	if p := r.take(8); p != nil {
+		return int64(binary.BigEndian.Uint64(p))
+	}
+	return 0
+}
+
+
+// string reads an int16 length prefixed string (-1 = null)
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+
+// appendKafkaString writes an int16 length prefixed string
+func appendKafkaString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+
+// murmur2 is the hash the Java client partitions keys with, so our records land
+// on the same partitions as any other producer keying by todo id
+func murmur2(data []byte) int32 {
+
+	const m = 0x5bd1e995
+	h := uint32(0x9747b28c) ^ uint32(len(data))
+
+	n := len(data) &^ 3
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> 24
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	switch len(data) % 4 {
+	case 3:
+		h ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[n])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+
+// appendRecordBatch encodes events as one v2 record batch (magic 2)
+func appendRecordBatch(b []byte, events []model.Event) []byte {
+
+	first := events[0].Time.UnixMilli()
+	last := events[len(events)-1].Time.UnixMilli()
+
+	// records: varint (zigzag) framed key/value pairs with an event type header, and the trace of
+	// the change's request when there is one
+	var records []byte
+	for i, e := range events {
+		key := strconv.Itoa(e.Todo.ID)
+		value, _ := json.Marshal(e)
+
+		var rec []byte
+		rec = append(rec, 0) // attributes
+		rec = binary.AppendVarint(rec, e.Time.UnixMilli()-first)
+		rec = binary.AppendVarint(rec, int64(i)) // offset delta
+		rec = binary.AppendVarint(rec, int64(len(key)))
+		rec = append(rec, key...)
+		rec = binary.AppendVarint(rec, int64(len(value)))
+		rec = append(rec, value...)
+		headers := [][2]string{{"type", e.Type}}
+		if e.Traceparent != "" {
+			headers = append(headers, [2]string{"traceparent", e.Traceparent})
+		}
+		rec = binary.AppendVarint(rec, int64(len(headers)))
+		for _, h := range headers {
+			rec = binary.AppendVarint(rec, int64(len(h[0])))
+			rec = append(rec, h[0]...)
+			rec = binary.AppendVarint(rec, int64(len(h[1])))
+			rec = append(rec, h[1]...)
+		}
+
+		records = binary.AppendVarint(records, int64(len(rec)))
+		records = append(records, rec...)
+	}
+
+	// everything after the crc field is checksummed
+	var tail []byte
+	tail = binary.BigEndian.AppendUint16(tail, 0) // attributes: no compression, create time
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(events)-1))
+	tail = binary.BigEndian.AppendUint64(tail, uint64(first))
+	tail = binary.BigEndian.AppendUint64(tail, uint64(max(first, last)))
+	tail = binary.BigEndian.AppendUint64(tail, ^uint64(0)) // producer id -1: not idempotent
+	tail = binary.BigEndian.AppendUint16(tail, ^uint16(0)) // producer epoch -1
+	tail = binary.BigEndian.AppendUint32(tail, ^uint32(0)) // base sequence -1
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(events)))
+	tail = append(tail, records...)
+
+	b = binary.BigEndian.AppendUint64(b, 0)                       // base offset, assigned by the broker
+	b = binary.BigEndian.AppendUint32(b, uint32(4+1+4+len(tail))) // batch length: leader epoch + magic + crc + tail
+	b = binary.BigEndian.AppendUint32(b, 0)                       // partition leader epoch
+	b = append(b, 2)                                              // magic
+	b = binary.BigEndian.AppendUint32(b, crc32.Checksum(tail, crc32c))
+	return append(b, tail...)
+}
+
+
+// dialKafka opens a broker connection
+func dialKafka(addr string) (*kafkaConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConn{conn: conn, br: bufio.NewReader(conn)}, nil
+}
+
+
+// roundTrip sends one request and returns the response body after the correlation id
+func (p *kafkaProducer) roundTrip(kc *kafkaConn, apiKey, apiVersion int16, body []byte) (*kafkaReader, error) {
+
+	p.correlation++
+
+	// request header v1: api key, version, correlation id, client id
+	var req []byte
+	req = binary.BigEndian.AppendUint16(req, uint16(apiKey))
+	req = binary.BigEndian.AppendUint16(req, uint16(apiVersion))
+	req = binary.BigEndian.AppendUint32(req, uint32(p.correlation))
+	req = appendKafkaString(req, *serviceName)
+	req = append(req, body...)
+
+	kc.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	defer kc.conn.SetDeadline(time.Time{})
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(req)))
+	if _, err := kc.conn.Write(append(frame, req...)); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(kc.br, size[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(kc.br, resp); err != nil {
+		return nil, err
+	}
+
+	r := &kafkaReader{b: resp}
+	if r.int32() != p.correlation {
+		return nil, errors.New("kafka: correlation id mismatch")
+	}
+	return r, r.err
+}
+
+
+// refreshMetadata asks a bootstrap broker where the topic's partition leaders are
+func (p *kafkaProducer) refreshMetadata() error {
+
+	// topics: [topic], allow_auto_topic_creation
+	var body []byte
+	body = binary.BigEndian.AppendUint32(body, 1)
+	body = appendKafkaString(body, *kafkaTopic)
+	body = append(body, 1)
+
+	var lastErr error
+	for _, addr := range p.bootstrap {
+		kc, err := dialKafka(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		r, err := p.roundTrip(kc, kafkaAPIMetadata, kafkaMetadataVersion, body)
+		kc.conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		r.int32() // throttle time
+		addrs := make(map[int32]string)
+		for n := r.int32(); n > 0 && r.err == nil; n-- {
+			id := r.int32()
+			host := r.string()
+			port := r.int32()
+			r.string() // rack
+			addrs[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+		}
+		r.string() // cluster id
+		r.int32()  // controller id
+
+		var leaders []int32
+		for n := r.int32(); n > 0 && r.err == nil; n-- {
+			code := r.int16()
+			name := r.string()
+			r.take(1) // is_internal
+			if code != 0 {
+				return fmt.Errorf("kafka: topic %s: error code %d", name, code)
+			}
+			count := r.int32()
+			leaders = make([]int32, count)
+			for ; count > 0 && r.err == nil; count-- {
+				r.int16() // partition error code
+				index := r.int32()
+				leader := r.int32()
+				r.take(4 * int(r.int32())) // replicas
+				r.take(4 * int(r.int32())) // isr
+				if index >= 0 && int(index) < len(leaders) {
+					leaders[index] = leader
+				}
+			}
+		}
+		if r.err != nil {
+			return r.err
+		}
+		if len(leaders) == 0 {
+			return errors.New("kafka: topic has no partitions yet")
+		}
+
+		p.addrs = addrs
+		p.leaders = leaders
+		return nil
+	}
+	return lastErr
+}
+
+
+// connFor returns (opening) the connection to a broker
+func (p *kafkaProducer) connFor(id int32) (*kafkaConn, error) {
+
+	if kc, ok := p.conns[id]; ok {
+		return kc, nil
+	}
+	addr, ok := p.addrs[id]
+	if !ok {
+		return nil, fmt.Errorf("kafka: unknown broker %d", id)
+	}
+	kc, err := dialKafka(addr)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[id] = kc
+	return kc, nil
+}
+
+
+// reset drops every connection and the cached leaders after a failure
+func (p *kafkaProducer) reset() {
+	for id, kc := range p.conns {
+		kc.conn.Close()
+		delete(p.conns, id)
+	}
+	p.leaders = nil
+}
+
+
+// produce writes events to their partitions and waits for all in-sync replicas (acks=-1).
+// it succeeds only when every partition accepted its records.
+func (p *kafkaProducer) produce(events []model.Event) error {
+
+	if p.leaders == nil {
+		if err := p.refreshMetadata(); err != nil {
+			return err
+		}
+	}
+
+	// same key -> same partition, so per-todo order is preserved
+	byPartition := make(map[int32][]model.Event)
+	for _, e := range events {
+		key := strconv.Itoa(e.Todo.ID)
+		partition := int32(int(murmur2([]byte(key))&0x7fffffff) % len(p.leaders))
+		byPartition[partition] = append(byPartition[partition], e)
+	}
+	byLeader := make(map[int32][]int32)
+	for partition := range byPartition {
+		leader := p.leaders[partition]
+		byLeader[leader] = append(byLeader[leader], partition)
+	}
+
+	for leader, partitions := range byLeader {
+		kc, err := p.connFor(leader)
+		if err != nil {
+			return err
+		}
+
+		// transactional id (null), acks, timeout, [topic [partition records]]
+		var body []byte
+		body = binary.BigEndian.AppendUint16(body, ^uint16(0))
+		body = binary.BigEndian.AppendUint16(body, ^uint16(0)) // acks -1 = all in-sync replicas
+		body = binary.BigEndian.AppendUint32(body, 10000)
+		body = binary.BigEndian.AppendUint32(body, 1)
+		body = appendKafkaString(body, *kafkaTopic)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(partitions)))
+		for _, partition := range partitions {
+			batch := appendRecordBatch(nil, byPartition[partition])
+			body = binary.BigEndian.AppendUint32(body, uint32(partition))
+			body = binary.BigEndian.AppendUint32(body, uint32(len(batch)))
+			body = append(body, batch...)
+		}
+
+		r, err := p.roundTrip(kc, kafkaAPIProduce, kafkaProduceVersion, body)
+		if err != nil {
+			return err
+		}
+		for topics := r.int32(); topics > 0 && r.err == nil; topics-- {
+			r.string() // topic
+			for n := r.int32(); n > 0 && r.err == nil; n-- {
+				partition := r.int32()
+				code := r.int16()
+				r.int64() // base offset
+				r.int64() // log append time
+				if code != 0 {
+					return fmt.Errorf("kafka: partition %d: error code %d", partition, code)
+				}
+			}
+		}
+		if r.err != nil {
+			return r.err
+		}
+	}
+	return nil
+}
+
+
+// startKafkaProducer streams change events to Kafka in the background.
+// the hub's change log is the outbox: the cursor only moves past events once the
+// brokers acknowledged them, so a failed produce is retried (at-least-once; consumers
+// should dedupe on the "seq" field). events older than -change-log-size are lost
+// if the brokers stay unreachable that long.
+func startKafkaProducer() {
+
+	// nothing to do if Kafka is disabled
+	if *kafkaBrokers == "" {
+		return
+	}
+
+	p := &kafkaProducer{conns: make(map[int32]*kafkaConn)}
+	for _, addr := range strings.Split(*kafkaBrokers, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			p.bootstrap = append(p.bootstrap, addr)
+		}
+	}
+	fmt.Println("producing change events to Kafka topic", *kafkaTopic)
+
+	go func() {
+		cursor := todoStore.Events().LastSeq()
+		delay := time.Second
+
+		for {
+			events, changed, ok := todoStore.Events().Since(cursor)
+
+			// fell behind the change log: skip to the oldest event still there
+			if !ok {
+				oldest, _, _ := todoStore.Events().Since(0)
+				if len(oldest) == 0 {
+					continue
+				}
+				fmt.Println("kafka producer lost events", cursor+1, "to", oldest[0].Seq-1)
+				cursor = oldest[0].Seq - 1
+				continue
+			}
+
+			// nothing new, wait for the next change
+			if len(events) == 0 {
+				<-changed
+				continue
+			}
+
+			batch := events[:min(len(events), kafkaMaxBatch)]
+			if err := p.produce(batch); err != nil {
+				fmt.Println("kafka produce failed, retrying in", delay, ":", err)
+				p.reset()
+				time.Sleep(delay)
+				delay = min(delay*2, kafkaMaxRetryDelay)
+				continue
+			}
+			cursor = batch[len(batch)-1].Seq
+			delay = time.Second
+		}
+	}()
+}
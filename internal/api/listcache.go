@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json" // for the stats response
+	"net/http"      // for HTTP handlers
+	"net/url"       // for cache keys with search text
+	"strconv"       // for cache keys
+	"strings"       // for creators in any case
+	"sync"          // for guarding the cache
+	"sync/atomic"   // for the hit counters
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// list cache config
+var listCacheEntries = flags.Int("list-cache-entries", 64, "GET /todos responses kept per distinct filter (0 = no cache)")
+var listCacheMaxBody = flags.Int("list-cache-max-body", 1<<20, "largest GET /todos response in bytes that is cached (bigger ones are only streamed)")
+
+// cachedList is one encoded GET /todos response; it is current while the store
+// generation it was built at is
+type cachedList struct {
+	generation uint64
+	body       []byte
+}
+
+// encoded responses by filter key, plus how often they helped
+var listCache = struct {
+	sync.Mutex
+	entries map[string]cachedList
+	hits    atomic.Int64
+	misses  atomic.Int64
+}{entries: make(map[string]cachedList)}
+
+// ListCacheStats is the answer to GET /admin/cache
+type ListCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// cappedBuffer keeps a copy of what's written to it until it would grow past max
+type cappedBuffer struct {
+	buf  []byte
+	max  int
+	over bool
+}
+
+
+// Write copies b unless the buffer has given up; it never fails, so it can sit behind io.MultiWriter
+func (c *cappedBuffer) Write(b []byte) (int, error) {
+	if !c.over && len(c.buf)+len(b) > c.max {
+		c.over, c.buf = true, nil
+	}
+	if !c.over {
+		c.buf = append(c.buf, b...)
+	}
+	return len(b), nil
+}
+
+
+// listCacheKey identifies a filter, so ?done=false and ?done=0 share an entry
+func listCacheKey(f store.Filter) string {
+
+	key := "done="
+	if f.Done != nil {
+		key += strconv.FormatBool(*f.Done)
+	}
+	if f.HasDue {
+		key += "&has_due"
+	}
+	if f.DueAfter != nil {
+		key += "&after=" + strconv.FormatInt(f.DueAfter.UnixNano(), 10)
+	}
+	if f.DueBefore != nil {
+		key += "&before=" + strconv.FormatInt(f.DueBefore.UnixNano(), 10)
+	}
+	if f.Text != "" {
+		key += "&q=" + url.QueryEscape(f.Text)
+	}
+	if f.List != "" {
+		key += "&list=" + url.QueryEscape(f.List)
+	}
+	if f.Tag != "" {
+		key += "&tag=" + url.QueryEscape(f.Tag)
+	}
+	if f.CreatedBy != "" {
+		key += "&created_by=" + url.QueryEscape(strings.ToLower(f.CreatedBy))
+	}
+	return key
+}
+
+
+// cachedListBody returns the cached response for key if nothing changed since it was built
+func cachedListBody(key string) ([]byte, bool) {
+
+	if *listCacheEntries <= 0 {
+		return nil, false
+	}
+
+	listCache.Lock()
+	defer listCache.Unlock()
+
+	// a stale entry is dropped right away instead of holding its body until it's rebuilt
+	entry, ok := listCache.entries[key]
+	if !ok || entry.generation != todoStore.Generation() {
+		delete(listCache.entries, key)
+		listCache.misses.Add(1)
+		return nil, false
+	}
+	listCache.hits.Add(1)
+	return entry.body, true
+}
+
+
+// storeListBody caches a response built from the store as of generation
+func storeListBody(key string, generation uint64, body []byte) {
+
+	listCache.Lock()
+	defer listCache.Unlock()
+
+	// full: stale entries go first, then any one (entries are per filter, so there are few)
+	if _, exists := listCache.entries[key]; !exists && len(listCache.entries) >= *listCacheEntries {
+		current := todoStore.Generation()
+		for k, entry := range listCache.entries {
+			if entry.generation != current {
+				delete(listCache.entries, k)
+			}
+		}
+		for k := range listCache.entries {
+			if len(listCache.entries) < *listCacheEntries {
+				break
+			}
+			delete(listCache.entries, k)
+		}
+	}
+	listCache.entries[key] = cachedList{generation: generation, body: body}
+}
+
+
+// clearListCache drops every entry, for when the store itself is replaced
+func clearListCache() {
+	listCache.Lock()
+	defer listCache.Unlock()
+	clear(listCache.entries)
+}
+
+
+// list cache size and hit counts
+func listCacheHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	listCache.Lock()
+	stats := ListCacheStats{Entries: len(listCache.entries), Hits: listCache.hits.Load(), Misses: listCache.misses.Load()}
+	listCache.Unlock()
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"  // for config errors
+	"fmt"     // for error context
+	"net"     // for TCP / unix listeners
+	"os"      // for socket file cleanup & permissions
+	"strings" // for address parsing
+)
+
+// comma separated list of addresses to serve on
+var listenAddrs = flags.String("listen", ":8080", "comma separated listen addresses: host:port, tcp://host:port or unix:///path/to.sock")
+
+// permissions for unix socket files (so e.g. nginx in the same group can connect)
+var socketMode = flags.Uint("socket-mode", 0660, "file mode for unix socket listeners")
+
+
+// newListener opens a listener for one address from -listen
+func newListener(addr string) (net.Listener, error) {
+
+	// unix:///var/run/todo.sock -> /var/run/todo.sock
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+
+		// remove a stale socket left behind by a previous run
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+
+		// let the proxy user connect
+		if err := os.Chmod(path, os.FileMode(*socketMode)); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+
+	// tcp://host:port or plain host:port
+	return net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+}
+
+
+// openListeners opens every address from -listen, closing any already opened on failure
+func openListeners() ([]net.Listener, error) {
+
+	var listeners []net.Listener
+	for _, addr := range strings.Split(*listenAddrs, ",") {
+
+		// tolerate "a, b" and trailing commas
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+
+		ln, err := newListener(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	// -listen "" would leave us with nothing to serve
+	if len(listeners) == 0 {
+		return nil, errors.New("no listen address configured")
+	}
+
+	return listeners, nil
+}
+
+// side servers with their own port, in the order their sockets follow the public
+// listeners when handed to a new process
+var sidePorts = []struct {
+	name string
+	addr *string
+}{
+	{"admin", adminAddr},
+	{"grpc", grpcAddr},
+	{"cluster", clusterAddr},
+}
+
+
+// setupListeners returns the public listeners and one listener per enabled side port,
+// inherited from a parent process when available so a binary upgrade never refuses connections
+func setupListeners() ([]net.Listener, map[string]net.Listener, error) {
+
+	inherited, err := inheritedListeners()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// fresh start, open everything ourselves
+	side := map[string]net.Listener{}
+	if inherited == nil {
+		listeners, err := openListeners()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, port := range sidePorts {
+			if *port.addr == "" {
+				continue
+			}
+			ln, err := net.Listen("tcp", *port.addr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", port.name, err)
+			}
+			side[port.name] = ln
+		}
+		return listeners, side, nil
+	}
+
+	// handed-over sockets: enabled side ports come last, in sidePorts order
+	public := inherited
+	for i := len(sidePorts) - 1; i >= 0; i-- {
+		if *sidePorts[i].addr == "" {
+			continue
+		}
+		if len(public) < 2 {
+			return nil, nil, errors.New(sidePorts[i].name + " server configured but no socket was inherited for it")
+		}
+		side[sidePorts[i].name] = public[len(public)-1]
+		public = public[:len(public)-1]
+	}
+	return public, side, nil
+}
+
+
+// handoffOrder lists every socket in the order a new process expects them
+func handoffOrder(public []net.Listener, side map[string]net.Listener) []net.Listener {
+
+	all := append([]net.Listener{}, public...)
+	for _, port := range sidePorts {
+		if ln, ok := side[port.name]; ok {
+			all = append(all, ln)
+		}
+	}
+	return all
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"       // for the caller's tenant
+	"encoding/json" // for JSON responses
+	"fmt"           // for not found errors
+	"net/http"      // for HTTP handlers
+	"strings"       // for the list name in the path
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for list filters
+)
+
+// lists are what todos' list fields name: there's nothing to create or delete, a list is there
+// while a todo is on it, and its name is its id in the path (/lists/groceries/todos)
+
+// ListSummary is one list and how many todos it has
+type ListSummary struct {
+	Name string `json:"name"`
+	Open int    `json:"open"`
+	Done int    `json:"done"`
+}
+
+
+// listSummary counts a list's todos
+func listSummary(ctx context.Context, name string) ListSummary {
+	summary := ListSummary{Name: name}
+	for _, todo := range todoStore.Find(ctx, store.Filter{List: name}) {
+		if todo.Done {
+			summary.Done++
+		} else {
+			summary.Open++
+		}
+	}
+	return summary
+}
+
+
+// listTodos reads the {id} of the path and returns the list's todos, model.ErrNotFound when no
+// todo is on it
+func listTodos(r *http.Request) (string, []model.Todo, error) {
+
+	name := strings.TrimSpace(r.PathValue("id"))
+	todos := todoStore.Find(r.Context(), store.Filter{List: name})
+	if name == "" || len(todos) == 0 {
+		return name, nil, fmt.Errorf("list %q: %w", name, model.ErrNotFound)
+	}
+	return name, todos, nil
+}
+
+
+// GET lists the lists, by name
+func listsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	lists := []ListSummary{}
+	for _, name := range todoStore.Lists(r.Context()) {
+		lists = append(lists, listSummary(r.Context(), name))
+	}
+	json.NewEncoder(w).Encode(lists)
+}
+
+
+// GET a list's todos, as GET /todos would list them
+func listTodosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	_, todos, err := listTodos(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeTodoMap(w, w, todos)
+}
+
+
+// download a list as a printable checklist, titled with its name and grouped by tag
+func listExportPDFHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// errors are JSON, the PDF sets its own type
+	w.Header().Set("Content-Type", "application/json")
+
+	name, todos, err := listTodos(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeChecklistPDF(w, r, name, "list-"+pdfFilename(name)+".pdf", "tag", todos)
+}
+
+
+// pdfFilename is name with what can't go in a Content-Disposition filename as "_"
+func pdfFilename(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || c == '/' {
+			return '_'
+		}
+		return c
+	}, name)
+}
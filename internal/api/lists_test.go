@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"bytes"    // for reading the PDF
+	"io"       // for the PDF body
+	"net/http" // for status codes
+	"strings"  // for the sections' order
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/server"  // for seeded todos
+)
+
+// list tests: lists and tags set on create and update, the lists they make, the todos filtered by
+// them, and a list's checklist grouped by tag
+
+
+// groceries seeds a shopping list and a todo on no list
+func groceries(s *apitest.Server) {
+	s.Seed(apitest.NewSeed().Add(
+		server.Todo{Title: "milk", List: "groceries", Tags: []string{"Dairy", "#fridge"}},
+		server.Todo{Title: "bread", List: "groceries", Tags: []string{"bakery"}, Done: true},
+		server.Todo{Title: "soap", List: "groceries"},
+		server.Todo{Title: "file taxes", Tags: []string{"home"}},
+	))
+}
+
+
+// TestTodoListAndTags checks what create and update take, and how they keep it
+func TestTodoListAndTags(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string // the todo, when status is 200
+	}{
+		{"create", http.MethodPost, "/todos/create", `{"title":"a","list":" groceries ","tags":["B","a","#b"," "]}`, http.StatusOK,
+			`{"id":5,"title":"a","done":false,"list":"groceries","tags":["a","b"],"rev":1}`},
+		{"create with two words", http.MethodPost, "/todos/create", `{"title":"a","tags":["two words"]}`, http.StatusBadRequest, ""},
+		{"create with a long list", http.MethodPost, "/todos/create", `{"title":"a","list":"` + strings.Repeat("x", 101) + `"}`, http.StatusBadRequest, ""},
+		{"create with tags not strings", http.MethodPost, "/todos/create", `{"title":"a","tags":[1]}`, http.StatusBadRequest, ""},
+		{"move to another list", http.MethodPut, "/todos/update?id=1", `{"list":"dairy"}`, http.StatusOK,
+			`{"id":1,"title":"milk","done":false,"list":"dairy","tags":["dairy","fridge"],"rev":2}`},
+		{"off its list, tags replaced", http.MethodPut, "/todos/update?id=1", `{"list":"","tags":["cold"]}`, http.StatusOK,
+			`{"id":1,"title":"milk","done":false,"tags":["cold"],"rev":2}`},
+		{"tags dropped", http.MethodPut, "/todos/update?id=1", `{"tags":[]}`, http.StatusOK,
+			`{"id":1,"title":"milk","done":false,"list":"groceries","rev":2}`},
+		{"too many tags", http.MethodPut, "/todos/update?id=1", `{"tags":["a","b","c","d","e","f","g","h","i","j","k","l","m","n","o","p","q","r","s","t","u"]}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			groceries(s)
+			req, _ := http.NewRequest(tt.method, s.URL+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := s.Send(req).ExpectStatus(tt.status)
+			if tt.want != "" {
+				resp.ExpectJSON(tt.want)
+			}
+		})
+	}
+}
+
+
+// TestLists checks the lists, a list's todos, the filters by list and tag and the counts by tag
+func TestLists(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/lists", http.StatusOK, `[{"name":"groceries","open":2,"done":1}]`},
+		{"/lists/groceries/todos", http.StatusOK, `{
+			"1":{"id":1,"title":"milk","done":false,"list":"groceries","tags":["dairy","fridge"],"rev":1},
+			"2":{"id":2,"title":"bread","done":true,"list":"groceries","tags":["bakery"],"rev":1},
+			"3":{"id":3,"title":"soap","done":false,"list":"groceries","rev":1}}`},
+		{"/lists/nothing/todos", http.StatusNotFound, ""},
+		{"/todos?tag=home", http.StatusOK, `{"4":{"id":4,"title":"file taxes","done":false,"tags":["home"],"rev":1}}`},
+		{"/todos?list=groceries&tag=%23Dairy&done=false", http.StatusOK, `{"1":{"id":1,"title":"milk","done":false,"list":"groceries","tags":["dairy","fridge"],"rev":1}}`},
+		{"/todos?list=groceries&tag=home", http.StatusOK, `{}`},
+		{"/todos/stats", http.StatusOK, `{"total":4,"open":3,"done":1,"with_due":0,"tags":{
+			"bakery":{"total":1,"open":0,"done":1},"dairy":{"total":1,"open":1,"done":0},
+			"fridge":{"total":1,"open":1,"done":0},"home":{"total":1,"open":1,"done":0}}}`},
+	}
+	for _, tt := range tests {
+		resp := s.Get(tt.path).ExpectStatus(tt.status)
+		if tt.want != "" {
+			resp.ExpectJSON(tt.want)
+		}
+	}
+}
+
+
+// TestListExportPDF checks a list prints with a section per tag, the untagged last, by default
+// and with the other groupings when asked
+func TestListExportPDF(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		lines  []string // text shown, in order
+	}{
+		{"by tag", "/lists/groceries/export.pdf", http.StatusOK, []string{"(groceries)", "(#bakery)", "(bread)", "(#dairy)", "(milk)", "(#fridge)", "(milk)", "(No tag)", "(soap)"}},
+		{"by status", "/lists/groceries/export.pdf?group=status", http.StatusOK, []string{"(groceries)", "(Open)", "(milk)", "(soap)", "(Done)", "(bread)"}},
+		{"one section", "/lists/groceries/export.pdf?group=none", http.StatusOK, []string{"(groceries)", "(milk)", "(bread)", "(soap)"}},
+		{"every todo by tag", "/todos/export.pdf?group=tag", http.StatusOK, []string{"(Todos)", "(#bakery)", "(#dairy)", "(#fridge)", "(#home)", "(file taxes)", "(No tag)", "(soap)"}},
+		{"unknown grouping", "/lists/groceries/export.pdf?group=size", http.StatusBadRequest, nil},
+		{"no such list", "/lists/nothing/export.pdf", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, s.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d: %s", resp.StatusCode, tt.status, body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/pdf" {
+				t.Errorf("Content-Type %q, want application/pdf", got)
+			}
+			rest := body
+			for _, line := range tt.lines {
+				i := bytes.Index(rest, []byte(line+" Tj"))
+				if i < 0 {
+					t.Fatalf("%s not shown after what came before it", line)
+				}
+				rest = rest[i+len(line):]
+			}
+		})
+	}
+}
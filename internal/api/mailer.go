@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"                // for building messages
+	"crypto/tls"           // for STARTTLS
+	"errors"               // for config errors
+	"fmt"                  // for printing logs to terminal
+	"io"                   // for the DATA writer
+	"mime"                 // for encoded subjects
+	"mime/multipart"       // for text + html bodies
+	"mime/quotedprintable" // for 7bit-safe bodies
+	"net"                  // for dialing the relay
+	"net/smtp"             // for sending mail
+	"net/textproto"        // for SMTP reply codes and part headers
+	"strings"              // for line endings
+	"sync"                 // for guarding the connection pool
+	"time"                 // for the Date header, idle timeout and backoff
+)
+
+// SMTP relay config (mail is off unless an address is given)
+var smtpAddr = flags.String("smtp-addr", "", "SMTP relay for outgoing mail, e.g. smtp.example.com:587 (mail disabled when empty)")
+var smtpUser = flags.String("smtp-user", "", "SMTP username (PLAIN auth, only sent over TLS or to localhost)")
+var smtpPass = flags.String("smtp-pass", "", "SMTP password")
+var smtpFrom = flags.String("smtp-from", "todo-api@localhost", "From address of outgoing mail")
+
+// connection pool: relays close idle sessions after a few minutes, so we drop them sooner
+const smtpMaxIdle = 4
+const smtpIdleTimeout = time.Minute
+
+// transient failures (4xx replies, network errors) are retried with backoff
+const smtpAttempts = 3
+const smtpRetryDelay = 2 * time.Second
+
+// an authenticated session kept open between messages
+type pooledSMTP struct {
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// idle sessions, most recently used last
+var smtpPool []pooledSMTP
+var smtpPoolMu sync.Mutex
+
+
+// mailEnabled reports whether an SMTP relay is configured
+func mailEnabled() bool {
+	return *smtpAddr != ""
+}
+
+
+// sendMail sends a plain text message
+func sendMail(to, subject, body string) error {
+	return sendMailAlt(to, subject, body, "")
+}
+
+
+// sendMailAlt sends text (and html as an alternative, if given), retrying transient failures
+func sendMailAlt(to, subject, text, html string) error {
+
+	if !mailEnabled() {
+		return errors.New("mail is not configured (-smtp-addr)")
+	}
+
+	msg := buildMessage(to, subject, text, html)
+
+	var err error
+	for attempt := 1; attempt <= smtpAttempts; attempt++ {
+		if err = deliverMail(to, msg); err == nil || !transientMailError(err) {
+			return err
+		}
+		if attempt < smtpAttempts {
+			fmt.Println("mail to", to, "failed, retrying:", err)
+			time.Sleep(smtpRetryDelay << (attempt - 1))
+		}
+	}
+	return err
+}
+
+
+// buildMessage renders headers and a quoted-printable body, multipart/alternative when there is html
+func buildMessage(to, subject, text, html string) []byte {
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + *smtpFrom + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n") // also keeps newlines in titles out of the headers
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	// body with CRLF line endings
+	crlf := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+	}
+
+	if html == "" {
+		msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&msg)
+		qp.Write([]byte(crlf(text)))
+		qp.Close()
+		return msg.Bytes()
+	}
+
+	// plain text first: clients show the last part they understand
+	parts := multipart.NewWriter(&msg)
+	msg.WriteString("Content-Type: multipart/alternative; boundary=" + parts.Boundary() + "\r\n\r\n")
+	for _, part := range []struct{ contentType, body string }{{"text/plain", text}, {"text/html", html}} {
+		w, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(crlf(part.body)))
+		qp.Close()
+	}
+	parts.Close()
+	return msg.Bytes()
+}
+
+
+// transientMailError reports whether sending again later might work
+func transientMailError(err error) bool {
+
+	// 4xx = try again later, 5xx = permanent (bad address, rejected, ...)
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+
+	// dropped connections, timeouts, relay down
+	return true
+}
+
+
+// deliverMail sends one message over a pooled session; a session that errors is closed, not reused
+func deliverMail(to string, msg []byte) error {
+
+	client, err := getSMTP()
+	if err != nil {
+		return err
+	}
+
+	err = client.Mail(*smtpFrom)
+	if err == nil {
+		err = client.Rcpt(to)
+	}
+	if err == nil {
+		var w io.WriteCloser
+		if w, err = client.Data(); err == nil {
+			if _, err = w.Write(msg); err == nil {
+				err = w.Close()
+			}
+		}
+	}
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	putSMTP(client)
+	return nil
+}
+
+
+// getSMTP takes an idle session from the pool, or dials a new one
+func getSMTP() (*smtp.Client, error) {
+
+	smtpPoolMu.Lock()
+	for len(smtpPool) > 0 {
+		last := smtpPool[len(smtpPool)-1]
+		smtpPool = smtpPool[:len(smtpPool)-1]
+
+		// too old, or the relay hung up on us
+		if time.Since(last.lastUsed) > smtpIdleTimeout || last.client.Reset() != nil {
+			last.client.Close()
+			continue
+		}
+		smtpPoolMu.Unlock()
+		return last.client, nil
+	}
+	smtpPoolMu.Unlock()
+
+	return dialSMTP()
+}
+
+
+// putSMTP returns a session to the pool, closing it if the pool is full
+func putSMTP(client *smtp.Client) {
+
+	smtpPoolMu.Lock()
+	defer smtpPoolMu.Unlock()
+
+	if len(smtpPool) >= smtpMaxIdle {
+		client.Quit()
+		return
+	}
+	smtpPool = append(smtpPool, pooledSMTP{client: client, lastUsed: time.Now()})
+}
+
+
+// dialSMTP opens a session: STARTTLS when the relay offers it, then PLAIN auth if a user is set
+func dialSMTP() (*smtp.Client, error) {
+
+	host, _, _ := net.SplitHostPort(*smtpAddr)
+	conn, err := net.DialTimeout("tcp", *smtpAddr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if *smtpUser != "" {
+		if err := client.Auth(smtp.PlainAuth("", *smtpUser, *smtpPass, host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"         // for putting peeked bodies back
+	"context"       // for the store's write gate
+	"encoding/json" // for JSON responses and peeking at RPC and GraphQL calls
+	"io"            // for peeking at request bodies
+	"net/http"      // for HTTP handlers
+	"strconv"       // for parsing the enabled param
+	"sync"          // for guarding the message
+	"sync/atomic"   // for the lock-free on/off switch
+	"time"          // for when to come back
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for the unavailable error
+)
+
+// start in maintenance mode, e.g. while a migration is still running
+var startInMaintenance = flags.Bool("maintenance", false, "start in read-only maintenance mode")
+
+// default text shown to clients while in maintenance
+const defaultMaintenanceMessage = "service is in read-only maintenance mode, please retry later"
+
+// maintenance state, checked on every request and, through the store's write gate, every change
+var maintenance atomic.Bool
+var maintenanceMsg = defaultMaintenanceMessage
+var maintenanceMu sync.Mutex // protects maintenanceMsg
+
+// MaintenanceStatus is the body of the admin endpoint and of rejected writes
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+
+// currentMaintenance returns a consistent view of the maintenance state
+func currentMaintenance() MaintenanceStatus {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+	return MaintenanceStatus{Enabled: maintenance.Load(), Message: maintenanceMsg}
+}
+
+
+// setMaintenance switches maintenance on or off, with message shown to clients (the default when empty)
+func setMaintenance(enabled bool, message string) {
+
+	// update message and switch together so readers never see a mix
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+	maintenanceMsg = defaultMaintenanceMessage
+	if message != "" {
+		maintenanceMsg = message
+	}
+	maintenance.Store(enabled)
+}
+
+
+// maintenanceGate is the store's write gate: while maintenance is on, every change is refused,
+// whoever makes it (REST, gRPC, the Telegram bot, scheduled and recurring todos, the janitor...)
+func maintenanceGate(ctx context.Context) error {
+	if !maintenance.Load() {
+		return nil
+	}
+	return model.Unavailable(currentMaintenance().Message, 60*time.Second)
+}
+
+
+// readOnlyRequest reports whether r only reads, for the gates turning writes away: GET, HEAD
+// and OPTIONS, the gRPC reads, JSON-RPC calls (single or batched) of read methods only, and
+// GraphQL queries. it peeks at RPC and GraphQL bodies, leaving them for the handler to read again
+func readOnlyRequest(r *http.Request) bool {
+
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return true
+	case r.Method != http.MethodPost:
+		return false
+	case grpcRead(r):
+		return true
+	case r.URL.Path != "/rpc" && r.URL.Path != "/graphql":
+		return false
+	}
+
+	// a body too big for its handler is never a read
+	body, err := io.ReadAll(io.LimitReader(r.Body, rpcMaxBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || len(body) > rpcMaxBody {
+		return false
+	}
+
+	if r.URL.Path == "/graphql" {
+		var req GraphQLRequest
+		if json.Unmarshal(body, &req) != nil {
+			return false
+		}
+		op, err := pickOperation(req.Query, req.OperationName)
+		return err == nil && op.kind == "query"
+	}
+
+	var calls []struct {
+		Method string `json:"method"`
+	}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] != '[' {
+		body = append(append([]byte{'['}, body...), ']')
+	}
+	if json.Unmarshal(body, &calls) != nil || len(calls) == 0 {
+		return false
+	}
+	for _, call := range calls {
+		if !rpcReads[call.Method] {
+			return false
+		}
+	}
+	return true
+}
+
+
+// rejectWritesInMaintenance answers 503 to every mutating request while maintenance is on
+func rejectWritesInMaintenance(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// reads always go through
+		if !maintenance.Load() || readOnlyRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// tell client why and when to come back
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(currentMaintenance())
+	})
+}
+
+
+// admin: GET shows maintenance state, POST ?enabled=true|false[&message=...] changes it
+func maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		// just report
+
+	case http.MethodPost:
+		// parse the new state
+		enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		setMaintenance(enabled, r.URL.Query().Get("message"))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(currentMaintenance())
+}
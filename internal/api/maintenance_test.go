@@ -0,0 +1,50 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"strings"  // for request bodies
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// maintenance tests: what's let through while -maintenance is on, reads over POST included
+
+
+// TestMaintenanceReads checks reads go through in maintenance, JSON-RPC and GraphQL ones too,
+// and every write is turned away
+func TestMaintenanceReads(t *testing.T) {
+
+	s := apitest.New(t, apitest.WithArgs("-maintenance"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", http.MethodGet, "/todos", "", http.StatusOK},
+		{"create", http.MethodPost, "/todos/create", `{"title":"bread"}`, http.StatusServiceUnavailable},
+		{"RPC list", http.MethodPost, "/rpc", `{"jsonrpc":"2.0","method":"todos.list","id":1}`, http.StatusOK},
+		{"RPC batch of reads", http.MethodPost, "/rpc", `[{"jsonrpc":"2.0","method":"todos.list","id":1},{"jsonrpc":"2.0","method":"todos.get","params":{"id":1},"id":2}]`, http.StatusOK},
+		{"RPC create", http.MethodPost, "/rpc", `{"jsonrpc":"2.0","method":"todos.create","params":{"title":"bread"},"id":1}`, http.StatusServiceUnavailable},
+		{"RPC batch with a write", http.MethodPost, "/rpc", `[{"jsonrpc":"2.0","method":"todos.list","id":1},{"jsonrpc":"2.0","method":"todos.delete","params":{"id":1},"id":2}]`, http.StatusServiceUnavailable},
+		{"RPC not JSON", http.MethodPost, "/rpc", `{`, http.StatusServiceUnavailable},
+		{"GraphQL query", http.MethodPost, "/graphql", `{"query":"{ todos { title } }"}`, http.StatusOK},
+		{"GraphQL named query", http.MethodPost, "/graphql", `{"query":"query a { todos { id } } mutation b { deleteTodo(id: 1) }","operationName":"a"}`, http.StatusOK},
+		{"GraphQL mutation", http.MethodPost, "/graphql", `{"query":"mutation { createTodo(title: \"bread\") { id } }"}`, http.StatusServiceUnavailable},
+		{"GraphQL named mutation", http.MethodPost, "/graphql", `{"query":"query a { todos { id } } mutation b { deleteTodo(id: 1) }","operationName":"b"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, s.URL+tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if got := s.Send(req).Status; got != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.status)
+		}
+	}
+
+	// the reads were answered from their bodies, read again by the handlers
+	s.Post("/rpc", `{"jsonrpc":"2.0","method":"todos.list","id":7}`).ExpectJSON(`{"jsonrpc":"2.0","result":[],"id":7}`)
+	s.Post("/graphql", `{"query":"{ todos { id } }"}`).ExpectJSON(`{"data":{"todos":[]}}`)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"       // for store calls outside a request
+	"encoding/json" // for JSON responses
+	"fmt"           // for printing logs to terminal and errors
+	"net/http"      // for HTTP handlers
+	"regexp"        // for finding mentions
+	"slices"        // for webhooks subscribed to mentions
+	"strconv"       // for the id in the path
+	"strings"       // for names and the mail address
+	"sync"          // for guarding the inboxes
+	"time"          // for notification times
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for events and errors
+)
+
+// "@alice" in a todo's title notifies the actor alice: the name is what a client sends as
+// Todo-Actor, there being no user accounts. todos have no comments or descriptions, so titles
+// are the only place to mention someone. a mention counts when it's new to the title (created
+// with it, or renamed to have it), and never for the actor making the change. each actor has
+// an inbox at GET /notifications, and can get a webhook ("mentioned") or a mail too
+var mentionEmail = flags.String("mention-email", "", "address mentioned actors are mailed at, with {name} for the name, e.g. {name}@example.com (needs -smtp-addr; off when empty)")
+
+// webhook event type of mentions, sent only to webhooks that ask for it by name
+const eventMentioned = "mentioned"
+
+// notifications kept per actor, oldest dropped first
+const inboxSize = 100
+
+// "@name" at the start or after a space or punctuation, so mail addresses don't count
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w][\w.-]{0,63})`)
+
+// Mention is a notification that someone mentioned the actor
+type Mention struct {
+	ID     int       `json:"id"`
+	By     string    `json:"by"` // actor of the change
+	TodoID int       `json:"todo_id"`
+	Title  string    `json:"title"` // the todo's title with the mention
+	Time   time.Time `json:"time"`
+	Read   bool      `json:"read"`
+}
+
+// Notifications is the body of GET /notifications
+type Notifications struct {
+	Unread        int       `json:"unread"`
+	Notifications []Mention `json:"notifications"` // newest first
+}
+
+// notifications by lowercased actor name, oldest first
+var inboxes = make(map[string][]*Mention)
+var inboxesMu sync.Mutex
+var nextMentionID = 1
+
+
+// loadMentionEmail checks -mention-email
+func loadMentionEmail() error {
+	if *mentionEmail != "" && !strings.Contains(*mentionEmail, "{name}") {
+		return fmt.Errorf("-mention-email: %q has no {name}", *mentionEmail)
+	}
+	return nil
+}
+
+
+// mentions returns the names mentioned in a title, each once, as written
+func mentions(title string) []string {
+	var names []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(title, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) }) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+
+// newMentions returns the names in e's title that weren't in the title before the change
+func newMentions(e model.Event) []string {
+
+	names := mentions(e.Todo.Title)
+	if e.Type != model.EventUpdated || len(names) == 0 {
+		return names
+	}
+	previous, err := todoStore.Revision(context.Background(), e.Todo.ID, e.Todo.Rev-1)
+	if err != nil {
+		return nil // not kept: don't notify again for mentions that may be old
+	}
+	old := mentions(previous.Todo.Title)
+	return slices.DeleteFunc(names, func(name string) bool {
+		return slices.ContainsFunc(old, func(n string) bool { return strings.EqualFold(n, name) })
+	})
+}
+
+
+// deliverMention puts a mention in name's inbox, and sends it to webhooks (in the trace of the
+// change making it) and by mail
+func deliverMention(name string, m Mention, traceparent string) {
+
+	inboxesMu.Lock()
+	m.ID = nextMentionID
+	nextMentionID++
+	key := strings.ToLower(name)
+	inbox := append(inboxes[key], &m)
+	if len(inbox) > inboxSize {
+		inbox = inbox[len(inbox)-inboxSize:]
+	}
+	inboxes[key] = inbox
+	inboxesMu.Unlock()
+
+	for _, hook := range webhooksFor(eventMentioned) {
+		if slices.Contains(hook.Events, eventMentioned) {
+			todo, _ := todoStore.Get(context.Background(), m.TodoID)
+			enqueueDelivery(hook.ID, WebhookPayload{DeliveryID: randomHex(8), Type: eventMentioned, Time: m.Time, Todo: &todo, Mention: &MentionPayload{To: name, By: m.By}}, traceparent)
+		}
+	}
+
+	if *mentionEmail != "" && mailEnabled() {
+		to := strings.ReplaceAll(*mentionEmail, "{name}", name)
+		body := fmt.Sprintf("%s mentioned you in todo %d:\n\n%s\n", m.By, m.TodoID, m.Title)
+		if err := sendMail(to, m.By+" mentioned you", body); err != nil {
+			fmt.Println("mention mail to", to, "failed:", err)
+		}
+	}
+}
+
+
+// startMentions notifies the actors mentioned in created and renamed todos
+func startMentions() {
+
+	events := todoStore.Events().Subscribe()
+	go func() {
+		for e := range events {
+			if e.Type == model.EventDeleted {
+				continue
+			}
+			for _, name := range newMentions(e) {
+				if strings.EqualFold(name, e.Actor) {
+					continue
+				}
+				deliverMention(name, Mention{By: e.Actor, TodoID: e.Todo.ID, Title: e.Todo.Title, Time: e.Time}, e.Traceparent)
+			}
+		}
+	}()
+}
+
+
+// GET /notifications?unread=true returns the requesting actor's notifications, newest first
+func notificationsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	unreadOnly, err := strconv.ParseBool(r.URL.Query().Get("unread"))
+	if err != nil && r.URL.Query().Get("unread") != "" {
+		writeError(w, r, model.Invalid("unread", "must be true or false"))
+		return
+	}
+	actor, err := requestActor(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list := Notifications{Notifications: []Mention{}}
+	inboxesMu.Lock()
+	inbox := inboxes[strings.ToLower(actor)]
+	for i := len(inbox) - 1; i >= 0; i-- {
+		if !inbox[i].Read {
+			list.Unread++
+		} else if unreadOnly {
+			continue
+		}
+		list.Notifications = append(list.Notifications, *inbox[i])
+	}
+	inboxesMu.Unlock()
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// POST /notifications/{id}/read marks one of the requesting actor's notifications read,
+// POST /notifications/read all of them; both return how many are left unread
+func readNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only POST
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id := 0
+	if v := r.PathValue("id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, model.Invalid("id", "must be an integer"))
+			return
+		}
+		id = n
+	}
+	actor, err := requestActor(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	inboxesMu.Lock()
+	found, unread := false, 0
+	for _, m := range inboxes[strings.ToLower(actor)] {
+		if id == 0 || m.ID == id {
+			m.Read, found = true, true
+		}
+		if !m.Read {
+			unread++
+		}
+	}
+	inboxesMu.Unlock()
+	if id != 0 && !found {
+		writeError(w, r, fmt.Errorf("notification %d: %w", id, model.ErrNotFound))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int{"unread": unread})
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http" // for HTTP handlers
+)
+
+// statusRecorder wraps a ResponseWriter to remember the status code and body size
+type statusRecorder struct {
+	http.ResponseWriter
+	status int // status code sent to the client
+	bytes  int // number of body bytes written
+}
+
+
+// newStatusRecorder starts with 200, which is what net/http sends if WriteHeader is never called
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+
+// WriteHeader records the status before passing it on
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+
+// Write counts body bytes before passing them on
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+
+// Unwrap lets http.ResponseController reach the underlying writer (flush, hijack, deadlines)
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
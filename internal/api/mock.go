@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors and logs
+	"net/http"      // for the middleware
+	"strconv"       // for rule values and Mock-Status
+	"strings"       // for parsing rules
+	"sync/atomic"   // for counting requests per rule
+	"time"          // for the frozen clock and delays
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for validation errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the canned store
+)
+
+// mock mode, for client developers: the same canned data on every start and after every
+// reset, a clock that doesn't move, and delays and errors where the client asks for them
+var (
+	mockMode   = flags.Bool("mock", false, "serve canned data on a frozen clock for client testing: the demo todos, reset with POST /mock/reset, no background jobs")
+	mockRoutes = flags.String("mock-routes", "", `mock delays and errors per route, e.g. "GET /todos delay=300ms; POST /todos/create status=503 every=3" (with -mock)`)
+)
+
+// where the mock clock stands: a fixed time, so canned due dates and timestamps never change
+var mockTime = time.Date(2030, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// longest delay a rule or a Mock-Delay header may ask for
+const maxMockDelay = time.Minute
+
+// mockRule is one -mock-routes entry
+type mockRule struct {
+	method string        // "" = any
+	path   string        // exact, or a prefix when it ends in *
+	delay  time.Duration // before answering
+	status int           // answer this instead of the route's own, 0 = don't
+	every  int           // only every nth matching request fails, 0 = all of them
+	seen   atomic.Int64  // matching requests so far
+}
+
+// MockReset is the body of POST /mock/reset
+type MockReset struct {
+	Todos int       `json:"todos"`
+	Now   time.Time `json:"now"`
+}
+
+// parsed -mock-routes, set up by loadMockRoutes
+var mockRules []*mockRule
+
+
+// loadMockRoutes parses -mock-routes (only allowed with -mock) and makes -mock seed the demo set
+func loadMockRoutes() error {
+
+	if !*mockMode {
+		if *mockRoutes != "" {
+			return fmt.Errorf("-mock-routes: needs -mock")
+		}
+		return nil
+	}
+	if *seedSet == "" {
+		*seedSet = "demo"
+	}
+
+	mockRules = nil
+	for _, entry := range strings.Split(*mockRoutes, ";") {
+		words := strings.Fields(entry)
+		if len(words) == 0 {
+			continue
+		}
+		rule := &mockRule{}
+		if !strings.HasPrefix(words[0], "/") {
+			rule.method, words = strings.ToUpper(words[0]), words[1:]
+		}
+		if len(words) == 0 || !strings.HasPrefix(words[0], "/") {
+			return fmt.Errorf("-mock-routes: %q: want [METHOD] /path key=value...", strings.TrimSpace(entry))
+		}
+		rule.path = words[0]
+		for _, opt := range words[1:] {
+			key, value, _ := strings.Cut(opt, "=")
+			var err error
+			switch key {
+			case "delay":
+				rule.delay, err = time.ParseDuration(value)
+				if err == nil && (rule.delay < 0 || rule.delay > maxMockDelay) {
+					err = fmt.Errorf("longer than %s", maxMockDelay)
+				}
+			case "status":
+				rule.status, err = strconv.Atoi(value)
+				if err == nil && (rule.status < 100 || rule.status > 599) {
+					err = fmt.Errorf("not an HTTP status")
+				}
+			case "every":
+				rule.every, err = strconv.Atoi(value)
+				if err == nil && rule.every < 1 {
+					err = fmt.Errorf("must be at least 1")
+				}
+			default:
+				err = fmt.Errorf("unknown option (delay, status, every)")
+			}
+			if err != nil {
+				return fmt.Errorf("-mock-routes: %s: %s: %v", strings.TrimSpace(rule.method+" "+rule.path), opt, err)
+			}
+		}
+		mockRules = append(mockRules, rule)
+	}
+	return nil
+}
+
+
+// newMockStore returns a store on the frozen clock; Handler seeds it with the demo set
+func newMockStore() *store.Store {
+	return store.New(*changeLogSize, store.WithClock(store.NewManualClock(mockTime)), store.WithHistorySize(*historySize))
+}
+
+
+// matches reports whether the rule is for r
+func (rule *mockRule) matches(r *http.Request) bool {
+
+	if rule.method != "" && rule.method != r.Method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(rule.path, "*"); ok {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+	return r.URL.Path == rule.path
+}
+
+
+// mockFaults delays and fails requests as -mock-routes says, or as the request's own Mock-Delay
+// and Mock-Status headers ask, and serves POST /mock/reset; everything else goes to next
+func mockFaults(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Path == "/mock/reset" {
+			mockResetHandler(w, r)
+			return
+		}
+
+		var delay time.Duration
+		status := 0
+		for _, rule := range mockRules {
+			if !rule.matches(r) {
+				continue
+			}
+			delay = max(delay, rule.delay)
+			n := rule.seen.Add(1)
+			if rule.status != 0 && status == 0 && (rule.every == 0 || n%int64(rule.every) == 0) {
+				status = rule.status
+			}
+		}
+
+		// a test asking for one slow or failing answer doesn't have to restart the server
+		if v := r.Header.Get("Mock-Delay"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 || d > maxMockDelay {
+				writeError(w, r, model.Invalid("Mock-Delay", "must be a duration up to 1m"))
+				return
+			}
+			delay = d
+		}
+		if v := r.Header.Get("Mock-Status"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 100 || n > 599 {
+				writeError(w, r, model.Invalid("Mock-Status", "must be an HTTP status"))
+				return
+			}
+			status = n
+		}
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the answer looks like the API's own errors, marked as injected
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Mock-Injected", "true")
+		if status == http.StatusServiceUnavailable || status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "mock: injected " + strconv.Itoa(status) + " " + http.StatusText(status)})
+	})
+}
+
+
+// POST puts the canned data back: a fresh store with the demo todos, no saved filters,
+// templates, snoozes, blockers, attachments, notifications, streaks or erasures, the clock at its
+// fixed time
+func mockResetHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	savedFiltersMu.Lock()
+	savedFilters, nextFilterID = make(map[int]SavedFilter), 1
+	savedFiltersMu.Unlock()
+	shareLinksMu.Lock()
+	shareLinks = make(map[string]ShareLink)
+	shareLinksMu.Unlock()
+	todoTemplatesMu.Lock()
+	todoTemplates, nextTemplateID = make(map[int]TodoTemplate), 1
+	todoTemplatesMu.Unlock()
+	snoozesMu.Lock()
+	snoozes = make(map[int]*SnoozeHistory)
+	snoozesMu.Unlock()
+	blockersMu.Lock()
+	blockers = make(map[int]map[int]bool)
+	blockersMu.Unlock()
+	attachmentsMu.Lock()
+	attachments, nextAttachmentID = make(map[int]Attachment), 1
+	attachmentsMu.Unlock()
+	inboxesMu.Lock()
+	inboxes = make(map[string][]*Mention)
+	inboxesMu.Unlock()
+	streaksMu.Lock()
+	streaks = make(map[string]*actorStreak)
+	streaksMu.Unlock()
+	erasuresMu.Lock()
+	erasures = make(map[string]Erasure)
+	erasuresMu.Unlock()
+	for _, rule := range mockRules {
+		rule.seen.Store(0)
+	}
+
+	// the routes read the store from todoStore, so swapping it is enough
+	Handler(newMockStore())
+	fmt.Println("mock data reset")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MockReset{Todos: todoStore.Count(), Now: todoStore.Now()})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"           // for reading JSON bodies
+	"encoding/binary" // for big-endian integers
+	"encoding/json"   // for the JSON being transcoded
+	"errors"          // for decode errors
+	"io"              // for EOF
+	"math"            // for float bits
+	"strconv"         // for JSON numbers
+)
+
+// other body formats are transcoded from the JSON the handlers write, so field names, order and
+// omitempty are the json struct tags everywhere. decodeOrderedJSON turns a body into plain values
+// (keeping object key order), which the format encoders then write out.
+
+// objectEntry is one key of a JSON object
+type objectEntry struct {
+	key   string
+	value any
+}
+
+// orderedObject is a JSON object with its keys in document order
+type orderedObject []objectEntry
+
+
+// decodeOrderedJSON parses exactly one JSON value into nil, bool, string, json.Number,
+// []any and orderedObject
+func decodeOrderedJSON(data []byte) (any, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	// one value per body, anything after it isn't JSON we can transcode
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+
+// decodeOrderedValue reads the next value from dec
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, objectEntry{key.(string), value})
+		}
+		_, err := dec.Token() // '}'
+		return obj, err
+
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token() // ']'
+		return list, err
+	}
+	return tok, nil
+}
+
+
+// appendMsgpack appends v (as returned by decodeOrderedJSON) in MessagePack
+func appendMsgpack(b []byte, v any) []byte {
+
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+
+	case json.Number:
+		// integers stay integers, the rest are float64
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return appendMsgpackInt(b, i)
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+		}
+		f, _ := v.Float64()
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+
+	case string:
+		b = appendMsgpackHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(b, v...)
+
+	case []any:
+		b = appendMsgpackHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			b = appendMsgpack(b, item)
+		}
+		return b
+
+	case orderedObject:
+		b = appendMsgpackHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, entry := range v {
+			b = appendMsgpack(b, entry.key)
+			b = appendMsgpack(b, entry.value)
+		}
+		return b
+	}
+	return append(b, 0xc0)
+}
+
+
+// appendMsgpackInt appends an integer in its smallest MessagePack form
+func appendMsgpackInt(b []byte, i int64) []byte {
+
+	switch {
+	case i >= 0 && i < 128:
+		return append(b, byte(i)) // positive fixint
+	case i >= -32 && i < 0:
+		return append(b, byte(i)) // negative fixint
+	case i >= 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+
+// appendMsgpackHeader appends the type and length of a string, array or map: the fix form below
+// fixLimit, else the 8 bit (if the type has one, str8 != 0), 16 bit or 32 bit length form
+func appendMsgpackHeader(b []byte, n int, fix byte, fixLimit int, len8, len16, len32 byte) []byte {
+
+	switch {
+	case n < fixLimit:
+		return append(b, fix|byte(n))
+	case len8 != 0 && n <= math.MaxUint8:
+		return append(b, len8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, len16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, len32), uint32(n))
+}
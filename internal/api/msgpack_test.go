@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/binary" // for big-endian lengths and numbers
+	"encoding/hex"    // for the expected bytes
+	"encoding/json"   // for JSON numbers
+	"errors"          // for decode errors
+	"math"            // for float bits
+	"strconv"         // for numbers
+	"strings"         // for long strings
+	"testing"         // for the tests
+)
+
+// MessagePack tests: the bytes a value comes out as, and JSON transcoded to MessagePack and read
+// back by a decoder of the spec's types giving the same JSON
+
+
+// decodeMsgpackTest reads one value into what decodeOrderedJSON returns, and the bytes after it
+func decodeMsgpackTest(b []byte) (any, []byte, error) {
+
+	short := errors.New("msgpack: unexpected end")
+	if len(b) == 0 {
+		return nil, nil, short
+	}
+	c, b := b[0], b[1:]
+	take := func(n int) ([]byte, error) {
+		if len(b) < n {
+			return nil, short
+		}
+		v := b[:n]
+		b = b[n:]
+		return v, nil
+	}
+	length := func(size int) (int, error) {
+		v, err := take(size)
+		n := 0
+		for _, x := range v {
+			n = n<<8 | int(x)
+		}
+		return n, err
+	}
+	number := func(size int, signed bool) (any, []byte, error) {
+		v, err := take(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		var u uint64
+		for _, x := range v {
+			u = u<<8 | uint64(x)
+		}
+		if !signed {
+			return json.Number(strconv.FormatUint(u, 10)), b, nil
+		}
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), b, nil
+	}
+
+	var n int
+	var err error
+	switch {
+	case c < 0x80:
+		return json.Number(strconv.Itoa(int(c))), b, nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), b, nil
+	case c <= 0x8f:
+		return decodeMsgpackMap(b, int(c&0x0f))
+	case c <= 0x9f:
+		return decodeMsgpackArray(b, int(c&0x0f))
+	case c <= 0xbf:
+		s, err := take(int(c & 0x1f))
+		return string(s), b, err
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, b, nil
+	case 0xcb:
+		v, err := take(8)
+		if err != nil {
+			return nil, nil, err
+		}
+		f := math.Float64frombits(binary.BigEndian.Uint64(v))
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return number(1<<(c-0xcc), false)
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return number(1<<(c-0xd0), true)
+	case 0xd9, 0xda, 0xdb:
+		if n, err = length(1 << (c - 0xd9)); err != nil {
+			return nil, nil, err
+		}
+		s, err := take(n)
+		return string(s), b, err
+	case 0xdc, 0xdd:
+		if n, err = length(2 << (c - 0xdc)); err != nil {
+			return nil, nil, err
+		}
+		return decodeMsgpackArray(b, n)
+	case 0xde, 0xdf:
+		if n, err = length(2 << (c - 0xde)); err != nil {
+			return nil, nil, err
+		}
+		return decodeMsgpackMap(b, n)
+	}
+	return nil, nil, errors.New("msgpack: type " + strconv.Itoa(int(c)) + " isn't written by appendMsgpack")
+}
+
+
+// decodeMsgpackArray reads n values
+func decodeMsgpackArray(b []byte, n int) (any, []byte, error) {
+	list := []any{}
+	for range n {
+		v, rest, err := decodeMsgpackTest(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		list, b = append(list, v), rest
+	}
+	return list, b, nil
+}
+
+
+// decodeMsgpackMap reads n string keys and their values
+func decodeMsgpackMap(b []byte, n int) (any, []byte, error) {
+	obj := orderedObject{}
+	for range n {
+		key, rest, err := decodeMsgpackTest(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, ok := key.(string)
+		if !ok {
+			return nil, nil, errors.New("msgpack: key isn't a string")
+		}
+		value, rest, err := decodeMsgpackTest(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		obj, b = append(obj, objectEntry{s, value}), rest
+	}
+	return obj, b, nil
+}
+
+
+// TestAppendMsgpack checks the smallest form is written for each JSON value
+func TestAppendMsgpack(t *testing.T) {
+
+	tests := []struct {
+		json string
+		want string // hex
+	}{
+		{`null`, "c0"},
+		{`true`, "c3"},
+		{`false`, "c2"},
+		{`0`, "00"},
+		{`127`, "7f"},
+		{`128`, "cc80"},
+		{`255`, "ccff"},
+		{`256`, "cd0100"},
+		{`65536`, "ce00010000"},
+		{`4294967296`, "cf0000000100000000"},
+		{`18446744073709551615`, "cfffffffffffffffff"},
+		{`-1`, "ff"},
+		{`-32`, "e0"},
+		{`-33`, "d0df"},
+		{`-129`, "d1ff7f"},
+		{`-32769`, "d2ffff7fff"},
+		{`-2147483649`, "d3ffffffff7fffffff"},
+		{`1.5`, "cb3ff8000000000000"},
+		{`""`, "a0"},
+		{`"a"`, "a161"},
+		{`"` + strings.Repeat("x", 31) + `"`, "bf" + strings.Repeat("78", 31)},
+		{`"` + strings.Repeat("x", 32) + `"`, "d920" + strings.Repeat("78", 32)},
+		{`"` + strings.Repeat("x", 256) + `"`, "da0100" + strings.Repeat("78", 256)},
+		{`[]`, "90"},
+		{`[1,[2]]`, "92019102"},
+		{`[` + strings.Repeat("0,", 15) + `0]`, "dc0010" + strings.Repeat("00", 16)},
+		{`{}`, "80"},
+		{`{"b":1,"a":null}`, "82a16201a161c0"},
+	}
+	for _, tt := range tests {
+		v, err := decodeOrderedJSON([]byte(tt.json))
+		if err != nil {
+			t.Fatalf("decodeOrderedJSON(%.40s): %v", tt.json, err)
+		}
+		if got := hex.EncodeToString(appendMsgpack(nil, v)); got != tt.want {
+			t.Errorf("appendMsgpack(%.40s) = %.60s, want %.60s", tt.json, got, tt.want)
+		}
+	}
+}
+
+
+// TestMsgpackRoundTrip checks JSON comes back the same from its MessagePack, key order included
+func TestMsgpackRoundTrip(t *testing.T) {
+
+	big := `[` + strings.TrimSuffix(strings.Repeat(`{"id":70000,"title":"t","done":false},`, 70000), ",") + `]`
+	tests := []struct {
+		name string
+		json string
+		want string // when it isn't the same text (floats come back in their shortest form)
+	}{
+		{"todo", `{"id":1,"title":"buy milk","done":false,"due":null,"tags":["home","shop"]}`, ""},
+		{"key order", `{"z":1,"a":2,"m":{"y":true,"b":false}}`, ""},
+		{"numbers", `[0,-1,-32,-33,127,128,-9223372036854775808,18446744073709551615,2.25,-0.5]`, ""},
+		{"float forms", `[1e3,1.0,0.1]`, `[1000,1,0.1]`},
+		{"unicode", `{"title":"café ☕ 日本"}`, ""},
+		{"empties", `[[],{},"",null]`, ""},
+		{"over 65535 entries", big, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := decodeOrderedJSON([]byte(tt.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			back, rest, err := decodeMsgpackTest(appendMsgpack(nil, v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Fatalf("%d bytes after the value", len(rest))
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.json
+			}
+			if got := string(encodeOrderedJSON(nil, back)); got != want {
+				t.Errorf("round trip = %.80s, want %.80s", got, want)
+			}
+		})
+	}
+}
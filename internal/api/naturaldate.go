@@ -0,0 +1,354 @@
+package api
+
+import (
+	"errors"   // for phrases we can't read
+	"net/http" // for due values in requests
+	"strconv"  // for numbers in phrases
+	"strings"  // for splitting phrases into words
+	"time"     // for resolving against now
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for validation errors
+)
+
+// due dates in words: "tomorrow 5pm", "next friday", "in 3 days", "jan 5 at noon". they are
+// resolved once, on the server, in the client's time zone, and stored as a plain time
+
+// errDuePhrase is what a client gets back for a phrase we can't read
+var errDuePhrase = errors.New(`is not a date we can read (try "tomorrow 5pm", "next friday", "in 3 days" or RFC 3339)`)
+
+// named times of day, and the time a day-only phrase is due at
+var (
+	namedTimes = map[string]time.Duration{
+		"midnight":  0,
+		"morning":   9 * time.Hour,
+		"noon":      12 * time.Hour,
+		"midday":    12 * time.Hour,
+		"afternoon": 15 * time.Hour,
+		"evening":   18 * time.Hour,
+		"tonight":   20 * time.Hour,
+		"night":     20 * time.Hour,
+		"eod":       17 * time.Hour,
+	}
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "sun": time.Sunday,
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+	}
+	months = map[string]time.Month{
+		"january": time.January, "jan": time.January,
+		"february": time.February, "feb": time.February,
+		"march": time.March, "mar": time.March,
+		"april": time.April, "apr": time.April,
+		"may":  time.May,
+		"june": time.June, "jun": time.June,
+		"july": time.July, "jul": time.July,
+		"august": time.August, "aug": time.August,
+		"september": time.September, "sep": time.September, "sept": time.September,
+		"october": time.October, "oct": time.October,
+		"november": time.November, "nov": time.November,
+		"december": time.December, "dec": time.December,
+	}
+	smallNumbers = map[string]int{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "twelve": 12,
+	}
+)
+
+
+// resolveDue reads an RFC 3339 time, a plain date or a phrase, relative to now in loc.
+// a day without a time is due at its start, like a plain date
+func resolveDue(s string, now time.Time, loc *time.Location) (time.Time, error) {
+
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	now = now.In(loc).Truncate(time.Minute) // "in 2 hours" needn't be due to the nanosecond
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	words := strings.Fields(strings.ToLower(strings.NewReplacer(",", " ", ".", " ").Replace(s)))
+	if len(words) == 0 {
+		return time.Time{}, errDuePhrase
+	}
+
+	// "in 3 days", "in an hour": from now; whole days keep the time of day unless one is given
+	if words[0] == "in" {
+		n, unit, rest, ok := readAmount(words[1:])
+		if !ok {
+			return time.Time{}, errDuePhrase
+		}
+		switch unit {
+		case "minute":
+			return exact(now.Add(time.Duration(n)*time.Minute), rest)
+		case "hour":
+			return exact(now.Add(time.Duration(n)*time.Hour), rest)
+		case "day":
+			return onDay(now.AddDate(0, 0, n), rest, now)
+		case "week":
+			return onDay(now.AddDate(0, 0, 7*n), rest, now)
+		case "month":
+			return onDay(now.AddDate(0, n, 0), rest, now)
+		}
+		return time.Time{}, errDuePhrase
+	}
+
+	// a day, then maybe a time
+	day, rest, ok := readDay(words, today)
+	if !ok {
+		// just a time: today, or tomorrow once it has passed
+		at, ok := readTime(words)
+		if !ok {
+			return time.Time{}, errDuePhrase
+		}
+		due := atTime(today, at)
+		if !due.After(now) {
+			due = atTime(today.AddDate(0, 0, 1), at)
+		}
+		return due, nil
+	}
+	if len(rest) == 0 {
+		if words[0] == "tonight" {
+			return atTime(day, namedTimes["tonight"]), nil
+		}
+		return day, nil
+	}
+	at, ok := readTime(rest)
+	if !ok {
+		return time.Time{}, errDuePhrase
+	}
+	return atTime(day, at), nil
+}
+
+
+// exact returns t when nothing follows an amount of hours or minutes
+func exact(t time.Time, rest []string) (time.Time, error) {
+	if len(rest) > 0 {
+		return time.Time{}, errDuePhrase
+	}
+	return t, nil
+}
+
+
+// onDay returns t's day at the time in rest, or t itself without one
+func onDay(t time.Time, rest []string, now time.Time) (time.Time, error) {
+
+	if len(rest) == 0 {
+		return t, nil
+	}
+	at, ok := readTime(rest)
+	if !ok {
+		return time.Time{}, errDuePhrase
+	}
+	return atTime(t.In(now.Location()), at), nil
+}
+
+
+// atTime returns day at the time of day at on its clocks, which isn't midnight plus at on the
+// days they change
+func atTime(day time.Time, at time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(at/time.Hour), int(at%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+
+// readAmount reads "3 days", "a week", "two hours", returning the unit in the singular
+func readAmount(words []string) (int, string, []string, bool) {
+
+	if len(words) < 2 {
+		return 0, "", nil, false
+	}
+	n, ok := smallNumbers[words[0]]
+	if !ok {
+		var err error
+		if n, err = strconv.Atoi(words[0]); err != nil || n < 0 || n > 10000 {
+			return 0, "", nil, false
+		}
+	}
+	unit := strings.TrimSuffix(words[1], "s")
+	switch unit {
+	case "min", "minute":
+		unit = "minute"
+	case "hr", "hour":
+		unit = "hour"
+	case "day", "week", "month":
+	default:
+		return 0, "", nil, false
+	}
+	return n, unit, words[2:], true
+}
+
+
+// readDay reads the day a phrase starts with, returning the words after it
+func readDay(words []string, today time.Time) (time.Time, []string, bool) {
+
+	switch words[0] {
+	case "today", "tonight":
+		return today, words[1:], true
+	case "tomorrow", "tmrw", "tmr":
+		return today.AddDate(0, 0, 1), words[1:], true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), words[1:], true
+	}
+
+	// "2030-01-05"
+	if t, err := time.ParseInLocation("2006-01-02", words[0], today.Location()); err == nil {
+		return t, words[1:], true
+	}
+
+	// "friday" and "this friday" are the next one from today on, "next friday" the next after today;
+	// "next week" and "next month" are the same day a week or a month on
+	next := false
+	if words[0] == "this" || words[0] == "next" {
+		next = words[0] == "next"
+		words = words[1:]
+		if len(words) == 0 {
+			return time.Time{}, nil, false
+		}
+		if next && words[0] == "week" {
+			return today.AddDate(0, 0, 7), words[1:], true
+		}
+		if next && words[0] == "month" {
+			return today.AddDate(0, 1, 0), words[1:], true
+		}
+	}
+	if wd, ok := weekdays[words[0]]; ok {
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if next && days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), words[1:], true
+	}
+	if next {
+		return time.Time{}, nil, false
+	}
+
+	// "jan 5", "5 jan", "january 5 2031": this year's, or next year's once it has passed
+	if len(words) >= 2 {
+		month, day := time.Month(0), 0
+		if m, ok := months[words[0]]; ok {
+			month, day = m, dayOfMonth(words[1])
+		} else if m, ok := months[words[1]]; ok {
+			month, day = m, dayOfMonth(words[0])
+		}
+		if month != 0 && day != 0 {
+			rest := words[2:]
+			year := today.Year()
+			if len(rest) > 0 {
+				if y, err := strconv.Atoi(rest[0]); err == nil && y >= 1000 && y <= 9999 {
+					year, rest = y, rest[1:]
+				}
+			}
+			t := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+			if t.Day() != day {
+				return time.Time{}, nil, false // feb 30
+			}
+			if year == today.Year() && len(words[2:]) == len(rest) && t.Before(today) {
+				t = t.AddDate(1, 0, 0)
+			}
+			return t, rest, true
+		}
+	}
+	return time.Time{}, nil, false
+}
+
+
+// dayOfMonth reads "5", "5th", "21st", 0 when it isn't one
+func dayOfMonth(s string) int {
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 31 {
+		return 0
+	}
+	return n
+}
+
+
+// readTime reads a time of day taking up all of words: "5pm", "5 pm", "at 17:30", "noon"
+func readTime(words []string) (time.Duration, bool) {
+
+	if len(words) > 0 && words[0] == "at" {
+		words = words[1:]
+	}
+	switch len(words) {
+	case 1:
+		if at, ok := namedTimes[words[0]]; ok {
+			return at, true
+		}
+		return clockTime(words[0], "")
+	case 2:
+		return clockTime(words[0], words[1])
+	case 3:
+		if words[0] == "end" && words[1] == "of" && words[2] == "day" {
+			return namedTimes["eod"], true
+		}
+	}
+	return 0, false
+}
+
+
+// clockTime reads "17:30", "5pm", "5:30pm" or "5" "pm"
+func clockTime(s, meridiem string) (time.Duration, bool) {
+
+	if meridiem == "" {
+		for _, m := range []string{"am", "pm"} {
+			if strings.HasSuffix(s, m) {
+				s, meridiem = strings.TrimSuffix(s, m), m
+			}
+		}
+	}
+	if meridiem != "" && meridiem != "am" && meridiem != "pm" {
+		return 0, false
+	}
+
+	hours, minutes, hasMinutes := strings.Cut(s, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, false
+	}
+	m := 0
+	if hasMinutes {
+		if len(minutes) != 2 {
+			return 0, false
+		}
+		if m, err = strconv.Atoi(minutes); err != nil || m > 59 {
+			return 0, false
+		}
+	}
+
+	switch {
+	case meridiem != "":
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+		h %= 12
+		if meridiem == "pm" {
+			h += 12
+		}
+	case !hasMinutes:
+		return 0, false // a bare "5" is too ambiguous
+	case h > 23:
+		return 0, false
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, true
+}
+
+
+// dueFromRequest resolves a due value from a request against the store's clock and the
+// client's time zone
+func dueFromRequest(r *http.Request, s string) (*time.Time, error) {
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		return nil, err
+	}
+	due, err := resolveDue(s, todoStore.Now(), loc)
+	if err != nil {
+		return nil, model.Invalid("due", err.Error())
+	}
+	return &due, nil
+}
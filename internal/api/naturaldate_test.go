@@ -0,0 +1,115 @@
+package api
+
+import (
+	"testing" // for the tests
+	"time"    // for now and the due dates
+)
+
+// natural date tests: phrases resolved against a fixed now, in UTC and across the clocks changing
+
+
+// mustZone loads a zone from the system's database, skipping the test when it isn't there
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("zone %s: %v", name, err)
+	}
+	return loc
+}
+
+
+// TestResolveDue checks each kind of phrase, and what it can't be
+func TestResolveDue(t *testing.T) {
+
+	newYork := mustZone(t, "America/New_York")
+
+	// Wednesday 2026-10-14 10:15:30 UTC
+	now := time.Date(2026, 10, 14, 10, 15, 30, 0, time.UTC)
+	utc := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		phrase string
+		now    time.Time
+		loc    *time.Location
+		want   time.Time
+	}{
+		{"2026-12-01T08:00:00Z", now, time.UTC, utc(2026, 12, 1, 8, 0)},
+		{"2026-12-01T08:00:00+02:00", now, newYork, utc(2026, 12, 1, 6, 0)},
+		{"2026-12-01", now, time.UTC, utc(2026, 12, 1, 0, 0)},
+		{"today", now, time.UTC, utc(2026, 10, 14, 0, 0)},
+		{"tonight", now, time.UTC, utc(2026, 10, 14, 20, 0)},
+		{"tomorrow", now, time.UTC, utc(2026, 10, 15, 0, 0)},
+		{"Tomorrow 5pm", now, time.UTC, utc(2026, 10, 15, 17, 0)},
+		{"tmrw at 9:30am", now, time.UTC, utc(2026, 10, 15, 9, 30)},
+		{"tomorrow 5 pm", now, time.UTC, utc(2026, 10, 15, 17, 0)},
+		{"tomorrow 12am", now, time.UTC, utc(2026, 10, 15, 0, 0)},
+		{"tomorrow 12pm", now, time.UTC, utc(2026, 10, 15, 12, 0)},
+		{"tomorrow noon", now, time.UTC, utc(2026, 10, 15, 12, 0)},
+		{"tomorrow end of day", now, time.UTC, utc(2026, 10, 15, 17, 0)},
+		{"yesterday", now, time.UTC, utc(2026, 10, 13, 0, 0)},
+		{"17:30", now, time.UTC, utc(2026, 10, 14, 17, 30)},
+		{"9am", now, time.UTC, utc(2026, 10, 15, 9, 0)}, // passed today
+		{"in 2 hours", now, time.UTC, utc(2026, 10, 14, 12, 15)},
+		{"in an hour", now, time.UTC, utc(2026, 10, 14, 11, 15)},
+		{"in 30 mins", now, time.UTC, utc(2026, 10, 14, 10, 45)},
+		{"in three days", now, time.UTC, utc(2026, 10, 17, 10, 15)},
+		{"in 3 days at noon", now, time.UTC, utc(2026, 10, 17, 12, 0)},
+		{"in a week", now, time.UTC, utc(2026, 10, 21, 10, 15)},
+		{"in 2 months", now, time.UTC, utc(2026, 12, 14, 10, 15)},
+		{"friday", now, time.UTC, utc(2026, 10, 16, 0, 0)},
+		{"wednesday", now, time.UTC, utc(2026, 10, 14, 0, 0)},
+		{"this wed", now, time.UTC, utc(2026, 10, 14, 0, 0)},
+		{"next wednesday", now, time.UTC, utc(2026, 10, 21, 0, 0)},
+		{"next friday 8am", now, time.UTC, utc(2026, 10, 16, 8, 0)},
+		{"next week", now, time.UTC, utc(2026, 10, 21, 0, 0)},
+		{"next month", now, time.UTC, utc(2026, 11, 14, 0, 0)},
+		{"jan 5", now, time.UTC, utc(2027, 1, 5, 0, 0)}, // passed this year
+		{"5th december", now, time.UTC, utc(2026, 12, 5, 0, 0)},
+		{"oct 14", now, time.UTC, utc(2026, 10, 14, 0, 0)},
+		{"january 5, 2031 at noon", now, time.UTC, utc(2031, 1, 5, 12, 0)},
+		{"feb 1 2026", now, time.UTC, utc(2026, 2, 1, 0, 0)}, // a year given is kept
+
+		// in the client's zone: 10:15 UTC is 06:15 EDT
+		{"tomorrow 5pm", now, newYork, time.Date(2026, 10, 15, 17, 0, 0, 0, newYork)},
+		{"today", now, newYork, time.Date(2026, 10, 14, 0, 0, 0, 0, newYork)},
+		{"9am", now, newYork, time.Date(2026, 10, 14, 9, 0, 0, 0, newYork)},
+
+		// the clocks go forward 2026-03-08 and back 2026-11-01: times of day stay on the clock
+		{"tomorrow 5pm", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 3, 8, 17, 0, 0, 0, newYork)},
+		{"tomorrow 5pm", time.Date(2026, 10, 31, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 11, 1, 17, 0, 0, 0, newYork)},
+		{"tonight", time.Date(2026, 11, 1, 8, 0, 0, 0, newYork), newYork, time.Date(2026, 11, 1, 20, 0, 0, 0, newYork)},
+		{"sunday noon", time.Date(2026, 3, 6, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 3, 8, 12, 0, 0, 0, newYork)},
+		{"in 1 day at 9am", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 3, 8, 9, 0, 0, 0, newYork)},
+		{"in 1 day", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 3, 8, 12, 0, 0, 0, newYork)},
+		{"in 24 hours", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), newYork, time.Date(2026, 3, 8, 13, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		got, err := resolveDue(tt.phrase, tt.now, tt.loc)
+		if err != nil {
+			t.Errorf("resolveDue(%q): %v", tt.phrase, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("resolveDue(%q, %v) = %v, want %v", tt.phrase, tt.now.In(tt.loc), got, tt.want.In(tt.loc))
+		}
+	}
+}
+
+
+// TestResolveDueErrors checks phrases that aren't dates are turned away
+func TestResolveDueErrors(t *testing.T) {
+
+	now := time.Date(2026, 10, 14, 10, 15, 30, 0, time.UTC)
+	for _, phrase := range []string{
+		"", "  ", "soon", "in", "in 3", "in 3 fortnights", "in -1 days", "in 2 hours at 5pm",
+		"tomorrow 25:00", "tomorrow 5:7", "tomorrow 13pm", "tomorrow 0am", "tomorrow 5", "5",
+		"next", "next tomorrow", "feb 30", "jan 32", "tomorrow 5 xm", "today at", "friday blah",
+	} {
+		if got, err := resolveDue(phrase, now, time.UTC); err == nil {
+			t.Errorf("resolveDue(%q) = %v, want an error", phrase, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors
+	"math"          // for rounding scores
+	"net/http"      // for HTTP handlers
+	"net/url"       // for the weights as key=value pairs
+	"sort"          // for ranking
+	"strconv"       // for limit and weights
+	"strings"       // for the flag syntax
+	"time"          // for due dates and ages
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for open todos
+)
+
+// "what should I do now": open todos ranked by a score, so a client can show the top few
+// without fetching everything. the score adds up weighted terms, per todo,
+//
+//	due     * 1 / (1 + days until due)   0.5 a day out, 1 once due; 0 without a due date
+//	overdue * days overdue               grows for as long as it's left
+//	age     * days since it was created  so old todos without a due date surface too
+//
+// todos have no priority, so there is no priority term; the weights come from -next-score and
+// can be changed per request with ?score=
+var nextScoreFlag = flags.String("next-score", "due=3,overdue=1,age=0.1", "weights of GET /todos/next's ranking: due, overdue, age")
+
+// NextWeights are the weights of the ranking terms
+type NextWeights struct {
+	Due     float64 `json:"due"`
+	Overdue float64 `json:"overdue"`
+	Age     float64 `json:"age"`
+}
+
+// RankedTodo is a todo with its score
+type RankedTodo struct {
+	Score float64    `json:"score"`
+	Todo  model.Todo `json:"todo"`
+}
+
+// NextTodos is the body of GET /todos/next
+type NextTodos struct {
+	Todos   []RankedTodo `json:"todos"` // highest score first
+	Weights NextWeights  `json:"weights"`
+}
+
+// most todos GET /todos/next returns, and how many when not told
+const (
+	maxNextLimit     = 100
+	defaultNextLimit = 3
+)
+
+// weights from -next-score, set up by loadNextScore
+var nextWeights NextWeights
+
+
+// parseNextWeights reads "due=3,overdue=1,age=0.1" over base; keys left out keep base's weight
+func parseNextWeights(s string, base NextWeights) (NextWeights, error) {
+
+	values, err := url.ParseQuery(strings.ReplaceAll(s, ",", "&"))
+	if err != nil {
+		return base, err
+	}
+	weights := map[string]*float64{"due": &base.Due, "overdue": &base.Overdue, "age": &base.Age}
+	for key, v := range values {
+		weight, ok := weights[key]
+		if !ok {
+			return base, fmt.Errorf("unknown weight %q (due, overdue, age)", key)
+		}
+		f, err := strconv.ParseFloat(v[0], 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return base, fmt.Errorf("%s=%s: not a number of at least 0", key, v[0])
+		}
+		*weight = f
+	}
+	return base, nil
+}
+
+
+// loadNextScore applies -next-score
+func loadNextScore() error {
+	weights, err := parseNextWeights(*nextScoreFlag, NextWeights{})
+	if err != nil {
+		return fmt.Errorf("-next-score: %w", err)
+	}
+	nextWeights = weights
+	return nil
+}
+
+
+// score is how urgent an open todo is, created at created, as of now
+func (w NextWeights) score(todo model.Todo, created, now time.Time) float64 {
+
+	days := func(d time.Duration) float64 { return max(d.Hours()/24, 0) }
+
+	score := w.Age * days(now.Sub(created))
+	if todo.Due != nil {
+		if todo.Due.After(now) {
+			score += w.Due / (1 + days(todo.Due.Sub(now)))
+		} else {
+			score += w.Due + w.Overdue*days(now.Sub(*todo.Due))
+		}
+	}
+	return math.Round(score*1000) / 1000
+}
+
+
+// GET /todos/next?limit=3 returns the open todos to work on first, ranked by score
+func nextTodosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	limit := defaultNextLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxNextLimit {
+			writeError(w, r, model.Invalid("limit", fmt.Sprintf("must be between 1 and %d", maxNextLimit)))
+			return
+		}
+		limit = n
+	}
+	weights := nextWeights
+	if v := q.Get("score"); v != "" {
+		var err error
+		if weights, err = parseNextWeights(v, weights); err != nil {
+			writeError(w, r, model.Invalid("score", err.Error()))
+			return
+		}
+	}
+
+	now := todoStore.Now()
+	open := false
+	ranked := []RankedTodo{}
+	for _, todo := range todoStore.Find(r.Context(), store.Filter{Done: &open}) {
+		created, err := storeFor(r).TodoCreated(todo.ID)
+		if err != nil {
+			continue // deleted meanwhile
+		}
+		ranked = append(ranked, RankedTodo{Score: weights.score(todo, created, now), Todo: todo})
+	}
+
+	// ties go to the older todo, which Find lists first
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	json.NewEncoder(w).Encode(NextTodos{Todos: ranked, Weights: weights})
+}
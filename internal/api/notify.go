@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context" // for store calls outside a request
+	"errors"  // for telling deleted todos apart
+	"fmt"     // for printing logs to terminal
+	"sync"    // for guarding the overdue set
+	"time"    // for the overdue check
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// notification kind for todos whose due date passed while still open
+const notifyOverdue = "overdue"
+
+// how often open todos are checked for a passed due date
+const overdueCheckEvery = 30 * time.Second
+
+// how many notifications a slow integration may lag behind before they are dropped
+const notifierBuffer = 256
+
+// Notification is what chat integrations are told about
+type Notification struct {
+	Kind   string           `json:"kind"` // created, completed, updated, deleted, overdue or streak
+	Todo   model.Todo       `json:"todo"`
+	Time   time.Time        `json:"time"`
+	Streak *StreakMilestone `json:"streak,omitempty"` // for streak, the todo being the one that reached it
+}
+
+// one queue per registered integration, so a slow one doesn't hold up the others
+var notifiers []chan Notification
+
+// todos already announced as overdue, with the due date they were announced for
+var overdueMu sync.Mutex
+var overdueSent = make(map[int]time.Time)
+
+
+// addNotifier registers an integration; send runs on its own goroutine, one notification at a time
+func addNotifier(name string, send func(Notification) error) {
+
+	queue := make(chan Notification, notifierBuffer)
+	notifiers = append(notifiers, queue)
+
+	go func() {
+		for n := range queue {
+			if err := send(n); err != nil {
+				fmt.Println(name, "notification failed:", err)
+			}
+		}
+	}()
+}
+
+
+// notify hands n to every integration without blocking
+func notify(n Notification) {
+	for _, queue := range notifiers {
+		select {
+		case queue <- n:
+		default:
+		}
+	}
+}
+
+
+// checkOverdue notifies once per todo (and due date) when an open todo's due date has passed
+func checkOverdue(now time.Time) {
+
+	overdueMu.Lock()
+	defer overdueMu.Unlock()
+
+	// the due date index hands us only open todos that are past due
+	ctx := context.Background()
+	open := false
+	for _, todo := range todoStore.Find(ctx, store.Filter{Done: &open, DueBefore: &now}) {
+		if sent, ok := overdueSent[todo.ID]; ok && sent.Equal(*todo.Due) {
+			continue
+		}
+		overdueSent[todo.ID] = *todo.Due
+		notify(Notification{Kind: notifyOverdue, Todo: todo, Time: now})
+	}
+
+	// forget deleted todos
+	for id := range overdueSent {
+		if _, err := todoStore.Get(ctx, id); errors.Is(err, model.ErrNotFound) {
+			delete(overdueSent, id)
+		}
+	}
+}
+
+
+// startNotifications feeds store events and overdue todos to the chat integrations
+func startNotifications() {
+
+	// nothing registered
+	if len(notifiers) == 0 {
+		return
+	}
+
+	events := todoStore.Events().Subscribe()
+	go func() {
+		for e := range events {
+			notify(Notification{Kind: model.ChangeKind(e), Todo: e.Todo, Time: e.Time})
+		}
+	}()
+
+	go func() {
+		for range time.Tick(overdueCheckEvery) {
+			if scheduling() {
+				checkOverdue(todoStore.Now())
+			}
+		}
+	}()
+}
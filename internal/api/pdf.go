@@ -0,0 +1,278 @@
+package api
+
+import (
+	"bytes"    // for building the document
+	"fmt"      // for PDF operators
+	"maps"     // for the tags' sections
+	"net/http" // for HTTP handlers
+	"slices"   // for the tags in order
+	"strings"  // for wrapping titles
+	"time"     // for due dates in the client's zone
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// a printable checklist as PDF, written by hand: A4 pages in the Helvetica every viewer has built
+// in, a box per todo (ticked when done) and its due date in a column on the right. the built-in
+// fonts only cover Latin-1, so other characters print as "?". todos are grouped open / done, or
+// under each of their tags like the Markdown export can, which a list's checklist does by default
+
+// page geometry in points, A4
+const (
+	pdfWidth    = 595
+	pdfHeight   = 842
+	pdfMargin   = 56
+	pdfLine     = 18 // line height of a todo
+	pdfDueX     = 440
+	pdfTitleMax = 60 // characters of a title per line, about what fits left of the due column at 11pt
+)
+
+
+// pdfText escapes s for a PDF string in WinAnsi, Latin-1 passing through and the rest as "?"
+func pdfText(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteRune(c)
+		case c >= 0xa0 && c <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", c)
+		case c == '\t' || c == '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+
+// wrapTitle splits a title into lines of at most pdfTitleMax characters, at spaces where it can
+func wrapTitle(title string) []string {
+	var lines []string
+	line := []rune{}
+	for _, word := range strings.Fields(title) {
+		w := []rune(word)
+		for len(w) > pdfTitleMax {
+			if len(line) > 0 {
+				lines, line = append(lines, string(line)), []rune{}
+			}
+			lines, w = append(lines, string(w[:pdfTitleMax])), w[pdfTitleMax:]
+		}
+		switch {
+		case len(line) == 0:
+			line = w
+		case len(line)+1+len(w) <= pdfTitleMax:
+			line = append(append(line, ' '), w...)
+		default:
+			lines, line = append(lines, string(line)), w
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// pdfSection is a heading and its todos
+type pdfSection struct {
+	name  string
+	todos []model.Todo
+}
+
+
+// checklistPDF lays the sections out on as many pages as they need, with due dates in loc
+func checklistPDF(title string, sections []pdfSection, generated time.Time, loc *time.Location) []byte {
+
+	var pages []*bytes.Buffer
+	var page *bytes.Buffer
+	y := 0
+	newPage := func() {
+		page = &bytes.Buffer{}
+		pages = append(pages, page)
+		y = pdfHeight - pdfMargin
+		fmt.Fprintf(page, "0.5 g BT /F1 8 Tf %d %d Td (%s) Tj ET 0 g\n", pdfMargin, pdfMargin/2, pdfText(generated.In(loc).Format("2006-01-02 15:04")))
+	}
+	// room makes sure h more points fit on the page
+	room := func(h int) {
+		if y-h < pdfMargin {
+			newPage()
+		}
+	}
+
+	newPage()
+	y -= 18
+	fmt.Fprintf(page, "BT /F2 18 Tf %d %d Td (%s) Tj ET\n", pdfMargin, y, pdfText(title))
+	y -= 12
+
+	for _, section := range sections {
+		if len(section.todos) == 0 {
+			continue
+		}
+		if section.name != "" {
+			room(36 + pdfLine)
+			y -= 30
+			fmt.Fprintf(page, "BT /F2 13 Tf %d %d Td (%s) Tj ET\n", pdfMargin, y, pdfText(section.name))
+			y -= 6
+		}
+
+		for _, todo := range section.todos {
+			lines := wrapTitle(todo.Title)
+			room(len(lines) * pdfLine)
+			y -= pdfLine
+
+			// the box sits on the first line's baseline, ticked when done
+			fmt.Fprintf(page, "0.8 w %d %d 10 10 re S\n", pdfMargin, y-1)
+			if todo.Done {
+				fmt.Fprintf(page, "1.2 w %d %d m %d %d l %d %d l S\n", pdfMargin+2, y+4, pdfMargin+4, y+1, pdfMargin+9, y+8)
+				page.WriteString("0.45 g\n")
+			}
+			for i, line := range lines {
+				fmt.Fprintf(page, "BT /F1 11 Tf %d %d Td (%s) Tj ET\n", pdfMargin+18, y-i*pdfLine, pdfText(line))
+			}
+			if todo.Due != nil {
+				due := todo.Due.In(loc)
+				format := "2006-01-02 15:04"
+				if due.Hour() == 0 && due.Minute() == 0 {
+					format = "2006-01-02"
+				}
+				fmt.Fprintf(page, "BT /F1 10 Tf %d %d Td (%s) Tj ET\n", pdfDueX, y, pdfText(due.Format(format)))
+			}
+			page.WriteString("0 g\n")
+			y -= (len(lines) - 1) * pdfLine
+		}
+	}
+
+	// objects: 1 catalog, 2 page tree, 3 and 4 fonts, then a page and its contents per page
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+
+// writeChecklistPDF answers with todos as a checklist PDF in sections by ?group=, group when
+// there's none: status (open ones first), tag (one per tag, a todo under each of its own and the
+// untagged last) or none
+func writeChecklistPDF(w http.ResponseWriter, r *http.Request, title, filename, group string, todos []model.Todo) {
+
+	if v := r.URL.Query().Get("group"); v != "" {
+		group = v
+	}
+	if group != "status" && group != "tag" && group != "none" {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, model.Invalid("group", "must be status, tag or none"))
+		return
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, err)
+		return
+	}
+
+	lang := requestLanguage(r)
+	var sections []pdfSection
+	switch group {
+	case "none":
+		sections = []pdfSection{{todos: todos}}
+	case "tag":
+		byTag := map[string][]model.Todo{}
+		untagged := pdfSection{name: translate(lang, "No tag")}
+		for _, todo := range todos {
+			for _, tag := range todo.Tags {
+				byTag[tag] = append(byTag[tag], todo)
+			}
+			if len(todo.Tags) == 0 {
+				untagged.todos = append(untagged.todos, todo)
+			}
+		}
+		for _, tag := range slices.Sorted(maps.Keys(byTag)) {
+			sections = append(sections, pdfSection{name: "#" + tag, todos: byTag[tag]})
+		}
+		sections = append(sections, untagged)
+	default:
+		open, done := pdfSection{name: translate(lang, "Open")}, pdfSection{name: translate(lang, "Done")}
+		for _, todo := range todos {
+			if todo.Done {
+				done.todos = append(done.todos, todo)
+			} else {
+				open.todos = append(open.todos, todo)
+			}
+		}
+		sections = []pdfSection{open, done}
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Write(checklistPDF(title, sections, todoStore.Now(), loc))
+}
+
+
+// download every todo as a printable checklist
+func exportPDFHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeChecklistPDF(w, r, translate(requestLanguage(r), "Todos"), "todos.pdf", "status", todoStore.List(r.Context()))
+}
+
+
+// download a saved filter's todos as a printable checklist, titled with its name
+func filterExportPDFHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// errors are JSON, the PDF sets its own type
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := savedFilterID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	saved, err := savedFilter(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	todos, err := savedFilterTodos(r, saved)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeChecklistPDF(w, r, saved.Name, fmt.Sprintf("filter-%d.pdf", id), "status", todos)
+}
@@ -0,0 +1,414 @@
+package api
+
+import (
+	"archive/zip"   // for the export archive
+	"context"       // for erasing outside a request
+	"crypto/hmac"   // for actor tokens
+	"crypto/sha256" // for their signature
+	"encoding/hex"  // for their text
+	"encoding/json" // for JSON responses and the archive's data
+	"fmt"           // for printing logs to terminal and errors
+	"io"            // for copying attachments into the archive
+	"mime"          // for the archive's file name
+	"net/http"      // for HTTP handlers
+	"sort"          // for the scheduler's order
+	"strings"       // for actor names
+	"sync"          // for guarding the erasures
+	"time"          // for the grace period
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the actor's data
+)
+
+// an actor's data, for handing over and forgetting (GDPR articles 15, 17 and 20). the actor is the
+// Todo-Actor name, there being no accounts: their data is the todos they created with their
+// attachments, every change they made, their inbox and their streak. GET /me/export zips it up;
+// DELETE /me erases it after -erasure-grace, which the actor can call off until then. erasing
+// deletes their todos and renames them to "erased" wherever their changes are still recorded.
+// Todo-Actor is only a name anyone can send, so these endpoints also want the actor's token in
+// Todo-Actor-Token, signed with -actor-secret and handed out by the admin server. the pending
+// erasures are kept in -erasures-file, so a restart doesn't call them off
+var (
+	erasureGrace = flags.Duration("erasure-grace", 30*24*time.Hour, "how long after DELETE /me an actor's data is erased, during which they can cancel")
+	actorSecret  = flags.String("actor-secret", "", "secret the actor tokens of the /me endpoints are signed with (random per process when empty)")
+	erasuresFile = flags.String("erasures-file", "", "JSON file the pending erasures are kept in, so a restart doesn't drop them (in memory when empty)")
+)
+
+// header carrying the actor's token, see actorToken
+const actorTokenHeader = "Todo-Actor-Token"
+
+// effective actor token key, set by loadActorSecret
+var actorKey []byte
+
+// what an erased actor is called from then on
+const erasedActor = "erased"
+
+// how often the scheduler looks for erasures that are due
+const erasureCheckEvery = time.Minute
+
+// Erasure is a pending DELETE /me
+type Erasure struct {
+	Actor       string    `json:"actor"`
+	RequestedAt time.Time `json:"requested_at"`
+	EraseAt     time.Time `json:"erase_at"`
+}
+
+// ExportedAttachment is an attachment in the archive, its bytes at Path
+type ExportedAttachment struct {
+	Attachment
+	Path string `json:"path"` // in the zip
+}
+
+// PersonalData is data.json in the archive of GET /me/export
+type PersonalData struct {
+	Actor         string               `json:"actor"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Todos         []model.Todo         `json:"todos"`     // created by the actor
+	Revisions     []model.Revision     `json:"revisions"` // every change they made that's still kept, to any todo
+	Attachments   []ExportedAttachment `json:"attachments"`
+	Notifications []Mention            `json:"notifications"`
+	Streak        Streak               `json:"streak"`
+	Erasure       *Erasure             `json:"erasure,omitempty"` // pending
+}
+
+// pending erasures by lowercased actor name, same in-memory pattern as the inboxes
+var erasures = make(map[string]Erasure)
+var erasuresMu sync.Mutex
+
+
+// loadActorSecret picks the actor token signing key
+func loadActorSecret() {
+	if *actorSecret != "" {
+		actorKey = []byte(*actorSecret)
+		return
+	}
+	actorKey = []byte(randomHex(32))
+}
+
+
+// actorToken signs an actor's name, whatever its case
+func actorToken(actor string) string {
+	mac := hmac.New(sha256.New, actorKey)
+	mac.Write([]byte("actor:" + strings.ToLower(actor)))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+
+// tokenActor is the requesting actor, if Todo-Actor-Token proves the request is theirs; otherwise
+// it answers 401 and ok=false
+func tokenActor(w http.ResponseWriter, r *http.Request) (string, bool) {
+
+	actor, err := requestActor(r)
+	if err != nil {
+		writeError(w, r, err)
+		return "", false
+	}
+	if !hmac.Equal([]byte(r.Header.Get(actorTokenHeader)), []byte(actorToken(actor))) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "the actor's " + actorTokenHeader + " is missing or wrong"})
+		return "", false
+	}
+	return actor, true
+}
+
+
+// loadErasures reads the pending erasures -erasures-file keeps
+func loadErasures() error {
+
+	if *erasuresFile == "" {
+		return nil
+	}
+	var stored []Erasure
+	if _, err := readStateFile(*erasuresFile, &stored); err != nil {
+		return fmt.Errorf("-erasures-file: %w", err)
+	}
+
+	erasuresMu.Lock()
+	defer erasuresMu.Unlock()
+	erasures = make(map[string]Erasure)
+	for _, e := range stored {
+		erasures[strings.ToLower(e.Actor)] = e
+	}
+	return nil
+}
+
+
+// saveErasures writes the pending erasures to -erasures-file, see statefile.go (caller holds
+// erasuresMu)
+func saveErasures() {
+
+	if *erasuresFile == "" {
+		return
+	}
+	stored := make([]Erasure, 0, len(erasures))
+	for _, e := range erasures {
+		stored = append(stored, e)
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].EraseAt.Before(stored[j].EraseAt) })
+	if err := writeStateFile(*erasuresFile, stored); err != nil {
+		fmt.Println("erasures: saving", *erasuresFile, "failed:", err)
+	}
+}
+
+
+// personalData collects actor's data, the streak counted in loc
+func personalData(ctx context.Context, actor string, loc *time.Location) PersonalData {
+
+	key := strings.ToLower(actor)
+	data := PersonalData{
+		Actor:         actor,
+		ExportedAt:    todoStore.Now(),
+		Todos:         todoStore.CreatedBy(ctx, actor),
+		Revisions:     todoStore.RevisionsBy(ctx, actor),
+		Attachments:   []ExportedAttachment{},
+		Notifications: []Mention{},
+	}
+	for _, todo := range data.Todos {
+		for _, a := range todoAttachments(todo.ID) {
+			data.Attachments = append(data.Attachments, ExportedAttachment{Attachment: a, Path: fmt.Sprintf("attachments/%d/%s", a.ID, a.Name)})
+		}
+	}
+
+	inboxesMu.Lock()
+	for _, m := range inboxes[key] {
+		data.Notifications = append(data.Notifications, *m)
+	}
+	inboxesMu.Unlock()
+
+	streaksMu.Lock()
+	s := streaks[key]
+	if s == nil {
+		s = &actorStreak{hours: map[int64]int{}}
+	}
+	data.Streak = streakOf(actor, s, data.ExportedAt, loc)
+	streaksMu.Unlock()
+
+	erasuresMu.Lock()
+	if e, ok := erasures[key]; ok {
+		data.Erasure = &e
+	}
+	erasuresMu.Unlock()
+	return data
+}
+
+
+// eraseActor forgets actor: deletes their todos, attachments with them, and renames them to
+// erasedActor in the revisions and change log, and in the inboxes of those they mentioned; an
+// error (e.g. maintenance) leaves it all as it was
+func eraseActor(actor string) error {
+
+	ctx := store.WithActor(context.Background(), store.SystemActor)
+	key := strings.ToLower(actor)
+	deleted, renamed, err := todoStore.EraseActor(ctx, actor, erasedActor)
+	if err != nil {
+		return err
+	}
+	for _, id := range deleted {
+		dropAttachments(ctx, id)
+	}
+
+	inboxesMu.Lock()
+	delete(inboxes, key)
+	for _, inbox := range inboxes {
+		for _, m := range inbox {
+			if strings.EqualFold(m.By, actor) {
+				m.By, m.Title = erasedActor, ""
+			}
+		}
+	}
+	inboxesMu.Unlock()
+	streaksMu.Lock()
+	delete(streaks, key)
+	streaksMu.Unlock()
+
+	fmt.Printf("erased actor: %d todos deleted, %d changes renamed\n", len(deleted), renamed)
+	return nil
+}
+
+
+// eraseDue erases the actors whose grace period is over by now; each erasure stays pending until
+// it's done, so a crash meanwhile leaves it to do again after the restart
+func eraseDue(now time.Time) {
+
+	erasuresMu.Lock()
+	var due []Erasure
+	for _, e := range erasures {
+		if !e.EraseAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	erasuresMu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].EraseAt.Before(due[j].EraseAt) })
+	for _, e := range due {
+		if err := eraseActor(e.Actor); err != nil {
+			fmt.Println("erasures: not erasing yet:", err)
+			continue
+		}
+
+		erasuresMu.Lock()
+		delete(erasures, strings.ToLower(e.Actor))
+		saveErasures()
+		erasuresMu.Unlock()
+	}
+}
+
+
+// startErasures erases actors once their grace period is over
+func startErasures() {
+	go func() {
+		for range time.Tick(erasureCheckEvery) {
+			if scheduling() {
+				eraseDue(todoStore.Now())
+			}
+		}
+	}()
+}
+
+
+// GET /me/export downloads the requesting actor's data as a zip: data.json (a PersonalData) and
+// the files attached to their todos
+func exportMeHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := tokenActor(w, r)
+	if !ok {
+		return
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	data := personalData(r.Context(), actor, loc)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "todo-data-" + actor + ".zip"}))
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	// the status is out once the zip starts, so a failure after it cuts the archive short
+	archive := zip.NewWriter(w)
+	f, err := archive.Create("data.json")
+	if err != nil {
+		fmt.Println("exporting actor data:", err)
+		return
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+
+	for _, a := range data.Attachments {
+		body, err := blobs.Get(r.Context(), a.key)
+		if err != nil {
+			fmt.Println("exporting attachment:", err)
+			return
+		}
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: a.Path, Method: zip.Deflate, Modified: a.CreatedAt})
+		if err == nil {
+			_, err = io.Copy(f, body)
+		}
+		body.Close()
+		if err != nil {
+			fmt.Println("exporting attachment:", err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		fmt.Println("exporting actor data:", err)
+	}
+}
+
+
+// DELETE /me schedules the requesting actor's erasure after -erasure-grace (again: the pending one)
+func deleteMeHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	actor, ok := tokenActor(w, r)
+	if !ok {
+		return
+	}
+	if strings.EqualFold(actor, store.SystemActor) || strings.EqualFold(actor, erasedActor) {
+		writeError(w, r, model.Invalid("Todo-Actor", "can't be erased"))
+		return
+	}
+
+	erasuresMu.Lock()
+	key := strings.ToLower(actor)
+	e, pending := erasures[key]
+	if !pending {
+		now := todoStore.Now()
+		e = Erasure{Actor: actor, RequestedAt: now, EraseAt: now.Add(*erasureGrace)}
+		erasures[key] = e
+		saveErasures()
+	}
+	erasuresMu.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(e)
+}
+
+
+// GET /me/erasure returns the requesting actor's pending erasure, DELETE calls it off
+func erasureHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	actor, ok := tokenActor(w, r)
+	if !ok {
+		return
+	}
+
+	erasuresMu.Lock()
+	key := strings.ToLower(actor)
+	e, pending := erasures[key]
+	if r.Method == http.MethodDelete && pending {
+		delete(erasures, key)
+		saveErasures()
+	}
+	erasuresMu.Unlock()
+
+	switch {
+	case !pending:
+		writeError(w, r, fmt.Errorf("erasure of %s: %w", actor, model.ErrNotFound))
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		json.NewEncoder(w).Encode(e)
+	}
+}
+
+
+// admin: GET /admin/actors/{actor}/token is the token the actor's /me requests need
+func actorTokenHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	actor := strings.TrimSpace(r.PathValue("actor"))
+	json.NewEncoder(w).Encode(map[string]string{"actor": actor, "token": actorToken(actor)})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"encoding/binary" // for varints
+	"errors"          // for decode errors
+	"maps"            // for the tags' counts
+	"slices"          // for the tags in order
+	"time"            // for timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and events
+)
+
+// hand written protobuf encoding for the messages in proto/todo.proto.
+// only the wire types we use are supported: varint (0) and length-delimited (2).
+
+// protobuf wire types
+const (
+	protoVarint = 0
+	protoBytes  = 2
+)
+
+// protoField is one decoded field
+type protoField struct {
+	num      int    // field number
+	wireType int    // protoVarint or protoBytes
+	varint   uint64 // value for varint fields
+	bytes    []byte // value for length-delimited fields
+}
+
+
+// appendProtoVarint appends a varint field (zero values are skipped, like proto3)
+func appendProtoVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(num)<<3|protoVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+
+// appendProtoBytes appends a length-delimited field (string, bytes or sub-message)
+func appendProtoBytes(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+
+// decodeProto splits a message into its fields
+func decodeProto(b []byte) ([]protoField, error) {
+
+	var fields []protoField
+	for len(b) > 0 {
+
+		// tag = field number << 3 | wire type
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("proto: bad tag")
+		}
+		b = b[n:]
+		f := protoField{num: int(tag >> 3), wireType: int(tag & 7)}
+
+		switch f.wireType {
+		case protoVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("proto: bad varint")
+			}
+			f.varint = v
+			b = b[n:]
+
+		case protoBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errors.New("proto: bad length")
+			}
+			f.bytes = b[n : n+int(l)]
+			b = b[n+int(l):]
+
+		default:
+			return nil, errors.New("proto: unsupported wire type")
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+
+// marshalTodoProto encodes a todo.v1.Todo
+func marshalTodoProto(t model.Todo) []byte {
+
+	var b []byte
+	b = appendProtoVarint(b, 1, uint64(t.ID))
+	if t.Title != "" {
+		b = appendProtoBytes(b, 2, []byte(t.Title))
+	}
+	if t.Done {
+		b = appendProtoVarint(b, 3, 1)
+	}
+	if t.Due != nil {
+		b = appendProtoBytes(b, 4, marshalTimestampProto(*t.Due))
+	}
+	return b
+}
+
+
+// marshalTimestampProto encodes a google.protobuf.Timestamp
+func marshalTimestampProto(t time.Time) []byte {
+
+	var b []byte
+	b = appendProtoVarint(b, 1, uint64(t.Unix())) // negative seconds are 10 byte varints, as in proto
+	return appendProtoVarint(b, 2, uint64(t.Nanosecond()))
+}
+
+
+// unmarshalTimestampProto decodes a google.protobuf.Timestamp
+func unmarshalTimestampProto(b []byte) (time.Time, error) {
+
+	fields, err := decodeProto(b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var seconds, nanos int64
+	for _, f := range fields {
+		switch {
+		case f.num == 1 && f.wireType == protoVarint:
+			seconds = int64(f.varint)
+		case f.num == 2 && f.wireType == protoVarint:
+			nanos = int64(int32(f.varint))
+		}
+	}
+	if nanos < 0 || nanos > 999999999 {
+		return time.Time{}, errors.New("proto: timestamp nanos out of range")
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+
+// unmarshalCreateTodoProto decodes a todo.v1.CreateTodoRequest
+func unmarshalCreateTodoProto(b []byte) (CreateTodoRequest, error) {
+
+	var req CreateTodoRequest
+	fields, err := decodeProto(b)
+	if err != nil {
+		return req, err
+	}
+	for _, f := range fields {
+		switch {
+		case f.num == 1 && f.wireType == protoBytes:
+			req.Title = string(f.bytes)
+		case f.num == 2 && f.wireType == protoBytes:
+			due, err := unmarshalTimestampProto(f.bytes)
+			if err != nil {
+				return req, err
+			}
+			req.Due = &due
+		}
+	}
+	return req, nil
+}
+
+// updateTodoProto is a todo.v1.UpdateTodoRequest, each field nil when the call leaves it as is
+type updateTodoProto struct {
+	ID       int
+	Title    *string
+	Done     *bool
+	Due      *time.Time
+	ClearDue bool
+}
+
+
+// apply makes the change on t
+func (u updateTodoProto) apply(t *model.Todo) {
+	if u.Title != nil {
+		t.Title = *u.Title
+	}
+	if u.Done != nil {
+		t.Done = *u.Done
+	}
+	switch {
+	case u.ClearDue:
+		t.Due = nil
+	case u.Due != nil:
+		t.Due = u.Due
+	}
+}
+
+
+// unmarshalUpdateTodoProto decodes a todo.v1.UpdateTodoRequest
+func unmarshalUpdateTodoProto(b []byte) (updateTodoProto, error) {
+
+	var req updateTodoProto
+	fields, err := decodeProto(b)
+	if err != nil {
+		return req, err
+	}
+	for _, f := range fields {
+		switch {
+		case f.num == 1 && f.wireType == protoVarint:
+			req.ID = int(f.varint)
+		case f.num == 2 && f.wireType == protoBytes:
+			title := string(f.bytes)
+			req.Title = &title
+		case f.num == 3 && f.wireType == protoVarint:
+			done := f.varint != 0
+			req.Done = &done
+		case f.num == 4 && f.wireType == protoBytes:
+			due, err := unmarshalTimestampProto(f.bytes)
+			if err != nil {
+				return req, err
+			}
+			req.Due = &due
+		case f.num == 5 && f.wireType == protoVarint:
+			req.ClearDue = f.varint != 0
+		}
+	}
+	return req, nil
+}
+
+
+// marshalStatsProto encodes a todo.v1.TodoStats
+func marshalStatsProto(s TodoStats) []byte {
+
+	var b []byte
+	b = appendProtoVarint(b, 1, uint64(s.Total))
+	b = appendProtoVarint(b, 2, uint64(s.Open))
+	b = appendProtoVarint(b, 3, uint64(s.Done))
+	b = appendProtoVarint(b, 4, uint64(s.WithDue))
+
+	// a map field is a repeated entry of key (1) and value (2), in tag order to keep the bytes stable
+	for _, tag := range slices.Sorted(maps.Keys(s.Tags)) {
+		var counts, entry []byte
+		counts = appendProtoVarint(counts, 1, uint64(s.Tags[tag].Total))
+		counts = appendProtoVarint(counts, 2, uint64(s.Tags[tag].Open))
+		counts = appendProtoVarint(counts, 3, uint64(s.Tags[tag].Done))
+		entry = appendProtoBytes(entry, 1, []byte(tag))
+		entry = appendProtoBytes(entry, 2, counts)
+		b = appendProtoBytes(b, 5, entry)
+	}
+	return b
+}
+
+
+// marshalTodoListProto encodes a todo.v1.ListTodosResponse
+func marshalTodoListProto(list []model.Todo) []byte {
+
+	var b []byte
+	for _, t := range list {
+		b = appendProtoBytes(b, 1, marshalTodoProto(t))
+	}
+	return b
+}
+
+
+// marshalEventProto encodes a todo.v1.TodoEvent
+func marshalEventProto(e model.Event) []byte {
+
+	var b []byte
+	b = appendProtoBytes(b, 1, []byte(e.Type))
+	b = appendProtoBytes(b, 2, marshalTodoProto(e.Todo))
+	b = appendProtoVarint(b, 3, uint64(e.Time.UnixNano()))
+	return b
+}
+
+
+// protoIntField returns field num of a message as an int (0 if absent)
+func protoIntField(b []byte, num int) (int, error) {
+
+	fields, err := decodeProto(b)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range fields {
+		if f.num == num && f.wireType == protoVarint {
+			return int(f.varint), nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/hex" // for the expected bytes
+	"testing"      // for the tests
+	"time"         // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// protobuf tests: the wire bytes of proto/todo.proto messages, and todos and requests read back
+
+
+// unmarshalTodoProtoTest decodes a todo.v1.Todo, which the server only ever writes
+func unmarshalTodoProtoTest(t *testing.T, b []byte) model.Todo {
+
+	t.Helper()
+	fields, err := decodeProto(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var todo model.Todo
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			todo.ID = int(f.varint)
+		case 2:
+			todo.Title = string(f.bytes)
+		case 3:
+			todo.Done = f.varint != 0
+		case 4:
+			due, err := unmarshalTimestampProto(f.bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			todo.Due = &due
+		}
+	}
+	return todo
+}
+
+
+// TestMarshalTodoProto checks the wire bytes of todos, zero fields left out as in proto3
+func TestMarshalTodoProto(t *testing.T) {
+
+	due := time.Unix(1700000000, 5).UTC()
+	tests := []struct {
+		name string
+		todo model.Todo
+		want string // hex
+	}{
+		{"zero", model.Todo{}, ""},
+		{"id", model.Todo{ID: 150}, "089601"},
+		{"id and title", model.Todo{ID: 1, Title: "a"}, "08011201" + "61"},
+		{"done", model.Todo{ID: 2, Done: true}, "08021801"},
+		{"due", model.Todo{ID: 3, Due: &due}, "0803" + "2208" + "08" + "80e2cfaa06" + "1005"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(marshalTodoProto(tt.todo)); got != tt.want {
+			t.Errorf("%s: marshalTodoProto = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+
+// TestTodoProtoRoundTrip checks a todo comes back the same from its encoding
+func TestTodoProtoRoundTrip(t *testing.T) {
+
+	at := func(s string) *time.Time {
+		v, _ := time.Parse(time.RFC3339Nano, s)
+		return &v
+	}
+	tests := []model.Todo{
+		{ID: 1, Title: "buy milk"},
+		{ID: 1 << 40, Title: "café ☕ 日本", Done: true},
+		{ID: 7, Title: "with due", Due: at("2026-10-14T09:30:00Z")},
+		{ID: 8, Title: "nanoseconds", Due: at("2026-10-14T09:30:00.123456789Z")},
+		{ID: 9, Title: "before 1970", Due: at("1969-07-20T20:17:40Z")},
+		{ID: 10, Title: "epoch", Due: at("1970-01-01T00:00:00Z")},
+	}
+	for _, want := range tests {
+		got := unmarshalTodoProtoTest(t, marshalTodoProto(want))
+		if got.ID != want.ID || got.Title != want.Title || got.Done != want.Done ||
+			(got.Due == nil) != (want.Due == nil) || got.Due != nil && !got.Due.Equal(*want.Due) {
+			t.Errorf("round trip of %+v = %+v", want, got)
+		}
+	}
+}
+
+
+// TestDecodeProtoErrors checks truncated and unsupported fields are turned away
+func TestDecodeProtoErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		msg  string // hex
+	}{
+		{"truncated tag", "80"},
+		{"truncated varint", "08"},
+		{"varint too long", "08ffffffffffffffffffff01"},
+		{"length past the end", "120561"},
+		{"truncated length", "12"},
+		{"fixed64", "090000000000000000"},
+		{"fixed32", "0d00000000"},
+		{"group", "0b"},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.msg)
+		if _, err := decodeProto(b); err == nil {
+			t.Errorf("%s: decodeProto(%s) succeeded", tt.name, tt.msg)
+		}
+	}
+}
+
+
+// TestUnmarshalTimestampProto checks out of range nanos are turned away
+func TestUnmarshalTimestampProto(t *testing.T) {
+
+	tests := []struct {
+		msg  string // hex
+		want time.Time
+		ok   bool
+	}{
+		{"", time.Unix(0, 0).UTC(), true},
+		{"08011002", time.Unix(1, 2).UTC(), true},
+		{"ffff", time.Time{}, false},
+		{"1080a8d6b907", time.Time{}, false}, // 1e9
+		{"10ffffffff0f", time.Time{}, false}, // -1 as an int32
+		{"08ffffffffffffffffff01", time.Unix(-1, 0).UTC(), true},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.msg)
+		got, err := unmarshalTimestampProto(b)
+		if (err == nil) != tt.ok || tt.ok && !got.Equal(tt.want) {
+			t.Errorf("unmarshalTimestampProto(%s) = %v, %v; want %v", tt.msg, got, err, tt.want)
+		}
+	}
+}
+
+
+// TestUnmarshalCreateTodoProto checks a todo.v1.CreateTodoRequest, unknown fields skipped
+func TestUnmarshalCreateTodoProto(t *testing.T) {
+
+	b, _ := hex.DecodeString("0a08627579206d696c6b" + "1206" + "0880e2cfaa06" + "1801" + "2a0178")
+	req, err := unmarshalCreateTodoProto(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Title != "buy milk" || req.Due == nil || !req.Due.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unmarshalCreateTodoProto = %+v", req)
+	}
+
+	bad, _ := hex.DecodeString("1203" + "1080a8")
+	if _, err := unmarshalCreateTodoProto(bad); err == nil {
+		t.Error("a broken due was accepted")
+	}
+}
+
+
+// TestUpdateTodoProto checks which fields an update sets, an explicit zero included
+func TestUpdateTodoProto(t *testing.T) {
+
+	due := time.Unix(1700000000, 0).UTC()
+	orig := func() model.Todo {
+		d := time.Unix(1600000000, 0).UTC()
+		return model.Todo{ID: 7, Title: "old", Done: true, Due: &d}
+	}
+	tests := []struct {
+		name  string
+		msg   string // hex
+		check func(model.Todo) bool
+	}{
+		{"only the id", "0807", func(t model.Todo) bool { return t.Title == "old" && t.Done && t.Due != nil }},
+		{"title", "08071203616263", func(t model.Todo) bool { return t.Title == "abc" && t.Done }},
+		{"empty title", "08071200", func(t model.Todo) bool { return t.Title == "" }},
+		{"not done", "08071800", func(t model.Todo) bool { return !t.Done && t.Title == "old" }},
+		{"new due", "080722060880e2cfaa06", func(t model.Todo) bool { return t.Due != nil && t.Due.Equal(due) }},
+		{"clear due", "08072801", func(t model.Todo) bool { return t.Due == nil }},
+		{"clear due wins", "080722060880e2cfaa062801", func(t model.Todo) bool { return t.Due == nil }},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.msg)
+		u, err := unmarshalUpdateTodoProto(b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if u.ID != 7 {
+			t.Errorf("%s: id %d, want 7", tt.name, u.ID)
+		}
+		todo := orig()
+		u.apply(&todo)
+		if !tt.check(todo) {
+			t.Errorf("%s: got %+v", tt.name, todo)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"io"       // for reading protobuf bodies
+	"mime"     // for Content-Type parsing
+	"net/http" // for HTTP handlers
+)
+
+// protobuf bodies on the REST endpoints, with the messages from proto/todo.proto:
+// Todo, ListTodosResponse (GET /todos), CreateTodoRequest and TodoStats
+
+// media type of protobuf bodies (application/protobuf is accepted too)
+const protoMediaType = "application/x-protobuf"
+
+// largest protobuf request body we read
+const protoMaxBody = 1 << 20
+
+
+// wantsProto reports whether Accept names protobuf and doesn't rank JSON higher
+func wantsProto(r *http.Request) bool {
+
+	accept := r.Header.Get("Accept")
+	jsonQ, _ := acceptQuality(accept, "application/json")
+	for _, name := range []string{protoMediaType, "application/protobuf"} {
+		if q, named := acceptQuality(accept, name); named && q > 0 && q >= jsonQ {
+			return true
+		}
+	}
+	return false
+}
+
+
+// sentProto reports whether the request body is protobuf
+func sentProto(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == protoMediaType || mediaType == "application/protobuf"
+}
+
+
+// writeProto sends an encoded message
+func writeProto(w http.ResponseWriter, msg []byte) {
+	w.Header().Set("Content-Type", protoMediaType)
+	w.Write(msg)
+}
+
+
+// readCreateTodoRequest decodes a create body in JSON or, by Content-Type, protobuf
+func readCreateTodoRequest(w http.ResponseWriter, r *http.Request) (CreateTodoRequest, error) {
+
+	var req CreateTodoRequest
+	if !sentProto(r) {
+		err := decodeBody(r, "todo-create", &req)
+		return req, bodyError(err)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, protoMaxBody))
+	if err != nil {
+		return req, err
+	}
+	return unmarshalCreateTodoProto(body)
+}
@@ -0,0 +1,525 @@
+package api
+
+import (
+	"context"       // for the store calls outside a request
+	"encoding/json" // for JSON encode/decode
+	"errors"        // for telling failures worth another try
+	"fmt"           // for printing logs to terminal and errors
+	"net/http"      // for HTTP handlers
+	"sort"          // for stable list order
+	"strconv"       // for the id in the path
+	"strings"       // for trimming titles
+	"sync"          // for guarding the rules
+	"time"          // for the schedules
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the actor and tenant
+)
+
+// recurring todos: a rule makes a todo at every time of a cron expression (see cron.go), read in
+// its time zone. a time the server missed, more than a minute late because it was down or the
+// store's backend was out of reach, is made up for by the rule's catch-up policy once it can. the
+// rules run, like scheduled todos, on the server running the scheduled jobs (see scheduler.go),
+// so each time is made once; another server that has some hands them over to it (handoff.go),
+// when they last ran with them. -recurring-file keeps them and when each last ran across restarts,
+// so the runs missed while the server was down are caught up when it's back. without it they're
+// in memory and go with a restart
+var recurringFile = flags.String("recurring-file", "", "JSON file the recurring todos are kept in, so runs missed while the server was down are caught up (in memory when empty)")
+
+// the catch-up policies, for the times a rule missed
+const (
+	catchUpSkip   = "skip"   // make nothing for them, carry on with the next time
+	catchUpLatest = "latest" // make one todo, for the latest of them
+	catchUpAll    = "all"    // make one for each, up to maxCatchUp
+)
+
+const (
+	recurrenceCheckEvery = time.Second
+	recurrenceGrace      = time.Minute // how late a time may be made and not count as missed
+	maxCatchUp           = 100         // most todos made for one rule's missed times
+	defaultPreview       = 5
+	maxPreview           = 100
+)
+
+// Recurrence is a todo made again at each time of a cron schedule
+type Recurrence struct {
+	ID        int        `json:"id"`
+	Title     string     `json:"title"`
+	Cron      string     `json:"cron"`                // e.g. "0 9 * * mon-fri" or "@weekly"
+	Timezone  string     `json:"timezone"`            // IANA name the schedule's times are read in
+	DueAfter  string     `json:"due_after,omitempty"` // each todo is due this long after its time, e.g. "8h"
+	CatchUp   string     `json:"catch_up"`            // skip, latest or all
+	By        string     `json:"by,omitempty"`        // who made the rule, and its todos
+	CreatedAt time.Time  `json:"created_at"`
+	LastRun   *time.Time `json:"last_run,omitempty"` // the latest time taken care of, made or skipped
+	NextRun   *time.Time `json:"next_run,omitempty"` // none when the schedule has no more times
+	Made      int        `json:"made"`               // todos made so far
+	LastTodo  int        `json:"last_todo,omitempty"`
+	LastError string     `json:"last_error,omitempty"` // why the latest time is late
+
+	tenant   string // whose rule it is, "" for the default tenant, see tenants.go
+	schedule cronSchedule
+	dueAfter time.Duration
+}
+
+// storedRecurrence is a rule as -recurring-file keeps it, with its tenant
+type storedRecurrence struct {
+	Recurrence
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// RecurrenceRequest represents input body for creating a recurring todo
+type RecurrenceRequest struct {
+	Title    string `json:"title"`
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"`  // defaults to the client's
+	DueAfter string `json:"due_after"` // a duration, no due when empty
+	CatchUp  string `json:"catch_up"`  // defaults to latest
+}
+
+// RecurrencePreview is the body of the preview endpoints
+type RecurrencePreview struct {
+	Cron     string      `json:"cron"`
+	Timezone string      `json:"timezone"`
+	Next     []time.Time `json:"next"`
+}
+
+// recurring todos, same in-memory pattern as saved filters
+var recurrences = make(map[int]Recurrence)
+var recurrencesMu sync.Mutex
+var nextRecurrenceID = 1
+
+
+// compileRecurrence parses a rule's schedule and due offset
+func compileRecurrence(rec *Recurrence) error {
+
+	loc, err := time.LoadLocation(rec.Timezone)
+	if err != nil {
+		return model.Invalid("timezone", "is not a time zone name (like Europe/Berlin)")
+	}
+	if rec.schedule, err = parseCron(rec.Cron, loc); err != nil {
+		return model.Invalid("cron", err.Error())
+	}
+	rec.dueAfter = 0
+	if rec.DueAfter != "" {
+		if rec.dueAfter, err = time.ParseDuration(rec.DueAfter); err != nil || rec.dueAfter < 0 {
+			return model.Invalid("due_after", "is not a duration like 8h or 30m")
+		}
+	}
+	switch rec.CatchUp {
+	case catchUpSkip, catchUpLatest, catchUpAll:
+	default:
+		return model.Invalid("catch_up", "must be skip, latest or all")
+	}
+	return nil
+}
+
+
+// planNext sets when the rule runs next: after its last run, or its creation
+func planNext(rec *Recurrence) {
+
+	from := rec.CreatedAt
+	if rec.LastRun != nil {
+		from = *rec.LastRun
+	}
+	rec.NextRun = nil
+	if at, ok := rec.schedule.next(from); ok {
+		rec.NextRun = &at
+	}
+}
+
+
+// loadRecurring reads the rules -recurring-file keeps; a file not made yet has none
+func loadRecurring() error {
+
+	if *recurringFile == "" {
+		return nil
+	}
+	var stored []storedRecurrence
+	if _, err := readStateFile(*recurringFile, &stored); err != nil {
+		return fmt.Errorf("-recurring-file: %w", err)
+	}
+
+	recurrencesMu.Lock()
+	defer recurrencesMu.Unlock()
+	recurrences, nextRecurrenceID = make(map[int]Recurrence), 1
+	for _, s := range stored {
+		rec := s.Recurrence
+		rec.tenant = s.Tenant
+		if err := compileRecurrence(&rec); err != nil {
+			return fmt.Errorf("-recurring-file: rule %d: %w", rec.ID, err)
+		}
+		planNext(&rec)
+		recurrences[rec.ID] = rec
+		nextRecurrenceID = max(nextRecurrenceID, rec.ID+1)
+	}
+	return nil
+}
+
+
+// saveRecurring writes the rules to -recurring-file, see statefile.go (caller holds recurrencesMu)
+func saveRecurring() {
+
+	if *recurringFile == "" {
+		return
+	}
+	stored := make([]storedRecurrence, 0, len(recurrences))
+	for _, rec := range recurrences {
+		stored = append(stored, storedRecurrence{Recurrence: rec, Tenant: rec.tenant})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	if err := writeStateFile(*recurringFile, stored); err != nil {
+		fmt.Println("recurring todos: saving", *recurringFile, "failed:", err)
+	}
+}
+
+
+// recurrence looks up one of the ctx tenant's rules, model.ErrNotFound if there is none
+func recurrence(ctx context.Context, id int) (Recurrence, error) {
+	recurrencesMu.Lock()
+	defer recurrencesMu.Unlock()
+
+	rec, ok := recurrences[id]
+	if !ok || rec.tenant != store.TenantFrom(ctx) {
+		return Recurrence{}, fmt.Errorf("recurring todo %d: %w", id, model.ErrNotFound)
+	}
+	return rec, nil
+}
+
+
+// receiveRecurrence keeps a rule another server handed over, under an id of this one's
+func receiveRecurrence(item json.RawMessage) error {
+
+	var s storedRecurrence
+	if err := json.Unmarshal(item, &s); err != nil {
+		return err
+	}
+	rec := s.Recurrence
+	rec.tenant, rec.LastError = s.Tenant, ""
+	if err := compileRecurrence(&rec); err != nil {
+		return err
+	}
+
+	recurrencesMu.Lock()
+	defer recurrencesMu.Unlock()
+	rec.ID = nextRecurrenceID
+	recurrences[rec.ID] = rec
+	nextRecurrenceID++
+	saveRecurring()
+	return nil
+}
+
+
+// handOffRecurring hands every rule to the server running the scheduled jobs; those it can't are
+// kept, their last_error saying why
+func handOffRecurring() {
+
+	recurrencesMu.Lock()
+	list := make([]Recurrence, 0, len(recurrences))
+	for _, rec := range recurrences {
+		list = append(list, rec)
+	}
+	recurrencesMu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	items := make([]json.RawMessage, len(list))
+	for i, rec := range list {
+		items[i], _ = json.Marshal(storedRecurrence{Recurrence: rec, Tenant: rec.tenant})
+	}
+	err := handOff("recurring", items)
+
+	recurrencesMu.Lock()
+	defer recurrencesMu.Unlock()
+	for _, rec := range list {
+		current, ok := recurrences[rec.ID]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			current.LastError = "waiting to hand over to the server making recurring todos: " + err.Error()
+			recurrences[rec.ID] = current
+			continue
+		}
+		delete(recurrences, rec.ID)
+	}
+	saveRecurring()
+}
+
+
+// startRecurring makes the recurring todos as their times come, while this server runs the
+// scheduled jobs, and hands the rules over while another one does
+func startRecurring() {
+	go func() {
+		for range time.Tick(recurrenceCheckEvery) {
+			if !scheduling() {
+				handOffRecurring()
+				continue
+			}
+			takeHandoffs("recurring")
+			runRecurring(todoStore.Now())
+		}
+	}()
+}
+
+
+// runRecurring runs every rule with a time due by now
+func runRecurring(now time.Time) {
+
+	recurrencesMu.Lock()
+	due := []Recurrence{}
+	for _, rec := range recurrences {
+		if rec.NextRun != nil && !rec.NextRun.After(now) {
+			due = append(due, rec)
+		}
+	}
+	recurrencesMu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+
+	for _, rec := range due {
+		runRecurrence(rec, now)
+	}
+}
+
+
+// runRecurrence makes a rule's todos for its times up to now, as its catch-up policy says for the
+// missed ones. a time it couldn't make for now is left for the next check
+func runRecurrence(rec Recurrence, now time.Time) {
+
+	// every time due, keeping the latest maxCatchUp
+	times := []time.Time{}
+	dropped := 0
+	for at, ok := *rec.NextRun, true; ok && !at.After(now); at, ok = rec.schedule.next(at) {
+		if len(times) == maxCatchUp {
+			times, dropped = times[1:], dropped+1
+		}
+		times = append(times, at)
+	}
+	missed := 0
+	for _, at := range times {
+		if now.Sub(at) > recurrenceGrace {
+			missed++
+		}
+	}
+	missed += dropped
+
+	ctx := store.WithTenant(store.WithActor(context.Background(), rec.By), rec.tenant)
+	var handled *time.Time
+	var failed error
+	made, lastTodo := 0, 0
+	for i, at := range times {
+		late := now.Sub(at) > recurrenceGrace
+		if (rec.CatchUp == catchUpSkip && late) || (rec.CatchUp == catchUpLatest && i < len(times)-1) {
+			handled = &at
+			continue
+		}
+
+		draft := model.Todo{Title: rec.Title}
+		if rec.DueAfter != "" {
+			due := at.Add(rec.dueAfter)
+			draft.Due = &due
+		}
+		todo, err := todoStore.Create(ctx, draft)
+		if errors.Is(err, model.ErrUnavailable) {
+			failed = err
+			break
+		}
+		if err != nil {
+			fmt.Println("recurring todos: rule", rec.ID, "skipping", at.Format(time.RFC3339), "the store refused it:", err)
+		} else {
+			made, lastTodo = made+1, todo.ID
+		}
+		handled = &at
+	}
+	if missed > 0 && handled != nil {
+		fmt.Println("recurring todos: rule", rec.ID, "missed", missed, "times, caught up with", made, "todos ("+rec.CatchUp+")")
+	}
+
+	// it may have been deleted meanwhile
+	recurrencesMu.Lock()
+	defer recurrencesMu.Unlock()
+	current, exists := recurrences[rec.ID]
+	if !exists {
+		return
+	}
+	if handled != nil {
+		current.LastRun = handled
+		planNext(&current)
+	}
+	current.Made += made
+	if lastTodo != 0 {
+		current.LastTodo = lastTodo
+	}
+	current.LastError = ""
+	if failed != nil {
+		current.LastError = failed.Error()
+	}
+	recurrences[rec.ID] = current
+	saveRecurring()
+}
+
+
+// previewCount reads ?n=, how many times a preview shows
+func previewCount(r *http.Request) (int, error) {
+
+	n := defaultPreview
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		n, err = strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPreview {
+			return 0, model.Invalid("n", fmt.Sprintf("must be between 1 and %d", maxPreview))
+		}
+	}
+	return n, nil
+}
+
+
+// GET lists the recurring todos, POST adds one
+func recurringHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		recurrencesMu.Lock()
+		list := make([]Recurrence, 0, len(recurrences))
+		for _, rec := range recurrences {
+			if rec.tenant == store.TenantFrom(r.Context()) {
+				list = append(list, rec)
+			}
+		}
+		recurrencesMu.Unlock()
+
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var req RecurrenceRequest
+		if err := decodeBody(r, "recurring", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		rec := Recurrence{
+			Title: strings.TrimSpace(req.Title), Cron: strings.TrimSpace(req.Cron), Timezone: req.Timezone,
+			DueAfter: req.DueAfter, CatchUp: req.CatchUp, By: store.ActorFrom(r.Context()),
+			CreatedAt: todoStore.Now(), tenant: store.TenantFrom(r.Context()),
+		}
+		if rec.Timezone == "" {
+			rec.Timezone = requestZoneName(r)
+		}
+		if rec.CatchUp == "" {
+			rec.CatchUp = catchUpLatest
+		}
+		if err := (model.Todo{Title: rec.Title}).Validate(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if err := compileRecurrence(&rec); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		planNext(&rec)
+		if rec.NextRun == nil {
+			writeError(w, r, model.Invalid("cron", fmt.Sprintf("has no times in the next %d years", cronSearchYears)))
+			return
+		}
+
+		recurrencesMu.Lock()
+		rec.ID = nextRecurrenceID
+		recurrences[rec.ID] = rec
+		nextRecurrenceID++
+		saveRecurring()
+		recurrencesMu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rec)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// GET shows one recurring todo, DELETE stops it (the todos it made stay)
+func recurrenceHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("id", "must be an integer"))
+		return
+	}
+	rec, err := recurrence(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(rec)
+
+	case http.MethodDelete:
+		recurrencesMu.Lock()
+		delete(recurrences, id)
+		saveRecurring()
+		recurrencesMu.Unlock()
+
+		// 204 = success with no response body
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// GET /recurring/{id}/preview?n=5 shows a rule's next n times, GET /recurring/preview?cron=...&timezone=...
+// those of a cron expression before it's saved
+func recurrencePreviewHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	n, err := previewCount(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var rec Recurrence
+	if r.PathValue("id") != "" {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, r, model.Invalid("id", "must be an integer"))
+			return
+		}
+		if rec, err = recurrence(r.Context(), id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		rec = Recurrence{Cron: q.Get("cron"), Timezone: q.Get("timezone"), CatchUp: catchUpLatest}
+		if rec.Timezone == "" {
+			rec.Timezone = requestZoneName(r)
+		}
+		if err := compileRecurrence(&rec); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(RecurrencePreview{Cron: rec.Cron, Timezone: rec.Timezone, Next: rec.schedule.upcoming(todoStore.Now(), n)})
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"bufio"         // for reading replies
+	"context"       // for the replicator interface
+	"encoding/json" // for the mutations in the log
+	"errors"        // for protocol and config errors
+	"fmt"           // for printing logs to terminal and errors
+	"io"            // for reading bulk strings
+	"net"           // for the TCP connections
+	"net/url"       // for parsing -redis-url
+	"strconv"       // for the protocol's lengths and numbers
+	"strings"       // for protocol parsing
+	"sync"          // for the shared connection
+	"sync/atomic"   // for the follower's health
+	"time"          // for timeouts and reconnect delay
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for the unavailable error
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store"   // for replicated mutations
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for the changes' traces
+)
+
+// replicas sharing their todos through Redis (off unless a server is given): every change is
+// appended to a Redis stream, <prefix>log, and every replica, the one that made it too, follows
+// the stream and applies the others' changes, so the change feed, WebSocket and SSE clients of any
+// replica see them all. a replica starting up replays the stream first. a todo is changed only by
+// a replica that has applied its last entry (<prefix>heads remembers it), so two replicas changing
+// the same todo at once can't end up apart: the later one gets a 503 and the client tries again.
+// ids come from INCR <prefix>ids
+var redisURL = flags.String("redis-url", "", "Redis server the replicas share their todos through, e.g. redis://:pass@localhost:6379/0 (disabled when empty)")
+var redisPrefix = flags.String("redis-prefix", "todo:", "prefix of the keys the replicas share in Redis")
+
+// appends a mutation (ARGV[3]) to the log if ARGV[2] is still the todo's (ARGV[1]) last entry,
+// returning the new entry's id, nil when another replica got there first. the entry carries the
+// traceparent of the request making the change (ARGV[4]) when there is one
+const redisAppendScript = `
+local head = redis.call('HGET', KEYS[1], ARGV[1])
+if (head or '') ~= ARGV[2] then
+	return false
+end
+local entry
+if ARGV[4] ~= '' then
+	entry = redis.call('XADD', KEYS[2], '*', 'm', ARGV[3], 'traceparent', ARGV[4])
+else
+	entry = redis.call('XADD', KEYS[2], '*', 'm', ARGV[3])
+end
+redis.call('HSET', KEYS[1], ARGV[1], entry)
+return entry`
+
+// most entries read from the stream at once, and how long the follower waits for new ones
+const (
+	redisReadCount = 500
+	redisBlock     = 5 * time.Second
+)
+
+// redisConn is a client speaking RESP2, one command at a time
+type redisConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// redisError is an error reply; the connection is still fine after one
+type redisError string
+
+// redisEntry is one entry of the stream
+type redisEntry struct {
+	id   string
+	data string // its mutation, as JSON
+}
+
+// redisShared is the replica's side of the shared state
+type redisShared struct {
+	mu      sync.Mutex      // one command at a time on conn, and each append together with noting it
+	conn    *redisConn      // for appends and ids, nil until dialed or after it broke
+	heads   map[int]string  // the last entry of each todo this replica has applied
+	mine    map[string]bool // entries this replica appended, and made the change of itself
+	top     int             // the highest id this replica has seen, for ids while Redis can't hand them out
+	healthy atomic.Bool     // the follower is reading the stream
+}
+
+// the replica's shared state, while -redis-url is set
+var shared *redisShared
+
+
+// Error says what the server answered
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+
+// loadRedis checks -redis-url
+func loadRedis() error {
+
+	if *redisURL == "" {
+		return nil
+	}
+	switch {
+	case clustered():
+		return errors.New("-redis-url: a cluster node keeps its todos in its Raft log, leave out -redis-url")
+	case *mockMode:
+		return errors.New("-redis-url: a mock server can't share its todos")
+	case *tenantsPath != "":
+		return errors.New("-redis-url: tenants aren't shared, leave out -tenants")
+	case *schedulerLease < time.Second:
+		return errors.New("-scheduler-lease must be at least 1s")
+	}
+	if u, err := url.Parse(*redisURL); err != nil || u.Scheme != "redis" || u.Host == "" {
+		return errors.New("-redis-url must look like redis://host:port")
+	}
+	shared = &redisShared{heads: map[int]string{}, mine: map[string]bool{}}
+	return nil
+}
+
+
+// dialRedis connects and logs in with the URL's password (and user), selecting its database
+func dialRedis(rawURL string) (*redisConn, error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	conn, err := net.DialTimeout("tcp", host, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, br: bufio.NewReader(conn)}
+
+	if u.User != nil {
+		auth := []string{"AUTH"}
+		if u.User.Username() != "" {
+			auth = append(auth, u.User.Username())
+		}
+		password, _ := u.User.Password()
+		if _, err := rc.do(5*time.Second, append(auth, password)...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" && db != "0" {
+		if _, err := rc.do(5*time.Second, "SELECT", db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+
+// do sends one command and reads its reply: a string, an int64, nil or a []any of those
+func (rc *redisConn) do(timeout time.Duration, args ...string) (any, error) {
+
+	cmd := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd = fmt.Appendf(cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	rc.conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := rc.conn.Write(cmd); err != nil {
+		return nil, err
+	}
+	return rc.read()
+}
+
+
+// read reads one reply
+func (rc *redisConn) read() (any, error) {
+
+	line, err := rc.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.br, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			// an error inside an array is one of its items, the rest still follow
+			item, err := rc.read()
+			var replyErr redisError
+			if errors.As(err, &replyErr) {
+				item = replyErr
+			} else if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, errors.New("redis: unexpected reply " + line)
+}
+
+
+// close closes the connection
+func (rc *redisConn) close() {
+	rc.conn.Close()
+}
+
+
+// command runs one command on the shared connection, dialing it first if need be; a broken
+// connection is dropped for the next command to dial again (caller holds s.mu)
+func (s *redisShared) command(args ...string) (any, error) {
+
+	if s.conn == nil {
+		conn, err := dialRedis(*redisURL)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+	}
+	reply, err := s.conn.do(5*time.Second, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		s.conn.close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+
+// NextID hands out the next id of every replica's todos
+func (s *redisShared) NextID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reply, err := s.command("INCR", *redisPrefix+"ids"); err == nil {
+		if id, ok := reply.(int64); ok {
+			return int(id)
+		}
+	}
+	// an id another replica may have taken meanwhile: the append notices, as that todo has a head
+	s.top++
+	return s.top
+}
+
+
+// Replicate appends a change to the log, if no other replica changed the todo since this one
+// applied its last entry
+func (s *redisShared) Replicate(ctx context.Context, m store.Mutation) error {
+
+	data, _ := json.Marshal(m)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := s.command("EVAL", redisAppendScript, "2", *redisPrefix+"heads", *redisPrefix+"log",
+		strconv.Itoa(m.Todo.ID), s.heads[m.Todo.ID], string(data), tracing.FromContext(ctx).Traceparent())
+	if redisTransient(err) {
+		// an append that did go through isn't made twice: its head has moved on
+		return fmt.Errorf("redis: %v: %w: %w", err, store.ErrTransient, model.ErrUnavailable)
+	}
+	if err != nil {
+		return fmt.Errorf("redis: %v: %w", err, model.ErrUnavailable)
+	}
+	entry, ok := reply.(string)
+	if !ok {
+		return fmt.Errorf("another replica changed todo %d meanwhile, try again: %w: %w", m.Todo.ID, store.ErrContended, model.ErrUnavailable)
+	}
+	s.heads[m.Todo.ID] = entry
+	s.mine[entry] = true
+	s.top = max(s.top, m.Todo.ID)
+	return nil
+}
+
+
+// redisTransient reports whether err is a blip: a connection that failed, or a server that's
+// loading, busy with a script or asks to try again
+func redisTransient(err error) bool {
+
+	var replyErr redisError
+	if !errors.As(err, &replyErr) {
+		return err != nil
+	}
+	for _, prefix := range []string{"LOADING", "BUSY", "TRYAGAIN"} {
+		if strings.HasPrefix(string(replyErr), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+
+// apply makes the change of an entry of the log, unless this replica made it already
+func (s *redisShared) apply(entry redisEntry) {
+
+	s.mu.Lock()
+	mine := s.mine[entry.id]
+	delete(s.mine, entry.id)
+	s.mu.Unlock()
+	if mine {
+		return
+	}
+
+	var m store.Mutation
+	if err := json.Unmarshal([]byte(entry.data), &m); err != nil {
+		fmt.Println("redis: skipping entry", entry.id, "that isn't a mutation:", err)
+		return
+	}
+	todoStore.Apply(m)
+
+	// only now may this replica change the todo itself
+	s.mu.Lock()
+	s.heads[m.Todo.ID] = entry.id
+	s.top = max(s.top, m.Todo.ID)
+	s.mu.Unlock()
+}
+
+
+// redisEntries picks the entries out of an XRANGE reply
+func redisEntries(reply any) []redisEntry {
+
+	items, _ := reply.([]any)
+	var entries []redisEntry
+	for _, item := range items {
+		parts, _ := item.([]any)
+		if len(parts) != 2 {
+			continue
+		}
+		id, _ := parts[0].(string)
+		fields, _ := parts[1].([]any)
+		entry := redisEntry{id: id}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if fields[i] == "m" {
+				entry.data, _ = fields[i+1].(string)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+
+// startRedis replays the shared log into the store (call after Handler), then follows it
+func startRedis() error {
+
+	if shared == nil {
+		return nil
+	}
+
+	last, start := "0-0", "-"
+	for {
+		shared.mu.Lock()
+		reply, err := shared.command("XRANGE", *redisPrefix+"log", start, "+", "COUNT", strconv.Itoa(redisReadCount))
+		shared.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		entries := redisEntries(reply)
+		if len(entries) == 0 {
+			break
+		}
+		for _, entry := range entries {
+			shared.apply(entry)
+			last = entry.id
+		}
+		start = "(" + last
+	}
+
+	shared.healthy.Store(true)
+	fmt.Println("redis: todos loaded from", *redisPrefix+"log", "up to", last)
+	go shared.follow(last)
+	return nil
+}
+
+
+// follow applies the entries appended after last, for as long as the process runs, on a
+// connection of its own since it blocks waiting for them
+func (s *redisShared) follow(last string) {
+
+	var conn *redisConn
+	for {
+		if conn == nil {
+			var err error
+			if conn, err = dialRedis(*redisURL); err != nil {
+				s.lost(err)
+				continue
+			}
+			if !s.healthy.Swap(true) {
+				fmt.Println("redis: following the log again")
+			}
+		}
+
+		reply, err := conn.do(redisBlock+5*time.Second, "XREAD", "COUNT", strconv.Itoa(redisReadCount),
+			"BLOCK", strconv.Itoa(int(redisBlock/time.Millisecond)), "STREAMS", *redisPrefix+"log", last)
+		if err != nil {
+			conn.close()
+			conn = nil
+			s.lost(err)
+			continue
+		}
+
+		// [[key, entries]], nil when nothing came
+		streams, _ := reply.([]any)
+		for _, stream := range streams {
+			if parts, _ := stream.([]any); len(parts) == 2 {
+				for _, entry := range redisEntries(parts[1]) {
+					s.apply(entry)
+					last = entry.id
+				}
+			}
+		}
+	}
+}
+
+
+// lost notes the follower can't read the stream, and waits a moment before it tries again
+func (s *redisShared) lost(err error) {
+	if s.healthy.Swap(false) {
+		fmt.Println("redis: lost the log, other replicas' changes wait:", err)
+	}
+	time.Sleep(time.Second)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"                      // for rendering templates
+	"errors"                     // for joining send errors
+	htmltemplate "html/template" // for the html body, escaped
+	"os"                         // for reading template overrides
+	"path/filepath"              // for the template directory
+	"text/template"              // for subject and plain text body
+	"time"                       // for the recipient's local time
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// directory with reminder_subject.txt, reminder.txt and reminder.html overriding the built-in templates
+var mailTemplatesDir = flags.String("mail-templates", "", "directory with reminder mail templates (built-in templates when empty)")
+
+// built-in reminder templates; they see .Todo, .Due (recipient's local time, nil if none) and .Recipient
+const defaultReminderSubject = `Overdue: {{.Todo.Title}}`
+
+const defaultReminderText = `"{{.Todo.Title}}" (#{{.Todo.ID}}) is overdue{{if .Due}}, it was due {{.Due.Format "Mon Jan 2 15:04 MST"}}{{end}}.
+
+Mark it done with: todo done {{.Todo.ID}}
+`
+
+const defaultReminderHTML = `<!doctype html>
+<html>
+<body style="font-family: sans-serif">
+  <p><strong>{{.Todo.Title}}</strong> (#{{.Todo.ID}}) is overdue{{if .Due}}, it was due {{.Due.Format "Mon Jan 2 15:04 MST"}}{{end}}.</p>
+  <p style="color: #666">Mark it done with <code>todo done {{.Todo.ID}}</code></p>
+</body>
+</html>
+`
+
+// ReminderMail is what the reminder templates are rendered with
+type ReminderMail struct {
+	Todo      model.Todo
+	Due       *time.Time
+	Recipient DigestSubscription
+}
+
+// parsed templates
+var reminderSubject, reminderText *template.Template
+var reminderHTML *htmltemplate.Template
+
+
+// reminderTemplate returns the override from -mail-templates, or the built-in text
+func reminderTemplate(name, builtin string) (string, error) {
+
+	if *mailTemplatesDir == "" {
+		return builtin, nil
+	}
+	data, err := os.ReadFile(filepath.Join(*mailTemplatesDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return builtin, nil
+	}
+	return string(data), err
+}
+
+
+// setupReminderMails parses the templates and registers the mail notifier (only when mail is configured)
+func setupReminderMails() error {
+
+	// nothing to send with
+	if !mailEnabled() {
+		return nil
+	}
+
+	subject, err := reminderTemplate("reminder_subject.txt", defaultReminderSubject)
+	if err != nil {
+		return err
+	}
+	text, err := reminderTemplate("reminder.txt", defaultReminderText)
+	if err != nil {
+		return err
+	}
+	html, err := reminderTemplate("reminder.html", defaultReminderHTML)
+	if err != nil {
+		return err
+	}
+
+	if reminderSubject, err = template.New("reminder_subject.txt").Parse(subject); err != nil {
+		return err
+	}
+	if reminderText, err = template.New("reminder.txt").Parse(text); err != nil {
+		return err
+	}
+	if reminderHTML, err = htmltemplate.New("reminder.html").Parse(html); err != nil {
+		return err
+	}
+
+	addNotifier("email", sendReminderMails)
+	return nil
+}
+
+
+// sendReminderMails mails every recipient with reminders on when a todo becomes overdue
+func sendReminderMails(n Notification) error {
+
+	if n.Kind != notifyOverdue {
+		return nil
+	}
+
+	digestsMu.Lock()
+	var recipients []DigestSubscription
+	for _, sub := range digests {
+		if sub.Reminders {
+			recipients = append(recipients, sub)
+		}
+	}
+	digestsMu.Unlock()
+
+	var errs []error
+	for _, sub := range recipients {
+		data := ReminderMail{Todo: n.Todo, Recipient: sub}
+		if n.Todo.Due != nil {
+			loc, _ := time.LoadLocation(sub.Timezone) // validated on subscribe
+			due := n.Todo.Due.In(loc)
+			data.Due = &due
+		}
+
+		var subject, text, html bytes.Buffer
+		if err := errors.Join(reminderSubject.Execute(&subject, data), reminderText.Execute(&text, data), reminderHTML.Execute(&html, data)); err != nil {
+			return err
+		}
+		if err := sendMailAlt(sub.Email, subject.String(), text.String(), html.String()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"time" // for the backoff
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the retrier
+)
+
+// changes the store's backend (a cluster's log or Redis) fails with a blip are tried again, up to
+// -store-attempts times with a jittered backoff, before the client sees a 503. only failures that
+// can't have changed anything, or can't change it twice, are: a Redis connection that broke or a
+// Redis still loading, a cluster leader not yet caught up. a change the cluster may still commit
+// isn't, it could be made twice
+var storeAttempts = flags.Int("store-attempts", 3, "attempts at a change when the store's backend fails with a blip (1 = no retries)")
+var storeRetryBackoff = flags.Duration("store-retry-backoff", 50*time.Millisecond, "longest wait before the second attempt, doubling after each (up to 1s)")
+
+
+// retrying tries the store's backend again on blips
+func retrying(r store.Replicator) store.Replicator {
+	if *storeAttempts <= 1 {
+		return r
+	}
+	return store.NewRetrier(r, *storeAttempts, *storeRetryBackoff)
+}
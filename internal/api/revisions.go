@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for missing revisions
+	"net/http"      // for HTTP handlers
+	"strconv"       // for ids and revisions in the path
+	"strings"       // for the actor header
+	"time"          // for revision times
+	"unicode"       // for checking the actor name
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for revisions and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the actor of a change
+)
+
+// the store keeps every version of a todo (the last -history-size of them), each with its time
+// and actor. there are no user accounts, so the actor is what the client calls itself in the
+// Todo-Actor header, or else its address; it's for reading the history, not for trusting it
+var historySize = flags.Int("history-size", store.DefaultHistorySize, "versions of each todo kept for GET /todos/{id}/revisions")
+
+// longest Todo-Actor accepted
+const maxActorLength = 64
+
+// TodoRevisions is the body of GET /todos/{id}/revisions
+type TodoRevisions struct {
+	ID        int              `json:"id"`
+	Revisions []model.Revision `json:"revisions"` // oldest first, the current one last
+}
+
+
+// requestActor is who a request's changes are recorded as made by
+func requestActor(r *http.Request) (string, error) {
+
+	if actor := strings.TrimSpace(r.Header.Get("Todo-Actor")); actor != "" {
+		if len([]rune(actor)) > maxActorLength || strings.IndexFunc(actor, func(c rune) bool { return !unicode.IsPrint(c) }) >= 0 {
+			return "", model.Invalid("Todo-Actor", "must be up to 64 printable characters")
+		}
+		return actor, nil
+	}
+	if addr, ok := clientIP(r); ok {
+		return addr.String(), nil
+	}
+	return "local", nil
+}
+
+
+// recordActors puts the request's actor in its context, for the store to record with its changes
+func recordActors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, err := requestActor(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(store.WithActor(r.Context(), actor)))
+	})
+}
+
+
+// GET /todos/{id}/revisions returns the versions of a todo the store still keeps
+func revisionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := todoPathID(r, "id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	revisions, err := todoStore.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(TodoRevisions{ID: id, Revisions: revisions})
+}
+
+
+// POST /todos/{id}/revisions/{rev}/restore sets the todo back to how it was at rev, as a new
+// revision, and returns it
+func restoreRevisionHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("id", "must be an integer"))
+		return
+	}
+	rev, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil || rev < 1 {
+		writeError(w, r, model.Invalid("rev", "must be an integer"))
+		return
+	}
+
+	// 404 for a missing todo, or a revision no longer kept
+	todo, err := todoStore.Restore(r.Context(), id, rev)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(todo)
+}
+
+// FieldChange is one field that differs between two revisions
+type FieldChange struct {
+	Field string `json:"field"` // title, done or due
+	From  any    `json:"from"`  // null for a due date that wasn't set
+	To    any    `json:"to"`
+}
+
+// RevisionRef names a revision in a diff
+type RevisionRef struct {
+	Rev   int       `json:"rev"`
+	Time  time.Time `json:"time"`
+	Actor string    `json:"actor"`
+}
+
+// RevisionDiff is the body of GET /todos/{id}/revisions/diff
+type RevisionDiff struct {
+	ID      int           `json:"id"`
+	From    RevisionRef   `json:"from"`
+	To      RevisionRef   `json:"to"`
+	Changes []FieldChange `json:"changes"` // empty when they're the same
+}
+
+
+// diffTodos lists the fields that differ from a to b
+func diffTodos(a, b model.Todo) []FieldChange {
+
+	changes := []FieldChange{}
+	if a.Title != b.Title {
+		changes = append(changes, FieldChange{Field: "title", From: a.Title, To: b.Title})
+	}
+	if a.Done != b.Done {
+		changes = append(changes, FieldChange{Field: "done", From: a.Done, To: b.Done})
+	}
+	if (a.Due == nil) != (b.Due == nil) || (a.Due != nil && !a.Due.Equal(*b.Due)) {
+		changes = append(changes, FieldChange{Field: "due", From: a.Due, To: b.Due})
+	}
+	return changes
+}
+
+
+// GET /todos/{id}/revisions/diff?from=2&to=5 returns what changed between two kept revisions;
+// to defaults to the current one, and from may be the later of the two
+func revisionDiffHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("id", "must be an integer"))
+		return
+	}
+	revisions, err := todoStore.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// from is required, to is the current revision unless given
+	q := r.URL.Query()
+	pick := func(name string) (model.Revision, error) {
+		v := q.Get(name)
+		if v == "" && name == "to" {
+			return revisions[len(revisions)-1], nil
+		}
+		rev, err := strconv.Atoi(v)
+		if err != nil || rev < 1 {
+			return model.Revision{}, model.Invalid(name, "must be a revision number")
+		}
+		for _, version := range revisions {
+			if version.Rev == rev {
+				return version, nil
+			}
+		}
+		return model.Revision{}, fmt.Errorf("todo %d revision %d: %w", id, rev, model.ErrNotFound)
+	}
+	from, err := pick("from")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	to, err := pick("to")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(RevisionDiff{
+		ID:      id,
+		From:    RevisionRef{Rev: from.Rev, Time: from.Time, Actor: from.Actor},
+		To:      RevisionRef{Rev: to.Rev, Time: to.Time, Actor: to.Actor},
+		Changes: diffTodos(from.Todo, to.Todo),
+	})
+}
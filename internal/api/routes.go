@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"  // for shutdown deadlines
+	"errors"   // for flag.ErrHelp
+	"flag"     // for flag.ErrHelp
+	"fmt"      // for printing logs to terminal
+	"net/http" // for HTTP server & routes
+	"os"       // for exit codes
+	"sync"     // for starting the jobs once
+	"time"     // for the mock clock
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store"   // for the todo store
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for the span exporter
+)
+
+// shared in-memory storage, set by Handler (and replaced by the benchmarks and tests)
+var todoStore *store.Store
+
+
+// Run parses the command line, starts the server and its background jobs, and returns once
+// it has shut down (or handed its sockets to a new process); config errors exit the process
+func Run() {
+
+	// read command line config (the flag package has printed what's wrong)
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	if err := applyConfig(); err != nil {
+		fmt.Println("invalid config:", err)
+		os.Exit(1)
+	}
+
+	// our router wrapped in middleware, over the todos every handler and job works on. a mock
+	// server answers from canned data and sends nothing out
+	var handler http.Handler
+	if *mockMode {
+		handler = mockFaults(Handler(newMockStore()))
+		fmt.Println("mock mode: canned data at", mockTime.Format(time.RFC3339))
+	} else {
+		handler = Handler(store.New(*changeLogSize, storeOptions()...))
+		if err := startCluster(); err != nil {
+			fmt.Println("cannot join the cluster:", err)
+			os.Exit(1)
+		}
+		if err := startRedis(); err != nil {
+			fmt.Println("cannot load the todos from Redis:", err)
+			os.Exit(1)
+		}
+		StartJobs()
+	}
+
+	// reuse sockets handed over by the old process (or systemd), otherwise open our own
+	listeners, sideListeners, err := setupListeners()
+	if err != nil {
+		fmt.Println("cannot listen:", err)
+		os.Exit(1)
+	}
+
+	// one server shared by every listener
+	srv := newHTTPServer(handler)
+	for _, ln := range listeners {
+		fmt.Println("Server started on", ln.Addr())
+		go func() {
+			if err := serve(srv, ln); err != nil && err != http.ErrServerClosed {
+				fmt.Println("server stopped:", err)
+			}
+		}()
+	}
+
+	// side servers on their own ports (nil when disabled)
+	sideServers := []*http.Server{
+		startAdminServer(sideListeners["admin"]),
+		startGRPCServer(sideListeners["grpc"]),
+		startClusterServer(sideListeners["cluster"]),
+	}
+
+	// we are serving, so an old process waiting on us can drain now
+	notifyParentReady()
+
+	// block until SIGTERM, handing all sockets to a new binary on SIGHUP
+	waitForSignals(func(ctx context.Context) error {
+		for _, side := range sideServers {
+			if side != nil {
+				side.Shutdown(ctx)
+			}
+		}
+		err := srv.Shutdown(ctx)
+		releaseSchedulerLease()
+		stopCluster()
+		return err
+	}, handoffOrder(listeners, sideListeners))
+}
+
+
+// Handler returns the public API over st: the routes wrapped in the middleware. the rest of the
+// API's state (config, admin switches, background jobs) is per process, so handlers from earlier
+// calls move over to st as well (tests get a fresh store this way, one test at a time)
+func Handler(st *store.Store) http.Handler {
+
+	// cached lists are of the old store, which had the maintenance gate already
+	if todoStore != st {
+		clearListCache()
+		st.GateWrites(maintenanceGate)
+	}
+	todoStore = st
+
+	// -seed fills a new, empty store
+	seedOnStart()
+
+	// outermost first
+	return logAccess(filterIPs(traceRequests(forwardWrites(injectChaos(securityHeaders(compressResponses(negotiateFormat(rejectWritesInMaintenance(readOnlyWhenDegraded(selectTenants(recordActors(nameSpans(newRouter())))))))))))))
+}
+
+
+// newRouter registers the public routes on their own router, so nothing registered on the
+// default mux (e.g. pprof) leaks onto the public port
+func newRouter() *http.ServeMux {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/todos", getTodosHandler)
+	mux.HandleFunc("/todos/get", getTodoHandler)
+	mux.HandleFunc("/todos/create", createTodoHandler)
+	mux.HandleFunc("/todos/update", updateTodoHandler)
+	mux.HandleFunc("/todos/delete", deleteTodoHandler)
+	mux.HandleFunc("/todos/changes", todoChangesHandler)
+	mux.HandleFunc("/changes", changeFeedHandler)
+	mux.HandleFunc("/activity", activityHandler)
+	mux.HandleFunc("/notifications", notificationsHandler)
+	mux.HandleFunc("/notifications/read", readNotificationsHandler)
+	mux.HandleFunc("/notifications/{id}/read", readNotificationsHandler)
+	mux.HandleFunc("/todos/sync", syncHandler)
+	mux.HandleFunc("/todos/stats", statsHandler)
+	mux.HandleFunc("/analytics", analyticsHandler)
+	mux.HandleFunc("/me/streak", streakHandler)
+	mux.HandleFunc("/me/export", exportMeHandler)
+	mux.HandleFunc("/me", deleteMeHandler)
+	mux.HandleFunc("/me/erasure", erasureHandler)
+	mux.HandleFunc("/todos/next", nextTodosHandler)
+	mux.HandleFunc("/todos/board", boardHandler)
+	mux.HandleFunc("/trash", trashHandler)
+	mux.HandleFunc("/trash/{id}", trashedTodoHandler)
+	mux.HandleFunc("/trash/{id}/restore", restoreTrashedHandler)
+	mux.HandleFunc("/scheduled", scheduledTodosHandler)
+	mux.HandleFunc("/scheduled/{id}", scheduledTodoHandler)
+	mux.HandleFunc("/recurring", recurringHandler)
+	mux.HandleFunc("/recurring/preview", recurrencePreviewHandler)
+	mux.HandleFunc("/recurring/{id}", recurrenceHandler)
+	mux.HandleFunc("/recurring/{id}/preview", recurrencePreviewHandler)
+	mux.HandleFunc("/todos/{id}/snooze", snoozeHandler)
+	mux.HandleFunc("/todos/{id}/revisions", revisionsHandler)
+	mux.HandleFunc("/todos/{id}/revisions/diff", revisionDiffHandler)
+	mux.HandleFunc("/todos/{id}/revisions/{rev}/restore", restoreRevisionHandler)
+
+	// dependencies between todos
+	mux.HandleFunc("/todos/{id}/blockers", blockersHandler)
+	mux.HandleFunc("/todos/{id}/blockers/{blocker}", deleteBlockerHandler)
+	mux.HandleFunc("/todos/graph", graphHandler)
+
+	// files attached to todos, and signed links to them
+	mux.HandleFunc("/todos/{id}/attachments", attachmentsHandler)
+	mux.HandleFunc("/todos/{id}/attachments/{attachment}", attachmentHandler)
+	mux.HandleFunc("/todos/{id}/attachments/{attachment}/url", attachmentURLHandler)
+	mux.HandleFunc("/attachments/{attachment}/{name}", signedAttachmentHandler)
+
+	// bulk export / import
+	mux.HandleFunc("/todos/export.csv", exportCSVHandler)
+	mux.HandleFunc("/todos/export.ndjson", exportNDJSONHandler)
+	mux.HandleFunc("/todos/export.md", exportMarkdownHandler)
+	mux.HandleFunc("/todos/export.pdf", exportPDFHandler)
+	mux.HandleFunc("/todos/import", importHandler)
+	mux.HandleFunc("/import/todoist", todoistImportHandler)
+	mux.HandleFunc("/import/taskwarrior", taskwarriorImportHandler)
+	mux.HandleFunc("/export/taskwarrior", taskwarriorExportHandler)
+
+	// saved filters ("smart lists"), their todos and read-only links to them
+	mux.HandleFunc("/filters", filtersHandler)
+	mux.HandleFunc("/filters/{id}", filterHandler)
+	mux.HandleFunc("/filters/{id}/todos", filterTodosHandler)
+	mux.HandleFunc("/filters/{id}/export.pdf", filterExportPDFHandler)
+	mux.HandleFunc("/filters/{id}/shares", filterSharesHandler)
+	mux.HandleFunc("/filters/{id}/shares/{prefix}", deleteFilterShareHandler)
+	mux.HandleFunc("/share/{token}", shareHandler)
+
+	// lists by the name todos' list fields give, and a list as a checklist to print
+	mux.HandleFunc("/lists", listsHandler)
+	mux.HandleFunc("/lists/{id}/todos", listTodosHandler)
+	mux.HandleFunc("/lists/{id}/export.pdf", listExportPDFHandler)
+
+	// todo templates and applying them
+	mux.HandleFunc("/templates", templatesHandler)
+	mux.HandleFunc("/templates/{id}", templateHandler)
+	mux.HandleFunc("/templates/{id}/apply", applyTemplateHandler)
+
+	// calendar and activity feeds, authenticated by a signed token in the URL
+	mux.HandleFunc("/todos.ics", icsFeedHandler)
+	mux.HandleFunc("/todos/feed.atom", atomFeedHandler)
+
+	// probe endpoints for kubernetes / load balancers
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/livez", livezHandler)
+	mux.HandleFunc("/readyz", readyzHandler)
+
+	// live change events over websocket
+	mux.HandleFunc("/ws", requireFeature("event-stream", wsHandler))
+
+	// JSON-RPC 2.0 over a single endpoint
+	mux.HandleFunc("/rpc", rpcHandler)
+
+	// GraphQL queries, mutations and websocket subscriptions
+	mux.HandleFunc("/graphql", graphqlHandler)
+	mux.HandleFunc("/graphql/schema", graphqlSchemaHandler)
+
+	// Web Push subscriptions for browser reminders
+	mux.HandleFunc("/push/vapid-public-key", vapidPublicKeyHandler)
+	mux.HandleFunc("/push/subscribe", pushSubscribeHandler)
+	mux.HandleFunc("/push/unsubscribe", pushUnsubscribeHandler)
+
+	// JSON Schemas of the request bodies
+	mux.HandleFunc("/schemas/", schemasHandler)
+
+	// what build is deployed
+	mux.HandleFunc("/version", versionHandler)
+
+	// web UI
+	mux.HandleFunc("/{$}", uiIndexHandler)
+	mux.Handle("/ui/", uiAssetsHandler())
+	mux.Handle("/htmx/", htmxHandler())
+	return mux
+}
+
+
+// newAdminRouter registers the admin-only endpoints, served on a separate port
+func newAdminRouter() *http.ServeMux {
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/{$}", dashboardHandler())
+	mux.Handle("/admin", http.RedirectHandler("admin/", http.StatusMovedPermanently))
+	mux.HandleFunc("/admin/maintenance", maintenanceHandler)
+	mux.HandleFunc("/admin/chaos", chaosHandler)
+	mux.HandleFunc("/admin/features", featuresHandler)
+	mux.HandleFunc("/admin/seed", seedHandler)
+	mux.HandleFunc("/admin/retention", retentionHandler)
+	mux.HandleFunc("/admin/cluster", clusterHandler)
+	mux.HandleFunc("/admin/cache", listCacheHandler)
+	mux.HandleFunc("/admin/feeds", feedsHandler)
+	mux.HandleFunc("/admin/actors/{actor}/token", actorTokenHandler)
+	mux.HandleFunc("/admin/digests", listDigestsHandler)
+	mux.HandleFunc("/admin/digests/create", createDigestHandler)
+	mux.HandleFunc("/admin/digests/delete", deleteDigestHandler)
+	mux.HandleFunc("/admin/digests/send", sendDigestNowHandler)
+	mux.HandleFunc("/admin/telegram/chats", listTelegramChatsHandler)
+	mux.HandleFunc("/admin/telegram/chats/link", linkTelegramChatHandler)
+	mux.HandleFunc("/admin/telegram/chats/unlink", unlinkTelegramChatHandler)
+	mux.HandleFunc("/admin/webhooks", listWebhooksHandler)
+	mux.HandleFunc("/admin/webhooks/create", createWebhookHandler)
+	mux.HandleFunc("/admin/webhooks/delete", deleteWebhookHandler)
+	mux.HandleFunc("/admin/webhooks/test", testWebhookHandler)
+	mux.HandleFunc("/admin/webhooks/dead-letters", deadLettersHandler)
+	mux.HandleFunc("/admin/webhooks/dead-letters/replay", replayDeadLettersHandler)
+	return mux
+}
+
+// the background jobs run once per process, see StartJobs
+var jobsOnce sync.Once
+
+
+// StartJobs starts the span exporter, the background consumers of store events and the
+// scheduled jobs, each only if its flags configure it (after Handler). only the first call
+// starts them, on the store Handler was given then; later calls do nothing
+func StartJobs() {
+	jobsOnce.Do(startJobs)
+}
+
+
+// startJobs starts what StartJobs does
+func startJobs() {
+
+	// ship spans to the collector if tracing is configured
+	tracing.StartExporter(*otlpEndpoint, *serviceName)
+
+	// background consumers of store events
+	startWebhookDispatcher()
+	startNATSPublisher()
+	startKafkaProducer()
+	startMQTTPublisher()
+	startTelegramBot()
+	startEvictor()
+	startMentions()
+	startStreaks()
+	startAttachmentCleanup()
+
+	// scheduled jobs, run by one server of several
+	startSchedulerLease()
+	startDigestScheduler()
+	startNotifications()
+	startErasures()
+	startJanitor()
+
+	// todos scheduled for later and recurring ones, made by the one running the scheduled jobs too,
+	// the others hand theirs over
+	startScheduledTodos()
+	startRecurring()
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"         // for detecting batch requests
+	"context"       // for store calls
+	"encoding/json" // for JSON-RPC messages
+	"errors"        // for mapping store errors
+	"io"            // for reading the body
+	"net/http"      // for HTTP handlers
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcNotFound       = -32001 // server defined: no such todo
+	rpcRejected       = -32002 // server defined: vetoed by a store hook
+	rpcUnavailable    = -32003 // server defined: the store can't take changes for now
+)
+
+// largest /rpc body we read
+const rpcMaxBody = 1 << 20
+
+// RPCRequest is one JSON-RPC call
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+}
+
+// RPCResponse is one JSON-RPC result or error
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// RPCError is the error member of a response
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcParams is the union of every method's params, by name ({"id": 1}) or position ([1])
+type rpcParams struct {
+	ID    *int    `json:"id"`
+	Title *string `json:"title"`
+	Done  *bool   `json:"done"`
+}
+
+
+// decodeParams accepts named params, or positional ones in the order (id|title) for single-arg methods
+func decodeParams(raw json.RawMessage, method string) (rpcParams, bool) {
+
+	var p rpcParams
+	if len(raw) == 0 {
+		return p, true
+	}
+
+	// positional: [value]
+	if raw[0] == '[' {
+		var list []json.RawMessage
+		if json.Unmarshal(raw, &list) != nil || len(list) > 1 {
+			return p, false
+		}
+		if len(list) == 0 {
+			return p, true
+		}
+		switch method {
+		case "todos.create":
+			return p, json.Unmarshal(list[0], &p.Title) == nil
+		case "todos.list":
+			return p, json.Unmarshal(list[0], &p.Done) == nil
+		default:
+			return p, json.Unmarshal(list[0], &p.ID) == nil
+		}
+	}
+
+	return p, json.Unmarshal(raw, &p) == nil
+}
+
+// the methods there are, so an unknown one is told apart from bad params
+var rpcMethods = map[string]bool{
+	"todos.list": true, "todos.get": true, "todos.create": true, "todos.complete": true, "todos.delete": true,
+}
+
+// the methods that change nothing, let through in maintenance and while read-only like GETs
+var rpcReads = map[string]bool{"todos.list": true, "todos.get": true}
+
+
+// callRPC runs one method and returns its result or error
+func callRPC(ctx context.Context, method string, raw json.RawMessage) (any, *RPCError) {
+
+	// an unknown method is reported as such, whatever its params
+	if !rpcMethods[method] {
+		return nil, &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + method}
+	}
+
+	p, ok := decodeParams(raw, method)
+	if !ok {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params"}
+	}
+
+	// every method but list/create takes an id
+	needsID := method != "todos.list" && method != "todos.create"
+	if needsID && p.ID == nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "missing param: id"}
+	}
+	switch method {
+	case "todos.list":
+		return todoStore.Find(ctx, store.Filter{Done: p.Done}), nil
+
+	case "todos.get":
+		todo, err := todoStore.Get(ctx, *p.ID)
+		if err != nil {
+			return nil, rpcStoreError(err)
+		}
+		return todo, nil
+
+	case "todos.create":
+		if p.Title == nil {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: "missing param: title"}
+		}
+		todo, err := todoStore.Create(ctx, model.Todo{Title: *p.Title})
+		if err != nil {
+			return nil, rpcStoreError(err)
+		}
+		return todo, nil
+
+	case "todos.complete":
+		todo, err := todoStore.Complete(ctx, *p.ID)
+		if err != nil {
+			return nil, rpcStoreError(err)
+		}
+		return todo, nil
+
+	case "todos.delete":
+		if err := todoStore.Delete(ctx, *p.ID); err != nil {
+			return nil, rpcStoreError(err)
+		}
+		return true, nil
+	}
+
+	return nil, &RPCError{Code: rpcInternalError, Message: "internal error"}
+}
+
+
+// rpcStoreError turns a store error into its JSON-RPC error, same mapping as errorStatus
+func rpcStoreError(err error) *RPCError {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return &RPCError{Code: rpcNotFound, Message: "todo not found"}
+	case errors.Is(err, model.ErrValidation):
+		return &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+	case errors.Is(err, model.ErrRejected):
+		return &RPCError{Code: rpcRejected, Message: err.Error()}
+	case errors.Is(err, model.ErrUnavailable):
+		return &RPCError{Code: rpcUnavailable, Message: err.Error()}
+	}
+	return &RPCError{Code: rpcInternalError, Message: "internal error"}
+}
+
+
+// handleRPC processes one request object; nil means no response (notification)
+func handleRPC(ctx context.Context, raw json.RawMessage) *RPCResponse {
+
+	var req RPCRequest
+	if json.Unmarshal(raw, &req) != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return &RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: rpcInvalidRequest, Message: "invalid request"}, ID: json.RawMessage("null")}
+	}
+
+	result, rpcErr := callRPC(ctx, req.Method, req.Params)
+
+	// notifications get no answer, not even errors
+	if len(req.ID) == 0 {
+		return nil
+	}
+	return &RPCResponse{JSONRPC: "2.0", Result: result, Error: rpcErr, ID: req.ID}
+}
+
+
+// post JSON-RPC 2.0 calls, single or batched
+func rpcHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rpcMaxBody))
+	body = bytes.TrimSpace(body)
+	if err != nil || !json.Valid(body) {
+		json.NewEncoder(w).Encode(RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: rpcParseError, Message: "parse error"}, ID: json.RawMessage("null")})
+		return
+	}
+
+	// single call
+	if body[0] != '[' {
+		resp := handleRPC(r.Context(), body)
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	// batch: calls run in order, notifications are left out of the answer
+	var batch []json.RawMessage
+	json.Unmarshal(body, &batch)
+	if len(batch) == 0 {
+		json.NewEncoder(w).Encode(RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: rpcInvalidRequest, Message: "empty batch"}, ID: json.RawMessage("null")})
+		return
+	}
+
+	responses := []*RPCResponse{}
+	for _, raw := range batch {
+		if resp := handleRPC(r.Context(), raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	// batch of only notifications
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(responses)
+}
@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"encoding/json" // for the responses
+	"net/http"      // for status codes
+	"testing"       // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// JSON-RPC tests: how /rpc answers malformed, unknown and failing calls, single and batched
+
+// rpcAnswer is what the tests read of a response
+type rpcAnswer struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+	ID json.RawMessage `json:"id"`
+}
+
+
+// TestRPCErrors checks the error code and id of each kind of call that fails
+func TestRPCErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		id   string
+	}{
+		{"not JSON", `{"jsonrpc":"2.0",`, -32700, "null"},
+		{"empty body", ``, -32700, "null"},
+		{"wrong version", `{"jsonrpc":"1.0","method":"todos.list","id":1}`, -32600, "null"},
+		{"no method", `{"jsonrpc":"2.0","id":1}`, -32600, "null"},
+		{"not an object", `"todos.list"`, -32600, "null"},
+		{"empty batch", `[]`, -32600, "null"},
+		{"unknown method", `{"jsonrpc":"2.0","method":"nope","id":1}`, -32601, "1"},
+		{"unknown method with bad params", `{"jsonrpc":"2.0","method":"nope","params":"x","id":"a"}`, -32601, `"a"`},
+		{"params not an object or array", `{"jsonrpc":"2.0","method":"todos.get","params":5,"id":2}`, -32602, "2"},
+		{"too many positional params", `{"jsonrpc":"2.0","method":"todos.get","params":[1,2],"id":3}`, -32602, "3"},
+		{"wrong param type", `{"jsonrpc":"2.0","method":"todos.get","params":{"id":"one"},"id":4}`, -32602, "4"},
+		{"missing id", `{"jsonrpc":"2.0","method":"todos.get","params":{},"id":5}`, -32602, "5"},
+		{"missing title", `{"jsonrpc":"2.0","method":"todos.create","id":6}`, -32602, "6"},
+		{"empty title", `{"jsonrpc":"2.0","method":"todos.create","params":{"title":""},"id":7}`, -32602, "7"},
+		{"no such todo", `{"jsonrpc":"2.0","method":"todos.get","params":[99],"id":8}`, -32001, "8"},
+		{"delete no such todo", `{"jsonrpc":"2.0","method":"todos.delete","params":{"id":99},"id":9}`, -32001, "9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			var got rpcAnswer
+			s.Post("/rpc", tt.body).ExpectStatus(http.StatusOK).Decode(&got)
+			if got.JSONRPC != "2.0" {
+				t.Errorf("jsonrpc = %q, want 2.0", got.JSONRPC)
+			}
+			if got.Error == nil {
+				t.Fatalf("no error, result %s; want code %d", got.Result, tt.code)
+			}
+			if got.Error.Code != tt.code {
+				t.Errorf("code = %d, want %d", got.Error.Code, tt.code)
+			}
+			if string(got.ID) != tt.id {
+				t.Errorf("id = %s, want %s", got.ID, tt.id)
+			}
+		})
+	}
+}
+
+
+// TestRPCMethods checks calls that succeed, by named and positional params
+func TestRPCMethods(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		body   string
+		result string // JSON of the result
+	}{
+		{"get by name", `{"jsonrpc":"2.0","method":"todos.get","params":{"id":1},"id":1}`, `"buy milk"`},
+		{"get by position", `{"jsonrpc":"2.0","method":"todos.get","params":[1],"id":1}`, `"buy milk"`},
+		{"create by position", `{"jsonrpc":"2.0","method":"todos.create","params":["walk dog"],"id":1}`, `"walk dog"`},
+		{"complete", `{"jsonrpc":"2.0","method":"todos.complete","params":{"id":1},"id":1}`, `"buy milk"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			s.Seed(apitest.NewSeed().Todo("buy milk"))
+
+			var got rpcAnswer
+			s.Post("/rpc", tt.body).ExpectStatus(http.StatusOK).Decode(&got)
+			if got.Error != nil {
+				t.Fatalf("error code %d", got.Error.Code)
+			}
+			var todo struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(got.Result, &todo); err != nil {
+				t.Fatalf("result %s: %v", got.Result, err)
+			}
+			if want, _ := json.Marshal(todo.Title); string(want) != tt.result {
+				t.Errorf("title = %s, want %s", want, tt.result)
+			}
+		})
+	}
+
+	t.Run("list filtered", func(t *testing.T) {
+		s := apitest.New(t)
+		s.Seed(apitest.NewSeed().Todo("open").Done("closed"))
+		var got rpcAnswer
+		s.Post("/rpc", `{"jsonrpc":"2.0","method":"todos.list","params":[true],"id":1}`).Decode(&got)
+		var todos []struct {
+			Title string `json:"title"`
+		}
+		json.Unmarshal(got.Result, &todos)
+		if len(todos) != 1 || todos[0].Title != "closed" {
+			t.Errorf("todos.list [true] = %s, want just the done one", got.Result)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := apitest.New(t)
+		s.Seed(apitest.NewSeed().Todo("buy milk"))
+		s.Post("/rpc", `{"jsonrpc":"2.0","method":"todos.delete","params":[1],"id":1}`).
+			ExpectJSON(`{"jsonrpc":"2.0","result":true,"id":1}`)
+		s.Get("/todos/get?id=1").ExpectStatus(http.StatusNotFound)
+	})
+}
+
+
+// TestRPCNotificationsAndBatches checks calls without an id get no answer, alone or in a batch
+func TestRPCNotificationsAndBatches(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string // the whole answer, when there's one
+	}{
+		{"notification", `{"jsonrpc":"2.0","method":"todos.create","params":["quiet"]}`, http.StatusNoContent, ""},
+		{"failing notification", `{"jsonrpc":"2.0","method":"nope"}`, http.StatusNoContent, ""},
+		{"batch of notifications", `[{"jsonrpc":"2.0","method":"nope"},{"jsonrpc":"2.0","method":"todos.list"}]`, http.StatusNoContent, ""},
+		{"batch", `[{"jsonrpc":"2.0","method":"nope","id":1},{"jsonrpc":"2.0","method":"todos.list"},{"jsonrpc":"2.0","method":"todos.delete","params":[1],"id":2},5]`,
+			http.StatusOK,
+			`[{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: nope"},"id":1},` +
+				`{"jsonrpc":"2.0","result":true,"id":2},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			s.Seed(apitest.NewSeed().Todo("buy milk"))
+			r := s.Post("/rpc", tt.body).ExpectStatus(tt.status)
+			if tt.want != "" {
+				r.ExpectJSON(tt.want)
+			} else if len(r.Body) != 0 {
+				t.Errorf("body %s, want none", r.Body)
+			}
+		})
+	}
+}
+
+
+// TestRPCMethodNotAllowed checks /rpc only takes POST
+func TestRPCMethodNotAllowed(t *testing.T) {
+	s := apitest.New(t)
+	s.Get("/rpc").ExpectStatus(http.StatusMethodNotAllowed)
+}
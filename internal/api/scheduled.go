@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"       // for the store calls outside a request
+	"encoding/json" // for JSON responses
+	"errors"        // for telling failures worth another try
+	"fmt"           // for printing logs to terminal and errors
+	"net/http"      // for HTTP handlers
+	"sort"          // for the list's order
+	"strconv"       // for the id in the path
+	"sync"          // for guarding the pending todos
+	"time"          // for creation times
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the actor and tenant
+)
+
+// scheduled todos: POST /todos/create with a create_at in the future doesn't create the todo yet,
+// it answers 202 with a pending todo at /scheduled/{id}, and the todo only appears (in lists, the
+// change feed, webhooks...) at create_at, made by whoever scheduled it. pending todos are made by
+// the server running the scheduled jobs (see scheduler.go), so they're made once: another server
+// that has some (one that accepted them without the Redis lease, a cluster leader since replaced)
+// hands them over to it, see handoff.go. -scheduled-file keeps them across restarts, in memory
+// without it. a creation that fails for a while (e.g. 503, maintenance) is tried again every
+// second; one the store refuses for good is dropped with a log line
+var scheduledFile = flags.String("scheduled-file", "", "JSON file the scheduled todos are kept in until they're created (in memory when empty)")
+
+// how often pending todos are checked for being due
+const scheduledCheckEvery = time.Second
+
+// ScheduledTodo is a todo waiting for its create_at
+type ScheduledTodo struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Due         *time.Time `json:"due,omitempty"`
+	List        string     `json:"list,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreateAt    time.Time  `json:"create_at"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	By          string     `json:"by,omitempty"`         // who scheduled it, and creates it
+	LastError   string     `json:"last_error,omitempty"` // why it's late, when creating it failed
+	tenant      string     // whose it is, "" for the default tenant, see tenants.go
+}
+
+// storedScheduled is a pending todo as -scheduled-file keeps it and it's handed over, with its tenant
+type storedScheduled struct {
+	ScheduledTodo
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// pending todos, same in-memory pattern as saved filters
+var scheduledTodos = make(map[int]ScheduledTodo)
+var scheduledTodosMu sync.Mutex
+var nextScheduledID = 1
+
+
+// scheduleTodo keeps a todo for creating at createAt; a due in words is read as of then
+func scheduleTodo(r *http.Request, req CreateTodoRequest, createAt time.Time) (ScheduledTodo, error) {
+
+	if req.duePhrase != "" {
+		loc, err := requestLocation(r)
+		if err != nil {
+			return ScheduledTodo{}, err
+		}
+		due, err := resolveDue(req.duePhrase, createAt, loc)
+		if err != nil {
+			return ScheduledTodo{}, model.Invalid("due", err.Error())
+		}
+		req.Due = &due
+	}
+
+	// refuse now what the store would refuse then (hooks aside)
+	if err := (model.Todo{Title: req.Title, Due: req.Due, List: req.List, Tags: req.Tags}).Validate(); err != nil {
+		return ScheduledTodo{}, err
+	}
+
+	scheduledTodosMu.Lock()
+	defer scheduledTodosMu.Unlock()
+	pending := ScheduledTodo{
+		ID: nextScheduledID, Title: req.Title, Due: req.Due, List: req.List, Tags: req.Tags, CreateAt: createAt, ScheduledAt: todoStore.Now(),
+		By: store.ActorFrom(r.Context()), tenant: store.TenantFrom(r.Context()),
+	}
+	scheduledTodos[pending.ID] = pending
+	nextScheduledID++
+	saveScheduled()
+	return pending, nil
+}
+
+
+// loadScheduled reads the pending todos -scheduled-file keeps; a file not made yet has none
+func loadScheduled() error {
+
+	if *scheduledFile == "" {
+		return nil
+	}
+	var stored []storedScheduled
+	if _, err := readStateFile(*scheduledFile, &stored); err != nil {
+		return fmt.Errorf("-scheduled-file: %w", err)
+	}
+
+	scheduledTodosMu.Lock()
+	defer scheduledTodosMu.Unlock()
+	scheduledTodos, nextScheduledID = make(map[int]ScheduledTodo), 1
+	for _, s := range stored {
+		pending := s.ScheduledTodo
+		pending.tenant = s.Tenant
+		scheduledTodos[pending.ID] = pending
+		nextScheduledID = max(nextScheduledID, pending.ID+1)
+	}
+	return nil
+}
+
+
+// saveScheduled writes the pending todos to -scheduled-file, see statefile.go (caller holds
+// scheduledTodosMu)
+func saveScheduled() {
+
+	if *scheduledFile == "" {
+		return
+	}
+	stored := make([]storedScheduled, 0, len(scheduledTodos))
+	for _, pending := range scheduledTodos {
+		stored = append(stored, storedScheduled{ScheduledTodo: pending, Tenant: pending.tenant})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	if err := writeStateFile(*scheduledFile, stored); err != nil {
+		fmt.Println("scheduled todos: saving", *scheduledFile, "failed:", err)
+	}
+}
+
+
+// receiveScheduledTodo keeps a pending todo another server handed over, under an id of this one's
+func receiveScheduledTodo(item json.RawMessage) error {
+
+	var s storedScheduled
+	if err := json.Unmarshal(item, &s); err != nil {
+		return err
+	}
+	pending := s.ScheduledTodo
+	pending.tenant, pending.LastError = s.Tenant, ""
+
+	scheduledTodosMu.Lock()
+	defer scheduledTodosMu.Unlock()
+	pending.ID = nextScheduledID
+	scheduledTodos[pending.ID] = pending
+	nextScheduledID++
+	saveScheduled()
+	return nil
+}
+
+
+// handOffScheduledTodos hands every pending todo to the server running the scheduled jobs; those
+// it can't are kept, their last_error saying why
+func handOffScheduledTodos() {
+
+	scheduledTodosMu.Lock()
+	list := make([]ScheduledTodo, 0, len(scheduledTodos))
+	for _, pending := range scheduledTodos {
+		list = append(list, pending)
+	}
+	scheduledTodosMu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	soonestFirst(list)
+
+	items := make([]json.RawMessage, len(list))
+	for i, pending := range list {
+		items[i], _ = json.Marshal(storedScheduled{ScheduledTodo: pending, Tenant: pending.tenant})
+	}
+	err := handOff("scheduled", items)
+
+	scheduledTodosMu.Lock()
+	defer scheduledTodosMu.Unlock()
+	for _, pending := range list {
+		if _, ok := scheduledTodos[pending.ID]; !ok {
+			continue
+		}
+		if err != nil {
+			pending.LastError = "waiting to hand over to the server making scheduled todos: " + err.Error()
+			scheduledTodos[pending.ID] = pending
+			continue
+		}
+		delete(scheduledTodos, pending.ID)
+	}
+	saveScheduled()
+}
+
+
+// scheduledTodo looks up one of the ctx tenant's pending todos, model.ErrNotFound if there is none
+func scheduledTodo(ctx context.Context, id int) (ScheduledTodo, error) {
+	scheduledTodosMu.Lock()
+	defer scheduledTodosMu.Unlock()
+
+	pending, ok := scheduledTodos[id]
+	if !ok || pending.tenant != store.TenantFrom(ctx) {
+		return ScheduledTodo{}, fmt.Errorf("scheduled todo %d: %w", id, model.ErrNotFound)
+	}
+	return pending, nil
+}
+
+
+// soonestFirst sorts pending todos by create_at, then as they were scheduled
+func soonestFirst(list []ScheduledTodo) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreateAt.Equal(list[j].CreateAt) {
+			return list[i].CreateAt.Before(list[j].CreateAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+
+// startScheduledTodos creates the pending todos as they come due, while this server runs the
+// scheduled jobs, and hands them over while another one does
+func startScheduledTodos() {
+	go func() {
+		for range time.Tick(scheduledCheckEvery) {
+			if !scheduling() {
+				handOffScheduledTodos()
+				continue
+			}
+			takeHandoffs("scheduled")
+			createScheduledTodos(todoStore.Now())
+		}
+	}()
+}
+
+
+// createScheduledTodos creates the pending todos due by now, earliest first; each is taken out
+// while it's being made, so cancelling it meanwhile finds nothing rather than racing the creation,
+// and -scheduled-file forgets it once it's made
+func createScheduledTodos(now time.Time) {
+
+	scheduledTodosMu.Lock()
+	due := []ScheduledTodo{}
+	for id, pending := range scheduledTodos {
+		if !pending.CreateAt.After(now) {
+			due = append(due, pending)
+			delete(scheduledTodos, id)
+		}
+	}
+	scheduledTodosMu.Unlock()
+	soonestFirst(due)
+
+	for _, pending := range due {
+		ctx := store.WithTenant(store.WithActor(context.Background(), pending.By), pending.tenant)
+		_, err := todoStore.Create(ctx, model.Todo{Title: pending.Title, Due: pending.Due, List: pending.List, Tags: pending.Tags})
+		scheduledTodosMu.Lock()
+		switch {
+		case err == nil:
+		case errors.Is(err, model.ErrUnavailable):
+			// kept for the next check, with why it's late
+			pending.LastError = err.Error()
+			scheduledTodos[pending.ID] = pending
+		default:
+			fmt.Println("scheduled todos: dropping", pending.ID, "the store refused it:", err)
+		}
+		saveScheduled()
+		scheduledTodosMu.Unlock()
+	}
+}
+
+
+// GET /scheduled lists the pending todos, soonest first
+func scheduledTodosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	scheduledTodosMu.Lock()
+	list := make([]ScheduledTodo, 0, len(scheduledTodos))
+	for _, pending := range scheduledTodos {
+		if pending.tenant == store.TenantFrom(r.Context()) {
+			list = append(list, pending)
+		}
+	}
+	scheduledTodosMu.Unlock()
+
+	soonestFirst(list)
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// GET shows one pending todo, DELETE cancels it
+func scheduledTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("id", "must be an integer"))
+		return
+	}
+	pending, err := scheduledTodo(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(pending)
+
+	case http.MethodDelete:
+		// it may have been created meanwhile
+		scheduledTodosMu.Lock()
+		_, exists := scheduledTodos[id]
+		delete(scheduledTodos, id)
+		saveScheduled()
+		scheduledTodosMu.Unlock()
+		if !exists {
+			writeError(w, r, fmt.Errorf("scheduled todo %d: %w", id, model.ErrNotFound))
+			return
+		}
+
+		// 204 = success with no response body
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"crypto/rand"  // for naming this replica's lease
+	"encoding/hex" // for the name
+	"fmt"          // for printing logs to terminal
+	"os"           // for the host name
+	"strconv"      // for the lease's length
+	"sync/atomic"  // for the lease's end
+	"time"         // for renewing it
+)
+
+// one server runs the scheduled jobs (digests, overdue reminders, erasures, the janitor and the
+// evictor), so that with several of them the jobs don't run once each: in a cluster the leader,
+// whose log the jobs write to anyway, and among replicas sharing Redis whichever holds the lease
+// <prefix>scheduler, renewed a third of -scheduler-lease at a time. the others' jobs tick on and
+// skip their turn, ready to take over. a lone server always runs them
+var schedulerLease = flags.Duration("scheduler-lease", 15*time.Second, "while replicas share Redis, how long the lease on running the scheduled jobs lasts unless renewed")
+
+// renews the lease if ARGV[1] holds it, or takes it when nobody does; 1 when ARGV[1] has it
+const redisLeaseScript = `
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if not holder then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0`
+
+// gives the lease up if ARGV[1] holds it
+const redisReleaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+end
+return 0`
+
+// this process, as the lease's holder, and when the lease it holds ends (unix nanoseconds)
+var leaseHolder = newLeaseHolder()
+var leaseUntil atomic.Int64
+
+
+// newLeaseHolder names this process: its host, and a random part for several on one host
+func newLeaseHolder() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+
+// scheduling reports whether this server runs the scheduled jobs now
+func scheduling() bool {
+	switch {
+	case cluster != nil:
+		return cluster.IsLeader()
+	case shared != nil:
+		return time.Now().UnixNano() < leaseUntil.Load()
+	}
+	return true
+}
+
+
+// startSchedulerLease competes for the lease, while replicas share Redis
+func startSchedulerLease() {
+
+	if shared == nil {
+		return
+	}
+
+	go func() {
+		for {
+			renewLease()
+			time.Sleep(*schedulerLease / 3)
+		}
+	}()
+}
+
+
+// renewLease takes or renews the lease; it counts from before asking, so it ends here no later
+// than in Redis
+func renewLease() {
+
+	asked := time.Now()
+	shared.mu.Lock()
+	reply, err := shared.command("EVAL", redisLeaseScript, "1", *redisPrefix+"scheduler",
+		leaseHolder, strconv.FormatInt(schedulerLease.Milliseconds(), 10))
+	shared.mu.Unlock()
+
+	held := scheduling()
+	if err != nil || reply != int64(1) {
+		// a lease not renewed runs out by itself; Redis may still have renewed it
+		if err == nil {
+			leaseUntil.Store(0)
+		}
+		if held && !scheduling() {
+			fmt.Println("scheduler: another replica runs the scheduled jobs now")
+		}
+		return
+	}
+	leaseUntil.Store(asked.Add(*schedulerLease).UnixNano())
+	if !held {
+		fmt.Println("scheduler: running the scheduled jobs as", leaseHolder)
+	}
+}
+
+
+// releaseSchedulerLease hands the lease to the other replicas straight away, on shutdown
+func releaseSchedulerLease() {
+
+	if shared == nil || !scheduling() {
+		return
+	}
+	leaseUntil.Store(0)
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	shared.command("EVAL", redisReleaseScript, "1", *redisPrefix+"scheduler", leaseHolder)
+}
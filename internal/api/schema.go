@@ -0,0 +1,319 @@
+package api
+
+import (
+	"bytes"         // for re-reading the body
+	"embed"         // for the published schemas
+	"encoding/json" // for parsing bodies and schemas
+	"errors"        // for empty bodies
+	"fmt"           // for messages
+	"io"            // for reading bodies
+	"net/http"      // for the schema endpoints
+	"path"          // for schema names
+	"slices"        // for listing schemas
+	"strconv"       // for array indexes in pointers
+	"strings"       // for pointer escaping
+	"time"          // for date-time formats
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for validation errors
+)
+
+// request bodies are checked against JSON Schemas before they're decoded, so a misspelt field
+// or a string where a boolean belongs is a 400 naming the spot (a JSON Pointer like
+// /changes/2/done) instead of being dropped or a decoder message. the schemas are served under
+// /schemas/, for clients to validate against too. what the schema can't say (a blank title,
+// a due date in words that can't be read) is still checked by the handler and the store
+
+// the published schemas, one file per body
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// jsonSchema is the part of JSON Schema (2020-12) the bodies use
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"` // false, or a schema for the values
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []string               `json:"enum"`
+	Format               string                 `json:"format"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MaxItems             *int                   `json:"maxItems"`
+	Minimum              *float64               `json:"minimum"`
+	additional           *jsonSchema            // parsed AdditionalProperties, nil when false or absent
+	closed               bool                   // additionalProperties: false
+}
+
+// schemaTypes is "type": one name or a list of them
+type schemaTypes []string
+
+// largest body checked against a schema
+const maxSchemaBody = 8 << 20
+
+// the schemas by name ("todo-create"), parsed once
+var requestSchemas = mustLoadSchemas()
+
+
+// UnmarshalJSON takes "string" as well as ["string", "null"]
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+
+// mustLoadSchemas parses the embedded schemas; they're part of the build, so a broken one panics
+func mustLoadSchemas() map[string]*jsonSchema {
+
+	loaded := map[string]*jsonSchema{}
+	entries, _ := schemaFiles.ReadDir("schemas")
+	for _, entry := range entries {
+		data, _ := schemaFiles.ReadFile("schemas/" + entry.Name())
+		var s jsonSchema
+		if err := json.Unmarshal(data, &s); err != nil {
+			panic("schema " + entry.Name() + ": " + err.Error())
+		}
+		if err := s.prepare(); err != nil {
+			panic("schema " + entry.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = &s
+	}
+	return loaded
+}
+
+
+// prepare reads additionalProperties, here and below
+func (s *jsonSchema) prepare() error {
+
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); raw {
+	case "", "true":
+	case "false":
+		s.closed = true
+	default:
+		s.additional = &jsonSchema{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+			return err
+		}
+		if err := s.additional.prepare(); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.Properties {
+		if err := p.prepare(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.prepare()
+	}
+	return nil
+}
+
+
+// decodeBody reads a JSON body, checks it against the named schema and decodes it into v.
+// an empty body is io.EOF, for handlers where it is optional
+func decodeBody(r *http.Request, schema string, v any) error {
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSchemaBody+1))
+	if err != nil {
+		return model.Invalid("body", err.Error())
+	}
+	if len(data) > maxSchemaBody {
+		return model.Invalid("body", "is larger than 8 MiB")
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return io.EOF
+	}
+
+	// numbers stay as written, so 1.5 can be refused where an integer belongs
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return model.Invalid("body", "is not valid JSON: "+err.Error())
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return model.Invalid("body", "has data after the JSON value")
+	}
+
+	invalid := &model.ValidationError{}
+	requestSchemas[schema].check(doc, "", invalid)
+	if len(invalid.Fields) > 0 {
+		return invalid
+	}
+
+	// what the schema let through decodes; custom decoders may still refuse a value
+	if err := json.Unmarshal(data, v); err != nil {
+		return model.Invalid("body", err.Error())
+	}
+	return nil
+}
+
+
+// bodyError is decodeBody's error for a body that isn't optional: an empty one is a 400 too
+func bodyError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return model.Invalid("body", "is required")
+	}
+	return err
+}
+
+
+// check adds the ways v breaks s to invalid, each at its JSON Pointer
+func (s *jsonSchema) check(v any, pointer string, invalid *model.ValidationError) {
+
+	fail := func(msg string) {
+		field := pointer
+		if field == "" {
+			field = "body"
+		}
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: field, Message: msg})
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		fail(typeMessage(s.Type))
+		return
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("is shorter than " + strconv.Itoa(*s.MinLength) + " characters")
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("is longer than " + strconv.Itoa(*s.MaxLength) + " characters")
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+			fail("must be one of " + strings.Join(s.Enum, ", "))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("must be an RFC 3339 time")
+			}
+		}
+
+	case json.Number:
+		if s.Minimum != nil {
+			if f, err := v.Float64(); err == nil && f < *s.Minimum {
+				fail("must be at least " + strconv.FormatFloat(*s.Minimum, 'f', -1, 64))
+			}
+		}
+
+	case []any:
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("has more than " + strconv.Itoa(*s.MaxItems) + " entries")
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(item, pointer+"/"+strconv.Itoa(i), invalid)
+			}
+		}
+
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				invalid.Fields = append(invalid.Fields, model.FieldError{Field: pointer + "/" + escapePointer(name), Message: "is required"})
+			}
+		}
+
+		// in the body's own order would be nicer, but maps don't keep it: sorted, so it's stable
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			at := pointer + "/" + escapePointer(name)
+			switch prop, ok := s.Properties[name]; {
+			case ok:
+				prop.check(v[name], at, invalid)
+			case s.additional != nil:
+				s.additional.check(v[name], at, invalid)
+			case s.closed:
+				invalid.Fields = append(invalid.Fields, model.FieldError{Field: at, Message: "is not allowed"})
+			}
+		}
+	}
+}
+
+
+// hasType reports whether a decoded value is of a JSON Schema type
+func hasType(v any, t string) bool {
+
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		_, err := v.Int64()
+		return t == "integer" && err == nil
+	}
+	return false
+}
+
+
+// typeMessage says what a value should have been: "must be a boolean", "must be a string or null"
+func typeMessage(types schemaTypes) string {
+
+	names := map[string]string{
+		"string": "a string", "boolean": "a boolean", "integer": "an integer", "number": "a number",
+		"object": "an object", "array": "an array", "null": "null",
+	}
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = names[t]
+	}
+	return "must be " + strings.Join(parts, " or ")
+}
+
+
+// escapePointer escapes a property name for a JSON Pointer (RFC 6901)
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+
+// GET /schemas/ lists the published schemas, GET /schemas/<name>.json serves one
+func schemasHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/schemas/")
+	if name == "" {
+		names := make([]string, 0, len(requestSchemas))
+		for name := range requestSchemas {
+			names = append(names, "/schemas/"+name+".json")
+		}
+		slices.Sort(names)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(names)
+		return
+	}
+
+	data, err := schemaFiles.ReadFile("schemas/" + name)
+	if err != nil || strings.Contains(name, "/") || path.Ext(name) != ".json" {
+		writeError(w, r, fmt.Errorf("schema %s: %w", name, model.ErrNotFound))
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(data)
+}
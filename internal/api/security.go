@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http" // for HTTP handlers
+	"strconv"  // for int -> string conversion
+	"time"     // for HSTS max-age
+)
+
+// security header config (set a header flag to "" to leave that header out)
+var frameOptions = flags.String("frame-options", "DENY", "X-Frame-Options header value")
+var contentSecurityPolicy = flags.String("csp", "default-src 'self'; frame-ancestors 'none'", "Content-Security-Policy header value (applies to the web UI)")
+var hstsMaxAge = flags.Duration("hsts-max-age", 365*24*time.Hour, "Strict-Transport-Security max-age sent on TLS connections (0 disables)")
+
+
+// securityHeaders sets the configured security headers on every response
+func securityHeaders(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		h := w.Header()
+
+		// never let browsers guess a content type
+		h.Set("X-Content-Type-Options", "nosniff")
+
+		// no framing of our pages (clickjacking)
+		if *frameOptions != "" {
+			h.Set("X-Frame-Options", *frameOptions)
+		}
+
+		// restrict what the web UI may load
+		if *contentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", *contentSecurityPolicy)
+		}
+
+		// HSTS only makes sense (and is only honoured) over TLS
+		if r.TLS != nil && *hstsMaxAge > 0 {
+			h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(hstsMaxAge.Seconds()))+"; includeSubDomains")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"       // for store calls outside a request
+	"encoding/json" // for JSON responses
+	"fmt"           // for config errors and logs
+	"net/http"      // for HTTP handlers
+	"time"          // for due dates relative to now
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// sample data for demos and frontend work: -seed demo fills an empty store at startup,
+// POST /admin/seed adds the set again at any time
+var seedSet = flags.String("seed", "", "sample data to start with when the store is empty: demo (none when empty)")
+
+// seedTodo is one sample todo; due is relative to the day it's seeded
+type seedTodo struct {
+	title string
+	done  bool
+	days  int           // due this many days from today (negative is overdue)
+	at    time.Duration // time of day it's due, 0 = no due date
+	list  string
+	tags  []string
+}
+
+// the demo set: a mix of open and done, overdue, due today, later and undated, on a few lists
+// and with tags, so every filter, badge and grouping has something to show
+var seedSets = map[string][]seedTodo{
+	"demo": {
+		{title: "Renew passport", days: -3, at: 12 * time.Hour, list: "errands", tags: []string{"urgent"}},
+		{title: "Reply to the landlord about the boiler", days: -1, at: 18 * time.Hour, list: "home", tags: []string{"urgent"}},
+		{title: "Send Q3 report to finance", days: 0, at: 17 * time.Hour, list: "work", tags: []string{"finance"}},
+		{title: "Pick up dry cleaning", days: 0, at: 18*time.Hour + 30*time.Minute, list: "errands"},
+		{title: "Call mum", days: 0, at: 20 * time.Hour, tags: []string{"family"}},
+		{title: "Book dentist appointment", days: 1, at: 10 * time.Hour, list: "errands", tags: []string{"health"}},
+		{title: "Review pull request for the billing service", days: 1, at: 15 * time.Hour, list: "work", tags: []string{"code"}},
+		{title: "Buy a birthday present for Sam", days: 3, at: 12 * time.Hour, list: "errands", tags: []string{"family"}},
+		{title: "Prepare slides for Thursday's demo", days: 3, at: 9 * time.Hour, list: "work"},
+		{title: "Pay electricity bill", days: 5, at: 23*time.Hour + 59*time.Minute, list: "home", tags: []string{"finance"}},
+		{title: "Plan weekend hike", days: 6, at: 19 * time.Hour, tags: []string{"outdoors"}},
+		{title: "Submit expense claims", days: 14, at: 17 * time.Hour, list: "work", tags: []string{"finance"}},
+		{title: "Read \"The Pragmatic Programmer\"", tags: []string{"books", "code"}},
+		{title: "Clean out the garage", list: "home"},
+		{title: "Learn to make sourdough", tags: []string{"cooking"}},
+		{title: "Update CV", list: "work"},
+		{title: "Water the plants", done: true, days: -1, at: 9 * time.Hour, list: "home"},
+		{title: "Order new running shoes", done: true, tags: []string{"health", "outdoors"}},
+		{title: "Back up the laptop", done: true, days: -2, at: 21 * time.Hour, list: "home", tags: []string{"code"}},
+		{title: "Cancel unused streaming subscription", done: true, tags: []string{"finance"}},
+	},
+}
+
+
+// checkSeedSet validates -seed
+func checkSeedSet() error {
+	if _, ok := seedSets[*seedSet]; *seedSet != "" && !ok {
+		return fmt.Errorf("-seed: unknown sample data %q (known: demo)", *seedSet)
+	}
+	return nil
+}
+
+
+// seedStore creates the named set's todos through the store (so hooks and the change log see
+// them like any other), due dates counted from today on the store's clock
+func seedStore(ctx context.Context, set string) ([]model.Todo, error) {
+
+	now := todoStore.Now()
+	year, month, day := now.Date()
+
+	var created []model.Todo
+	for _, s := range seedSets[set] {
+		draft := model.Todo{Title: s.title, Done: s.done, List: s.list, Tags: s.tags}
+		if s.at > 0 {
+			// the wall clock time that day, also on a day the clocks change
+			due := time.Date(year, month, day+s.days, int(s.at/time.Hour), int(s.at%time.Hour/time.Minute), 0, 0, now.Location())
+			draft.Due = &due
+		}
+		todo, err := todoStore.Create(ctx, draft)
+		if err != nil {
+			return created, fmt.Errorf("seeding %q: %w", s.title, err)
+		}
+		created = append(created, todo)
+	}
+	return created, nil
+}
+
+
+// seedOnStart fills the store from -seed, unless it already has todos
+func seedOnStart() {
+
+	if *seedSet == "" || todoStore.Count() > 0 {
+		return
+	}
+	created, err := seedStore(context.Background(), *seedSet)
+	if err != nil {
+		fmt.Println("seed failed after", len(created), "todos:", err)
+		return
+	}
+	fmt.Println("seeded", len(created), "sample todos (-seed "+*seedSet+")")
+}
+
+// SeedResult is the body of POST /admin/seed
+type SeedResult struct {
+	Set   string       `json:"set"`
+	Todos []model.Todo `json:"todos"`
+}
+
+
+// admin: POST adds the sample todos (?set=demo, the default) to the store
+func seedHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	set := r.URL.Query().Get("set")
+	if set == "" {
+		set = "demo"
+	}
+	if _, ok := seedSets[set]; !ok {
+		writeError(w, r, model.Invalid("set", "unknown sample data (known: demo)"))
+		return
+	}
+
+	created, err := seedStore(r.Context(), set)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SeedResult{Set: set, Todos: created})
+}
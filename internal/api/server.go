@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net"      // for listeners
+	"net/http" // for HTTP server
+	"time"     // for server timeouts
+)
+
+// TLS and protocol config
+var tlsCert = flags.String("tls-cert", "", "TLS certificate file (serves HTTPS when set together with -tls-key)")
+var tlsKey = flags.String("tls-key", "", "TLS private key file")
+var enableHTTP2 = flags.Bool("http2", true, "negotiate HTTP/2 on TLS connections")
+var enableH2C = flags.Bool("h2c", false, "accept cleartext HTTP/2 (prior knowledge), for deployments behind an h2c-capable proxy")
+
+
+// tlsEnabled reports whether HTTPS is configured
+func tlsEnabled() bool {
+	return *tlsCert != "" && *tlsKey != ""
+}
+
+
+// newHTTPServer builds the public server with the configured protocols
+func newHTTPServer(handler http.Handler) *http.Server {
+
+	// HTTP/1.1 always, HTTP/2 over TLS and h2c only when enabled
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(*enableHTTP2)
+	protocols.SetUnencryptedHTTP2(*enableH2C)
+
+	return &http.Server{
+		Handler:           handler,
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 10 * time.Second, // don't let slow clients hold connections forever
+	}
+}
+
+
+// serve runs srv on one listener, with TLS if configured
+func serve(srv *http.Server, ln net.Listener) error {
+	if tlsEnabled() {
+		return srv.ServeTLS(ln, *tlsCert, *tlsKey)
+	}
+	return srv.Serve(ln)
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"         // for rendering before writing
+	"encoding/json" // for JSON responses
+	"fmt"           // for not found errors and translations
+	"html/template" // for the shared page, escaped
+	"net/http"      // for HTTP handlers
+	"sort"          // for stable list order
+	"sync"          // for guarding the links
+	"time"          // for timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+)
+
+// read-only links to a saved filter, the closest thing to a list: anyone with the token can see
+// its todos at GET /share/{token}, as JSON or a page, and nothing else. the token is random and
+// is the only key, so it's shown once when minted; revoking a link (or deleting the filter) ends it
+
+// random bytes in a share token
+const shareTokenBytes = 16
+
+// ShareLink is a minted link, the token only set in the POST response
+type ShareLink struct {
+	FilterID  int       `json:"filter_id"`
+	Token     string    `json:"token,omitempty"`
+	Path      string    `json:"path,omitempty"` // /share/{token}
+	Prefix    string    `json:"prefix"`         // first characters of the token, to tell links apart
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SharedList is the JSON body of GET /share/{token}
+type SharedList struct {
+	Name  string       `json:"name"`
+	Todos []model.Todo `json:"todos"`
+	Time  time.Time    `json:"time"` // when it was read
+}
+
+// share links by token, same in-memory pattern as the saved filters
+var shareLinks = make(map[string]ShareLink)
+var shareLinksMu sync.Mutex
+
+// the read-only page, strings in the client's language
+var sharePage = template.Must(template.New("share").Parse(`<!doctype html>
+<html lang="{{.Lang}}">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>{{.List.Name}}</title>
+</head>
+<body>
+	<main>
+		<h1>{{.List.Name}}</h1>
+		<ul>{{range .List.Todos}}
+			<li><label><input type="checkbox" disabled{{if .Done}} checked{{end}}> {{.Title}}</label>{{with .Due}} <time datetime="{{.Format "2006-01-02T15:04:05Z07:00"}}">{{(.In $.Loc).Format "2006-01-02 15:04"}}</time>{{end}}</li>{{else}}
+			<li>{{.Empty}}</li>{{end}}
+		</ul>
+		<p>{{.Count}} · {{.ReadOnly}}</p>
+	</main>
+</body>
+</html>
+`))
+
+// what the page template sees
+type sharePageData struct {
+	Lang                   string
+	List                   SharedList
+	Loc                    *time.Location
+	Empty, Count, ReadOnly string
+}
+
+
+// dropShareLinks revokes every link to a filter
+func dropShareLinks(filterID int) {
+	shareLinksMu.Lock()
+	defer shareLinksMu.Unlock()
+
+	for token, link := range shareLinks {
+		if link.FilterID == filterID {
+			delete(shareLinks, token)
+		}
+	}
+}
+
+
+// GET lists the links to the {id} filter (without tokens), POST mints one
+func filterSharesHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := savedFilterID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if _, err := savedFilter(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shareLinksMu.Lock()
+		list := []ShareLink{}
+		for _, link := range shareLinks {
+			if link.FilterID == id {
+				list = append(list, link)
+			}
+		}
+		shareLinksMu.Unlock()
+
+		sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		token := randomHex(shareTokenBytes)
+		link := ShareLink{FilterID: id, Prefix: token[:8], CreatedAt: todoStore.Now()}
+		shareLinksMu.Lock()
+		shareLinks[token] = link
+		shareLinksMu.Unlock()
+
+		link.Token, link.Path = token, "/share/"+token
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(link)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// DELETE /filters/{id}/shares/{prefix} revokes a link, named by its token or the token's prefix
+func deleteFilterShareHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := savedFilterID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	prefix := r.PathValue("prefix")
+
+	shareLinksMu.Lock()
+	found := false
+	for token, link := range shareLinks {
+		if link.FilterID == id && (token == prefix || link.Prefix == prefix) {
+			delete(shareLinks, token)
+			found = true
+		}
+	}
+	shareLinksMu.Unlock()
+	if !found {
+		writeError(w, r, fmt.Errorf("share link %s of filter %d: %w", prefix, id, model.ErrNotFound))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// GET /share/{token} shows the shared filter's todos: JSON, or a page for ?format=html and for
+// browsers, which ask for HTML first
+func shareHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// shared pages are nobody's business but the holder's
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		htmlQ, named := acceptQuality(r.Header.Get("Accept"), "text/html")
+		if jsonQ, _ := acceptQuality(r.Header.Get("Accept"), "application/json"); named && htmlQ > jsonQ {
+			format = "html"
+		}
+	}
+	if format != "json" && format != "html" {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, model.Invalid("format", "must be json or html"))
+		return
+	}
+
+	// an unknown or revoked token and a deleted filter look the same
+	shareLinksMu.Lock()
+	link, ok := shareLinks[r.PathValue("token")]
+	shareLinksMu.Unlock()
+	saved, err := savedFilter(r.Context(), link.FilterID)
+	if !ok || err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, fmt.Errorf("share link: %w", model.ErrNotFound))
+		return
+	}
+
+	todos, err := savedFilterTodos(r, saved)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, err)
+		return
+	}
+	list := SharedList{Name: saved.Name, Todos: todos, Time: todoStore.Now()}
+	if list.Todos == nil {
+		list.Todos = []model.Todo{}
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+
+	lang := requestLanguage(r)
+	loc, _ := requestLocation(r) // checked above
+	done := 0
+	for _, todo := range list.Todos {
+		if todo.Done {
+			done++
+		}
+	}
+	data := sharePageData{
+		Lang:     lang,
+		List:     list,
+		Loc:      loc,
+		Empty:    translate(lang, "Nothing here yet"),
+		Count:    fmt.Sprintf(translate(lang, "%d open, %d done"), len(list.Todos)-done, done),
+		ReadOnly: translate(lang, "Shared read-only"),
+	}
+	var buf bytes.Buffer
+	if err := sharePage.Execute(&buf, data); err != nil {
+		fmt.Println("share render failed:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"         // for request bodies
+	"encoding/json" // for config and Slack payloads
+	"errors"        // for API errors
+	"net/http"      // for posting to Slack
+	"os"            // for reading the config file
+	"slices"        // for event matching
+	"strings"       // for title matching
+	"text/template" // for message templates
+	"time"          // for client timeout
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// Slack integration (off unless a config file is given)
+var slackConfigPath = flags.String("slack-config", "", "JSON file configuring Slack notifications (disabled when empty)")
+
+// Slack Web API endpoint for bot tokens
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// default message per notification kind; templates see .Kind, .Todo, .Time and, for streaks, .Streak
+var defaultSlackTemplates = map[string]string{
+	model.EventCreated:    `New todo: *{{.Todo.Title}}*{{if .Todo.Due}} (due {{.Todo.Due.Format "Jan 2 15:04"}}){{end}}`,
+	model.ChangeCompleted: `:white_check_mark: Completed: *{{.Todo.Title}}*`,
+	notifyOverdue:         `:warning: Overdue: *{{.Todo.Title}}* was due {{.Todo.Due.Format "Jan 2 15:04"}}`,
+	notifyStreak:          `:fire: {{.Streak.Actor}} is on a {{.Streak.Days}}-day streak, most recently with *{{.Todo.Title}}*`,
+}
+
+// SlackConfig is the -slack-config file
+type SlackConfig struct {
+	WebhookURL     string            `json:"webhook_url"`     // incoming webhook, posts to its own channel
+	Token          string            `json:"token"`           // bot token (xoxb-...), posts to any channel via chat.postMessage
+	DefaultChannel string            `json:"default_channel"` // channel for the bot token when no route sets one
+	Events         []string          `json:"events"`          // kinds to post, defaults to created, completed, overdue
+	Templates      map[string]string `json:"templates"`       // per kind, overriding the defaults
+	Routes         []SlackRoute      `json:"routes"`          // first match wins
+}
+
+// SlackRoute sends matching notifications somewhere other than the default
+type SlackRoute struct {
+	Events        []string `json:"events"`         // kinds this route applies to, empty = all
+	TitleContains string   `json:"title_contains"` // case-insensitive filter on the title
+	Channel       string   `json:"channel"`        // for the bot token
+	WebhookURL    string   `json:"webhook_url"`    // for incoming webhooks, one per channel
+}
+
+// parsed config and templates
+var slackConfig SlackConfig
+var slackTemplates = make(map[string]*template.Template)
+
+// client used for every Slack call
+var slackClient = &http.Client{Timeout: 10 * time.Second}
+
+
+// setupSlack reads -slack-config and registers the Slack notifier
+func setupSlack() error {
+
+	// nothing to do if Slack is disabled
+	if *slackConfigPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(*slackConfigPath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &slackConfig); err != nil {
+		return errors.New("slack config: " + err.Error())
+	}
+	if slackConfig.WebhookURL == "" && slackConfig.Token == "" {
+		return errors.New("slack config: set webhook_url or token")
+	}
+	if len(slackConfig.Events) == 0 {
+		slackConfig.Events = []string{model.EventCreated, model.ChangeCompleted, notifyOverdue}
+	}
+
+	// defaults first, then the file's overrides
+	for kind, text := range defaultSlackTemplates {
+		slackTemplates[kind] = template.Must(template.New(kind).Parse(text))
+	}
+	for kind, text := range slackConfig.Templates {
+		tmpl, err := template.New(kind).Parse(text)
+		if err != nil {
+			return errors.New("slack config: template " + kind + ": " + err.Error())
+		}
+		slackTemplates[kind] = tmpl
+	}
+
+	addNotifier("slack", sendSlack)
+	return nil
+}
+
+
+// slackDestination picks the channel / webhook for a notification from the routing rules
+func slackDestination(n Notification) (channel, webhookURL string) {
+
+	channel, webhookURL = slackConfig.DefaultChannel, slackConfig.WebhookURL
+	for _, route := range slackConfig.Routes {
+		if len(route.Events) > 0 && !slices.Contains(route.Events, n.Kind) {
+			continue
+		}
+		if route.TitleContains != "" && !strings.Contains(strings.ToLower(n.Todo.Title), strings.ToLower(route.TitleContains)) {
+			continue
+		}
+		if route.Channel != "" {
+			channel = route.Channel
+		}
+		if route.WebhookURL != "" {
+			webhookURL = route.WebhookURL
+		}
+		break
+	}
+	return channel, webhookURL
+}
+
+
+// sendSlack renders and posts one notification
+func sendSlack(n Notification) error {
+
+	if !slices.Contains(slackConfig.Events, n.Kind) {
+		return nil
+	}
+	tmpl, ok := slackTemplates[n.Kind]
+	if !ok {
+		return errors.New("no template for " + n.Kind)
+	}
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, n); err != nil {
+		return err
+	}
+
+	channel, webhookURL := slackDestination(n)
+
+	// bot token: chat.postMessage answers 200 with {"ok": false, "error": ...} on failure
+	if slackConfig.Token != "" && channel != "" {
+		body, _ := json.Marshal(map[string]string{"channel": channel, "text": text.String()})
+		req, _ := http.NewRequest(http.MethodPost, slackPostMessageURL, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+slackConfig.Token)
+
+		resp, err := slackClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return err
+		}
+		if !result.OK {
+			return errors.New("slack: " + result.Error)
+		}
+		return nil
+	}
+
+	// incoming webhook
+	if webhookURL == "" {
+		return errors.New("no webhook_url or channel for " + n.Kind)
+	}
+	body, _ := json.Marshal(map[string]string{"text": text.String()})
+	resp, err := slackClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.New("slack webhook returned " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for conflict errors
+	"net/http"      // for HTTP handlers
+	"strconv"       // for the id in the path and day counts
+	"strings"       // for the days suffix
+	"sync"          // for guarding the history
+	"time"          // for snooze times
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for errors
+)
+
+// snoozing moves an open todo's due date forward (never back), by a duration or to a time. the overdue
+// notifier keys on the due date, so a snoozed todo is announced again when the new one passes.
+// every snooze is recorded, so how often todos get put off can be looked at later
+
+// longest a single snooze may put a todo off
+const maxSnooze = 365 * 24 * time.Hour
+
+// snoozes kept per todo; the count goes on past it
+const snoozeHistorySize = 100
+
+// SnoozeRequest is the body of POST /todos/{id}/snooze: one of for or until
+type SnoozeRequest struct {
+	For   string `json:"for"`   // e.g. "2h", "90m", "3d", from the due date (now, if it's past or unset)
+	Until string `json:"until"` // RFC 3339 or words like "monday 9am", in the client's time zone
+}
+
+// Snooze is one recorded snooze
+type Snooze struct {
+	At   time.Time  `json:"at"`            // when it was snoozed
+	From *time.Time `json:"from"`          // due date before, null if it had none
+	To   time.Time  `json:"to"`            // due date after
+	For  string     `json:"for,omitempty"` // as asked, when snoozed by a duration
+}
+
+// SnoozeHistory is the body of GET /todos/{id}/snooze
+type SnoozeHistory struct {
+	ID      int      `json:"id"`
+	Count   int      `json:"count"`   // every snooze, also ones no longer listed
+	Snoozes []Snooze `json:"snoozes"` // the latest ones, oldest first
+}
+
+// snooze history by todo id, same in-memory pattern as the saved filters
+var snoozes = make(map[int]*SnoozeHistory)
+var snoozesMu sync.Mutex
+
+
+// parseSnooze reads a snooze duration: a Go duration ("2h30m") or whole days ("3d")
+func parseSnooze(s string) (time.Duration, error) {
+
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	switch {
+	case err != nil:
+		return 0, model.Invalid("for", "must be a duration like 2h or 3d")
+	case d <= 0 || d > maxSnooze:
+		return 0, model.Invalid("for", "must be positive and at most 365 days")
+	}
+	return d, nil
+}
+
+
+// recordSnooze adds a snooze to the todo's history
+func recordSnooze(id int, s Snooze) {
+
+	snoozesMu.Lock()
+	defer snoozesMu.Unlock()
+
+	h := snoozes[id]
+	if h == nil {
+		h = &SnoozeHistory{ID: id}
+		snoozes[id] = h
+	}
+	h.Count++
+	h.Snoozes = append(h.Snoozes, s)
+	if len(h.Snoozes) > snoozeHistorySize {
+		h.Snoozes = h.Snoozes[len(h.Snoozes)-snoozeHistorySize:]
+	}
+}
+
+
+// POST snoozes the {id} todo ({"for": "2h"} or {"until": "tomorrow 9am"}) and returns it,
+// GET returns its snooze history
+func snoozeHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("id", "must be an integer"))
+		return
+	}
+
+	// 404 if todo doesn't exist, for both methods
+	todo, err := todoStore.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		snoozesMu.Lock()
+		history := SnoozeHistory{ID: id, Snoozes: []Snooze{}}
+		if h := snoozes[id]; h != nil {
+			history.Count, history.Snoozes = h.Count, append(history.Snoozes, h.Snoozes...)
+		}
+		snoozesMu.Unlock()
+		json.NewEncoder(w).Encode(history)
+		return
+
+	case http.MethodPost:
+		// below
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SnoozeRequest
+	if err := decodeBody(r, "snooze", &req); err != nil {
+		writeError(w, r, bodyError(err))
+		return
+	}
+	if (req.For == "") == (req.Until == "") {
+		writeError(w, r, model.Invalid("body", "needs one of for and until"))
+		return
+	}
+	if todo.Done {
+		writeError(w, r, fmt.Errorf("todo %d is done, there's nothing to snooze: %w", id, model.ErrConflict))
+		return
+	}
+
+	now := todoStore.Now()
+	var by time.Duration
+	var until *time.Time
+	if req.For != "" {
+		if by, err = parseSnooze(req.For); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	} else {
+		loc, err := requestLocation(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		t, err := resolveDue(req.Until, now, loc)
+		if err != nil {
+			writeError(w, r, model.Invalid("until", err.Error()))
+			return
+		}
+		until = &t
+		if !until.After(now) {
+			writeError(w, r, model.Invalid("until", "must be in the future"))
+			return
+		}
+		if todo.Due != nil && !until.After(*todo.Due) {
+			writeError(w, r, model.Invalid("until", "must be later than the due date"))
+			return
+		}
+		if until.Sub(now) > maxSnooze {
+			writeError(w, r, model.Invalid("until", "is more than 365 days away"))
+			return
+		}
+	}
+
+	// the new due date is worked out from the todo as stored, not as read above
+	var record Snooze
+	todo, err = todoStore.Update(r.Context(), id, func(t *model.Todo) {
+		record = Snooze{At: now, From: t.Due, For: req.For}
+		switch {
+		case until != nil:
+			record.To = *until
+		case t.Due != nil && t.Due.After(now):
+			record.To = t.Due.Add(by)
+		default:
+			record.To = now.Truncate(time.Second).Add(by)
+		}
+		to := record.To
+		t.Due = &to
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	recordSnooze(id, record)
+
+	json.NewEncoder(w).Encode(todo)
+}
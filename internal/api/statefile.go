@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json" // for the files' contents
+	"errors"        // for telling a file not made yet
+	"os"            // for reading and writing the files
+)
+
+// state files: what a feature keeps across restarts, and across a SIGHUP handoff to the new
+// process (recurring todos, pending erasures and scheduled todos), as one JSON file each, written
+// whole on every change through a temporary file, so a crash leaves the old one or the new one
+
+
+// readStateFile reads path into v; ok=false when there's no file yet
+func readStateFile(path string, v any) (bool, error) {
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+
+// writeStateFile replaces path with v
+func writeStateFile(path string, v any) error {
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
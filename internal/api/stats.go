@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json" // for JSON encode
+	"net/http"      // for HTTP handler
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the counters
+)
+
+// TodoStats is the answer to GET /todos/stats
+type TodoStats struct {
+	Total   int64               `json:"total"`
+	Open    int64               `json:"open"`
+	Done    int64               `json:"done"`
+	WithDue int64               `json:"with_due"`
+	Tags    map[string]TagStats `json:"tags,omitempty"` // by tag, for every tag a todo has
+}
+
+// TagStats counts the todos with one tag
+type TagStats struct {
+	Total int64 `json:"total"`
+	Open  int64 `json:"open"`
+	Done  int64 `json:"done"`
+}
+
+
+// countStats reads a store's counters into stats
+func countStats(st *store.Store) TodoStats {
+
+	total, done, withDue := st.Counts()
+	stats := TodoStats{Total: total, Open: max(total-done, 0), Done: done, WithDue: withDue}
+	for tag, c := range st.TagCounts() {
+		if stats.Tags == nil {
+			stats.Tags = make(map[string]TagStats)
+		}
+		stats.Tags[tag] = TagStats{Total: c.Total, Open: max(c.Total-c.Done, 0), Done: c.Done}
+	}
+	return stats
+}
+
+
+// counts of todos, read from the store counters: O(1) however many todos there are
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := countStats(storeFor(r))
+
+	if wantsProto(r) {
+		writeProto(w, marshalStatsProto(stats))
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"fmt"           // for the goal's bounds
+	"net/http"      // for HTTP handlers
+	"slices"        // for milestones
+	"strings"       // for actor names
+	"sync"          // for guarding the streaks
+	"time"          // for days in the actor's zone
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for events and errors
+)
+
+// completion streaks per actor (the Todo-Actor name, there being no accounts): the days in a row
+// on which the actor completed at least their daily goal of todos. completions are kept per UTC
+// hour for a year, so the days can be counted in the asking client's zone. when a completion
+// meets the goal and the streak reaches a milestone, a "streak" notification goes to the chat
+// integrations that post that kind, counted in the zone the actor last set their goal in
+var streakGoal = flags.Int("streak-goal", 1, "todos a day an actor has to complete to keep a streak going, unless they set their own")
+
+// streak lengths in days that are announced
+var streakMilestones = []int{3, 7, 14, 30, 50, 100, 200, 365}
+
+// notification kind of streak milestones
+const notifyStreak = "streak"
+
+// hours of completions kept per actor, and the highest goal one can set
+const (
+	streakHours   = 366 * 24
+	maxStreakGoal = 100
+)
+
+// StreakGoalRequest is the body of PUT /me/streak
+type StreakGoalRequest struct {
+	DailyGoal int `json:"daily_goal"`
+}
+
+// StreakMilestone is what a "streak" notification celebrates
+type StreakMilestone struct {
+	Actor string `json:"actor"`
+	Days  int    `json:"days"`
+}
+
+// StreakToday is how today is going
+type StreakToday struct {
+	Date      string `json:"date"` // 2006-01-02
+	Completed int    `json:"completed"`
+	Met       bool   `json:"met"` // the goal is reached
+}
+
+// Streak is the body of GET /me/streak
+type Streak struct {
+	Actor         string      `json:"actor"`
+	DailyGoal     int         `json:"daily_goal"`
+	Current       int         `json:"current"` // days in a row up to today, or yesterday while today isn't met yet
+	Longest       int         `json:"longest"` // in the last year
+	Today         StreakToday `json:"today"`
+	NextMilestone int         `json:"next_milestone,omitempty"`
+}
+
+// actorStreak is what's kept per actor
+type actorStreak struct {
+	hours map[int64]int // unix hour -> completions in it
+	goal  int           // 0 = -streak-goal
+	loc   *time.Location
+}
+
+// streaks by lowercased actor name, same in-memory pattern as the inboxes
+var streaks = make(map[string]*actorStreak)
+var streaksMu sync.Mutex
+
+
+// days adds up the completions per day in loc (caller holds streaksMu)
+func (s *actorStreak) days(loc *time.Location) map[string]int {
+	days := map[string]int{}
+	for hour, n := range s.hours {
+		days[time.Unix(hour*3600, 0).In(loc).Format("2006-01-02")] += n
+	}
+	return days
+}
+
+
+// dailyGoal is the actor's goal, or the default (caller holds streaksMu)
+func (s *actorStreak) dailyGoal() int {
+	if s.goal > 0 {
+		return s.goal
+	}
+	return *streakGoal
+}
+
+
+// streakOf works out name's streak as of now, in loc (caller holds streaksMu)
+func streakOf(name string, s *actorStreak, now time.Time, loc *time.Location) Streak {
+
+	goal := s.dailyGoal()
+	days := s.days(loc)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	met := func(day time.Time) bool { return days[day.Format("2006-01-02")] >= goal }
+
+	streak := Streak{Actor: name, DailyGoal: goal}
+	streak.Today = StreakToday{Date: today.Format("2006-01-02"), Completed: days[today.Format("2006-01-02")], Met: met(today)}
+
+	// today still counts until it's over
+	day := today
+	if !streak.Today.Met {
+		day = day.AddDate(0, 0, -1)
+	}
+	for ; met(day); day = day.AddDate(0, 0, -1) {
+		streak.Current++
+	}
+
+	run := 0
+	for day := today.AddDate(0, 0, -streakHours/24); !day.After(today); day = day.AddDate(0, 0, 1) {
+		if met(day) {
+			run++
+			streak.Longest = max(streak.Longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	for _, m := range streakMilestones {
+		if m > streak.Current {
+			streak.NextMilestone = m
+			break
+		}
+	}
+	return streak
+}
+
+
+// recordCompletion counts a completion by actor at t, and returns the milestone it reaches, if any
+func recordCompletion(actor string, t time.Time) (milestone int) {
+
+	streaksMu.Lock()
+	defer streaksMu.Unlock()
+
+	key := strings.ToLower(actor)
+	s := streaks[key]
+	if s == nil {
+		s = &actorStreak{hours: map[int64]int{}}
+		streaks[key] = s
+	}
+	hour := t.Unix() / 3600
+	s.hours[hour]++
+	for h := range s.hours {
+		if h <= hour-streakHours {
+			delete(s.hours, h)
+		}
+	}
+
+	// only the completion that meets today's goal can take the streak a day further
+	loc := s.loc
+	if loc == nil {
+		loc = defaultLocation
+	}
+	streak := streakOf(actor, s, t, loc)
+	if streak.Today.Completed != streak.DailyGoal || !slices.Contains(streakMilestones, streak.Current) {
+		return 0
+	}
+	return streak.Current
+}
+
+
+// startStreaks counts every completion for its actor, and announces milestones
+func startStreaks() {
+
+	events := todoStore.Events().Subscribe()
+	go func() {
+		for e := range events {
+			if model.ChangeKind(e) != model.ChangeCompleted || e.Actor == "" {
+				continue
+			}
+			if days := recordCompletion(e.Actor, e.Time); days > 0 {
+				notify(Notification{Kind: notifyStreak, Todo: e.Todo, Time: e.Time, Streak: &StreakMilestone{Actor: e.Actor, Days: days}})
+			}
+		}
+	}()
+}
+
+
+// GET /me/streak returns the requesting actor's streak in the client's zone, PUT
+// {"daily_goal": 3} sets their goal (and the zone milestones are counted in)
+func streakHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	actor, err := requestActor(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var goal StreakGoalRequest
+	switch r.Method {
+	case http.MethodGet:
+		// just report
+
+	case http.MethodPut:
+		if err := decodeBody(r, "streak-goal", &goal); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		if goal.DailyGoal < 1 || goal.DailyGoal > maxStreakGoal {
+			writeError(w, r, model.Invalid("daily_goal", fmt.Sprintf("must be between 1 and %d", maxStreakGoal)))
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	streaksMu.Lock()
+	key := strings.ToLower(actor)
+	s := streaks[key]
+	if s == nil {
+		s = &actorStreak{hours: map[int64]int{}}
+		if r.Method == http.MethodPut {
+			streaks[key] = s
+		}
+	}
+	if r.Method == http.MethodPut {
+		s.goal, s.loc = goal.DailyGoal, loc
+	}
+	streak := streakOf(actor, s, todoStore.Now(), loc)
+	streaksMu.Unlock()
+
+	json.NewEncoder(w).Encode(streak)
+}
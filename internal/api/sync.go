@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json" // for JSON encode/decode
+	"errors"        // for bad due values and merge conflicts
+	"net/http"      // for HTTP handlers
+	"time"          // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and change events
+)
+
+// delta sync for offline clients: one POST sends what the client changed while offline, since
+// the last change it has seen, and gets back what the server changed meanwhile. a local change
+// to a todo that also changed on the server is not applied but returned as a conflict, with
+// the server's version, for the client to resolve and send again
+
+// most changes one sync may carry
+const maxSyncChanges = 1000
+
+// sync operations
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncChange is one change the client made offline
+type SyncChange struct {
+	Op       string  `json:"op"`                  // create, update or delete
+	ClientID string  `json:"client_id,omitempty"` // the client's own id for a create, echoed back
+	ID       int     `json:"id,omitempty"`        // the todo, for update and delete
+	Rev      int     `json:"rev,omitempty"`       // update: the revision it was made against, to merge with
+	Title    *string `json:"title,omitempty"`     // new title (create: required)
+	Done     *bool   `json:"done,omitempty"`      // new done status
+	Due      DueEdit `json:"due"`                 // new due date; null clears it, absent leaves it
+}
+
+// DueEdit is a due date in a change: absent (no change), null (clear it) or an RFC 3339 time
+type DueEdit struct {
+	Set bool
+	Due *time.Time
+}
+
+// SyncRequest is the body of POST /todos/sync
+type SyncRequest struct {
+	Since   uint64       `json:"since"`   // last_seq of the previous sync (or of GET /todos/changes)
+	Changes []SyncChange `json:"changes"` // applied in order
+}
+
+// SyncApplied is a change that went through
+type SyncApplied struct {
+	Index    int         `json:"index"` // position in the request's changes
+	ClientID string      `json:"client_id,omitempty"`
+	ID       int         `json:"id"`
+	Todo     *model.Todo `json:"todo,omitempty"` // as stored, null for deletes
+}
+
+// SyncConflict is a change to a todo that changed on the server since the client's since
+type SyncConflict struct {
+	Index  int         `json:"index"`
+	ID     int         `json:"id"`
+	Server *model.Todo `json:"server"`           // the server's version, null if it was deleted
+	Fields []string    `json:"fields,omitempty"` // for updates with a rev: changed on both sides
+}
+
+// SyncFailure is a change the server refused, e.g. a blank title
+type SyncFailure struct {
+	Index int           `json:"index"`
+	Error ErrorResponse `json:"error"`
+}
+
+// SyncResponse is the answer to a sync
+type SyncResponse struct {
+	Applied   []SyncApplied  `json:"applied"`
+	Conflicts []SyncConflict `json:"conflicts"`
+	Failed    []SyncFailure  `json:"failed"`
+	Changes   []model.Event  `json:"changes"`          // every change after since, this sync's own included
+	LastSeq   uint64         `json:"last_seq"`         // since for the next sync
+	Resync    bool           `json:"resync,omitempty"` // changes fell out of the log meanwhile: reload GET /todos
+}
+
+
+// UnmarshalJSON tells a null due (clear it) from an absent one (UnmarshalJSON isn't called)
+func (d *DueEdit) UnmarshalJSON(data []byte) error {
+
+	d.Set = true
+	if string(data) == "null" {
+		d.Due = nil
+		return nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return errors.New("due must be an RFC 3339 time or null")
+	}
+	d.Due = &t
+	return nil
+}
+
+
+// MarshalJSON writes the due back as the client sent it (absent shows as null)
+func (d DueEdit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Due)
+}
+
+
+// POST: apply the client's offline changes and return the server's since its last sync
+func syncHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SyncRequest
+	if err := decodeBody(r, "sync", &req); err != nil {
+		writeError(w, r, bodyError(err))
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		writeError(w, r, model.Invalid("changes", "has more than 1000 entries"))
+		return
+	}
+	if req.Since > storeFor(r).Events().LastSeq() {
+		writeError(w, r, model.Invalid("since", "is ahead of the server"))
+		return
+	}
+
+	// what changed on the server since the client last looked; too far behind and nothing is
+	// applied: the client reloads the list, redoes its changes on it and syncs from last_seq
+	remote, _, ok := storeFor(r).Events().Since(req.Since)
+	if !ok {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(SyncResponse{Applied: []SyncApplied{}, Conflicts: []SyncConflict{}, Failed: []SyncFailure{}, Changes: []model.Event{}, LastSeq: storeFor(r).Events().LastSeq(), Resync: true})
+		return
+	}
+	changedRemotely := map[int]bool{}
+	for _, e := range remote {
+		changedRemotely[e.Todo.ID] = true
+	}
+
+	resp := SyncResponse{Applied: []SyncApplied{}, Conflicts: []SyncConflict{}, Failed: []SyncFailure{}}
+	for i, change := range req.Changes {
+
+		// the server's version wins until the client has seen it, unless an update names its
+		// revision: then it's merged field by field
+		merging := change.Op == SyncUpdate && change.Rev > 0
+		if change.Op != SyncCreate && !merging && changedRemotely[change.ID] {
+			conflict := SyncConflict{Index: i, ID: change.ID}
+			if todo, err := todoStore.Get(r.Context(), change.ID); err == nil {
+				conflict.Server = &todo
+			}
+			resp.Conflicts = append(resp.Conflicts, conflict)
+			continue
+		}
+
+		applied, err := applySyncChange(r, change)
+		var merge *model.MergeConflict
+		if errors.As(err, &merge) {
+			resp.Conflicts = append(resp.Conflicts, SyncConflict{Index: i, ID: change.ID, Server: &merge.Server, Fields: merge.Fields})
+			continue
+		}
+		if err != nil {
+			_, body, _ := errorResponse(r, err)
+			resp.Failed = append(resp.Failed, SyncFailure{Index: i, Error: body})
+			continue
+		}
+		applied.Index = i
+		resp.Applied = append(resp.Applied, applied)
+
+		// later changes in the batch may touch it again without conflicting with themselves
+		delete(changedRemotely, applied.ID)
+	}
+
+	// everything after since, ours included, so the client ends up with the server's state
+	events, _, ok := storeFor(r).Events().Since(req.Since)
+	resp.Changes, resp.LastSeq = []model.Event{}, req.Since
+	switch {
+	case !ok:
+		resp.LastSeq, resp.Resync = storeFor(r).Events().LastSeq(), true
+	case len(events) > 0:
+		resp.Changes, resp.LastSeq = events, events[len(events)-1].Seq
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+
+// applySyncChange applies one change through the store, like the single-todo endpoints
+func applySyncChange(r *http.Request, change SyncChange) (SyncApplied, error) {
+
+	applied := SyncApplied{ClientID: change.ClientID, ID: change.ID}
+	switch change.Op {
+	case SyncCreate:
+		draft := model.Todo{Due: change.Due.Due}
+		if change.Title != nil {
+			draft.Title = *change.Title
+		}
+		if change.Done != nil {
+			draft.Done = *change.Done
+		}
+		todo, err := todoStore.Create(r.Context(), draft)
+		if err != nil {
+			return applied, err
+		}
+		applied.ID, applied.Todo = todo.ID, &todo
+
+	case SyncUpdate:
+		patch := model.Patch{Title: change.Title, Done: change.Done, SetDue: change.Due.Set, Due: change.Due.Due}
+		todo, err := todoStore.Merge(r.Context(), change.ID, change.Rev, patch)
+		if err != nil {
+			return applied, err
+		}
+		applied.Todo = &todo
+
+	case SyncDelete:
+		if err := todoStore.Delete(r.Context(), change.ID); err != nil {
+			return applied, err
+		}
+
+	default:
+		return applied, model.Invalid("op", "must be create, update or delete")
+	}
+	return applied, nil
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"crypto/sha1"   // for stable task uuids
+	"encoding/json" // for Taskwarrior JSON
+	"fmt"           // for uuid formatting
+	"net/http"      // for HTTP handlers
+	"strconv"       // for dry-run flag
+	"strings"       // for trimming
+	"time"          // for Taskwarrior dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// Taskwarrior writes dates as UTC basic format
+const taskwarriorTimeFormat = "20060102T150405Z"
+
+// taskwarriorTask is one task as `task export` writes it and `task import` reads it
+type taskwarriorTask struct {
+	UUID        string                  `json:"uuid"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"` // pending, completed, deleted, waiting, recurring
+	Entry       string                  `json:"entry,omitempty"`
+	End         string                  `json:"end,omitempty"` // required by Taskwarrior on completed tasks
+	Due         string                  `json:"due,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Priority    string                  `json:"priority,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Annotations []taskwarriorAnnotation `json:"annotations,omitempty"`
+	Urgency     float64                 `json:"urgency,omitempty"`
+}
+
+// taskwarriorAnnotation is a timestamped note on a task
+type taskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// TaskwarriorUnmapped counts Taskwarrior data this server has nowhere to keep
+type TaskwarriorUnmapped struct {
+	Annotations int `json:"annotations"`
+	Priorities  int `json:"priorities"`
+}
+
+// TaskwarriorImportReport is the answer to POST /import/taskwarrior
+type TaskwarriorImportReport struct {
+	DryRun   bool                `json:"dry_run"`
+	Todos    []model.Todo        `json:"todos"` // created, or that would be created on a dry run
+	Skipped  []ImportError       `json:"skipped"`
+	Unmapped TaskwarriorUnmapped `json:"unmapped"`
+}
+
+
+// taskwarriorUUID derives a stable uuid (v5 layout) from the todo id, so exporting twice and
+// running `task import` again updates the same tasks instead of duplicating them
+func taskwarriorUUID(host string, id int) string {
+	sum := sha1.Sum([]byte("todo-api:" + host + ":" + strconv.Itoa(id)))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+
+// download every todo as a Taskwarrior JSON array, ready for `task import`
+func taskwarriorExportHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// we don't track creation or completion times, so both are the export time.
+	// urgency is left out: Taskwarrior computes it from its own fields
+	now := todoStore.Now().UTC().Format(taskwarriorTimeFormat)
+	tasks := []taskwarriorTask{}
+	for _, todo := range todoStore.List(r.Context()) {
+		task := taskwarriorTask{
+			UUID:        taskwarriorUUID(r.Host, todo.ID),
+			Description: todo.Title,
+			Status:      "pending",
+			Entry:       now,
+			Project:     todo.List,
+			Tags:        todo.Tags,
+		}
+		if todo.Done {
+			task.Status = "completed"
+			task.End = now
+		}
+		if todo.Due != nil {
+			task.Due = todo.Due.UTC().Format(taskwarriorTimeFormat)
+		}
+		tasks = append(tasks, task)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.taskwarrior.json"`)
+	json.NewEncoder(w).Encode(tasks)
+}
+
+
+// import `task export` output (JSON array or one task per line); ?dry_run=true only reports
+func taskwarriorImportHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := TaskwarriorImportReport{Todos: []model.Todo{}, Skipped: []ImportError{}}
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		dry, err := strconv.ParseBool(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		report.DryRun = dry
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// `task export` writes an array, `task import` also takes one object per line
+	var tasks []taskwarriorTask
+	dec := json.NewDecoder(r.Body)
+	for dec.More() {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == nil {
+			if raw[0] == '[' {
+				var batch []taskwarriorTask
+				err = json.Unmarshal(raw, &batch)
+				tasks = append(tasks, batch...)
+			} else {
+				var task taskwarriorTask
+				err = json.Unmarshal(raw, &task)
+				tasks = append(tasks, task)
+			}
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "not a Taskwarrior export: " + err.Error()})
+			return
+		}
+	}
+
+	for i, task := range tasks {
+		row := i + 1 // position in the export
+
+		// deleted tasks are gone, recurring ones are templates whose instances are exported separately
+		var todo model.Todo
+		switch task.Status {
+		case "pending", "waiting":
+		case "completed":
+			todo.Done = true
+		case "deleted", "recurring":
+			continue
+		default:
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "unknown status " + strconv.Quote(task.Status)})
+			continue
+		}
+
+		todo.Title = strings.TrimSpace(task.Description)
+		if todo.Title == "" {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "description is empty"})
+			continue
+		}
+		if task.Due != "" {
+			due, err := time.Parse(taskwarriorTimeFormat, task.Due)
+			if err != nil {
+				report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "due: cannot read " + strconv.Quote(task.Due)})
+				continue
+			}
+			todo.Due = &due
+		}
+
+		// the project is the list and tags are tags
+		todo.List = strings.TrimSpace(task.Project)
+		todo.Tags = model.CleanTags(task.Tags)
+		if err := todo.Validate(); err != nil {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		// count what we can't store; urgency is derived data and needs no mapping
+		report.Unmapped.Annotations += len(task.Annotations)
+		if task.Priority != "" {
+			report.Unmapped.Priorities++
+		}
+
+		if report.DryRun {
+			report.Todos = append(report.Todos, todo)
+			continue
+		}
+		created, err := todoStore.Create(r.Context(), todo)
+		if err != nil {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+			continue
+		}
+		report.Todos = append(report.Todos, created)
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"encoding/json" // for the exported tasks
+	"net/http"      // for status codes
+	"testing"       // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// Taskwarrior tests: a todo's list is its task's project and its tags the task's tags, both ways
+
+
+// TestTaskwarriorExport checks each task carries its todo's list as project, and its tags
+func TestTaskwarriorExport(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+
+	var tasks []struct {
+		Description string   `json:"description"`
+		Status      string   `json:"status"`
+		Project     string   `json:"project"`
+		Tags        []string `json:"tags"`
+	}
+	s.Get("/export/taskwarrior").ExpectStatus(http.StatusOK).Decode(&tasks)
+	got, _ := json.Marshal(tasks)
+	want := `[{"description":"milk","status":"pending","project":"groceries","tags":["dairy","fridge"]},` +
+		`{"description":"bread","status":"completed","project":"groceries","tags":["bakery"]},` +
+		`{"description":"soap","status":"pending","project":"groceries","tags":null},` +
+		`{"description":"file taxes","status":"pending","project":"","tags":["home"]}]`
+	if string(got) != want {
+		t.Errorf("exported %s, want %s", got, want)
+	}
+}
+
+
+// TestTaskwarriorImport checks a dry run keeps projects as lists and tags as tags, and counts
+// what it can't keep
+func TestTaskwarriorImport(t *testing.T) {
+
+	s := apitest.New(t)
+	s.Post("/import/taskwarrior?dry_run=true", `[
+		{"description": "milk", "status": "pending", "project": "groceries", "tags": ["Dairy", "fridge"], "priority": "H"},
+		{"description": "bread", "status": "completed", "project": "groceries", "annotations": [{"entry": "20261101T090000Z", "description": "rye"}]},
+		{"description": "old", "status": "deleted", "project": "groceries"},
+		{"description": "tax", "status": "pending", "tags": ["two words"]}
+	]`).ExpectStatus(http.StatusOK).ExpectJSON(`{"dry_run":true,"todos":[
+		{"id":0,"title":"milk","done":false,"list":"groceries","tags":["dairy","fridge"],"rev":0},
+		{"id":0,"title":"bread","done":true,"list":"groceries","rev":0}],
+		"skipped":[{"row":4,"error":"validation failed: tags: must be one word each"}],
+		"unmapped":{"annotations":1,"priorities":1}}`)
+}
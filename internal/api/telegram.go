@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"         // for request bodies
+	"context"       // for store calls outside a request
+	"encoding/json" // for the Bot API and the admin API
+	"errors"        // for API errors
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for the Bot API and admin handlers
+	"net/url"       // for getUpdates parameters
+	"sort"          // for stable list order
+	"strconv"       // for chat and todo ids
+	"strings"       // for parsing commands
+	"sync"          // for guarding linked chats
+	"time"          // for polling and backoff
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// Telegram bot (off unless a token is given)
+var telegramToken = flags.String("telegram-token", "", "Telegram bot token from @BotFather (bot disabled when empty)")
+var telegramAPI = flags.String("telegram-api", "https://api.telegram.org", "Telegram Bot API base URL (for a self-hosted Bot API server)")
+
+// how long getUpdates waits for a message before returning empty
+const telegramPollTimeout = 30 * time.Second
+
+// TelegramChat links a Telegram chat to a person.
+// there are no user accounts, so the link itself carries the name and preferences (like digest subscriptions).
+type TelegramChat struct {
+	ChatID    int64     `json:"chat_id"`
+	Name      string    `json:"name"`
+	Reminders bool      `json:"reminders"` // ping this chat when a todo becomes overdue
+	Linked    time.Time `json:"linked_at"`
+}
+
+// LinkTelegramChatRequest represents input body for linking a chat
+type LinkTelegramChatRequest struct {
+	ChatID    int64  `json:"chat_id"`
+	Name      string `json:"name"`
+	Reminders *bool  `json:"reminders"` // defaults to true
+}
+
+// linked chats by chat id; only these may use the bot
+var telegramChats = make(map[int64]TelegramChat)
+var telegramChatsMu sync.Mutex
+
+// client for Bot API calls; must outlive the long poll
+var telegramClient = &http.Client{Timeout: telegramPollTimeout + 10*time.Second}
+
+// the parts of a Bot API update we read
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+
+// telegramCall posts a Bot API method and decodes its result
+func telegramCall(method string, params any, result any) error {
+
+	body, _ := json.Marshal(params)
+	resp, err := telegramClient.Post(*telegramAPI+"/bot"+*telegramToken+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the URL contains the token, keep it out of the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	// every answer is {"ok": bool, "result": ..., "description": ...}
+	var answer struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return err
+	}
+	if !answer.OK {
+		return errors.New("telegram " + method + ": " + answer.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(answer.Result, result)
+	}
+	return nil
+}
+
+
+// telegramSend replies to a chat with plain text
+func telegramSend(chatID int64, text string) error {
+	return telegramCall("sendMessage", map[string]any{"chat_id": chatID, "text": text}, nil)
+}
+
+
+// telegramReply runs one chat command and returns the answer
+func telegramReply(chatID int64, text string) string {
+
+	// "/add@MyTodoBot buy milk" -> "/add", "buy milk"
+	command, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	command, _, _ = strings.Cut(command, "@")
+	arg = strings.TrimSpace(arg)
+
+	telegramChatsMu.Lock()
+	chat, linked := telegramChats[chatID]
+	telegramChatsMu.Unlock()
+
+	help := "/list - open todos\n/add <title> - new todo\n/done <id> - complete a todo\n/undo <id> - reopen a todo"
+
+	// unlinked chats only learn their id, so an admin can link them
+	if !linked {
+		return "This chat isn't linked to the todo list yet. Ask the admin to link chat id " + strconv.FormatInt(chatID, 10) + "."
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "/start", "/help":
+		return "Hi " + chat.Name + "!\n\n" + help
+
+	case "/list":
+		var b strings.Builder
+		open := false
+		for _, todo := range todoStore.Find(ctx, store.Filter{Done: &open}) {
+			b.WriteString("#" + strconv.Itoa(todo.ID) + " " + todo.Title)
+			if todo.Due != nil {
+				b.WriteString(" (due " + todo.Due.Format("Jan 2 15:04") + ")")
+			}
+			b.WriteString("\n")
+		}
+		if b.Len() == 0 {
+			return "Nothing open."
+		}
+		return b.String()
+
+	case "/add":
+		if arg == "" {
+			return "Usage: /add <title>"
+		}
+		todo, err := todoStore.Create(ctx, model.Todo{Title: arg})
+		if err != nil {
+			return "Could not add: " + err.Error()
+		}
+		return "Added #" + strconv.Itoa(todo.ID) + " " + todo.Title
+
+	case "/done", "/undo":
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return "Usage: " + command + " <id>"
+		}
+		todo, err := todoStore.SetDone(ctx, id, command == "/done")
+		if err != nil {
+			return "No todo #" + strconv.Itoa(id)
+		}
+		if todo.Done {
+			return "Completed #" + strconv.Itoa(todo.ID) + " " + todo.Title
+		}
+		return "Reopened #" + strconv.Itoa(todo.ID) + " " + todo.Title
+	}
+
+	return "Unknown command.\n\n" + help
+}
+
+
+// sendTelegramReminder pings every linked chat with reminders on when a todo becomes overdue
+func sendTelegramReminder(n Notification) error {
+
+	if n.Kind != notifyOverdue {
+		return nil
+	}
+
+	telegramChatsMu.Lock()
+	var ids []int64
+	for id, chat := range telegramChats {
+		if chat.Reminders {
+			ids = append(ids, id)
+		}
+	}
+	telegramChatsMu.Unlock()
+
+	text := "⏰ Overdue: #" + strconv.Itoa(n.Todo.ID) + " " + n.Todo.Title + "\nReply /done " + strconv.Itoa(n.Todo.ID) + " when it's finished."
+	var errs []error
+	for _, id := range ids {
+		if err := telegramSend(id, text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+
+// startTelegramBot long-polls the Bot API for chat commands and registers the reminder pings
+func startTelegramBot() {
+
+	// nothing to do if the bot is disabled
+	if *telegramToken == "" {
+		return
+	}
+
+	addNotifier("telegram", sendTelegramReminder)
+
+	go func() {
+		var offset int64
+		for {
+			params := map[string]any{"offset": offset, "timeout": int(telegramPollTimeout.Seconds()), "allowed_updates": []string{"message"}}
+			var updates []telegramUpdate
+			if err := telegramCall("getUpdates", params, &updates); err != nil {
+				fmt.Println("telegram poll failed, retrying:", err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+
+			for _, u := range updates {
+				offset = u.UpdateID + 1 // confirms this update on the next poll
+				if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+					continue
+				}
+				if err := telegramSend(u.Message.Chat.ID, telegramReply(u.Message.Chat.ID, u.Message.Text)); err != nil {
+					fmt.Println("telegram reply failed:", err)
+				}
+			}
+		}
+	}()
+}
+
+
+// admin: list linked chats
+func listTelegramChatsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	telegramChatsMu.Lock()
+	list := make([]TelegramChat, 0, len(telegramChats))
+	for _, chat := range telegramChats {
+		list = append(list, chat)
+	}
+	telegramChatsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Linked.Before(list[j].Linked) })
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// admin: let a chat use the bot (the bot tells unlinked chats their id)
+func linkTelegramChatHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// err handling for decoding request body (bad input)
+	var req LinkTelegramChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChatID == 0 || strings.TrimSpace(req.Name) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chat := TelegramChat{ChatID: req.ChatID, Name: strings.TrimSpace(req.Name), Reminders: true, Linked: time.Now()}
+	if req.Reminders != nil {
+		chat.Reminders = *req.Reminders
+	}
+
+	// linking again updates name and preferences
+	telegramChatsMu.Lock()
+	telegramChats[chat.ChatID] = chat
+	telegramChatsMu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(chat)
+}
+
+
+// admin: revoke a chat (?chat_id=)
+func unlinkTelegramChatHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	telegramChatsMu.Lock()
+	_, exists := telegramChats[id]
+	delete(telegramChats, id)
+	telegramChatsMu.Unlock()
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"encoding/json" // for JSON encode/decode
+	"errors"        // for an empty body
+	"fmt"           // for not found errors
+	"io"            // for an empty body
+	"net/http"      // for HTTP handlers
+	"regexp"        // for placeholders in titles
+	"sort"          // for stable list order
+	"strconv"       // for the id in the path
+	"strings"       // for trimming input
+	"sync"          // for guarding the templates
+	"time"          // for timestamps and due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+)
+
+// TodoTemplate is a reusable blueprint: applying it creates a todo, and one more per subtask,
+// with {placeholders} in the titles filled in and the due date counted from the day it's applied
+type TodoTemplate struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`              // e.g. "Onboard {name}"
+	Due       string    `json:"due,omitempty"`      // relative, in words: "in 3 days", "friday 5pm"
+	Subtasks  []string  `json:"subtasks,omitempty"` // titles, placeholders allowed too
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SaveTemplateRequest represents input body for creating or replacing a template
+type SaveTemplateRequest struct {
+	Name     string   `json:"name"`
+	Title    string   `json:"title"`
+	Due      string   `json:"due"`
+	Subtasks []string `json:"subtasks"`
+}
+
+// ApplyTemplateRequest is the optional body of POST /templates/{id}/apply
+type ApplyTemplateRequest struct {
+	Values map[string]string `json:"values"` // placeholder -> text
+}
+
+// ApplyTemplateResult is what applying created: the todo first, then its subtasks
+type ApplyTemplateResult struct {
+	Template int          `json:"template"`
+	Todos    []model.Todo `json:"todos"`
+}
+
+// most subtasks one template may have
+const maxTemplateSubtasks = 50
+
+// {name}-style placeholders; {date} is always there, the day it's applied
+var templatePlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templates, same in-memory pattern as the webhooks
+var todoTemplates = make(map[int]TodoTemplate)
+var todoTemplatesMu sync.Mutex
+var nextTemplateID = 1
+
+
+// checkTemplate validates a request: a name, a title, and a due date we can read
+func checkTemplate(r *http.Request, req *SaveTemplateRequest) error {
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Title = strings.TrimSpace(req.Title)
+	req.Due = strings.TrimSpace(req.Due)
+
+	invalid := &model.ValidationError{}
+	if req.Name == "" {
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: "name", Message: "is required"})
+	}
+	if req.Title == "" {
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: "title", Message: "is required"})
+	}
+	if len(req.Subtasks) > maxTemplateSubtasks {
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: "subtasks", Message: "has more than 50 entries"})
+	}
+	for i, sub := range req.Subtasks {
+		if req.Subtasks[i] = strings.TrimSpace(sub); req.Subtasks[i] == "" {
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "subtasks", Message: "has an empty title"})
+			break
+		}
+	}
+	if req.Due != "" {
+		loc, err := requestLocation(r)
+		if err != nil {
+			return err
+		}
+		if _, err := resolveDue(req.Due, todoStore.Now(), loc); err != nil {
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "due", Message: err.Error()})
+		}
+	}
+	if len(invalid.Fields) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+
+// templateID reads the {id} of the path
+func templateID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, model.Invalid("id", "must be an integer")
+	}
+	return id, nil
+}
+
+
+// todoTemplate looks a template up, model.ErrNotFound if there is none
+func todoTemplate(id int) (TodoTemplate, error) {
+	todoTemplatesMu.Lock()
+	defer todoTemplatesMu.Unlock()
+
+	tmpl, ok := todoTemplates[id]
+	if !ok {
+		return TodoTemplate{}, fmt.Errorf("template %d: %w", id, model.ErrNotFound)
+	}
+	return tmpl, nil
+}
+
+
+// fillTitle replaces the placeholders in a title, a validation error naming those without a value
+func fillTitle(pattern string, values map[string]string) (string, error) {
+
+	var missing []string
+	title := templatePlaceholder.ReplaceAllStringFunc(pattern, func(ph string) string {
+		name := ph[1 : len(ph)-1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		missing = append(missing, name)
+		return ph
+	})
+	if len(missing) > 0 {
+		invalid := &model.ValidationError{}
+		for _, name := range missing {
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "values." + name, Message: "is required"})
+		}
+		return "", invalid
+	}
+	return title, nil
+}
+
+
+// GET lists the templates, POST saves one
+func templatesHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		todoTemplatesMu.Lock()
+		list := make([]TodoTemplate, 0, len(todoTemplates))
+		for _, tmpl := range todoTemplates {
+			list = append(list, tmpl)
+		}
+		todoTemplatesMu.Unlock()
+
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var req SaveTemplateRequest
+		if err := decodeBody(r, "template", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		if err := checkTemplate(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		todoTemplatesMu.Lock()
+		tmpl := TodoTemplate{ID: nextTemplateID, Name: req.Name, Title: req.Title, Due: req.Due, Subtasks: req.Subtasks, CreatedAt: todoStore.Now()}
+		todoTemplates[tmpl.ID] = tmpl
+		nextTemplateID++
+		todoTemplatesMu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tmpl)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// GET shows one template, PUT replaces it, DELETE removes it
+func templateHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := templateID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tmpl, err := todoTemplate(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(tmpl)
+
+	case http.MethodPut:
+		var req SaveTemplateRequest
+		if err := decodeBody(r, "template", &req); err != nil {
+			writeError(w, r, bodyError(err))
+			return
+		}
+		if err := checkTemplate(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// it may have been deleted meanwhile
+		todoTemplatesMu.Lock()
+		_, exists := todoTemplates[id]
+		if exists {
+			tmpl.Name, tmpl.Title, tmpl.Due, tmpl.Subtasks = req.Name, req.Title, req.Due, req.Subtasks
+			todoTemplates[id] = tmpl
+		}
+		todoTemplatesMu.Unlock()
+		if !exists {
+			writeError(w, r, fmt.Errorf("template %d: %w", id, model.ErrNotFound))
+			return
+		}
+		json.NewEncoder(w).Encode(tmpl)
+
+	case http.MethodDelete:
+		todoTemplatesMu.Lock()
+		delete(todoTemplates, id)
+		todoTemplatesMu.Unlock()
+
+		// 204 = success with no response body
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// POST creates the template's todos, due counted from now in the client's time zone
+func applyTemplateHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := templateID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tmpl, err := todoTemplate(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the body is optional: a template without placeholders needs no values
+	var req ApplyTemplateRequest
+	if err := decodeBody(r, "template-apply", &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, err)
+		return
+	}
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	now := todoStore.Now()
+	values := map[string]string{"date": now.In(loc).Format("2006-01-02")}
+	for name, v := range req.Values {
+		values[name] = v
+	}
+
+	// fill in every title and the due date before creating anything
+	var due *time.Time
+	if tmpl.Due != "" {
+		t, err := resolveDue(tmpl.Due, now, loc)
+		if err != nil {
+			writeError(w, r, model.Invalid("due", err.Error()))
+			return
+		}
+		due = &t
+	}
+	drafts := make([]model.Todo, 0, 1+len(tmpl.Subtasks))
+	for _, pattern := range append([]string{tmpl.Title}, tmpl.Subtasks...) {
+		title, err := fillTitle(pattern, values)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		draft := model.Todo{Title: title, Due: due}
+		if err := draft.Validate(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		drafts = append(drafts, draft)
+	}
+
+	// through the store like any create, so hooks and validation apply
+	result := ApplyTemplateResult{Template: id, Todos: make([]model.Todo, 0, len(drafts))}
+	for _, draft := range drafts {
+		todo, err := todoStore.Create(r.Context(), draft)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		result.Todos = append(result.Todos, todo)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"crypto/sha256" // for looking keys up without comparing them
+	"encoding/json" // for the config file and JSON errors
+	"errors"        // for config errors
+	"fmt"           // for config errors
+	"net"           // for the port of a Host header
+	"net/http"      // for the middleware
+	"os"            // for reading the config file
+	"regexp"        // for tenant names
+	"strings"       // for bearer tokens and host names
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the tenants' stores
+)
+
+// tenants: isolated namespaces on one server, for hosting several teams' lists. -tenants names
+// them with the API keys and host names (a subdomain each, say) their requests come with; the
+// store keeps a tenant's todos, ids, history, trash and change log apart from everyone else's, and
+// saved filters are per tenant too. what's kept per process (templates, blockers, attachments,
+// shares, integrations, ...) isn't split, so tenants only get the routes in tenantRoutes. requests
+// naming no tenant are the default tenant's, as without -tenants, unless -tenants-required
+var (
+	tenantsPath     = flags.String("tenants", "", "JSON file of tenants, by name, with the api_keys and hosts that select them (off when empty)")
+	tenantsRequired = flags.Bool("tenants-required", false, "refuse requests that name no tenant (probes, /version and /schemas/ excepted)")
+)
+
+// TenantConfig is one tenant of the -tenants file
+type TenantConfig struct {
+	APIKeys []string `json:"api_keys"` // sent as "Authorization: Bearer <key>"
+	Hosts   []string `json:"hosts"`    // Host headers, without the port, e.g. acme.todo.example.com
+}
+
+// what a tenant's name may be
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// the tenant of each key (by hash) and host, set by loadTenants
+var tenantKeys map[[32]byte]string
+var tenantHosts map[string]string
+
+// the routes tenants may use, see newTenantRoutes
+var tenantRoutes = newTenantRoutes()
+
+
+// newTenantRoutes registers the routes working only on the store and on saved filters, on a router
+// of their own to match requests against (the handlers do nothing)
+func newTenantRoutes() *http.ServeMux {
+
+	mux := http.NewServeMux()
+	for _, pattern := range []string{
+		"/todos", "/todos/get", "/todos/create", "/todos/update", "/todos/delete", "/todos/changes", "/todos/sync",
+		"/todos/stats", "/todos/next", "/todos/board", "/changes", "/activity", "/analytics",
+		"/todos/{id}/revisions", "/todos/{id}/revisions/diff", "/todos/{id}/revisions/{rev}/restore",
+		"/trash", "/trash/{id}", "/trash/{id}/restore", "/scheduled", "/scheduled/{id}",
+		"/recurring", "/recurring/preview", "/recurring/{id}", "/recurring/{id}/preview",
+		"/todos/export.csv", "/todos/export.ndjson", "/todos/export.md", "/todos/export.pdf", "/todos/import",
+		"/filters", "/filters/{id}", "/filters/{id}/todos", "/filters/{id}/export.pdf",
+		"/lists", "/lists/{id}/todos", "/lists/{id}/export.pdf",
+		"/healthz", "/livez", "/readyz", "/version", "/schemas/",
+		grpcService + "ListTodos", grpcService + "GetTodo", grpcService + "CreateTodo", grpcService + "UpdateTodo",
+		grpcService + "CompleteTodo", grpcService + "DeleteTodo",
+	} {
+		mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+	}
+	return mux
+}
+
+
+// loadTenants reads and checks -tenants
+func loadTenants() error {
+
+	tenantKeys, tenantHosts = nil, nil
+	if *tenantsPath == "" {
+		if *tenantsRequired {
+			return errors.New("-tenants-required needs a -tenants file")
+		}
+		return nil
+	}
+
+	data, err := os.ReadFile(*tenantsPath)
+	if err != nil {
+		return fmt.Errorf("-tenants: %w", err)
+	}
+	var config map[string]TenantConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("-tenants: %w", err)
+	}
+
+	// no key or host may select two tenants
+	tenantKeys, tenantHosts = make(map[[32]byte]string), make(map[string]string)
+	for name, tenant := range config {
+		if !tenantNamePattern.MatchString(name) {
+			return fmt.Errorf("-tenants: %q: names are lowercase letters, digits and dashes", name)
+		}
+		if len(tenant.APIKeys) == 0 && len(tenant.Hosts) == 0 {
+			return fmt.Errorf("-tenants: %s: has no api_keys or hosts to be selected by", name)
+		}
+		for _, key := range tenant.APIKeys {
+			if len(key) < 16 {
+				return fmt.Errorf("-tenants: %s: api keys must be at least 16 characters", name)
+			}
+			sum := sha256.Sum256([]byte(key))
+			if other, taken := tenantKeys[sum]; taken {
+				return fmt.Errorf("-tenants: %s and %s share an api key", other, name)
+			}
+			tenantKeys[sum] = name
+		}
+		for _, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if other, taken := tenantHosts[host]; taken {
+				return fmt.Errorf("-tenants: %s and %s share host %s", other, name, host)
+			}
+			tenantHosts[host] = name
+		}
+	}
+	return nil
+}
+
+
+// requestTenant picks r's tenant: its API key's, else its host's, else "" for the default one.
+// ok is false for a bearer token that's no tenant's key
+func requestTenant(r *http.Request) (tenant string, ok bool) {
+
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		tenant, ok = tenantKeys[sha256.Sum256([]byte(token))]
+		return tenant, ok
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return tenantHosts[strings.ToLower(host)], true
+}
+
+
+// storeFor returns the store of r's tenant, for the store calls that take no context
+func storeFor(r *http.Request) *store.Store {
+	return todoStore.Tenant(r.Context())
+}
+
+
+// selectTenants puts each request's tenant in its context, for the store calls below, and keeps
+// tenants to the routes that are theirs alone
+func selectTenants(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// nothing to do without tenants
+		if *tenantsPath == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant, ok := requestTenant(r)
+		_, pattern := tenantRoutes.Handler(r)
+		switch {
+		case !ok:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "unknown api key"})
+			return
+		case tenant == "" && *tenantsRequired && !isPublicRoute(pattern):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "an api key or tenant host is required"})
+			return
+		case tenant != "" && pattern == "":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "not available to tenants"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(store.WithTenant(r.Context(), tenant)))
+	})
+}
+
+
+// isPublicRoute reports whether a tenantRoutes pattern is served to anyone, tenant or not
+func isPublicRoute(pattern string) bool {
+	switch pattern {
+	case "/healthz", "/livez", "/readyz", "/version", "/schemas/":
+		return true
+	}
+	return false
+}
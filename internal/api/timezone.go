@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"      // for config errors
+	"net/http" // for the client's time zone header
+	"strings"  // for trimming the header
+	"time"     // for time zones
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for validation errors
+)
+
+// there are no user accounts, so the time zone is the client's: each request names its own in
+// a Time-Zone header (an IANA name, like Europe/Berlin), and -timezone is the one for clients
+// that don't. it decides what "tomorrow 5pm", a plain 2026-11-02 and ?due=today mean
+var defaultTimezone = flags.String("timezone", "", "IANA time zone for requests without a Time-Zone header (server local time when empty)")
+
+// the zone -timezone names
+var defaultLocation = time.Local
+
+
+// loadTimezone validates -timezone
+func loadTimezone() error {
+
+	if *defaultTimezone == "" {
+		defaultLocation = time.Local
+		return nil
+	}
+	loc, err := time.LoadLocation(*defaultTimezone)
+	if err != nil {
+		return fmt.Errorf("-timezone: %w", err)
+	}
+	defaultLocation = loc
+	return nil
+}
+
+
+// requestLocation is the client's time zone: the Time-Zone header, or -timezone without one
+func requestLocation(r *http.Request) (*time.Location, error) {
+
+	name := strings.TrimSpace(r.Header.Get("Time-Zone"))
+	if name == "" {
+		return defaultLocation, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, model.Invalid("Time-Zone", "is not a time zone name (like Europe/Berlin)")
+	}
+	return loc, nil
+}
+
+
+// requestZoneName names the client's zone for settings kept past the request (digest
+// subscriptions), UTC when neither the client nor -timezone says
+func requestZoneName(r *http.Request) string {
+
+	if name := strings.TrimSpace(r.Header.Get("Time-Zone")); name != "" {
+		return name
+	}
+	if *defaultTimezone != "" {
+		return *defaultTimezone
+	}
+	return "UTC"
+}
+
+
+// dueRange is the [start, end) a ?due= keyword stands for, in loc
+func dueRange(keyword string, now time.Time, loc *time.Location) (start, end *time.Time, ok bool) {
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // weeks start on Monday
+
+	span := func(from time.Time, days int) (*time.Time, *time.Time, bool) {
+		to := from.AddDate(0, 0, days)
+		return &from, &to, true
+	}
+	switch keyword {
+	case "today":
+		return span(today, 1)
+	case "tomorrow":
+		return span(today.AddDate(0, 0, 1), 1)
+	case "this_week":
+		return span(monday, 7)
+	case "next_week":
+		return span(monday.AddDate(0, 0, 7), 7)
+	case "overdue":
+		now = now.Truncate(time.Minute) // so polls within a minute share a cached list
+		return nil, &now, true
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"encoding/csv"  // for Todoist's CSV template export
+	"encoding/json" // for Todoist's JSON backup
+	"errors"        // for format errors
+	"io"            // for reading uploads
+	"net/http"      // for HTTP handlers
+	"strconv"       // for dry-run flag and priorities
+	"strings"       // for header matching
+	"time"          // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// todoistExport is the part of a Todoist sync backup (projects + items) we read
+type todoistExport struct {
+	Projects []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"projects"`
+	Items []todoistItem `json:"items"`
+}
+
+// todoistItem is one task in a Todoist backup
+type todoistItem struct {
+	Content   string   `json:"content"`
+	ProjectID string   `json:"project_id"`
+	Priority  int      `json:"priority"` // 4 is Todoist's p1 (most urgent), 1 is p4
+	Labels    []string `json:"labels"`
+	Checked   bool     `json:"checked"`
+	IsDeleted bool     `json:"is_deleted"`
+	Due       *struct {
+		Date        string `json:"date"` // 2026-11-01, 2026-11-01T17:00:00 (floating) or ...Z
+		IsRecurring bool   `json:"is_recurring"`
+	} `json:"due"`
+}
+
+// TodoistUnmapped counts Todoist data that only carries over in part
+type TodoistUnmapped struct {
+	Recurring int `json:"recurring"` // recurring due dates imported as their next occurrence
+}
+
+// TodoistImportReport is the answer to POST /import/todoist
+type TodoistImportReport struct {
+	DryRun   bool            `json:"dry_run"`
+	Todos    []model.Todo    `json:"todos"` // created, or that would be created on a dry run
+	Skipped  []ImportError   `json:"skipped"`
+	Unmapped TodoistUnmapped `json:"unmapped"`
+}
+
+
+// todoistTag makes a label a tag: labels may have spaces, tags are one word
+func todoistTag(label string) string {
+	return strings.Join(strings.Fields(label), "-")
+}
+
+
+// todoistPriority is the tag for Todoist's priority p1..p3 (counted 1 is most urgent), or "" for
+// the default p4
+func todoistPriority(p int) string {
+	if p < 1 || p > 3 {
+		return ""
+	}
+	return "p" + strconv.Itoa(p)
+}
+
+
+// parseTodoistDue reads Todoist's due.date: plain date, floating local time (taken as UTC) or UTC
+func parseTodoistDue(s string) (*time.Time, error) {
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.New("due: cannot read " + strconv.Quote(s))
+}
+
+
+// todoistFromJSON converts a sync backup into drafts
+func todoistFromJSON(body io.Reader, report *TodoistImportReport) ([]model.Todo, error) {
+
+	var export todoistExport
+	if err := json.NewDecoder(body).Decode(&export); err != nil {
+		return nil, errors.New("not a Todoist JSON export: " + err.Error())
+	}
+
+	projectNames := make(map[string]string)
+	for _, p := range export.Projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	var drafts []model.Todo
+	for i, item := range export.Items {
+		row := i + 1 // position in the items array
+		if item.IsDeleted {
+			continue
+		}
+		todo := model.Todo{Title: strings.TrimSpace(item.Content), Done: item.Checked}
+		if todo.Title == "" {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "content is empty"})
+			continue
+		}
+
+		// the project is the list, labels and the priority are tags (JSON priorities count up: 4 is p1)
+		todo.List = strings.TrimSpace(projectNames[item.ProjectID])
+		for _, l := range item.Labels {
+			todo.Tags = append(todo.Tags, todoistTag(l))
+		}
+		if p := todoistPriority(5 - item.Priority); p != "" {
+			todo.Tags = append(todo.Tags, p)
+		}
+		todo.Tags = model.CleanTags(todo.Tags)
+		if err := todo.Validate(); err != nil {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+			continue
+		}
+		if item.Due != nil && item.Due.Date != "" {
+			due, err := parseTodoistDue(item.Due.Date)
+			if err != nil {
+				report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+				continue
+			}
+			todo.Due = due
+			if item.Due.IsRecurring {
+				report.Unmapped.Recurring++
+			}
+		}
+
+		drafts = append(drafts, todo)
+	}
+
+	return drafts, nil
+}
+
+
+// todoistFromCSV converts a project exported as Todoist's CSV template (TYPE,CONTENT,...,PRIORITY,...,DATE);
+// the template doesn't name its project, so its todos go on list
+func todoistFromCSV(body io.Reader, list string, report *TodoistImportReport) ([]model.Todo, error) {
+
+	in := csv.NewReader(body)
+	in.FieldsPerRecord = -1
+
+	header, err := in.Read()
+	if err != nil {
+		return nil, errors.New("cannot read CSV header: " + err.Error())
+	}
+	column := make(map[string]int)
+	for i, h := range header {
+		column[strings.ToUpper(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"TYPE", "CONTENT"} {
+		if _, ok := column[required]; !ok {
+			return nil, errors.New("not a Todoist CSV template: no " + required + " column")
+		}
+	}
+
+	var drafts []model.Todo
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		row, _ := in.FieldPos(0)
+		if err != nil {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+			break
+		}
+		cell := func(name string) string {
+			if i, ok := column[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		// sections and notes have no todo equivalent
+		if !strings.EqualFold(cell("TYPE"), "task") {
+			continue
+		}
+
+		// labels are written inline as @label in the content
+		todo := model.Todo{List: list}
+		var title []string
+		for _, word := range strings.Fields(cell("CONTENT")) {
+			if strings.HasPrefix(word, "@") && len(word) > 1 {
+				todo.Tags = append(todo.Tags, word[1:])
+				continue
+			}
+			title = append(title, word)
+		}
+		todo.Title = strings.Join(title, " ")
+		if todo.Title == "" {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "content is empty"})
+			continue
+		}
+
+		// CSV priorities count the other way round: 1 is the most urgent
+		if p, err := strconv.Atoi(cell("PRIORITY")); err == nil {
+			if tag := todoistPriority(p); tag != "" {
+				todo.Tags = append(todo.Tags, tag)
+			}
+		}
+		todo.Tags = model.CleanTags(todo.Tags)
+		if err := todo.Validate(); err != nil {
+			report.Skipped = append(report.Skipped, ImportError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		// DATE is the text typed into Todoist ("tomorrow", "every monday"); only real dates carry over
+		if date := cell("DATE"); date != "" {
+			due, err := parseDue(date, time.UTC)
+			if err != nil {
+				report.Skipped = append(report.Skipped, ImportError{Row: row, Error: "date " + strconv.Quote(date) + " is not a calendar date"})
+				continue
+			}
+			todo.Due = due
+		}
+
+		drafts = append(drafts, todo)
+	}
+
+	return drafts, nil
+}
+
+
+// import a Todoist export (JSON backup or CSV template); ?dry_run=true only reports what would be created,
+// ?list= names the list a CSV template's todos go on
+func todoistImportHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := TodoistImportReport{Todos: []model.Todo{}, Skipped: []ImportError{}}
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		dry, err := strconv.ParseBool(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		report.DryRun = dry
+	}
+
+	var drafts []model.Todo
+	var err error
+	switch mediaType(r.Header.Get("Content-Type")) {
+	case "application/json":
+		drafts, err = todoistFromJSON(r.Body, &report)
+	case "text/csv":
+		drafts, err = todoistFromCSV(r.Body, strings.TrimSpace(r.URL.Query().Get("list")), &report)
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// everything parsed, now create (unless this is a dry run)
+	for _, draft := range drafts {
+		if report.DryRun {
+			report.Todos = append(report.Todos, draft)
+			continue
+		}
+		todo, err := todoStore.Create(r.Context(), draft)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		report.Todos = append(report.Todos, todo)
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"strings"  // for request bodies
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// Todoist import tests: projects kept as lists, labels and priorities as tags, from both formats
+
+
+// TestTodoistImport checks a dry run of each format keeps what Todoist knew about a task
+func TestTodoistImport(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+		want        string
+	}{
+		{"JSON backup", "/import/todoist?dry_run=true", "application/json", `{
+			"projects": [{"id": "7", "name": "Groceries"}],
+			"items": [
+				{"content": "milk", "project_id": "7", "priority": 4, "labels": ["Dairy", "cold stuff"]},
+				{"content": "bread", "project_id": "7", "priority": 1, "checked": true},
+				{"content": "call mum", "project_id": "8", "priority": 2, "due": {"date": "2026-11-01", "is_recurring": true}}
+			]}`, http.StatusOK, `{"dry_run":true,"todos":[
+				{"id":0,"title":"milk","done":false,"list":"Groceries","tags":["cold-stuff","dairy","p1"],"rev":0},
+				{"id":0,"title":"bread","done":true,"list":"Groceries","rev":0},
+				{"id":0,"title":"call mum","done":false,"due":"2026-11-01T00:00:00Z","tags":["p3"],"rev":0}],
+				"skipped":[],"unmapped":{"recurring":1}}`},
+		{"CSV template on a list", "/import/todoist?dry_run=true&list=groceries", "text/csv",
+			"TYPE,CONTENT,PRIORITY,DATE\ntask,milk @dairy @Cold,1,\nsection,Bakery,,\ntask,bread,4,\n", http.StatusOK,
+			`{"dry_run":true,"todos":[
+				{"id":0,"title":"milk","done":false,"list":"groceries","tags":["cold","dairy","p1"],"rev":0},
+				{"id":0,"title":"bread","done":false,"list":"groceries","rev":0}],
+				"skipped":[],"unmapped":{"recurring":0}}`},
+		{"CSV template on no list", "/import/todoist?dry_run=true", "text/csv",
+			"TYPE,CONTENT,PRIORITY\ntask,bread,2\n", http.StatusOK,
+			`{"dry_run":true,"todos":[{"id":0,"title":"bread","done":false,"tags":["p2"],"rev":0}],"skipped":[],"unmapped":{"recurring":0}}`},
+		{"label that isn't a tag", "/import/todoist?dry_run=true", "text/csv",
+			"TYPE,CONTENT\ntask,bread @" + strings.Repeat("x", 51) + "\n", http.StatusOK,
+			`{"dry_run":true,"todos":[],"skipped":[{"row":2,"error":"validation failed: tags: can't be longer than 50 characters each"}],"unmapped":{"recurring":0}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			req, _ := http.NewRequest(http.MethodPost, s.URL+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			s.Send(req).ExpectStatus(tt.status).ExpectJSON(tt.want)
+		})
+	}
+}
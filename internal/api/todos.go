@@ -0,0 +1,419 @@
+package api
+
+import (
+	"encoding/json" // for JSON encode/decode
+	"errors"        // for bad due values
+	"fmt"           // for writing the list
+	"io"            // for caching the list while streaming it
+	"net/http"      // for HTTP handlers
+	"net/url"       // for filters from query strings
+	"strconv"       // for string -> int conversion
+	"strings"       // for title search
+	"time"          // for due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for list filters
+)
+
+// CreateTodoRequest represents input body for creating todo
+type CreateTodoRequest struct {
+	Title    string     `json:"title"`
+	Due      *time.Time `json:"due"`            // optional, RFC 3339 or words like "tomorrow 5pm"
+	CreateAt *time.Time `json:"create_at"`      // optional, when the todo appears, see scheduled.go
+	List     string     `json:"list"`           // optional, the list it goes on
+	Tags     []string   `json:"tags,omitempty"` // optional
+
+	duePhrase      string // due in words, resolved by the handler in the client's time zone
+	createAtPhrase string // create_at in words, likewise
+}
+
+
+// UnmarshalJSON keeps a due or create_at that isn't RFC 3339 as a phrase to resolve later
+func (req *CreateTodoRequest) UnmarshalJSON(data []byte) error {
+
+	var body struct {
+		Title    string          `json:"title"`
+		Due      json.RawMessage `json:"due"`
+		CreateAt json.RawMessage `json:"create_at"`
+		List     string          `json:"list"`
+		Tags     []string        `json:"tags"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	*req = CreateTodoRequest{Title: body.Title, List: strings.TrimSpace(body.List), Tags: model.CleanTags(body.Tags)}
+
+	var err error
+	if req.Due, req.duePhrase, err = timeOrPhrase(body.Due); err != nil {
+		return errors.New("due must be a string")
+	}
+	if req.CreateAt, req.createAtPhrase, err = timeOrPhrase(body.CreateAt); err != nil {
+		return errors.New("create_at must be a string")
+	}
+	return nil
+}
+
+
+// timeOrPhrase reads an optional JSON string as an RFC 3339 time, or else as words
+func timeOrPhrase(raw json.RawMessage) (*time.Time, string, error) {
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, "", err
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, "", nil
+	}
+	return nil, s, nil
+}
+
+// UpdateTodoRequest is the optional JSON body of PUT /todos/update; absent fields stay as they are
+type UpdateTodoRequest struct {
+	Rev   int       `json:"rev"` // the revision the change was made against (0 = don't check)
+	Title *string   `json:"title"`
+	Done  *bool     `json:"done"`
+	Due   DueEdit   `json:"due"`  // RFC 3339, or null to clear it
+	List  *string   `json:"list"` // "" takes it off its list
+	Tags  *[]string `json:"tags"` // replaces them all
+}
+
+// flush GET /todos every this many entries so large lists go out in chunks
+const listFlushEvery = 1000
+
+
+// get all todos, optionally filtered with ?done=, ?due_after=, ?due_before=, ?due=, ?q=, ?list=, ?tag=, ?created_by=
+func getTodosHandler(w http.ResponseWriter, r *http.Request) {
+
+	// tell client that response is JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// bad filter values are bad input; dates and ?due= are in the client's time zone
+	loc, err := requestLocation(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter, err := todoFilterFromQuery(r.URL.Query(), todoStore.Now(), loc)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// due date filters are still behind a feature flag
+	if (filter.DueAfter != nil || filter.DueBefore != nil) && !featureEnabled(r, "due-search") {
+		writeError(w, r, model.Invalid("due_after", "due date filters are not enabled on this server"))
+		return
+	}
+
+	// any change may change a filtered list too, so every list has the collection's time;
+	// read before the copy, so a change in between only makes the next poll fetch again
+	if notModified(w, r, storeFor(r).Modified()) {
+		return
+	}
+
+	// protobuf: a ListTodosResponse, in id order like the JSON
+	if wantsProto(r) {
+		writeProto(w, marshalTodoListProto(todoStore.Find(r.Context(), filter)))
+		return
+	}
+
+	// polling clients repeat the same few filters: answer from the cache while the store is
+	// unchanged. the cache follows the default tenant's store, so tenants' lists aren't kept
+	key := listCacheKey(filter)
+	cacheable := store.TenantFrom(r.Context()) == ""
+	if cacheable {
+		if body, ok := cachedListBody(key); ok {
+			w.Header().Set("X-Cache", "HIT")
+			w.Write(body)
+			return
+		}
+		w.Header().Set("X-Cache", "MISS")
+	}
+
+	// copy under the store locks, then encode and write without them:
+	// a slow client must not keep writers waiting. the generation is read first, so a change
+	// during the copy leaves the cached copy already stale rather than wrongly current
+	generation := todoStore.Generation()
+	list := todoStore.Find(r.Context(), filter)
+
+	// same id -> Todo object as before, but written one entry at a time (in id order)
+	// so the encoded response is never held in memory as a whole (unless it's small enough to cache)
+	saved := &cappedBuffer{max: *listCacheMaxBody, over: *listCacheEntries <= 0}
+	if err := writeTodoMap(w, io.MultiWriter(w, saved), list); err != nil {
+		return // client went away
+	}
+
+	if !saved.over && cacheable {
+		storeListBody(key, generation, saved.buf)
+	}
+}
+
+
+// writeTodoMap writes list as the id -> Todo object of GET /todos, in id order, flushing w
+// every listFlushEvery entries
+func writeTodoMap(w http.ResponseWriter, out io.Writer, list []model.Todo) error {
+
+	flusher := http.NewResponseController(w)
+	fmt.Fprint(out, "{")
+	for i, todo := range list {
+		entry, _ := json.Marshal(todo)
+		if i > 0 {
+			fmt.Fprint(out, ",")
+		}
+		if _, err := fmt.Fprintf(out, `"%d":%s`, todo.ID, entry); err != nil {
+			return err
+		}
+		if (i+1)%listFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+	_, err := fmt.Fprintln(out, "}")
+	return err
+}
+
+
+// patch is the change the request asks for
+func (req UpdateTodoRequest) patch() model.Patch {
+	if req.List != nil {
+		list := strings.TrimSpace(*req.List)
+		req.List = &list
+	}
+	return model.Patch{Title: req.Title, Done: req.Done, SetDue: req.Due.Set, Due: req.Due.Due, List: req.List, Tags: req.Tags}
+}
+
+
+// get
+func createTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// err handling for decoding request body (bad input), JSON or protobuf
+	req, err := readCreateTodoRequest(w, r)
+	var invalid *model.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		err = model.Invalid("body", err.Error())
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// a create_at still to come schedules the todo instead
+	if req.createAtPhrase != "" {
+		loc, err := requestLocation(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		createAt, err := resolveDue(req.createAtPhrase, todoStore.Now(), loc)
+		if err != nil {
+			writeError(w, r, model.Invalid("create_at", err.Error()))
+			return
+		}
+		req.CreateAt = &createAt
+	}
+	if req.CreateAt != nil && req.CreateAt.After(todoStore.Now()) {
+		pending, err := scheduleTodo(r, req, *req.CreateAt)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/scheduled/%d", pending.ID))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(pending)
+		return
+	}
+
+	// "tomorrow 5pm" and such, in the client's time zone
+	if req.duePhrase != "" {
+		if req.Due, err = dueFromRequest(r, req.duePhrase); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	// store the new todo (store handles locking and validation)
+	todo, err := todoStore.Create(r.Context(), model.Todo{Title: req.Title, Due: req.Due, List: req.List, Tags: req.Tags})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// convert todo to JSON (or protobuf) and send response
+	if wantsProto(r) {
+		writeProto(w, marshalTodoProto(todo))
+		return
+	}
+	json.NewEncoder(w).Encode(todo)
+}
+
+
+// put update
+func updateTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only PUT method
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// read id from query param (?id=1)
+	id, err := queryID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// a JSON body changes the fields it has: {"rev": 3, "title": "...", "done": true, "due": null, "tags": ["home"]}
+	q := r.URL.Query()
+	var req UpdateTodoRequest
+	hasBody := false
+	if mediaType(r.Header.Get("Content-Type")) == "application/json" {
+		if err := decodeBody(r, "todo-update", &req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, r, err)
+			return
+		} else if err == nil {
+			hasBody = true
+		}
+	} else if n, _ := io.ReadFull(r.Body, make([]byte, 1)); n > 0 {
+		// only no body at all means "mark done", not a body we can't read
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(map[string]string{"error": "a body must be sent as application/json"})
+		return
+	}
+	patch := req.patch()
+
+	// without one, mark as done (or open again with ?done=false); a request that only moves the
+	// due date with ?due= (RFC 3339 or words like "next friday", empty clears it) leaves done alone
+	if !hasBody {
+		if v := q.Get("done"); v != "" || !q.Has("due") {
+			done := true
+			if v != "" {
+				if done, err = strconv.ParseBool(v); err != nil {
+					writeError(w, r, model.Invalid("done", "must be true or false"))
+					return
+				}
+			}
+			patch.Done = &done
+		}
+		if q.Has("due") {
+			patch.SetDue = true
+			if v := q.Get("due"); v != "" {
+				if patch.Due, err = dueFromRequest(r, v); err != nil {
+					writeError(w, r, err)
+					return
+				}
+			}
+		}
+	}
+
+	// the revision the client last saw (?rev= or "rev"): changes since then are merged, and
+	// changes to the same field are a 409 with both versions. none = last write wins
+	if v := q.Get("rev"); v != "" {
+		if req.Rev, err = strconv.Atoi(v); err != nil || req.Rev < 0 {
+			writeError(w, r, model.Invalid("rev", "must be an integer"))
+			return
+		}
+	}
+
+	// 404 if todo doesn't exist
+	todo, err := todoStore.Merge(r.Context(), id, req.Rev, patch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// return updated todo
+	if wantsProto(r) {
+		writeProto(w, marshalTodoProto(todo))
+		return
+	}
+	json.NewEncoder(w).Encode(todo)
+}
+
+
+// delete
+func deleteTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// read id from query param
+	id, err := queryID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// delete todo, 404 if it doesn't exist
+	if err := todoStore.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// todoFilterFromQuery reads ?done=true|false, ?due_after= and ?due_before= (RFC 3339 or 2006-01-02,
+// midnight in loc) or ?due=today|tomorrow|this_week|next_week|overdue, ?q= for title text,
+// ?list= and ?tag= for one list or tag, and ?created_by= for one actor's todos; a
+// *model.ValidationError names every bad parameter
+func todoFilterFromQuery(q url.Values, now time.Time, loc *time.Location) (store.Filter, error) {
+
+	var f store.Filter
+	invalid := &model.ValidationError{}
+	if v := q.Get("done"); v != "" {
+		done, err := strconv.ParseBool(v)
+		if err != nil {
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "done", Message: "must be true or false"})
+		}
+		f.Done = &done
+	}
+
+	var err error
+	if f.DueAfter, err = parseDue(q.Get("due_after"), loc); err != nil {
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: "due_after", Message: "is not a date (2006-01-02 or RFC 3339)"})
+	}
+	if f.DueBefore, err = parseDue(q.Get("due_before"), loc); err != nil {
+		invalid.Fields = append(invalid.Fields, model.FieldError{Field: "due_before", Message: "is not a date (2006-01-02 or RFC 3339)"})
+	}
+	if v := q.Get("due"); v != "" {
+		start, end, ok := dueRange(v, now, loc)
+		switch {
+		case !ok:
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "due", Message: "must be today, tomorrow, this_week, next_week or overdue"})
+		case f.DueAfter != nil || f.DueBefore != nil:
+			invalid.Fields = append(invalid.Fields, model.FieldError{Field: "due", Message: "can't be combined with due_after or due_before"})
+		default:
+			f.DueAfter, f.DueBefore = start, end
+		}
+	}
+	f.Text = strings.TrimSpace(q.Get("q"))
+	f.List = strings.TrimSpace(q.Get("list"))
+	if tags := model.CleanTags([]string{q.Get("tag")}); tags != nil {
+		f.Tag = tags[0]
+	}
+	f.CreatedBy = strings.TrimSpace(q.Get("created_by"))
+	if len(invalid.Fields) > 0 {
+		return f, invalid
+	}
+	return f, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"           // for seeding the store
+	"net/http"          // for handler requests
+	"net/http/httptest" // for calling handlers in-process
+	"strconv"           // for ids
+	"strings"           // for request bodies
+	"testing"           // for the benchmarks
+	"time"              // for seeded due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// benchmarks of the todo handlers, called directly without the middleware:
+// go test -run '^$' -bench . -benchmem ./internal/api
+
+
+// useSeededStore makes the handlers serve a new store of n todos: every 10th done, half with a due date
+func useSeededStore(n int) {
+
+	todoStore = store.New(*changeLogSize)
+	clearListCache() // cached lists are of the old store
+	for i := range n {
+		draft := model.Todo{Title: "seed " + strconv.Itoa(i)}
+		if i%2 == 0 {
+			due := time.Now().Add(time.Duration(i) * time.Hour)
+			draft.Due = &due
+		}
+		todo, _ := todoStore.Create(context.Background(), draft)
+		if i%10 == 0 {
+			todoStore.SetDone(context.Background(), todo.ID, true)
+		}
+	}
+}
+
+
+// BenchmarkListHandler10k encodes GET /todos of 10k todos, the list cache off
+func BenchmarkListHandler10k(b *testing.B) {
+	useSeededStore(10000)
+	entries := *listCacheEntries
+	*listCacheEntries = 0 // encoding every time, not the cache
+	defer func() { *listCacheEntries = entries }()
+	for b.Loop() {
+		getTodosHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos", nil))
+	}
+}
+
+
+// BenchmarkListHandler10kCached answers GET /todos?done=false of 10k todos from the list cache
+func BenchmarkListHandler10kCached(b *testing.B) {
+	useSeededStore(10000)
+	for b.Loop() {
+		getTodosHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos?done=false", nil))
+	}
+}
+
+
+// BenchmarkCreateHandler answers POST /todos/create
+func BenchmarkCreateHandler(b *testing.B) {
+	useSeededStore(0)
+	for b.Loop() {
+		createTodoHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/todos/create", strings.NewReader(`{"title":"bench"}`)))
+	}
+}
+
+
+// BenchmarkUpdateHandler answers PUT /todos/update, toggling todos
+func BenchmarkUpdateHandler(b *testing.B) {
+	useSeededStore(10000)
+	i := 0
+	for b.Loop() {
+		updateTodoHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/todos/update?id="+strconv.Itoa(1+i%10000), nil))
+		i++
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http" // for HTTP middleware
+	"strconv"  // for the status code attribute
+	"strings"  // for gRPC span names
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for request spans
+)
+
+// request tracing: spans themselves and their export live in internal/tracing, this is the
+// middleware giving every request a server span, named after the route it took
+
+// tracing config (spans are only recorded when an OTLP endpoint is set)
+var otlpEndpoint = flags.String("otlp-endpoint", "", "OTLP/HTTP collector base URL, e.g. http://localhost:4318 (tracing off when empty)")
+
+// name this service reports to collectors and brokers
+var serviceName = flags.String("service-name", "todo-api", "service.name reported on exported spans")
+
+
+// traceRequests wraps every request in a server span, continuing any incoming trace context
+func traceRequests(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// tracing off, pass straight through
+		if !tracing.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// continue the caller's trace if they sent one
+		ctx := r.Context()
+		if parent, ok := tracing.ParseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = tracing.ContextWithSpan(ctx, parent)
+		}
+
+		// named by the method until the router knows the route (see nameSpans); a gRPC call's path
+		// is its service and method already
+		name := r.Method
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			name = strings.TrimPrefix(r.URL.Path, "/")
+		}
+		ctx, span := tracing.Start(ctx, name, tracing.KindServer)
+		span.SetAttr("http.method", r.Method)
+		span.SetAttr("http.target", r.URL.RequestURI())
+		if addr, ok := clientIP(r); ok {
+			span.SetAttr("client.address", addr.String())
+		}
+
+		// tell the client which trace served them (traceparent is only ever sent to a callee)
+		w.Header().Set("traceresponse", span.Traceparent())
+
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		// 5xx marks the span as failed
+		span.SetAttr("http.status_code", strconv.Itoa(rec.status))
+		span.SetStatus(tracing.StatusOK)
+		if rec.status >= 500 {
+			span.SetStatus(tracing.StatusError)
+		}
+		span.End()
+	})
+}
+
+
+// nameSpans names each request's span after the pattern mux matched, e.g. "GET /todos/{id}/revisions",
+// so spans of one route group together whatever ids are in the path. the mux sets the pattern on
+// the request it's handed, so this has to be the handler right around it
+func nameSpans(mux *http.ServeMux) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		mux.ServeHTTP(w, r)
+
+		// no pattern: nothing matched, the method is all there is
+		switch {
+		case r.Pattern == "":
+		case strings.Contains(r.Pattern, " "):
+			tracing.FromContext(r.Context()).SetName(r.Pattern)
+		default:
+			tracing.FromContext(r.Context()).SetName(r.Method + " " + r.Pattern)
+		}
+	})
+}
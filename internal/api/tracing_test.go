@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"encoding/json"     // for the exported spans
+	"net/http"          // for the requests
+	"net/http/httptest" // for the collector
+	"strings"           // for the trace ids
+	"sync"              // for guarding the spans seen
+	"testing"           // for the tests
+	"time"              // for waiting for the export
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest"          // for the API under test
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for turning tracing on
+)
+
+// tracing tests: server spans named after their route, continuing the caller's trace, over a
+// collector taking the OTLP export. tracing can't be turned off again, so it stays on for the
+// tests that run after these
+
+// exportedSpan is the part of an OTLP/JSON span the tests look at
+type exportedSpan struct {
+	TraceID  string `json:"traceId"`
+	ParentID string `json:"parentSpanId"`
+	Name     string `json:"name"`
+	Kind     int    `json:"kind"`
+}
+
+
+// collect turns tracing on, exporting to a collector that keeps every span it's sent
+func collect() func() []exportedSpan {
+
+	var mu sync.Mutex
+	var spans []exportedSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range payload.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	// not closed: the tests after these export to it too
+	tracing.StartExporter(collector.URL, "todo-api-test")
+
+	return func() []exportedSpan {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]exportedSpan{}, spans...)
+	}
+}
+
+
+// TestRequestSpans checks each request's span is named after its route and continues the
+// caller's trace, and that the answer names it in traceresponse
+func TestRequestSpans(t *testing.T) {
+
+	spans := collect()
+	s := apitest.New(t)
+	s.Seed(apitest.NewSeed().Todo("milk"))
+
+	tests := []struct {
+		path    string
+		traceID string
+		want    string // span name
+	}{
+		{"/todos/1/revisions", "4bf92f3577b34da6a3ce929d0e0e4736", "GET /todos/{id}/revisions"},
+		{"/todos/get?id=1", "5bf92f3577b34da6a3ce929d0e0e4736", "GET /todos/get"},
+		{"/lists/groceries/export.pdf", "6bf92f3577b34da6a3ce929d0e0e4736", "GET /lists/{id}/export.pdf"},
+		{"/no/such/route", "7bf92f3577b34da6a3ce929d0e0e4736", "GET"},
+	}
+	const parentID = "00f067aa0ba902b7"
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, s.URL+tt.path, nil)
+		req.Header.Set("traceparent", "00-"+tt.traceID+"-"+parentID+"-01")
+		resp := s.Send(req)
+		if got := resp.Header.Get("traceresponse"); !strings.HasPrefix(got, "00-"+tt.traceID+"-") {
+			t.Errorf("%s: traceresponse %q, want one in trace %s", tt.path, got, tt.traceID)
+		}
+		if got := resp.Header.Get("traceparent"); got != "" {
+			t.Errorf("%s: answered with traceparent %q", tt.path, got)
+		}
+	}
+
+	// spans are exported in batches every couple of seconds
+	names := make(map[string]string)
+	for deadline := time.Now().Add(10 * time.Second); len(names) < len(tests) && time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		for _, span := range spans() {
+			if span.Kind == tracing.KindServer && span.ParentID == parentID {
+				names[span.TraceID] = span.Name
+			}
+		}
+	}
+	for _, tt := range tests {
+		if got, ok := names[tt.traceID]; !ok {
+			t.Errorf("%s: no server span exported in trace %s", tt.path, tt.traceID)
+		} else if got != tt.want {
+			t.Errorf("%s: span %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
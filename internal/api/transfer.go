@@ -0,0 +1,454 @@
+package api
+
+import (
+	"bufio"         // for reading NDJSON line by line
+	"encoding/csv"  // for spreadsheet export/import
+	"encoding/json" // for import reports
+	"errors"        // for row errors
+	"io"            // for reading uploads
+	"maps"          // for the Markdown sections
+	"net/http"      // for HTTP handlers
+	"slices"        // for the sections in order
+	"strconv"       // for ids and booleans
+	"strings"       // for header matching
+	"time"          // for due dates
+	"unicode"       // for splitting tags
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// column names accepted for each field, first one is what we export
+var csvColumnAliases = map[string][]string{
+	"title": {"title", "name", "task", "content", "description"},
+	"done":  {"done", "completed", "complete", "status", "checked"},
+	"due":   {"due", "due date", "due_date", "deadline"},
+	"list":  {"list", "project", "category"},
+	"tags":  {"tags", "tag", "labels"},
+}
+
+// tags share one CSV cell, a todo's tags can't contain spaces
+const csvTagSeparator = " "
+
+// ImportError reports one row that could not be imported
+type ImportError struct {
+	Row   int    `json:"row"` // line in the upload, the header is row 1
+	Error string `json:"error"`
+}
+
+// ImportResult is the answer to an import
+type ImportResult struct {
+	Imported      int           `json:"imported"`
+	Errors        []ImportError `json:"errors"`
+	ErrorsDropped int           `json:"errors_dropped,omitempty"` // failed rows beyond maxImportErrors
+}
+
+// how many row errors an import reports in detail
+const maxImportErrors = 1000
+
+
+// fail records a row error, only counting it once the report is full
+func (res *ImportResult) fail(row int, msg string) {
+	if len(res.Errors) >= maxImportErrors {
+		res.ErrorsDropped++
+		return
+	}
+	res.Errors = append(res.Errors, ImportError{Row: row, Error: msg})
+}
+
+
+// parseDone accepts the usual spreadsheet spellings of a checkbox
+func parseDone(s string) (bool, error) {
+
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "false", "no", "n", "open", "todo", "pending":
+		return false, nil
+	case "1", "true", "yes", "y", "x", "done", "completed", "complete":
+		return true, nil
+	}
+	return false, errors.New("done: cannot read " + strconv.Quote(s) + " as yes/no")
+}
+
+
+// parseDue accepts RFC 3339 timestamps or plain dates (midnight in loc)
+func parseDue(s string, loc *time.Location) (*time.Time, error) {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.New("due: " + strconv.Quote(s) + " is not a date (2006-01-02 or RFC 3339)")
+}
+
+
+// csvColumns maps fields to column indexes, from ?map=field:Header,... or the header aliases
+func csvColumns(header []string, mapping string) (map[string]int, error) {
+
+	index := make(map[string]int)
+	findColumn := func(name string) int {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// explicit mapping first
+	if mapping != "" {
+		for _, pair := range strings.Split(mapping, ",") {
+			field, column, ok := strings.Cut(pair, ":")
+			if _, known := csvColumnAliases[field]; !ok || !known {
+				return nil, errors.New("map: expected field:Column pairs with fields title, done, due, list, tags")
+			}
+			i := findColumn(column)
+			if i < 0 {
+				return nil, errors.New("map: no column named " + strconv.Quote(column))
+			}
+			index[field] = i
+		}
+	}
+
+	// everything else by well known names
+	for field, aliases := range csvColumnAliases {
+		if _, mapped := index[field]; mapped {
+			continue
+		}
+		for _, alias := range aliases {
+			if i := findColumn(alias); i >= 0 {
+				index[field] = i
+				break
+			}
+		}
+	}
+
+	if _, ok := index["title"]; !ok {
+		return nil, errors.New("no title column (use ?map=title:YourColumn)")
+	}
+	return index, nil
+}
+
+
+// download every todo as CSV
+func exportCSVHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.csv"`)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "title", "done", "due", "list", "tags"})
+	for _, todo := range todoStore.List(r.Context()) {
+		due := ""
+		if todo.Due != nil {
+			due = todo.Due.Format(time.RFC3339)
+		}
+		out.Write([]string{strconv.Itoa(todo.ID), todo.Title, strconv.FormatBool(todo.Done), due, todo.List, strings.Join(todo.Tags, csvTagSeparator)})
+	}
+	out.Flush()
+}
+
+
+// importCSV creates a todo per valid row and collects errors for the rest
+func importCSV(r *http.Request, body io.Reader) (ImportResult, error) {
+
+	in := csv.NewReader(body)
+	in.FieldsPerRecord = -1 // ragged rows are reported per row, not fatal
+	in.TrimLeadingSpace = true
+
+	header, err := in.Read()
+	if err != nil {
+		return ImportResult{}, errors.New("cannot read CSV header: " + err.Error())
+	}
+	columns, err := csvColumns(header, r.URL.Query().Get("map"))
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result := ImportResult{Errors: []ImportError{}}
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := in.FieldPos(0)
+		if err != nil {
+			// a broken quote can't be skipped reliably, stop here
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			result.fail(line, err.Error())
+			break
+		}
+
+		cell := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		// tags from other tools may be comma separated too
+		tags := strings.FieldsFunc(cell("tags"), func(c rune) bool { return c == ',' || unicode.IsSpace(c) })
+		todo := model.Todo{Title: strings.TrimSpace(cell("title")), List: strings.TrimSpace(cell("list")), Tags: model.CleanTags(tags)}
+		if todo.Title == "" {
+			result.fail(line, "title is empty")
+			continue
+		}
+		if todo.Done, err = parseDone(cell("done")); err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+		if todo.Due, err = parseDue(cell("due"), time.UTC); err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+
+		if _, err := todoStore.Create(r.Context(), todo); err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// flush NDJSON exports every this many lines so clients see progress
+const ndjsonFlushEvery = 1000
+
+// longest NDJSON line we accept
+const ndjsonMaxLine = 1 << 20
+
+
+// stream every todo as one JSON object per line
+func exportNDJSONHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.ndjson"`)
+
+	// Encode writes each todo plus a newline straight to the connection
+	flusher := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for i, todo := range todoStore.List(r.Context()) {
+		if enc.Encode(todo) != nil {
+			return // client went away
+		}
+		if (i+1)%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+
+// importNDJSON creates a todo per line as it is read; bad lines are reported and skipped
+func importNDJSON(r *http.Request, body io.Reader) ImportResult {
+
+	result := ImportResult{Errors: []ImportError{}}
+	lines := bufio.NewScanner(body)
+	lines.Buffer(make([]byte, 64<<10), ndjsonMaxLine)
+
+	line := 0
+	for lines.Scan() {
+		line++
+		text := strings.TrimSpace(lines.Text())
+		if text == "" {
+			continue
+		}
+
+		// same fields as the export, ids are ignored and newly assigned
+		var todo model.Todo
+		if err := json.Unmarshal([]byte(text), &todo); err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+		todo.Title = strings.TrimSpace(todo.Title)
+		if todo.Title == "" {
+			result.fail(line, "title is empty")
+			continue
+		}
+
+		if _, err := todoStore.Create(r.Context(), model.Todo{Title: todo.Title, Done: todo.Done, Due: todo.Due, List: strings.TrimSpace(todo.List), Tags: todo.Tags}); err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+		result.Imported++
+	}
+
+	// too long a line or a dropped connection ends the import early
+	if err := lines.Err(); err != nil {
+		result.fail(line+1, err.Error())
+	}
+	return result
+}
+
+// markdownEscape keeps a title on one checklist line and stops it from turning into markup
+var markdownEscape = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "&lt;",
+	"\r\n", " ", "\n", " ", "\r", " ",
+)
+
+
+// download a Markdown checklist, a section per list by default (?group=tag for one per tag,
+// ?group=status for open / done, ?group=none for one flat list)
+func exportMarkdownHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	group := r.URL.Query().Get("group")
+	if group == "" {
+		group = "list"
+	}
+	if group != "list" && group != "tag" && group != "status" && group != "none" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// open todos come first in every section
+	list := todoStore.List(r.Context())
+	slices.SortStableFunc(list, func(a, b model.Todo) int {
+		switch {
+		case a.Done == b.Done:
+			return 0
+		case b.Done:
+			return -1
+		}
+		return 1
+	})
+
+	type section struct {
+		name  string
+		items []model.Todo
+	}
+	var sections []section
+	switch group {
+	case "none":
+		sections = []section{{items: list}}
+	case "list":
+		byList := map[string][]model.Todo{}
+		for _, todo := range list {
+			byList[todo.List] = append(byList[todo.List], todo)
+		}
+		for _, name := range slices.Sorted(maps.Keys(byList)) {
+			if name != "" {
+				sections = append(sections, section{markdownEscape.Replace(name), byList[name]})
+			}
+		}
+		sections = append(sections, section{"No list", byList[""]})
+	case "tag":
+		// a todo is under each of its tags
+		byTag := map[string][]model.Todo{}
+		var untagged []model.Todo
+		for _, todo := range list {
+			for _, tag := range todo.Tags {
+				byTag[tag] = append(byTag[tag], todo)
+			}
+			if len(todo.Tags) == 0 {
+				untagged = append(untagged, todo)
+			}
+		}
+		for _, tag := range slices.Sorted(maps.Keys(byTag)) {
+			sections = append(sections, section{"#" + markdownEscape.Replace(tag), byTag[tag]})
+		}
+		sections = append(sections, section{"No tag", untagged})
+	default:
+		var open, done []model.Todo
+		for _, todo := range list {
+			if todo.Done {
+				done = append(done, todo)
+			} else {
+				open = append(open, todo)
+			}
+		}
+		sections = []section{{"Open", open}, {"Done", done}}
+	}
+
+	var b strings.Builder
+	for _, sec := range sections {
+		if len(sec.items) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if sec.name != "" {
+			b.WriteString("## " + sec.name + "\n\n")
+		}
+		for _, todo := range sec.items {
+			mark := " "
+			if todo.Done {
+				mark = "x"
+			}
+			b.WriteString("- [" + mark + "] " + markdownEscape.Replace(todo.Title))
+			if todo.Due != nil {
+				b.WriteString(" (due " + todo.Due.Format("2006-01-02") + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+
+// import todos from an upload; bad rows are skipped and reported, good rows are created
+func importHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// both formats are read row by row, so the upload size is not limited
+	body := r.Body
+
+	// pick the format from the upload type
+	var result ImportResult
+	var err error
+	switch mediaType(r.Header.Get("Content-Type")) {
+	case "text/csv":
+		result, err = importCSV(r, body)
+	case "application/x-ndjson", "application/jsonl":
+		result = importNDJSON(r, body)
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+
+// mediaType strips parameters (charset etc.) from a Content-Type
+func mediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"strings"  // for request bodies
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// import and export tests: the CSV columns, lists and tags included, both ways, and the Markdown
+// checklist's sections
+
+
+// TestExportCSV checks every todo is a row, with its list and its tags in one cell
+func TestExportCSV(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+
+	want := "id,title,done,due,list,tags\n" +
+		"1,milk,false,,groceries,dairy fridge\n" +
+		"2,bread,true,,groceries,bakery\n" +
+		"3,soap,false,,groceries,\n" +
+		"4,file taxes,false,,,home\n"
+	resp := s.Get("/todos/export.csv").ExpectStatus(http.StatusOK).ExpectHeader("Content-Type", "text/csv; charset=utf-8")
+	if got := string(resp.Body); got != want {
+		t.Errorf("exported\n%s\nwant\n%s", got, want)
+	}
+}
+
+
+// TestImportCSV checks the list and tags columns are read by any of their names, and what an
+// export wrote imports the same again
+func TestImportCSV(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		csv      string
+		imported string
+		want     string // the todos
+	}{
+		{"export", "id,title,done,due,list,tags\n1,milk,false,,groceries,dairy fridge\n2,soap,true,,,\n", "2", `{
+			"1":{"id":1,"title":"milk","done":false,"list":"groceries","tags":["dairy","fridge"],"rev":1},
+			"2":{"id":2,"title":"soap","done":true,"rev":1}}`},
+		{"other names, comma separated", "Task,Project,Labels\nmilk, groceries ,\"#Fridge, dairy\"\n", "1", `{
+			"1":{"id":1,"title":"milk","done":false,"list":"groceries","tags":["dairy","fridge"],"rev":1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			req, _ := http.NewRequest(http.MethodPost, s.URL+"/todos/import", strings.NewReader(tt.csv))
+			req.Header.Set("Content-Type", "text/csv")
+			s.Send(req).ExpectStatus(http.StatusOK).ExpectJSON(`{"imported":`+tt.imported+`,"errors":[]}`)
+			s.Get("/todos").ExpectJSON(tt.want)
+		})
+	}
+
+	// a list too long is reported like any other bad row
+	s := apitest.New(t)
+	req, _ := http.NewRequest(http.MethodPost, s.URL+"/todos/import", strings.NewReader("title,list\nmilk,"+strings.Repeat("x", 101)+"\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	s.Send(req).ExpectJSON(`{"imported":0,"errors":[{"row":2,"error":"validation failed: list: is longer than 100 characters"}]}`)
+}
+
+
+// TestExportMarkdown checks the checklist's sections for each grouping, open todos first in each
+func TestExportMarkdown(t *testing.T) {
+
+	s := apitest.New(t)
+	groceries(s)
+
+	tests := []struct {
+		group  string
+		status int
+		want   string
+	}{
+		{"", http.StatusOK, "## groceries\n\n- [ ] milk\n- [ ] soap\n- [x] bread\n\n## No list\n\n- [ ] file taxes\n"},
+		{"tag", http.StatusOK, "## #bakery\n\n- [x] bread\n\n## #dairy\n\n- [ ] milk\n\n## #fridge\n\n- [ ] milk\n\n## #home\n\n- [ ] file taxes\n\n## No tag\n\n- [ ] soap\n"},
+		{"status", http.StatusOK, "## Open\n\n- [ ] milk\n- [ ] soap\n- [ ] file taxes\n\n## Done\n\n- [x] bread\n"},
+		{"none", http.StatusOK, "- [ ] milk\n- [ ] soap\n- [ ] file taxes\n- [x] bread\n"},
+		{"size", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		resp := s.Get("/todos/export.md?group=" + tt.group).ExpectStatus(tt.status)
+		if got := string(resp.Body); tt.status == http.StatusOK && got != tt.want {
+			t.Errorf("group %q:\n%s\nwant\n%s", tt.group, got, tt.want)
+		}
+	}
+}
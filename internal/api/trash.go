@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"       // for the janitor's store calls
+	"encoding/json" // for JSON responses
+	"errors"        // for config errors
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for HTTP handlers
+	"strconv"       // for ids in the path
+	"sync"          // for guarding the counters
+	"time"          // for retention ages
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the janitor's actor and filters
+)
+
+// the trash and the retention rules. deleted todos stay in the store's trash, where they can be
+// restored, until a janitor purges those deleted more than -trash-retention ago; it also archives
+// todos done for over -archive-done-after to -archive-file, like -max-todos evictions. the janitor
+// runs every few minutes and counts what it did, for GET /admin/retention
+var (
+	trashRetention   = flags.Duration("trash-retention", 30*24*time.Hour, "how long deleted todos stay in the trash before they're purged (0 keeps them until emptied)")
+	archiveDoneAfter = flags.Duration("archive-done-after", 0, "archive todos done for longer than this to -archive-file (0 = never)")
+)
+
+// how often the janitor applies the rules
+const retentionCheckEvery = 10 * time.Minute
+
+// RetentionStats is the body of GET /admin/retention
+type RetentionStats struct {
+	TrashRetention   string     `json:"trash_retention"`    // "off" or a duration
+	ArchiveDoneAfter string     `json:"archive_done_after"` // "off" or a duration
+	InTrash          int        `json:"in_trash"`
+	Runs             int        `json:"runs"`
+	LastRun          *time.Time `json:"last_run,omitempty"`
+	LastPurged       int        `json:"last_purged"`
+	LastArchived     int        `json:"last_archived"`
+	Purged           int        `json:"purged"`   // trashed todos purged since start
+	Archived         int        `json:"archived"` // done todos archived since start
+}
+
+// what the janitor has done since start
+var retention RetentionStats
+var retentionMu sync.Mutex
+
+
+// loadRetention checks the retention rules
+func loadRetention() error {
+	switch {
+	case *trashRetention < 0:
+		return errors.New("-trash-retention: must not be negative")
+	case *archiveDoneAfter < 0:
+		return errors.New("-archive-done-after: must not be negative")
+	case *archiveDoneAfter > 0 && *archiveFile == "":
+		return errors.New("-archive-done-after needs an -archive-file to archive to")
+	}
+	return nil
+}
+
+
+// ruleString shows a rule's duration, or off
+func ruleString(d time.Duration) string {
+	if d <= 0 {
+		return "off"
+	}
+	return d.String()
+}
+
+
+// applyRetention purges old trash, every tenant's, and archives long done todos as of now, and
+// counts it. only the default tenant's todos are archived: the archive doesn't say whose they were
+func applyRetention(now time.Time) RetentionStats {
+
+	ctx := store.WithActor(context.Background(), store.SystemActor)
+
+	purged := []int{}
+	if *trashRetention > 0 {
+		for _, tenant := range append([]string{""}, todoStore.Tenants()...) {
+			tenantCtx := store.WithTenant(ctx, tenant)
+			ids := todoStore.PurgeTrash(tenantCtx, now.Add(-*trashRetention))
+			for _, id := range ids {
+				dropAttachments(tenantCtx, id)
+			}
+			purged = append(purged, ids...)
+		}
+	}
+
+	archived := 0
+	if *archiveDoneAfter > 0 {
+		done := true
+		for _, todo := range todoStore.Find(ctx, store.Filter{Done: &done}) {
+			modified, err := todoStore.Tenant(ctx).TodoModified(todo.ID)
+			if err == nil && modified.Before(now.Add(-*archiveDoneAfter)) && evictTodo(ctx, todo.ID) {
+				archived++
+			}
+		}
+	}
+	if len(purged) > 0 || archived > 0 {
+		fmt.Println("retention:", len(purged), "trashed todos purged,", archived, "done todos archived")
+	}
+
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	retention.Runs++
+	retention.LastRun = &now
+	retention.LastPurged, retention.LastArchived = len(purged), archived
+	retention.Purged += len(purged)
+	retention.Archived += archived
+	return retentionReport()
+}
+
+
+// retentionReport is the counters with the rules and the size of every tenant's trash (caller
+// holds retentionMu)
+func retentionReport() RetentionStats {
+	report := retention
+	report.TrashRetention = ruleString(*trashRetention)
+	report.ArchiveDoneAfter = ruleString(*archiveDoneAfter)
+	for _, tenant := range append([]string{""}, todoStore.Tenants()...) {
+		report.InTrash += len(todoStore.Trash(store.WithTenant(context.Background(), tenant)))
+	}
+	return report
+}
+
+
+// startJanitor applies the retention rules every few minutes
+func startJanitor() {
+
+	// -archive-done-after archives to the same file as evictions
+	if *archiveDoneAfter > 0 {
+		openArchive()
+	}
+
+	go func() {
+		for range time.Tick(retentionCheckEvery) {
+			if scheduling() {
+				applyRetention(todoStore.Now())
+			}
+		}
+	}()
+}
+
+
+// GET /trash lists the deleted todos, most recently deleted first, DELETE empties it
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(todoStore.Trash(r.Context()))
+
+	case http.MethodDelete:
+		for _, id := range todoStore.PurgeTrash(r.Context(), todoStore.Now().Add(time.Nanosecond)) {
+			dropAttachments(r.Context(), id)
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+
+// trashPathID reads the {id} of a /trash path
+func trashPathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, model.Invalid("id", "must be an integer")
+	}
+	return id, nil
+}
+
+
+// DELETE /trash/{id} purges one deleted todo for good
+func trashedTodoHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := trashPathID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := todoStore.PurgeTrashed(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	dropAttachments(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// POST /trash/{id}/restore puts a deleted todo back, with its id and history
+func restoreTrashedHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only POST
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := trashPathID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	todo, err := todoStore.RestoreTrashed(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(todo)
+}
+
+
+// admin: GET /admin/retention reports the rules and what the janitor did, POST runs it now
+func retentionHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		retentionMu.Lock()
+		report := retentionReport()
+		retentionMu.Unlock()
+		json.NewEncoder(w).Encode(report)
+
+	case http.MethodPost:
+		json.NewEncoder(w).Encode(applyRetention(todoStore.Now()))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"embed"    // for the UI files in the binary
+	"io/fs"    // for serving them
+	"net/http" // for HTTP handlers
+)
+
+// serve the web UI at / (turn off for API-only deployments)
+var webUI = flags.Bool("ui", true, "serve the web UI at /")
+
+// the web UI: one page and its script and styles, built into the binary so there's nothing to
+// deploy next to it. it only talks to the public JSON endpoints, like any other client
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// the ui/ directory, served under /ui/
+var uiAssets, _ = fs.Sub(uiFiles, "ui")
+
+
+// serves the UI page, only at / so unknown paths stay 404s
+func uiIndexHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET (and HEAD)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !*webUI {
+		http.NotFound(w, r)
+		return
+	}
+
+	// revalidate so a new binary's page is picked up
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFileFS(w, r, uiAssets, "index.html")
+}
+
+
+// serves the UI's script and styles
+func uiAssetsHandler() http.Handler {
+
+	files := http.StripPrefix("/ui/", http.FileServerFS(uiAssets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !*webUI {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
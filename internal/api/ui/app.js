@@ -0,0 +1,168 @@
+// the web UI: lists, adds, toggles and deletes todos through the JSON API. paths are relative,
+// so the UI keeps working when the API is mounted under a prefix (package server)
+"use strict";
+
+const list = document.getElementById("todos");
+const errorBox = document.getElementById("error");
+let filter = "";
+
+// the browser's time zone, so the server reads dates the way the user means them
+const timeZone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+
+// api calls the endpoint and returns its JSON (null for 204); errors carry the server's message
+async function api(method, path, body) {
+	const opts = {method, headers: {Accept: "application/json"}};
+	if (timeZone) {
+		opts.headers["Time-Zone"] = timeZone;
+	}
+	if (body !== undefined) {
+		opts.headers["Content-Type"] = "application/json";
+		opts.body = JSON.stringify(body);
+	}
+	const resp = await fetch(path, opts);
+	if (resp.status === 204) {
+		return null;
+	}
+	const data = await resp.json().catch(() => null);
+	if (!resp.ok) {
+		throw new Error(data && data.error ? data.error : resp.status + " " + resp.statusText);
+	}
+	return data;
+}
+
+function showError(err) {
+	errorBox.textContent = err ? err.message : "";
+	errorBox.hidden = !err;
+}
+
+// load fetches the list for the current filter and redraws it
+async function load() {
+	try {
+		const todos = Object.values(await api("GET", "todos" + (filter ? "?done=" + filter : "")));
+		todos.sort((a, b) => a.id - b.id);
+		render(todos);
+		showError(null);
+	} catch (err) {
+		showError(err);
+	}
+}
+
+// localDay formats a time as its YYYY-MM-DD in the browser's time zone
+function localDay(time) {
+	return new Date(time.getTime() - time.getTimezoneOffset() * 60000).toISOString().slice(0, 10);
+}
+
+function render(todos) {
+	const today = localDay(new Date());
+	list.replaceChildren(...todos.map(todo => {
+		const li = document.createElement("li");
+		li.classList.toggle("done", todo.done);
+
+		const box = document.createElement("input");
+		box.type = "checkbox";
+		box.checked = todo.done;
+		box.title = todo.done ? "Mark as open" : "Mark as done";
+		box.addEventListener("change", () => act(api("PUT", "todos/update?id=" + todo.id + "&done=" + box.checked)));
+
+		const title = document.createElement("span");
+		title.className = "title";
+		title.textContent = todo.title;
+		li.append(box, title);
+
+		if (todo.due) {
+			const due = document.createElement("span");
+			const day = localDay(new Date(todo.due));
+			due.className = "due";
+			due.classList.toggle("overdue", !todo.done && day < today);
+			due.textContent = day;
+			li.append(due);
+		}
+
+		const del = document.createElement("button");
+		del.className = "delete";
+		del.type = "button";
+		del.title = "Delete";
+		del.textContent = "✕";
+		del.addEventListener("click", () => act(api("DELETE", "todos/delete?id=" + todo.id)));
+		li.append(del);
+		return li;
+	}));
+
+	document.getElementById("empty").hidden = todos.length > 0;
+	const open = todos.filter(todo => !todo.done).length;
+	document.getElementById("count").textContent = todos.length ? open + " open, " + (todos.length - open) + " done" : "";
+}
+
+// act waits for a change and reloads, or shows why it failed
+async function act(call) {
+	try {
+		await call;
+	} catch (err) {
+		showError(err);
+		return;
+	}
+	load();
+}
+
+document.getElementById("add").addEventListener("submit", event => {
+	event.preventDefault();
+	const form = event.target;
+	const due = document.getElementById("due").value;
+	const body = {title: document.getElementById("title").value};
+	if (due) {
+		// local midnight of the picked day
+		body.due = new Date(due + "T00:00:00").toISOString();
+	}
+	act(api("POST", "todos/create", body).then(() => form.reset()));
+});
+
+for (const button of document.querySelectorAll("#filters button")) {
+	button.addEventListener("click", () => {
+		filter = button.dataset.filter;
+		for (const other of document.querySelectorAll("#filters button")) {
+			other.setAttribute("aria-pressed", other === button);
+		}
+		load();
+	});
+}
+
+// urlBase64Bytes decodes the server's base64url VAPID key for pushManager.subscribe
+function urlBase64Bytes(text) {
+	const raw = atob(text.replace(/-/g, "+").replace(/_/g, "/"));
+	return Uint8Array.from(raw, c => c.charCodeAt(0));
+}
+
+// setUpPush offers overdue reminders through the service worker. a browser already subscribed
+// sends its subscription again, the server only keeps them in memory
+async function setUpPush() {
+	if (!("serviceWorker" in navigator) || !("PushManager" in window)) {
+		return;
+	}
+	const button = document.getElementById("notify");
+	const reg = await navigator.serviceWorker.register("ui/sw.js");
+	const subscribed = sub => {
+		button.hidden = true;
+		return api("POST", "push/subscribe", sub.toJSON());
+	};
+
+	const existing = await reg.pushManager.getSubscription();
+	if (existing) {
+		await subscribed(existing);
+		return;
+	}
+	button.hidden = Notification.permission === "denied";
+	button.addEventListener("click", async () => {
+		try {
+			const {public_key} = await api("GET", "push/vapid-public-key");
+			const sub = await reg.pushManager.subscribe({userVisibleOnly: true, applicationServerKey: urlBase64Bytes(public_key)});
+			await subscribed(sub);
+		} catch (err) {
+			showError(err);
+		}
+	});
+}
+
+// other clients change todos too
+window.addEventListener("focus", load);
+load();
+setUpPush().catch(showError);
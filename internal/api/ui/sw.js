@@ -0,0 +1,18 @@
+// the web UI's service worker: shows the server's Web Push reminders, even with the tab closed.
+// payloads are {"title", "body", "tag", "todo"}, see sendPushReminders
+"use strict";
+
+self.addEventListener("push", event => {
+	const msg = event.data ? event.data.json() : {title: "Todos"};
+	event.waitUntil(self.registration.showNotification(msg.title, {body: msg.body, tag: msg.tag, data: msg.todo}));
+});
+
+// clicking a reminder brings up an open UI tab, or opens one; the page lives next to ui/
+self.addEventListener("notificationclick", event => {
+	event.notification.close();
+	const page = new URL("..", self.registration.scope).href;
+	event.waitUntil(self.clients.matchAll({type: "window"}).then(tabs => {
+		const tab = tabs.find(tab => tab.url.startsWith(page));
+		return tab ? tab.focus() : self.clients.openWindow(page);
+	}));
+});
@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	"net/http" // for status codes
+	"strings"  // for request bodies
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// update tests: no body marks a todo done, a JSON body changes its fields, any other body is refused
+
+
+// TestUpdateBody checks which bodies PUT /todos/update reads, and that only none at all means done
+func TestUpdateBody(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        string // the todo, when status is 200
+	}{
+		{"no body", "", "", http.StatusOK, `{"id":1,"title":"milk","done":true,"rev":2}`},
+		{"empty JSON body", "application/json", "", http.StatusOK, `{"id":1,"title":"milk","done":true,"rev":2}`},
+		{"JSON", "application/json; charset=utf-8", `{"title":"oat milk"}`, http.StatusOK, `{"id":1,"title":"oat milk","done":false,"rev":2}`},
+		{"JSON without a type", "", `{"title":"oat milk"}`, http.StatusUnsupportedMediaType, ""},
+		{"form", "application/x-www-form-urlencoded", "title=oat+milk", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			s.Seed(apitest.NewSeed().Todo("milk"))
+			req, _ := http.NewRequest(http.MethodPut, s.URL+"/todos/update?id=1", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp := s.Send(req).ExpectStatus(tt.status)
+			if tt.want != "" {
+				resp.ExpectJSON(tt.want)
+			} else {
+				s.Get("/todos/get?id=1").ExpectJSON(`{"id":1,"title":"milk","done":false,"rev":1}`)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"   // for shutdown deadline
+	"errors"    // for listener type errors
+	"fmt"       // for printing logs to terminal
+	"net"       // for listeners
+	"os"        // for files, env & process handling
+	"os/exec"   // for starting the new binary
+	"os/signal" // for SIGHUP / SIGTERM handling
+	"strconv"   // for env parsing
+	"syscall"   // for signal numbers
+	"time"      // for shutdown timeout
+)
+
+// how long in-flight requests get to finish after SIGTERM or a handoff
+var shutdownTimeout = flags.Duration("shutdown-timeout", 30*time.Second, "how long to drain in-flight requests before exiting")
+
+// env vars used for socket handoff (LISTEN_FDS / LISTEN_PID follow systemd socket activation)
+const (
+	envListenFDs     = "LISTEN_FDS"
+	envListenPID     = "LISTEN_PID"
+	envUpgradeParent = "TODO_UPGRADE_PARENT"
+)
+
+// inherited fds start right after stdin/stdout/stderr
+const firstListenFD = 3
+
+
+// inheritedListeners rebuilds listeners passed in by a parent process or systemd.
+// returns nil, nil when nothing was inherited.
+func inheritedListeners() ([]net.Listener, error) {
+
+	count, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	// systemd sets LISTEN_PID to make sure the fds are meant for us, not a child
+	if pid := os.Getenv(envListenPID); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	// don't leak the handoff into processes we start later
+	os.Unsetenv(envListenFDs)
+	os.Unsetenv(envListenPID)
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		f := os.NewFile(uintptr(firstListenFD+i), "listener-"+strconv.Itoa(i))
+
+		// FileListener dups the fd, so the original can be closed
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited fd %d: %w", firstListenFD+i, err)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
+
+
+// listenerFile returns a dup of the listener's socket for passing to a child
+func listenerFile(ln net.Listener) (*os.File, error) {
+
+	switch l := ln.(type) {
+	case *net.TCPListener:
+		return l.File()
+	case *net.UnixListener:
+		// the new process keeps using the socket path, so closing ours must not remove it
+		l.SetUnlinkOnClose(false)
+		return l.File()
+	default:
+		return nil, errors.New("cannot hand off listener of type " + ln.Addr().Network())
+	}
+}
+
+
+// startUpgrade re-executes our own binary with the listening sockets attached
+func startUpgrade(listeners []net.Listener) error {
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// collect the sockets in -listen order, the child rebuilds them from fd 3 onwards
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, ln := range listeners {
+		f, err := listenerFile(ln)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envListenFDs+"="+strconv.Itoa(len(files)),
+		envUpgradeParent+"="+strconv.Itoa(os.Getpid()),
+	)
+
+	return cmd.Start()
+}
+
+
+// notifyParentReady tells the process that handed us its sockets to start draining
+func notifyParentReady() {
+
+	pid, err := strconv.Atoi(os.Getenv(envUpgradeParent))
+	os.Unsetenv(envUpgradeParent)
+	if err != nil {
+		return
+	}
+
+	// SIGTERM = graceful shutdown in the old process
+	if parent, err := os.FindProcess(pid); err == nil {
+		parent.Signal(syscall.SIGTERM)
+	}
+}
+
+
+// waitForSignals blocks until the server should exit:
+// SIGHUP starts a new binary on the same sockets, SIGTERM/SIGINT drain and return
+func waitForSignals(shutdown func(context.Context) error, listeners []net.Listener) {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, os.Interrupt)
+
+	for sig := range signals {
+
+		// binary upgrade: the child signals us with SIGTERM once it is serving
+		if sig == syscall.SIGHUP && clustered() {
+			fmt.Println("SIGHUP ignored: a cluster node can't share its log with a new process, restart it instead")
+			continue
+		}
+		if sig == syscall.SIGHUP {
+			fmt.Println("SIGHUP: starting new process on inherited sockets")
+			if err := startUpgrade(listeners); err != nil {
+				fmt.Println("upgrade failed, keep serving:", err)
+			}
+			continue
+		}
+
+		// stop accepting, report not-ready, and let in-flight requests finish
+		fmt.Println("shutting down, draining for up to", *shutdownTimeout)
+		shuttingDown.Store(true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		if err := shutdown(ctx); err != nil {
+			fmt.Println("drain incomplete:", err)
+		}
+		cancel()
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json" // for JSON responses
+	"net/http"      // for HTTP handlers
+	"runtime"       // for Go version
+	"runtime/debug" // for VCS info embedded by go build
+)
+
+// build info, injected at build time:
+//
+//	pkg=github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/api
+//	go build -ldflags "-X $pkg.version=1.2.0 -X $pkg.commit=$(git rev-parse HEAD) -X $pkg.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/todo-server
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// VersionInfo is the body of GET /version
+type VersionInfo struct {
+	Version   string `json:"version"`    // semantic version
+	Commit    string `json:"commit"`     // git commit hash
+	BuildDate string `json:"build_date"` // RFC3339 build time
+	GoVersion string `json:"go_version"` // toolchain that built the binary
+}
+
+
+// buildInfo returns ldflags values, falling back to the VCS stamp go build embeds
+func buildInfo() VersionInfo {
+
+	info := VersionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	// go build stamps vcs.revision / vcs.time when built inside a git checkout
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+
+	return info
+}
+
+
+// get version and build info
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only GET method
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildInfo())
+}
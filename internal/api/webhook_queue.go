@@ -0,0 +1,269 @@
+package api
+
+import (
+	"encoding/json" // for admin responses
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for admin handlers
+	"strconv"       // for string -> int conversion
+	"sync"          // for guarding status and dead letters
+	"time"          // for backoff
+)
+
+// delivery queue config
+var webhookWorkers = flags.Int("webhook-workers", 4, "number of concurrent webhook deliveries")
+var webhookMaxAttempts = flags.Int("webhook-max-attempts", 6, "delivery attempts before a payload is dead-lettered")
+var webhookBackoff = flags.Duration("webhook-backoff", 2*time.Second, "delay before the first retry, doubled on every further attempt")
+var webhookMaxBackoff = flags.Duration("webhook-max-backoff", 10*time.Minute, "upper bound for the retry delay")
+
+// how many dead letters are kept (oldest dropped first)
+const maxDeadLetters = 1000
+
+// webhookDelivery is one payload on its way to one webhook
+type webhookDelivery struct {
+	hookID      int
+	payload     WebhookPayload
+	attempt     int    // attempts made so far
+	traceparent string // trace of the change it's about, "" for none
+}
+
+// WebhookStatus tracks delivery health per webhook
+type WebhookStatus struct {
+	Delivered      int       `json:"delivered"`
+	Failed         int       `json:"failed"`  // failed attempts, including retried ones
+	Pending        int       `json:"pending"` // queued or waiting for a retry
+	DeadLettered   int       `json:"dead_lettered"`
+	LastAttempt    time.Time `json:"last_attempt,omitzero"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// DeadLetter is a payload that could not be delivered
+type DeadLetter struct {
+	ID        int            `json:"id"`
+	WebhookID int            `json:"webhook_id"`
+	Payload   WebhookPayload `json:"payload"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"last_error"`
+	FailedAt  time.Time      `json:"failed_at"`
+
+	traceparent string // passed on again when replayed
+}
+
+// deliveries waiting for a worker
+var deliveryQueue = make(chan webhookDelivery, 1024)
+
+// per webhook status and the dead letter list
+var deliveryMu sync.Mutex
+var webhookStatus = make(map[int]*WebhookStatus)
+var deadLetters []DeadLetter
+var nextDeadLetterID = 1
+
+
+// statusFor returns (creating) the status entry of a webhook; caller holds deliveryMu
+func statusFor(hookID int) *WebhookStatus {
+	st, ok := webhookStatus[hookID]
+	if !ok {
+		st = &WebhookStatus{}
+		webhookStatus[hookID] = st
+	}
+	return st
+}
+
+
+// enqueueDelivery queues a fresh payload for a webhook, sent as part of the trace traceparent names
+func enqueueDelivery(hookID int, payload WebhookPayload, traceparent string) {
+	deliveryMu.Lock()
+	statusFor(hookID).Pending++
+	deliveryMu.Unlock()
+
+	deliveryQueue <- webhookDelivery{hookID: hookID, payload: payload, traceparent: traceparent}
+}
+
+
+// retryable reports whether a failed delivery may succeed later (network errors, 429, 5xx)
+func retryable(res DeliveryResult) bool {
+	return res.StatusCode == 0 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+
+// backoffFor returns the delay before retry number attempt (1-based)
+func backoffFor(attempt int) time.Duration {
+	delay := *webhookBackoff
+	for i := 1; i < attempt && delay < *webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, *webhookMaxBackoff)
+}
+
+
+// attemptDelivery makes one attempt and decides between done, retry and dead letter
+func attemptDelivery(d webhookDelivery) {
+
+	// webhook removed while the payload was queued
+	webhooksMu.Lock()
+	hook, exists := webhooks[d.hookID]
+	webhooksMu.Unlock()
+	if !exists {
+		deliveryMu.Lock()
+		delete(webhookStatus, d.hookID)
+		deliveryMu.Unlock()
+		return
+	}
+
+	res := deliverWebhook(hook, d.payload, d.traceparent)
+	d.attempt++
+
+	deliveryMu.Lock()
+	defer deliveryMu.Unlock()
+
+	st := statusFor(d.hookID)
+	st.LastAttempt = time.Now()
+	st.LastStatusCode = res.StatusCode
+	st.LastError = res.Error
+
+	// success
+	if res.Error == "" {
+		st.Delivered++
+		st.Pending--
+		return
+	}
+	st.Failed++
+
+	// transient failure with attempts left: try again later
+	if retryable(res) && d.attempt < *webhookMaxAttempts {
+		delay := backoffFor(d.attempt)
+		time.AfterFunc(delay, func() { deliveryQueue <- d })
+		return
+	}
+
+	// out of attempts, or the receiver rejected it for good
+	fmt.Println("webhook", d.hookID, "delivery", d.payload.DeliveryID, "dead-lettered after", d.attempt, "attempts:", res.Error)
+	st.Pending--
+	st.DeadLettered++
+	deadLetters = append(deadLetters, DeadLetter{
+		ID:        nextDeadLetterID,
+		WebhookID: d.hookID,
+		Payload:   d.payload,
+		Attempts:  d.attempt,
+		LastError: res.Error,
+		FailedAt:  time.Now(),
+
+		traceparent: d.traceparent,
+	})
+	nextDeadLetterID++
+	if len(deadLetters) > maxDeadLetters {
+		deadLetters = deadLetters[len(deadLetters)-maxDeadLetters:]
+	}
+}
+
+
+// startDeliveryWorkers runs the delivery queue
+func startDeliveryWorkers() {
+	for i := 0; i < max(*webhookWorkers, 1); i++ {
+		go func() {
+			for d := range deliveryQueue {
+				attemptDelivery(d)
+			}
+		}()
+	}
+}
+
+
+// currentWebhookStatus returns a copy of a webhook's delivery status
+func currentWebhookStatus(hookID int) WebhookStatus {
+	deliveryMu.Lock()
+	defer deliveryMu.Unlock()
+
+	if st, ok := webhookStatus[hookID]; ok {
+		return *st
+	}
+	return WebhookStatus{}
+}
+
+
+// admin: list dead letters, optionally for one webhook (?webhook_id=)
+func deadLettersHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := 0
+	if v := r.URL.Query().Get("webhook_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter = id
+	}
+
+	deliveryMu.Lock()
+	list := make([]DeadLetter, 0, len(deadLetters))
+	for _, dl := range deadLetters {
+		if filter == 0 || dl.WebhookID == filter {
+			list = append(list, dl)
+		}
+	}
+	deliveryMu.Unlock()
+
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// admin: requeue dead letters with a fresh attempt budget (?id= for one, all otherwise)
+func replayDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// optional single id
+	only := 0
+	if v := r.URL.Query().Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		only = id
+	}
+
+	replayed := replayDeadLetters(only)
+	if only != 0 && replayed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"replayed": replayed})
+}
+
+
+// replayDeadLetters requeues the dead letter with id only (every one for 0), returning how many
+func replayDeadLetters(only int) int {
+
+	// take the selected letters off the list
+	deliveryMu.Lock()
+	var replay []DeadLetter
+	kept := deadLetters[:0]
+	for _, dl := range deadLetters {
+		if only == 0 || dl.ID == only {
+			replay = append(replay, dl)
+			st := statusFor(dl.WebhookID)
+			st.DeadLettered--
+			st.Pending++
+		} else {
+			kept = append(kept, dl)
+		}
+	}
+	deadLetters = kept
+	deliveryMu.Unlock()
+
+	// queue outside the lock, workers take deliveryMu too
+	for _, dl := range replay {
+		deliveryQueue <- webhookDelivery{hookID: dl.WebhookID, payload: dl.Payload, traceparent: dl.traceparent}
+	}
+	return len(replay)
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"bytes"         // for request bodies
+	"crypto/hmac"   // for payload signatures
+	"crypto/rand"   // for secrets and delivery ids
+	"crypto/sha256" // for payload signatures
+	"encoding/hex"  // for signature encoding
+	"encoding/json" // for payloads and admin API
+	"net/http"      // for admin handlers & delivery client
+	"net/url"       // for URL validation
+	"slices"        // for event type matching
+	"sort"          // for stable list order
+	"strconv"       // for string -> int conversion
+	"sync"          // for guarding subscriptions
+	"time"          // for timestamps and timeouts
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for passing traces on
+)
+
+// pseudo event sent by the test-fire endpoint
+const EventPing = "ping"
+
+// Webhook is one registered subscription
+type Webhook struct {
+	ID        int            `json:"id"`
+	URL       string         `json:"url"`              // where payloads are POSTed
+	Events    []string       `json:"events"`           // event types, "*" for all
+	Secret    string         `json:"secret,omitempty"` // HMAC key, only shown on create
+	CreatedAt time.Time      `json:"created_at"`
+	Status    *WebhookStatus `json:"status,omitempty"` // delivery health, filled in by the list endpoint
+}
+
+// CreateWebhookRequest represents input body for registering a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"` // optional, generated when empty
+}
+
+// WebhookPayload is the signed JSON body POSTed to subscribers
+type WebhookPayload struct {
+	DeliveryID string          `json:"delivery_id"`
+	Seq        uint64          `json:"seq,omitempty"` // the change's place in GET /changes, none for pings
+	Type       string          `json:"type"`
+	Time       time.Time       `json:"time"`
+	Todo       *model.Todo     `json:"todo,omitempty"`
+	Mention    *MentionPayload `json:"mention,omitempty"` // for "mentioned"
+}
+
+// MentionPayload says who mentioned whom in a "mentioned" delivery
+type MentionPayload struct {
+	To string `json:"to"`
+	By string `json:"by"`
+}
+
+// DeliveryResult reports the outcome of one POST
+type DeliveryResult struct {
+	DeliveryID string `json:"delivery_id"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// registered webhooks, same in-memory pattern as the todos map
+var webhooks = make(map[int]Webhook)
+var webhooksMu sync.Mutex
+var nextWebhookID = 1
+
+// client used for every delivery
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+
+// signPayload computes the X-Todo-Signature value: HMAC-SHA256 over "<timestamp>.<body>"
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+
+// deliverWebhook POSTs one signed payload, naming traceparent's trace, and reports what happened
+func deliverWebhook(hook Webhook, payload WebhookPayload, traceparent string) DeliveryResult {
+
+	result := DeliveryResult{DeliveryID: payload.DeliveryID}
+	body, _ := json.Marshal(payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// receivers verify the signature and reject stale timestamps to stop replays
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-api-webhooks")
+	req.Header.Set("X-Todo-Event", payload.Type)
+	req.Header.Set("X-Todo-Delivery", payload.DeliveryID)
+	req.Header.Set("X-Todo-Timestamp", timestamp)
+	req.Header.Set("X-Todo-Signature", signPayload(hook.Secret, timestamp, body))
+	tracing.Propagate(req.Header, traceparent)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 300 {
+		result.Error = "receiver returned " + resp.Status
+	}
+	return result
+}
+
+
+// webhooksFor returns the subscriptions interested in an event type
+func webhooksFor(eventType string) []Webhook {
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+
+	var hooks []Webhook
+	for _, hook := range webhooks {
+		if slices.Contains(hook.Events, "*") || slices.Contains(hook.Events, eventType) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+
+// startWebhookDispatcher queues every store event for the matching webhooks
+func startWebhookDispatcher() {
+
+	startDeliveryWorkers()
+	events := todoStore.Events().Subscribe()
+
+	go func() {
+		for e := range events {
+			todo := e.Todo
+			for _, hook := range webhooksFor(e.Type) {
+				enqueueDelivery(hook.ID, WebhookPayload{DeliveryID: randomHex(8), Seq: e.Seq, Type: e.Type, Time: e.Time, Todo: &todo}, e.Traceparent)
+			}
+		}
+	}()
+}
+
+// validEventTypes are the values allowed in Webhook.Events
+var validEventTypes = []string{"*", model.EventCreated, model.EventUpdated, model.EventDeleted, eventMentioned}
+
+
+// admin: list webhooks
+func listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+
+	// response will be JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	webhooksMu.Lock()
+	list := make([]Webhook, 0, len(webhooks))
+	for _, hook := range webhooks {
+		// never echo secrets back
+		hook.Secret = ""
+		list = append(list, hook)
+	}
+	webhooksMu.Unlock()
+
+	// attach delivery health
+	for i := range list {
+		st := currentWebhookStatus(list[i].ID)
+		list[i].Status = &st
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	json.NewEncoder(w).Encode(list)
+}
+
+
+// admin: register a webhook
+func createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+
+	// err handling for decoding request body (bad input)
+	var req CreateWebhookRequest
+	if err := decodeBody(r, "webhook", &req); err != nil {
+		writeError(w, r, bodyError(err))
+		return
+	}
+
+	// only absolute http(s) URLs
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// default to every event, reject unknown ones
+	if len(req.Events) == 0 {
+		req.Events = []string{"*"}
+	}
+	for _, e := range req.Events {
+		if !slices.Contains(validEventTypes, e) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	// generate a secret unless the caller brought one
+	if req.Secret == "" {
+		req.Secret = randomHex(32)
+	}
+
+	webhooksMu.Lock()
+	hook := Webhook{ID: nextWebhookID, URL: req.URL, Events: req.Events, Secret: req.Secret, CreatedAt: time.Now()}
+	webhooks[hook.ID] = hook
+	nextWebhookID++
+	webhooksMu.Unlock()
+
+	// the only time the secret is shown
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+
+// webhookFromQuery looks up the webhook named by ?id=, writing 400/404 on failure
+func webhookFromQuery(w http.ResponseWriter, r *http.Request) (Webhook, bool) {
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return Webhook{}, false
+	}
+
+	webhooksMu.Lock()
+	hook, exists := webhooks[id]
+	webhooksMu.Unlock()
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return Webhook{}, false
+	}
+	return hook, true
+}
+
+
+// admin: remove a webhook
+func deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	// allow only DELETE method
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	hook, ok := webhookFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	webhooksMu.Lock()
+	delete(webhooks, hook.ID)
+	webhooksMu.Unlock()
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
+
+
+// admin: send a signed ping to one webhook right now and report the result
+func testWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	hook, ok := webhookFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	// synchronous so the admin sees the receiver's answer
+	result := deliverWebhook(hook, WebhookPayload{DeliveryID: randomHex(8), Type: EventPing, Time: time.Now()}, tracing.FromContext(r.Context()).Traceparent())
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Error != "" {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	json.NewEncoder(w).Encode(result)
+}
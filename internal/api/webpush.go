@@ -0,0 +1,334 @@
+package api
+
+import (
+	"bytes"           // for request bodies
+	"crypto/aes"      // for payload encryption
+	"crypto/cipher"   // for AES-GCM
+	"crypto/ecdh"     // for the per-message key agreement
+	"crypto/ecdsa"    // for VAPID signatures
+	"crypto/elliptic" // for P-256
+	"crypto/hmac"     // for HKDF
+	"crypto/rand"     // for salts and keys
+	"crypto/sha256"   // for HKDF and ES256
+	"encoding/base64" // for keys and JWTs
+	"encoding/binary" // for the record size header
+	"encoding/json"   // for the subscription API
+	"errors"          // for push errors
+	"fmt"             // for printing logs to terminal
+	"net/http"        // for push handlers and delivery
+	"net/url"         // for validating endpoints
+	"slices"          // for deleting expired subscriptions
+	"strconv"         // for TTL and ids
+	"sync"            // for guarding subscriptions
+	"time"            // for JWT expiry
+)
+
+// VAPID identity (RFC 8292); random per process when no key is given, so browsers must resubscribe after a restart
+var vapidPrivateKey = flags.String("vapid-private-key", "", "VAPID private key, base64url raw P-256 scalar as printed by `web-push generate-vapid-keys` (random per process when empty)")
+var vapidSubject = flags.String("vapid-subject", "mailto:admin@localhost", "contact sent to push services in the VAPID token (mailto: or https: URL)")
+
+// how long a push service keeps an undelivered message
+const pushTTL = 24 * time.Hour
+
+// aes128gcm record size (RFC 8188); our payloads fit in one record
+const pushRecordSize = 4096
+
+// effective VAPID key, set by loadVAPIDKey
+var vapidKey *ecdsa.PrivateKey
+
+// PushSubscription is what the browser's PushManager.subscribe() returns, sent to us as JSON
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"` // browser's public key, base64url
+		Auth   string `json:"auth"`   // shared auth secret, base64url
+	} `json:"keys"`
+}
+
+// push subscriptions by endpoint
+var pushSubscriptions = make(map[string]PushSubscription)
+var pushSubscriptionsMu sync.Mutex
+
+// client used for every push service call
+var pushClient = &http.Client{Timeout: 10 * time.Second}
+
+
+// loadVAPIDKey picks the VAPID signing key and registers the push notifier
+func loadVAPIDKey() error {
+
+	if *vapidPrivateKey == "" {
+		vapidKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		raw, err := base64.RawURLEncoding.DecodeString(*vapidPrivateKey)
+		if err != nil {
+			return errors.New("vapid-private-key: " + err.Error())
+		}
+		if vapidKey, err = ecdsa.ParseRawPrivateKey(elliptic.P256(), raw); err != nil {
+			return errors.New("vapid-private-key: " + err.Error())
+		}
+	}
+
+	addNotifier("webpush", sendPushReminders)
+	return nil
+}
+
+
+// vapidPublicKey is the applicationServerKey browsers subscribe with
+func vapidPublicKey() string {
+	raw, _ := vapidKey.PublicKey.Bytes()
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+
+// vapidAuthorization signs a short-lived ES256 JWT for the push service at endpoint
+func vapidAuthorization(endpoint *url.URL) (string, error) {
+
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(), // push services reject more than 24h
+		"sub": *vapidSubject,
+	})
+	signingInput := header + "." + enc.EncodeToString(claims)
+
+	// JWS wants r || s, 32 bytes each, not ASN.1
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, vapidKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return "vapid t=" + signingInput + "." + enc.EncodeToString(sig) + ", k=" + vapidPublicKey(), nil
+}
+
+
+// hkdf is HKDF-SHA-256 for outputs of at most 32 bytes (one expand round)
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+
+// encryptPush encrypts payload for one subscription (RFC 8291, aes128gcm content coding)
+func encryptPush(sub PushSubscription, payload []byte) ([]byte, error) {
+
+	uaPublic, err := base64.RawURLEncoding.DecodeString(sub.Keys.P256DH)
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(sub.Keys.Auth)
+	if err != nil {
+		return nil, err
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// fresh sender key and salt for every message
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+	sharedSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	rand.Read(salt)
+
+	// combine the shared secret with the browser's auth secret, then derive key and nonce
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+
+	// header: salt, record size, sender key; then the single (last, 0x02) record
+	body := append([]byte{}, salt...)
+	body = binary.BigEndian.AppendUint32(body, pushRecordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+	plaintext := append(append([]byte{}, payload...), 2)
+	return gcm.Seal(body, nonce, plaintext, nil), nil
+}
+
+
+// sendPush delivers one payload; gone=true means the subscription expired and should be dropped
+func sendPush(sub PushSubscription, payload []byte) (gone bool, err error) {
+
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return true, err
+	}
+	body, err := encryptPush(sub, payload)
+	if err != nil {
+		return true, err
+	}
+	authorization, err := vapidAuthorization(endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	// 404 / 410 = the user unsubscribed or the subscription expired
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return true, nil
+	case resp.StatusCode >= 300:
+		return false, errors.New("push service returned " + resp.Status)
+	}
+	return false, nil
+}
+
+
+// sendPushReminders pushes a reminder to every subscribed browser when a todo becomes overdue
+func sendPushReminders(n Notification) error {
+
+	if n.Kind != notifyOverdue {
+		return nil
+	}
+
+	// the service worker shows this with showNotification(title, {body, tag, data})
+	payload, _ := json.Marshal(map[string]any{
+		"title": "Overdue: " + n.Todo.Title,
+		"body":  "#" + strconv.Itoa(n.Todo.ID) + " was due " + n.Todo.Due.Format("Mon Jan 2 15:04 MST"),
+		"tag":   "todo-" + strconv.Itoa(n.Todo.ID),
+		"todo":  n.Todo,
+	})
+
+	pushSubscriptionsMu.Lock()
+	subs := make([]PushSubscription, 0, len(pushSubscriptions))
+	for _, sub := range pushSubscriptions {
+		subs = append(subs, sub)
+	}
+	pushSubscriptionsMu.Unlock()
+
+	var errs []error
+	var expired []string
+	for _, sub := range subs {
+		gone, err := sendPush(sub, payload)
+		if gone {
+			expired = append(expired, sub.Endpoint)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(expired) > 0 {
+		pushSubscriptionsMu.Lock()
+		for endpoint := range pushSubscriptions {
+			if slices.Contains(expired, endpoint) {
+				delete(pushSubscriptions, endpoint)
+			}
+		}
+		pushSubscriptionsMu.Unlock()
+		fmt.Println("dropped", len(expired), "expired push subscriptions")
+	}
+	return errors.Join(errs...)
+}
+
+
+// the applicationServerKey for PushManager.subscribe()
+func vapidPublicKeyHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not GET, return 405
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// since we returning JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"public_key": vapidPublicKey()})
+}
+
+
+// store a browser's push subscription (the JSON of PushSubscription.toJSON())
+func pushSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// err handling for decoding request body (bad input)
+	var sub PushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// push services are https only; checking the keys now beats failing on the first reminder
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if _, err := encryptPush(sub, nil); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// subscribing again replaces the keys
+	pushSubscriptionsMu.Lock()
+	pushSubscriptions[sub.Endpoint] = sub
+	pushSubscriptionsMu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+
+// forget a browser's push subscription ({"endpoint": ...})
+func pushUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+
+	// if request is not POST, return 405
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var sub PushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	pushSubscriptionsMu.Lock()
+	_, exists := pushSubscriptions[sub.Endpoint]
+	delete(pushSubscriptions, sub.Endpoint)
+	pushSubscriptionsMu.Unlock()
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// 204 = success with no response body
+	w.WriteHeader(http.StatusNoContent)
+}
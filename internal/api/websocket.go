@@ -0,0 +1,297 @@
+package api
+
+import (
+	"bufio"           // for buffered frame reads
+	"crypto/sha1"     // for the handshake accept key
+	"encoding/base64" // for the handshake accept key
+	"encoding/binary" // for frame lengths
+	"encoding/json"   // for event payloads
+	"errors"          // for protocol errors
+	"io"              // for reading frames
+	"net"             // for the hijacked connection
+	"net/http"        // for the upgrade request
+	"strconv"         // for parsing since
+	"strings"         // for header parsing
+	"sync"            // for serializing frame writes
+	"time"            // for pings and deadlines
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// RFC 6455 constants
+const (
+	wsGUID        = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsOpText      = 0x1
+	wsOpClose     = 0x8
+	wsOpPing      = 0x9
+	wsOpPong      = 0xA
+	wsMaxFrame    = 64 << 10 // clients only send control frames, so keep this small
+	wsPingEvery   = 30 * time.Second
+	wsWriteTimout = 10 * time.Second
+)
+
+// wsConn is a server side websocket connection
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	wmu  sync.Mutex // one frame writer at a time
+}
+
+
+// headerHasToken checks comma separated header values like "Connection: keep-alive, Upgrade"
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+
+// upgradeWebSocket performs the opening handshake and takes over the connection.
+// subprotocol is echoed back when the client offers it ("" for none).
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, subprotocol string) (*wsConn, error) {
+
+	// must be a GET with the upgrade headers
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+
+	// we only speak version 13
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		w.WriteHeader(http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+
+	// take the raw connection from net/http (works through wrapping middleware)
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, err
+	}
+
+	// accept = base64(sha1(key + GUID))
+	sum := sha1.Sum([]byte(key + wsGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	if subprotocol != "" && headerHasToken(r.Header, "Sec-WebSocket-Protocol", subprotocol) {
+		rw.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	rw.WriteString("Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// handshake done, net/http no longer applies deadlines for us
+	conn.SetDeadline(time.Time{})
+
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+
+// writeFrame sends one unmasked, unfragmented frame
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	// FIN bit + opcode
+	header := []byte{0x80 | opcode}
+
+	// 7 bit, 16 bit or 64 bit payload length
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimout))
+	c.rw.Write(header)
+	c.rw.Write(payload)
+	return c.rw.Flush()
+}
+
+
+// readFrame reads one (masked) client frame
+func (c *wsConn) readFrame() (byte, []byte, error) {
+
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+
+	// our messages are small, so we don't reassemble fragments
+	if head[0]&0x80 == 0 {
+		return 0, nil, errors.New("fragmented frames are not supported")
+	}
+
+	// clients must mask every frame
+	if head[1]&0x80 == 0 {
+		return 0, nil, errors.New("unmasked client frame")
+	}
+
+	// extended payload length
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxFrame {
+		return 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+
+	// unmask in place
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return opcode, payload, nil
+}
+
+
+// readMessage returns the next data message, answering pings on the way;
+// io.EOF means the client closed the connection
+func (c *wsConn) readMessage() ([]byte, error) {
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			c.writeFrame(wsOpPong, payload)
+		case wsOpPong:
+			// reply to our keepalive, nothing to do
+		case wsOpClose:
+			// echo the close frame, then we're done
+			c.writeFrame(wsOpClose, payload)
+			return nil, io.EOF
+		default:
+			return payload, nil
+		}
+	}
+}
+
+
+// readLoop discards client messages and returns when the client closes or goes away
+func (c *wsConn) readLoop() {
+	for {
+		if _, err := c.readMessage(); err != nil {
+			return
+		}
+	}
+}
+
+
+// push change events to a websocket client as JSON text frames. ?since=<seq> resumes after a
+// reconnect: the logged changes after seq come first, then the live ones
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+
+	// checked before the upgrade, so a bad since is a plain HTTP error
+	var since uint64
+	resume := r.URL.Query().Has("since")
+	if resume {
+		var err error
+		if since, err = strconv.ParseUint(r.URL.Query().Get("since"), 10, 64); err != nil {
+			writeError(w, r, model.Invalid("since", "must be a sequence number"))
+			return
+		}
+	}
+
+	// start listening before the client can miss anything
+	events := todoStore.Events().Subscribe()
+	defer todoStore.Events().Unsubscribe(events)
+
+	var backlog []model.Event
+	if resume {
+		var ok bool
+		if backlog, _, ok = todoStore.Events().Since(since); !ok {
+			// fell out of the log: reload GET /todos and reconnect with its last_seq
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+	}
+
+	conn, err := upgradeWebSocket(w, r, "")
+	if err != nil {
+		return
+	}
+	defer conn.conn.Close()
+
+	// the backlog and the subscription overlap by whatever was published in between
+	for _, e := range backlog {
+		payload, _ := json.Marshal(e)
+		if conn.writeFrame(wsOpText, payload) != nil {
+			return
+		}
+		since = e.Seq
+	}
+
+	// client frames are read in the background; closed means the client is gone
+	closed := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(closed)
+	}()
+
+	ping := time.NewTicker(wsPingEvery)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			if resume && e.Seq <= since {
+				continue
+			}
+			payload, _ := json.Marshal(e)
+			if conn.writeFrame(wsOpText, payload) != nil {
+				return
+			}
+		case <-ping.C:
+			// keep proxies from dropping an idle connection
+			if conn.writeFrame(wsOpPing, nil) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package api_test
+
+import (
+	"bufio"           // for reading the handshake and frames
+	"encoding/binary" // for frame lengths
+	"encoding/json"   // for the events
+	"io"              // for reading frames
+	"net"             // for the raw connection
+	"net/http"        // for the handshake
+	"net/url"         // for the server's address
+	"testing"         // for the tests
+	"time"            // for read deadlines
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// WebSocket tests: the /ws handshake, change events as text frames, resuming with ?since, and
+// control frames, over a raw connection speaking RFC 6455
+
+// the example key of RFC 6455 section 1.3 and the accept value it gets
+const (
+	wsTestKey    = "dGhlIHNhbXBsZSBub25jZQ=="
+	wsTestAccept = "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+)
+
+// wsTestConn is a client connection after the handshake
+type wsTestConn struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+
+// dialWS sends a handshake for path with header and returns the answer, and the connection
+// when it's a 101
+func dialWS(t *testing.T, s *apitest.Server, method, path string, header http.Header) (*http.Response, *wsTestConn) {
+
+	t.Helper()
+	u, _ := url.Parse(s.URL)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(method, s.URL+path, nil)
+	req.Header = header
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+	return resp, &wsTestConn{t: t, conn: conn, r: r}
+}
+
+
+// wsHeader is a valid handshake's header
+func wsHeader() http.Header {
+	return http.Header{
+		"Connection":            {"keep-alive, Upgrade"},
+		"Upgrade":               {"websocket"},
+		"Sec-Websocket-Key":     {wsTestKey},
+		"Sec-Websocket-Version": {"13"},
+	}
+}
+
+
+// send writes one masked frame
+func (c *wsTestConn) send(opcode byte, payload []byte) {
+	c.t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+
+// next reads one server frame, which must be unmasked and whole
+func (c *wsTestConn) next() (byte, []byte) {
+
+	c.t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		c.t.Fatal("reading a frame:", err)
+	}
+	if head[0]&0x80 == 0 || head[1]&0x80 != 0 {
+		c.t.Fatalf("frame header %x: want FIN set and no mask", head)
+	}
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.r, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		c.t.Fatal("reading a frame:", err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+
+// event reads the next text frame as a change event
+func (c *wsTestConn) event() (seq uint64, kind, title string) {
+
+	c.t.Helper()
+	opcode, payload := c.next()
+	if opcode != 1 {
+		c.t.Fatalf("opcode %d, want a text frame", opcode)
+	}
+	var e struct {
+		Seq  uint64 `json:"seq"`
+		Type string `json:"type"`
+		Todo struct {
+			Title string `json:"title"`
+		} `json:"todo"`
+	}
+	if err := json.Unmarshal(payload, &e); err != nil {
+		c.t.Fatalf("event %s: %v", payload, err)
+	}
+	return e.Seq, e.Type, e.Todo.Title
+}
+
+
+// TestWebSocketHandshake checks what's upgraded and what's answered with a plain HTTP error
+func TestWebSocketHandshake(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		change func(http.Header)
+		status int
+	}{
+		{"valid", http.MethodGet, "/ws", func(http.Header) {}, http.StatusSwitchingProtocols},
+		{"header case and lists", http.MethodGet, "/ws", func(h http.Header) { h.Set("Upgrade", "WebSocket"); h.Set("Connection", "upgrade") }, http.StatusSwitchingProtocols},
+		{"no key", http.MethodGet, "/ws", func(h http.Header) { h.Del("Sec-Websocket-Key") }, http.StatusBadRequest},
+		{"no upgrade", http.MethodGet, "/ws", func(h http.Header) { h.Del("Upgrade") }, http.StatusBadRequest},
+		{"not an upgrade connection", http.MethodGet, "/ws", func(h http.Header) { h.Set("Connection", "keep-alive") }, http.StatusBadRequest},
+		{"POST", http.MethodPost, "/ws", func(http.Header) {}, http.StatusBadRequest},
+		{"old version", http.MethodGet, "/ws", func(h http.Header) { h.Set("Sec-Websocket-Version", "8") }, http.StatusUpgradeRequired},
+		{"bad since", http.MethodGet, "/ws?since=x", func(http.Header) {}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.New(t)
+			h := wsHeader()
+			tt.change(h)
+			resp, _ := dialWS(t, s, tt.method, tt.path, h)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			switch tt.status {
+			case http.StatusSwitchingProtocols:
+				if got := resp.Header.Get("Sec-Websocket-Accept"); got != wsTestAccept {
+					t.Errorf("Sec-WebSocket-Accept %q, want %q", got, wsTestAccept)
+				}
+			case http.StatusUpgradeRequired:
+				if got := resp.Header.Get("Sec-Websocket-Version"); got != "13" {
+					t.Errorf("Sec-WebSocket-Version %q, want 13", got)
+				}
+			}
+		})
+	}
+}
+
+
+// TestWebSocketEvents checks changes made after connecting arrive in order
+func TestWebSocketEvents(t *testing.T) {
+
+	s := apitest.New(t)
+	_, ws := dialWS(t, s, http.MethodGet, "/ws", wsHeader())
+
+	s.Post("/todos/create", map[string]string{"title": "buy milk"}).ExpectStatus(http.StatusOK)
+	s.Delete("/todos/delete?id=1").ExpectStatus(http.StatusNoContent)
+
+	tests := []struct {
+		kind, title string
+	}{
+		{"created", "buy milk"},
+		{"deleted", "buy milk"},
+	}
+	var last uint64
+	for _, tt := range tests {
+		seq, kind, title := ws.event()
+		if kind != tt.kind || title != tt.title || seq <= last {
+			t.Errorf("event %d %s %q, want %s %q after %d", seq, kind, title, tt.kind, tt.title, last)
+		}
+		last = seq
+	}
+}
+
+
+// TestWebSocketResume checks ?since replays the logged changes after it, then goes on live
+func TestWebSocketResume(t *testing.T) {
+
+	s := apitest.New(t)
+	s.Seed(apitest.NewSeed().Todo("one").Todo("two").Todo("three"))
+
+	_, ws := dialWS(t, s, http.MethodGet, "/ws?since=1", wsHeader())
+	for _, want := range []string{"two", "three"} {
+		if _, kind, title := ws.event(); kind != "created" || title != want {
+			t.Errorf("replayed %s %q, want created %q", kind, title, want)
+		}
+	}
+	s.Post("/todos/create", map[string]string{"title": "four"}).ExpectStatus(http.StatusOK)
+	if _, _, title := ws.event(); title != "four" {
+		t.Errorf("live event %q, want four", title)
+	}
+}
+
+
+// TestWebSocketControlFrames checks pings are answered and a close is echoed
+func TestWebSocketControlFrames(t *testing.T) {
+
+	s := apitest.New(t)
+	_, ws := dialWS(t, s, http.MethodGet, "/ws", wsHeader())
+
+	ws.send(0x9, []byte("hi"))
+	if opcode, payload := ws.next(); opcode != 0xa || string(payload) != "hi" {
+		t.Errorf("answer to a ping: opcode %d %q, want a pong with hi", opcode, payload)
+	}
+
+	ws.send(0x8, []byte{0x03, 0xe8})
+	if opcode, payload := ws.next(); opcode != 0x8 || string(payload) != "\x03\xe8" {
+		t.Errorf("answer to a close: opcode %d %x, want the close echoed", opcode, payload)
+	}
+	if _, err := ws.r.ReadByte(); err != io.EOF {
+		t.Errorf("connection still open after the close (%v)", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package blob keeps attachment bytes in memory, in a directory or in an S3-compatible bucket.
+package blob
+
+import (
+	"bytes"         // for in-memory readers
+	"context"       // for cancelling requests
+	"errors"        // for missing blobs
+	"fmt"           // for config errors
+	"io"            // for readers
+	"net/url"       // for the store's address
+	"os"            // for the disk store and credentials from the environment
+	"path"          // for keys
+	"path/filepath" // for the disk store's files
+	"strings"       // for keys
+	"sync"          // for guarding the memory store
+)
+
+// ErrNotFound is returned for a key that isn't stored
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs by key. keys are slash separated, like todos/3/9f86d081
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error) // ErrNotFound if there's none
+	Delete(ctx context.Context, key string) error               // deleting a missing key is no error
+}
+
+// Credentials sign requests to S3
+type Credentials struct {
+	AccessKey, SecretKey string
+}
+
+
+// Open returns the store addr names: "memory", "file:///var/lib/todo/blobs" or
+// "s3://bucket/prefix?endpoint=https://s3.eu-west-1.amazonaws.com&region=eu-west-1". S3
+// credentials come from creds, or else the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY variables
+func Open(addr string, creds Credentials) (Store, error) {
+
+	if addr == "" || addr == "memory" {
+		return NewMemory(), nil
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		return NewDisk(u.Path)
+	case "s3":
+		q := u.Query()
+		if creds.AccessKey == "" {
+			creds = Credentials{AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"), SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY")}
+		}
+		return NewS3(S3Config{
+			Endpoint: q.Get("endpoint"),
+			Region:   q.Get("region"),
+			Bucket:   u.Host,
+			Prefix:   strings.Trim(u.Path, "/"),
+			Creds:    creds,
+		})
+	}
+	return nil, fmt.Errorf("%q: not memory, file:// or s3://", addr)
+}
+
+
+// checkKey refuses keys that could leave the store's directory or prefix
+func checkKey(key string) error {
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "/") || strings.HasPrefix(key, "..") {
+		return fmt.Errorf("bad blob key %q", key)
+	}
+	return nil
+}
+
+// Memory keeps blobs in memory, gone with the process like the todos
+type Memory struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+
+// NewMemory returns an empty memory store
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+
+// Put stores a copy of data
+func (m *Memory) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = bytes.Clone(data)
+	return nil
+}
+
+
+// Get returns the blob's bytes
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+
+// Delete drops the blob
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
+
+// Disk keeps each blob in a file below a directory
+type Disk struct {
+	dir string
+}
+
+
+// NewDisk returns a store in dir, creating it if needed
+func NewDisk(dir string) (*Disk, error) {
+	if dir == "" {
+		return nil, errors.New("file:// needs a directory, e.g. file:///var/lib/todo/blobs")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir}, nil
+}
+
+
+// Put writes data to a temporary file and renames it into place, so a reader never sees half a blob
+func (d *Disk) Put(ctx context.Context, key string, data []byte, contentType string) error {
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	name := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails once renamed, which is fine
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+
+// Get opens the blob's file
+func (d *Disk) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+
+// Delete removes the blob's file
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
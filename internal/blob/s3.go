@@ -0,0 +1,178 @@
+package blob
+
+import (
+	"bytes"         // for request bodies
+	"context"       // for cancelling requests
+	"crypto/hmac"   // for request signatures
+	"crypto/sha256" // for payload hashes and signatures
+	"encoding/hex"  // for hashes
+	"errors"        // for config errors
+	"fmt"           // for S3 errors
+	"io"            // for response bodies
+	"net/http"      // for talking to S3
+	"net/url"       // for the endpoint
+	"strings"       // for object paths
+	"time"          // for signature dates and timeouts
+)
+
+// an S3-compatible bucket (AWS, MinIO, R2, ...), addressed path style, endpoint/bucket/key, which
+// every implementation takes. requests are signed with AWS Signature Version 4
+
+// S3Config says where the bucket is and how to sign for it
+type S3Config struct {
+	Endpoint string // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region   string // us-east-1 when empty, which is what most non-AWS servers expect
+	Bucket   string
+	Prefix   string // put before every key, without slashes at the ends
+	Creds    Credentials
+}
+
+// S3 is a store in an S3 bucket
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// hash of an empty payload, for GET and DELETE
+var emptyPayloadHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+
+// NewS3 checks cfg and returns a store for its bucket
+func NewS3(cfg S3Config) (*S3, error) {
+
+	switch {
+	case cfg.Bucket == "":
+		return nil, errors.New("s3:// needs a bucket, e.g. s3://todo-attachments")
+	case cfg.Endpoint == "":
+		return nil, errors.New("s3:// needs ?endpoint=, e.g. https://s3.eu-west-1.amazonaws.com")
+	case cfg.Creds.AccessKey == "" || cfg.Creds.SecretKey == "":
+		return nil, errors.New("s3:// needs an access key and secret key")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q: not an http(s) URL", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3{cfg: cfg, endpoint: u, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+
+// hmacSHA256 is one step of the signing key derivation
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+
+// do signs and sends one request for key, with body (nil for none)
+func (s *S3) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	object := key
+	if s.cfg.Prefix != "" {
+		object = s.cfg.Prefix + "/" + key
+	}
+	segments := strings.Split(s.cfg.Bucket+"/"+object, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	u := *s.endpoint
+	u.RawPath = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(segments, "/")
+	u.Path, _ = url.PathUnescape(u.RawPath)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// the canonical request, the string to sign over it, and the key for the day and region
+	now := time.Now().UTC()
+	date, stamp := now.Format("20060102"), now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", stamp)
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		"",
+		"host:" + u.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + stamp + "\n",
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	signingKey := hmacSHA256(hmacSHA256(hmacSHA256(hmacSHA256([]byte("AWS4"+s.cfg.Creds.SecretKey), date), s.cfg.Region), "s3"), "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.cfg.Creds.AccessKey, scope, signed, signature))
+
+	return s.client.Do(req)
+}
+
+
+// s3Error reads a failed response into an error, with the start of S3's XML explanation
+func s3Error(method, key string, resp *http.Response) error {
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body.Close()
+	return fmt.Errorf("s3 %s %s: %s: %s", method, key, resp.Status, bytes.TrimSpace(detail))
+}
+
+
+// Put uploads data as the object
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if data == nil {
+		data = []byte{}
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("PUT", key, resp)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+
+// Get streams the object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	return nil, s3Error("GET", key, resp)
+}
+
+
+// Delete removes the object; S3 answers 204 whether it existed or not
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error("DELETE", key, resp)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"bytes"         // for request bodies
+	"encoding/json" // for API payloads and the config file
+	"errors"        // for client errors
+	"flag"          // for subcommand flags
+	"fmt"           // for printing results
+	"net/http"      // for talking to the server
+	"os"            // for config lookup and exit codes
+	"path/filepath" // for the config path
+	"sort"          // for stable list order
+	"strconv"       // for ids
+	"strings"       // for joining titles
+	"time"          // for the client timeout
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/api"   // for request bodies
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// CLIConfig is the client config file (~/.config/todo/config.json)
+type CLIConfig struct {
+	Server string `json:"server"` // base URL of a running server
+	Token  string `json:"token"`  // sent as a bearer token when set
+}
+
+// cliCommands are the client subcommands: `todo <command> [flags] [args]`
+var cliCommands = map[string]func(c *apiClient, args []string) error{
+	"add":  cliAdd,
+	"list": cliList,
+	"done": cliDone,
+	"rm":   cliRemove,
+	"tui":  cliTUI,
+
+	// performance tooling
+	"loadgen": cliLoadgen,
+}
+
+// apiClient calls the HTTP API of a running server
+type apiClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+
+// loadCLIConfig reads the config file, then lets TODO_SERVER / TODO_TOKEN override it
+func loadCLIConfig() (CLIConfig, error) {
+
+	cfg := CLIConfig{Server: "http://localhost:8080"}
+
+	path := os.Getenv("TODO_CONFIG")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "todo", "config.json")
+		}
+	}
+
+	// a missing file just means defaults
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return cfg, err
+	}
+
+	if v := os.Getenv("TODO_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("TODO_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	return cfg, nil
+}
+
+
+// IsCommand reports whether name is a client subcommand rather than server flags
+func IsCommand(name string) bool {
+	_, ok := cliCommands[name]
+	return ok
+}
+
+
+// Run runs a client subcommand and returns the process exit code
+func Run(name string, args []string) int {
+
+	cfg, err := loadCLIConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todo: config:", err)
+		return 1
+	}
+
+	cli := &apiClient{
+		server: strings.TrimRight(cfg.Server, "/"),
+		token:  cfg.Token,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := cliCommands[name](cli, args); err != nil {
+		fmt.Fprintln(os.Stderr, "todo "+name+":", err)
+		return 1
+	}
+	return 0
+}
+
+
+// do sends a request and decodes a JSON answer into out (when not nil)
+func (c *apiClient) do(method, path string, body any, out any) error {
+
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.New("no such todo")
+	case resp.StatusCode >= 300:
+		return errors.New("server returned " + resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+
+// todo add <title...>
+func cliAdd(c *apiClient, args []string) error {
+
+	if len(args) == 0 {
+		return errors.New("usage: todo add <title>")
+	}
+
+	var todo model.Todo
+	if err := c.do(http.MethodPost, "/todos/create", api.CreateTodoRequest{Title: strings.Join(args, " ")}, &todo); err != nil {
+		return err
+	}
+	fmt.Println("added", todo.ID)
+	return nil
+}
+
+
+// todo list [--done | --pending]
+func cliList(c *apiClient, args []string) error {
+
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	onlyDone := fs.Bool("done", false, "only completed todos")
+	onlyPending := fs.Bool("pending", false, "only open todos")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// server answers with an id -> todo object
+	var byID map[string]model.Todo
+	if err := c.do(http.MethodGet, "/todos", nil, &byID); err != nil {
+		return err
+	}
+
+	list := make([]model.Todo, 0, len(byID))
+	for _, t := range byID {
+		if (*onlyDone && !t.Done) || (*onlyPending && t.Done) {
+			continue
+		}
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	for _, t := range list {
+		mark := " "
+		if t.Done {
+			mark = "x"
+		}
+		fmt.Printf("[%s] %4d  %s\n", mark, t.ID, t.Title)
+	}
+	return nil
+}
+
+
+// cliID parses the single id argument of done/rm
+func cliID(usage string, args []string) (int, error) {
+
+	if len(args) != 1 {
+		return 0, errors.New("usage: " + usage)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, errors.New("id must be a number")
+	}
+	return id, nil
+}
+
+
+// todo done <id>
+func cliDone(c *apiClient, args []string) error {
+
+	id, err := cliID("todo done <id>", args)
+	if err != nil {
+		return err
+	}
+	var todo model.Todo
+	if err := c.do(http.MethodPut, "/todos/update?id="+strconv.Itoa(id), nil, &todo); err != nil {
+		return err
+	}
+	fmt.Println("completed", todo.ID, todo.Title)
+	return nil
+}
+
+
+// todo rm <id>
+func cliRemove(c *apiClient, args []string) error {
+
+	id, err := cliID("todo rm <id>", args)
+	if err != nil {
+		return err
+	}
+	if err := c.do(http.MethodDelete, "/todos/delete?id="+strconv.Itoa(id), nil, nil); err != nil {
+		return err
+	}
+	fmt.Println("deleted", id)
+	return nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"net/http" // for the client
+	"strings"  // for typed input
+	"testing"  // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/apitest" // for the API under test
+)
+
+// client command tests, against the whole API on apitest: what `todo add` and the TUI send is a
+// body the server takes
+
+
+// TestAdd checks `todo add` and adding in the TUI both create the todo
+func TestAdd(t *testing.T) {
+
+	s := apitest.New(t)
+	c := &apiClient{server: s.URL, http: http.DefaultClient}
+
+	if err := cliAdd(c, []string{"buy", "milk"}); err != nil {
+		t.Fatalf("todo add: %v", err)
+	}
+
+	st := &tuiState{c: c, mode: tuiModeAdd, input: []rune(" file taxes ")}
+	st.submit()
+	if strings.HasPrefix(st.status, "add failed") {
+		t.Fatalf("TUI add: %s", st.status)
+	}
+
+	s.Get("/todos").ExpectJSON(`{
+		"1":{"id":1,"title":"buy milk","done":false,"rev":1},
+		"2":{"id":2,"title":"file taxes","done":false,"rev":1}}`)
+	if len(st.todos) != 2 {
+		t.Errorf("the TUI shows %d todos after adding, want 2", len(st.todos))
+	}
+}
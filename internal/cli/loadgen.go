@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/json" // for created todos
+	"errors"        // for flag errors
+	"flag"          // for subcommand flags
+	"fmt"           // for the report
+	"io"            // for draining bodies
+	"math/rand/v2"  // for picking operations and ids
+	"net/http"      // for driving the server
+	"slices"        // for percentiles
+	"strconv"       // for parsing the mix
+	"strings"       // for building bodies and the mix
+	"sync"          // for workers
+	"sync/atomic"   // for the highest id seen
+	"time"          // for durations and pacing
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// loadgen operations, in report order
+var loadgenOps = []string{"list", "filter", "create", "update", "delete"}
+
+// loadgenStats collects one worker's latencies per operation
+type loadgenStats struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int // transport errors and 5xx
+	misses    map[string]int // 404 on update / delete of an id another worker deleted
+}
+
+
+// parseLoadMix reads "list=20,filter=40,..." into weights per operation
+func parseLoadMix(s string) (map[string]int, error) {
+
+	mix := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		op, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(weight)
+		if !ok || err != nil || n < 0 || !slices.Contains(loadgenOps, op) {
+			return nil, errors.New("bad -mix entry " + strconv.Quote(part) + ", want op=weight with op one of " + strings.Join(loadgenOps, ", "))
+		}
+		mix[op] = n
+	}
+	return mix, nil
+}
+
+
+// percentile of sorted latencies (nearest rank)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p+0.5) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+
+// loadgen: drive a mixed workload against the server and report latency percentiles
+func cliLoadgen(c *apiClient, args []string) error {
+
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	concurrency := fs.Int("c", 16, "concurrent workers")
+	duration := fs.Duration("d", 10*time.Second, "how long to run")
+	rate := fs.Int("rate", 0, "total requests per second across all workers (0 = as fast as possible)")
+	seed := fs.Int("seed", 1000, "todos to create before measuring")
+	mixFlag := fs.String("mix", "list=20,filter=40,create=20,update=15,delete=5", "operation weights")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	mix, err := parseLoadMix(*mixFlag)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, w := range mix {
+		total += w
+	}
+	if total == 0 || *concurrency < 1 {
+		return errors.New("need at least one worker and one operation with weight > 0")
+	}
+
+	// one keep-alive connection per worker, or we'd measure TCP handshakes
+	client := &http.Client{
+		Timeout:   c.http.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency, MaxConnsPerHost: *concurrency},
+	}
+
+	// ids are sequential, so anything up to the highest id we created may exist
+	var maxID atomic.Int64
+
+	// send one request and return its status (0 on transport errors); created todos raise maxID
+	call := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, c.server+path, strings.NewReader(body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+
+		var todo model.Todo
+		if resp.StatusCode < 300 && method == http.MethodPost && json.NewDecoder(resp.Body).Decode(&todo) == nil {
+			for {
+				cur := maxID.Load()
+				if int64(todo.ID) <= cur || maxID.CompareAndSwap(cur, int64(todo.ID)) {
+					break
+				}
+			}
+		}
+		io.Copy(io.Discard, resp.Body) // keeps the connection reusable
+		return resp.StatusCode
+	}
+	create := func() int {
+		return call(http.MethodPost, "/todos/create", `{"title":"loadgen"}`)
+	}
+
+	fmt.Printf("seeding %d todos on %s\n", *seed, c.server)
+	for range *seed {
+		if status := create(); status == 0 || status >= 300 {
+			return fmt.Errorf("seeding failed with status %d", status)
+		}
+	}
+
+	// a shared ticker paces all workers when -rate is set
+	var tick <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	fmt.Printf("running %s with %d workers, mix %s\n", *duration, *concurrency, *mixFlag)
+	deadline := time.Now().Add(*duration)
+	stats := make([]loadgenStats, *concurrency)
+	var wg sync.WaitGroup
+	for w := range stats {
+		st := &stats[w]
+		st.latencies, st.errors, st.misses = map[string][]time.Duration{}, map[string]int{}, map[string]int{}
+		wg.Go(func() {
+			for time.Now().Before(deadline) {
+				if tick != nil {
+					<-tick
+				}
+
+				// weighted pick
+				op, n := "", rand.IntN(total)
+				for _, name := range loadgenOps {
+					if n -= mix[name]; n < 0 {
+						op = name
+						break
+					}
+				}
+				id := strconv.FormatInt(1+rand.Int64N(max(1, maxID.Load())), 10)
+
+				start := time.Now()
+				var status int
+				switch op {
+				case "list":
+					status = call(http.MethodGet, "/todos", "")
+				case "filter":
+					status = call(http.MethodGet, "/todos?done=false", "")
+				case "create":
+					status = create()
+				case "update":
+					status = call(http.MethodPut, "/todos/update?id="+id+"&done="+strconv.FormatBool(rand.IntN(2) == 0), "")
+				case "delete":
+					status = call(http.MethodDelete, "/todos/delete?id="+id, "")
+				}
+				st.latencies[op] = append(st.latencies[op], time.Since(start))
+
+				switch {
+				case status == http.StatusNotFound:
+					st.misses[op]++
+				case status == 0 || status >= 500:
+					st.errors[op]++
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	// merge workers and report
+	fmt.Printf("\n%-8s %9s %8s %7s %7s %10s %10s %10s %10s\n", "op", "requests", "req/s", "errors", "404s", "p50", "p90", "p99", "max")
+	var all []time.Duration
+	for _, op := range loadgenOps {
+		var lat []time.Duration
+		errs, misses := 0, 0
+		for _, st := range stats {
+			lat = append(lat, st.latencies[op]...)
+			errs += st.errors[op]
+			misses += st.misses[op]
+		}
+		if len(lat) == 0 {
+			continue
+		}
+		all = append(all, lat...)
+		slices.Sort(lat)
+		fmt.Printf("%-8s %9d %8.0f %7d %7d %10s %10s %10s %10s\n", op, len(lat), float64(len(lat))/duration.Seconds(), errs, misses,
+			percentile(lat, 0.50).Round(time.Microsecond), percentile(lat, 0.90).Round(time.Microsecond),
+			percentile(lat, 0.99).Round(time.Microsecond), lat[len(lat)-1].Round(time.Microsecond))
+	}
+	slices.Sort(all)
+	if len(all) > 0 {
+		fmt.Printf("%-8s %9d %8.0f %7s %7s %10s %10s %10s %10s\n", "total", len(all), float64(len(all))/duration.Seconds(), "", "",
+			percentile(all, 0.50).Round(time.Microsecond), percentile(all, 0.90).Round(time.Microsecond),
+			percentile(all, 0.99).Round(time.Microsecond), all[len(all)-1].Round(time.Microsecond))
+	}
+	return nil
+}
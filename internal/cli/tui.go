@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"errors"       // for terminal errors
+	"fmt"          // for drawing
+	"net/http"     // for API calls
+	"os"           // for the terminal
+	"os/exec"      // for stty raw mode
+	"sort"         // for stable list order
+	"strconv"      // for ids
+	"strings"      // for building the screen and filtering
+	"time"         // for periodic refresh
+	"unicode/utf8" // for splitting typed input
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/api"   // for request bodies
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// which todos the TUI shows
+const (
+	tuiShowAll     = "all"
+	tuiShowOpen    = "open"
+	tuiShowDone    = "done"
+	tuiRefreshRate = 2 * time.Second
+)
+
+// what typed keys mean right now
+const (
+	tuiModeNormal = iota
+	tuiModeAdd    // typing a new title
+	tuiModeFilter // typing a title filter
+)
+
+// tuiState is everything on screen
+type tuiState struct {
+	c      *apiClient
+	todos  []model.Todo // everything the server has, ordered by id
+	cursor int          // index into visible()
+	show   string       // all / open / done
+	query  string       // case-insensitive title filter
+	mode   int
+	input  []rune // line being typed in add / filter mode
+	status string // last message or error
+}
+
+
+// stty runs stty against the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+
+// todo tui: interactive list backed by the HTTP API
+func cliTUI(c *apiClient, args []string) error {
+
+	if len(args) != 0 {
+		return errors.New("usage: todo tui")
+	}
+
+	// raw mode so we get keys one by one without echo, restored on the way out
+	saved, err := stty("-g")
+	if err != nil {
+		return errors.New("stdin is not a terminal")
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(saved)
+
+	// alternate screen, hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	// keys arrive from a reader goroutine so refreshes can interleave
+	keys := make(chan string)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, key := range splitKeys(string(buf[:n])) {
+				keys <- key
+			}
+		}
+	}()
+
+	st := &tuiState{c: c, show: tuiShowAll}
+	st.refresh()
+	st.draw()
+
+	ticker := time.NewTicker(tuiRefreshRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || !st.handleKey(key) {
+				return nil
+			}
+		case <-ticker.C:
+			st.refresh()
+		}
+		st.draw()
+	}
+}
+
+
+// splitKeys breaks one read into key presses: arrow key escape sequences or single characters
+func splitKeys(s string) []string {
+
+	var keys []string
+	for s != "" {
+		if strings.HasPrefix(s, "\x1b[") && len(s) >= 3 {
+			keys = append(keys, s[:3])
+			s = s[3:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		keys = append(keys, s[:size])
+		s = s[size:]
+	}
+	return keys
+}
+
+
+// refresh reloads the list from the server
+func (st *tuiState) refresh() {
+
+	var byID map[string]model.Todo
+	if err := st.c.do(http.MethodGet, "/todos", nil, &byID); err != nil {
+		st.status = "refresh failed: " + err.Error()
+		return
+	}
+
+	st.todos = st.todos[:0]
+	for _, t := range byID {
+		st.todos = append(st.todos, t)
+	}
+	sort.Slice(st.todos, func(i, j int) bool { return st.todos[i].ID < st.todos[j].ID })
+	st.cursor = max(min(st.cursor, len(st.visible())-1), 0)
+}
+
+
+// visible applies the done/open and title filters
+func (st *tuiState) visible() []model.Todo {
+
+	var list []model.Todo
+	query := strings.ToLower(st.query)
+	for _, t := range st.todos {
+		if (st.show == tuiShowOpen && t.Done) || (st.show == tuiShowDone && !t.Done) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(t.Title), query) {
+			continue
+		}
+		list = append(list, t)
+	}
+	return list
+}
+
+
+// selected returns the todo under the cursor
+func (st *tuiState) selected() (model.Todo, bool) {
+	list := st.visible()
+	if st.cursor < 0 || st.cursor >= len(list) {
+		return model.Todo{}, false
+	}
+	return list[st.cursor], true
+}
+
+
+// handleKey applies one key press; false means quit
+func (st *tuiState) handleKey(key string) bool {
+
+	// ctrl-c always quits
+	if key == "\x03" {
+		return false
+	}
+
+	// line editing for add / filter
+	if st.mode != tuiModeNormal {
+		switch key {
+		case "\r", "\n":
+			st.submit()
+		case "\x1b":
+			st.mode = tuiModeNormal
+			st.input = nil
+		case "\x7f", "\b":
+			if len(st.input) > 0 {
+				st.input = st.input[:len(st.input)-1]
+			}
+		default:
+			if !strings.HasPrefix(key, "\x1b") {
+				for _, r := range key {
+					if r >= ' ' {
+						st.input = append(st.input, r)
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	st.status = ""
+	switch key {
+	case "q":
+		return false
+	case "j", "\x1b[B":
+		st.cursor = min(st.cursor+1, max(len(st.visible())-1, 0))
+	case "k", "\x1b[A":
+		st.cursor = max(st.cursor-1, 0)
+	case " ", "\r":
+		st.toggle()
+	case "d":
+		st.remove()
+	case "a":
+		st.mode = tuiModeAdd
+		st.input = nil
+	case "/":
+		st.mode = tuiModeFilter
+		st.input = []rune(st.query)
+	case "f":
+		// cycle all -> open -> done
+		switch st.show {
+		case tuiShowAll:
+			st.show = tuiShowOpen
+		case tuiShowOpen:
+			st.show = tuiShowDone
+		default:
+			st.show = tuiShowAll
+		}
+		st.cursor = 0
+	case "r":
+		st.refresh()
+	}
+	return true
+}
+
+
+// submit finishes the line typed in add / filter mode
+func (st *tuiState) submit() {
+
+	line := strings.TrimSpace(string(st.input))
+	mode := st.mode
+	st.mode = tuiModeNormal
+	st.input = nil
+
+	if mode == tuiModeFilter {
+		st.query = line
+		st.cursor = 0
+		return
+	}
+
+	// adding
+	if line == "" {
+		return
+	}
+	var todo model.Todo
+	if err := st.c.do(http.MethodPost, "/todos/create", api.CreateTodoRequest{Title: line}, &todo); err != nil {
+		st.status = "add failed: " + err.Error()
+		return
+	}
+	st.status = "added " + strconv.Itoa(todo.ID)
+	st.refresh()
+}
+
+
+// toggle flips done on the selected todo
+func (st *tuiState) toggle() {
+
+	todo, ok := st.selected()
+	if !ok {
+		return
+	}
+	path := "/todos/update?id=" + strconv.Itoa(todo.ID) + "&done=" + strconv.FormatBool(!todo.Done)
+	if err := st.c.do(http.MethodPut, path, nil, nil); err != nil {
+		st.status = "update failed: " + err.Error()
+	}
+	st.refresh()
+}
+
+
+// remove deletes the selected todo
+func (st *tuiState) remove() {
+
+	todo, ok := st.selected()
+	if !ok {
+		return
+	}
+	if err := st.c.do(http.MethodDelete, "/todos/delete?id="+strconv.Itoa(todo.ID), nil, nil); err != nil {
+		st.status = "delete failed: " + err.Error()
+	} else {
+		st.status = "deleted " + strconv.Itoa(todo.ID)
+	}
+	st.refresh()
+}
+
+
+// draw repaints the whole screen (raw mode: lines end in \r\n)
+func (st *tuiState) draw() {
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+
+	fmt.Fprintf(&b, "\x1b[1mtodo\x1b[0m  %s  showing: %s", st.c.server, st.show)
+	if st.query != "" {
+		fmt.Fprintf(&b, "  filter: %q", st.query)
+	}
+	b.WriteString("\r\n\r\n")
+
+	list := st.visible()
+	if len(list) == 0 {
+		b.WriteString("  (nothing here)\r\n")
+	}
+	for i, t := range list {
+		mark := " "
+		if t.Done {
+			mark = "x"
+		}
+		line := fmt.Sprintf(" [%s] %4d  %s", mark, t.ID, t.Title)
+		if i == st.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m" // reverse video for the cursor
+		}
+		b.WriteString(line + "\r\n")
+	}
+
+	b.WriteString("\r\n")
+	switch st.mode {
+	case tuiModeAdd:
+		b.WriteString("new todo: " + string(st.input) + "_\r\n")
+	case tuiModeFilter:
+		b.WriteString("filter: " + string(st.input) + "_\r\n")
+	default:
+		if st.status != "" {
+			b.WriteString(st.status + "\r\n")
+		}
+		b.WriteString("\x1b[2mj/k move  space toggle  a add  d delete  / filter  f all/open/done  r refresh  q quit\x1b[0m\r\n")
+	}
+
+	os.Stdout.WriteString(b.String())
+}
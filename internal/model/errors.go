@@ -0,0 +1,150 @@
+package model
+
+import (
+	"errors"       // for the sentinel errors
+	"fmt"          // for vetoes
+	"strings"      // for error messages
+	"time"         // for how long the store is unavailable
+	"unicode"      // for spaces in tags
+	"unicode/utf8" // for title length
+)
+
+// domain errors, returned (wrapped) by the store and mapped to status codes by the API.
+// check them with errors.Is: errors.Is(err, ErrValidation) holds for every *ValidationError
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrRejected    = errors.New("rejected")    // vetoed by a lifecycle hook, see Reject
+	ErrUnavailable = errors.New("unavailable") // the store can't take changes for now, e.g. a cluster without a leader
+)
+
+// longest title a todo may have, in characters
+const MaxTitleLength = 1000
+
+// limits on a todo's list name and tags, in characters and tags
+const (
+	MaxListLength = 100
+	MaxTagLength  = 50
+	MaxTags       = 20
+)
+
+// FieldError is what's wrong with one field of the input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of an input
+type ValidationError struct {
+	Fields []FieldError
+}
+
+
+// Invalid returns a validation error for one field
+func Invalid(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+
+// Error lists the fields, e.g. "validation failed: title: is required"
+func (e *ValidationError) Error() string {
+
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(parts, "; ")
+}
+
+
+// Is makes errors.Is(err, ErrValidation) true
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+
+// Validate checks a todo before it's stored: a title that isn't blank, and not too long, a list
+// name without spaces around it and not too long, and few enough tags, each one word
+func (t Todo) Validate() error {
+
+	switch {
+	case strings.TrimSpace(t.Title) == "":
+		return Invalid("title", "is required")
+	case utf8.RuneCountInString(t.Title) > MaxTitleLength:
+		return Invalid("title", "is longer than 1000 characters")
+	case strings.TrimSpace(t.List) != t.List:
+		return Invalid("list", "can't start or end with spaces")
+	case utf8.RuneCountInString(t.List) > MaxListLength:
+		return Invalid("list", "is longer than 100 characters")
+	case len(t.Tags) > MaxTags:
+		return Invalid("tags", "are more than 20")
+	}
+	for _, tag := range t.Tags {
+		switch {
+		case tag == "" || strings.ContainsFunc(tag, unicode.IsSpace) || strings.Contains(tag, ","):
+			return Invalid("tags", "must be one word each")
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			return Invalid("tags", "can't be longer than 50 characters each")
+		}
+	}
+	return nil
+}
+
+
+// Reject is the error for a store hook to veto an operation with; reason is shown to the client
+func Reject(reason string) error {
+	return fmt.Errorf("%w: %s", ErrRejected, reason)
+}
+
+// UnavailableError is ErrUnavailable for a known while, see Unavailable. errors.Is(err,
+// ErrUnavailable) holds
+type UnavailableError struct {
+	Reason     string
+	RetryAfter time.Duration // when to try again
+}
+
+
+// Unavailable is the error for a store that can't take changes for retryAfter; reason is shown
+// to the client
+func Unavailable(reason string, retryAfter time.Duration) error {
+	return &UnavailableError{Reason: reason, RetryAfter: retryAfter}
+}
+
+
+// Error gives the reason, e.g. "unavailable: the store's backend is failing"
+func (e *UnavailableError) Error() string {
+	return ErrUnavailable.Error() + ": " + e.Reason
+}
+
+
+// Is makes errors.Is(err, ErrUnavailable) true
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// MergeConflict is a change made against an older revision of a todo that can't be merged
+// with what changed since: both sides changed Fields, or (no Fields) the base revision is too
+// old to compare with. errors.Is(err, ErrConflict) holds
+type MergeConflict struct {
+	ID     int
+	Base   int      // the revision the change was made against
+	Fields []string // changed on both sides, to different values
+	Server Todo     // the todo as it is
+	Client Todo     // the todo as the change would have made it
+}
+
+
+// Error names the fields, e.g. "todo 3: conflict: title changed since revision 2"
+func (e *MergeConflict) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("todo %d: %s: revision %d is too old to merge with", e.ID, ErrConflict, e.Base)
+	}
+	return fmt.Sprintf("todo %d: %s: %s changed since revision %d", e.ID, ErrConflict, strings.Join(e.Fields, ", "), e.Base)
+}
+
+
+// Is makes errors.Is(err, ErrConflict) true
+func (e *MergeConflict) Is(target error) bool {
+	return target == ErrConflict
+}
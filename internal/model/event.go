@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time" // for event timestamps
+)
+
+// change event types
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// pseudo event type for updates that mark a todo done, used by the message bus publishers
+const ChangeCompleted = "completed"
+
+// Event describes one change to a todo
+type Event struct {
+	Seq   uint64    `json:"seq"`             // position in the change log, increasing by one per change
+	Type  string    `json:"type"`            // created, updated or deleted
+	Todo  Todo      `json:"todo"`            // todo after the change (before, for deletes)
+	Time  time.Time `json:"time"`            // when the change was applied
+	Actor string    `json:"actor,omitempty"` // who made it, as recorded in the todo's history
+
+	Traceparent string `json:"-"` // W3C trace context of the request making it, for publishers to pass on
+}
+
+
+// ChangeKind names an event for message bus publishers: the event type, except
+// that updates marking a todo done are reported as "completed"
+func ChangeKind(e Event) string {
+	if e.Type == EventUpdated && e.Todo.Done {
+		return ChangeCompleted
+	}
+	return e.Type
+}
@@ -0,0 +1,146 @@
+// Package model holds the types shared by the store and the API: todos and the change events about them.
+package model
+
+import (
+	"slices"  // for comparing and sorting tags
+	"strings" // for cleaning tags
+	"time"    // for due dates
+)
+
+// Todo represents a single todo item (response structure)
+type Todo struct {
+	ID    int        `json:"id"`             // unique identifier
+	Title string     `json:"title"`          // task description
+	Done  bool       `json:"done"`           // completion status
+	Due   *time.Time `json:"due,omitempty"`  // optional deadline
+	List  string     `json:"list,omitempty"` // the list it's on ("groceries"), "" for none
+	Tags  []string   `json:"tags,omitempty"` // sorted and without repeats, see CleanTags
+	Rev   int        `json:"rev"`            // revision: 1 when created, one more per change (set by the store)
+}
+
+// Revision is one version of a todo, as it was stored
+type Revision struct {
+	Rev   int       `json:"rev"`
+	Time  time.Time `json:"time"`  // when it was stored
+	Actor string    `json:"actor"` // who stored it, see store.WithActor
+	Todo  Todo      `json:"todo"`
+}
+
+// Trashed is a deleted todo the store still keeps, to be restored or purged
+type Trashed struct {
+	Todo      Todo      `json:"todo"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+// Patch is a change to some fields of a todo; nil fields (and Due unless SetDue) stay as they are
+type Patch struct {
+	Title  *string
+	Done   *bool
+	SetDue bool
+	Due    *time.Time // nil with SetDue clears it
+	List   *string    // "" takes it off its list
+	Tags   *[]string  // replaces every tag, empty drops them
+}
+
+
+// Apply returns todo with the patch's fields
+func (p Patch) Apply(todo Todo) Todo {
+	if p.Title != nil {
+		todo.Title = *p.Title
+	}
+	if p.Done != nil {
+		todo.Done = *p.Done
+	}
+	if p.SetDue {
+		todo.Due = p.Due
+	}
+	if p.List != nil {
+		todo.List = *p.List
+	}
+	if p.Tags != nil {
+		todo.Tags = CleanTags(*p.Tags)
+	}
+	return todo
+}
+
+
+// MergePatch is the three-way merge of a patch made against base with current, the todo as it
+// is now: fields the patch changes from base win unless current changed them too, to another
+// value; those are returned as conflicts
+func MergePatch(base, current Todo, p Patch) (Todo, []string) {
+
+	var conflicts []string
+	merged := current
+	if p.Title != nil && *p.Title != base.Title {
+		if current.Title != base.Title && current.Title != *p.Title {
+			conflicts = append(conflicts, "title")
+		}
+		merged.Title = *p.Title
+	}
+	if p.Done != nil && *p.Done != base.Done {
+		if current.Done != base.Done && current.Done != *p.Done {
+			conflicts = append(conflicts, "done")
+		}
+		merged.Done = *p.Done
+	}
+	if p.SetDue && !sameTime(p.Due, base.Due) {
+		if !sameTime(current.Due, base.Due) && !sameTime(current.Due, p.Due) {
+			conflicts = append(conflicts, "due")
+		}
+		merged.Due = p.Due
+	}
+	if p.List != nil && *p.List != base.List {
+		if current.List != base.List && current.List != *p.List {
+			conflicts = append(conflicts, "list")
+		}
+		merged.List = *p.List
+	}
+	if p.Tags != nil && !slices.Equal(CleanTags(*p.Tags), base.Tags) {
+		tags := CleanTags(*p.Tags)
+		if !slices.Equal(current.Tags, base.Tags) && !slices.Equal(current.Tags, tags) {
+			conflicts = append(conflicts, "tags")
+		}
+		merged.Tags = tags
+	}
+	return merged, conflicts
+}
+
+
+// SameFields reports whether two versions of a todo have the same title, done status, due date,
+// list and tags
+func (t Todo) SameFields(other Todo) bool {
+	return t.Title == other.Title && t.Done == other.Done && sameTime(t.Due, other.Due) &&
+		t.List == other.List && slices.Equal(t.Tags, other.Tags)
+}
+
+
+// HasTag reports whether the todo is tagged tag
+func (t Todo) HasTag(tag string) bool {
+	_, found := slices.BinarySearch(t.Tags, tag)
+	return found
+}
+
+
+// CleanTags is tags as a todo keeps them: trimmed, lowercase, without a leading "#", sorted and
+// each once, blanks dropped; nil when none are left
+func CleanTags(tags []string) []string {
+
+	var clean []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#")); tag != "" {
+			clean = append(clean, tag)
+		}
+	}
+	slices.Sort(clean)
+	return slices.Compact(clean)
+}
+
+
+// sameTime compares optional times
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
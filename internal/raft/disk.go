@@ -0,0 +1,156 @@
+package raft
+
+import (
+	"bufio"         // for reading the log
+	"encoding/json" // for the state and log files
+	"fmt"           // for errors
+	"os"            // for the files
+	"path/filepath" // for their paths
+)
+
+// what a node keeps in its directory: state.json, the current term and the vote cast in it,
+// replaced as a whole, and log.ndjson, an entry per line, appended to and fsynced before the node
+// answers for it. a conflicting suffix from an old leader is rare, and rewrites the file
+
+// persistedState is state.json
+type persistedState struct {
+	Term     uint64 `json:"term"`
+	VotedFor string `json:"voted_for,omitempty"`
+}
+
+// disk is a node's directory
+type disk struct {
+	dir string
+	log *os.File
+}
+
+
+// openDisk opens dir, made if need be, and reads what's in it
+func openDisk(dir string) (*disk, uint64, string, []Entry, error) {
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, 0, "", nil, fmt.Errorf("raft: %w", err)
+	}
+
+	var state persistedState
+	if data, err := os.ReadFile(filepath.Join(dir, "state.json")); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, 0, "", nil, fmt.Errorf("raft: state.json: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, 0, "", nil, fmt.Errorf("raft: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "log.ndjson"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, 0, "", nil, fmt.Errorf("raft: %w", err)
+	}
+	var entries []Entry
+	torn := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Index != uint64(len(entries))+1 {
+			// a line cut short by a crash is the end of the log; it was never acknowledged
+			torn = true
+			break
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, 0, "", nil, fmt.Errorf("raft: log.ndjson: %w", err)
+	}
+
+	d := &disk{dir: dir, log: f}
+	if torn {
+		if err := d.rewrite(entries); err != nil {
+			f.Close()
+			return nil, 0, "", nil, err
+		}
+	}
+	return d, state.Term, state.VotedFor, entries, nil
+}
+
+
+// saveState replaces state.json
+func (d *disk) saveState(term uint64, votedFor string) error {
+
+	data, _ := json.Marshal(persistedState{Term: term, VotedFor: votedFor})
+	tmp := filepath.Join(d.dir, "state.json.tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(d.dir, "state.json"))
+}
+
+
+// appendEntries adds entries to the end of log.ndjson
+func (d *disk) appendEntries(entries []Entry) error {
+
+	var buf []byte
+	for _, e := range entries {
+		line, _ := json.Marshal(e)
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := d.log.Write(buf); err != nil {
+		return err
+	}
+	return d.log.Sync()
+}
+
+
+// rewrite replaces log.ndjson with entries, for a log cut back to where it agrees with the leader
+// (and on open, to drop a torn last line)
+func (d *disk) rewrite(entries []Entry) error {
+
+	path := filepath.Join(d.dir, "log.ndjson")
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		line, _ := json.Marshal(e)
+		w.Write(append(line, '\n'))
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("raft: rewriting the log: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("raft: %w", err)
+	}
+	d.log.Close()
+	d.log = f
+	return nil
+}
+
+
+// close closes the log file
+func (d *disk) close() {
+	d.log.Close()
+}
@@ -0,0 +1,522 @@
+// Package raft replicates a log of commands between a few nodes with the Raft consensus algorithm:
+// one elected leader takes every proposal, and an entry counts once a majority of the nodes has it.
+package raft
+
+import (
+	"context"   // for proposals
+	"errors"    // for config errors
+	"math/rand" // for election timeouts
+	"net/http"  // for talking to the other nodes
+	"sort"      // for the commit index
+	"sync"      // for guarding the node
+	"time"      // for heartbeats and elections
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for the proposals' traces
+)
+
+// the algorithm of the paper (Ongaro and Ousterhout, "In Search of an Understandable Consensus
+// Algorithm"), without membership changes or log compaction: the nodes are fixed by Config.Peers
+// and every entry is kept, so a node that restarts replays the whole log. the term, the vote and
+// the log are on disk before a node answers for them; the nodes call each other over HTTP, see rpc.go
+
+// how often a leader reminds the followers it's there, and how long a follower waits for it before
+// standing for election (a random time in between the two, so elections rarely tie)
+const (
+	heartbeatEvery     = 50 * time.Millisecond
+	minElectionTimeout = 300 * time.Millisecond
+	maxElectionTimeout = 600 * time.Millisecond
+)
+
+// most entries sent in one append, and longest a proposal waits to be committed
+const (
+	maxAppendEntries = 256
+	proposalTimeout  = 5 * time.Second
+)
+
+// ErrNotLeader is a proposal to a node that isn't the leader, or one that hasn't caught up yet
+var ErrNotLeader = errors.New("raft: not the leader")
+
+// ErrNotCommitted is a proposal the leader couldn't get a majority for in time, or lost its
+// leadership over. it may still be committed later, by the next leader
+var ErrNotCommitted = errors.New("raft: not committed")
+
+// Config is a node and the cluster it's in
+type Config struct {
+	ID        string            // this node, one of Peers
+	Peers     map[string]string // every node, this one included: id -> base URL of its cluster port
+	Dir       string            // where the node keeps its term, vote and log
+	Secret    string            // bearer token the nodes authenticate each other with
+	Advertise string            // told to the followers while this node leads, e.g. its public URL
+	Apply     func(data []byte) // applies a committed entry that wasn't proposed here, in log order
+}
+
+// Entry is one command in the log
+type Entry struct {
+	Index uint64 `json:"index"`
+	Term  uint64 `json:"term"`
+	Data  []byte `json:"data"` // nil (null) for the no-op a new leader starts its term with, never for a proposal
+
+	trace string // traceparent of the proposal's request, on the leader that took it
+}
+
+// roles a node has
+const (
+	Follower  = "follower"
+	Candidate = "candidate"
+	Leader    = "leader"
+)
+
+// Node is one member of a cluster
+type Node struct {
+	cfg     Config
+	disk    *disk
+	client  *http.Client
+	applyCh chan struct{} // wakes the applier
+
+	mu          sync.Mutex
+	role        string
+	term        uint64
+	votedFor    string
+	log         []Entry // log[0] is a sentinel at index 0, term 0
+	commitIndex uint64
+	lastApplied uint64
+	leader      string // the current leader's id, "" while unknown
+	leaderURL   string // its Config.Advertise
+	heardAt     time.Time
+	timeout     time.Duration // this round's election timeout
+
+	// while leading
+	leadingSince uint64                   // index of this term's no-op, proposals wait for it to be applied
+	nextIndex    map[string]uint64        // next entry to send each peer
+	matchIndex   map[string]uint64        // last entry each peer is known to have
+	wake         map[string]chan struct{} // tells a peer's replicator there's something new
+	waiting      map[uint64]chan error    // proposals waiting for their entry to be committed
+	proposed     map[uint64]bool          // committed entries their proposer applies, not the applier
+	stop         chan struct{}
+}
+
+// Status is what a node knows about the cluster
+type Status struct {
+	ID          string       `json:"id"`
+	Role        string       `json:"role"`
+	Term        uint64       `json:"term"`
+	Leader      string       `json:"leader,omitempty"`
+	LeaderURL   string       `json:"leader_url,omitempty"`
+	LastIndex   uint64       `json:"last_index"`
+	CommitIndex uint64       `json:"commit_index"`
+	LastApplied uint64       `json:"last_applied"`
+	Peers       []PeerStatus `json:"peers,omitempty"` // while leading
+}
+
+// PeerStatus is how far a follower is, as its leader sees it
+type PeerStatus struct {
+	ID         string `json:"id"`
+	MatchIndex uint64 `json:"match_index"`
+}
+
+
+// Start checks cfg, loads what the node kept on disk and joins the cluster as a follower
+func Start(cfg Config) (*Node, error) {
+
+	switch {
+	case cfg.ID == "":
+		return nil, errors.New("raft: the node needs an id")
+	case cfg.Peers[cfg.ID] == "":
+		return nil, errors.New("raft: the node " + cfg.ID + " isn't one of the peers")
+	case cfg.Dir == "":
+		return nil, errors.New("raft: the node needs a directory for its log")
+	case cfg.Apply == nil:
+		return nil, errors.New("raft: the node needs an Apply func")
+	}
+	d, term, votedFor, entries, err := openDisk(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		cfg:      cfg,
+		disk:     d,
+		client:   &http.Client{Timeout: 2 * time.Second},
+		applyCh:  make(chan struct{}, 1),
+		role:     Follower,
+		term:     term,
+		votedFor: votedFor,
+		log:      append([]Entry{{}}, entries...),
+		heardAt:  time.Now(),
+		timeout:  electionTimeout(),
+		proposed: map[uint64]bool{},
+		stop:     make(chan struct{}),
+	}
+	go n.run()
+	go n.applier()
+	return n, nil
+}
+
+
+// electionTimeout picks a follower's patience for this round
+func electionTimeout() time.Duration {
+	return minElectionTimeout + time.Duration(rand.Int63n(int64(maxElectionTimeout-minElectionTimeout)))
+}
+
+
+// Stop leaves the cluster: the node stops standing for election and replicating (its RPC
+// handler answers no more than a follower's would)
+func (n *Node) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	select {
+	case <-n.stop:
+	default:
+		close(n.stop)
+		n.becomeFollower(n.term)
+		n.disk.close()
+	}
+}
+
+
+// run stands for election whenever the leader has been silent too long
+func (n *Node) run() {
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+		}
+		n.mu.Lock()
+		if n.role != Leader && time.Since(n.heardAt) > n.timeout {
+			n.startElection()
+		}
+		n.mu.Unlock()
+	}
+}
+
+
+// lastIndex is the index of the log's last entry (caller holds n.mu)
+func (n *Node) lastIndex() uint64 {
+	return n.log[len(n.log)-1].Index
+}
+
+
+// lastTerm is the term of the log's last entry (caller holds n.mu)
+func (n *Node) lastTerm() uint64 {
+	return n.log[len(n.log)-1].Term
+}
+
+
+// majority is how many nodes make one
+func (n *Node) majority() int {
+	return len(n.cfg.Peers)/2 + 1
+}
+
+
+// startElection makes the node a candidate in the next term and asks the others for their votes
+// (caller holds n.mu)
+func (n *Node) startElection() {
+
+	n.term++
+	n.role, n.votedFor, n.leader, n.leaderURL = Candidate, n.cfg.ID, "", ""
+	n.heardAt, n.timeout = time.Now(), electionTimeout()
+	if err := n.disk.saveState(n.term, n.votedFor); err != nil {
+		n.role = Follower // no vote asked for, none to stand by
+		return
+	}
+
+	term := n.term
+	req := voteRequest{Term: term, Candidate: n.cfg.ID, LastIndex: n.lastIndex(), LastTerm: n.lastTerm()}
+	votes := 1
+	if votes >= n.majority() {
+		n.becomeLeader()
+		return
+	}
+	for id, url := range n.cfg.Peers {
+		if id == n.cfg.ID {
+			continue
+		}
+		go func() {
+			resp, err := n.callVote(url, req)
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			switch {
+			case resp.Term > n.term:
+				n.becomeFollower(resp.Term)
+			case n.role == Candidate && n.term == term && resp.Granted:
+				votes++
+				if votes >= n.majority() {
+					n.becomeLeader()
+				}
+			}
+		}()
+	}
+}
+
+
+// becomeFollower steps down to follow whoever leads term (caller holds n.mu). proposals still
+// waiting fail; their entries may be committed by the next leader, and are applied if they are
+func (n *Node) becomeFollower(term uint64) {
+
+	if term > n.term {
+		n.term, n.votedFor, n.leader, n.leaderURL = term, "", "", ""
+		n.disk.saveState(n.term, n.votedFor)
+	}
+	if n.role == Leader {
+		for index, ch := range n.waiting {
+			ch <- ErrNotCommitted
+			delete(n.waiting, index)
+		}
+		n.leader, n.leaderURL = "", ""
+	}
+	n.role = Follower
+	n.heardAt, n.timeout = time.Now(), electionTimeout()
+}
+
+
+// becomeLeader takes over the cluster: the term starts with a no-op entry, which commits what
+// earlier leaders left uncommitted, and a replicator per follower (caller holds n.mu)
+func (n *Node) becomeLeader() {
+
+	n.role, n.leader, n.leaderURL = Leader, n.cfg.ID, n.cfg.Advertise
+	n.nextIndex, n.matchIndex = map[string]uint64{}, map[string]uint64{}
+	n.wake, n.waiting = map[string]chan struct{}{}, map[uint64]chan error{}
+	noop := Entry{Index: n.lastIndex() + 1, Term: n.term}
+	if err := n.disk.appendEntries([]Entry{noop}); err != nil {
+		n.becomeFollower(n.term)
+		return
+	}
+	n.log = append(n.log, noop)
+	n.leadingSince = noop.Index
+
+	for id, url := range n.cfg.Peers {
+		if id == n.cfg.ID {
+			continue
+		}
+		n.nextIndex[id], n.matchIndex[id] = noop.Index, 0
+		n.wake[id] = make(chan struct{}, 1)
+		go n.replicate(id, url, n.term, n.wake[id])
+	}
+	n.advanceCommit()
+}
+
+
+// replicate keeps one follower's log in step with the leader's for as long as it leads term
+func (n *Node) replicate(id, url string, term uint64, wake chan struct{}) {
+
+	heartbeat := time.NewTicker(heartbeatEvery)
+	defer heartbeat.Stop()
+	for {
+		n.mu.Lock()
+		if n.role != Leader || n.term != term {
+			n.mu.Unlock()
+			return
+		}
+		next := n.nextIndex[id]
+		prev := n.log[next-1]
+		last := min(n.lastIndex(), next-1+maxAppendEntries)
+		req := appendRequest{
+			Term:         term,
+			Leader:       n.cfg.ID,
+			LeaderURL:    n.cfg.Advertise,
+			PrevIndex:    prev.Index,
+			PrevTerm:     prev.Term,
+			Entries:      append([]Entry{}, n.log[next:last+1]...),
+			LeaderCommit: n.commitIndex,
+		}
+		n.mu.Unlock()
+
+		resp, err := n.callAppend(url, req)
+
+		n.mu.Lock()
+		more := false
+		switch {
+		case err != nil:
+		case resp.Term > n.term:
+			n.becomeFollower(resp.Term)
+		case n.role != Leader || n.term != term:
+		case resp.Success:
+			n.matchIndex[id] = max(n.matchIndex[id], req.PrevIndex+uint64(len(req.Entries)))
+			n.nextIndex[id] = n.matchIndex[id] + 1
+			n.advanceCommit()
+			more = n.nextIndex[id] <= n.lastIndex()
+		default:
+			// back up to where the follower's log may agree, at most to its end
+			n.nextIndex[id] = max(1, min(req.PrevIndex, resp.LastIndex+1))
+			more = true
+		}
+		n.mu.Unlock()
+
+		if more {
+			continue
+		}
+		select {
+		case <-wake:
+		case <-heartbeat.C:
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+
+// advanceCommit commits up to the last entry of this term a majority has (caller holds n.mu)
+func (n *Node) advanceCommit() {
+
+	matched := []uint64{n.lastIndex()}
+	for _, index := range n.matchIndex {
+		matched = append(matched, index)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i] > matched[j] })
+	index := matched[n.majority()-1]
+
+	// entries of earlier terms are only committed by one of this term (Raft's figure 8)
+	if index <= n.commitIndex || n.log[index].Term != n.term {
+		return
+	}
+	for i := n.commitIndex + 1; i <= index; i++ {
+		if ch, ok := n.waiting[i]; ok {
+			n.proposed[i] = true
+			ch <- nil
+			delete(n.waiting, i)
+		}
+	}
+	n.commitIndex = index
+	n.wakeApplier()
+}
+
+
+// wakeApplier tells the applier there are committed entries (caller holds n.mu)
+func (n *Node) wakeApplier() {
+	select {
+	case n.applyCh <- struct{}{}:
+	default:
+	}
+}
+
+
+// applier applies committed entries in order, except the no-ops and those their proposer applies
+func (n *Node) applier() {
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-n.applyCh:
+		}
+		for {
+			n.mu.Lock()
+			if n.lastApplied >= n.commitIndex {
+				n.mu.Unlock()
+				break
+			}
+			n.lastApplied++
+			entry := n.log[n.lastApplied]
+			mine := n.proposed[entry.Index]
+			delete(n.proposed, entry.Index)
+			n.mu.Unlock()
+
+			if entry.Data != nil && !mine {
+				n.cfg.Apply(entry.Data)
+			}
+		}
+	}
+}
+
+
+// Propose appends data to the log and returns once a majority has it, for the caller to apply
+// itself: the node's Apply isn't called for it. ErrNotLeader on a node that doesn't lead, or
+// hasn't applied what earlier leaders committed yet; ErrNotCommitted when the leader gives up
+func (n *Node) Propose(ctx context.Context, data []byte) error {
+
+	if data == nil {
+		data = []byte{}
+	}
+	n.mu.Lock()
+	if n.role != Leader || n.lastApplied < n.leadingSince {
+		n.mu.Unlock()
+		return ErrNotLeader
+	}
+	entry := Entry{Index: n.lastIndex() + 1, Term: n.term, Data: data, trace: tracing.FromContext(ctx).Traceparent()}
+	if err := n.disk.appendEntries([]Entry{entry}); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	n.log = append(n.log, entry)
+	done := make(chan error, 1)
+	n.waiting[entry.Index] = done
+	for _, wake := range n.wake {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	n.advanceCommit()
+	n.mu.Unlock()
+
+	timeout := time.NewTimer(proposalTimeout)
+	defer timeout.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	case <-timeout.C:
+	}
+
+	// given up on: if it's committed after all, the applier applies it. a leader that can't
+	// commit in time is likely cut off, and stepping down keeps it from applying later entries
+	// of its own before this one
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.waiting[entry.Index]; !ok {
+		return <-done
+	}
+	delete(n.waiting, entry.Index)
+	if n.role == Leader && n.term == entry.Term {
+		n.becomeFollower(n.term)
+	}
+	return ErrNotCommitted
+}
+
+
+// IsLeader reports whether the node leads the cluster and takes proposals
+func (n *Node) IsLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == Leader && n.lastApplied >= n.leadingSince
+}
+
+
+// Leader returns the current leader's id and advertised URL, "" while there's none known
+func (n *Node) Leader() (id, url string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.leader, n.leaderURL
+}
+
+
+// Status reports the node's view of the cluster
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	s := Status{
+		ID:          n.cfg.ID,
+		Role:        n.role,
+		Term:        n.term,
+		Leader:      n.leader,
+		LeaderURL:   n.leaderURL,
+		LastIndex:   n.lastIndex(),
+		CommitIndex: n.commitIndex,
+		LastApplied: n.lastApplied,
+	}
+	if n.role == Leader {
+		for id, index := range n.matchIndex {
+			s.Peers = append(s.Peers, PeerStatus{ID: id, MatchIndex: index})
+		}
+		sort.Slice(s.Peers, func(i, j int) bool { return s.Peers[i].ID < s.Peers[j].ID })
+	}
+	return s
+}
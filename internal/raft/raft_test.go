@@ -0,0 +1,418 @@
+package raft
+
+import (
+	"context"           // for proposals
+	"errors"            // for matching proposal errors
+	"net/http"          // for the nodes' RPCs
+	"net/http/httptest" // for the nodes' cluster ports
+	"slices"            // for comparing applied entries
+	"strings"           // for RPC bodies and config errors
+	"sync"              // for guarding a member
+	"testing"           // for the tests
+	"time"              // for waiting on the cluster
+)
+
+// raft tests: clusters of three nodes on test servers, electing a leader, committing proposals,
+// losing nodes and coming back from disk. a node taken down answers every RPC with a 503, as an
+// unreachable one would fail, and can be started again from its directory
+
+// the secret the test nodes share
+const testSecret = "s3cret"
+
+// how long a cluster gets to settle, several election timeouts
+const settleTime = 5 * time.Second
+
+// testMember is one node of a test cluster, with what it has applied
+type testMember struct {
+	id  string
+	dir string
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	node    *Node // nil while down
+	applied []string
+}
+
+// testCluster is the members by id
+type testCluster struct {
+	t       *testing.T
+	peers   map[string]string
+	members map[string]*testMember
+}
+
+
+// ServeHTTP answers for the member's node, 503 while it's down
+func (m *testMember) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	node := m.node
+	m.mu.Unlock()
+	if node == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	node.Handler().ServeHTTP(w, r)
+}
+
+
+// newTestCluster starts nodes a, b and c
+func newTestCluster(t *testing.T) *testCluster {
+
+	c := &testCluster{t: t, peers: map[string]string{}, members: map[string]*testMember{}}
+	for _, id := range []string{"a", "b", "c"} {
+		m := &testMember{id: id, dir: t.TempDir()}
+		m.srv = httptest.NewServer(m)
+		c.peers[id] = m.srv.URL
+		c.members[id] = m
+	}
+	for id := range c.members {
+		c.start(id)
+	}
+	t.Cleanup(func() {
+		for id, m := range c.members {
+			c.stop(id)
+			m.srv.Close()
+		}
+	})
+	return c
+}
+
+
+// start starts the member's node from its directory
+func (c *testCluster) start(id string) {
+
+	c.t.Helper()
+	m := c.members[id]
+	node, err := Start(Config{
+		ID:        id,
+		Peers:     c.peers,
+		Dir:       m.dir,
+		Secret:    testSecret,
+		Advertise: "http://" + id + ".example",
+		Apply: func(data []byte) {
+			m.mu.Lock()
+			m.applied = append(m.applied, string(data))
+			m.mu.Unlock()
+		},
+	})
+	if err != nil {
+		c.t.Fatal("starting", id+":", err)
+	}
+	m.mu.Lock()
+	m.node, m.applied = node, nil
+	m.mu.Unlock()
+}
+
+
+// stop takes the member down
+func (c *testCluster) stop(id string) {
+	m := c.members[id]
+	m.mu.Lock()
+	node := m.node
+	m.node = nil
+	m.mu.Unlock()
+	if node != nil {
+		node.Stop()
+	}
+}
+
+
+// node is the member's running node
+func (c *testCluster) node(id string) *Node {
+	m := c.members[id]
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.node
+}
+
+
+// applied is what the member applied since it was started
+func (c *testCluster) applied(id string) []string {
+	m := c.members[id]
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.applied)
+}
+
+
+// waitFor polls cond until it holds, failing the test after settleTime
+func (c *testCluster) waitFor(what string, cond func() bool) {
+	c.t.Helper()
+	for deadline := time.Now().Add(settleTime); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			c.t.Fatal("timed out waiting for", what)
+		}
+	}
+}
+
+
+// leader waits for one running node to lead with every other running one following it, and
+// returns its id
+func (c *testCluster) leader() string {
+
+	c.t.Helper()
+	var leader string
+	c.waitFor("a leader everyone follows", func() bool {
+		leader = ""
+		for id := range c.members {
+			if n := c.node(id); n != nil && n.IsLeader() {
+				if leader != "" {
+					return false
+				}
+				leader = id
+			}
+		}
+		if leader == "" {
+			return false
+		}
+		term := c.node(leader).Status().Term
+		for id := range c.members {
+			n := c.node(id)
+			if n == nil || id == leader {
+				continue
+			}
+			if s := n.Status(); s.Leader != leader || s.Term != term || s.LeaderURL != "http://"+leader+".example" {
+				return false
+			}
+		}
+		return true
+	})
+	return leader
+}
+
+
+// followers are the running members other than id
+func (c *testCluster) followers(leader string) []string {
+	var ids []string
+	for id := range c.members {
+		if id != leader && c.node(id) != nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+
+// TestStartConfig checks the config a node can't start with
+func TestStartConfig(t *testing.T) {
+
+	dir := t.TempDir()
+	apply := func([]byte) {}
+	peers := map[string]string{"a": "http://a"}
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no id", Config{Peers: peers, Dir: dir, Apply: apply}, "needs an id"},
+		{"not a peer", Config{ID: "b", Peers: peers, Dir: dir, Apply: apply}, "isn't one of the peers"},
+		{"no dir", Config{ID: "a", Peers: peers, Apply: apply}, "needs a directory"},
+		{"no apply", Config{ID: "a", Peers: peers, Dir: dir}, "needs an Apply"},
+	}
+	for _, tt := range tests {
+		if _, err := Start(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Start = %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+
+// TestSingleNode checks a cluster of one leads itself and commits alone
+func TestSingleNode(t *testing.T) {
+
+	node, err := Start(Config{ID: "a", Peers: map[string]string{"a": "http://127.0.0.1:1"}, Dir: t.TempDir(), Apply: func([]byte) {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Stop()
+	for deadline := time.Now().Add(settleTime); !node.IsLeader(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("a lone node never led")
+		}
+	}
+	if err := node.Propose(context.Background(), []byte("x")); err != nil {
+		t.Fatal("Propose:", err)
+	}
+	if s := node.Status(); s.CommitIndex != 2 || s.LastIndex != 2 {
+		t.Errorf("status %+v, want the no-op and the proposal committed", s)
+	}
+}
+
+
+// TestElection checks three nodes agree on one leader, and elect another in a later term
+// when it goes down
+func TestElection(t *testing.T) {
+
+	c := newTestCluster(t)
+	first := c.leader()
+	term := c.node(first).Status().Term
+
+	for _, id := range c.followers(first) {
+		if err := c.node(id).Propose(context.Background(), []byte("x")); !errors.Is(err, ErrNotLeader) {
+			t.Errorf("Propose on follower %s = %v, want ErrNotLeader", id, err)
+		}
+	}
+
+	c.stop(first)
+	second := c.leader()
+	if second == first {
+		t.Fatal("the stopped node still leads")
+	}
+	if got := c.node(second).Status().Term; got <= term {
+		t.Errorf("new leader's term %d, want after %d", got, term)
+	}
+
+	// the old leader comes back as a follower of the new one
+	c.start(first)
+	if got := c.leader(); got != second {
+		t.Errorf("leader %s after the old one came back, want %s", got, second)
+	}
+}
+
+
+// TestCommit checks proposals are committed and applied on the followers in order, and not on
+// the leader, whose caller applies them
+func TestCommit(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		proposals []string
+	}{
+		{"one", []string{"create 1"}},
+		{"several in order", []string{"create 1", "create 2", "delete 1", "create 3"}},
+		{"empty data", []string{""}},
+		{"many", strings.Split(strings.Repeat("x,", 300)+"last", ",")}, // more than one append carries
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCluster(t)
+			leader := c.leader()
+			for _, p := range tt.proposals {
+				if err := c.node(leader).Propose(context.Background(), []byte(p)); err != nil {
+					t.Fatalf("Propose(%q): %v", p, err)
+				}
+			}
+			for _, id := range c.followers(leader) {
+				c.waitFor(id+" applying every proposal", func() bool { return len(c.applied(id)) >= len(tt.proposals) })
+				if got := c.applied(id); !slices.Equal(got, tt.proposals) {
+					t.Errorf("%s applied %.60q, want %.60q", id, got, tt.proposals)
+				}
+			}
+			if got := c.applied(leader); len(got) != 0 {
+				t.Errorf("the leader applied its own proposals: %q", got)
+			}
+			want := uint64(len(tt.proposals) + 1) // and the no-op
+			if s := c.node(leader).Status(); s.CommitIndex != want || len(s.Peers) != 2 {
+				t.Errorf("leader status %+v, want commit index %d and two peers", s, want)
+			}
+		})
+	}
+}
+
+
+// TestMinority checks a leader left without a majority commits nothing, and what a restarted
+// node catches up on
+func TestMinority(t *testing.T) {
+
+	c := newTestCluster(t)
+	leader := c.leader()
+	if err := c.node(leader).Propose(context.Background(), []byte("before")); err != nil {
+		t.Fatal(err)
+	}
+	followers := c.followers(leader)
+	c.stop(followers[0])
+
+	// one follower gone: two of three still commit
+	if err := c.node(leader).Propose(context.Background(), []byte("while one is down")); err != nil {
+		t.Fatal("Propose with a majority:", err)
+	}
+
+	// both gone: it can't
+	c.stop(followers[1])
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := c.node(leader).Propose(ctx, []byte("alone")); !errors.Is(err, ErrNotCommitted) {
+		t.Fatalf("Propose without a majority = %v, want ErrNotCommitted", err)
+	}
+	if c.node(leader).IsLeader() {
+		t.Error("a leader that couldn't commit is still leading")
+	}
+
+	// the first one back replays its log and catches up with what it missed
+	c.start(followers[0])
+	c.leader()
+	want := []string{"before", "while one is down"}
+	c.waitFor(followers[0]+" catching up", func() bool { return len(c.applied(followers[0])) >= len(want) })
+	if got := c.applied(followers[0]); !slices.Equal(got[:len(want)], want) {
+		t.Errorf("%s applied %q after coming back, want %q first", followers[0], got, want)
+	}
+}
+
+
+// TestRestart checks a cluster started again from its directories keeps its term and replays
+// the whole log, each node applying every entry (nothing was proposed in this run)
+func TestRestart(t *testing.T) {
+
+	c := newTestCluster(t)
+	leader := c.leader()
+	proposals := []string{"one", "two", "three"}
+	for _, p := range proposals {
+		if err := c.node(leader).Propose(context.Background(), []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	term := c.node(leader).Status().Term
+	for id := range c.members {
+		c.stop(id)
+	}
+
+	for id := range c.members {
+		c.start(id)
+	}
+	next := c.leader()
+	if got := c.node(next).Status().Term; got <= term {
+		t.Errorf("term %d after the restart, want after %d", got, term)
+	}
+	for id := range c.members {
+		c.waitFor(id+" replaying the log", func() bool { return len(c.applied(id)) >= len(proposals) })
+		if got := c.applied(id); !slices.Equal(got, proposals) {
+			t.Errorf("%s replayed %q, want %q", id, got, proposals)
+		}
+	}
+}
+
+
+// TestRPCAuth checks the RPCs need the shared secret and a message
+func TestRPCAuth(t *testing.T) {
+
+	c := newTestCluster(t)
+	url := c.members["a"].srv.URL
+	tests := []struct {
+		name   string
+		path   string
+		auth   string
+		body   string
+		status int
+	}{
+		{"no secret", "/raft/vote", "", `{}`, http.StatusUnauthorized},
+		{"wrong secret", "/raft/append", "Bearer nope", `{}`, http.StatusUnauthorized},
+		{"not bearer", "/raft/vote", "Basic " + testSecret, `{}`, http.StatusUnauthorized},
+		{"bad body", "/raft/vote", "Bearer " + testSecret, `{`, http.StatusBadRequest},
+		{"stale vote", "/raft/vote", "Bearer " + testSecret, `{"term":0,"candidate":"x"}`, http.StatusOK},
+		{"unknown RPC", "/raft/snapshot", "Bearer " + testSecret, `{}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, url+tt.path, strings.NewReader(tt.body))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}
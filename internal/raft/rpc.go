@@ -0,0 +1,198 @@
+package raft
+
+import (
+	"bytes"         // for request bodies
+	"crypto/subtle" // for comparing the secret
+	"encoding/json" // for the messages
+	"fmt"           // for errors
+	"net/http"      // for the RPCs
+	"strings"       // for URLs
+	"time"          // for the leader's last word
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for passing proposals' traces on
+)
+
+// the two RPCs of the paper, as JSON POSTs to a node's cluster port: /raft/vote (RequestVote)
+// and /raft/append (AppendEntries, a heartbeat when it carries no entries)
+
+// voteRequest asks for a node's vote
+type voteRequest struct {
+	Term      uint64 `json:"term"`
+	Candidate string `json:"candidate"`
+	LastIndex uint64 `json:"last_index"`
+	LastTerm  uint64 `json:"last_term"`
+}
+
+// voteResponse grants or refuses it
+type voteResponse struct {
+	Term    uint64 `json:"term"`
+	Granted bool   `json:"granted"`
+}
+
+// appendRequest is the leader's entries after PrevIndex, or a heartbeat
+type appendRequest struct {
+	Term         uint64  `json:"term"`
+	Leader       string  `json:"leader"`
+	LeaderURL    string  `json:"leader_url,omitempty"`
+	PrevIndex    uint64  `json:"prev_index"`
+	PrevTerm     uint64  `json:"prev_term"`
+	Entries      []Entry `json:"entries,omitempty"`
+	LeaderCommit uint64  `json:"leader_commit"`
+}
+
+// appendResponse says whether the follower's log agreed at PrevIndex, and where it ends
+type appendResponse struct {
+	Term      uint64 `json:"term"`
+	Success   bool   `json:"success"`
+	LastIndex uint64 `json:"last_index"`
+}
+
+
+// Handler serves the node's RPCs, for the cluster port
+func (n *Node) Handler() http.Handler {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /raft/vote", func(w http.ResponseWriter, r *http.Request) {
+		var req voteRequest
+		if n.decode(w, r, &req) {
+			json.NewEncoder(w).Encode(n.vote(req))
+		}
+	})
+	mux.HandleFunc("POST /raft/append", func(w http.ResponseWriter, r *http.Request) {
+		var req appendRequest
+		if n.decode(w, r, &req) {
+			json.NewEncoder(w).Encode(n.appendEntries(req))
+		}
+	})
+	return mux
+}
+
+
+// decode checks the caller's secret and reads the message, answering the failures itself
+func (n *Node) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(n.cfg.Secret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<20)).Decode(v); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return true
+}
+
+
+// vote answers a candidate: one vote per term, for a candidate whose log is at least as up to
+// date as this node's
+func (n *Node) vote(req voteRequest) voteResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if req.Term > n.term {
+		n.becomeFollower(req.Term)
+	}
+	upToDate := req.LastTerm > n.lastTerm() || (req.LastTerm == n.lastTerm() && req.LastIndex >= n.lastIndex())
+	if req.Term < n.term || !upToDate || (n.votedFor != "" && n.votedFor != req.Candidate) {
+		return voteResponse{Term: n.term}
+	}
+	if n.votedFor != req.Candidate {
+		if err := n.disk.saveState(n.term, req.Candidate); err != nil {
+			return voteResponse{Term: n.term}
+		}
+		n.votedFor = req.Candidate
+	}
+	n.heardAt = time.Now()
+	return voteResponse{Term: n.term, Granted: true}
+}
+
+
+// appendEntries takes a leader's entries into the log, replacing any that conflict with them
+func (n *Node) appendEntries(req appendRequest) appendResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if req.Term < n.term {
+		return appendResponse{Term: n.term, LastIndex: n.lastIndex()}
+	}
+	if req.Term > n.term || n.role != Follower {
+		n.becomeFollower(req.Term)
+	}
+	n.leader, n.leaderURL, n.heardAt = req.Leader, req.LeaderURL, time.Now()
+
+	if req.PrevIndex > n.lastIndex() || n.log[req.PrevIndex].Term != req.PrevTerm {
+		return appendResponse{Term: n.term, LastIndex: min(n.lastIndex(), req.PrevIndex-1)}
+	}
+
+	// skip what's already there; the first entry that differs cuts the log back
+	entries := req.Entries
+	for len(entries) > 0 && entries[0].Index <= n.lastIndex() {
+		if n.log[entries[0].Index].Term != entries[0].Term {
+			n.log = n.log[:entries[0].Index]
+			if err := n.disk.rewrite(n.log[1:]); err != nil {
+				return appendResponse{Term: n.term, LastIndex: n.lastIndex()}
+			}
+			break
+		}
+		entries = entries[1:]
+	}
+	if len(entries) > 0 {
+		if err := n.disk.appendEntries(entries); err != nil {
+			return appendResponse{Term: n.term, LastIndex: n.lastIndex()}
+		}
+		n.log = append(n.log, entries...)
+	}
+
+	if last := req.PrevIndex + uint64(len(req.Entries)); req.LeaderCommit > n.commitIndex {
+		n.commitIndex = min(req.LeaderCommit, last)
+		n.wakeApplier()
+	}
+	return appendResponse{Term: n.term, Success: true, LastIndex: n.lastIndex()}
+}
+
+
+// call POSTs one RPC to the node at base, in the trace traceparent names ("" for none)
+func (n *Node) call(base, path, traceparent string, req, resp any) error {
+
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(base, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+n.cfg.Secret)
+	tracing.Propagate(httpReq.Header, traceparent)
+	httpResp, err := n.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("raft: %s%s: %s", base, path, httpResp.Status)
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+
+// callVote asks the node at base for its vote
+func (n *Node) callVote(base string, req voteRequest) (voteResponse, error) {
+	var resp voteResponse
+	return resp, n.call(base, "/raft/vote", "", req, &resp)
+}
+
+
+// callAppend sends the node at base entries, or a heartbeat. a call has one trace, so entries
+// go in the first traced proposal's
+func (n *Node) callAppend(base string, req appendRequest) (appendResponse, error) {
+	var resp appendResponse
+	var trace string
+	for _, e := range req.Entries {
+		if e.trace != "" {
+			trace = e.trace
+			break
+		}
+	}
+	return resp, n.call(base, "/raft/append", trace, req, &resp)
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"sort" // for hours in time order
+	"sync" // for guarding the counters
+	"time" // for hour buckets and ages
+)
+
+// productivity counters, kept up to date as todos change so reading them never scans the todos.
+// creations and completions are counted per UTC hour, the last AnalyticsHours of them, in a ring
+// that overwrites the oldest hour; callers add the hours up into days in whatever zone they like.
+// each tag has its own hours too, only the ones that had anything, counted with the tags the todo
+// has when it's created or completed. the open todos' creation times are kept as a sum, so their
+// average age is one division
+
+// hours of creations and completions kept (a year and a day, so a year ago is still there)
+const AnalyticsHours = 366 * 24
+
+// HourCount is what happened in one UTC hour
+type HourCount struct {
+	Hour      time.Time     // start of the hour
+	Created   int           // todos created
+	Completed int           // todos marked done
+	TimeToDo  time.Duration // from creation to completion, summed over the completions
+}
+
+// TagActivity is what happened to one tag's todos over a span of hours
+type TagActivity struct {
+	Created   int
+	Completed int
+	TimeToDo  time.Duration // summed over the completions
+}
+
+// OpenAges are the open todos and how old they are on average
+type OpenAges struct {
+	Open       int
+	AverageAge time.Duration // 0 without open todos
+}
+
+// one slot of the ring, for the hour it's tagged with
+type hourSlot struct {
+	hour      int64 // unix hours
+	created   int32
+	completed int32
+	timeToDo  int64 // seconds
+}
+
+// activityCounters are the store-wide counters, updated by the shards under their own lock
+type activityCounters struct {
+	mu       sync.Mutex
+	hours    []hourSlot                     // AnalyticsHours slots, made on first use
+	open     int64                          // open todos
+	openBorn int64                          // sum of their creation times, unix seconds
+	tags     map[string]map[int64]*hourSlot // per tag, by unix hour, the hours with anything in them
+}
+
+
+// slot returns the slot of t's hour, emptied if it last held an older one (caller holds a.mu)
+func (a *activityCounters) slot(t time.Time) *hourSlot {
+	if a.hours == nil {
+		a.hours = make([]hourSlot, AnalyticsHours)
+	}
+	hour := t.Unix() / 3600
+	s := &a.hours[(hour%AnalyticsHours+AnalyticsHours)%AnalyticsHours]
+	if s.hour != hour {
+		*s = hourSlot{hour: hour}
+	}
+	return s
+}
+
+
+// tagSlot returns the slot of t's hour for tag, dropping the tag's hours no longer kept when it
+// starts a new one (caller holds a.mu)
+func (a *activityCounters) tagSlot(tag string, t time.Time) *hourSlot {
+
+	if a.tags == nil {
+		a.tags = make(map[string]map[int64]*hourSlot)
+	}
+	hours := a.tags[tag]
+	if hours == nil {
+		hours = make(map[int64]*hourSlot)
+		a.tags[tag] = hours
+	}
+	hour := t.Unix() / 3600
+	s := hours[hour]
+	if s == nil {
+		for h := range hours {
+			if h <= hour-AnalyticsHours {
+				delete(hours, h)
+			}
+		}
+		s = &hourSlot{hour: hour}
+		hours[hour] = s
+	}
+	return s
+}
+
+
+// changed counts a todo going from wasDone (nil: it didn't exist) to isDone (nil: removed), at now,
+// created at born and tagged with tags
+func (a *activityCounters) changed(wasDone, isDone *bool, tags []string, born, now time.Time) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	wasOpen := wasDone != nil && !*wasDone
+	isOpen := isDone != nil && !*isDone
+	switch {
+	case wasOpen && !isOpen:
+		a.open--
+		a.openBorn -= born.Unix()
+	case !wasOpen && isOpen:
+		a.open++
+		a.openBorn += born.Unix()
+	}
+
+	if wasDone == nil && isDone != nil {
+		a.slot(now).created++
+		for _, tag := range tags {
+			a.tagSlot(tag, now).created++
+		}
+	}
+	if wasOpen && isDone != nil && *isDone {
+		timeToDo := int64(max(now.Sub(born), 0) / time.Second)
+		s := a.slot(now)
+		s.completed++
+		s.timeToDo += timeToDo
+		for _, tag := range tags {
+			s := a.tagSlot(tag, now)
+			s.completed++
+			s.timeToDo += timeToDo
+		}
+	}
+}
+
+
+// Activity returns the hours from from up to to that are still kept and had anything in them,
+// oldest first
+func (s *Store) Activity(from, to time.Time) []HourCount {
+
+	a := &s.stats.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	first, last := from.Unix()/3600, to.Unix()/3600
+	var hours []HourCount
+	for _, slot := range a.hours {
+		if slot.hour < first || slot.hour > last || (slot.created == 0 && slot.completed == 0) {
+			continue
+		}
+		hours = append(hours, HourCount{
+			Hour:      time.Unix(slot.hour*3600, 0).UTC(),
+			Created:   int(slot.created),
+			Completed: int(slot.completed),
+			TimeToDo:  time.Duration(slot.timeToDo) * time.Second,
+		})
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Hour.Before(hours[j].Hour) })
+	return hours
+}
+
+
+// TagActivity adds up each tag's hours that start from from up to to and are still kept, for the
+// tags that had anything in them
+func (s *Store) TagActivity(from, to time.Time) map[string]TagActivity {
+
+	a := &s.stats.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	first, last := (from.Unix()+3599)/3600, to.Unix()/3600
+	out := map[string]TagActivity{}
+	for tag, hours := range a.tags {
+		var sum TagActivity
+		for hour, slot := range hours {
+			if hour < first || hour > last || hour <= last-AnalyticsHours {
+				continue
+			}
+			sum.Created += int(slot.created)
+			sum.Completed += int(slot.completed)
+			sum.TimeToDo += time.Duration(slot.timeToDo) * time.Second
+		}
+		if sum.Created > 0 || sum.Completed > 0 {
+			out[tag] = sum
+		}
+	}
+	return out
+}
+
+
+// OpenAges returns how many todos are open and their average age as of now
+func (s *Store) OpenAges(now time.Time) OpenAges {
+
+	a := &s.stats.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.open <= 0 {
+		return OpenAges{}
+	}
+	born := time.Unix(a.openBorn/a.open, 0)
+	return OpenAges{Open: int(a.open), AverageAge: max(now.Sub(born), 0).Truncate(time.Second)}
+}
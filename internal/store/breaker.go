@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context" // for the replicator interface
+	"errors"  // for telling contention from failures
+	"sync"    // for guarding the breaker
+	"time"    // for the cool-down
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for the unavailable error
+)
+
+// a Breaker stands between the store and a Replicator whose backend may go away: after a few
+// failures in a row it stops calling it (it's open) and fails changes straight away, saying when
+// to try again, instead of every change waiting out the backend's timeouts under its shard lock.
+// once the cool-down is over the next change goes through as a probe (half-open): it closes the
+// breaker if it succeeds, and opens it again for another cool-down if not
+
+// what a Breaker's state is
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// ErrContended marks a replicator error about the one change rather than the backend, e.g.
+// another copy changed the todo first; a Breaker doesn't count it as a failure
+var ErrContended = errors.New("contended")
+
+// Breaker is a Replicator guarding another, see NewBreaker
+type Breaker struct {
+	next      Replicator
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int       // in a row
+	openUntil time.Time // end of the cool-down, once open
+	probing   bool      // the probe is under way
+	trips     int       // times it opened
+}
+
+// BreakerStatus is how a Breaker stands
+type BreakerStatus struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"` // in a row
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+	Trips     int        `json:"trips"`
+}
+
+
+// NewBreaker guards next: threshold failures in a row open the breaker for cooldown
+func NewBreaker(next Replicator, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{next: next, threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+
+// Replicate hands m to the guarded replicator, unless the breaker is open
+func (b *Breaker) Replicate(ctx context.Context, m Mutation) error {
+
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = b.next.Replicate(ctx, m)
+	b.done(probe, err)
+	return err
+}
+
+
+// allow lets a change through, as the probe once the cool-down is over, or fails it
+func (b *Breaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	wait := time.Until(b.openUntil)
+	if wait <= 0 && !b.probing {
+		b.probing = true
+		return true, nil
+	}
+	if wait <= 0 {
+		// the probe's answer is a moment away
+		wait = time.Second
+	}
+	return false, model.Unavailable("the store's backend is failing, not trying it for now", wait)
+}
+
+
+// done counts a change's outcome: a success closes the breaker, a failure may open it
+func (b *Breaker) done(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if err == nil || errors.Is(err, ErrContended) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures == b.threshold || probe {
+		b.trips++
+	}
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+
+// Status reports the breaker's state
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStatus{State: BreakerClosed, Failures: b.failures, Trips: b.trips}
+	switch {
+	case b.failures < b.threshold:
+	case b.probing || !time.Now().Before(b.openUntil):
+		s.State = BreakerHalfOpen
+	default:
+		until := b.openUntil
+		s.State, s.OpenUntil = BreakerOpen, &until
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"sync"        // for guarding the manual clock
+	"sync/atomic" // for the id counter
+	"time"        // for timestamps
+)
+
+// the store asks these for the time and for new ids, instead of calling time.Now and counting
+// itself, so tests can freeze time and expect exact ids, and other id schemes can be plugged in
+
+// Clock tells the store what time it is: event times, modification times for conditional GETs
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator hands out todo ids; each has to be unique for as long as the store lives, and
+// may be called from many goroutines at once
+type IDGenerator interface {
+	NextID() int
+}
+
+// Option configures New
+type Option func(*Store)
+
+// SystemClock is the real time
+type SystemClock struct{}
+
+// ManualClock stands still until it's moved, for tests
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Sequence hands out 1, 2, 3, ... (the default)
+type Sequence struct {
+	last atomic.Int64
+}
+
+
+// WithClock makes the store take its time from c
+func WithClock(c Clock) Option {
+	return func(s *Store) {
+		s.clock = c
+	}
+}
+
+
+// WithIDGenerator makes the store take todo ids from g
+func WithIDGenerator(g IDGenerator) Option {
+	return func(s *Store) {
+		s.ids = g
+	}
+}
+
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+
+// NewManualClock returns a clock showing t
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+
+// Now returns the time the clock was set to
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+
+// Set moves the clock to t
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+
+// NextID returns the id after the last one handed out
+func (q *Sequence) NextID() int {
+	return int(q.last.Add(1))
+}
+
+
+// observe counts id as handed out, for ids that came from another copy of the store (see Apply)
+func (q *Sequence) observe(id int) {
+	for {
+		last := q.last.Load()
+		if int64(id) <= last || q.last.CompareAndSwap(last, int64(id)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context" // for the deletes
+	"sort"    // for stable order
+	"strings" // for matching actor names
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for todos and revisions
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for store spans
+)
+
+// what the store knows about one actor, to hand it over or forget it: the todos they created,
+// and the changes they made, in the todos' histories and in the change log. actor names are
+// matched without regard to case, as a client may send "Alice" one day and "alice" the next
+
+
+// CreatedBy returns the todos actor created that are still there, by id
+func (s *Store) CreatedBy(ctx context.Context, actor string) []model.Todo {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for the store locks
+	_, span := tracing.Start(ctx, "store.created_by", tracing.KindInternal)
+	defer span.End()
+
+	s.rlockShards()
+	defer s.runlockShards()
+
+	todos := []model.Todo{}
+	for i := range s.shards {
+		for id := range s.shards[i].byCreator[strings.ToLower(actor)] {
+			todos = append(todos, s.shards[i].todos[id])
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos
+}
+
+
+// RevisionsBy returns the versions actor stored that are still kept, by todo and revision
+func (s *Store) RevisionsBy(ctx context.Context, actor string) []model.Revision {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for the store locks
+	_, span := tracing.Start(ctx, "store.revisions_by", tracing.KindInternal)
+	defer span.End()
+
+	s.rlockShards()
+	defer s.runlockShards()
+
+	revisions := []model.Revision{}
+	for i := range s.shards {
+		for _, versions := range s.shards[i].history {
+			for _, v := range versions {
+				if strings.EqualFold(v.Actor, actor) {
+					revisions = append(revisions, v)
+				}
+			}
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		if revisions[i].Todo.ID != revisions[j].Todo.ID {
+			return revisions[i].Todo.ID < revisions[j].Todo.ID
+		}
+		return revisions[i].Rev < revisions[j].Rev
+	})
+	return revisions
+}
+
+
+// EraseActor deletes the todos actor created for good, trashed ones too, and records everything
+// else they did as done by as: in the histories of the todos left, the trash and the change log,
+// where the deleted todos' events lose their titles and due dates too. a todo an on-delete hook
+// keeps is only renamed like the rest. it returns the ids of the deleted todos and how many
+// revisions and events were renamed, or a write gate's refusal, before anything is changed
+func (s *Store) EraseActor(ctx context.Context, actor, as string) (deleted []int, renamed int, err error) {
+
+	s = s.Tenant(ctx)
+	if err := s.hooks.runWriteGates(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	// trace the whole erasure
+	ctx, span := tracing.Start(ctx, "store.erase_actor", tracing.KindInternal)
+	defer span.End()
+
+	// deleted todos go to the trash, which is emptied of the actor's below
+	for _, todo := range s.CreatedBy(ctx, actor) {
+		s.Delete(ctx, todo.ID)
+	}
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		for _, versions := range shard.history {
+			renamed += renameActor(versions, actor, as)
+		}
+		for id := range shard.byCreator[strings.ToLower(actor)] {
+			shard.setCreator(id, as)
+		}
+		for id, t := range shard.trash {
+			if strings.EqualFold(t.creator, actor) {
+				delete(shard.trash, id)
+				deleted = append(deleted, id)
+				continue
+			}
+			renamed += renameActor(t.history, actor, as)
+			if strings.EqualFold(t.deletedBy, actor) {
+				t.deletedBy = as
+				shard.trash[id] = t
+			}
+		}
+		shard.mu.Unlock()
+	}
+	sort.Ints(deleted)
+	return deleted, renamed + s.hub.eraseActor(actor, as, deleted), nil
+}
+
+
+// renameActor renames actor to as in versions, returning how many it renamed
+func renameActor(versions []model.Revision, actor, as string) int {
+	renamed := 0
+	for i := range versions {
+		if strings.EqualFold(versions[i].Actor, actor) {
+			versions[i].Actor = as
+			renamed++
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"strings" // for matching actor names
+	"sync"    // for guarding the subscriber set
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for change events
+)
+
+// how many events a subscriber may fall behind before it starts missing them
+const subscriberBuffer = 64
+
+// Hub fans out store events to every subscriber and keeps a log of recent ones
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[chan model.Event]struct{}
+	seq     uint64        // sequence number of the last published event
+	log     []model.Event // most recent events, oldest first
+	changed chan struct{} // closed (and replaced) on every publish
+	limit   int           // how many past events are kept for catch-up by sequence number
+}
+
+
+// newHub returns a hub keeping the last logSize events (at least one)
+func newHub(logSize int) *Hub {
+	return &Hub{subs: make(map[chan model.Event]struct{}), changed: make(chan struct{}), limit: max(logSize, 1)}
+}
+
+
+// Subscribe returns a channel receiving every future event
+func (h *Hub) Subscribe() chan model.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan model.Event, subscriberBuffer)
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+
+// Unsubscribe stops delivery and closes the channel
+func (h *Hub) Unsubscribe(ch chan model.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+
+// Publish numbers e, appends it to the change log and delivers it to every subscriber
+// without blocking; a subscriber whose buffer is full misses the event rather than
+// stalling the store (it can catch up from the log by sequence number)
+func (h *Hub) Publish(e model.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// next sequence number
+	h.seq++
+	e.Seq = h.seq
+
+	// keep the log bounded. trimming only once it holds twice the limit copies each
+	// event once instead of copying the whole log on every publish
+	h.log = append(h.log, e)
+	if len(h.log) >= 2*h.limit {
+		h.log = append(h.log[:0:0], h.log[len(h.log)-h.limit:]...)
+	}
+
+	// wake up everyone waiting for a change
+	close(h.changed)
+	h.changed = make(chan struct{})
+
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+
+// Since returns logged events with Seq > seq plus a channel closed on the next publish.
+// ok is false when events after seq have already dropped out of the log.
+func (h *Hub) Since(seq uint64) (events []model.Event, changed <-chan struct{}, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// only the most recent limit events count, the rest is waiting to be trimmed
+	log := h.log[max(0, len(h.log)-h.limit):]
+
+	// the client missed events that are no longer in the log
+	if len(log) > 0 && seq+1 < log[0].Seq {
+		return nil, h.changed, false
+	}
+
+	for i, e := range log {
+		if e.Seq > seq {
+			events = append(events, log[i:]...)
+			break
+		}
+	}
+	return events, h.changed, true
+}
+
+
+// Recent returns up to the n most recent logged events, oldest first
+func (h *Hub) Recent(n int) []model.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	log := h.log[max(0, len(h.log)-min(h.limit, n)):]
+	return append([]model.Event(nil), log...)
+}
+
+
+// version finds revision rev of a todo in the log, if it's still there
+func (h *Hub) version(id, rev int) (model.Todo, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	log := h.log[max(0, len(h.log)-h.limit):]
+	for i := len(log) - 1; i >= 0; i-- {
+		if e := log[i]; e.Todo.ID == id && e.Todo.Rev == rev && e.Type != model.EventDeleted {
+			return e.Todo, true
+		}
+	}
+	return model.Todo{}, false
+}
+
+
+// LastSeq returns the sequence number of the most recent event
+func (h *Hub) LastSeq() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+
+// eraseActor renames actor to as in the logged events and strips the todos in erased down to
+// their id, revision and done flag, returning how many events were renamed
+func (h *Hub) eraseActor(actor, as string, erased []int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	gone := make(map[int]bool, len(erased))
+	for _, id := range erased {
+		gone[id] = true
+	}
+	renamed := 0
+	for i := range h.log {
+		e := &h.log[i]
+		if strings.EqualFold(e.Actor, actor) {
+			e.Actor = as
+			renamed++
+		}
+		if gone[e.Todo.ID] {
+			e.Todo = model.Todo{ID: e.Todo.ID, Rev: e.Todo.Rev, Done: e.Todo.Done}
+		}
+	}
+	return renamed
+}
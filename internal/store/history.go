@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context" // for the actor of a change
+	"errors"  // for restores that change nothing
+	"fmt"     // for missing revisions
+	"time"    // for revision times
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for revisions
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for store spans
+)
+
+// every version of a todo is kept with it, the latest ones up to WithHistorySize, along with
+// when it was stored and by whom. the actor comes from the context of the call that stored it;
+// calls without one (background jobs, imports run at startup) are the "system"
+
+// versions kept per todo unless told otherwise
+const DefaultHistorySize = 100
+
+// actor of calls whose context names none
+const SystemActor = "system"
+
+// context key of the actor
+type actorKey struct{}
+
+
+// WithHistorySize keeps the last n versions of each todo (at least 1, the current one)
+func WithHistorySize(n int) Option {
+	return func(s *Store) {
+		s.historySize = max(n, 1)
+	}
+}
+
+
+// WithActor returns a context whose store calls are recorded as made by actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+
+// ActorFrom returns the actor WithActor put in ctx, or SystemActor
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+
+// record adds a version to the todo's history, dropping the oldest beyond the limit (caller
+// holds s.mu for writing)
+func (s *storeShard) record(todo model.Todo, at time.Time, actor string) {
+	versions := append(s.history[todo.ID], model.Revision{Rev: todo.Rev, Time: at, Actor: actor, Todo: todo})
+	if len(versions) > s.keep {
+		versions = append([]model.Revision(nil), versions[len(versions)-s.keep:]...)
+	}
+	s.history[todo.ID] = versions
+}
+
+
+// version returns revision rev of a todo from its history (caller holds s.mu)
+func (s *storeShard) version(id, rev int) (model.Todo, bool) {
+	for _, v := range s.history[id] {
+		if v.Rev == rev {
+			return v.Todo, true
+		}
+	}
+	return model.Todo{}, false
+}
+
+
+// Revisions returns the versions of a todo still kept, oldest first, the current one last
+// (model.ErrNotFound if there is no such todo)
+func (s *Store) Revisions(ctx context.Context, id int) ([]model.Revision, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for the store lock
+	_, span := tracing.Start(ctx, "store.revisions", tracing.KindInternal)
+	defer span.End()
+
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	versions, exists := shard.history[id]
+	if !exists {
+		return nil, notFound(id)
+	}
+	return append([]model.Revision(nil), versions...), nil
+}
+
+
+// Revision returns one version of a todo: model.ErrNotFound if there is no such todo, or the
+// revision was never stored or is no longer kept
+func (s *Store) Revision(ctx context.Context, id, rev int) (model.Revision, error) {
+
+	versions, err := s.Revisions(ctx, id)
+	if err != nil {
+		return model.Revision{}, err
+	}
+	for _, v := range versions {
+		if v.Rev == rev {
+			return v, nil
+		}
+	}
+	return model.Revision{}, fmt.Errorf("todo %d revision %d: %w", id, rev, model.ErrNotFound)
+}
+
+
+// Restore sets a todo's fields back to revision rev, stored as a new revision (model.ErrNotFound
+// if there is no such todo or the revision isn't kept). a todo already as it was is left alone
+func (s *Store) Restore(ctx context.Context, id, rev int) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.restore", tracing.KindInternal)
+	defer span.End()
+
+	shard := s.shardFor(id)
+	unchanged := errors.New("unchanged")
+	var current model.Todo
+	todo, err := s.update(ctx, id, func(todo model.Todo) (model.Todo, error) {
+		old, ok := shard.version(id, rev)
+		if !ok {
+			return model.Todo{}, fmt.Errorf("todo %d revision %d: %w", id, rev, model.ErrNotFound)
+		}
+		if todo.SameFields(old) {
+			current = todo
+			return model.Todo{}, unchanged
+		}
+		todo.Title, todo.Done, todo.Due = old.Title, old.Done, old.Due
+		return todo, nil
+	})
+	if errors.Is(err, unchanged) {
+		return current, nil
+	}
+	return todo, err
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context" // for the caller's context
+	"sync"    // for guarding the hook lists
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// lifecycle hooks let a program embedding the store add its own rules. they run for every
+// caller (REST, RPC, gRPC, GraphQL, imports, chat commands), in the order they were registered,
+// and outside the shard locks, so a hook may read the store. a hook error is returned by the
+// store call as it is: model.Reject makes a veto the API answers with 422
+
+// BeforeCreateHook sees a draft before it's validated and stored; it may change it, or veto the create
+type BeforeCreateHook func(ctx context.Context, draft *model.Todo) error
+
+// AfterCompleteHook is told about a todo that went from open to done
+type AfterCompleteHook func(ctx context.Context, todo model.Todo)
+
+// OnDeleteHook sees a todo about to be deleted and may veto the delete. evictions
+// (-max-todos) don't ask: they only drop done todos, and keep them in the archive
+type OnDeleteHook func(ctx context.Context, todo model.Todo) error
+
+// WriteGate is asked before every change to the todos and the trash, whichever call makes it, and
+// may refuse them all for a while (e.g. maintenance); unlike the other hooks it runs under the
+// todo's shard lock, so it mustn't use the store. changes other copies replicated aren't asked
+type WriteGate func(ctx context.Context) error
+
+// hooks are a store's registered hooks
+type hooks struct {
+	mu            sync.RWMutex
+	beforeCreate  []BeforeCreateHook
+	afterComplete []AfterCompleteHook
+	onDelete      []OnDeleteHook
+	writeGates    []WriteGate
+}
+
+
+// BeforeCreate registers h to run before every create
+func (s *Store) BeforeCreate(h BeforeCreateHook) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.beforeCreate = append(s.hooks.beforeCreate, h)
+}
+
+
+// AfterComplete registers h to run after a todo is completed
+func (s *Store) AfterComplete(h AfterCompleteHook) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.afterComplete = append(s.hooks.afterComplete, h)
+}
+
+
+// OnDelete registers h to run before every delete
+func (s *Store) OnDelete(h OnDeleteHook) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onDelete = append(s.hooks.onDelete, h)
+}
+
+
+// GateWrites registers g to be asked before every change
+func (s *Store) GateWrites(g WriteGate) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.writeGates = append(s.hooks.writeGates, g)
+}
+
+
+// runBeforeCreate runs the before-create hooks on draft, stopping at the first veto
+func (h *hooks) runBeforeCreate(ctx context.Context, draft *model.Todo) error {
+
+	h.mu.RLock()
+	list := h.beforeCreate
+	h.mu.RUnlock()
+
+	for _, hook := range list {
+		if err := hook(ctx, draft); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+// runAfterComplete runs the after-complete hooks
+func (h *hooks) runAfterComplete(ctx context.Context, todo model.Todo) {
+
+	h.mu.RLock()
+	list := h.afterComplete
+	h.mu.RUnlock()
+
+	for _, hook := range list {
+		hook(ctx, todo)
+	}
+}
+
+
+// runOnDelete runs the on-delete hooks, stopping at the first veto
+func (h *hooks) runOnDelete(ctx context.Context, todo model.Todo) error {
+
+	h.mu.RLock()
+	list := h.onDelete
+	h.mu.RUnlock()
+
+	for _, hook := range list {
+		if err := hook(ctx, todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+// runWriteGates asks the write gates, stopping at the first refusal
+func (h *hooks) runWriteGates(ctx context.Context) error {
+
+	h.mu.RLock()
+	list := h.writeGates
+	h.mu.RUnlock()
+
+	for _, gate := range list {
+		if err := gate(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package store
+
+import (
+	"context" // for tracing store calls
+	"slices"  // for list names once
+	"sort"    // for stable list order
+	"strings" // for title search
+	"time"    // for due date buckets
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for todos
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for store spans
+)
+
+// secondary indexes on each store shard, kept in step with the shard's todos map under the
+// same lock, so filtered lists only visit matching todos instead of scanning every one.
+// done status, due date, list, tags and creator are indexed; title search scans what the other
+// indexes leave.
+
+// seconds per due date bucket (one UTC day)
+const dueBucketSeconds = 24 * 60 * 60
+
+// set of todo ids
+type idSet map[int]struct{}
+
+// shardIndex is the per-shard index state, embedded in storeShard
+type shardIndex struct {
+	byDone    [2]idSet         // [0] open, [1] done
+	byDueDay  map[int64]idSet  // UTC day number -> ids; todos without a due date are in no bucket
+	byList    map[string]idSet // list name -> ids; todos on no list are in none
+	byTag     map[string]idSet // tag -> ids
+	byCreator map[string]idSet // lowercase creator -> ids, kept with creators by setCreator
+}
+
+// Filter narrows Find; zero fields match everything
+type Filter struct {
+	Done      *bool      // only open (false) or only done (true) todos
+	HasDue    bool       // only todos with a due date
+	DueAfter  *time.Time // due at or after this time (implies HasDue)
+	DueBefore *time.Time // due strictly before this time (implies HasDue)
+	Text      string     // only todos whose title contains this, ignoring case
+	List      string     // only todos on this list
+	Tag       string     // only todos with this tag
+	CreatedBy string     // only todos this actor created, ignoring case
+}
+
+
+// newShardIndex allocates empty indexes
+func newShardIndex() shardIndex {
+	return shardIndex{byDone: [2]idSet{{}, {}}, byDueDay: make(map[int64]idSet), byList: make(map[string]idSet), byTag: make(map[string]idSet), byCreator: make(map[string]idSet)}
+}
+
+
+// dueBucket returns the UTC day number a due date falls in (floor, also before 1970)
+func dueBucket(t time.Time) int64 {
+	day := t.Unix() / dueBucketSeconds
+	if t.Unix()%dueBucketSeconds < 0 {
+		day--
+	}
+	return day
+}
+
+
+// addID puts id in the set for key, making it when it's the first
+func addID[K comparable](sets map[K]idSet, key K, id int) {
+	if sets[key] == nil {
+		sets[key] = idSet{}
+	}
+	sets[key][id] = struct{}{}
+}
+
+
+// dropID takes id out of the set for key, and drops the set when it's left empty
+func dropID[K comparable](sets map[K]idSet, key K, id int) {
+	delete(sets[key], id)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+
+// doneSlot maps done to its byDone index
+func doneSlot(done bool) int {
+	if done {
+		return 1
+	}
+	return 0
+}
+
+
+// put stores todo in the shard as its next revision, made by actor, and updates the indexes, the
+// creation and modification times, the creator and the history, returning it as stored (caller
+// holds s.mu for writing)
+func (s *storeShard) put(todo model.Todo, actor string) model.Todo {
+	todo.Rev = 1
+	now := s.clock.Now()
+	var wasDone *bool
+	if old, exists := s.todos[todo.ID]; exists {
+		s.unindex(old)
+		todo.Rev = old.Rev + 1
+		wasDone = &old.Done
+	} else if versions := s.history[todo.ID]; len(versions) > 0 {
+		// back from the trash, with its history, creation time and creator
+		todo.Rev = versions[len(versions)-1].Rev + 1
+	} else {
+		s.created[todo.ID] = now
+		s.setCreator(todo.ID, actor)
+	}
+	s.stats.activity.changed(wasDone, &todo.Done, todo.Tags, s.created[todo.ID], now)
+	s.todos[todo.ID] = todo
+	s.modified[todo.ID] = now
+	s.record(todo, now, actor)
+	s.stats.touch(now)
+
+	s.byDone[doneSlot(todo.Done)][todo.ID] = struct{}{}
+	s.stats.total.Add(1)
+	if todo.Done {
+		s.stats.done.Add(1)
+	}
+	if todo.Due != nil {
+		s.stats.withDue.Add(1)
+		addID(s.byDueDay, dueBucket(*todo.Due), todo.ID)
+	}
+	if todo.List != "" {
+		addID(s.byList, todo.List, todo.ID)
+	}
+	for _, tag := range todo.Tags {
+		addID(s.byTag, tag, todo.ID)
+	}
+	s.stats.tags.count(todo, 1)
+	return todo
+}
+
+
+// remove deletes a todo from the shard and its indexes (caller holds s.mu for writing)
+func (s *storeShard) remove(id int) {
+	if old, exists := s.todos[id]; exists {
+		s.unindex(old)
+		s.stats.activity.changed(&old.Done, nil, old.Tags, s.created[id], s.clock.Now())
+		delete(s.todos, id)
+		delete(s.modified, id)
+		delete(s.created, id)
+		delete(s.history, id)
+		s.dropCreator(id)
+		s.stats.touch(s.clock.Now())
+	}
+}
+
+
+// setCreator records who created a todo, in creators and its index (caller holds s.mu for writing)
+func (s *storeShard) setCreator(id int, actor string) {
+	s.dropCreator(id)
+	s.creators[id] = actor
+	addID(s.byCreator, strings.ToLower(actor), id)
+}
+
+
+// dropCreator forgets who created a todo (caller holds s.mu for writing)
+func (s *storeShard) dropCreator(id int) {
+	if creator, ok := s.creators[id]; ok {
+		dropID(s.byCreator, strings.ToLower(creator), id)
+		delete(s.creators, id)
+	}
+}
+
+
+// unindex drops todo's index entries, and empty buckets with them
+func (s *storeShard) unindex(todo model.Todo) {
+	delete(s.byDone[doneSlot(todo.Done)], todo.ID)
+	s.stats.total.Add(-1)
+	if todo.Done {
+		s.stats.done.Add(-1)
+	}
+	if todo.Due != nil {
+		s.stats.withDue.Add(-1)
+		dropID(s.byDueDay, dueBucket(*todo.Due), todo.ID)
+	}
+	if todo.List != "" {
+		dropID(s.byList, todo.List, todo.ID)
+	}
+	for _, tag := range todo.Tags {
+		dropID(s.byTag, tag, todo.ID)
+	}
+	s.stats.tags.count(todo, -1)
+}
+
+
+// dueFiltered reports whether the filter restricts due dates at all
+func (f Filter) dueFiltered() bool {
+	return f.HasDue || f.DueAfter != nil || f.DueBefore != nil
+}
+
+
+// matches checks a todo against every condition of the filter
+func (f Filter) matches(todo model.Todo) bool {
+	if f.Done != nil && todo.Done != *f.Done {
+		return false
+	}
+	if f.dueFiltered() && todo.Due == nil {
+		return false
+	}
+	if f.DueAfter != nil && todo.Due.Before(*f.DueAfter) {
+		return false
+	}
+	if f.DueBefore != nil && !todo.Due.Before(*f.DueBefore) {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(todo.Title), strings.ToLower(f.Text)) {
+		return false
+	}
+	if f.List != "" && todo.List != f.List {
+		return false
+	}
+	if f.Tag != "" && !todo.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+
+// bucketInRange reports whether any time in bucket day could satisfy the due bounds
+func (f Filter) bucketInRange(day int64) bool {
+	start, end := day*dueBucketSeconds, (day+1)*dueBucketSeconds
+	if f.DueAfter != nil && end <= f.DueAfter.Unix() {
+		return false
+	}
+	if f.DueBefore != nil && start > f.DueBefore.Unix() {
+		return false
+	}
+	return true
+}
+
+
+// candidates calls visit with every todo the indexes can't rule out, and only with todos the
+// filter's creator made: matches can't tell (caller holds s.mu)
+func (s *storeShard) candidates(f Filter, visit func(model.Todo)) {
+
+	// a creator, a list or a tag: the smallest of the sets they name (a few among many, as a rule)
+	created := s.byCreator[strings.ToLower(f.CreatedBy)]
+	var sets []idSet
+	if f.CreatedBy != "" {
+		sets = append(sets, created)
+	}
+	if f.List != "" {
+		sets = append(sets, s.byList[f.List])
+	}
+	if f.Tag != "" {
+		sets = append(sets, s.byTag[f.Tag])
+	}
+	if len(sets) > 0 {
+		ids := slices.MinFunc(sets, func(a, b idSet) int { return len(a) - len(b) })
+		for id := range ids {
+			if _, ok := created[id]; ok || f.CreatedBy == "" {
+				visit(s.todos[id])
+			}
+		}
+		return
+	}
+
+	// due bounds: only buckets in range (there are far fewer days than todos)
+	if f.dueFiltered() {
+		for day, ids := range s.byDueDay {
+			if !f.bucketInRange(day) {
+				continue
+			}
+			for id := range ids {
+				visit(s.todos[id])
+			}
+		}
+		return
+	}
+
+	// done status only: one of the two sets
+	if f.Done != nil {
+		for id := range s.byDone[doneSlot(*f.Done)] {
+			visit(s.todos[id])
+		}
+		return
+	}
+
+	for _, todo := range s.todos {
+		visit(todo)
+	}
+}
+
+
+// Find returns the todos matching f, ordered by id (never nil)
+func (s *Store) Find(ctx context.Context, f Filter) []model.Todo {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.find", tracing.KindInternal)
+	defer span.End()
+
+	// one consistent snapshot, like List
+	list := []model.Todo{}
+	s.rlockShards()
+	for i := range s.shards {
+		s.shards[i].candidates(f, func(todo model.Todo) {
+			if f.matches(todo) {
+				list = append(list, todo)
+			}
+		})
+	}
+	s.runlockShards()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+
+// Lists returns the names of the lists that have todos, sorted (never nil)
+func (s *Store) Lists(ctx context.Context) []string {
+
+	s = s.Tenant(ctx)
+	names := []string{}
+	s.rlockShards()
+	for i := range s.shards {
+		for name := range s.shards[i].byList {
+			names = append(names, name)
+		}
+	}
+	s.runlockShards()
+
+	sort.Strings(names)
+	return slices.Compact(names)
+}
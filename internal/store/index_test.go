@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context" // for store calls
+	"maps"    // for comparing tag counts
+	"slices"  // for comparing ids
+	"testing" // for the tests
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// index tests: Find through each index and their mix, the creator index kept in step with
+// deletes, the trash and erasure, and the per-tag counts with every change
+
+
+// ids are the ids of todos, in order
+func ids(todos []model.Todo) []int {
+	var out []int
+	for _, todo := range todos {
+		out = append(out, todo.ID)
+	}
+	return out
+}
+
+
+// TestFind checks each filter finds the todos it should, whichever index it goes through
+func TestFind(t *testing.T) {
+
+	s := New(DefaultChangeLogSize)
+	as := func(actor string) context.Context { return WithActor(context.Background(), actor) }
+	for _, c := range []struct {
+		actor string
+		todo  model.Todo
+	}{
+		{"alice", model.Todo{Title: "milk", List: "groceries", Tags: []string{"dairy"}}}, // 1
+		{"Alice", model.Todo{Title: "bread", List: "groceries"}},                         // 2
+		{"bob", model.Todo{Title: "cheese", List: "groceries", Tags: []string{"dairy"}}}, // 3
+		{"bob", model.Todo{Title: "taxes", Tags: []string{"home"}}},                      // 4
+		{"", model.Todo{Title: "walk"}},                                                  // 5
+	} {
+		if _, err := s.Create(as(c.actor), c.todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.SetDone(context.Background(), 2, true)
+
+	done := true
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"everything", Filter{}, []int{1, 2, 3, 4, 5}},
+		{"creator in any case", Filter{CreatedBy: "ALICE"}, []int{1, 2}},
+		{"creator nobody is", Filter{CreatedBy: "carol"}, nil},
+		{"creator and list", Filter{CreatedBy: "bob", List: "groceries"}, []int{3}},
+		{"creator and tag", Filter{CreatedBy: "alice", Tag: "dairy"}, []int{1}},
+		{"creator and done", Filter{CreatedBy: "alice", Done: &done}, []int{2}},
+		{"creator and title", Filter{CreatedBy: "bob", Text: "TAX"}, []int{4}},
+		{"list and tag", Filter{List: "groceries", Tag: "dairy"}, []int{1, 3}},
+		{"tag", Filter{Tag: "home"}, []int{4}},
+	}
+	for _, tt := range tests {
+		if got := ids(s.Find(context.Background(), tt.filter)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Find = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+
+// TestCreatorIndex checks the creator index follows deletes, restores from the trash and erasure
+func TestCreatorIndex(t *testing.T) {
+
+	s := New(DefaultChangeLogSize)
+	alice := WithActor(context.Background(), "alice")
+	for _, title := range []string{"one", "two", "three"} {
+		s.Create(alice, model.Todo{Title: title})
+	}
+	s.Create(WithActor(context.Background(), "bob"), model.Todo{Title: "four"})
+	created := func(actor string) []int { return ids(s.Find(context.Background(), Filter{CreatedBy: actor})) }
+
+	steps := []struct {
+		name  string
+		do    func()
+		actor string
+		want  []int
+	}{
+		{"created", func() {}, "alice", []int{1, 2, 3}},
+		{"updated by someone else", func() { s.SetDone(WithActor(context.Background(), "bob"), 1, true) }, "alice", []int{1, 2, 3}},
+		{"deleted", func() { s.Delete(alice, 2) }, "alice", []int{1, 3}},
+		{"back from the trash", func() { s.RestoreTrashed(context.Background(), 2) }, "alice", []int{1, 2, 3}},
+		{"erased as someone else", func() { s.Delete(alice, 3); s.EraseActor(context.Background(), "bob", "alice") }, "alice", []int{1, 2}},
+		{"the erased have none", func() {}, "bob", nil},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := created(step.actor); !slices.Equal(got, step.want) {
+			t.Errorf("%s: created by %s %v, want %v", step.name, step.actor, got, step.want)
+		}
+	}
+	if got := ids(s.CreatedBy(context.Background(), "ALICE")); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("CreatedBy(ALICE) = %v, want [1 2]", got)
+	}
+	for i := range s.shards {
+		for key, set := range s.shards[i].byCreator {
+			if len(set) == 0 {
+				t.Errorf("empty creator set %q left in shard %d", key, i)
+			}
+		}
+	}
+}
+
+
+// TestTagCounts checks the per-tag counts follow tag changes, completion, deletes and the trash
+func TestTagCounts(t *testing.T) {
+
+	s := New(DefaultChangeLogSize)
+	ctx := context.Background()
+	s.Create(ctx, model.Todo{Title: "milk", Tags: []string{"dairy", "fridge"}})
+	s.Create(ctx, model.Todo{Title: "butter", Tags: []string{"dairy"}})
+	s.Create(ctx, model.Todo{Title: "soap"})
+
+	steps := []struct {
+		name string
+		do   func()
+		want map[string]TagCount
+	}{
+		{"created", func() {}, map[string]TagCount{"dairy": {2, 0}, "fridge": {1, 0}}},
+		{"done", func() { s.SetDone(ctx, 2, true) }, map[string]TagCount{"dairy": {2, 1}, "fridge": {1, 0}}},
+		{"retagged", func() { s.Update(ctx, 1, func(todo *model.Todo) { todo.Tags = []string{"cold"} }) }, map[string]TagCount{"cold": {1, 0}, "dairy": {1, 1}}},
+		{"deleted", func() { s.Delete(ctx, 2) }, map[string]TagCount{"cold": {1, 0}}},
+		{"back from the trash", func() { s.RestoreTrashed(ctx, 2) }, map[string]TagCount{"cold": {1, 0}, "dairy": {1, 1}}},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := s.TagCounts(); !maps.Equal(got, step.want) {
+			t.Errorf("%s: %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context" // for the caller's context
+	"time"    // for when a change was made
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos and events
+)
+
+// a store can be one of several copies kept alike, e.g. by a consensus log. with a Replicator,
+// every change to the todos and the trash is handed to it first, under the todo's shard lock,
+// and only made once it returns nil; the other copies Apply the same mutations in the same
+// order. revisions and the trash follow from the mutations, and the times recorded are the
+// first copy's (from just before it made the change), so they're alike too; what isn't replicated is EraseActor's renaming, and each copy's change log numbers its
+// events itself
+
+// what a Mutation does
+const (
+	MutationPut     = "put"     // creates or replaces the todo
+	MutationTrash   = "trash"   // deletes it, to the trash
+	MutationEvict   = "evict"   // removes it (it's archived)
+	MutationUntrash = "untrash" // puts it back from the trash
+	MutationPurge   = "purge"   // drops it from the trash
+)
+
+// Mutation is one change as it's replicated
+type Mutation struct {
+	Op    string     `json:"op"`
+	Todo  model.Todo `json:"todo"` // as it's put (its revision follows from the store), or just its id
+	Actor string     `json:"actor"`
+	Time  time.Time  `json:"time"`
+}
+
+// Replicator takes every change before the store makes it; an error leaves the store as it was
+// and is returned by the store call (so make it a model.ErrUnavailable for the API's 503)
+type Replicator interface {
+	Replicate(ctx context.Context, m Mutation) error
+}
+
+
+// WithReplicator makes the store hand every change to r before making it
+func WithReplicator(r Replicator) Option {
+	return func(s *Store) {
+		s.replicator = r
+	}
+}
+
+
+// replicate hands a change to the replicator, if there is one (caller holds the todo's shard
+// lock); every change passes here first, so it's where the write gates are asked too
+func (s *Store) replicate(ctx context.Context, op string, todo model.Todo) error {
+	if err := s.hooks.runWriteGates(ctx); err != nil {
+		return err
+	}
+	if s.replicator == nil {
+		return nil
+	}
+	return s.replicator.Replicate(ctx, Mutation{Op: op, Todo: todo, Actor: ActorFrom(ctx), Time: s.clock.Now()})
+}
+
+
+// Apply makes a change another copy replicated, announcing it on the hub like the copy did; no
+// hooks run, and nothing is replicated again. a mutation for a todo that isn't there (or, for
+// untrash and purge, isn't in the trash) does nothing
+func (s *Store) Apply(m Mutation) {
+
+	id := m.Todo.ID
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// recorded at the time the other copy made the change
+	shard.clock = NewManualClock(m.Time)
+	defer func() { shard.clock = s.clock }()
+
+	todo, exists := shard.todos[id]
+	event := model.Event{Type: model.EventDeleted, Todo: todo, Time: m.Time, Actor: m.Actor}
+	switch {
+	case m.Op == MutationPut:
+		// ids the other copy handed out are taken here too
+		if q, ok := s.ids.(*Sequence); ok {
+			q.observe(id)
+		}
+		event.Type = model.EventUpdated
+		if !exists {
+			event.Type = model.EventCreated
+		}
+		event.Todo = shard.put(m.Todo, m.Actor)
+	case m.Op == MutationTrash && exists:
+		shard.trashTodo(id, m.Actor)
+		shard.remove(id)
+	case m.Op == MutationEvict && exists:
+		shard.remove(id)
+	case m.Op == MutationUntrash:
+		restored, ok := shard.untrash(id, m.Actor)
+		if !ok {
+			return
+		}
+		event.Type, event.Todo = model.EventCreated, restored
+	case m.Op == MutationPurge:
+		delete(shard.trash, id)
+		return
+	default:
+		return
+	}
+	s.hub.Publish(event)
+}
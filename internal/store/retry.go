@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"   // for the replicator interface
+	"errors"    // for telling transient errors
+	"math/rand" // for jitter
+	"time"      // for the backoff
+)
+
+// a Retrier tries a change again when its Replicator's backend had a blip, so one dropped
+// connection isn't a failed request. only errors the replicator marks ErrTransient are tried
+// again: those where nothing was changed, or where trying again can't change anything twice.
+// the waits grow twice as long each time, each a random part of that (full jitter) so servers
+// that failed together don't come back together. the caller holds the todo's shard lock meanwhile
+
+// longest wait between two attempts
+const maxRetryBackoff = time.Second
+
+// ErrTransient marks a replicator error worth trying again, see Retrier
+var ErrTransient = errors.New("transient")
+
+// Retrier is a Replicator trying another again on transient errors, see NewRetrier
+type Retrier struct {
+	next     Replicator
+	attempts int
+	backoff  time.Duration
+}
+
+
+// NewRetrier makes up to attempts attempts at each change, waiting up to backoff before the
+// second one
+func NewRetrier(next Replicator, attempts int, backoff time.Duration) *Retrier {
+	return &Retrier{next: next, attempts: max(attempts, 1), backoff: max(backoff, time.Millisecond)}
+}
+
+
+// Replicate hands m to the retried replicator until it takes it, fails for good or runs out of
+// attempts; the last error is returned
+func (r *Retrier) Replicate(ctx context.Context, m Mutation) error {
+
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		err := r.next.Replicate(ctx, m)
+		if err == nil || attempt == r.attempts || !errors.Is(err, ErrTransient) {
+			return err
+		}
+
+		wait := time.NewTimer(time.Duration(rand.Int63n(int64(backoff))) + 1)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return err
+		case <-wait.C:
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,623 @@
+// Package store is the in-memory todo store: sharded maps with secondary indexes, counters for
+// stats, modification times for conditional GETs, and an event hub announcing every change.
+package store
+
+import (
+	"context"     // for tracing store calls
+	"fmt"         // for wrapping errors
+	"maps"        // for copying the tag counts
+	"sort"        // for stable list order
+	"sync"        // for shard locks
+	"sync/atomic" // for the counters
+	"time"        // for event and modification timestamps
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for store spans
+)
+
+// store operations on the in-memory todos. the map is split into shards by id, each
+// with its own lock, so writers to different todos don't wait for each other. reads share a
+// shard's lock (RLock); every mutation holds it exclusively and is announced on the event hub
+// in the same critical section, so subscribers see each todo's changes in the order they were applied.
+
+// number of shards; ids are sequential, so id % storeShards spreads them evenly
+const storeShards = 32
+
+// how many past events the hub keeps for catch-up unless told otherwise
+const DefaultChangeLogSize = 10000
+
+// Store holds todos in memory; the zero value is not usable, see New
+type Store struct {
+	shards  [storeShards]storeShard
+	clock   Clock
+	ids     IDGenerator
+	stats   storeStats
+	created time.Time
+	hub     *Hub
+	hooks   *hooks // shared with the tenants' stores
+
+	replicator Replicator // nil unless the store is one of several copies, see replication.go
+
+	historySize int // versions kept per todo, see WithHistorySize
+
+	tenant    string            // the tenant this store is for, "" for the default one
+	tenants   map[string]*Store // the tenants' stores, by name, see tenants.go
+	tenantsMu sync.Mutex
+}
+
+// storeShard is one partition of the todos map
+type storeShard struct {
+	mu       sync.RWMutex
+	todos    map[int]model.Todo       // id -> Todo, written only through put / remove to keep the indexes in step
+	modified map[int]time.Time        // id -> when it was created or last changed, for Last-Modified
+	created  map[int]time.Time        // id -> when it was created, for ranking by age
+	history  map[int][]model.Revision // id -> its versions, oldest first, see history.go
+	creators map[int]string           // id -> the actor who created it, see erasure.go
+	trash    map[int]trashedTodo      // id -> a deleted todo, see trash.go
+	keep     int                      // versions kept per todo
+	stats    *storeStats              // the owning store's counters
+	clock    Clock                    // the owning store's clock
+	shardIndex
+}
+
+// storeStats are the store-wide counters, kept with the indexes so stats never scan. each is
+// exact, but they're read without the shard locks, so a response may combine values from
+// either side of a write
+type storeStats struct {
+	total      atomic.Int64
+	done       atomic.Int64
+	withDue    atomic.Int64
+	modified   atomic.Int64  // when any todo was last created, changed or removed (unix nanoseconds, 0 = never)
+	generation atomic.Uint64 // bumped on every mutation, for caches of store reads
+
+	activity activityCounters // creations, completions and open ages, see analytics.go
+	tags     tagCounters      // todos and done todos per tag
+}
+
+// TagCount is how many todos have a tag, and how many of those are done
+type TagCount struct {
+	Total int64
+	Done  int64
+}
+
+// tagCounters are the per-tag counts, updated by the shards under their own lock
+type tagCounters struct {
+	mu     sync.Mutex
+	counts map[string]TagCount // tags no todo has are dropped
+}
+
+
+// New returns an empty store whose event hub keeps the last changeLogSize events for catch-up.
+// without options it runs on the system clock and numbers todos 1, 2, 3, ...
+func New(changeLogSize int, opts ...Option) *Store {
+
+	s := &Store{clock: SystemClock{}, ids: &Sequence{}, hub: newHub(changeLogSize), hooks: &hooks{}, historySize: DefaultHistorySize}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.created = s.clock.Now()
+
+	for i := range s.shards {
+		s.shards[i].todos = make(map[int]model.Todo)
+		s.shards[i].modified = make(map[int]time.Time)
+		s.shards[i].created = make(map[int]time.Time)
+		s.shards[i].history = make(map[int][]model.Revision)
+		s.shards[i].creators = make(map[int]string)
+		s.shards[i].trash = make(map[int]trashedTodo)
+		s.shards[i].keep = s.historySize
+		s.shards[i].stats = &s.stats
+		s.shards[i].clock = s.clock
+		s.shards[i].shardIndex = newShardIndex()
+	}
+	return s
+}
+
+
+// Now returns the time on the store's clock, for comparing with the times it records
+func (s *Store) Now() time.Time {
+	return s.clock.Now()
+}
+
+
+// Events returns the hub every change is published on
+func (s *Store) Events() *Hub {
+	return s.hub
+}
+
+
+// shardFor returns the shard that owns id
+func (s *Store) shardFor(id int) *storeShard {
+	return &s.shards[uint(id)%storeShards]
+}
+
+
+// rlockShards read-locks every shard (in order) for a consistent view of all todos
+func (s *Store) rlockShards() {
+	for i := range s.shards {
+		s.shards[i].mu.RLock()
+	}
+}
+
+
+// runlockShards releases rlockShards
+func (s *Store) runlockShards() {
+	for i := range s.shards {
+		s.shards[i].mu.RUnlock()
+	}
+}
+
+
+// count adds todo's tags to the per-tag counts, or takes them off for by -1
+func (tc *tagCounters) count(todo model.Todo, by int64) {
+
+	if len(todo.Tags) == 0 {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.counts == nil {
+		tc.counts = make(map[string]TagCount)
+	}
+	for _, tag := range todo.Tags {
+		c := tc.counts[tag]
+		c.Total += by
+		if todo.Done {
+			c.Done += by
+		}
+		if c.Total <= 0 {
+			delete(tc.counts, tag)
+			continue
+		}
+		tc.counts[tag] = c
+	}
+}
+
+
+// touch records a mutation at t (caller holds the shard lock for writing)
+func (st *storeStats) touch(t time.Time) {
+	st.modified.Store(t.UnixNano())
+	st.generation.Add(1)
+}
+
+
+// Create stores a new todo built from draft, assigning the next id. errors: a before-create hook's
+// veto, model.ErrValidation for a draft that doesn't validate, model.ErrConflict if the id
+// generator repeats an id
+func (s *Store) Create(ctx context.Context, draft model.Todo) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.create", tracing.KindInternal)
+	defer span.End()
+
+	// hooks may rewrite the draft, so validate what they leave, with its tags cleaned (and the
+	// store's own copy of them)
+	if err := s.hooks.runBeforeCreate(ctx, &draft); err != nil {
+		return model.Todo{}, err
+	}
+	draft.Tags = model.CleanTags(draft.Tags)
+	if err := draft.Validate(); err != nil {
+		return model.Todo{}, err
+	}
+
+	// a refused write doesn't use up an id (replicate asks the gates again, under the lock)
+	if err := s.hooks.runWriteGates(ctx); err != nil {
+		return model.Todo{}, err
+	}
+
+	// next id without any lock, then only its shard is locked
+	todo := draft
+	todo.ID = s.ids.NextID()
+	shard := s.shardFor(todo.ID)
+
+	// lock coz concurrent access to shared resource
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// a plugged-in id scheme may hand out an id twice: never overwrite a todo
+	if _, exists := shard.todos[todo.ID]; exists {
+		return model.Todo{}, fmt.Errorf("todo %d already exists: %w", todo.ID, model.ErrConflict)
+	}
+
+	// store todo in its shard, once the other copies (if any) have it
+	if err := s.replicate(ctx, MutationPut, todo); err != nil {
+		return model.Todo{}, err
+	}
+	todo = shard.put(todo, ActorFrom(ctx))
+
+	s.hub.Publish(model.Event{Type: model.EventCreated, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return todo, nil
+}
+
+
+// notFound is the error for a missing todo
+func notFound(id int) error {
+	return fmt.Errorf("todo %d: %w", id, model.ErrNotFound)
+}
+
+
+// Complete marks a todo as done (model.ErrNotFound if there is no such todo)
+func (s *Store) Complete(ctx context.Context, id int) (model.Todo, error) {
+	return s.SetDone(ctx, id, true)
+}
+
+
+// SetDone marks a todo as done or open again (model.ErrNotFound if there is no such todo)
+func (s *Store) SetDone(ctx context.Context, id int, done bool) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.update", tracing.KindInternal)
+	defer span.End()
+
+	todo, wasDone, err := s.setDone(ctx, id, done)
+	if err != nil {
+		return model.Todo{}, err
+	}
+
+	// after-complete hooks run once the lock is released
+	if done && !wasDone {
+		s.hooks.runAfterComplete(ctx, todo)
+	}
+	return todo, nil
+}
+
+
+// setDone updates the todo under its shard lock, reporting whether it was done before
+func (s *Store) setDone(ctx context.Context, id int, done bool) (model.Todo, bool, error) {
+
+	// lock the todo's shard before modifying
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// check if todo exists
+	todo, exists := shard.todos[id]
+	if !exists {
+		return model.Todo{}, false, notFound(id)
+	}
+
+	// update todo status
+	wasDone := todo.Done
+	todo.Done = done
+	if err := s.replicate(ctx, MutationPut, todo); err != nil {
+		return model.Todo{}, false, err
+	}
+	todo = shard.put(todo, ActorFrom(ctx))
+
+	s.hub.Publish(model.Event{Type: model.EventUpdated, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return todo, wasDone, nil
+}
+
+
+// SetDue moves a todo's due date (nil clears it), model.ErrNotFound if there is no such todo
+func (s *Store) SetDue(ctx context.Context, id int, due *time.Time) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.update", tracing.KindInternal)
+	defer span.End()
+
+	// lock the todo's shard before modifying
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// check if todo exists
+	todo, exists := shard.todos[id]
+	if !exists {
+		return model.Todo{}, notFound(id)
+	}
+
+	// update due date (put re-indexes it)
+	todo.Due = due
+	if err := s.replicate(ctx, MutationPut, todo); err != nil {
+		return model.Todo{}, err
+	}
+	todo = shard.put(todo, ActorFrom(ctx))
+
+	s.hub.Publish(model.Event{Type: model.EventUpdated, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return todo, nil
+}
+
+
+// Update applies change to a copy of the todo and stores it if it still validates (the id can't
+// change). errors: model.ErrNotFound, model.ErrValidation
+func (s *Store) Update(ctx context.Context, id int, change func(*model.Todo)) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.update", tracing.KindInternal)
+	defer span.End()
+
+	return s.update(ctx, id, func(todo model.Todo) (model.Todo, error) {
+		change(&todo)
+		return todo, nil
+	})
+}
+
+
+// Merge applies a patch made against revision base of the todo. when the todo has moved on
+// since, the fields the patch changes are merged with the ones changed on the server; a field
+// both sides changed to different values is a *model.MergeConflict (model.ErrConflict) with
+// both versions, and nothing is stored. base 0 applies the patch as it is, like Update
+func (s *Store) Merge(ctx context.Context, id, base int, patch model.Patch) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.merge", tracing.KindInternal)
+	defer span.End()
+
+	return s.update(ctx, id, func(current model.Todo) (model.Todo, error) {
+		switch {
+		case base == 0 || base == current.Rev:
+			return patch.Apply(current), nil
+		case base > current.Rev:
+			return model.Todo{}, model.Invalid("rev", "is newer than the todo")
+		}
+
+		// the base version, from the todo's history or else the change log (update holds the shard lock)
+		old, ok := s.shardFor(id).version(id, base)
+		if !ok {
+			old, ok = s.hub.version(id, base)
+		}
+		if !ok {
+			return model.Todo{}, &model.MergeConflict{ID: id, Base: base, Server: current, Client: patch.Apply(current)}
+		}
+		merged, fields := model.MergePatch(old, current, patch)
+		if len(fields) > 0 {
+			return model.Todo{}, &model.MergeConflict{ID: id, Base: base, Fields: fields, Server: current, Client: patch.Apply(old)}
+		}
+		return merged, nil
+	})
+}
+
+
+// update stores what change makes of the todo, under its shard lock; after-complete hooks run
+// once the lock is released
+func (s *Store) update(ctx context.Context, id int, change func(model.Todo) (model.Todo, error)) (model.Todo, error) {
+
+	todo, wasDone, err := func() (model.Todo, bool, error) {
+
+		// lock the todo's shard before modifying
+		shard := s.shardFor(id)
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+
+		// check if todo exists
+		old, exists := shard.todos[id]
+		if !exists {
+			return model.Todo{}, false, notFound(id)
+		}
+
+		todo, err := change(old)
+		if err != nil {
+			return model.Todo{}, false, err
+		}
+		todo.ID = id
+		todo.Tags = model.CleanTags(todo.Tags)
+		if err := todo.Validate(); err != nil {
+			return model.Todo{}, false, err
+		}
+		if err := s.replicate(ctx, MutationPut, todo); err != nil {
+			return model.Todo{}, false, err
+		}
+		todo = shard.put(todo, ActorFrom(ctx))
+
+		s.hub.Publish(model.Event{Type: model.EventUpdated, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+		return todo, old.Done, nil
+	}()
+	if err != nil {
+		return model.Todo{}, err
+	}
+
+	if todo.Done && !wasDone {
+		s.hooks.runAfterComplete(ctx, todo)
+	}
+	return todo, nil
+}
+
+
+// Delete moves a todo to the trash (model.ErrNotFound if there is no such todo, or an on-delete
+// hook's veto)
+func (s *Store) Delete(ctx context.Context, id int) error {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.delete", tracing.KindInternal)
+	defer span.End()
+
+	// on-delete hooks see the todo as it is just before, without the lock held
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	todo, exists := shard.todos[id]
+	shard.mu.RUnlock()
+	if !exists {
+		return notFound(id)
+	}
+	if err := s.hooks.runOnDelete(ctx, todo); err != nil {
+		return err
+	}
+
+	// lock the todo's shard before deleting from it
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// check existence again, another delete may have won the race
+	todo, exists = shard.todos[id]
+	if !exists {
+		return notFound(id)
+	}
+
+	// delete todo, keeping it in the trash
+	if err := s.replicate(ctx, MutationTrash, todo); err != nil {
+		return err
+	}
+	shard.trashTodo(id, ActorFrom(ctx))
+	shard.remove(id)
+
+	s.hub.Publish(model.Event{Type: model.EventDeleted, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return nil
+}
+
+
+// Evict removes a todo if it is still done (it may have been reopened since the caller looked),
+// calling archive with it first; an archive error keeps the todo and is returned
+func (s *Store) Evict(ctx context.Context, id int, archive func(model.Todo) error) (bool, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.evict", tracing.KindInternal)
+	defer span.End()
+
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	todo, exists := shard.todos[id]
+	if !exists || !todo.Done {
+		return false, nil
+	}
+
+	// archive first: a failed write keeps the todo in memory
+	if err := archive(todo); err != nil {
+		return false, err
+	}
+	if err := s.replicate(ctx, MutationEvict, todo); err != nil {
+		return false, err
+	}
+	shard.remove(id)
+
+	// to API clients an evicted todo is gone, same as a delete
+	s.hub.Publish(model.Event{Type: model.EventDeleted, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return true, nil
+}
+
+
+// Get looks up one todo by id (model.ErrNotFound if there is no such todo)
+func (s *Store) Get(ctx context.Context, id int) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.get", tracing.KindInternal)
+	defer span.End()
+
+	// readers share the shard's lock
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	todo, exists := shard.todos[id]
+	if !exists {
+		return model.Todo{}, notFound(id)
+	}
+	return todo, nil
+}
+
+
+// List returns a copy of all todos ordered by id
+func (s *Store) List(ctx context.Context) []model.Todo {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.list", tracing.KindInternal)
+	defer span.End()
+
+	// copy under the read locks, callers use the result without them
+	s.rlockShards()
+	var list []model.Todo
+	for i := range s.shards {
+		for _, todo := range s.shards[i].todos {
+			list = append(list, todo)
+		}
+	}
+	s.runlockShards()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+
+// Count returns how many todos are in the store
+func (s *Store) Count() int {
+	return int(s.stats.total.Load())
+}
+
+
+// Counts returns the number of todos, done todos and todos with a due date, without locking
+func (s *Store) Counts() (total, done, withDue int64) {
+	return s.stats.total.Load(), s.stats.done.Load(), s.stats.withDue.Load()
+}
+
+
+// TagCounts returns the counts per tag, for the tags at least one todo has
+func (s *Store) TagCounts() map[string]TagCount {
+
+	s.stats.tags.mu.Lock()
+	defer s.stats.tags.mu.Unlock()
+	return maps.Clone(s.stats.tags.counts)
+}
+
+
+// Modified returns when the collection last changed; a store starts empty, so
+// an untouched store hasn't changed since it was created
+func (s *Store) Modified() time.Time {
+	if ns := s.stats.modified.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return s.created
+}
+
+
+// TodoModified returns when a todo was created or last changed (model.ErrNotFound if there is no such todo)
+func (s *Store) TodoModified(id int) (time.Time, error) {
+
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	t, exists := shard.modified[id]
+	if !exists {
+		return time.Time{}, notFound(id)
+	}
+	return t, nil
+}
+
+
+// TodoCreated returns when a todo was created (model.ErrNotFound if there is no such todo)
+func (s *Store) TodoCreated(id int) (time.Time, error) {
+
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	t, exists := shard.created[id]
+	if !exists {
+		return time.Time{}, notFound(id)
+	}
+	return t, nil
+}
+
+
+// Generation changes on every mutation, so a value computed from the store is current
+// as long as the generation read before computing it still is
+func (s *Store) Generation() uint64 {
+	return s.stats.generation.Load()
+}
+
+
+// Probe read-locks and releases every shard, returning once no writer holds any of them;
+// read locks wait for a stuck writer but don't block other readers
+func (s *Store) Probe() {
+	s.rlockShards()
+	s.runlockShards()
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context" // for store calls
+	"strconv" // for seeded titles
+	"testing" // for the benchmarks
+	"time"    // for seeded due dates
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos
+)
+
+// benchmarks of the store on its own: go test -run '^$' -bench . -benchmem ./internal/store
+
+
+// seededStore returns a new store of n todos: every 10th done, half with a due date
+func seededStore(n int) *Store {
+
+	s := New(DefaultChangeLogSize)
+	for i := range n {
+		draft := model.Todo{Title: "seed " + strconv.Itoa(i)}
+		if i%2 == 0 {
+			due := time.Now().Add(time.Duration(i) * time.Hour)
+			draft.Due = &due
+		}
+		todo, _ := s.Create(context.Background(), draft)
+		if i%10 == 0 {
+			s.SetDone(context.Background(), todo.ID, true)
+		}
+	}
+	return s
+}
+
+
+// BenchmarkCreate creates todos one after another
+func BenchmarkCreate(b *testing.B) {
+	s := seededStore(0)
+	for b.Loop() {
+		s.Create(context.Background(), model.Todo{Title: "bench"})
+	}
+}
+
+
+// BenchmarkGet looks todos up by id
+func BenchmarkGet(b *testing.B) {
+	s := seededStore(10000)
+	i := 0
+	for b.Loop() {
+		s.Get(context.Background(), 1+i%10000)
+		i++
+	}
+}
+
+
+// BenchmarkGetParallel looks todos up from every CPU at once, across the shards
+func BenchmarkGetParallel(b *testing.B) {
+	s := seededStore(10000)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Get(context.Background(), 1+i%10000)
+			i++
+		}
+	})
+}
+
+
+// BenchmarkUpdateParallel completes and reopens todos from every CPU at once
+func BenchmarkUpdateParallel(b *testing.B) {
+	s := seededStore(10000)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.SetDone(context.Background(), 1+i%10000, i%2 == 0)
+			i++
+		}
+	})
+}
+
+
+// BenchmarkList10k lists all of 10k todos
+func BenchmarkList10k(b *testing.B) {
+	s := seededStore(10000)
+	for b.Loop() {
+		s.List(context.Background())
+	}
+}
+
+
+// BenchmarkFindDue10k filters 10k todos down to the open ones with a due date, through the indexes
+func BenchmarkFindDue10k(b *testing.B) {
+	s := seededStore(10000)
+	open := false
+	for b.Loop() {
+		s.Find(context.Background(), Filter{Done: &open, HasDue: true})
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context" // for the caller's tenant
+	"sort"    // for stable tenant order
+)
+
+// tenants are isolated namespaces in one store. each has a store of its own, made the first time
+// it's used: its own todos and ids, history, trash, counters and event hub, sharing only the
+// clock and the hooks with the default one. every method taking a context works on the store of
+// the tenant WithTenant put in it, so a caller can't reach another tenant's todos by id; the
+// methods without one (Events, Counts, TodoModified, ...) are the store's own, see Tenant
+
+// context key of the tenant
+type tenantKey struct{}
+
+
+// WithTenant returns a context whose store calls work on tenant's todos ("" is the default tenant)
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+
+// TenantFrom returns the tenant WithTenant put in ctx, or "" for the default one
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+
+// Tenant returns the store of ctx's tenant, made on first use; the default tenant's is s itself,
+// and so is a tenant's own store asked again
+func (s *Store) Tenant(ctx context.Context) *Store {
+
+	name := TenantFrom(ctx)
+	if name == "" || s.tenant != "" {
+		return s
+	}
+
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+	if t, ok := s.tenants[name]; ok {
+		return t
+	}
+
+	// ids count from 1 in every tenant, unless they come from a generator of the embedder's
+	ids := s.ids
+	if _, ok := ids.(*Sequence); ok {
+		ids = &Sequence{}
+	}
+	t := New(s.hub.limit, WithClock(s.clock), WithIDGenerator(ids), WithHistorySize(s.historySize))
+	t.tenant, t.hooks = name, s.hooks
+	if s.tenants == nil {
+		s.tenants = make(map[string]*Store)
+	}
+	s.tenants[name] = t
+	return t
+}
+
+
+// Tenants returns the names of the tenants that have a store, sorted
+func (s *Store) Tenants() []string {
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+
+	names := make([]string, 0, len(s.tenants))
+	for name := range s.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context" // for the actor of a restore
+	"fmt"     // for missing trashed todos
+	"sort"    // for stable order
+	"time"    // for deletion times
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model"   // for todos and events
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/tracing" // for store spans
+)
+
+// deleted todos go to the trash, with their history, creation time and creator, until they are
+// restored or purged; evictions don't, they're in the archive. a restored todo keeps its id and
+// history and takes the next revision, announced as created. nothing empties the trash on its
+// own: that's PurgeTrash, which the API's janitor calls for -trash-retention
+
+// trashedTodo is a deleted todo and what's needed to put it back
+type trashedTodo struct {
+	todo      model.Todo
+	history   []model.Revision
+	created   time.Time
+	creator   string
+	deletedAt time.Time
+	deletedBy string
+}
+
+
+// trashTodo keeps a todo about to be removed in the trash (caller holds s.mu for writing)
+func (s *storeShard) trashTodo(id int, actor string) {
+	s.trash[id] = trashedTodo{
+		todo:      s.todos[id],
+		history:   s.history[id],
+		created:   s.created[id],
+		creator:   s.creators[id],
+		deletedAt: s.clock.Now(),
+		deletedBy: actor,
+	}
+}
+
+
+// untrash puts a trashed todo back with its history, creation time and creator, the next
+// revision stored by actor (caller holds s.mu for writing)
+func (s *storeShard) untrash(id int, actor string) (model.Todo, bool) {
+	t, ok := s.trash[id]
+	if !ok {
+		return model.Todo{}, false
+	}
+	delete(s.trash, id)
+	s.history[id], s.created[id] = t.history, t.created
+	s.setCreator(id, t.creator)
+	return s.put(t.todo, actor), true
+}
+
+
+// Trash returns the trashed todos, most recently deleted first
+func (s *Store) Trash(ctx context.Context) []model.Trashed {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for the store locks
+	_, span := tracing.Start(ctx, "store.trash", tracing.KindInternal)
+	defer span.End()
+
+	s.rlockShards()
+	defer s.runlockShards()
+
+	list := []model.Trashed{}
+	for i := range s.shards {
+		for _, t := range s.shards[i].trash {
+			list = append(list, model.Trashed{Todo: t.todo, DeletedAt: t.deletedAt, DeletedBy: t.deletedBy})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].DeletedAt.Equal(list[j].DeletedAt) {
+			return list[i].DeletedAt.After(list[j].DeletedAt)
+		}
+		return list[i].Todo.ID > list[j].Todo.ID
+	})
+	return list
+}
+
+
+// InTrash reports whether the todo id is in the trash
+func (s *Store) InTrash(id int) bool {
+	shard := s.shardFor(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	_, ok := shard.trash[id]
+	return ok
+}
+
+
+// RestoreTrashed puts a trashed todo back (model.ErrNotFound if it isn't in the trash)
+func (s *Store) RestoreTrashed(ctx context.Context, id int) (model.Todo, error) {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store lock
+	_, span := tracing.Start(ctx, "store.restore_trashed", tracing.KindInternal)
+	defer span.End()
+
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	t, ok := shard.trash[id]
+	if !ok {
+		return model.Todo{}, fmt.Errorf("todo %d in the trash: %w", id, model.ErrNotFound)
+	}
+	if err := s.replicate(ctx, MutationUntrash, t.todo); err != nil {
+		return model.Todo{}, err
+	}
+	todo, _ := shard.untrash(id, ActorFrom(ctx))
+
+	s.hub.Publish(model.Event{Type: model.EventCreated, Todo: todo, Time: s.clock.Now(), Actor: ActorFrom(ctx), Traceparent: tracing.FromContext(ctx).Traceparent()})
+	return todo, nil
+}
+
+
+// PurgeTrashed drops a todo from the trash for good (model.ErrNotFound if it isn't in it)
+func (s *Store) PurgeTrashed(ctx context.Context, id int) error {
+
+	s = s.Tenant(ctx)
+
+	shard := s.shardFor(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.trash[id]; !ok {
+		return fmt.Errorf("todo %d in the trash: %w", id, model.ErrNotFound)
+	}
+	if err := s.replicate(ctx, MutationPurge, model.Todo{ID: id}); err != nil {
+		return err
+	}
+	delete(shard.trash, id)
+	return nil
+}
+
+
+// PurgeTrash drops the todos deleted before before from the trash for good, returning their ids
+// (those the replicator refuses stay)
+func (s *Store) PurgeTrash(ctx context.Context, before time.Time) []int {
+
+	s = s.Tenant(ctx)
+
+	// trace time spent waiting for and holding the store locks
+	_, span := tracing.Start(ctx, "store.purge_trash", tracing.KindInternal)
+	defer span.End()
+
+	purged := []int{}
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		for id, t := range shard.trash {
+			if t.deletedAt.Before(before) && s.replicate(ctx, MutationPurge, model.Todo{ID: id}) == nil {
+				delete(shard.trash, id)
+				purged = append(purged, id)
+			}
+		}
+		shard.mu.Unlock()
+	}
+	sort.Ints(purged)
+	return purged
+}
@@ -0,0 +1,288 @@
+// Package tracing records spans and exports them to an OTLP/HTTP collector.
+package tracing
+
+import (
+	"bytes"         // for request bodies
+	"context"       // for carrying the current span
+	"crypto/rand"   // for trace and span ids
+	"encoding/hex"  // for W3C / OTLP id encoding
+	"encoding/json" // for OTLP/JSON payloads
+	"fmt"           // for printing logs to terminal
+	"net/http"      // for the exporter client
+	"strconv"       // for int -> string conversion
+	"strings"       // for header parsing and the collector URL
+	"time"          // for span timing
+)
+
+// OTLP/HTTP collector base URL, set by StartExporter (spans are only recorded when there is one)
+var endpoint string
+
+// OTLP span kinds
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// OTLP status codes
+const (
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// exporter batching limits
+const (
+	spanQueueSize     = 2048
+	spanBatchSize     = 256
+	spanFlushInterval = 2 * time.Second
+)
+
+// Span is one timed operation in a trace
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	status   int
+}
+
+// finished spans waiting to be exported
+var spanQueue = make(chan *Span, spanQueueSize)
+
+// context key for the active span
+type spanKey struct{}
+
+
+// Start begins a child of the span in ctx (or a new root) and returns it in a derived context.
+// returns a nil span when tracing is disabled; all Span methods are safe on nil.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+
+	// tracing off, skip all the work
+	if endpoint == "" {
+		return ctx, nil
+	}
+
+	span := &Span{name: name, kind: kind, start: time.Now(), attrs: map[string]string{}}
+	rand.Read(span.spanID[:])
+
+	// inherit trace id from parent, otherwise start a new trace
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+
+// Enabled reports whether spans are recorded (an OTLP endpoint is set)
+func Enabled() bool {
+	return endpoint != ""
+}
+
+
+// ContextWithSpan returns ctx with span as the parent of spans started from it
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+
+// FromContext returns the active span in ctx, nil when there is none
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+
+// SetName renames the span, for when what it's best named after is only known once it has started
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.name = name
+}
+
+
+// SetAttr attaches a string attribute to the span
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	s.attrs[key] = value
+}
+
+
+// SetStatus sets the OTLP status code (StatusOK or StatusError)
+func (s *Span) SetStatus(code int) {
+	if s == nil {
+		return
+	}
+	s.status = code
+}
+
+
+// Traceparent returns the W3C traceparent header value naming this span
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+
+// Propagate sets the traceparent header of an outgoing request, for the callee to continue the
+// trace; nothing for "" (tracing off, or no trace to continue)
+func Propagate(header http.Header, traceparent string) {
+	if traceparent != "" {
+		header.Set("traceparent", traceparent)
+	}
+}
+
+
+// End finishes the span and hands it to the exporter (dropped if the queue is full)
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.end = time.Now()
+
+	select {
+	case spanQueue <- s:
+	default:
+	}
+}
+
+
+// ParseTraceparent reads a W3C traceparent header ("00-<trace>-<span>-<flags>") into a remote parent span
+func ParseTraceparent(header string) (*Span, bool) {
+
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return nil, false
+	}
+
+	parent := &Span{}
+	if _, err := hex.Decode(parent.traceID[:], []byte(parts[1])); err != nil {
+		return nil, false
+	}
+	if _, err := hex.Decode(parent.spanID[:], []byte(parts[2])); err != nil {
+		return nil, false
+	}
+
+	// all-zero ids are invalid per spec
+	if parent.traceID == [16]byte{} || parent.spanID == [8]byte{} {
+		return nil, false
+	}
+
+	return parent, true
+}
+
+
+// StartExporter turns tracing on and ships finished spans to the OTLP collector at
+// collector in batches, reporting them as serviceName (call before serving; empty leaves tracing off)
+func StartExporter(collector, serviceName string) {
+
+	// nothing to export to
+	if collector == "" {
+		return
+	}
+	endpoint = collector
+
+	url := strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	go func() {
+		batch := make([]*Span, 0, spanBatchSize)
+		ticker := time.NewTicker(spanFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case span := <-spanQueue:
+				batch = append(batch, span)
+				if len(batch) < spanBatchSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			}
+
+			// send and start a fresh batch either way, failed batches are dropped
+			if err := exportSpans(client, url, serviceName, batch); err != nil {
+				fmt.Println("span export failed:", err)
+			}
+			batch = batch[:0]
+		}
+	}()
+}
+
+
+// exportSpans POSTs one batch as OTLP/JSON
+func exportSpans(client *http.Client, url, serviceName string, batch []*Span) error {
+
+	body, err := json.Marshal(otlpPayload(serviceName, batch))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+
+// otlpPayload builds an ExportTraceServiceRequest in the OTLP/JSON mapping
+func otlpPayload(serviceName string, batch []*Span) map[string]any {
+
+	spans := make([]map[string]any, 0, len(batch))
+	for _, s := range batch {
+
+		attrs := make([]map[string]any, 0, len(s.attrs))
+		for k, v := range s.attrs {
+			attrs = append(attrs, map[string]any{"key": k, "value": map[string]any{"stringValue": v}})
+		}
+
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        attrs,
+			"status":            map[string]any{"code": s.status},
+		}
+		if s.parentID != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		spans = append(spans, span)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []any{map[string]any{"key": "service.name", "value": map[string]any{"stringValue": serviceName}}},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "todo-api"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
@@ -0,0 +1,221 @@
+// Package server mounts the todo API in another Go program, instead of running the todo-server binary:
+//
+//	todos := server.NewStore()
+//	api, err := server.New(todos, server.WithArgs("-max-todos", "5000"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	mux := http.NewServeMux()
+//	mux.Handle("/api/", http.StripPrefix("/api", api))
+//	http.ListenAndServe(":8080", mux)
+//
+// The program keeps its own listeners, TLS and shutdown. The API's config is per process (the
+// todo-server flags), and so is its store: New gives out one handler at a time, and another only
+// once that one is Released.
+package server
+
+import (
+	"context"     // for the actor of store calls
+	"errors"      // for ErrInUse
+	"fmt"         // for config errors
+	"net/http"    // for the handler
+	"sync"        // for guarding the live handler
+	"sync/atomic" // for a released handler
+	"time"        // for manual clocks
+
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/api"   // for the handlers and middleware
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/model" // for todos, events and errors
+	"github.com/jiyagarg03/TO-DO-LIST-no-gin--GoP1/internal/store" // for the todo store
+)
+
+// Store is the in-memory todo store the API serves; programs can also call it directly
+// (Create, Get, Find, Events, ...), and API clients see those changes like their own
+type Store = store.Store
+
+// Todo, Event, Filter and Patch (for Store.Merge) are the store's types
+type (
+	Todo   = model.Todo
+	Event  = model.Event
+	Filter = store.Filter
+	Patch  = model.Patch
+)
+
+// Revision is a version of a todo the store kept, see Store.Revisions
+type Revision = model.Revision
+
+// errors the store's methods return (wrapped, check them with errors.Is), and the
+// *ValidationError behind ErrValidation that lists the invalid fields
+var (
+	ErrNotFound    = model.ErrNotFound
+	ErrConflict    = model.ErrConflict
+	ErrValidation  = model.ErrValidation
+	ErrRejected    = model.ErrRejected
+	ErrUnavailable = model.ErrUnavailable
+)
+
+// ValidationError lists the invalid fields of a rejected todo
+type ValidationError = model.ValidationError
+
+// MergeConflict is the ErrConflict of Store.Merge, with both versions of the todo
+type MergeConflict = model.MergeConflict
+
+// lifecycle hooks, registered on the store (Store.BeforeCreate, AfterComplete, OnDelete, GateWrites) to add
+// business rules: they run for every API and every caller of the store
+type (
+	BeforeCreateHook  = store.BeforeCreateHook
+	AfterCompleteHook = store.AfterCompleteHook
+	OnDeleteHook      = store.OnDeleteHook
+	WriteGate         = store.WriteGate
+)
+
+// Clock and IDGenerator replace time.Now and the 1, 2, 3, ... todo ids, see NewStore
+type (
+	Clock       = store.Clock
+	IDGenerator = store.IDGenerator
+	ManualClock = store.ManualClock
+	StoreOption = store.Option
+)
+
+// ErrInUse is New's error while the handler it returned before hasn't been Released
+var ErrInUse = errors.New("server: the API is already serving a store in this process, Release its handler first")
+
+// Option configures New
+type Option func(*config)
+
+// config is what the options set
+type config struct {
+	args []string
+	jobs bool
+}
+
+// handler is what New returns, serving until it's Released
+type handler struct {
+	next     http.Handler
+	released atomic.Bool
+}
+
+// the handler New returned last, nil once it's Released
+var live *handler
+var liveMu sync.Mutex
+
+
+// NewStore returns an empty store keeping store.DefaultChangeLogSize events for catch-up
+func NewStore(opts ...StoreOption) *Store {
+	return store.New(store.DefaultChangeLogSize, opts...)
+}
+
+
+// WithClock makes the store, and the handlers comparing against its times (Last-Modified,
+// overdue and due-today checks), take the time from c; NewManualClock makes one for tests
+func WithClock(c Clock) StoreOption {
+	return store.WithClock(c)
+}
+
+
+// NewManualClock returns a clock that shows t until it's Set or Advanced
+func NewManualClock(t time.Time) *ManualClock {
+	return store.NewManualClock(t)
+}
+
+
+// WithHistorySize keeps the last n versions of each todo for Store.Revisions (default 100)
+func WithHistorySize(n int) StoreOption {
+	return store.WithHistorySize(n)
+}
+
+
+// WithActor returns a context whose store calls are recorded in the todos' history as made by
+// actor; calls through the API are recorded with the client's Todo-Actor header or address
+func WithActor(ctx context.Context, actor string) context.Context {
+	return store.WithActor(ctx, actor)
+}
+
+
+// WithIDGenerator makes the store take todo ids from g (unique ones, from any goroutine)
+func WithIDGenerator(g IDGenerator) StoreOption {
+	return store.WithIDGenerator(g)
+}
+
+
+// WithArgs sets config as todo-server command line flags, e.g. WithArgs("-compress", "gzip");
+// flags it doesn't mention keep their defaults. the listener flags (-listen, -admin-addr, ...) have
+// no effect here, the program serves the handler itself
+func WithArgs(args ...string) Option {
+	return func(c *config) {
+		c.args = append(c.args, args...)
+	}
+}
+
+
+// WithBackgroundJobs also starts what the binary runs besides the handlers, each if its flags
+// configure it: span export, webhook and message bus publishers, the evictor, digests and
+// reminders. they run until the process exits, so only the first New asking for them starts
+// them, over its store; a New after Release doesn't start them twice
+func WithBackgroundJobs() Option {
+	return func(c *config) {
+		c.jobs = true
+	}
+}
+
+
+// New returns the public todo API over st, or the config's error; ErrInUse while an earlier
+// handler hasn't been Released, as the API's config and store are per process
+func New(st *Store, opts ...Option) (http.Handler, error) {
+
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	if live != nil {
+		return nil, ErrInUse
+	}
+
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if err := api.Configure(c.args); err != nil {
+		return nil, fmt.Errorf("server: invalid config: %w", err)
+	}
+
+	live = &handler{next: api.Handler(st)}
+	if c.jobs {
+		api.StartJobs()
+	}
+	return live, nil
+}
+
+
+// Release stops h, a handler New returned, from serving (it answers 503 from then on), so
+// New can be called again, e.g. by the next test; background jobs go on until the process exits
+// (see WithBackgroundJobs)
+func Release(h http.Handler) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	if h, ok := h.(*handler); ok && h == live {
+		h.released.Store(true)
+		live = nil
+	}
+}
+
+
+// ServeHTTP serves the API until the handler is released
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.released.Load() {
+		http.Error(w, "todo API handler released", http.StatusServiceUnavailable)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+
+// Admin returns the admin endpoints (maintenance, cache, webhooks, ...), for mounting on an
+// internal port; -admin-token protects them when set
+func Admin() http.Handler {
+	return api.AdminHandler()
+}
+
+
+// Reject vetoes an operation from a hook; the API answers 422 with reason as the error
+func Reject(reason string) error {
+	return model.Reject(reason)
+}